@@ -11,9 +11,11 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/nats-io/nats.go"
 	"github.com/spf13/cobra"
 
 	"github.com/hikmaai-io/hikmaai-argus/internal/api"
@@ -24,6 +26,7 @@ import (
 	"github.com/hikmaai-io/hikmaai-argus/internal/feeds"
 	"github.com/hikmaai-io/hikmaai-argus/internal/gcs"
 	"github.com/hikmaai-io/hikmaai-argus/internal/observability"
+	"github.com/hikmaai-io/hikmaai-argus/internal/queue"
 	internalredis "github.com/hikmaai-io/hikmaai-argus/internal/redis"
 	"github.com/hikmaai-io/hikmaai-argus/internal/scanner"
 	"github.com/hikmaai-io/hikmaai-argus/internal/trivy"
@@ -37,10 +40,17 @@ func newDaemonCmd() *cobra.Command {
 		clamDBDir          string
 		natsURL            string
 		httpAddr           string
+		apiKeys            []string
+		scanCacheMaxEntries int
+		mergePolicy        string
+		uploadAllowedTypes []string
+		uploadDeniedTypes  []string
 		trivyServerURL     string
 		trivyCacheTTL       time.Duration
+		trivyCacheMaxEntries int
 		trivyCacheDir       string
 		trivySkipDBUpdate   bool
+		trivyJobRetention   time.Duration
 		// Argus worker flags.
 		argusWorkerEnabled bool
 		redisAddr          string
@@ -48,6 +58,7 @@ func newDaemonCmd() *cobra.Command {
 		redisPrefix        string
 		gcsBucket          string
 		gcsDownloadDir     string
+		gcsReportPrefix    string
 		// DB update service flags.
 		dbUpdateEnabled         bool
 		dbUpdateClamAVInterval  time.Duration
@@ -65,34 +76,64 @@ In foreground mode (default), the daemon runs in the current terminal.
 Use --background to daemonize the process.
 
 Use --db-update to enable periodic database updates for ClamAV, Trivy,
-and signature feeds with retry logic and scan coordination.`,
+and signature feeds with retry logic and scan coordination.
+
+Send SIGHUP to reload the log level and format, DB update intervals, and
+feed sources from the config file without restarting.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if background {
 				return fmt.Errorf("background mode not yet implemented")
 			}
-			return runDaemon(cmd.Context(), daemonConfig{
+			if len(apiKeys) == 0 {
+				apiKeys = apiKeysFromEnv()
+			}
+			policy, err := engine.ParseMergePolicy(mergePolicy)
+			if err != nil {
+				return err
+			}
+			configFile := cfgFile
+			if configFile == "" {
+				configFile = config.DefaultConfigPath()
+			}
+			fileCfg, err := config.LoadConfig(configFile)
+			if err != nil {
+				return err
+			}
+			dc := daemonConfig{
+				ConfigFile:     configFile,
+				File:           fileCfg,
 				DataDir:        dataDir,
 				ClamDBDir:      clamDBDir,
 				NatsURL:        natsURL,
 				HTTPAddr:       httpAddr,
+				APIKeys:        apiKeys,
+				ScanCacheMaxEntries: scanCacheMaxEntries,
+				MergePolicy:         policy,
+				UploadAllowedTypes:  uploadAllowedTypes,
+				UploadDeniedTypes:   uploadDeniedTypes,
 				LogLevel:       logLevel,
 				LogFormat:      logFormat,
 				TrivyServerURL: trivyServerURL,
 				TrivyCacheTTL:       trivyCacheTTL,
+				TrivyCacheMaxEntries: trivyCacheMaxEntries,
 				TrivyCacheDir:       trivyCacheDir,
 				TrivySkipDBUpdate:   trivySkipDBUpdate,
+				TrivyJobRetention:   trivyJobRetention,
 				ArgusWorkerEnabled:  argusWorkerEnabled,
 				RedisAddr:           redisAddr,
 				RedisPassword:       redisPassword,
 				RedisPrefix:         redisPrefix,
 				GCSBucket:           gcsBucket,
 				GCSDownloadDir:      gcsDownloadDir,
+				GCSReportPrefix:     gcsReportPrefix,
 				// DB update service config.
 				DBUpdateEnabled:            dbUpdateEnabled,
 				DBUpdateClamAVInterval:     dbUpdateClamAVInterval,
 				DBUpdateTrivyInterval:      dbUpdateTrivyInterval,
 				DBUpdateSignaturesInterval: dbUpdateSignaturesInterval,
-			})
+			}
+			dc.applyFile(fileCfg, cmd.Flags().Changed)
+			return runDaemon(cmd.Context(), dc)
 		},
 	}
 
@@ -101,10 +142,17 @@ and signature feeds with retry logic and scan coordination.`,
 	cmd.Flags().StringVar(&clamDBDir, "clamdb-dir", config.DefaultClamDBDir(), "directory for ClamAV databases (CVD files)")
 	cmd.Flags().StringVar(&natsURL, "nats-url", "nats://localhost:4222", "NATS server URL")
 	cmd.Flags().StringVar(&httpAddr, "http-addr", ":8080", "HTTP address for health/metrics")
+	cmd.Flags().StringSliceVar(&apiKeys, "api-keys", nil, "API keys required by the HTTP API (defaults to $"+apiKeysEnv+", then http.api_keys)")
+	cmd.Flags().IntVar(&scanCacheMaxEntries, "scan-cache-max-entries", 100000, "maximum cached file scan results, least recently used evicted first (0 = unlimited)")
+	cmd.Flags().StringVar(&mergePolicy, "merge-policy", "overwrite", "how signatures sharing a hash are combined (overwrite, merge, keep-first)")
+	cmd.Flags().StringSliceVar(&uploadAllowedTypes, "upload-allowed-types", nil, "MIME types accepted by file uploads, e.g. application/zip,text/* (default: any)")
+	cmd.Flags().StringSliceVar(&uploadDeniedTypes, "upload-denied-types", nil, "MIME types rejected by file uploads; takes precedence over --upload-allowed-types")
 	cmd.Flags().StringVar(&trivyServerURL, "trivy-server", "", "Trivy server URL (e.g., http://trivy:4954)")
 	cmd.Flags().DurationVar(&trivyCacheTTL, "trivy-cache-ttl", 1*time.Hour, "Trivy cache TTL")
+	cmd.Flags().IntVar(&trivyCacheMaxEntries, "trivy-cache-max-entries", 100000, "maximum cached Trivy package results (0 = unlimited)")
 	cmd.Flags().StringVar(&trivyCacheDir, "trivy-cache-dir", "/app/data/trivy-cache", "Trivy cache directory for vulnerability database")
 	cmd.Flags().BoolVar(&trivySkipDBUpdate, "trivy-skip-db-update", false, "Skip Trivy database updates (use cached)")
+	cmd.Flags().DurationVar(&trivyJobRetention, "trivy-job-retention", 24*time.Hour, "how long finished dependency scan jobs are kept (0 = forever)")
 
 	// Argus worker flags.
 	cmd.Flags().BoolVar(&argusWorkerEnabled, "argus-worker", false, "enable Argus worker for Redis integration")
@@ -113,6 +161,7 @@ and signature feeds with retry logic and scan coordination.`,
 	cmd.Flags().StringVar(&redisPrefix, "redis-prefix", "argus:", "Redis key prefix")
 	cmd.Flags().StringVar(&gcsBucket, "gcs-bucket", "", "GCS bucket for skill downloads")
 	cmd.Flags().StringVar(&gcsDownloadDir, "gcs-download-dir", "/tmp/argus/downloads", "local directory for GCS downloads")
+	cmd.Flags().StringVar(&gcsReportPrefix, "gcs-report-prefix", "", "GCS object prefix for archiving scan reports as {prefix}/{org}/{job}/report.json (empty = disabled)")
 
 	// DB update service flags.
 	cmd.Flags().BoolVar(&dbUpdateEnabled, "db-update", false, "enable background DB update service")
@@ -123,17 +172,42 @@ and signature feeds with retry logic and scan coordination.`,
 	return cmd
 }
 
+// apiKeysEnv names the environment variable holding comma-separated API keys.
+const apiKeysEnv = "HIKMAAI_ARGUS_API_KEYS"
+
+// apiKeysFromEnv returns the API keys configured in the environment.
+func apiKeysFromEnv() []string {
+	var keys []string
+	for _, key := range strings.Split(os.Getenv(apiKeysEnv), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
 type daemonConfig struct {
+	ConfigFile     string
+	// File is the config file loaded at startup, with environment
+	// overrides applied.
+	File           *config.Config
 	DataDir        string
 	ClamDBDir      string
 	NatsURL        string
 	HTTPAddr       string
+	APIKeys        []string
+	ScanCacheMaxEntries int
+	MergePolicy         engine.MergePolicy
+	UploadAllowedTypes  []string
+	UploadDeniedTypes   []string
 	LogLevel       string
 	LogFormat      string
 	TrivyServerURL string
 	TrivyCacheTTL       time.Duration
+	TrivyCacheMaxEntries int
 	TrivyCacheDir       string
 	TrivySkipDBUpdate   bool
+	TrivyJobRetention   time.Duration
 	// Argus worker settings.
 	ArgusWorkerEnabled bool
 	RedisAddr          string
@@ -141,6 +215,7 @@ type daemonConfig struct {
 	RedisPrefix        string
 	GCSBucket          string
 	GCSDownloadDir     string
+	GCSReportPrefix    string
 	// DB update service settings.
 	DBUpdateEnabled            bool
 	DBUpdateClamAVInterval     time.Duration
@@ -148,9 +223,48 @@ type daemonConfig struct {
 	DBUpdateSignaturesInterval time.Duration
 }
 
+// applyFile fills the settings whose flag was not given from the config
+// file, so values set in the file or through HIKMAAI_ARGUS_* variables take
+// effect. Empty file values keep the flag defaults. changed reports whether
+// a flag was set on the command line.
+func (c *daemonConfig) applyFile(file *config.Config, changed func(name string) bool) {
+	settings := []struct {
+		flag  string
+		field *string
+		value string
+	}{
+		{"data-dir", &c.DataDir, file.DataDir},
+		{"nats-url", &c.NatsURL, file.NATS.URL},
+		{"http-addr", &c.HTTPAddr, file.HTTP.Addr},
+		{"redis-addr", &c.RedisAddr, file.Redis.Addr},
+		{"redis-password", &c.RedisPassword, file.Redis.Password},
+		{"redis-prefix", &c.RedisPrefix, file.Redis.Prefix},
+		{"gcs-bucket", &c.GCSBucket, file.GCS.Bucket},
+		{"gcs-download-dir", &c.GCSDownloadDir, file.GCS.DownloadDir},
+	}
+
+	for _, s := range settings {
+		if !changed(s.flag) && s.value != "" {
+			*s.field = s.value
+		}
+	}
+
+	// Keys from --api-keys or the environment take precedence.
+	if len(c.APIKeys) == 0 {
+		c.APIKeys = file.HTTP.APIKeys
+	}
+}
+
+// trivyJobGCInterval is how often finished dependency scan jobs are pruned.
+const trivyJobGCInterval = 10 * time.Minute
+
 func runDaemon(ctx context.Context, cfg daemonConfig) error {
+	if cfg.File == nil {
+		cfg.File = config.DefaultConfig()
+	}
+
 	// Set up logging.
-	logger := observability.NewLogger(observability.LoggingConfig{
+	logger, logReloader := observability.NewReloadableLogger(observability.LoggingConfig{
 		Level:       cfg.LogLevel,
 		Format:      cfg.LogFormat,
 		ServiceName: "hikmaai-argus",
@@ -165,6 +279,17 @@ func runDaemon(ctx context.Context, cfg daemonConfig) error {
 		slog.String("http_addr", cfg.HTTPAddr),
 	)
 
+	// Export spans when tracing is enabled in the config file.
+	if tracerProvider := startTracing(ctx, cfg.File.Tracing, logger); tracerProvider != nil {
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := tracerProvider.Shutdown(shutdownCtx); err != nil {
+				logger.Warn("tracer shutdown error", slog.String("error", err.Error()))
+			}
+		}()
+	}
+
 	// Ensure data directory exists.
 	if err := os.MkdirAll(cfg.DataDir, 0o755); err != nil {
 		return fmt.Errorf("failed to create data directory: %w", err)
@@ -179,6 +304,10 @@ func runDaemon(ctx context.Context, cfg daemonConfig) error {
 			ExpectedItems:     10_000_000, // 10M signatures.
 			FalsePositiveRate: 0.001,      // 0.1% false positive rate.
 		},
+		// Populate the bloom filter from existing signatures, reusing the
+		// snapshot from the last run when it is current.
+		RebuildBloomOnStart: true,
+		MergePolicy:         cfg.MergePolicy,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create engine: %w", err)
@@ -198,10 +327,11 @@ func runDaemon(ctx context.Context, cfg daemonConfig) error {
 	logger.Info("job store initialized")
 
 	// Create scan cache.
-	scanCache, err := engine.NewScanCache(
-		engine.StoreConfig{Path: filepath.Join(cfg.DataDir, "cache")},
-		24*time.Hour,
-	)
+	scanCache, err := engine.NewScanCacheWithConfig(engine.ScanCacheConfig{
+		Store:      engine.StoreConfig{Path: filepath.Join(cfg.DataDir, "cache")},
+		TTL:        24 * time.Hour,
+		MaxEntries: cfg.ScanCacheMaxEntries,
+	})
 	if err != nil {
 		return fmt.Errorf("creating scan cache: %w", err)
 	}
@@ -215,6 +345,11 @@ func runDaemon(ctx context.Context, cfg daemonConfig) error {
 		DatabaseDir: cfg.ClamDBDir,
 		Timeout:     5 * time.Minute,
 	})
+	// Argus and NATS scans reuse results for content already scanned.
+	clamScanner.ScanCache = scanCache
+
+	// Prometheus metrics served on /metrics.
+	metrics := observability.NewMetrics()
 
 	// Create scan worker.
 	worker := scanner.NewWorker(scanner.WorkerConfig{
@@ -223,7 +358,22 @@ func runDaemon(ctx context.Context, cfg daemonConfig) error {
 		ScanCache:       scanCache,
 		SignatureEngine: eng,
 		Concurrency:     2,
+		Metrics:         metrics,
 	})
+	if err := metrics.RegisterQueueLength(worker.QueueLength); err != nil {
+		return err
+	}
+	if err := metrics.RegisterWorkerStats(func() observability.WorkerStats {
+		s := worker.Stats()
+		return observability.WorkerStats{
+			Processed:   s.Processed,
+			Errors:      s.Errors,
+			Infected:    s.Infected,
+			AvgScanTime: s.AvgScanTime,
+		}
+	}); err != nil {
+		return err
+	}
 
 	// Start worker.
 	workerCtx, workerCancel := context.WithCancel(ctx)
@@ -234,13 +384,41 @@ func runDaemon(ctx context.Context, cfg daemonConfig) error {
 	// Create Trivy scanner if configured.
 	var trivyScanner *trivy.Scanner
 	var trivyCache *trivy.Cache
-	trivyJobStore := api.NewTrivyJobStore()
+	var trivyJobStore api.TrivyJobStorage
+	badgerTrivyJobs, err := api.NewBadgerTrivyJobStore(api.BadgerTrivyJobStoreConfig{
+		Path:      filepath.Join(cfg.DataDir, "trivy-jobs"),
+		Retention: cfg.TrivyJobRetention,
+		Logger:    logger,
+	})
+	if err != nil {
+		logger.Warn("failed to open Trivy job store, keeping jobs in memory",
+			slog.String("error", err.Error()),
+		)
+		memoryTrivyJobs := api.NewTrivyJobStore()
+		if cfg.TrivyJobRetention > 0 {
+			memoryTrivyJobs.StartGC(ctx, cfg.TrivyJobRetention, trivyJobGCInterval)
+		}
+		trivyJobStore = memoryTrivyJobs
+	} else {
+		defer badgerTrivyJobs.Close()
+		if pruned, err := badgerTrivyJobs.Prune(); err != nil {
+			logger.Warn("failed to prune Trivy jobs", slog.String("error", err.Error()))
+		} else if pruned > 0 {
+			logger.Info("pruned expired Trivy jobs", slog.Int("count", pruned))
+		}
+		if cfg.TrivyJobRetention > 0 {
+			badgerTrivyJobs.StartGC(ctx, trivyJobGCInterval)
+		}
+		trivyJobStore = badgerTrivyJobs
+	}
 
 	if cfg.TrivyServerURL != "" {
 		var err error
 		trivyCache, err = trivy.NewCache(trivy.CacheConfig{
-			Path: filepath.Join(cfg.DataDir, "trivy-cache"),
-			TTL:  cfg.TrivyCacheTTL,
+			Path:            filepath.Join(cfg.DataDir, "trivy-cache"),
+			TTL:             cfg.TrivyCacheTTL,
+			MaxEntries:      cfg.TrivyCacheMaxEntries,
+			CleanupInterval: 10 * time.Minute,
 		})
 		if err != nil {
 			logger.Warn("failed to create Trivy cache, continuing without caching",
@@ -261,10 +439,35 @@ func runDaemon(ctx context.Context, cfg daemonConfig) error {
 
 	// Initialize DB update service if enabled (before API handler for health endpoint).
 	var dbUpdateService *dbupdater.DBUpdateService
+	var sigUpdater *dbupdater.SignatureFeedUpdater
 	var dbUpdateProvider api.DBUpdateStatusProvider
 	if cfg.DBUpdateEnabled {
-		dbUpdateService = initDBUpdateService(cfg, eng, logger)
-		dbUpdateProvider = &dbUpdateStatusAdapter{service: dbUpdateService}
+		statusConn, statusSubject := connectStatusPublisher(cfg, logger)
+		if statusConn != nil {
+			defer statusConn.Close()
+		}
+		dbUpdateService, sigUpdater, err = initDBUpdateService(cfg, eng, scanCache, statusConn, statusSubject, logger)
+		if err != nil {
+			return err
+		}
+		statusAdapter := &dbUpdateStatusAdapter{service: dbUpdateService}
+		dbUpdateProvider = statusAdapter
+		if err := metrics.RegisterUpdaterStatus(statusAdapter.UpdaterStatuses); err != nil {
+			return err
+		}
+	}
+
+	// Initialize Argus worker if enabled (before API handler for health endpoint).
+	var argusWorker *argus.Worker
+	var redisHealth api.RedisHealthChecker
+	if cfg.ArgusWorkerEnabled {
+		var err error
+		argusWorker, err = initArgusWorker(workerCtx, cfg, clamScanner, logger)
+		if err != nil {
+			logger.Error("failed to initialize Argus worker", slog.String("error", err.Error()))
+		} else {
+			redisHealth = argusWorker
+		}
 	}
 
 	// Create API handler.
@@ -278,15 +481,26 @@ func runDaemon(ctx context.Context, cfg daemonConfig) error {
 		TrivyScanner:     trivyScanner,
 		TrivyJobStore:    trivyJobStore,
 		DBUpdateProvider: dbUpdateProvider,
+		Redis:            redisHealth,
+		Metrics:          metrics,
+
+		AllowedContentTypes: cfg.UploadAllowedTypes,
+		DeniedContentTypes:  cfg.UploadDeniedTypes,
 	})
 
 	// Start HTTP server.
 	mux := http.NewServeMux()
 	handler.RegisterRoutes(mux)
 
+	var httpHandler http.Handler = mux
+	if len(cfg.APIKeys) > 0 {
+		httpHandler = api.AuthMiddleware(cfg.APIKeys)(httpHandler)
+		logger.Info("HTTP API key authentication enabled", slog.Int("keys", len(cfg.APIKeys)))
+	}
+
 	httpServer := &http.Server{
 		Addr:    cfg.HTTPAddr,
-		Handler: api.LoggingMiddleware(mux),
+		Handler: api.NewLoggingMiddleware(api.LoggingConfig{Logger: logger})(httpHandler),
 	}
 
 	go func() {
@@ -310,25 +524,34 @@ func runDaemon(ctx context.Context, cfg daemonConfig) error {
 	}
 
 	// Start Argus worker if enabled.
-	var argusWorker *argus.Worker
-	if cfg.ArgusWorkerEnabled {
-		var err error
-		argusWorker, err = initArgusWorker(workerCtx, cfg, clamScanner, logger)
-		if err != nil {
-			logger.Error("failed to initialize Argus worker", slog.String("error", err.Error()))
-		} else {
-			if err := argusWorker.Start(workerCtx); err != nil {
-				logger.Error("failed to start Argus worker", slog.String("error", err.Error()))
-			}
+	if argusWorker != nil {
+		if err := argusWorker.Start(workerCtx); err != nil {
+			logger.Error("failed to start Argus worker", slog.String("error", err.Error()))
 		}
 	}
 
-	// TODO: Connect to NATS and start message handler.
+	// Connect to NATS and start the scan request handler.
+	var natsClient *queue.Client
+	if cfg.NatsURL != "" {
+		natsClient, err = startNATSHandler(ctx, cfg, eng, trivyScanner, clamScanner, logger)
+		if err != nil {
+			logger.Warn("NATS scan handler not started, continuing without NATS",
+				slog.String("error", err.Error()),
+			)
+			natsClient = nil
+		}
+	}
 
 	// Wait for shutdown signal.
 	ctx, cancel := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	// Reload log, DB update, and feed settings from the config file on SIGHUP.
+	reloader := newConfigReloader(cfg.ConfigFile, cfg.File, logReloader, logger)
+	reloader.dbUpdates = dbUpdateService
+	reloader.signatures = sigUpdater
+	reloader.watch(ctx)
+
 	logger.Info("daemon ready, waiting for requests")
 	<-ctx.Done()
 
@@ -338,6 +561,12 @@ func runDaemon(ctx context.Context, cfg daemonConfig) error {
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
 
+	if natsClient != nil {
+		if err := natsClient.Drain(shutdownCtx); err != nil {
+			logger.Warn("NATS drain error", slog.String("error", err.Error()))
+		}
+	}
+
 	if err := httpServer.Shutdown(shutdownCtx); err != nil {
 		logger.Warn("HTTP server shutdown error", slog.String("error", err.Error()))
 	}
@@ -361,6 +590,69 @@ func runDaemon(ctx context.Context, cfg daemonConfig) error {
 	return nil
 }
 
+// startTracing installs the global tracer provider when tracing is enabled
+// in the config file. It returns nil when tracing is disabled or cannot be
+// set up; the daemon then runs with the no-op tracer.
+func startTracing(ctx context.Context, cfg config.TracingConfig, logger *slog.Logger) *observability.TracerProvider {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	tp, err := observability.NewTracerProvider(ctx, tracingConfig(cfg))
+	if err != nil {
+		logger.Warn("tracing not started", slog.String("error", err.Error()))
+		return nil
+	}
+
+	logger.Info("tracing enabled",
+		slog.String("endpoint", cfg.Endpoint),
+		slog.Float64("sampling_ratio", cfg.SamplingRatio),
+	)
+	return tp
+}
+
+// tracingConfig maps the config file tracing settings to the tracer setup.
+func tracingConfig(cfg config.TracingConfig) observability.TracingConfig {
+	return observability.TracingConfig{
+		Enabled:       cfg.Enabled,
+		ServiceName:   "hikmaai-argus",
+		Version:       version,
+		Endpoint:      cfg.Endpoint,
+		Insecure:      cfg.Insecure,
+		SamplingRatio: cfg.SamplingRatio,
+	}
+}
+
+// startNATSHandler connects to NATS and subscribes the scan request handler
+// on the default subject and queue group. File requests are limited to the
+// config file's nats.scan_root.
+func startNATSHandler(ctx context.Context, cfg daemonConfig, eng *engine.Engine, trivyScanner *trivy.Scanner, clamScanner *scanner.ClamAVScanner, logger *slog.Logger) (*queue.Client, error) {
+	handler := queue.NewHandlerWithTrivy(eng, trivyScanner)
+	if clamScanner != nil {
+		handler.SetFileScanner(clamScanner)
+	}
+	if err := handler.SetScanRoot(cfg.File.NATS.ScanRoot); err != nil {
+		return nil, fmt.Errorf("invalid nats.scan_root: %w", err)
+	}
+
+	natsCfg := queue.DefaultNATSConfig()
+	natsCfg.URL = cfg.NatsURL
+
+	client, err := queue.NewClient(natsCfg, handler, logger)
+	if err != nil {
+		return nil, fmt.Errorf("creating NATS client: %w", err)
+	}
+	if err := client.Connect(ctx); err != nil {
+		return nil, err
+	}
+	if err := client.Subscribe(ctx); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
 // initArgusWorker initializes the Argus worker for Redis integration.
 func initArgusWorker(ctx context.Context, cfg daemonConfig, clamScanner *scanner.ClamAVScanner, logger *slog.Logger) (*argus.Worker, error) {
 	logger.Info("initializing Argus worker",
@@ -420,17 +712,7 @@ func initArgusWorker(ctx context.Context, cfg daemonConfig, clamScanner *scanner
 
 	// Create worker.
 	worker, err := argus.NewWorker(
-		argus.WorkerConfig{
-			TaskQueue:         "argus_task_queue",
-			ConsumerGroup:     "argus-workers",
-			ConsumerName:      hostname,
-			CompletionPrefix:  "argus_completion",
-			Workers:           2,
-			DefaultTimeout:    15 * time.Minute,
-			MaxRetries:        3,
-			CleanupOnComplete: true,
-			StateTTL:          7 * 24 * time.Hour,
-		},
+		argusWorkerConfig(cfg, hostname),
 		redisClient,
 		gcsClient,
 		runner,
@@ -447,6 +729,38 @@ func initArgusWorker(ctx context.Context, cfg daemonConfig, clamScanner *scanner
 	return worker, nil
 }
 
+// argusWorkerConfig maps the argus_worker section of the config file to
+// the worker settings. consumerName is used unless the file names one; the
+// report prefix and temp directory come from the GCS settings.
+func argusWorkerConfig(cfg daemonConfig, consumerName string) argus.WorkerConfig {
+	w := cfg.File.ArgusWorker
+	if w.ConsumerName != "" {
+		consumerName = w.ConsumerName
+	}
+
+	return argus.WorkerConfig{
+		TaskQueue:          w.TaskQueue,
+		ConsumerGroup:      w.ConsumerGroup,
+		ConsumerName:       consumerName,
+		CompletionPrefix:   w.CompletionPrefix,
+		CancelPrefix:       w.CancelPrefix,
+		Workers:            w.Workers,
+		MaxConcurrentScans: w.MaxConcurrentScans,
+		TempDir:            cfg.GCSDownloadDir,
+		MaxTempDiskUsage:   w.MaxTempDiskUsage,
+		DefaultTimeout:     w.DefaultTimeout,
+		MaxTaskTimeout:     w.MaxTaskTimeout,
+		MaxRetries:         w.MaxRetries,
+		CleanupOnComplete:  w.CleanupOnComplete,
+		StateTTL:           w.StateTTL,
+		ClaimMinIdle:       w.ClaimMinIdle,
+		ClaimInterval:      w.ClaimInterval,
+		DeadLetterStream:   w.DeadLetterStream,
+		ReportPrefix:       cfg.GCSReportPrefix,
+		DrainTimeout:       w.DrainTimeout,
+	}
+}
+
 // clamAVScannerAdapter adapts ClamAVScanner to the argus.ClamAVScanner interface.
 type clamAVScannerAdapter struct {
 	scanner *scanner.ClamAVScanner
@@ -460,39 +774,134 @@ func (a *clamAVScannerAdapter) ScanDirectory(ctx context.Context, path string) (
 	return a.scanner.ScanDir(ctx, path, true)
 }
 
-// initDBUpdateService initializes the database update service.
-func initDBUpdateService(cfg daemonConfig, eng *engine.Engine, logger *slog.Logger) *dbupdater.DBUpdateService {
-	// Create the DB update service.
-	service := dbupdater.NewDBUpdateService(dbupdater.DBUpdateServiceConfig{
+func (a *clamAVScannerAdapter) ScanDirectoryWithProgress(ctx context.Context, path string, progress func(scanned, total int)) ([]*types.ScanResult, error) {
+	return a.scanner.ScanDirWithProgress(ctx, path, true, progress)
+}
+
+// connectStatusPublisher connects to NATS for DB update status messages
+// when db_update.status_publish is enabled in the config file. It returns a
+// nil connection when publishing is disabled or NATS is unreachable.
+func connectStatusPublisher(cfg daemonConfig, logger *slog.Logger) (*nats.Conn, string) {
+	publish := cfg.File.DBUpdate.StatusPublish
+	if !publish.Enabled || cfg.NatsURL == "" {
+		return nil, ""
+	}
+
+	conn, err := nats.Connect(cfg.NatsURL,
+		nats.Name("hikmaai-argus-dbupdate"),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		logger.Warn("DB update status publishing disabled, NATS unreachable",
+			slog.String("error", err.Error()),
+		)
+		return nil, ""
+	}
+
+	subject := publish.Subject
+	logger.Info("publishing DB update status", slog.String("subject", subject))
+	return conn, subject
+}
+
+// initDBUpdateService initializes the database update service. Update
+// results are published to statusSubject when statusConn is non-nil.
+// It also returns the signature feed updater, whose feeds can be changed on reload.
+// Downloads use the proxy and TLS settings of the config file's feeds section.
+// New ClamAV databases clear scanCache, whose results came from the old ones.
+// With db_update.clamav.verify_only set, the ClamAV updater only checks the
+// installed databases and never downloads.
+func initDBUpdateService(cfg daemonConfig, eng *engine.Engine, scanCache *engine.ScanCache, statusConn *nats.Conn, statusSubject string, logger *slog.Logger) (*dbupdater.DBUpdateService, *dbupdater.SignatureFeedUpdater, error) {
+	network := feedDownloaderConfig(cfg.File.Feeds)
+
+	serviceCfg := dbupdater.DBUpdateServiceConfig{
 		Logger:           logger,
 		RunInitialUpdate: true,
-	})
+		StateDir:         cfg.DataDir,
+	}
+	if statusConn != nil {
+		serviceCfg.Publisher = statusConn
+		serviceCfg.StatusSubject = statusSubject
+		serviceCfg.NodeName, _ = os.Hostname()
+	}
+
+	// Create the DB update service.
+	service := dbupdater.NewDBUpdateService(serviceCfg)
 
 	// Register ClamAV updater.
+	verifyOnly := cfg.File.DBUpdate.ClamAV.VerifyOnly
 	clamUpdater := dbupdater.NewClamAVUpdater(dbupdater.ClamAVUpdaterConfig{
 		DatabaseDir: cfg.ClamDBDir,
+		VerifyOnly:  verifyOnly,
+		OnUpdated: func(ctx context.Context) {
+			if err := scanCache.Clear(ctx); err != nil {
+				logger.Warn("failed to clear scan cache after ClamAV update", slog.String("error", err.Error()))
+			}
+		},
+	})
+	if err := clamUpdater.SetDownloaderConfig(network); err != nil {
+		return nil, nil, fmt.Errorf("configuring ClamAV downloads: %w", err)
+	}
+	// Only download when the CVD headers report a newer version. A
+	// verify-only updater skips the check, which would query the mirrors.
+	service.RegisterUpdaterWithOptions(clamUpdater, dbupdater.UpdaterOptions{
+		Interval:          cfg.DBUpdateClamAVInterval,
+		CheckBeforeUpdate: !verifyOnly,
 	})
-	service.RegisterUpdater(clamUpdater, cfg.DBUpdateClamAVInterval)
 
 	// Register Trivy updater.
 	trivyUpdater := dbupdater.NewTrivyUpdater(dbupdater.TrivyUpdaterConfig{
 		Binary:   "trivy",
 		CacheDir: cfg.TrivyCacheDir,
 	})
-	service.RegisterUpdater(trivyUpdater, cfg.DBUpdateTrivyInterval)
+	// Only download once the DB metadata's NextUpdate has passed.
+	service.RegisterUpdaterWithOptions(trivyUpdater, dbupdater.UpdaterOptions{
+		Interval:          cfg.DBUpdateTrivyInterval,
+		CheckBeforeUpdate: true,
+	})
 
 	// Register signature feed updater for BadgerDB.
 	sigUpdater := dbupdater.NewSignatureFeedUpdater(dbupdater.SignatureFeedUpdaterConfig{
-		Engine: &signatureEngineAdapter{engine: eng},
+		Engine:      &signatureEngineAdapter{engine: eng},
+		KeepSources: cfg.MergePolicy == engine.MergeCombine,
 	})
 
 	// Register signature feeds.
-	sigUpdater.RegisterFeed(&signatureFeedAdapter{feed: feeds.NewMalwareBazaarFeed()})
-	sigUpdater.RegisterFeed(&signatureFeedAdapter{feed: feeds.NewThreatFoxFeed()})
+	// Remember ETag/Last-Modified so unchanged exports aren't re-downloaded.
+	var validators feeds.ValidatorCache
+	if cache, err := feeds.NewFileValidatorCache(filepath.Join(cfg.DataDir, "feed-validators.json")); err != nil {
+		logger.Warn("feed validator cache unavailable, using in-memory cache", slog.String("error", err.Error()))
+		validators = feeds.NewMemoryValidatorCache()
+	} else {
+		validators = cache
+	}
+
+	malwareBazaar := feeds.NewMalwareBazaarFeed()
+	malwareBazaar.SetValidatorCache(validators)
+	// The full export is large; resume interrupted downloads.
+	malwareBazaar.SetStagingDir(filepath.Join(cfg.DataDir, "feed-staging"))
+	threatFox := feeds.NewThreatFoxFeed()
+	threatFox.SetValidatorCache(validators)
+	for _, feed := range []interface {
+		SetDownloaderConfig(cfg feeds.DownloaderConfig) error
+	}{malwareBazaar, threatFox} {
+		if err := feed.SetDownloaderConfig(network); err != nil {
+			return nil, nil, fmt.Errorf("configuring feed downloads: %w", err)
+		}
+	}
+
+	// MalwareBazaar streams its export, so register it directly.
+	sigUpdater.RegisterFeed(eicarFeed{})
+	sigUpdater.RegisterFeed(malwareBazaar)
+	sigUpdater.RegisterFeed(&signatureFeedAdapter{feed: threatFox})
+
+	// feeds.sources selects among the registered feeds; empty enables all.
+	if err := sigUpdater.SetEnabledFeeds(cfg.File.Feeds.Sources); err != nil {
+		return nil, nil, fmt.Errorf("invalid feeds.sources: %w", err)
+	}
 
 	service.RegisterUpdater(sigUpdater, cfg.DBUpdateSignaturesInterval)
 
-	return service
+	return service, sigUpdater, nil
 }
 
 // signatureEngineAdapter adapts engine.Engine to dbupdater.SignatureEngine.
@@ -512,6 +921,17 @@ func (a *signatureEngineAdapter) Count() int64 {
 	return stats.SignatureCount
 }
 
+// eicarFeed serves the built-in EICAR test signatures as a signature feed.
+type eicarFeed struct{}
+
+func (eicarFeed) Name() string {
+	return "eicar"
+}
+
+func (eicarFeed) Fetch(context.Context) ([]*types.Signature, error) {
+	return feeds.EICARSignatures(), nil
+}
+
 // signatureFeedAdapter adapts existing feeds to dbupdater.SignatureFeed interface.
 type signatureFeedAdapter struct {
 	feed interface {
@@ -528,6 +948,14 @@ func (a *signatureFeedAdapter) Fetch(ctx context.Context) ([]*types.Signature, e
 	return a.feed.Fetch(ctx)
 }
 
+// CommitValidators forwards to feeds that download conditionally.
+func (a *signatureFeedAdapter) CommitValidators() error {
+	if vc, ok := a.feed.(interface{ CommitValidators() error }); ok {
+		return vc.CommitValidators()
+	}
+	return nil
+}
+
 // dbUpdateStatusAdapter adapts dbupdater.DBUpdateService to api.DBUpdateStatusProvider.
 type dbUpdateStatusAdapter struct {
 	service *dbupdater.DBUpdateService
@@ -559,3 +987,19 @@ func (a *dbUpdateStatusAdapter) GetStatus() map[string]*api.DBUpdateStatus {
 	}
 	return result
 }
+
+// UpdaterStatuses reports updater state for the Prometheus DB updater gauges.
+func (a *dbUpdateStatusAdapter) UpdaterStatuses() []observability.UpdaterStatus {
+	statuses := a.service.GetStatus()
+	result := make([]observability.UpdaterStatus, 0, len(statuses))
+
+	for _, s := range statuses {
+		result = append(result, observability.UpdaterStatus{
+			Name:       s.Name,
+			Ready:      s.Ready,
+			Failed:     s.Status == dbupdater.StatusFailed,
+			LastUpdate: s.LastUpdate,
+		})
+	}
+	return result
+}
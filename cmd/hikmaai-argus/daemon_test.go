@@ -4,6 +4,9 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
@@ -137,3 +140,166 @@ func TestNewDaemonCmd_TrivyFlags(t *testing.T) {
 		t.Errorf("trivy-skip-db-update default = %q, want %q", skipUpdateFlag.DefValue, "false")
 	}
 }
+
+func TestDaemonConfig_ApplyFile(t *testing.T) {
+	t.Parallel()
+
+	file := config.DefaultConfig()
+	file.DataDir = "/srv/argus"
+	file.NATS.URL = "nats://nats.internal:4222"
+	file.Redis.Password = "secret"
+	file.HTTP.APIKeys = []string{"file-key"}
+
+	tests := []struct {
+		name    string
+		changed map[string]bool
+		want    daemonConfig
+	}{
+		{
+			name: "file fills unset flags",
+			want: daemonConfig{
+				DataDir:        "/srv/argus",
+				NatsURL:        "nats://nats.internal:4222",
+				HTTPAddr:       ":8080",
+				RedisAddr:      "localhost:6379",
+				RedisPassword:  "secret",
+				RedisPrefix:    "argus:",
+				GCSDownloadDir: "/tmp/argus/downloads",
+			},
+		},
+		{
+			name:    "flags win",
+			changed: map[string]bool{"data-dir": true, "nats-url": true},
+			want: daemonConfig{
+				DataDir:        "/flag/data",
+				NatsURL:        "nats://flag:4222",
+				HTTPAddr:       ":8080",
+				RedisAddr:      "localhost:6379",
+				RedisPassword:  "secret",
+				RedisPrefix:    "argus:",
+				GCSDownloadDir: "/tmp/argus/downloads",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Flag values; HTTPAddr keeps its default because the file's is empty.
+			got := daemonConfig{
+				DataDir:        "/flag/data",
+				NatsURL:        "nats://flag:4222",
+				HTTPAddr:       ":8080",
+				RedisAddr:      "localhost:6379",
+				RedisPrefix:    "argus:",
+				GCSDownloadDir: "/tmp/argus/downloads",
+			}
+			got.applyFile(file, func(name string) bool { return tt.changed[name] })
+
+			if got.DataDir != tt.want.DataDir || got.NatsURL != tt.want.NatsURL || got.HTTPAddr != tt.want.HTTPAddr ||
+				got.RedisAddr != tt.want.RedisAddr || got.RedisPassword != tt.want.RedisPassword ||
+				got.RedisPrefix != tt.want.RedisPrefix || got.GCSDownloadDir != tt.want.GCSDownloadDir {
+				t.Errorf("applyFile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDaemonConfig_ApplyFile_APIKeys(t *testing.T) {
+	t.Parallel()
+
+	file := config.DefaultConfig()
+	file.HTTP.APIKeys = []string{"file-key"}
+
+	tests := []struct {
+		name string
+		keys []string
+		want []string
+	}{
+		{name: "file keys as fallback", want: []string{"file-key"}},
+		{name: "flag or env keys win", keys: []string{"flag-key"}, want: []string{"flag-key"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := daemonConfig{APIKeys: tt.keys}
+			cfg.applyFile(file, func(string) bool { return false })
+
+			if !slices.Equal(cfg.APIKeys, tt.want) {
+				t.Errorf("APIKeys = %v, want %v", cfg.APIKeys, tt.want)
+			}
+		})
+	}
+}
+
+func TestArgusWorkerConfig_ScanLimitFollowsWorkers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		yaml    string
+		wantMax int
+	}{
+		{name: "unset follows workers", yaml: "argus_worker:\n  workers: 6\n", wantMax: 6},
+		{name: "explicit limit", yaml: "argus_worker:\n  workers: 6\n  max_concurrent_scans: 3\n", wantMax: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			file, err := config.LoadConfig(path)
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+
+			got := argusWorkerConfig(daemonConfig{File: file}, "node-1")
+			if err := got.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if got.MaxConcurrentScans != tt.wantMax {
+				t.Errorf("MaxConcurrentScans = %d, want %d", got.MaxConcurrentScans, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestArgusWorkerConfig(t *testing.T) {
+	t.Parallel()
+
+	file := config.DefaultConfig()
+	file.ArgusWorker.MaxConcurrentScans = 4
+	file.ArgusWorker.MaxTempDiskUsage = 0.9
+	file.ArgusWorker.DrainTimeout = time.Minute
+
+	got := argusWorkerConfig(daemonConfig{
+		File:            file,
+		GCSDownloadDir:  "/tmp/downloads",
+		GCSReportPrefix: "reports",
+	}, "node-1")
+
+	if got.MaxConcurrentScans != 4 || got.MaxTempDiskUsage != 0.9 || got.DrainTimeout != time.Minute {
+		t.Errorf("limits = (%d, %v, %v), want (4, 0.9, 1m)", got.MaxConcurrentScans, got.MaxTempDiskUsage, got.DrainTimeout)
+	}
+	if got.ConsumerName != "node-1" {
+		t.Errorf("ConsumerName = %q, want node-1", got.ConsumerName)
+	}
+	if got.TempDir != "/tmp/downloads" || got.ReportPrefix != "reports" {
+		t.Errorf("TempDir, ReportPrefix = %q, %q", got.TempDir, got.ReportPrefix)
+	}
+	if got.TaskQueue != file.ArgusWorker.TaskQueue || got.ClaimMinIdle != file.ArgusWorker.ClaimMinIdle {
+		t.Errorf("TaskQueue, ClaimMinIdle = %q, %v; want config defaults", got.TaskQueue, got.ClaimMinIdle)
+	}
+
+	file.ArgusWorker.ConsumerName = "configured"
+	if got := argusWorkerConfig(daemonConfig{File: file}, "node-1"); got.ConsumerName != "configured" {
+		t.Errorf("ConsumerName = %q, want the configured name", got.ConsumerName)
+	}
+}
@@ -0,0 +1,182 @@
+// ABOUTME: Severity threshold checks that turn scan findings into process exit codes
+// ABOUTME: Shared by trivy scan and scan --with-file so CI pipelines can fail builds
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/trivy"
+	"github.com/hikmaai-io/hikmaai-argus/internal/types"
+)
+
+// failOnSecrets is the --fail-on value that fails on any detected secret.
+const failOnSecrets = "secrets"
+
+// exitCodeError is returned when scan findings meet the --fail-on
+// threshold or files could not be scanned. main exits with Code instead of
+// the default 1.
+type exitCodeError struct {
+	Code   int
+	Reason string
+}
+
+func (e *exitCodeError) Error() string {
+	return "scan failed policy: " + e.Reason
+}
+
+// failPolicy decides whether scan findings fail the command.
+type failPolicy struct {
+	// exitCode is the process exit code on failure; 0 disables the policy.
+	exitCode int
+
+	// minSeverity is the lowest vulnerability or malware severity that
+	// fails; nil means severities are not checked.
+	minSeverity *types.Severity
+
+	// secrets fails on any detected secret.
+	secrets bool
+}
+
+// newFailPolicy builds a policy from the --fail-on and --exit-code flags.
+// failOn is a comma-separated list of one severity and/or "secrets". A
+// threshold without an exit code exits with 1; an exit code without a
+// threshold fails on any finding.
+func newFailPolicy(failOn string, exitCode int) (failPolicy, error) {
+	if exitCode < 0 || exitCode > 125 {
+		return failPolicy{}, fmt.Errorf("invalid --exit-code %d; expected 0-125", exitCode)
+	}
+
+	var p failPolicy
+	for _, part := range strings.Split(failOn, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		if part == failOnSecrets {
+			p.secrets = true
+			continue
+		}
+
+		sev, ok := types.ParseSeverity(part)
+		if !ok {
+			return failPolicy{}, fmt.Errorf("invalid --fail-on %q; expected low, medium, high, critical, or secrets", part)
+		}
+		if p.minSeverity != nil {
+			return failPolicy{}, fmt.Errorf("--fail-on accepts a single severity")
+		}
+		p.minSeverity = &sev
+	}
+
+	switch {
+	case p.minSeverity == nil && !p.secrets && exitCode != 0:
+		// Any finding fails.
+		anySeverity := types.SeverityUnknown
+		p.minSeverity = &anySeverity
+		p.secrets = true
+	case (p.minSeverity != nil || p.secrets) && exitCode == 0:
+		exitCode = 1
+	}
+	p.exitCode = exitCode
+
+	return p, nil
+}
+
+// enabled reports whether findings can fail the command.
+func (p failPolicy) enabled() bool {
+	return p.exitCode != 0
+}
+
+// check returns an *exitCodeError if the ClamAV or Trivy findings meet the
+// policy. Infected files whose severity is unknown meet any threshold, and
+// files ClamAV could not scan always fail, since they may hide a finding.
+func (p failPolicy) check(clamResults []*types.ScanResult, trivyResult *trivy.ScanResult) error {
+	if !p.enabled() {
+		return nil
+	}
+
+	var reasons []string
+
+	unscanned := 0
+	for _, r := range clamResults {
+		if r.Status == types.ScanStatusError {
+			unscanned++
+		}
+	}
+	if unscanned > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d %s not scanned", unscanned, plural(unscanned, "file", "files")))
+	}
+
+	if p.minSeverity != nil {
+		infected := 0
+		for _, r := range clamResults {
+			if r.Status == types.ScanStatusInfected && (r.Severity == types.SeverityUnknown || r.Severity >= *p.minSeverity) {
+				infected++
+			}
+		}
+		if infected > 0 {
+			reasons = append(reasons, fmt.Sprintf("%d infected %s", infected, plural(infected, "file", "files")))
+		}
+
+		if trivyResult != nil {
+			vulns := 0
+			for _, v := range trivyResult.Vulnerabilities {
+				sev, _ := types.ParseSeverity(v.Severity)
+				if sev >= *p.minSeverity {
+					vulns++
+				}
+			}
+			if vulns > 0 {
+				reasons = append(reasons, fmt.Sprintf("%d %s", vulns, plural(vulns, "vulnerability", "vulnerabilities")))
+			}
+		}
+	}
+
+	if p.secrets && trivyResult != nil && len(trivyResult.Secrets) > 0 {
+		n := len(trivyResult.Secrets)
+		reasons = append(reasons, fmt.Sprintf("%d %s", n, plural(n, "secret", "secrets")))
+	}
+
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	reason := strings.Join(reasons, ", ")
+	if p.minSeverity != nil && *p.minSeverity != types.SeverityUnknown {
+		reason += " (fail-on " + p.minSeverity.String() + ")"
+	}
+
+	return &exitCodeError{Code: p.exitCode, Reason: reason}
+}
+
+// dependencySeverities returns the Trivy severities a dependency scan
+// reports: CRITICAL and HIGH, widened down to the policy's threshold so
+// that check sees every vulnerability that can fail the scan.
+func (p failPolicy) dependencySeverities() []string {
+	severities := []string{trivy.SeverityCritical, trivy.SeverityHigh}
+	if p.minSeverity == nil {
+		return severities
+	}
+
+	for _, sev := range []struct {
+		name  string
+		level types.Severity
+	}{
+		{trivy.SeverityMedium, types.SeverityMedium},
+		{trivy.SeverityLow, types.SeverityLow},
+		{trivy.SeverityUnknown, types.SeverityUnknown},
+	} {
+		if sev.level >= *p.minSeverity {
+			severities = append(severities, sev.name)
+		}
+	}
+	return severities
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
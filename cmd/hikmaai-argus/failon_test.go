@@ -0,0 +1,253 @@
+// ABOUTME: Tests for --fail-on and --exit-code threshold handling
+// ABOUTME: Covers flag parsing, threshold checks, and the trivy and ClamAV scan paths
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/config"
+	"github.com/hikmaai-io/hikmaai-argus/internal/trivy"
+	"github.com/hikmaai-io/hikmaai-argus/internal/types"
+)
+
+func TestNewFailPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		failOn       string
+		exitCode     int
+		wantCode     int
+		wantSeverity string
+		wantSecrets  bool
+		wantErr      bool
+	}{
+		{name: "disabled"},
+		{name: "severity defaults to exit 1", failOn: "high", wantCode: 1, wantSeverity: "high"},
+		{name: "severity and secrets", failOn: "HIGH, secrets", exitCode: 3, wantCode: 3, wantSeverity: "high", wantSecrets: true},
+		{name: "secrets only", failOn: "secrets", wantCode: 1, wantSecrets: true},
+		{name: "exit code alone fails on anything", exitCode: 2, wantCode: 2, wantSeverity: "unknown", wantSecrets: true},
+		{name: "unknown value", failOn: "severe", wantErr: true},
+		{name: "two severities", failOn: "high,low", wantErr: true},
+		{name: "negative exit code", failOn: "high", exitCode: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := newFailPolicy(tt.failOn, tt.exitCode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newFailPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if p.exitCode != tt.wantCode {
+				t.Errorf("exitCode = %d, want %d", p.exitCode, tt.wantCode)
+			}
+			gotSeverity := ""
+			if p.minSeverity != nil {
+				gotSeverity = p.minSeverity.String()
+			}
+			if gotSeverity != tt.wantSeverity {
+				t.Errorf("minSeverity = %q, want %q", gotSeverity, tt.wantSeverity)
+			}
+			if p.secrets != tt.wantSecrets {
+				t.Errorf("secrets = %v, want %v", p.secrets, tt.wantSecrets)
+			}
+		})
+	}
+}
+
+func TestFailPolicy_Check(t *testing.T) {
+	t.Parallel()
+
+	eicar := types.NewInfectedScanResult("eicar.com", "", 0, "Eicar-Test-Signature")
+	trojan := types.NewInfectedScanResult("payload.exe", "", 0, "Win.Trojan.Agent-1")
+	clean := types.NewCleanScanResult("readme.txt", "", 0)
+	unreadable := types.NewErrorScanResult("locked.bin", "permission denied")
+
+	vulnerable := &trivy.ScanResult{
+		Vulnerabilities: []trivy.Vulnerability{
+			{CVEID: "CVE-2021-23337", Severity: trivy.SeverityHigh},
+			{CVEID: "CVE-2023-32681", Severity: trivy.SeverityMedium},
+		},
+	}
+	withSecret := &trivy.ScanResult{
+		Secrets: []trivy.Secret{{RuleID: "aws-access-key-id", Severity: trivy.SeverityCritical}},
+	}
+
+	tests := []struct {
+		name     string
+		failOn   string
+		exitCode int
+		clam     []*types.ScanResult
+		trivy    *trivy.ScanResult
+		wantCode int
+	}{
+		{name: "disabled", clam: []*types.ScanResult{trojan}, trivy: vulnerable},
+		{name: "malware below threshold", failOn: "high", clam: []*types.ScanResult{eicar, clean}},
+		{name: "malware above threshold", failOn: "high", clam: []*types.ScanResult{trojan}, wantCode: 1},
+		{name: "malware at threshold", failOn: "low", clam: []*types.ScanResult{eicar}, wantCode: 1},
+		{name: "vulnerability below threshold", failOn: "critical", trivy: vulnerable},
+		{name: "vulnerability at threshold", failOn: "high", exitCode: 4, trivy: vulnerable, wantCode: 4},
+		{name: "secret ignored without secrets", failOn: "high", trivy: withSecret},
+		{name: "secret fails", failOn: "secrets", trivy: withSecret, wantCode: 1},
+		{name: "secrets do not check severity", failOn: "secrets", clam: []*types.ScanResult{trojan}, trivy: vulnerable},
+		{name: "any finding", exitCode: 2, clam: []*types.ScanResult{eicar}, wantCode: 2},
+		{name: "nothing found", exitCode: 2, clam: []*types.ScanResult{clean}, trivy: &trivy.ScanResult{}},
+		{name: "scan error ignored when disabled", clam: []*types.ScanResult{clean, unreadable}},
+		{name: "scan error fails", failOn: "critical", clam: []*types.ScanResult{clean, unreadable}, wantCode: 1},
+		{name: "scan error fails with secrets only", failOn: "secrets", exitCode: 3, clam: []*types.ScanResult{unreadable}, wantCode: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := newFailPolicy(tt.failOn, tt.exitCode)
+			if err != nil {
+				t.Fatalf("newFailPolicy() error = %v", err)
+			}
+
+			assertExitCode(t, p.check(tt.clam, tt.trivy), tt.wantCode)
+		})
+	}
+}
+
+func TestRunTrivyLocalScan_FailOn(t *testing.T) {
+	t.Parallel()
+
+	// A trivy stand-in that reports one HIGH vulnerability.
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "trivy")
+	report := `{"SchemaVersion":2,"Results":[{"Target":"requirements.txt","Class":"lang-pkgs","Type":"pip",` +
+		`"Vulnerabilities":[{"VulnerabilityID":"CVE-2021-23337","PkgName":"requests","InstalledVersion":"2.25.0","Severity":"HIGH"}]}]}`
+	script := "#!/bin/sh\ncat <<'EOF'\n" + report + "\nEOF\n"
+	if err := os.WriteFile(binary, []byte(script), 0o755); err != nil {
+		t.Fatalf("writing fake trivy: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		failOn   string
+		exitCode int
+		wantCode int
+	}{
+		{name: "no threshold"},
+		{name: "below threshold", failOn: "critical"},
+		{name: "at threshold", failOn: "high", wantCode: 1},
+		{name: "custom exit code", failOn: "medium", exitCode: 5, wantCode: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			policy, err := newFailPolicy(tt.failOn, tt.exitCode)
+			if err != nil {
+				t.Fatalf("newFailPolicy() error = %v", err)
+			}
+
+			err = runTrivyLocalScan(context.Background(), t.TempDir(), trivy.TargetTypeFS, binary, true,
+				trivy.ScanOptions{}, time.Minute, trivyFormatJSON, "", policy)
+			assertExitCode(t, err, tt.wantCode)
+		})
+	}
+}
+
+// TestScanWithClamAV_FailOn puts a clamscan stand-in on PATH, so it cannot
+// run in parallel.
+func TestScanWithClamAV_FailOn(t *testing.T) {
+	bin := t.TempDir()
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" = --version ]; then echo 'ClamAV 1.0.0/27000/Mon Jan 1 00:00:00 2024'; exit 0; fi\n" +
+		"for last; do :; done\n" +
+		"if grep -q TROJAN \"$last\"; then echo \"$last: Win.Trojan.Agent-1 FOUND\"; exit 1; fi\n" +
+		"if grep -q EICAR \"$last\"; then echo \"$last: Eicar-Test-Signature FOUND\"; exit 1; fi\n" +
+		"echo \"$last: OK\"\n"
+	if err := os.WriteFile(filepath.Join(bin, "clamscan"), []byte(script), 0o755); err != nil {
+		t.Fatalf("writing fake clamscan: %v", err)
+	}
+
+	// A trivy stand-in that, like trivy, only reports the MEDIUM
+	// vulnerability when --severity asks for it.
+	medium := `{"SchemaVersion":2,"Results":[{"Target":"requirements.txt","Class":"lang-pkgs","Type":"pip",` +
+		`"Vulnerabilities":[{"VulnerabilityID":"CVE-2023-32681","PkgName":"requests","InstalledVersion":"2.25.0","Severity":"MEDIUM"}]}]}`
+	trivyScript := "#!/bin/sh\n" +
+		"case \"$*\" in *--severity*MEDIUM*) cat <<'EOF'\n" + medium + "\nEOF\n;;\n" +
+		"*) echo '{\"SchemaVersion\":2,\"Results\":[]}';;\nesac\n"
+	if err := os.WriteFile(filepath.Join(bin, "trivy"), []byte(trivyScript), 0o755); err != nil {
+		t.Fatalf("writing fake trivy: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	files := t.TempDir()
+	for name, content := range map[string]string{
+		"eicar.com":   "EICAR",
+		"payload.exe": "TROJAN",
+		"readme.txt":  "hello",
+	} {
+		if err := os.WriteFile(filepath.Join(files, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		file     string
+		withDeps bool
+		failOn   string
+		exitCode int
+		wantCode int
+	}{
+		{name: "low severity malware below threshold", file: "eicar.com", failOn: "high"},
+		{name: "low severity malware at threshold", file: "eicar.com", failOn: "low", wantCode: 1},
+		{name: "critical malware", file: "payload.exe", failOn: "high", exitCode: 3, wantCode: 3},
+		{name: "clean file", file: "readme.txt", exitCode: 2},
+		{name: "medium vulnerability at threshold", file: "readme.txt", withDeps: true, failOn: "medium", wantCode: 1},
+		{name: "medium vulnerability below threshold", file: "readme.txt", withDeps: true, failOn: "high"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := newFailPolicy(tt.failOn, tt.exitCode)
+			if err != nil {
+				t.Fatalf("newFailPolicy() error = %v", err)
+			}
+
+			cfg := &config.ClamAVConfig{Mode: "clamscan", Binary: "clamscan", Timeout: time.Minute}
+			err = scanWithClamAV(context.Background(), filepath.Join(files, tt.file), false, cfg, t.TempDir(), true, false, tt.withDeps, "", policy)
+			assertExitCode(t, err, tt.wantCode)
+		})
+	}
+}
+
+// assertExitCode checks that err is an *exitCodeError with wantCode, or nil
+// when wantCode is 0.
+func assertExitCode(t *testing.T, err error, wantCode int) {
+	t.Helper()
+
+	if wantCode == 0 {
+		if err != nil {
+			t.Fatalf("error = %v, want nil", err)
+		}
+		return
+	}
+
+	var exitErr *exitCodeError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("error = %v, want *exitCodeError", err)
+	}
+	if exitErr.Code != wantCode {
+		t.Errorf("exit code = %d, want %d (%v)", exitErr.Code, wantCode, exitErr)
+	}
+}
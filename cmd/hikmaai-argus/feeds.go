@@ -1,5 +1,5 @@
 // ABOUTME: Feed management commands for signature updates
-// ABOUTME: Provides list, update, and import operations for multiple feed sources
+// ABOUTME: Provides list, update, import, prune, and export operations for multiple feed sources
 
 package main
 
@@ -27,6 +27,9 @@ func newFeedsCmd() *cobra.Command {
 	cmd.AddCommand(newFeedsListCmd())
 	cmd.AddCommand(newFeedsUpdateCmd())
 	cmd.AddCommand(newFeedsImportCmd())
+	cmd.AddCommand(newFeedsPruneCmd())
+	cmd.AddCommand(newFeedsPruneExpiredCmd())
+	cmd.AddCommand(newFeedsExportCmd())
 
 	return cmd
 }
@@ -46,7 +49,7 @@ func newFeedsListCmd() *cobra.Command {
 			fmt.Println("  malwarebazaar - abuse.ch MalwareBazaar SHA256 hash list (~1M hashes)")
 			fmt.Println("  clamav        - ClamAV signature hashes (extracts from CVD files)")
 			fmt.Println("  threatfox     - abuse.ch ThreatFox IOC feed (mostly URLs/IPs, few hashes)")
-			fmt.Println("  urlhaus       - abuse.ch URLhaus (URL-based, no file hashes)")
+			fmt.Println("  urlhaus       - abuse.ch URLhaus (payload hashes)")
 			fmt.Println()
 			fmt.Println("Meta source:")
 			fmt.Println("  all           - Load clamav-db + eicar + malwarebazaar + clamav (DEFAULT)")
@@ -67,6 +70,7 @@ func newFeedsUpdateCmd() *cobra.Command {
 		source       string
 		reloadClamd  bool
 		clamdAddress string
+		mergePolicy  string
 	)
 
 	cmd := &cobra.Command{
@@ -86,9 +90,18 @@ Example:
   hikmaai-argus feeds update                        # Load all feeds (default)
   hikmaai-argus feeds update --source clamav-db     # ClamAV databases only
   hikmaai-argus feeds update --source eicar         # EICAR test signatures only
-  hikmaai-argus feeds update --source malwarebazaar # MalwareBazaar hashes only`,
+  hikmaai-argus feeds update --source malwarebazaar # MalwareBazaar hashes only
+  hikmaai-argus feeds update --merge-policy merge   # Combine feeds reporting the same hash`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runFeedsUpdate(cmd.Context(), dataDir, clamDBDir, source, reloadClamd, clamdAddress)
+			policy, err := engine.ParseMergePolicy(mergePolicy)
+			if err != nil {
+				return err
+			}
+			fileCfg, err := config.LoadConfig(cfgFile)
+			if err != nil {
+				return err
+			}
+			return runFeedsUpdate(cmd.Context(), dataDir, clamDBDir, source, reloadClamd, clamdAddress, policy, feedDownloaderConfig(fileCfg.Feeds), fileCfg.Feeds.ClamAVBodySignatures)
 		},
 	}
 
@@ -97,18 +110,29 @@ Example:
 	cmd.Flags().StringVar(&source, "source", "all", "feed source to load (eicar, clamav, clamav-db, malwarebazaar, threatfox, all)")
 	cmd.Flags().BoolVar(&reloadClamd, "reload-clamd", false, "send RELOAD command to clamd after updating CVD files")
 	cmd.Flags().StringVar(&clamdAddress, "clamd-address", "", "clamd address for reload (unix:// or tcp://)")
+	cmd.Flags().StringVar(&mergePolicy, "merge-policy", "overwrite", "how signatures sharing a hash are combined (overwrite, merge, keep-first)")
 
 	return cmd
 }
 
-func runFeedsUpdate(ctx context.Context, dataDir, clamDBDir, source string, reloadClamd bool, clamdAddress string) error {
+// feedDownloaderConfig maps the network settings of the feeds config
+// section to the feed downloader.
+func feedDownloaderConfig(cfg config.FeedsConfig) feeds.DownloaderConfig {
+	return feeds.DownloaderConfig{
+		ProxyURL:           cfg.ProxyURL,
+		CACertFile:         cfg.CACertFile,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+}
+
+func runFeedsUpdate(ctx context.Context, dataDir, clamDBDir, source string, reloadClamd bool, clamdAddress string, mergePolicy engine.MergePolicy, network feeds.DownloaderConfig, clamavBody bool) error {
 	sources := parseSources(source)
 
 	// Handle clamav-db separately (doesn't return signatures, manages CVD files).
 	var cvdUpdated bool
 	for i, src := range sources {
 		if src == "clamav-db" {
-			updated, err := updateClamAVDB(ctx, clamDBDir)
+			updated, err := updateClamAVDB(ctx, clamDBDir, network)
 			if err != nil {
 				fmt.Printf("Warning: failed to update clamav-db: %v\n", err)
 			} else {
@@ -149,6 +173,7 @@ func runFeedsUpdate(ctx context.Context, dataDir, clamDBDir, source string, relo
 			ExpectedItems:     10_000_000,
 			FalsePositiveRate: 0.001,
 		},
+		MergePolicy: mergePolicy,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
@@ -160,7 +185,7 @@ func runFeedsUpdate(ctx context.Context, dataDir, clamDBDir, source string, relo
 	for _, src := range sources {
 		fmt.Printf("Loading signatures from '%s' feed...\n", src)
 
-		sigs, err := loadFeed(ctx, src, clamDBDir)
+		sigs, err := loadFeed(ctx, src, clamDBDir, network, clamavBody)
 		if err != nil {
 			fmt.Printf("  Warning: failed to load %s: %v\n", src, err)
 			continue
@@ -205,10 +230,13 @@ func runFeedsUpdate(ctx context.Context, dataDir, clamDBDir, source string, relo
 // updateClamAVDB downloads ClamAV database files (CVD) for clamscan.
 // This works like freshclam: downloads main.cvd and daily.cvd to the specified directory.
 // Returns true if any databases were actually downloaded (not skipped).
-func updateClamAVDB(ctx context.Context, clamDBDir string) (bool, error) {
+func updateClamAVDB(ctx context.Context, clamDBDir string, network feeds.DownloaderConfig) (bool, error) {
 	fmt.Printf("Updating ClamAV databases (CVD files) in %s...\n", clamDBDir)
 
 	dbFeed := feeds.NewClamAVDBFeed(clamDBDir)
+	if err := dbFeed.SetDownloaderConfig(network); err != nil {
+		return false, err
+	}
 
 	stats, err := dbFeed.Update(ctx)
 	if err != nil {
@@ -233,7 +261,8 @@ func parseSources(source string) []string {
 		// Include all useful feeds:
 		// - clamav-db: CVD files for clamscan (data/clamdb)
 		// - eicar, malwarebazaar, clamav: signature hashes (data/hikmaaidb)
-		// ThreatFox and URLhaus primarily provide URLs/IPs, not file hashes.
+		// ThreatFox and URLhaus are opt-in: ThreatFox is mostly URLs/IPs and
+		// URLhaus payloads largely overlap with MalwareBazaar.
 		return []string{"clamav-db", "eicar", "malwarebazaar", "clamav"}
 	}
 
@@ -254,8 +283,15 @@ func parseSources(source string) []string {
 }
 
 // loadFeed loads signatures from a specific feed source.
-// clamDBDir is used by the clamav feed to read from local CVD files.
-func loadFeed(ctx context.Context, source string, clamDBDir string) ([]*types.Signature, error) {
+// clamDBDir is used by the clamav feed to read from local CVD files, and
+// clamavBody makes it catalog their body signatures too.
+func loadFeed(ctx context.Context, source string, clamDBDir string, network feeds.DownloaderConfig, clamavBody bool) ([]*types.Signature, error) {
+	// Feeds downloaded over the network.
+	var feed interface {
+		Fetch(ctx context.Context) ([]*types.Signature, error)
+		SetDownloaderConfig(cfg feeds.DownloaderConfig) error
+	}
+
 	switch strings.ToLower(source) {
 	case "eicar":
 		return feeds.EICARSignatures(), nil
@@ -263,29 +299,33 @@ func loadFeed(ctx context.Context, source string, clamDBDir string) ([]*types.Si
 	case "clamav":
 		// Use local CVD files if they exist (downloaded by clamav-db feed).
 		feed := feeds.NewClamAVFeedFromLocal(clamDBDir)
+		feed.SetIncludeBodySignatures(clamavBody)
 		return feed.Fetch(ctx)
 
 	case "malwarebazaar", "abusech", "abuse.ch":
-		feed := feeds.NewMalwareBazaarFeed()
-		return feed.Fetch(ctx)
+		feed = feeds.NewMalwareBazaarFeed()
 
 	case "threatfox":
-		feed := feeds.NewThreatFoxFeed()
-		return feed.Fetch(ctx)
+		feed = feeds.NewThreatFoxFeed()
 
 	case "urlhaus":
-		feed := feeds.NewURLhausFeed()
-		return feed.Fetch(ctx)
+		feed = feeds.NewURLhausFeed()
 
 	default:
 		return nil, fmt.Errorf("unknown feed source: %s (available: eicar, clamav, malwarebazaar, threatfox, urlhaus, all)", source)
 	}
+
+	if err := feed.SetDownloaderConfig(network); err != nil {
+		return nil, err
+	}
+	return feed.Fetch(ctx)
 }
 
 func newFeedsImportCmd() *cobra.Command {
 	var (
 		feedType string
 		dataDir  string
+		columns  feeds.CSVConfig
 	)
 
 	cmd := &cobra.Command{
@@ -296,21 +336,36 @@ func newFeedsImportCmd() *cobra.Command {
 Supported formats:
   csv    - CSV file with hash columns (abuse.ch format)
 
-Example:
-  hikmaai-argus feeds import --type csv hashes.csv`,
+Column flags are 0-based; -1 means the column is absent. Rows need at
+least one valid hash. With --ttl, imported signatures stop matching once
+the TTL has passed and are removed by "feeds prune-expired".
+
+Examples:
+  hikmaai-argus feeds import --type csv hashes.csv
+  hikmaai-argus feeds import --sha256-col -1 --md5-col 0 --detection-col 1 vendor.csv
+  hikmaai-argus feeds import --ttl 2160h hashes.csv`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runFeedsImport(cmd.Context(), args[0], feedType, dataDir)
+			return runFeedsImport(cmd.Context(), args[0], feedType, dataDir, columns)
 		},
 	}
 
 	cmd.Flags().StringVarP(&feedType, "type", "t", "csv", "feed type (csv)")
 	cmd.Flags().StringVar(&dataDir, "data-dir", config.DefaultDataDir(), "data directory for BadgerDB")
+	cmd.Flags().IntVar(&columns.SHA256Column, "sha256-col", 0, "CSV column holding SHA256 hashes")
+	cmd.Flags().IntVar(&columns.MD5Column, "md5-col", -1, "CSV column holding MD5 hashes")
+	cmd.Flags().IntVar(&columns.SHA1Column, "sha1-col", -1, "CSV column holding SHA1 hashes")
+	cmd.Flags().IntVar(&columns.DetectionColumn, "detection-col", -1, "CSV column holding detection names")
+	cmd.Flags().IntVar(&columns.SeverityColumn, "severity-col", -1, "CSV column holding severities (low, medium, high, critical)")
+	cmd.Flags().IntVar(&columns.ThreatTypeColumn, "threat-type-col", -1, "CSV column holding threat types (malware, trojan, ...)")
+	cmd.Flags().IntVar(&columns.SourceColumn, "source-col", -1, "CSV column holding signature sources (empty values use \"import\")")
+	cmd.Flags().IntVar(&columns.FirstSeenColumn, "first-seen-col", -1, "CSV column holding RFC 3339 first-seen times")
+	cmd.Flags().DurationVar(&columns.TTL, "ttl", 0, "expire imported signatures after this duration (0 = never)")
 
 	return cmd
 }
 
-func runFeedsImport(ctx context.Context, filePath, feedType, dataDir string) error {
+func runFeedsImport(ctx context.Context, filePath, feedType, dataDir string, columns feeds.CSVConfig) error {
 	fmt.Printf("Importing signatures from %s (type=%s)...\n", filePath, feedType)
 
 	// Open the file.
@@ -345,11 +400,9 @@ func runFeedsImport(ctx context.Context, filePath, feedType, dataDir string) err
 
 	switch strings.ToLower(feedType) {
 	case "csv":
-		csvFeed := feeds.NewCSVFeed("import", feeds.CSVConfig{
-			SHA256Column: 0,
-			SkipHeader:   true,
-			CommentChar:  '#',
-		})
+		columns.SkipHeader = true
+		columns.CommentChar = '#'
+		csvFeed := feeds.NewCSVFeed("import", columns)
 		sigs, err = csvFeed.Parse(ctx, file)
 		if err != nil {
 			return fmt.Errorf("failed to parse CSV: %w", err)
@@ -378,3 +431,206 @@ func runFeedsImport(ctx context.Context, filePath, feedType, dataDir string) err
 
 	return nil
 }
+
+func newFeedsPruneCmd() *cobra.Command {
+	var (
+		source  string
+		dataDir string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove all signatures imported from a source",
+		Long: `Remove every signature whose source matches --source from the database,
+for example after a bad feed import. The bloom filter is rebuilt afterwards.
+
+Examples:
+  hikmaai-argus feeds prune --source malwarebazaar
+  hikmaai-argus feeds prune --source import --data-dir /var/lib/argus`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFeedsPrune(cmd.Context(), source, dataDir)
+		},
+	}
+
+	cmd.Flags().StringVar(&source, "source", "", "signature source to remove (e.g. malwarebazaar, threatfox, import)")
+	cmd.Flags().StringVar(&dataDir, "data-dir", config.DefaultDataDir(), "data directory for BadgerDB")
+	_ = cmd.MarkFlagRequired("source")
+
+	return cmd
+}
+
+func runFeedsPrune(ctx context.Context, source, dataDir string) error {
+	eng, err := engine.NewEngine(engine.EngineConfig{
+		StoreConfig: engine.StoreConfig{
+			Path: dataDir,
+		},
+		BloomConfig: engine.BloomConfig{
+			ExpectedItems:     10_000_000,
+			FalsePositiveRate: 0.001,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer eng.Close()
+
+	count, err := eng.PruneBySource(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		fmt.Printf("No signatures found from source %q.\n", source)
+		return nil
+	}
+
+	// Drop the pruned hashes from the bloom filter.
+	fmt.Println("Rebuilding bloom filter...")
+	if err := eng.RebuildBloomFilter(ctx); err != nil {
+		return fmt.Errorf("failed to rebuild bloom filter: %w", err)
+	}
+
+	fmt.Printf("Removed %d signatures from source %q\n", count, source)
+
+	return nil
+}
+
+func newFeedsPruneExpiredCmd() *cobra.Command {
+	var dataDir string
+
+	cmd := &cobra.Command{
+		Use:   "prune-expired",
+		Short: "Remove signatures past their expiry",
+		Long: `Remove every signature whose expiry (set by feeds or "feeds import --ttl")
+has passed. Expired signatures already stop matching; pruning reclaims
+their space. The bloom filter is rebuilt afterwards.
+
+Examples:
+  hikmaai-argus feeds prune-expired
+  hikmaai-argus feeds prune-expired --data-dir /var/lib/argus`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFeedsPruneExpired(cmd.Context(), dataDir)
+		},
+	}
+
+	cmd.Flags().StringVar(&dataDir, "data-dir", config.DefaultDataDir(), "data directory for BadgerDB")
+
+	return cmd
+}
+
+func runFeedsPruneExpired(ctx context.Context, dataDir string) error {
+	eng, err := engine.NewEngine(engine.EngineConfig{
+		StoreConfig: engine.StoreConfig{
+			Path: dataDir,
+		},
+		BloomConfig: engine.BloomConfig{
+			ExpectedItems:     10_000_000,
+			FalsePositiveRate: 0.001,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer eng.Close()
+
+	count, err := eng.PruneExpired(ctx)
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		fmt.Println("No expired signatures found.")
+		return nil
+	}
+
+	// Drop the pruned hashes from the bloom filter.
+	fmt.Println("Rebuilding bloom filter...")
+	if err := eng.RebuildBloomFilter(ctx); err != nil {
+		return fmt.Errorf("failed to rebuild bloom filter: %w", err)
+	}
+
+	fmt.Printf("Removed %d expired signatures\n", count)
+
+	return nil
+}
+
+func newFeedsExportCmd() *cobra.Command {
+	var (
+		format  string
+		out     string
+		dataDir string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export all signatures to a file",
+		Long: `Write every signature in the database to a file, for backups or for
+diffing two databases.
+
+Formats:
+  csv   - sha256,sha1,md5,detection_name,severity,threat_type,source,first_seen
+  json  - one JSON signature per line (NDJSON), with every field
+
+A CSV export can be imported again with:
+  hikmaai-argus feeds import --sha1-col 1 --md5-col 2 --detection-col 3 --severity-col 4 \
+    --threat-type-col 5 --source-col 6 --first-seen-col 7 sigs.csv
+
+Examples:
+  hikmaai-argus feeds export --format json --out sigs.ndjson
+  hikmaai-argus feeds export --format csv > sigs.csv`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFeedsExport(cmd.Context(), format, out, dataDir)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", engine.ExportFormatCSV, "export format (csv, json)")
+	cmd.Flags().StringVarP(&out, "out", "o", "-", "output file (- for stdout)")
+	cmd.Flags().StringVar(&dataDir, "data-dir", config.DefaultDataDir(), "data directory for BadgerDB")
+
+	return cmd
+}
+
+func runFeedsExport(ctx context.Context, format, out, dataDir string) error {
+	eng, err := engine.NewEngine(engine.EngineConfig{
+		StoreConfig: engine.StoreConfig{
+			Path: dataDir,
+		},
+		BloomConfig: engine.BloomConfig{
+			ExpectedItems:     10_000_000,
+			FalsePositiveRate: 0.001,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer eng.Close()
+
+	w := os.Stdout
+	if out != "-" {
+		file, err := os.Create(out)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	count, err := eng.Export(ctx, w, format)
+	if err != nil {
+		return err
+	}
+
+	if out != "-" {
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		fmt.Printf("Exported %d signatures to %s\n", count, out)
+	} else {
+		fmt.Fprintf(os.Stderr, "Exported %d signatures\n", count)
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 )
 
@@ -17,6 +18,11 @@ var (
 func main() {
 	cmd := newRootCmd()
 	if err := cmd.Execute(); err != nil {
+		// Findings over a --fail-on threshold use the requested exit code.
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }
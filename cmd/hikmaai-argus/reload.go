@@ -0,0 +1,127 @@
+// ABOUTME: SIGHUP-triggered reload of the daemon's hot-reloadable settings
+// ABOUTME: Applies log, DB update interval, and feed changes; warns on the rest
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/config"
+	"github.com/hikmaai-io/hikmaai-argus/internal/dbupdater"
+	"github.com/hikmaai-io/hikmaai-argus/internal/observability"
+)
+
+// configReloader re-reads the config file and applies the settings that can
+// change without a restart. Only settings that differ from the previously
+// loaded file are applied, so values given as flags are kept until the file
+// changes them.
+type configReloader struct {
+	path   string
+	logger *slog.Logger
+
+	// current is the configuration the last reload compared against.
+	current *config.Config
+
+	logs       *observability.LogReloader
+	dbUpdates  *dbupdater.DBUpdateService      // nil when DB updates are disabled
+	signatures *dbupdater.SignatureFeedUpdater // nil when DB updates are disabled
+}
+
+// newConfigReloader reloads the config file at path, comparing against
+// current, the configuration loaded at startup.
+func newConfigReloader(path string, current *config.Config, logs *observability.LogReloader, logger *slog.Logger) *configReloader {
+	return &configReloader{
+		path:    path,
+		logger:  logger,
+		current: current,
+		logs:    logs,
+	}
+}
+
+// watch reloads the configuration on every SIGHUP until ctx is done.
+func (r *configReloader) watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				r.Reload()
+			}
+		}
+	}()
+}
+
+// Reload re-reads the config file and applies changed hot-reloadable
+// settings. An invalid file is reported and leaves everything unchanged.
+func (r *configReloader) Reload() {
+	logger := r.logger.With(slog.String("path", r.path))
+
+	next, err := config.LoadConfig(r.path)
+	if err != nil {
+		logger.Error("config reload failed, keeping current settings", slog.String("error", err.Error()))
+		return
+	}
+	prev := r.current
+	r.current = next
+
+	// Settings bound at startup need a restart.
+	if next.HTTP.Addr != prev.HTTP.Addr {
+		logger.Warn("http.addr cannot be reloaded, restart the daemon to apply it",
+			slog.String("http_addr", next.HTTP.Addr))
+	}
+	if next.DataDir != prev.DataDir {
+		logger.Warn("data_dir cannot be reloaded, restart the daemon to apply it",
+			slog.String("data_dir", next.DataDir))
+	}
+
+	if next.Log != prev.Log {
+		r.logs.Reload(next.Log.Level, next.Log.Format)
+		logger.Info("log settings reloaded",
+			slog.String("level", next.Log.Level),
+			slog.String("format", next.Log.Format),
+		)
+	}
+
+	if r.dbUpdates != nil {
+		intervals := []struct {
+			name       string
+			prev, next time.Duration
+		}{
+			{"clamav", prev.DBUpdate.ClamAV.Interval, next.DBUpdate.ClamAV.Interval},
+			{"trivy", prev.DBUpdate.Trivy.Interval, next.DBUpdate.Trivy.Interval},
+			{"signatures", prev.DBUpdate.Signatures.Interval, next.DBUpdate.Signatures.Interval},
+		}
+		for _, iv := range intervals {
+			if iv.next == iv.prev {
+				continue
+			}
+			if err := r.dbUpdates.Reschedule(iv.name, iv.next); err != nil {
+				logger.Warn("db update interval not reloaded",
+					slog.String("updater", iv.name),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+
+	if r.signatures != nil && !slices.Equal(next.Feeds.Sources, prev.Feeds.Sources) {
+		if err := r.signatures.SetEnabledFeeds(next.Feeds.Sources); err != nil {
+			logger.Warn("feed sources not reloaded", slog.String("error", err.Error()))
+		} else {
+			logger.Info("feed sources reloaded", slog.Any("sources", next.Feeds.Sources))
+		}
+	}
+
+	logger.Info("configuration reloaded")
+}
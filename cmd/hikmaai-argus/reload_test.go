@@ -0,0 +1,123 @@
+// ABOUTME: Unit tests for reloading daemon settings from the config file
+// ABOUTME: Covers log, DB update interval, feed source, and invalid-file handling
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/config"
+	"github.com/hikmaai-io/hikmaai-argus/internal/dbupdater"
+	"github.com/hikmaai-io/hikmaai-argus/internal/feeds"
+	"github.com/hikmaai-io/hikmaai-argus/internal/observability"
+)
+
+func TestConfigReloader_Reload(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile := func(contents string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatalf("writing config: %v", err)
+		}
+	}
+	writeFile("log:\n  level: info\n  format: text\n")
+
+	var logs bytes.Buffer
+	logger, logReloader := observability.NewReloadableLogger(observability.LoggingConfig{
+		Level:  "info",
+		Format: "text",
+	}, &logs)
+
+	service := dbupdater.NewDBUpdateService(dbupdater.DBUpdateServiceConfig{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	sigUpdater := dbupdater.NewSignatureFeedUpdater(dbupdater.SignatureFeedUpdaterConfig{})
+	sigUpdater.RegisterFeed(eicarFeed{})
+	sigUpdater.RegisterFeed(&signatureFeedAdapter{feed: feeds.NewThreatFoxFeed()})
+	service.RegisterUpdater(sigUpdater, time.Hour)
+
+	if err := service.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer service.Stop()
+
+	current, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	reloader := newConfigReloader(path, current, logReloader, logger)
+	reloader.dbUpdates = service
+	reloader.signatures = sigUpdater
+
+	// A reload with no changes leaves debug logging off.
+	reloader.Reload()
+	logger.Debug("debug before change")
+	if strings.Contains(logs.String(), "debug before change") {
+		t.Error("debug logged before the level was changed")
+	}
+
+	writeFile(`
+data_dir: /elsewhere
+log:
+  level: debug
+  format: text
+db_update:
+  signatures:
+    interval: 2h
+feeds:
+  update_interval: 1h
+  sources: [threatfox]
+`)
+	reloader.Reload()
+
+	logger.Debug("debug after change")
+	out := logs.String()
+	if !strings.Contains(out, "debug after change") {
+		t.Errorf("log level not reloaded:\n%s", out)
+	}
+	if !strings.Contains(out, "data_dir cannot be reloaded") {
+		t.Errorf("missing warning for data_dir:\n%s", out)
+	}
+	if !strings.Contains(out, "feed sources reloaded") {
+		t.Errorf("feed sources not reloaded:\n%s", out)
+	}
+
+	// The new interval moves the next scheduled update.
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Until(service.GetStatus()["signatures"].NextScheduled) < 90*time.Minute {
+		if time.Now().After(deadline) {
+			t.Fatalf("NextScheduled = %v, want about 2h from now", service.GetStatus()["signatures"].NextScheduled)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// An invalid file keeps the current settings.
+	logs.Reset()
+	writeFile("feeds:\n  update_interval: 1hour\n")
+	reloader.Reload()
+	if !strings.Contains(logs.String(), "config reload failed") {
+		t.Errorf("missing reload failure:\n%s", logs.String())
+	}
+	logger.Debug("still debug")
+	if !strings.Contains(logs.String(), "still debug") {
+		t.Error("invalid reload changed the log level")
+	}
+
+	// Feed names without a registered feed are rejected.
+	logs.Reset()
+	writeFile("log:\n  level: debug\n  format: text\nfeeds:\n  update_interval: 1h\n  sources: [clamav-db]\n")
+	reloader.Reload()
+	if !strings.Contains(logs.String(), "feed sources not reloaded") {
+		t.Errorf("missing warning for unknown feed:\n%s", logs.String())
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -16,6 +18,7 @@ import (
 
 	"github.com/hikmaai-io/hikmaai-argus/internal/config"
 	"github.com/hikmaai-io/hikmaai-argus/internal/engine"
+	"github.com/hikmaai-io/hikmaai-argus/internal/queue"
 	"github.com/hikmaai-io/hikmaai-argus/internal/scanner"
 	"github.com/hikmaai-io/hikmaai-argus/internal/trivy"
 	"github.com/hikmaai-io/hikmaai-argus/internal/types"
@@ -27,7 +30,11 @@ func newScanCmd() *cobra.Command {
 		batch          string
 		direct         bool
 		nats           bool
+		natsURL        string
+		natsTimeout    time.Duration
 		outputJSON     bool
+		outputJSONL    bool
+		outputFile     string
 		dataDir        string
 		clamDBDir      string
 		withFile       string
@@ -36,6 +43,9 @@ func newScanCmd() *cobra.Command {
 		persistMalware bool
 		withDeps       bool
 		trivyServer    string
+		cacheTTL       time.Duration
+		failOn         string
+		exitCode       int
 	)
 
 	cmd := &cobra.Command{
@@ -45,6 +55,9 @@ func newScanCmd() *cobra.Command {
 
 HASH LOOKUP MODE (default):
   Checks hashes against the signature database for known malware.
+  Lookups go to a running daemon over NATS when one responds, and fall
+  back to opening the local database directly otherwise. Use --nats or
+  --direct to force one or the other.
 
 FILE SCAN MODE (--with-file):
   Scans files with ClamAV for malware detection.
@@ -55,31 +68,54 @@ Examples:
   hikmaai-argus scan 275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f
   hikmaai-argus scan --file hashes.txt
   hikmaai-argus scan --batch "hash1,hash2,hash3"
+  hikmaai-argus scan --nats --nats-url nats://argus:4222 <hash>
+  hikmaai-argus scan --file hashes.txt --jsonl --output results.jsonl  # Stream one result per line
 
   # File scanning with ClamAV
   hikmaai-argus scan --with-file /path/to/suspicious.exe
   hikmaai-argus scan --with-file /path/to/directory --recursive
   hikmaai-argus scan --with-file /path/to/file.exe --persist  # Save detections to DB
+  hikmaai-argus scan --with-file /path/to/directory -r --cache-ttl 24h  # Reuse results from the last day
+  curl -s https://example.com/file.bin | hikmaai-argus scan --with-file -  # Scan stdin
 
   # Combined scan (ClamAV malware + Trivy dependencies)
   hikmaai-argus scan --with-file /path/to/app.zip --with-deps
   hikmaai-argus scan --with-file /path/to/project --with-deps --recursive
-  hikmaai-argus scan --with-file /path/to/project --with-deps --trivy-server http://trivy:4954  # Use server mode`,
+  hikmaai-argus scan --with-file /path/to/project --with-deps --trivy-server http://trivy:4954  # Use server mode
+
+  # Fail a CI build on findings; files that cannot be scanned also fail
+  hikmaai-argus scan --with-file ./dist -r --fail-on high  # Exit 1 on high or critical malware
+  hikmaai-argus scan --with-file ./app --with-deps --fail-on high,secrets --exit-code 3`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 
 			// File scan mode.
 			if withFile != "" {
+				if outputFile != "" || outputJSONL {
+					return fmt.Errorf("--output and --jsonl apply to hash lookups only")
+				}
+
+				policy, err := newFailPolicy(failOn, exitCode)
+				if err != nil {
+					return err
+				}
+				cmd.SilenceUsage = true
+
 				cfg := &config.ClamAVConfig{
 					Mode:        "clamscan",
 					Binary:      "clamscan",
 					DatabaseDir: clamDBDir,
 					Address:     clamdAddress,
 					Timeout:     5 * time.Minute,
+					CacheTTL:    cacheTTL,
 				}
 
-				return scanWithClamAV(ctx, withFile, recursive, cfg, dataDir, outputJSON, persistMalware, withDeps, trivyServer)
+				return scanWithClamAV(ctx, withFile, recursive, cfg, dataDir, outputJSON, persistMalware, withDeps, trivyServer, policy)
+			}
+
+			if failOn != "" || exitCode != 0 {
+				return fmt.Errorf("--fail-on and --exit-code apply to --with-file scans only")
 			}
 
 			// Hash lookup mode.
@@ -115,7 +151,58 @@ Examples:
 				return fmt.Errorf("cannot use both --nats and --direct")
 			}
 
-			return scanDirect(ctx, hashes, dataDir, outputJSON)
+			mode := scanModeAuto
+			switch {
+			case nats:
+				mode = scanModeNATS
+			case direct:
+				mode = scanModeDirect
+			}
+
+			// Prefer the config file's NATS URL unless one was given.
+			if !cmd.Flags().Changed("nats-url") {
+				if fileCfg, err := config.LoadConfig(cfgFile); err == nil && fileCfg.NATS.URL != "" {
+					natsURL = fileCfg.NATS.URL
+				}
+			}
+
+			target := natsTarget{
+				URL:     natsURL,
+				Subject: queue.DefaultNATSConfig().Subject,
+				Timeout: natsTimeout,
+			}
+
+			format := formatText
+			switch {
+			case outputJSONL:
+				format = formatJSONL
+			case outputJSON:
+				format = formatJSON
+			}
+
+			var (
+				out io.Writer = os.Stdout
+				buf *bufio.Writer
+			)
+			if outputFile != "" {
+				f, err := os.Create(outputFile)
+				if err != nil {
+					return fmt.Errorf("creating output file: %w", err)
+				}
+				defer f.Close()
+
+				buf = bufio.NewWriter(f)
+				out = buf
+			}
+
+			err := runHashScan(ctx, newResultWriter(out, format), hashes, mode, target, dataDir)
+			if buf != nil {
+				// Keep the results written before any failure.
+				if flushErr := buf.Flush(); flushErr != nil && err == nil {
+					err = fmt.Errorf("writing output file: %w", flushErr)
+				}
+			}
+			return err
 		},
 	}
 
@@ -124,24 +211,33 @@ Examples:
 	cmd.Flags().StringVarP(&batch, "batch", "b", "", "comma-separated list of hashes")
 	cmd.Flags().BoolVar(&direct, "direct", false, "force direct database access (skip NATS check)")
 	cmd.Flags().BoolVar(&nats, "nats", false, "force NATS (fail if no daemon)")
+	cmd.Flags().StringVar(&natsURL, "nats-url", "nats://localhost:4222", "NATS server URL of the daemon")
+	cmd.Flags().DurationVar(&natsTimeout, "nats-timeout", queue.DefaultNATSConfig().Timeout, "timeout for connecting to NATS and for each request")
 	cmd.Flags().BoolVarP(&outputJSON, "json", "j", false, "output results as JSON")
+	cmd.Flags().BoolVar(&outputJSONL, "jsonl", false, "stream hash lookup results as JSON lines, one per hash (implies --json)")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "write hash lookup results to this file instead of stdout")
 	cmd.Flags().StringVar(&dataDir, "data-dir", config.DefaultDataDir(), "data directory for HikmaAI signatures")
 	cmd.Flags().StringVar(&clamDBDir, "clamdb-dir", config.DefaultClamDBDir(), "directory for ClamAV databases (CVD files)")
 
 	// ClamAV file scanning flags.
-	cmd.Flags().StringVar(&withFile, "with-file", "", "path to file or directory to scan with ClamAV")
+	cmd.Flags().StringVar(&withFile, "with-file", "", "path to file or directory to scan with ClamAV (- for stdin)")
 	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "scan directories recursively")
 	cmd.Flags().StringVar(&clamdAddress, "clamd-address", "", "clamd address (for clamd mode)")
 	cmd.Flags().BoolVar(&persistMalware, "persist", false, "persist malware detections to signature database")
+	cmd.Flags().DurationVar(&cacheTTL, "cache-ttl", 0, "reuse cached results for identical files scanned within this window (0 = no cache)")
 
 	// Trivy dependency scanning flags (used with --with-file).
 	cmd.Flags().BoolVar(&withDeps, "with-deps", false, "also scan for dependency vulnerabilities and secrets")
 	cmd.Flags().StringVar(&trivyServer, "trivy-server", "", "Trivy server URL (uses local trivy if not set)")
 
+	// CI gating flags (used with --with-file).
+	cmd.Flags().StringVar(&failOn, "fail-on", "", "fail when findings reach this severity (low, medium, high, critical) and/or on secrets, e.g. high,secrets")
+	cmd.Flags().IntVar(&exitCode, "exit-code", 0, "exit code for findings over --fail-on (default 1); alone, fails on any finding")
+
 	return cmd
 }
 
-func scanDirect(ctx context.Context, hashes []string, dataDir string, outputJSON bool) error {
+func scanDirect(ctx context.Context, rw *resultWriter, hashes []string, dataDir string) error {
 	// Create engine with bloom filter rebuilt from existing signatures.
 	eng, err := engine.NewEngine(engine.EngineConfig{
 		StoreConfig: engine.StoreConfig{
@@ -158,61 +254,128 @@ func scanDirect(ctx context.Context, hashes []string, dataDir string, outputJSON
 	}
 	defer eng.Close()
 
-	// Scan each hash.
-	results := make([]types.Result, 0, len(hashes))
+	// Scan each hash, writing results as they complete.
 	for _, hashStr := range hashes {
 		hash, err := types.ParseHash(hashStr)
 		if err != nil {
-			if outputJSON {
-				results = append(results, types.NewErrorResult(types.Hash{Value: hashStr}, err.Error()))
-			} else {
+			if rw.format == formatText {
 				fmt.Fprintf(os.Stderr, "invalid hash %q: %v\n", hashStr, err)
+				continue
+			}
+			if err := rw.writeResult(types.NewErrorResult(types.Hash{Value: hashStr}, err.Error())); err != nil {
+				return err
 			}
 			continue
 		}
 
 		result, err := eng.Lookup(ctx, hash)
 		if err != nil {
-			if outputJSON {
-				results = append(results, types.NewErrorResult(hash, err.Error()))
-			} else {
+			if rw.format == formatText {
 				fmt.Fprintf(os.Stderr, "lookup error for %s: %v\n", hashStr, err)
+				continue
 			}
-			continue
+			result = types.NewErrorResult(hash, err.Error())
 		}
 
-		results = append(results, result)
-
-		if !outputJSON {
-			printResult(result)
+		if err := rw.writeResult(result); err != nil {
+			return err
 		}
 	}
 
-	if outputJSON {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		return enc.Encode(results)
+	return rw.flush()
+}
+
+func printResult(w io.Writer, result types.Result) {
+	fmt.Fprintf(w, "Hash:   %s (%s)\n", result.Hash.Value, result.Hash.Type)
+	fmt.Fprintf(w, "Status: %s\n", result.Status)
+
+	if result.Status == types.StatusMalware && result.Signature != nil {
+		fmt.Fprintf(w, "Detection: %s\n", result.Signature.DetectionName)
+		fmt.Fprintf(w, "Threat:    %s (%s)\n", result.Signature.ThreatType, result.Signature.Severity)
+		fmt.Fprintf(w, "Source:    %s\n", result.Signature.Source)
+	}
+
+	if result.Error != "" {
+		fmt.Fprintf(w, "Error: %s\n", result.Error)
+	}
+
+	fmt.Fprintf(w, "Lookup:  %.3fms (bloom=%v)\n", result.LookupTimeMs, result.BloomHit)
+	fmt.Fprintln(w)
+}
+
+// outputFormat selects how hash lookup results are written.
+type outputFormat int
+
+const (
+	// formatText prints human-readable results.
+	formatText outputFormat = iota
+
+	// formatJSON writes all results as one JSON array.
+	formatJSON
+
+	// formatJSONL writes one JSON object per line as each lookup completes.
+	formatJSONL
+)
+
+// resultWriter writes hash lookup results in the selected format. Text and
+// JSON lines are written as each result arrives; JSON arrays are buffered
+// until flush. Every format writes types.Result, whichever path produced it.
+type resultWriter struct {
+	w       io.Writer
+	format  outputFormat
+	enc     *json.Encoder
+	pending []types.Result
+
+	// written counts results accepted so far, including buffered ones.
+	written int
+}
+
+// newResultWriter returns a resultWriter that writes format to w.
+func newResultWriter(w io.Writer, format outputFormat) *resultWriter {
+	return &resultWriter{
+		w:       w,
+		format:  format,
+		enc:     json.NewEncoder(w),
+		pending: make([]types.Result, 0),
+	}
+}
+
+// writeResult writes a result from a direct database lookup.
+func (rw *resultWriter) writeResult(result types.Result) error {
+	rw.written++
+
+	switch rw.format {
+	case formatJSONL:
+		if err := rw.enc.Encode(result); err != nil {
+			return fmt.Errorf("writing result: %w", err)
+		}
+	case formatJSON:
+		rw.pending = append(rw.pending, result)
+	default:
+		printResult(rw.w, result)
 	}
 
 	return nil
 }
 
-func printResult(result types.Result) {
-	fmt.Printf("Hash:   %s (%s)\n", result.Hash.Value, result.Hash.Type)
-	fmt.Printf("Status: %s\n", result.Status)
+// writeResponse writes a scan reply from the daemon as a types.Result.
+func (rw *resultWriter) writeResponse(resp queue.ScanResponse) error {
+	return rw.writeResult(responseResult(resp))
+}
 
-	if result.Status == types.StatusMalware && result.Signature != nil {
-		fmt.Printf("Detection: %s\n", result.Signature.DetectionName)
-		fmt.Printf("Threat:    %s (%s)\n", result.Signature.ThreatType, result.Signature.Severity)
-		fmt.Printf("Source:    %s\n", result.Signature.Source)
+// flush writes the buffered JSON array, if any.
+func (rw *resultWriter) flush() error {
+	if rw.format != formatJSON {
+		return nil
 	}
 
-	if result.Error != "" {
-		fmt.Printf("Error: %s\n", result.Error)
+	rw.enc.SetIndent("", "  ")
+	if err := rw.enc.Encode(rw.pending); err != nil {
+		return fmt.Errorf("writing results: %w", err)
 	}
+	rw.pending = rw.pending[:0]
 
-	fmt.Printf("Lookup:  %.3fms (bloom=%v)\n", result.LookupTimeMs, result.BloomHit)
-	fmt.Println()
+	return nil
 }
 
 // CombinedScanResult holds results from both ClamAV and Trivy scans.
@@ -229,16 +392,39 @@ type ClamAVSummary struct {
 	Errors   int                 `json:"errors"`
 }
 
-func scanWithClamAV(ctx context.Context, path string, recursive bool, cfg *config.ClamAVConfig, dataDir string, outputJSON, persistMalware, withDeps bool, trivyServer string) error {
+func scanWithClamAV(ctx context.Context, path string, recursive bool, cfg *config.ClamAVConfig, dataDir string, outputJSON, persistMalware, withDeps bool, trivyServer string, policy failPolicy) error {
+	// "-" scans standard input.
+	fromStdin := path == "-"
+	if fromStdin && withDeps {
+		return fmt.Errorf("--with-deps cannot be used when scanning stdin")
+	}
+
 	// Check if path exists.
-	info, err := os.Stat(path)
-	if err != nil {
-		return fmt.Errorf("accessing path: %w", err)
+	var (
+		info os.FileInfo
+		err  error
+	)
+	if !fromStdin {
+		info, err = os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("accessing path: %w", err)
+		}
 	}
 
 	// Create scanner.
 	clamScanner := scanner.NewClamAVScanner(cfg)
 
+	// Reuse results for identical content; the cache is shared with the daemon.
+	if cfg.CacheTTL > 0 {
+		scanCache, err := engine.NewScanCache(engine.StoreConfig{Path: filepath.Join(dataDir, "cache")}, cfg.CacheTTL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: scan cache unavailable, scanning without it: %v\n", err)
+		} else {
+			defer scanCache.Close()
+			clamScanner.ScanCache = scanCache
+		}
+	}
+
 	// Check if clamscan is available.
 	if err := clamScanner.Ping(ctx); err != nil {
 		return fmt.Errorf("clamscan not available: %w (install ClamAV or check PATH)", err)
@@ -246,7 +432,13 @@ func scanWithClamAV(ctx context.Context, path string, recursive bool, cfg *confi
 
 	var results []*types.ScanResult
 
-	if info.IsDir() {
+	if fromStdin {
+		result, err := clamScanner.ScanReader(ctx, os.Stdin, "stdin")
+		if err != nil {
+			return fmt.Errorf("scanning stdin: %w", err)
+		}
+		results = []*types.ScanResult{result}
+	} else if info.IsDir() {
 		// Scan directory.
 		results, err = clamScanner.ScanDir(ctx, path, recursive)
 		if err != nil {
@@ -264,7 +456,7 @@ func scanWithClamAV(ctx context.Context, path string, recursive bool, cfg *confi
 	// Run Trivy dependency scan if requested.
 	var trivyResult *trivy.ScanResult
 	if withDeps {
-		trivyResult, err = runDependencyScan(ctx, path, trivyServer)
+		trivyResult, err = runDependencyScan(ctx, path, trivyServer, policy.dependencySeverities())
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: dependency scan failed: %v\n", err)
 		}
@@ -323,7 +515,10 @@ func scanWithClamAV(ctx context.Context, path string, recursive bool, cfg *confi
 		}
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
-		return enc.Encode(combined)
+		if err := enc.Encode(combined); err != nil {
+			return err
+		}
+		return policy.check(results, trivyResult)
 	}
 
 	// Print human-readable ClamAV output.
@@ -349,7 +544,7 @@ func scanWithClamAV(ctx context.Context, path string, recursive bool, cfg *confi
 		printTrivyCombinedResult(trivyResult)
 	}
 
-	return nil
+	return policy.check(results, trivyResult)
 }
 
 func printScanResult(result *types.ScanResult) {
@@ -368,7 +563,11 @@ func printScanResult(result *types.ScanResult) {
 	if result.FileHash != "" {
 		fmt.Printf("SHA256: %s\n", result.FileHash)
 	}
-	fmt.Printf("Scan:   %.3fms\n", result.ScanTimeMs)
+	if result.Cached {
+		fmt.Printf("Scan:   %.3fms (cached)\n", result.ScanTimeMs)
+	} else {
+		fmt.Printf("Scan:   %.3fms\n", result.ScanTimeMs)
+	}
 	fmt.Println()
 }
 
@@ -391,9 +590,10 @@ func readHashesFromFile(path string) ([]string, error) {
 	return hashes, sc.Err()
 }
 
-// runDependencyScan scans a path for dependency vulnerabilities and secrets.
-// Uses server mode if trivyServer is provided, otherwise uses local mode.
-func runDependencyScan(ctx context.Context, path string, trivyServer string) (*trivy.ScanResult, error) {
+// runDependencyScan scans a path for dependency vulnerabilities of the given
+// severities and for secrets. Uses server mode if trivyServer is provided,
+// otherwise uses local mode.
+func runDependencyScan(ctx context.Context, path string, trivyServer string, severities []string) (*trivy.ScanResult, error) {
 	// Determine mode based on server URL.
 	var cfg *config.TrivyConfig
 	if trivyServer != "" {
@@ -413,9 +613,8 @@ func runDependencyScan(ctx context.Context, path string, trivyServer string) (*t
 	// Create unified scanner.
 	scanner := trivy.NewUnifiedScanner(cfg)
 
-	// Scan with HIGH/CRITICAL filter and secrets.
 	opts := trivy.ScanOptions{
-		SeverityFilter: []string{trivy.SeverityCritical, trivy.SeverityHigh},
+		SeverityFilter: severities,
 		ScanSecrets:    true,
 	}
 
@@ -0,0 +1,157 @@
+// ABOUTME: NATS request/reply client for CLI hash lookups against a running daemon
+// ABOUTME: Sends one scan request per hash and falls back to direct access in auto mode
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/queue"
+	"github.com/hikmaai-io/hikmaai-argus/internal/types"
+)
+
+// errNATSUnavailable is returned when no daemon can be reached over NATS,
+// either because the server is down or nothing answers on the scan subject.
+var errNATSUnavailable = errors.New("no daemon reachable over NATS")
+
+// hashScanMode selects how hash lookups reach the signature database.
+type hashScanMode int
+
+const (
+	// scanModeAuto tries NATS first and falls back to direct access.
+	scanModeAuto hashScanMode = iota
+
+	// scanModeNATS only uses NATS and fails if no daemon responds.
+	scanModeNATS
+
+	// scanModeDirect opens the local database without trying NATS.
+	scanModeDirect
+)
+
+// natsTarget identifies the daemon scan handler to send requests to.
+type natsTarget struct {
+	// URL of the NATS server.
+	URL string
+
+	// Subject the daemon's scan handler subscribes to.
+	Subject string
+
+	// Timeout bounds the connection attempt and each request.
+	Timeout time.Duration
+}
+
+// runHashScan looks up hashes according to mode and writes the results to
+// rw. In auto mode it falls back to direct access only if NATS fails before
+// any result is written.
+func runHashScan(ctx context.Context, rw *resultWriter, hashes []string, mode hashScanMode, target natsTarget, dataDir string) error {
+	if mode != scanModeDirect {
+		err := scanNATS(ctx, rw, hashes, target)
+		if err == nil || mode == scanModeNATS || rw.written > 0 || !errors.Is(err, errNATSUnavailable) {
+			return err
+		}
+	}
+
+	return scanDirect(ctx, rw, hashes, dataDir)
+}
+
+// scanNATS sends a lookup request per hash to the daemon over NATS and
+// writes each reply to rw as it arrives.
+func scanNATS(ctx context.Context, rw *resultWriter, hashes []string, target natsTarget) error {
+	conn, err := nats.Connect(target.URL,
+		nats.Name("hikmaai-argus-cli"),
+		nats.Timeout(target.Timeout),
+		nats.NoReconnect(),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: connecting to %s: %v", errNATSUnavailable, target.URL, err)
+	}
+	defer conn.Close()
+
+	for _, hash := range hashes {
+		resp, err := requestScan(ctx, conn, hash, target)
+		if err != nil {
+			return err
+		}
+		if err := rw.writeResponse(resp); err != nil {
+			return err
+		}
+	}
+
+	return rw.flush()
+}
+
+// requestScan sends a scan request for hash and waits for the reply.
+func requestScan(ctx context.Context, conn *nats.Conn, hash string, target natsTarget) (queue.ScanResponse, error) {
+	var resp queue.ScanResponse
+
+	data, err := json.Marshal(queue.ScanRequest{Hash: hash})
+	if err != nil {
+		return resp, fmt.Errorf("encoding scan request: %w", err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, target.Timeout)
+	defer cancel()
+
+	msg, err := conn.RequestWithContext(reqCtx, target.Subject, data)
+	switch {
+	case errors.Is(err, nats.ErrNoResponders):
+		return resp, fmt.Errorf("%w: no daemon subscribed to %s", errNATSUnavailable, target.Subject)
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+		return resp, fmt.Errorf("%w: no reply on %s within %s", errNATSUnavailable, target.Subject, target.Timeout)
+	case err != nil:
+		return resp, fmt.Errorf("requesting scan of %s: %w", hash, err)
+	}
+
+	if err := json.Unmarshal(msg.Data, &resp); err != nil {
+		return resp, fmt.Errorf("decoding scan reply: %w", err)
+	}
+
+	return resp, nil
+}
+
+// responseResult converts a daemon scan reply to the types.Result a direct
+// lookup produces, so both paths share one output schema.
+func responseResult(resp queue.ScanResponse) types.Result {
+	hash, err := types.ParseHash(resp.Hash)
+	if err != nil {
+		hash = types.Hash{Value: resp.Hash}
+	}
+	status, _ := types.ParseStatus(resp.Status)
+
+	result := types.Result{
+		Hash:         hash,
+		Status:       status,
+		Error:        resp.Error,
+		ScannedAt:    resp.ScannedAt,
+		LookupTimeMs: resp.LookupTimeMs,
+		BloomHit:     resp.BloomHit,
+	}
+
+	if resp.Detection != "" {
+		threatType, _ := types.ParseThreatType(resp.Threat)
+		severity, _ := types.ParseSeverity(resp.Severity)
+		sig := &types.Signature{
+			DetectionName: resp.Detection,
+			ThreatType:    threatType,
+			Severity:      severity,
+			Source:        resp.Source,
+		}
+		switch hash.Type {
+		case types.HashTypeSHA256:
+			sig.SHA256 = hash.Value
+		case types.HashTypeSHA1:
+			sig.SHA1 = hash.Value
+		case types.HashTypeMD5:
+			sig.MD5 = hash.Value
+		}
+		result.Signature = sig
+	}
+
+	return result
+}
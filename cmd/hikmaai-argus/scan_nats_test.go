@@ -0,0 +1,262 @@
+// ABOUTME: Tests for CLI hash lookups over NATS against an embedded server
+// ABOUTME: Uses a stub responder for replies and checks no-daemon errors and direct fallback
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/queue"
+	"github.com/hikmaai-io/hikmaai-argus/internal/types"
+)
+
+const (
+	testScanSubject = "test.argus.scan"
+	eicarSHA256     = "275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f"
+)
+
+// startTestNATS runs an embedded NATS server and returns its client URL.
+func startTestNATS(t *testing.T) string {
+	t.Helper()
+
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	srv := natsserver.RunServer(&opts)
+	t.Cleanup(srv.Shutdown)
+
+	return srv.ClientURL()
+}
+
+// startStubResponder answers scan requests on testScanSubject, reporting
+// the EICAR hash as malware and every other hash as unknown.
+func startStubResponder(t *testing.T, url string) {
+	t.Helper()
+
+	conn, err := nats.Connect(url)
+	if err != nil {
+		t.Fatalf("nats.Connect() error = %v", err)
+	}
+	t.Cleanup(conn.Close)
+
+	_, err = conn.Subscribe(testScanSubject, func(msg *nats.Msg) {
+		var req queue.ScanRequest
+		if err := json.Unmarshal(msg.Data, &req); err != nil {
+			return
+		}
+
+		resp := queue.ScanResponse{Hash: req.Hash, HashType: "sha256", Status: "unknown"}
+		if req.Hash == eicarSHA256 {
+			resp.Status = "malware"
+			resp.Detection = "EICAR-Test-File"
+			resp.Threat = "testfile"
+			resp.Severity = "low"
+			resp.Source = "eicar"
+			resp.BloomHit = true
+		}
+
+		data, _ := json.Marshal(resp)
+		_ = msg.Respond(data)
+	})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	if err := conn.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+}
+
+func TestRunHashScan_NATS(t *testing.T) {
+	t.Parallel()
+
+	url := startTestNATS(t)
+	startStubResponder(t, url)
+	target := natsTarget{URL: url, Subject: testScanSubject, Timeout: 2 * time.Second}
+	otherHash := strings.Repeat("a", 64)
+
+	tests := []struct {
+		name     string
+		mode     hashScanMode
+		format   outputFormat
+		contains []string
+
+		// decode, if set, parses the output for checkResults.
+		decode func(t *testing.T, data []byte) []types.Result
+	}{
+		{
+			name: "forced nats",
+			mode: scanModeNATS,
+			contains: []string{
+				"Hash:   " + eicarSHA256 + " (sha256)",
+				"Status: malware",
+				"Detection: EICAR-Test-File",
+				"Status: unknown",
+			},
+		},
+		{
+			name:     "auto detect",
+			mode:     scanModeAuto,
+			contains: []string{"Status: malware", "Source:    eicar"},
+		},
+		{
+			name:   "json output",
+			mode:   scanModeNATS,
+			format: formatJSON,
+			decode: decodeJSONArray,
+		},
+		{
+			name:   "json lines output",
+			mode:   scanModeNATS,
+			format: formatJSONL,
+			decode: decodeJSONLines,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			err := runHashScan(context.Background(), newResultWriter(&out, tt.format), []string{eicarSHA256, otherHash}, tt.mode, target, t.TempDir())
+			if err != nil {
+				t.Fatalf("runHashScan() error = %v", err)
+			}
+
+			for _, want := range tt.contains {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, out.String())
+				}
+			}
+			if tt.decode != nil {
+				checkResults(t, tt.decode(t, out.Bytes()), otherHash)
+			}
+		})
+	}
+}
+
+// decodeJSONArray decodes data as a JSON array of types.Result.
+func decodeJSONArray(t *testing.T, data []byte) []types.Result {
+	t.Helper()
+
+	var results []types.Result
+	if err := json.Unmarshal(data, &results); err != nil {
+		t.Fatalf("output is not a JSON array of results: %v\n%s", err, data)
+	}
+	return results
+}
+
+// checkResults checks the stub responder's replies for the EICAR hash and
+// otherHash arrived in the same schema as direct lookups.
+func checkResults(t *testing.T, results []types.Result, otherHash string) {
+	t.Helper()
+
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+
+	eicar := results[0]
+	if eicar.Hash != (types.Hash{Type: types.HashTypeSHA256, Value: eicarSHA256}) || eicar.Status != types.StatusMalware {
+		t.Errorf("first result = %+v, want malware for %s", eicar, eicarSHA256)
+	}
+	sig := eicar.Signature
+	if sig == nil || sig.DetectionName != "EICAR-Test-File" || sig.ThreatType != types.ThreatTypeTestFile ||
+		sig.Severity != types.SeverityLow || sig.Source != "eicar" || sig.SHA256 != eicarSHA256 {
+		t.Errorf("first result signature = %+v, want the EICAR signature", sig)
+	}
+	if !eicar.BloomHit {
+		t.Error("first result BloomHit = false, want true")
+	}
+
+	other := results[1]
+	if other.Hash.Value != otherHash || other.Status != types.StatusUnknown || other.Signature != nil {
+		t.Errorf("second result = %+v, want unknown for %s", other, otherHash)
+	}
+}
+
+func TestRunHashScan_JSONLSchema(t *testing.T) {
+	t.Parallel()
+
+	url := startTestNATS(t)
+	startStubResponder(t, url)
+	target := natsTarget{URL: url, Subject: testScanSubject, Timeout: 2 * time.Second}
+
+	// Lines from the daemon and from a direct lookup both decode as
+	// types.Result with no fields left over.
+	for _, mode := range []hashScanMode{scanModeNATS, scanModeDirect} {
+		var out bytes.Buffer
+		if err := runHashScan(context.Background(), newResultWriter(&out, formatJSONL), []string{eicarSHA256}, mode, target, t.TempDir()); err != nil {
+			t.Fatalf("runHashScan(mode %d) error = %v", mode, err)
+		}
+
+		dec := json.NewDecoder(&out)
+		dec.DisallowUnknownFields()
+		var result types.Result
+		if err := dec.Decode(&result); err != nil {
+			t.Errorf("mode %d: line is not a types.Result: %v", mode, err)
+		}
+		if dec.More() {
+			t.Errorf("mode %d: want one line per hash", mode)
+		}
+	}
+}
+
+func TestRunHashScan_NoDaemon(t *testing.T) {
+	t.Parallel()
+
+	// A server with nothing subscribed to the scan subject.
+	url := startTestNATS(t)
+
+	tests := []struct {
+		name   string
+		target natsTarget
+	}{
+		{
+			name:   "no responders",
+			target: natsTarget{URL: url, Subject: testScanSubject, Timeout: 2 * time.Second},
+		},
+		{
+			name:   "server unreachable",
+			target: natsTarget{URL: "nats://127.0.0.1:1", Subject: testScanSubject, Timeout: time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			err := runHashScan(context.Background(), newResultWriter(&out, formatText), []string{eicarSHA256}, scanModeNATS, tt.target, t.TempDir())
+			if !errors.Is(err, errNATSUnavailable) {
+				t.Fatalf("runHashScan() error = %v, want %v", err, errNATSUnavailable)
+			}
+			if out.Len() != 0 {
+				t.Errorf("unexpected output on failure:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestRunHashScan_FallsBackToDirect(t *testing.T) {
+	t.Parallel()
+
+	url := startTestNATS(t)
+	target := natsTarget{URL: url, Subject: testScanSubject, Timeout: 2 * time.Second}
+
+	// Nothing answers on NATS, so the empty local database is used.
+	var out bytes.Buffer
+	if err := runHashScan(context.Background(), newResultWriter(&out, formatText), []string{eicarSHA256}, scanModeAuto, target, t.TempDir()); err != nil {
+		t.Fatalf("runHashScan() error = %v", err)
+	}
+
+	if !strings.Contains(out.String(), "Status: unknown") {
+		t.Errorf("expected a direct lookup result, got:\n%s", out.String())
+	}
+}
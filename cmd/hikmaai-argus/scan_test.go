@@ -0,0 +1,111 @@
+// ABOUTME: Tests for hash lookup output formats of the scan command
+// ABOUTME: Checks JSON lines streaming, the JSON array default, and --output files
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/types"
+)
+
+// testHashes mixes valid hashes of each type with an invalid one.
+var testHashes = []string{
+	eicarSHA256,
+	"3395856ce81f2b7382dee72602f798b642f14140",
+	"44d88612fea8a8f36de82e1278abb02f",
+	"not-a-hash",
+	strings.Repeat("b", 64),
+}
+
+// decodeJSONLines decodes each line of data as a types.Result.
+func decodeJSONLines(t *testing.T, data []byte) []types.Result {
+	t.Helper()
+
+	var results []types.Result
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		var r types.Result
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("line %q is not a JSON object: %v", sc.Text(), err)
+		}
+		results = append(results, r)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return results
+}
+
+func TestScanDirect_JSONL(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	if err := scanDirect(context.Background(), newResultWriter(&out, formatJSONL), testHashes, t.TempDir()); err != nil {
+		t.Fatalf("scanDirect() error = %v", err)
+	}
+
+	results := decodeJSONLines(t, out.Bytes())
+	if len(results) != len(testHashes) {
+		t.Fatalf("got %d lines, want one per hash (%d):\n%s", len(results), len(testHashes), out.String())
+	}
+	for i, r := range results {
+		if !strings.EqualFold(r.Hash.Value, testHashes[i]) {
+			t.Errorf("line %d hash = %q, want %q", i, r.Hash.Value, testHashes[i])
+		}
+	}
+	if results[3].Status != types.StatusError {
+		t.Errorf("invalid hash status = %v, want %v", results[3].Status, types.StatusError)
+	}
+}
+
+func TestScanDirect_JSONArray(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	if err := scanDirect(context.Background(), newResultWriter(&out, formatJSON), testHashes, t.TempDir()); err != nil {
+		t.Fatalf("scanDirect() error = %v", err)
+	}
+
+	var results []types.Result
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, out.String())
+	}
+	if len(results) != len(testHashes) {
+		t.Errorf("got %d results, want %d", len(results), len(testHashes))
+	}
+}
+
+func TestScanCmd_OutputFile(t *testing.T) {
+	t.Parallel()
+
+	outPath := filepath.Join(t.TempDir(), "results.jsonl")
+
+	cmd := newScanCmd()
+	cmd.SetArgs([]string{
+		"--direct",
+		"--jsonl",
+		"--output", outPath,
+		"--data-dir", t.TempDir(),
+		"--batch", strings.Join(testHashes, ","),
+	})
+	if err := cmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("scan error = %v", err)
+	}
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decodeJSONLines(t, data); len(got) != len(testHashes) {
+		t.Errorf("got %d lines, want %d:\n%s", len(got), len(testHashes), data)
+	}
+}
@@ -1,26 +1,205 @@
 // ABOUTME: Status command for checking daemon health
-// ABOUTME: Shows daemon status, connection info, and statistics
+// ABOUTME: Queries the daemon health endpoint and prints signatures, queue, and DB update status
 
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/api"
 )
 
+// errDaemonDegraded is returned when the daemon reports a degraded status.
+var errDaemonDegraded = errors.New("daemon status is degraded")
+
+// healthResponse mirrors the payload of GET /api/v1/health.
+type healthResponse struct {
+	Status    string                     `json:"status"`
+	Timestamp time.Time                  `json:"timestamp"`
+	Checks    map[string]json.RawMessage `json:"checks"`
+	Stats     map[string]int64           `json:"stats"`
+}
+
 func newStatusCmd() *cobra.Command {
-	return &cobra.Command{
+	var (
+		httpAddr string
+		asJSON   bool
+		timeout  time.Duration
+	)
+
+	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show daemon status",
-		Long:  `Check if the hikmaai-argus daemon is running and show status information.`,
+		Long: `Query a running hikmaai-argus daemon's health endpoint and show its status,
+signature count, worker queue length, and database update status.
+
+Exits non-zero when the daemon cannot be reached or reports a degraded status.
+
+Examples:
+  hikmaai-argus status
+  hikmaai-argus status --http-addr argus.internal:8080
+  hikmaai-argus status --json | jq .checks`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// TODO: Check NATS connection and daemon health endpoint.
-			fmt.Println("hikmaai-argus daemon status:")
-			fmt.Println("  Daemon:  not connected (NATS check not implemented)")
-			fmt.Println("  Mode:    CLI-only (direct database access)")
-			fmt.Printf("  Version: %s\n", version)
-			return nil
+			cmd.SilenceUsage = true
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+			defer cancel()
+
+			return runStatus(ctx, os.Stdout, httpAddr, asJSON)
 		},
 	}
+
+	cmd.Flags().StringVar(&httpAddr, "http-addr", ":8080", "daemon HTTP address (host:port or URL)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the raw health response as JSON")
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "request timeout")
+
+	return cmd
+}
+
+// runStatus fetches the daemon health from httpAddr and writes it to w.
+func runStatus(ctx context.Context, w io.Writer, httpAddr string, asJSON bool) error {
+	baseURL := daemonBaseURL(httpAddr)
+
+	body, err := fetchHealth(ctx, baseURL)
+	if err != nil {
+		return err
+	}
+
+	var health healthResponse
+	if err := json.Unmarshal(body, &health); err != nil {
+		return fmt.Errorf("decoding health response: %w", err)
+	}
+
+	if asJSON {
+		if _, err := w.Write(body); err != nil {
+			return err
+		}
+	} else {
+		printHealth(w, baseURL, &health)
+	}
+
+	if health.Status == "degraded" {
+		return errDaemonDegraded
+	}
+	return nil
+}
+
+// daemonBaseURL turns an address like ":8080" or "host:8080" into a URL.
+func daemonBaseURL(addr string) string {
+	if strings.Contains(addr, "://") {
+		return strings.TrimSuffix(addr, "/")
+	}
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	return "http://" + addr
+}
+
+// fetchHealth returns the body of GET /api/v1/health, including the
+// per-source signature counts.
+func fetchHealth(ctx context.Context, baseURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/v1/health?detailed=1", nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("daemon not reachable at %s: %w", baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("reading health response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("health check returned %s", resp.Status)
+	}
+
+	return body, nil
+}
+
+// printHealth writes a human-readable summary of health.
+func printHealth(w io.Writer, baseURL string, health *healthResponse) {
+	now := health.Timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	fmt.Fprintf(w, "hikmaai-argus daemon status (%s):\n", baseURL)
+	fmt.Fprintf(w, "  Status:      %s\n", health.Status)
+	if n, ok := health.Stats["signatures"]; ok {
+		fmt.Fprintf(w, "  Signatures:  %d\n", n)
+	}
+	if n, ok := health.Stats["queue_length"]; ok {
+		fmt.Fprintf(w, "  Queue:       %d\n", n)
+	}
+	if n, ok := health.Stats["jobs"]; ok {
+		fmt.Fprintf(w, "  Jobs:        %d\n", n)
+	}
+
+	// Surface failing checks; healthy ones are summarized above.
+	for _, name := range sortedKeys(health.Checks) {
+		var check string
+		if json.Unmarshal(health.Checks[name], &check) == nil && !strings.HasPrefix(check, "ok") {
+			fmt.Fprintf(w, "  %s: %s\n", name, check)
+		}
+	}
+
+	var updates map[string]*api.DBUpdateStatus
+	if raw, ok := health.Checks["db_updates"]; !ok || json.Unmarshal(raw, &updates) != nil || len(updates) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "DB updates:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  NAME\tSTATUS\tREADY\tLAST UPDATE\tNEXT SCHEDULED\tLAST ERROR")
+	for _, name := range sortedKeys(updates) {
+		u := updates[name]
+		last, next := "never", "-"
+		if u.LastUpdate != nil {
+			last = formatAge(now.Sub(*u.LastUpdate)) + " ago"
+		}
+		if u.NextScheduled != nil {
+			next = "in " + formatAge(u.NextScheduled.Sub(now))
+		}
+		lastErr := u.LastError
+		if lastErr == "" {
+			lastErr = "-"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%t\t%s\t%s\t%s\n", name, u.Status, u.Ready, last, next, lastErr)
+	}
+	tw.Flush()
+}
+
+// formatAge rounds d for display, clamping negative durations to zero.
+func formatAge(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	return d.Round(time.Second).String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,129 @@
+// ABOUTME: Unit tests for the status command against a fake daemon
+// ABOUTME: Covers the printed summary, JSON passthrough, and degraded exit status
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const healthPayload = `{
+  "status": %q,
+  "timestamp": "2024-01-01T12:00:00Z",
+  "checks": {
+    "engine": %q,
+    "worker": "ok (queue: 3)",
+    "db_updates": {
+      "clamav": {"name": "clamav", "status": "idle", "ready": true,
+        "last_update": "2024-01-01T11:55:00Z", "next_scheduled": "2024-01-01T12:55:00Z"},
+      "trivy": {"name": "trivy", "status": "failed", "ready": false, "last_error": "download failed"}
+    }
+  },
+  "stats": {"signatures": 1234, "queue_length": 3}
+}`
+
+func TestRunStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		status   string
+		engine   string
+		asJSON   bool
+		wantErr  error
+		contains []string
+	}{
+		{
+			name:   "healthy",
+			status: "ok",
+			engine: "ok (signatures: 1234)",
+			contains: []string{
+				"Status:      ok",
+				"Signatures:  1234",
+				"Queue:       3",
+				"clamav  idle    true   5m0s ago     in 55m0s        -",
+				"trivy   failed  false  never        -               download failed",
+			},
+		},
+		{
+			name:     "degraded",
+			status:   "degraded",
+			engine:   "error: database closed",
+			wantErr:  errDaemonDegraded,
+			contains: []string{"Status:      degraded", "engine: error: database closed"},
+		},
+		{
+			name:     "json passthrough",
+			status:   "ok",
+			engine:   "ok (signatures: 1234)",
+			asJSON:   true,
+			contains: []string{`"signatures": 1234`, `"last_error": "download failed"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/health" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, healthPayload, tt.status, tt.engine)
+			}))
+			defer srv.Close()
+
+			var out bytes.Buffer
+			err := runStatus(context.Background(), &out, srv.URL, tt.asJSON)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("runStatus() error = %v, want %v", err, tt.wantErr)
+			}
+
+			for _, want := range tt.contains {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRunStatus_Unreachable(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	var out bytes.Buffer
+	if err := runStatus(context.Background(), &out, srv.URL, false); err == nil {
+		t.Error("runStatus() against a stopped server should fail")
+	}
+}
+
+func TestDaemonBaseURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{addr: ":8080", want: "http://localhost:8080"},
+		{addr: "argus:9090", want: "http://argus:9090"},
+		{addr: "https://argus.example.com/", want: "https://argus.example.com"},
+	}
+
+	for _, tt := range tests {
+		if got := daemonBaseURL(tt.addr); got != tt.want {
+			t.Errorf("daemonBaseURL(%q) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
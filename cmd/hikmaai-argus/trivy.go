@@ -37,6 +37,10 @@ Examples:
   # Scan an archive
   hikmaai-argus trivy scan /path/to/app.zip
 
+  # Scan a container image or git repository (local mode)
+  hikmaai-argus trivy scan alpine:3.19
+  hikmaai-argus trivy scan git::https://github.com/org/repo
+
   # Scan with server mode
   hikmaai-argus trivy scan --mode server --server http://trivy:4954 /path/to/project
 
@@ -44,7 +48,16 @@ Examples:
   hikmaai-argus trivy scan --mode server --server http://trivy:4954 --packages "requests:2.25.0:pip"
 
   # Output as JSON
-  hikmaai-argus trivy scan /path/to/project --json`,
+  hikmaai-argus trivy scan /path/to/project --json
+
+  # Output as SARIF for code scanning dashboards
+  hikmaai-argus trivy scan /path/to/project --format sarif
+
+  # Also write a CycloneDX SBOM
+  hikmaai-argus trivy scan /path/to/project --sbom sbom.cdx.json
+
+  # Fail a CI build on high or critical vulnerabilities or any secret
+  hikmaai-argus trivy scan /path/to/project --fail-on high,secrets`,
 	}
 
 	cmd.AddCommand(newTrivyScanCmd())
@@ -54,25 +67,37 @@ Examples:
 
 func newTrivyScanCmd() *cobra.Command {
 	var (
-		mode           string
-		packages       string
-		serverURL      string
-		binary         string
-		severityFilter string
-		scanSecrets    bool
-		skipDBUpdate   bool
-		timeout        time.Duration
-		outputJSON     bool
+		mode              string
+		packages          string
+		serverURL         string
+		binary            string
+		severityFilter    string
+		scanSecrets       bool
+		scanLicenses      bool
+		skipDBUpdate      bool
+		timeout           time.Duration
+		outputJSON        bool
+		format            string
+		ignoreFile        string
+		secretConfig      string
+		sbomPath          string
+		extendedManifests bool
+		skipIndirect      bool
+		targetType        string
+		failOn            string
+		exitCode          int
 	)
 
 	cmd := &cobra.Command{
-		Use:   "scan [path]",
+		Use:   "scan [target]",
 		Short: "Scan for vulnerabilities and secrets",
 		Long: `Scan a directory or archive for known vulnerabilities and secrets.
 
 LOCAL MODE (default):
   Uses the local trivy binary to scan the filesystem directly.
   Requires trivy to be installed (brew install trivy, apt install trivy, etc.)
+  Container images (alpine:3.19) and git repositories (git::https://...)
+  can also be scanned; --target-type auto detects the kind of target.
 
 SERVER MODE:
   Extracts package metadata and sends to remote Trivy server via Twirp.
@@ -90,15 +115,47 @@ Examples:
   # Local mode - scan archive
   hikmaai-argus trivy scan /path/to/app.zip
 
+  # Local mode - scan a container image or git repository
+  hikmaai-argus trivy scan alpine:3.19
+  hikmaai-argus trivy scan git::https://github.com/org/repo
+  hikmaai-argus trivy scan --target-type image myapp
+
   # Server mode - scan path
   hikmaai-argus trivy scan --mode server --server http://trivy:4954 /path/to/project
 
   # Server mode - scan specific packages
-  hikmaai-argus trivy scan --mode server --server http://trivy:4954 --packages "requests:2.25.0:pip"`,
+  hikmaai-argus trivy scan --mode server --server http://trivy:4954 --packages "requests:2.25.0:pip"
+
+  # Also report package licenses
+  hikmaai-argus trivy scan --licenses /path/to/project
+
+  # Add custom secret rules (local mode)
+  hikmaai-argus trivy scan --secret-config trivy-secret.yaml /path/to/project`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 
+			// --json is shorthand for --format json.
+			if outputJSON {
+				if cmd.Flags().Changed("format") && format != trivyFormatJSON {
+					return fmt.Errorf("--json conflicts with --format %s", format)
+				}
+				format = trivyFormatJSON
+			}
+			if !isValidTrivyFormat(format) {
+				return fmt.Errorf("invalid --format %q; expected text, json, or sarif", format)
+			}
+			if !trivy.IsValidTargetType(targetType) {
+				return fmt.Errorf("invalid --target-type %q; expected auto, fs, image, or repo", targetType)
+			}
+			if secretConfig != "" && !scanSecrets {
+				return fmt.Errorf("--secret-config requires secret scanning; remove --secrets=false")
+			}
+			policy, err := newFailPolicy(failOn, exitCode)
+			if err != nil {
+				return err
+			}
+
 			// Parse severity filter (default: HIGH, CRITICAL).
 			sevFilter := parseSeverityFilter(severityFilter)
 			if sevFilter == nil {
@@ -106,8 +163,13 @@ Examples:
 			}
 
 			opts := trivy.ScanOptions{
-				SeverityFilter: sevFilter,
-				ScanSecrets:    scanSecrets,
+				SeverityFilter:    sevFilter,
+				ScanSecrets:       scanSecrets,
+				ScanLicenses:      scanLicenses,
+				IgnoreFile:        ignoreFile,
+				SecretConfigPath:  secretConfig,
+				ExtendedManifests: extendedManifests,
+				SkipGoIndirect:    skipIndirect,
 			}
 
 			// Validate mode-specific requirements.
@@ -115,15 +177,23 @@ Examples:
 				if serverURL == "" {
 					return fmt.Errorf("--server is required for server mode")
 				}
-				return runTrivyServerScan(ctx, args, serverURL, packages, opts, timeout, outputJSON)
+				if secretConfig != "" {
+					return fmt.Errorf("--secret-config is only supported in local mode")
+				}
+				if targetType != trivy.TargetTypeAuto && targetType != trivy.TargetTypeFS {
+					return fmt.Errorf("--target-type %s is only supported in local mode", targetType)
+				}
+				cmd.SilenceUsage = true
+				return runTrivyServerScan(ctx, args, serverURL, packages, opts, timeout, format, sbomPath, policy)
 			}
 
 			// Local mode.
 			if len(args) == 0 {
-				return fmt.Errorf("path is required for local mode")
+				return fmt.Errorf("target is required for local mode")
 			}
 
-			return runTrivyLocalScan(ctx, args[0], binary, skipDBUpdate, opts, timeout, outputJSON)
+			cmd.SilenceUsage = true
+			return runTrivyLocalScan(ctx, args[0], targetType, binary, skipDBUpdate, opts, timeout, format, sbomPath, policy)
 		},
 	}
 
@@ -133,13 +203,39 @@ Examples:
 	cmd.Flags().StringVarP(&packages, "packages", "p", "", "comma-separated packages (name:version:ecosystem) for server mode")
 	cmd.Flags().StringVar(&severityFilter, "severity", "", "severity filter (default: HIGH,CRITICAL)")
 	cmd.Flags().BoolVar(&scanSecrets, "secrets", true, "scan for secrets (default: true)")
+	cmd.Flags().BoolVar(&scanLicenses, "licenses", false, "report package licenses")
 	cmd.Flags().BoolVar(&skipDBUpdate, "skip-db-update", false, "skip updating vulnerability database (local mode)")
 	cmd.Flags().DurationVar(&timeout, "timeout", 5*time.Minute, "scan timeout")
-	cmd.Flags().BoolVarP(&outputJSON, "json", "j", false, "output as JSON")
+	cmd.Flags().StringVar(&ignoreFile, "ignore-file", "", "path to .trivyignore file (default: .trivyignore at scan root)")
+	cmd.Flags().StringVar(&secretConfig, "secret-config", "", "path to a trivy secret rule file with custom rules (local mode)")
+	cmd.Flags().BoolVarP(&outputJSON, "json", "j", false, "output as JSON (shorthand for --format json)")
+	cmd.Flags().StringVarP(&format, "format", "f", trivyFormatText, "output format: text, json, or sarif")
+	cmd.Flags().BoolVar(&extendedManifests, "extended-manifests", false, "also read requirements*.txt variants and sniff unrecognized lockfiles (server mode and --sbom)")
+	cmd.Flags().BoolVar(&skipIndirect, "skip-indirect", false, "leave out go.mod requirements marked // indirect (server mode and --sbom)")
+	cmd.Flags().StringVar(&sbomPath, "sbom", "", "write a CycloneDX 1.5 SBOM of the scanned packages to this file")
+	cmd.Flags().StringVar(&targetType, "target-type", trivy.TargetTypeAuto, "target type for local mode: auto, fs, image, or repo")
+	cmd.Flags().StringVar(&failOn, "fail-on", "", "fail when findings reach this severity (low, medium, high, critical) and/or on secrets, e.g. high,secrets")
+	cmd.Flags().IntVar(&exitCode, "exit-code", 0, "exit code for findings over --fail-on (default 1); alone, fails on any finding")
 
 	return cmd
 }
 
+// Output formats for trivy scan results.
+const (
+	trivyFormatText  = "text"
+	trivyFormatJSON  = "json"
+	trivyFormatSARIF = "sarif"
+)
+
+func isValidTrivyFormat(format string) bool {
+	switch format {
+	case trivyFormatText, trivyFormatJSON, trivyFormatSARIF:
+		return true
+	default:
+		return false
+	}
+}
+
 func parseSeverityFilter(severityFilter string) []string {
 	if severityFilter == "" {
 		return nil
@@ -155,7 +251,15 @@ func parseSeverityFilter(severityFilter string) []string {
 	return sevFilter
 }
 
-func runTrivyLocalScan(ctx context.Context, path, binary string, skipDBUpdate bool, opts trivy.ScanOptions, timeout time.Duration, outputJSON bool) error {
+func runTrivyLocalScan(ctx context.Context, target, targetType, binary string, skipDBUpdate bool, opts trivy.ScanOptions, timeout time.Duration, format, sbomPath string, policy failPolicy) error {
+	targetType, target, err := trivy.ResolveTarget(target, targetType)
+	if err != nil {
+		return err
+	}
+	if sbomPath != "" && targetType != trivy.TargetTypeFS {
+		return fmt.Errorf("--sbom is only supported for filesystem targets")
+	}
+
 	// Create local scanner.
 	scanner := trivy.NewUnifiedScanner(&config.TrivyConfig{
 		Mode:         "local",
@@ -170,15 +274,29 @@ func runTrivyLocalScan(ctx context.Context, path, binary string, skipDBUpdate bo
 	}
 
 	// Run scan.
-	result, err := scanner.ScanPath(ctx, path, opts)
+	result, err := scanner.ScanTarget(ctx, target, targetType, opts)
 	if err != nil {
 		return fmt.Errorf("scan failed: %w", err)
 	}
 
-	return outputTrivyResult(result, outputJSON)
+	if sbomPath != "" {
+		pkgs, err := trivy.ScanPathForPackagesWithOptions(target, opts.ManifestOptions())
+		if err != nil {
+			return fmt.Errorf("listing packages for SBOM: %w", err)
+		}
+		if err := writeSBOM(sbomPath, pkgs, result); err != nil {
+			return err
+		}
+	}
+
+	if err := outputTrivyResult(result, format); err != nil {
+		return err
+	}
+
+	return policy.check(nil, result)
 }
 
-func runTrivyServerScan(ctx context.Context, args []string, serverURL, packages string, opts trivy.ScanOptions, timeout time.Duration, outputJSON bool) error {
+func runTrivyServerScan(ctx context.Context, args []string, serverURL, packages string, opts trivy.ScanOptions, timeout time.Duration, format, sbomPath string, policy failPolicy) error {
 	// Create server scanner.
 	scanner := trivy.NewUnifiedScanner(&config.TrivyConfig{
 		Mode:      "server",
@@ -187,11 +305,12 @@ func runTrivyServerScan(ctx context.Context, args []string, serverURL, packages
 	})
 
 	var result *trivy.ScanResult
+	var pkgs []trivy.Package
 	var err error
 
 	if packages != "" {
 		// Scan specific packages.
-		pkgs, err := parsePackages(packages)
+		pkgs, err = parsePackages(packages)
 		if err != nil {
 			return err
 		}
@@ -205,22 +324,57 @@ func runTrivyServerScan(ctx context.Context, args []string, serverURL, packages
 		if err != nil {
 			return fmt.Errorf("scan failed: %w", err)
 		}
+		if sbomPath != "" {
+			if pkgs, err = trivy.ScanPathForPackagesWithOptions(args[0], opts.ManifestOptions()); err != nil {
+				return fmt.Errorf("listing packages for SBOM: %w", err)
+			}
+		}
 	} else {
 		return fmt.Errorf("either path or --packages is required")
 	}
 
-	return outputTrivyResult(result, outputJSON)
+	if sbomPath != "" {
+		if err := writeSBOM(sbomPath, pkgs, result); err != nil {
+			return err
+		}
+	}
+
+	if err := outputTrivyResult(result, format); err != nil {
+		return err
+	}
+
+	return policy.check(nil, result)
+}
+
+// writeSBOM writes a CycloneDX SBOM of pkgs and the result's vulnerabilities.
+func writeSBOM(path string, pkgs []trivy.Package, result *trivy.ScanResult) error {
+	data, err := trivy.ToCycloneDX(pkgs, result.Vulnerabilities)
+	if err != nil {
+		return fmt.Errorf("building SBOM: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing SBOM: %w", err)
+	}
+	return nil
 }
 
-func outputTrivyResult(result *trivy.ScanResult, outputJSON bool) error {
-	if outputJSON {
+func outputTrivyResult(result *trivy.ScanResult, format string) error {
+	switch format {
+	case trivyFormatJSON:
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		return enc.Encode(result)
+	case trivyFormatSARIF:
+		data, err := trivy.ToSARIF(result)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	default:
+		printTrivyResult(result)
+		return nil
 	}
-
-	printTrivyResult(result)
-	return nil
 }
 
 func parsePackages(input string) ([]trivy.Package, error) {
@@ -258,6 +412,8 @@ func parsePackages(input string) ([]trivy.Package, error) {
 }
 
 func printTrivyResult(result *trivy.ScanResult) {
+	result.SortBySeverity()
+
 	fmt.Println("=========== TRIVY DEPENDENCY SCAN ===========")
 	fmt.Printf("Packages Scanned: %d\n", result.Summary.PackagesScanned)
 	fmt.Printf("Scan Time:        %.2fms\n", result.ScanTimeMs)
@@ -287,6 +443,9 @@ func printTrivyResult(result *trivy.ScanResult) {
 		for _, vuln := range result.Vulnerabilities {
 			fmt.Printf("\n%s [%s]\n", vuln.CVEID, vuln.Severity)
 			fmt.Printf("  Package: %s@%s (%s)\n", vuln.Package, vuln.Version, vuln.Ecosystem)
+			if vuln.CVSSScore > 0 {
+				fmt.Printf("  CVSS:    %.1f\n", vuln.CVSSScore)
+			}
 			if vuln.Title != "" {
 				fmt.Printf("  Title:   %s\n", vuln.Title)
 			}
@@ -332,5 +491,42 @@ func printTrivyResult(result *trivy.ScanResult) {
 		fmt.Println("No secrets found.")
 	}
 
+	// License summary, only present when license scanning was requested.
+	if result.LicenseSummary != nil && result.LicenseSummary.TotalLicenses > 0 {
+		fmt.Println()
+		fmt.Println("----------- LICENSE SUMMARY -----------")
+		fmt.Printf("Total:    %d\n", result.LicenseSummary.TotalLicenses)
+		if result.LicenseSummary.Critical > 0 {
+			fmt.Printf("Critical: %d\n", result.LicenseSummary.Critical)
+		}
+		if result.LicenseSummary.High > 0 {
+			fmt.Printf("High:     %d\n", result.LicenseSummary.High)
+		}
+		if result.LicenseSummary.Medium > 0 {
+			fmt.Printf("Medium:   %d\n", result.LicenseSummary.Medium)
+		}
+		if result.LicenseSummary.Low > 0 {
+			fmt.Printf("Low:      %d\n", result.LicenseSummary.Low)
+		}
+		if result.LicenseSummary.Unknown > 0 {
+			fmt.Printf("Unknown:  %d\n", result.LicenseSummary.Unknown)
+		}
+		fmt.Println()
+
+		fmt.Println("----------- LICENSES -----------")
+		for _, lic := range result.Licenses {
+			fmt.Printf("\n%s [%s]\n", lic.Name, lic.Severity)
+			if lic.Package != "" {
+				fmt.Printf("  Package:  %s\n", lic.Package)
+			}
+			if lic.FilePath != "" {
+				fmt.Printf("  File:     %s\n", lic.FilePath)
+			}
+			if lic.Category != "" {
+				fmt.Printf("  Category: %s\n", lic.Category)
+			}
+		}
+	}
+
 	fmt.Println()
 }
@@ -0,0 +1,53 @@
+// ABOUTME: Tests for the trivy scan command's flag validation
+// ABOUTME: Covers conflicting flag combinations rejected before any scan runs
+
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTrivyScanCmd_RejectsConflictingFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "secret config without secret scanning",
+			args:    []string{"--secrets=false", "--secret-config", "trivy-secret.yaml", "."},
+			wantErr: "--secret-config requires secret scanning",
+		},
+		{
+			name:    "json with sarif format",
+			args:    []string{"--json", "--format", "sarif", "."},
+			wantErr: "--json conflicts with --format sarif",
+		},
+		{
+			name:    "json with text format",
+			args:    []string{"-j", "-f", "text", "."},
+			wantErr: "--json conflicts with --format text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := newTrivyScanCmd()
+			cmd.SetArgs(tt.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+
+			err := cmd.ExecuteContext(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// ABOUTME: MIME type policy for uploaded files, detected from their leading bytes
+// ABOUTME: Supports exact types and "type/*" wildcards in allow and deny lists
+
+package api
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// sniffLen is the number of leading bytes http.DetectContentType inspects.
+const sniffLen = 512
+
+// contentTypePolicy decides which detected MIME types may be uploaded.
+type contentTypePolicy struct {
+	allowed []string
+	denied  []string
+}
+
+// newContentTypePolicy normalizes the configured allow and deny lists.
+func newContentTypePolicy(allowed, denied []string) contentTypePolicy {
+	return contentTypePolicy{
+		allowed: normalizeMediaTypes(allowed),
+		denied:  normalizeMediaTypes(denied),
+	}
+}
+
+// Allows reports whether mediaType may be uploaded. A denied type is always
+// rejected; when an allowlist is configured, the type must also be on it.
+func (p contentTypePolicy) Allows(mediaType string) bool {
+	if matchesAnyMediaType(p.denied, mediaType) {
+		return false
+	}
+	return len(p.allowed) == 0 || matchesAnyMediaType(p.allowed, mediaType)
+}
+
+// detectContentType sniffs the MIME type of r from its first bytes. It
+// returns the media type without parameters and a reader that still yields
+// the whole content.
+func detectContentType(r io.Reader) (string, io.Reader, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, err
+	}
+	head = head[:n]
+
+	mediaType := http.DetectContentType(head)
+	if parsed, _, err := mime.ParseMediaType(mediaType); err == nil {
+		mediaType = parsed
+	}
+
+	return mediaType, io.MultiReader(bytes.NewReader(head), r), nil
+}
+
+func normalizeMediaTypes(types []string) []string {
+	out := make([]string, 0, len(types))
+	for _, t := range types {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func matchesAnyMediaType(patterns []string, mediaType string) bool {
+	mediaType = strings.ToLower(mediaType)
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if pattern == mediaType {
+			return true
+		}
+	}
+	return false
+}
@@ -8,17 +8,21 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/hikmaai-io/hikmaai-argus/internal/engine"
+	"github.com/hikmaai-io/hikmaai-argus/internal/observability"
+	"github.com/hikmaai-io/hikmaai-argus/internal/redis"
 	"github.com/hikmaai-io/hikmaai-argus/internal/scanner"
 	"github.com/hikmaai-io/hikmaai-argus/internal/trivy"
 	"github.com/hikmaai-io/hikmaai-argus/internal/types"
@@ -29,6 +33,12 @@ type DBUpdateStatusProvider interface {
 	GetStatus() map[string]*DBUpdateStatus
 }
 
+// RedisHealthChecker reports the state of the Argus worker's Redis
+// connection as last observed by the worker, without contacting Redis.
+type RedisHealthChecker interface {
+	RedisHealth() redis.Health
+}
+
 // DBUpdateStatus contains the status of a single database updater.
 type DBUpdateStatus struct {
 	Name          string     `json:"name"`
@@ -49,8 +59,19 @@ type Handler struct {
 	uploadDir        string
 	maxFileSize      int64
 	trivyScanner     *trivy.Scanner
-	trivyJobStore    *TrivyJobStore
+	trivyJobStore    TrivyJobStorage
 	dbUpdateProvider DBUpdateStatusProvider
+	redis            RedisHealthChecker
+	metrics          *observability.Metrics
+	maxBodySize      int64
+	requestTimeout   time.Duration
+	uploadTypes      contentTypePolicy
+
+	// trivyCancels holds the cancel func of each pending or running
+	// dependency scan. trivyMu also serializes the status changes of those
+	// jobs, so a cancellation cannot be overwritten by the scan finishing.
+	trivyMu      sync.Mutex
+	trivyCancels map[string]context.CancelFunc
 }
 
 // HandlerConfig holds configuration for API handlers.
@@ -62,15 +83,53 @@ type HandlerConfig struct {
 	UploadDir        string
 	MaxFileSize      int64
 	TrivyScanner     *trivy.Scanner
-	TrivyJobStore    *TrivyJobStore
+	TrivyJobStore    TrivyJobStorage
 	DBUpdateProvider DBUpdateStatusProvider
+
+	// Redis adds a Redis connectivity check to the health endpoint; a
+	// failing check reports the daemon as degraded.
+	Redis RedisHealthChecker
+
+	// Metrics enables the /metrics endpoint and Trivy job counters.
+	Metrics *observability.Metrics
+
+	// MaxBodySize caps JSON request bodies. Defaults to DefaultMaxBodySize.
+	MaxBodySize int64
+
+	// RequestTimeout bounds how long a request may run before it is
+	// answered with 408. File uploads are exempt, since their duration
+	// depends on the client's bandwidth. Defaults to DefaultRequestTimeout;
+	// a negative value disables the timeout.
+	RequestTimeout time.Duration
+
+	// AllowedContentTypes restricts file uploads to these MIME types, as
+	// detected from the first 512 bytes of the file. Entries may be exact
+	// ("application/zip") or wildcards ("text/*"). Empty allows any type.
+	AllowedContentTypes []string
+
+	// DeniedContentTypes rejects file uploads of these MIME types, using the
+	// same matching as AllowedContentTypes. It takes precedence over the
+	// allowlist.
+	DeniedContentTypes []string
 }
 
+// Request limits applied when HandlerConfig leaves them unset.
+const (
+	DefaultMaxBodySize    = 5 * 1024 * 1024
+	DefaultRequestTimeout = 30 * time.Second
+)
+
 // NewHandler creates a new API handler.
 func NewHandler(cfg HandlerConfig) *Handler {
 	if cfg.MaxFileSize <= 0 {
 		cfg.MaxFileSize = 100 * 1024 * 1024 // 100MB default
 	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = DefaultMaxBodySize
+	}
+	if cfg.RequestTimeout == 0 {
+		cfg.RequestTimeout = DefaultRequestTimeout
+	}
 	return &Handler{
 		engine:           cfg.Engine,
 		jobStore:         cfg.JobStore,
@@ -81,19 +140,42 @@ func NewHandler(cfg HandlerConfig) *Handler {
 		trivyScanner:     cfg.TrivyScanner,
 		trivyJobStore:    cfg.TrivyJobStore,
 		dbUpdateProvider: cfg.DBUpdateProvider,
+		redis:            cfg.Redis,
+		metrics:          cfg.Metrics,
+		maxBodySize:      cfg.MaxBodySize,
+		requestTimeout:   cfg.RequestTimeout,
+		uploadTypes:      newContentTypePolicy(cfg.AllowedContentTypes, cfg.DeniedContentTypes),
+		trivyCancels:     make(map[string]context.CancelFunc),
 	}
 }
 
 // RegisterRoutes registers all API routes on the given mux.
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("GET /api/v1/files/{hash}", h.HandleGetFileByHash)
+	mux.Handle("GET /api/v1/files/{hash}", h.withTimeout(h.HandleGetFileByHash))
+	mux.Handle("POST /api/v1/files/lookup", h.withTimeout(h.HandleBatchLookup))
 	mux.HandleFunc("POST /api/v1/files", h.HandleUploadFile)
-	mux.HandleFunc("GET /api/v1/jobs/{id}", h.HandleGetJob)
-	mux.HandleFunc("GET /api/v1/health", h.HandleHealth)
+	mux.Handle("GET /api/v1/jobs", h.withTimeout(h.HandleListJobs))
+	mux.Handle("GET /api/v1/jobs/{id}", h.withTimeout(h.HandleGetJob))
+	mux.Handle("GET /api/v1/health", h.withTimeout(h.HandleHealth))
 
 	// Trivy dependency scanning endpoints.
-	mux.HandleFunc("POST /api/v1/dependencies/scan", h.HandleDependencyScan)
-	mux.HandleFunc("GET /api/v1/dependencies/jobs/{id}", h.HandleGetDependencyJob)
+	mux.Handle("POST /api/v1/dependencies/scan", h.withTimeout(h.HandleDependencyScan))
+	mux.HandleFunc("POST /api/v1/dependencies/upload", h.HandleDependencyUpload)
+	mux.Handle("GET /api/v1/dependencies/jobs/{id}", h.withTimeout(h.HandleGetDependencyJob))
+	mux.Handle("DELETE /api/v1/dependencies/jobs/{id}", h.withTimeout(h.HandleCancelDependencyJob))
+
+	// Prometheus metrics.
+	if h.metrics != nil {
+		mux.Handle("GET /metrics", h.withTimeout(h.metrics.Handler().ServeHTTP))
+	}
+}
+
+// withTimeout applies the configured request timeout to fn.
+func (h *Handler) withTimeout(fn http.HandlerFunc) http.Handler {
+	if h.requestTimeout < 0 {
+		return fn
+	}
+	return TimeoutMiddleware(h.requestTimeout)(fn)
 }
 
 // HandleGetFileByHash handles hash lookup requests.
@@ -120,6 +202,74 @@ func (h *Handler) HandleGetFileByHash(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, result)
 }
 
+// Batch lookup limits.
+const (
+	maxBatchLookupHashes  = 10000
+	batchLookupWorkers    = 8
+	maxBatchLookupBodyLen = 2 * 1024 * 1024
+)
+
+// BatchLookupRequest is the body of a batch hash lookup.
+type BatchLookupRequest struct {
+	Hashes []string `json:"hashes"`
+}
+
+// HandleBatchLookup handles batch hash lookup requests.
+// POST /api/v1/files/lookup
+// Returns one result per requested hash, in request order. Invalid hashes
+// and failed lookups are reported inline as error results.
+func (h *Handler) HandleBatchLookup(w http.ResponseWriter, r *http.Request) {
+	var req BatchLookupRequest
+	if !decodeJSONBody(w, r, &req, min(h.maxBodySize, maxBatchLookupBodyLen)) {
+		return
+	}
+
+	if len(req.Hashes) == 0 {
+		writeError(w, http.StatusBadRequest, "hashes is required")
+		return
+	}
+	if len(req.Hashes) > maxBatchLookupHashes {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("too many hashes: %d (max %d)", len(req.Hashes), maxBatchLookupHashes))
+		return
+	}
+
+	results := make([]types.Result, len(req.Hashes))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(batchLookupWorkers, len(req.Hashes)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = h.lookupOne(r.Context(), req.Hashes[i])
+			}
+		}()
+	}
+	for i := range req.Hashes {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	writeJSON(w, http.StatusOK, results)
+}
+
+// lookupOne resolves a single hash of a batch, turning failures into
+// error results.
+func (h *Handler) lookupOne(ctx context.Context, hashStr string) types.Result {
+	hash, err := types.ParseHash(hashStr)
+	if err != nil {
+		return types.NewErrorResult(types.Hash{Value: hashStr}, fmt.Sprintf("invalid hash: %v", err))
+	}
+
+	result, err := h.engine.Lookup(ctx, hash)
+	if err != nil {
+		return types.NewErrorResult(hash, fmt.Sprintf("lookup failed: %v", err))
+	}
+	return result
+}
+
 // HandleUploadFile handles file upload for scanning.
 // POST /api/v1/files
 // Returns 202 Accepted with job ID for polling.
@@ -129,7 +279,7 @@ func (h *Handler) HandleUploadFile(w http.ResponseWriter, r *http.Request) {
 
 	// Parse multipart form.
 	if err := r.ParseMultipartForm(h.maxFileSize); err != nil {
-		writeError(w, http.StatusBadRequest, fmt.Sprintf("parsing form: %v", err))
+		writeBodyError(w, "parsing form", err)
 		return
 	}
 
@@ -140,9 +290,20 @@ func (h *Handler) HandleUploadFile(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
+	// Reject disallowed file types before anything is stored.
+	contentType, content, err := detectContentType(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("reading file: %v", err))
+		return
+	}
+	if !h.uploadTypes.Allows(contentType) {
+		writeError(w, http.StatusUnsupportedMediaType, fmt.Sprintf("file type %s is not allowed", contentType))
+		return
+	}
+
 	// Hash the file while saving it.
 	hasher := sha256.New()
-	teeReader := io.TeeReader(file, hasher)
+	teeReader := io.TeeReader(content, hasher)
 
 	// Create upload directory if needed.
 	if err := os.MkdirAll(h.uploadDir, 0o755); err != nil {
@@ -205,6 +366,7 @@ func (h *Handler) HandleUploadFile(w http.ResponseWriter, r *http.Request) {
 
 	// Create new job.
 	job := types.NewJob(fileHash, header.Filename, written)
+	job.ContentType = contentType
 
 	if h.jobStore != nil {
 		if err := h.jobStore.Create(r.Context(), job); err != nil {
@@ -213,9 +375,10 @@ func (h *Handler) HandleUploadFile(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Submit job to worker.
+	// Submit job to worker; the uploader is waiting on the result, so it
+	// goes ahead of background scans.
 	if h.worker != nil {
-		if err := h.worker.Submit(job.ID, uploadPath); err != nil {
+		if err := h.worker.SubmitWithPriority(job.ID, uploadPath, scanner.PriorityHigh); err != nil {
 			writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("queueing job: %v", err))
 			return
 		}
@@ -225,11 +388,12 @@ func (h *Handler) HandleUploadFile(w http.ResponseWriter, r *http.Request) {
 	// Return 202 Accepted with job ID.
 	w.Header().Set("Location", fmt.Sprintf("/api/v1/jobs/%s", job.ID))
 	writeJSON(w, http.StatusAccepted, map[string]interface{}{
-		"job_id":    job.ID,
-		"status":    job.Status,
-		"file_hash": fileHash,
-		"file_size": written,
-		"message":   "scan queued",
+		"job_id":       job.ID,
+		"status":       job.Status,
+		"file_hash":    fileHash,
+		"file_size":    written,
+		"content_type": contentType,
+		"message":      "scan queued",
 	})
 }
 
@@ -255,20 +419,99 @@ func (h *Handler) HandleGetJob(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, job)
 }
 
+// Page sizes for HandleListJobs.
+const (
+	defaultJobListLimit = 50
+	maxJobListLimit     = 500
+)
+
+// HandleListJobs lists scan jobs newest first. Query parameters: status
+// (comma-separated), since and until (RFC 3339 creation time bounds),
+// limit (default 50, at most 500), and cursor (next_cursor from the
+// previous page).
+// GET /api/v1/jobs
+func (h *Handler) HandleListJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	opts := engine.ListOptions{
+		Limit:  defaultJobListLimit,
+		Cursor: query.Get("cursor"),
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxJobListLimit {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxJobListLimit))
+			return
+		}
+		opts.Limit = limit
+	}
+
+	if v := query.Get("status"); v != "" {
+		for _, name := range strings.Split(v, ",") {
+			status := types.JobStatus(strings.TrimSpace(name))
+			if status.String() == "unknown" {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid status: %q", name))
+				return
+			}
+			opts.Statuses = append(opts.Statuses, status)
+		}
+	}
+
+	for param, dst := range map[string]*time.Time{"since": &opts.Since, "until": &opts.Until} {
+		if v := query.Get(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s: %v", param, err))
+				return
+			}
+			*dst = t
+		}
+	}
+
+	jobs, next, err := h.jobStore.List(r.Context(), opts)
+	if errors.Is(err, engine.ErrInvalidCursor) {
+		writeError(w, http.StatusBadRequest, "invalid cursor")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("listing jobs: %v", err))
+		return
+	}
+	if jobs == nil {
+		jobs = []*types.Job{}
+	}
+
+	resp := map[string]interface{}{"jobs": jobs}
+	if next != "" {
+		resp["next_cursor"] = next
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
 // HandleHealth handles health check requests.
 // GET /api/v1/health
+// Per-source signature counts walk the whole store, so they are only
+// included when requested with ?detailed=1.
 func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 	status := "ok"
 	checks := make(map[string]interface{})
+	stats := make(map[string]int64)
 
 	// Check engine.
 	if h.engine != nil {
-		stats, err := h.engine.Stats(r.Context())
+		engineStats, err := h.engine.Stats(r.Context())
 		if err != nil {
 			status = "degraded"
 			checks["engine"] = fmt.Sprintf("error: %v", err)
 		} else {
-			checks["engine"] = fmt.Sprintf("ok (signatures: %d)", stats.SignatureCount)
+			checks["engine"] = fmt.Sprintf("ok (signatures: %d)", engineStats.SignatureCount)
+			stats["signatures"] = engineStats.SignatureCount
+		}
+
+		if r.URL.Query().Get("detailed") == "1" {
+			if detailed, err := h.engine.StatsDetailed(r.Context()); err == nil {
+				checks["signatures_by_source"] = detailed.BySource
+			}
 		}
 	}
 
@@ -279,12 +522,25 @@ func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 			checks["job_store"] = fmt.Sprintf("error: %v", err)
 		} else {
 			checks["job_store"] = fmt.Sprintf("ok (jobs: %d)", count)
+			stats["jobs"] = count
+		}
+	}
+
+	// Check Redis.
+	if h.redis != nil {
+		if health := h.redis.RedisHealth(); !health.Connected {
+			status = "degraded"
+			checks["redis"] = "error: " + health.LastError
+		} else {
+			checks["redis"] = "ok"
 		}
 	}
 
 	// Check worker queue.
 	if h.worker != nil {
-		checks["worker"] = fmt.Sprintf("ok (queue: %d)", h.worker.QueueLength())
+		queueLength := h.worker.QueueLength()
+		checks["worker"] = fmt.Sprintf("ok (queue: %d)", queueLength)
+		stats["queue_length"] = int64(queueLength)
 	}
 
 	// Include DB update status if provider is configured.
@@ -299,6 +555,7 @@ func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 		"status":    status,
 		"timestamp": time.Now().UTC(),
 		"checks":    checks,
+		"stats":     stats,
 	})
 }
 
@@ -313,8 +570,7 @@ func (h *Handler) HandleDependencyScan(w http.ResponseWriter, r *http.Request) {
 
 	// Parse request body.
 	var req trivy.ScanRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+	if !decodeJSONBody(w, r, &req, h.maxBodySize) {
 		return
 	}
 
@@ -324,6 +580,107 @@ func (h *Handler) HandleDependencyScan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.queueTrivyScan(w, req)
+}
+
+// HandleDependencyUpload handles dependency scans of an uploaded project
+// archive. The archive (multipart field "file") is extracted, its manifests
+// are parsed, and the packages found are scanned like HandleDependencyScan.
+// An optional "severity_filter" field takes comma-separated severities.
+// POST /api/v1/dependencies/upload
+// Returns 202 Accepted with job ID for polling.
+func (h *Handler) HandleDependencyUpload(w http.ResponseWriter, r *http.Request) {
+	if h.trivyScanner == nil {
+		writeError(w, http.StatusServiceUnavailable, "trivy scanning is not enabled")
+		return
+	}
+
+	// Limit request body size.
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxFileSize)
+
+	// Parse multipart form.
+	if err := r.ParseMultipartForm(h.maxFileSize); err != nil {
+		writeBodyError(w, "parsing form", err)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("reading file: %v", err))
+		return
+	}
+	defer file.Close()
+
+	// Create upload directory if needed.
+	if err := os.MkdirAll(h.uploadDir, 0o755); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("creating upload dir: %v", err))
+		return
+	}
+
+	// Keep the extension: ExtractArchive picks the format from it.
+	tempFile, err := os.CreateTemp(h.uploadDir, "dependencies-*"+archiveSuffix(header.Filename))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("creating temp file: %v", err))
+		return
+	}
+	uploadPath := tempFile.Name()
+	defer os.Remove(uploadPath)
+
+	_, err = io.Copy(tempFile, file)
+	tempFile.Close()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("saving file: %v", err))
+		return
+	}
+
+	extractDir, err := trivy.ExtractArchive(uploadPath)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("extracting archive: %v", err))
+		return
+	}
+	defer os.RemoveAll(extractDir)
+
+	packages, err := trivy.ScanPathForPackages(extractDir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("parsing manifests: %v", err))
+		return
+	}
+	if len(packages) == 0 {
+		writeError(w, http.StatusUnprocessableEntity, "no packages found in archive manifests")
+		return
+	}
+
+	req := trivy.ScanRequest{Packages: packages}
+	if v := r.FormValue("severity_filter"); v != "" {
+		for _, sev := range strings.Split(v, ",") {
+			req.SeverityFilter = append(req.SeverityFilter, strings.ToUpper(strings.TrimSpace(sev)))
+		}
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("validation error: %v", err))
+		return
+	}
+
+	h.queueTrivyScan(w, req)
+}
+
+// archiveSuffix returns the archive extension of filename, keeping
+// compound extensions such as ".tar.gz".
+func archiveSuffix(filename string) string {
+	name := strings.ToLower(filepath.Base(filename))
+	for _, suffix := range []string{".tar.gz", ".tar.xz", ".tar.bz2"} {
+		if strings.HasSuffix(name, suffix) {
+			return suffix
+		}
+	}
+	return filepath.Ext(name)
+}
+
+// queueTrivyScan stores a pending job for req, starts scanning it in the
+// background, and responds with 202 Accepted and the job ID.
+func (h *Handler) queueTrivyScan(w http.ResponseWriter, req trivy.ScanRequest) {
 	// Create job.
 	jobID := uuid.New().String()
 	job := &TrivyJob{
@@ -331,16 +688,20 @@ func (h *Handler) HandleDependencyScan(w http.ResponseWriter, r *http.Request) {
 		Status:         "pending",
 		Packages:       req.Packages,
 		SeverityFilter: req.SeverityFilter,
+		Progress:       trivy.ScanProgress{PackagesTotal: len(req.Packages)},
 		CreatedAt:      time.Now(),
 	}
 
 	// Store job.
-	if h.trivyJobStore != nil {
-		h.trivyJobStore.Set(jobID, job)
-	}
+	h.saveTrivyJob(job)
+
+	// Process scan asynchronously, cancellable through DELETE.
+	ctx, cancel := context.WithCancel(context.Background())
+	h.trivyMu.Lock()
+	h.trivyCancels[jobID] = cancel
+	h.trivyMu.Unlock()
 
-	// Process scan asynchronously.
-	go h.processTrivyScan(job)
+	go h.processTrivyScan(ctx, job)
 
 	// Return 202 Accepted.
 	w.Header().Set("Location", fmt.Sprintf("/api/v1/dependencies/jobs/%s", jobID))
@@ -351,20 +712,46 @@ func (h *Handler) HandleDependencyScan(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// processTrivyScan performs the Trivy scan in the background.
-func (h *Handler) processTrivyScan(job *TrivyJob) {
-	ctx := context.Background()
-
+// processTrivyScan performs the Trivy scan in the background. It stops
+// early when ctx is cancelled, in which case the job was already marked
+// cancelled by HandleCancelDependencyJob.
+func (h *Handler) processTrivyScan(ctx context.Context, job *TrivyJob) {
 	// Update status to running.
+	h.trivyMu.Lock()
+	if ctx.Err() != nil {
+		h.trivyMu.Unlock()
+		return
+	}
 	job.Status = "running"
 	now := time.Now()
 	job.StartedAt = &now
-	if h.trivyJobStore != nil {
-		h.trivyJobStore.Set(job.ID, job)
-	}
+	h.saveTrivyJob(job)
+	h.trivyMu.Unlock()
+
+	// Perform scan, persisting progress as batches complete.
+	result, err := h.trivyScanner.ScanPackagesWithOptions(ctx, job.Packages, trivy.ScanOptions{
+		SeverityFilter: job.SeverityFilter,
+		OnProgress: func(progress trivy.ScanProgress) {
+			h.trivyMu.Lock()
+			defer h.trivyMu.Unlock()
+			if _, running := h.trivyCancels[job.ID]; !running {
+				return // Cancelled; keep the stored cancelled status
+			}
+			job.Progress = progress
+			h.saveTrivyJob(job)
+		},
+	})
 
-	// Perform scan.
-	result, err := h.trivyScanner.ScanPackages(ctx, job.Packages, job.SeverityFilter)
+	h.trivyMu.Lock()
+	defer h.trivyMu.Unlock()
+
+	cancel, running := h.trivyCancels[job.ID]
+	if !running {
+		// Cancelled while scanning; the cancelled status is already stored.
+		return
+	}
+	delete(h.trivyCancels, job.ID)
+	cancel()
 
 	completed := time.Now()
 	job.CompletedAt = &completed
@@ -378,11 +765,22 @@ func (h *Handler) processTrivyScan(job *TrivyJob) {
 	}
 
 	// Update final status.
-	if h.trivyJobStore != nil {
-		h.trivyJobStore.Set(job.ID, job)
+	h.saveTrivyJob(job)
+	if h.metrics != nil {
+		h.metrics.ObserveTrivyJob(job.Status)
 	}
 }
 
+// saveTrivyJob stores a copy of job so that pollers never read the fields
+// the background scan is still updating.
+func (h *Handler) saveTrivyJob(job *TrivyJob) {
+	if h.trivyJobStore == nil {
+		return
+	}
+	snapshot := *job
+	h.trivyJobStore.Set(job.ID, &snapshot)
+}
+
 // HandleGetDependencyJob handles dependency scan job status polling.
 // GET /api/v1/dependencies/jobs/{id}
 func (h *Handler) HandleGetDependencyJob(w http.ResponseWriter, r *http.Request) {
@@ -404,9 +802,11 @@ func (h *Handler) HandleGetDependencyJob(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Build response based on job status.
+	progress := job.Progress
 	resp := trivy.JobStatusResponse{
-		JobID:  job.ID,
-		Status: job.Status,
+		JobID:    job.ID,
+		Status:   job.Status,
+		Progress: &progress,
 	}
 
 	if job.Result != nil {
@@ -422,53 +822,73 @@ func (h *Handler) HandleGetDependencyJob(w http.ResponseWriter, r *http.Request)
 	writeJSON(w, http.StatusOK, resp)
 }
 
-// TrivyJob represents an async dependency scan job.
-type TrivyJob struct {
-	ID             string            `json:"id"`
-	Status         string            `json:"status"`
-	Packages       []trivy.Package   `json:"packages"`
-	SeverityFilter []string          `json:"severity_filter,omitempty"`
-	Result         *trivy.ScanResult `json:"result,omitempty"`
-	Error          string            `json:"error,omitempty"`
-	CreatedAt      time.Time         `json:"created_at"`
-	StartedAt      *time.Time        `json:"started_at,omitempty"`
-	CompletedAt    *time.Time        `json:"completed_at,omitempty"`
-}
+// HandleCancelDependencyJob cancels a pending or running dependency scan.
+// DELETE /api/v1/dependencies/jobs/{id}
+func (h *Handler) HandleCancelDependencyJob(w http.ResponseWriter, r *http.Request) {
+	if h.trivyJobStore == nil {
+		writeError(w, http.StatusServiceUnavailable, "trivy scanning is not enabled")
+		return
+	}
 
-// TrivyJobStore is an in-memory store for Trivy scan jobs.
-// For production, this should be replaced with persistent storage.
-type TrivyJobStore struct {
-	mu   sync.RWMutex
-	jobs map[string]*TrivyJob
-}
+	jobID := r.PathValue("id")
+	if jobID == "" {
+		writeError(w, http.StatusBadRequest, "job ID is required")
+		return
+	}
 
-// NewTrivyJobStore creates a new in-memory job store.
-func NewTrivyJobStore() *TrivyJobStore {
-	return &TrivyJobStore{
-		jobs: make(map[string]*TrivyJob),
+	h.trivyMu.Lock()
+	defer h.trivyMu.Unlock()
+
+	job, found := h.trivyJobStore.Get(jobID)
+	if !found {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+
+	cancel, running := h.trivyCancels[jobID]
+	if !running || job.IsTerminal() {
+		writeError(w, http.StatusConflict, fmt.Sprintf("job already %s", job.Status))
+		return
+	}
+	delete(h.trivyCancels, jobID)
+	cancel()
+
+	completed := time.Now()
+	job.Status = "cancelled"
+	job.Error = "scan cancelled"
+	job.CompletedAt = &completed
+	h.saveTrivyJob(job)
+	if h.metrics != nil {
+		h.metrics.ObserveTrivyJob(job.Status)
 	}
-}
 
-// Set stores a job.
-func (s *TrivyJobStore) Set(id string, job *TrivyJob) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.jobs[id] = job
+	writeJSON(w, http.StatusOK, trivy.JobStatusResponse{
+		JobID:  job.ID,
+		Status: job.Status,
+		Error:  job.Error,
+	})
 }
 
-// Get retrieves a job by ID.
-func (s *TrivyJobStore) Get(id string) (*TrivyJob, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	job, ok := s.jobs[id]
-	return job, ok
+// decodeJSONBody decodes the request body into dst, reading at most limit
+// bytes. On failure it writes a 413 or 400 error and returns false.
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst any, limit int64) bool {
+	body := http.MaxBytesReader(w, r.Body, limit)
+	if err := json.NewDecoder(body).Decode(dst); err != nil {
+		writeBodyError(w, "invalid request body", err)
+		return false
+	}
+	return true
 }
 
-// Delete removes a job.
-func (s *TrivyJobStore) Delete(id string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	delete(s.jobs, id)
+// writeBodyError reports a failure to read the request body, using 413
+// when the body exceeded its size limit.
+func writeBodyError(w http.ResponseWriter, prefix string, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body too large (limit %d bytes)", tooLarge.Limit))
+		return
+	}
+	writeError(w, http.StatusBadRequest, fmt.Sprintf("%s: %v", prefix, err))
 }
 
 // writeJSON writes a JSON response.
@@ -485,20 +905,6 @@ func writeError(w http.ResponseWriter, status int, message string) {
 	})
 }
 
-// Middleware for logging requests.
-func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		next.ServeHTTP(w, r)
-		duration := time.Since(start)
-
-		// Skip logging for health checks.
-		if !strings.HasSuffix(r.URL.Path, "/health") {
-			fmt.Printf("%s %s %s\n", r.Method, r.URL.Path, duration)
-		}
-	})
-}
-
 // CleanupUploadedFile removes a temporary uploaded file.
 func CleanupUploadedFile(path string) {
 	if path != "" && filepath.Dir(path) != "/" {
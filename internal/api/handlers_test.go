@@ -1,22 +1,33 @@
-// ABOUTME: Tests for API handlers including hash lookup and job polling
+// ABOUTME: Tests for API handlers including hash lookup, job polling, and dependency uploads
 // ABOUTME: Validates request/response handling and error cases
 
 package api
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
+	"maps"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/hikmaai-io/hikmaai-argus/internal/engine"
+	"github.com/hikmaai-io/hikmaai-argus/internal/observability"
+	"github.com/hikmaai-io/hikmaai-argus/internal/redis"
+	"github.com/hikmaai-io/hikmaai-argus/internal/trivy"
 	"github.com/hikmaai-io/hikmaai-argus/internal/types"
 )
 
@@ -103,6 +114,151 @@ func TestHandler_HandleGetFileByHash_InvalidHash(t *testing.T) {
 	}
 }
 
+func TestHandler_HandleBatchLookup(t *testing.T) {
+	t.Parallel()
+
+	eng := setupTestEngine(t)
+	handler := NewHandler(HandlerConfig{Engine: eng})
+
+	sig := &types.Signature{
+		SHA256:        "b" + strings.Repeat("0", 63),
+		MD5:           "c" + strings.Repeat("0", 31),
+		DetectionName: "Test.Malware",
+		ThreatType:    types.ThreatTypeMalware,
+		Severity:      types.SeverityHigh,
+		Source:        "test",
+	}
+	eng.AddSignature(context.Background(), sig)
+
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	hashes := []string{sig.SHA256, "invalid", strings.Repeat("0", 64), "", strings.ToUpper(sig.MD5)}
+	body, _ := json.Marshal(BatchLookupRequest{Hashes: hashes})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/files/lookup", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Status = %d, want %d; body: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var results []types.Result
+	if err := json.NewDecoder(rec.Body).Decode(&results); err != nil {
+		t.Fatalf("Decoding response: %v", err)
+	}
+
+	wantStatus := []types.Status{
+		types.StatusMalware,
+		types.StatusError,
+		types.StatusUnknown,
+		types.StatusError,
+		types.StatusMalware,
+	}
+	if len(results) != len(wantStatus) {
+		t.Fatalf("len(results) = %d, want %d", len(results), len(wantStatus))
+	}
+	for i, want := range wantStatus {
+		if results[i].Status != want {
+			t.Errorf("results[%d].Status = %v, want %v", i, results[i].Status, want)
+		}
+		if want == types.StatusError && results[i].Error == "" {
+			t.Errorf("results[%d].Error is empty", i)
+		}
+	}
+	if results[1].Hash.Value != "invalid" {
+		t.Errorf("results[1].Hash.Value = %q, want %q", results[1].Hash.Value, "invalid")
+	}
+}
+
+func TestHandler_HandleBatchLookup_BadRequest(t *testing.T) {
+	t.Parallel()
+
+	tooMany, _ := json.Marshal(BatchLookupRequest{Hashes: make([]string, maxBatchLookupHashes+1)})
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "malformed json", body: "{"},
+		{name: "missing hashes", body: "{}"},
+		{name: "empty hashes", body: `{"hashes": []}`},
+		{name: "too many hashes", body: string(tooMany)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := NewHandler(HandlerConfig{Engine: setupTestEngine(t)})
+			mux := http.NewServeMux()
+			handler.RegisterRoutes(mux)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/files/lookup", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("Status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestHandler_BodyTooLarge(t *testing.T) {
+	t.Parallel()
+
+	handler := NewHandler(HandlerConfig{
+		Engine:       setupTestEngine(t),
+		TrivyScanner: trivy.NewScanner(trivy.ScannerConfig{ServerURL: "http://127.0.0.1:0"}),
+		MaxBodySize:  1024,
+	})
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	large := `{"hashes": ["` + strings.Repeat("a", 2048) + `"]}`
+	for _, path := range []string{"/api/v1/files/lookup", "/api/v1/dependencies/scan"} {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(large))
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("Status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+		})
+	}
+}
+
+func TestHandler_Metrics(t *testing.T) {
+	t.Parallel()
+
+	handler := NewHandler(HandlerConfig{
+		Engine:  setupTestEngine(t),
+		Metrics: observability.NewMetrics(),
+	})
+
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	for _, name := range []string{"argus_scan_duration_seconds", "go_goroutines"} {
+		if !strings.Contains(rec.Body.String(), name) {
+			t.Errorf("metrics output missing %q", name)
+		}
+	}
+}
+
 func TestHandler_HandleGetJob(t *testing.T) {
 	t.Parallel()
 
@@ -154,6 +310,72 @@ func TestHandler_HandleGetJob_NotFound(t *testing.T) {
 	}
 }
 
+func TestHandler_HandleListJobs(t *testing.T) {
+	t.Parallel()
+
+	jobStore := setupTestJobStore(t)
+	handler := NewHandler(HandlerConfig{JobStore: jobStore})
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ids := make([]string, 3)
+	for i := range ids {
+		job := types.NewJob("hash", "file.exe", 1024)
+		job.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if i == 1 {
+			job.Start()
+		}
+		if err := jobStore.Create(context.Background(), job); err != nil {
+			t.Fatalf("Creating job: %v", err)
+		}
+		ids[i] = job.ID
+	}
+
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	list := func(query string) (int, []string, string) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs"+query, nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		var resp struct {
+			Jobs       []types.Job `json:"jobs"`
+			NextCursor string      `json:"next_cursor"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+
+		var got []string
+		for _, job := range resp.Jobs {
+			got = append(got, job.ID)
+		}
+		return rec.Code, got, resp.NextCursor
+	}
+
+	code, got, next := list("?limit=2")
+	if code != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", code, http.StatusOK)
+	}
+	if strings.Join(got, ",") != ids[2]+","+ids[1] || next == "" {
+		t.Errorf("first page = %v (cursor %q), want [%s %s] with a cursor", got, next, ids[2], ids[1])
+	}
+
+	_, got, next = list("?limit=2&cursor=" + next)
+	if strings.Join(got, ",") != ids[0] || next != "" {
+		t.Errorf("second page = %v (cursor %q), want [%s] without a cursor", got, next, ids[0])
+	}
+
+	_, got, _ = list("?status=running")
+	if strings.Join(got, ",") != ids[1] {
+		t.Errorf("status=running = %v, want [%s]", got, ids[1])
+	}
+
+	for _, query := range []string{"?limit=0", "?limit=abc", "?status=bogus", "?since=yesterday", "?cursor=%21%21"} {
+		if code, _, _ := list(query); code != http.StatusBadRequest {
+			t.Errorf("GET /api/v1/jobs%s status = %d, want %d", query, code, http.StatusBadRequest)
+		}
+	}
+}
+
 func TestHandler_HandleUploadFile(t *testing.T) {
 	t.Parallel()
 
@@ -256,6 +478,106 @@ func TestHandler_HandleUploadFile_CacheHit(t *testing.T) {
 	}
 }
 
+func TestHandler_HandleUploadFile_ContentTypePolicy(t *testing.T) {
+	t.Parallel()
+
+	zipContent := zipArchive(t, map[string]string{"readme.txt": "hello"})
+
+	tests := []struct {
+		name            string
+		allowed         []string
+		denied          []string
+		content         []byte
+		wantStatus      int
+		wantContentType string
+	}{
+		{
+			name:            "no policy passes everything through",
+			content:         []byte("plain text"),
+			wantStatus:      http.StatusAccepted,
+			wantContentType: "text/plain",
+		},
+		{
+			name:            "allowed type",
+			allowed:         []string{"application/zip", "application/x-msdownload"},
+			content:         zipContent,
+			wantStatus:      http.StatusAccepted,
+			wantContentType: "application/zip",
+		},
+		{
+			name:       "type missing from allowlist",
+			allowed:    []string{"application/zip"},
+			content:    []byte("plain text"),
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:       "denied wildcard wins over allowlist",
+			allowed:    []string{"text/plain"},
+			denied:     []string{"text/*"},
+			content:    []byte("plain text"),
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			jobStore := setupTestJobStore(t)
+			uploadDir := t.TempDir()
+			handler := NewHandler(HandlerConfig{
+				JobStore:            jobStore,
+				UploadDir:           uploadDir,
+				AllowedContentTypes: tt.allowed,
+				DeniedContentTypes:  tt.denied,
+			})
+			mux := http.NewServeMux()
+			handler.RegisterRoutes(mux)
+
+			// The filename claims an executable; only the content counts.
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			part, err := writer.CreateFormFile("file", "setup.exe")
+			if err != nil {
+				t.Fatalf("Creating form file: %v", err)
+			}
+			part.Write(tt.content)
+			writer.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/files", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Status = %d, want %d; body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+
+			jobs, _, err := jobStore.List(context.Background(), engine.ListOptions{})
+			if err != nil {
+				t.Fatalf("Listing jobs: %v", err)
+			}
+
+			if tt.wantStatus != http.StatusAccepted {
+				if len(jobs) != 0 {
+					t.Errorf("rejected upload created %d jobs", len(jobs))
+				}
+				if entries, _ := os.ReadDir(uploadDir); len(entries) != 0 {
+					t.Errorf("rejected upload left %d files in the upload dir", len(entries))
+				}
+				return
+			}
+
+			if len(jobs) != 1 {
+				t.Fatalf("got %d jobs, want 1", len(jobs))
+			}
+			if jobs[0].ContentType != tt.wantContentType {
+				t.Errorf("job ContentType = %q, want %q", jobs[0].ContentType, tt.wantContentType)
+			}
+		})
+	}
+}
+
 func TestHandler_HandleHealth(t *testing.T) {
 	t.Parallel()
 
@@ -281,6 +603,474 @@ func TestHandler_HandleHealth(t *testing.T) {
 	if response["status"] != "ok" {
 		t.Errorf("Status = %q, want %q", response["status"], "ok")
 	}
+
+	stats, _ := response["stats"].(map[string]interface{})
+	if stats["signatures"] != float64(0) {
+		t.Errorf("stats.signatures = %v, want 0", stats["signatures"])
+	}
+}
+
+// fakeRedisHealth reports a fixed Redis ping result.
+type fakeRedisHealth struct {
+	err error
+}
+
+func (f fakeRedisHealth) RedisHealth() redis.Health {
+	if f.err != nil {
+		return redis.Health{LastError: f.err.Error()}
+	}
+	return redis.Health{Connected: true}
+}
+
+func TestHandler_HandleHealth_Redis(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		pingErr    error
+		wantStatus string
+		wantCheck  string
+	}{
+		{name: "connected", wantStatus: "ok", wantCheck: "ok"},
+		{
+			name:       "disconnected",
+			pingErr:    errors.New("connection refused"),
+			wantStatus: "degraded",
+			wantCheck:  "error: connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := NewHandler(HandlerConfig{Redis: fakeRedisHealth{err: tt.pingErr}})
+			mux := http.NewServeMux()
+			handler.RegisterRoutes(mux)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/health", nil)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			var response struct {
+				Status string            `json:"status"`
+				Checks map[string]string `json:"checks"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("Decoding response: %v", err)
+			}
+
+			if response.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", response.Status, tt.wantStatus)
+			}
+			if response.Checks["redis"] != tt.wantCheck {
+				t.Errorf("checks.redis = %q, want %q", response.Checks["redis"], tt.wantCheck)
+			}
+		})
+	}
+}
+
+func TestHandler_HandleHealth_SignaturesBySource(t *testing.T) {
+	t.Parallel()
+
+	eng := setupTestEngine(t)
+	sigs := []*types.Signature{
+		{SHA256: strings.Repeat("a", 64), DetectionName: "A", Source: "malwarebazaar"},
+		{SHA256: strings.Repeat("b", 64), DetectionName: "B", Source: "malwarebazaar"},
+		{SHA256: strings.Repeat("c", 64), DetectionName: "C", Source: "threatfox"},
+	}
+	if err := eng.BatchAddSignatures(context.Background(), sigs); err != nil {
+		t.Fatalf("BatchAddSignatures() error: %v", err)
+	}
+
+	handler := NewHandler(HandlerConfig{Engine: eng})
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	tests := []struct {
+		name string
+		path string
+		want map[string]int64
+	}{
+		{name: "omitted by default", path: "/api/v1/health", want: nil},
+		{name: "detailed", path: "/api/v1/health?detailed=1", want: map[string]int64{"malwarebazaar": 2, "threatfox": 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			var response struct {
+				Checks struct {
+					SignaturesBySource map[string]int64 `json:"signatures_by_source"`
+				} `json:"checks"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("Decoding response: %v", err)
+			}
+
+			if !maps.Equal(response.Checks.SignaturesBySource, tt.want) {
+				t.Errorf("checks.signatures_by_source = %v, want %v", response.Checks.SignaturesBySource, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_HandleDependencyUpload(t *testing.T) {
+	t.Parallel()
+
+	trivySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/twirp/trivy.cache.v1.Cache/PutBlob", "/twirp/trivy.cache.v1.Cache/PutArtifact":
+			w.Write([]byte(`{}`))
+		case "/twirp/trivy.scanner.v1.Scanner/Scan":
+			json.NewEncoder(w).Encode(trivy.TwirpScanResponse{
+				Results: []trivy.TwirpResult{{
+					Target: "dependency-scan",
+					Type:   "pip",
+					Vulnerabilities: []trivy.TwirpVulnerability{{
+						VulnerabilityID:  "CVE-2023-32681",
+						PkgName:          "requests",
+						InstalledVersion: "2.25.0",
+						FixedVersion:     "2.31.0",
+						Severity:         "HIGH",
+					}},
+				}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer trivySrv.Close()
+
+	uploadDir := t.TempDir()
+	handler := NewHandler(HandlerConfig{
+		UploadDir:     uploadDir,
+		MaxFileSize:   64 * 1024,
+		TrivyScanner:  trivy.NewScanner(trivy.ScannerConfig{ServerURL: trivySrv.URL, Timeout: 5 * time.Second}),
+		TrivyJobStore: NewTrivyJobStore(),
+	})
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	upload := func(filename string, content []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, _ := mw.CreateFormFile("file", filename)
+		part.Write(content)
+		mw.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/dependencies/upload", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("zip with requirements.txt", func(t *testing.T) {
+		rec := upload("project.zip", zipArchive(t, map[string]string{
+			"project/requirements.txt": "requests==2.25.0\n",
+		}))
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("Status = %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body.String())
+		}
+
+		var queued trivy.JobResponse
+		if err := json.NewDecoder(rec.Body).Decode(&queued); err != nil {
+			t.Fatalf("Decoding response: %v", err)
+		}
+
+		// Poll until the background scan finishes.
+		var status trivy.JobStatusResponse
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/dependencies/jobs/"+queued.JobID, nil)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			json.NewDecoder(rec.Body).Decode(&status)
+			if status.Status == "completed" || status.Status == "failed" {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if status.Status != "completed" {
+			t.Fatalf("job status = %q (error %q), want completed", status.Status, status.Error)
+		}
+		if len(status.Vulnerabilities) != 1 || status.Vulnerabilities[0].CVEID != "CVE-2023-32681" {
+			t.Errorf("Vulnerabilities = %+v, want CVE-2023-32681", status.Vulnerabilities)
+		}
+	})
+
+	rejects := []struct {
+		name     string
+		filename string
+		content  []byte
+		want     int
+	}{
+		{name: "not an archive", filename: "requirements.txt", content: []byte("requests==2.25.0\n"), want: http.StatusBadRequest},
+		{name: "no manifests", filename: "docs.zip", content: zipArchive(t, map[string]string{"README.md": "hello"}), want: http.StatusUnprocessableEntity},
+		{name: "too large", filename: "big.zip", content: bytes.Repeat([]byte("x"), 128*1024), want: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range rejects {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := upload(tt.filename, tt.content); rec.Code != tt.want {
+				t.Errorf("Status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	// Uploads are removed once their packages are queued or rejected.
+	if entries, _ := os.ReadDir(uploadDir); len(entries) != 0 {
+		t.Errorf("upload dir not cleaned up: %d entries left", len(entries))
+	}
+}
+
+func TestHandler_HandleCancelDependencyJob(t *testing.T) {
+	t.Parallel()
+
+	// The fake Trivy server holds every scan open until the client gives up.
+	scanStarted := make(chan struct{}, 1)
+	scanAborted := make(chan struct{}, 1)
+	trivySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/twirp/trivy.cache.v1.Cache/PutBlob", "/twirp/trivy.cache.v1.Cache/PutArtifact":
+			w.Write([]byte(`{}`))
+		case "/twirp/trivy.scanner.v1.Scanner/Scan":
+			// Drain the body so the server notices the client hanging up.
+			io.Copy(io.Discard, r.Body)
+			scanStarted <- struct{}{}
+			<-r.Context().Done()
+			scanAborted <- struct{}{}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer trivySrv.Close()
+
+	handler := NewHandler(HandlerConfig{
+		TrivyScanner:  trivy.NewScanner(trivy.ScannerConfig{ServerURL: trivySrv.URL, Timeout: time.Minute}),
+		TrivyJobStore: NewTrivyJobStore(),
+	})
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	cancelJob := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/dependencies/jobs/"+id, nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	body := `{"packages":[{"ecosystem":"pip","name":"requests","version":"2.25.0"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/dependencies/scan", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Status = %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body.String())
+	}
+	var queued trivy.JobResponse
+	if err := json.NewDecoder(rec.Body).Decode(&queued); err != nil {
+		t.Fatalf("Decoding response: %v", err)
+	}
+
+	select {
+	case <-scanStarted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scan never reached the Trivy server")
+	}
+
+	rec = cancelJob(queued.JobID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("DELETE status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var cancelled trivy.JobStatusResponse
+	if err := json.NewDecoder(rec.Body).Decode(&cancelled); err != nil {
+		t.Fatalf("Decoding response: %v", err)
+	}
+	if cancelled.Status != "cancelled" {
+		t.Errorf("DELETE reported status %q, want cancelled", cancelled.Status)
+	}
+
+	select {
+	case <-scanAborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("in-flight scan request was not cancelled")
+	}
+
+	// The scan goroutine must not overwrite the cancellation, and it must
+	// release the job's cancel func once it returns.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		handler.trivyMu.Lock()
+		pending := len(handler.trivyCancels)
+		handler.trivyMu.Unlock()
+		if pending == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d cancel funcs still registered", pending)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/dependencies/jobs/"+queued.JobID, nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var status trivy.JobStatusResponse
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatalf("Decoding response: %v", err)
+	}
+	if status.Status != "cancelled" {
+		t.Errorf("job status = %q, want cancelled", status.Status)
+	}
+
+	if rec := cancelJob(queued.JobID); rec.Code != http.StatusConflict {
+		t.Errorf("second DELETE status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if rec := cancelJob("no-such-job"); rec.Code != http.StatusNotFound {
+		t.Errorf("DELETE of unknown job status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+// progressRecordingStore records the progress of every job it stores.
+type progressRecordingStore struct {
+	*TrivyJobStore
+
+	mu       sync.Mutex
+	progress []trivy.ScanProgress
+}
+
+func (s *progressRecordingStore) Set(id string, job *TrivyJob) {
+	s.mu.Lock()
+	s.progress = append(s.progress, job.Progress)
+	s.mu.Unlock()
+	s.TrivyJobStore.Set(id, job)
+}
+
+func TestHandler_DependencyJobProgress(t *testing.T) {
+	t.Parallel()
+
+	// The fake Trivy server holds the second batch until released so the
+	// test can observe a partially scanned job.
+	release := make(chan struct{})
+	var scans atomic.Int32
+	trivySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/twirp/trivy.cache.v1.Cache/PutBlob", "/twirp/trivy.cache.v1.Cache/PutArtifact":
+			w.Write([]byte(`{}`))
+		case "/twirp/trivy.scanner.v1.Scanner/Scan":
+			if scans.Add(1) == 2 {
+				<-release
+			}
+			w.Write([]byte(`{"Results":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer trivySrv.Close()
+
+	store := &progressRecordingStore{TrivyJobStore: NewTrivyJobStore()}
+	handler := NewHandler(HandlerConfig{
+		TrivyScanner: trivy.NewScanner(trivy.ScannerConfig{
+			ServerURL:    trivySrv.URL,
+			Timeout:      5 * time.Second,
+			MaxBatchSize: 2,
+		}),
+		TrivyJobStore: store,
+	})
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	body := `{"packages":[
+		{"ecosystem":"pip","name":"pkg0","version":"1.0.0"},
+		{"ecosystem":"pip","name":"pkg1","version":"1.0.0"},
+		{"ecosystem":"pip","name":"pkg2","version":"1.0.0"},
+		{"ecosystem":"pip","name":"pkg3","version":"1.0.0"},
+		{"ecosystem":"pip","name":"pkg4","version":"1.0.0"}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/dependencies/scan", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		close(release)
+		t.Fatalf("Status = %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body.String())
+	}
+	var queued trivy.JobResponse
+	if err := json.NewDecoder(rec.Body).Decode(&queued); err != nil {
+		close(release)
+		t.Fatalf("Decoding response: %v", err)
+	}
+
+	poll := func(done func(trivy.JobStatusResponse) bool) trivy.JobStatusResponse {
+		t.Helper()
+		var status trivy.JobStatusResponse
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/dependencies/jobs/"+queued.JobID, nil)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			status = trivy.JobStatusResponse{}
+			json.NewDecoder(rec.Body).Decode(&status)
+			if done(status) {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return status
+	}
+
+	running := poll(func(s trivy.JobStatusResponse) bool {
+		return s.Progress != nil && s.Progress.Batch == 1
+	})
+	close(release)
+
+	wantRunning := trivy.ScanProgress{PackagesScanned: 2, PackagesTotal: 5, Batch: 1, Batches: 3}
+	if running.Status != "running" || running.Progress == nil || *running.Progress != wantRunning {
+		t.Fatalf("mid-scan status = %q progress %+v, want running %+v", running.Status, running.Progress, wantRunning)
+	}
+
+	completed := poll(func(s trivy.JobStatusResponse) bool { return s.Status == "completed" || s.Status == "failed" })
+	wantDone := trivy.ScanProgress{PackagesScanned: 5, PackagesTotal: 5, Batch: 3, Batches: 3}
+	if completed.Status != "completed" || completed.Progress == nil || *completed.Progress != wantDone {
+		t.Fatalf("final status = %q progress %+v, want completed %+v", completed.Status, completed.Progress, wantDone)
+	}
+
+	// Every batch's progress is persisted through the job store.
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	var batches []int
+	for _, p := range store.progress {
+		if len(batches) == 0 || batches[len(batches)-1] != p.Batch {
+			batches = append(batches, p.Batch)
+		}
+	}
+	if want := []int{0, 1, 2, 3}; !slices.Equal(batches, want) {
+		t.Errorf("stored batch progress = %v, want %v", batches, want)
+	}
+}
+
+// zipArchive returns a zip archive holding files.
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Creating zip entry: %v", err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Closing zip: %v", err)
+	}
+	return buf.Bytes()
 }
 
 // Test helpers.
@@ -0,0 +1,265 @@
+// ABOUTME: HTTP middleware for the hikmaai-argus API
+// ABOUTME: Provides API-key authentication, request timeouts, and structured request logging
+
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// healthPath is left unauthenticated so probes work without credentials.
+const healthPath = "/api/v1/health"
+
+// AuthMiddleware returns middleware that requires one of keys in either an
+// "Authorization: Bearer <key>" or an "X-API-Key" header. Requests without a
+// valid key get a 401 JSON error.
+func AuthMiddleware(keys []string) func(http.Handler) http.Handler {
+	// Compare digests so the comparison time does not depend on key length.
+	digests := make([][sha256.Size]byte, 0, len(keys))
+	for _, key := range keys {
+		if key != "" {
+			digests = append(digests, sha256.Sum256([]byte(key)))
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == healthPath {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := requestAPIKey(r)
+			if key == "" {
+				writeError(w, http.StatusUnauthorized, "missing API key")
+				return
+			}
+
+			digest := sha256.Sum256([]byte(key))
+			valid := 0
+			for _, d := range digests {
+				valid |= subtle.ConstantTimeCompare(digest[:], d[:])
+			}
+			if valid != 1 {
+				writeError(w, http.StatusUnauthorized, "invalid API key")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestAPIKey extracts the API key from the request headers.
+func requestAPIKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if scheme, key, ok := strings.Cut(auth, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(key)
+		}
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// TimeoutMiddleware returns middleware that cancels the request context
+// after timeout. If the handler has not returned by then, the client gets a
+// 408 JSON error and anything the handler writes afterwards is discarded.
+// Responses are buffered until the handler returns, so it is not suitable
+// for streaming endpoints.
+func TimeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				dst := w.Header()
+				for k, v := range tw.header {
+					dst[k] = v
+				}
+				if tw.status == 0 {
+					tw.status = http.StatusOK
+				}
+				w.WriteHeader(tw.status)
+				w.Write(tw.buf.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				tw.timedOut = true
+				writeError(w, http.StatusRequestTimeout, fmt.Sprintf("request timed out after %s", timeout))
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers a response for TimeoutMiddleware.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+// Header returns the buffered response headers.
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// Write buffers b, failing once the request has timed out.
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.buf.Write(b)
+}
+
+// WriteHeader records the status code.
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+	tw.status = status
+}
+
+// LoggingConfig configures the request logging middleware.
+type LoggingConfig struct {
+	// Logger receives one record per request. Defaults to slog.Default().
+	Logger *slog.Logger
+
+	// LogHealthChecks logs requests to the health endpoint, which are
+	// skipped by default to keep probe traffic out of the logs.
+	LogHealthChecks bool
+}
+
+// NewLoggingMiddleware returns middleware that logs each request with its
+// method, path, response status, duration, and bytes written.
+func NewLoggingMiddleware(cfg LoggingConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+
+			if !cfg.LogHealthChecks && r.URL.Path == healthPath {
+				return
+			}
+
+			logger := cfg.Logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+			logger.LogAttrs(r.Context(), slog.LevelInfo, "http request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rec.Status()),
+				slog.Duration("duration", time.Since(start)),
+				slog.Int64("bytes", rec.bytes),
+			)
+		})
+	}
+}
+
+// LoggingMiddleware logs requests to the default slog logger, skipping
+// health checks.
+func LoggingMiddleware(next http.Handler) http.Handler {
+	return NewLoggingMiddleware(LoggingConfig{})(next)
+}
+
+// statusRecorder captures the status code and size of a response while
+// passing flushes and hijacks through to the underlying writer.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// WriteHeader records the status code.
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the number of bytes written.
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Status returns the response status, or 200 if the handler wrote nothing.
+func (r *statusRecorder) Status() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+// Flush implements http.Flusher when the underlying writer does.
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		if r.status == 0 {
+			r.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker when the underlying writer does.
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,228 @@
+// ABOUTME: Tests for API HTTP middleware
+// ABOUTME: Validates API-key authentication, health bypass, timeouts, and request logging
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		path       string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{
+			name:       "bearer key",
+			path:       "/api/v1/jobs/1",
+			headers:    map[string]string{"Authorization": "Bearer key-two"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "x-api-key header",
+			path:       "/api/v1/jobs/1",
+			headers:    map[string]string{"X-API-Key": "key-one"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "wrong key",
+			path:       "/api/v1/jobs/1",
+			headers:    map[string]string{"Authorization": "Bearer key-three"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "wrong scheme",
+			path:       "/api/v1/jobs/1",
+			headers:    map[string]string{"Authorization": "Basic key-one"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "missing key",
+			path:       "/api/v1/jobs/1",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "health bypass",
+			path:       "/api/v1/health",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := AuthMiddleware([]string{"key-one", "key-two"})(next)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rec.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "fast handler",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Test", "yes")
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("done"))
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   "done",
+		},
+		{
+			name: "handler ignoring its context",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(200 * time.Millisecond)
+				w.Write([]byte("late"))
+			},
+			wantStatus: http.StatusRequestTimeout,
+			wantBody:   "request timed out",
+		},
+		{
+			name: "handler watching its context",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+				w.Write([]byte("cancelled"))
+			},
+			wantStatus: http.StatusRequestTimeout,
+			wantBody:   "request timed out",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := TimeoutMiddleware(50 * time.Millisecond)(tt.handler)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("Body = %q, want it to contain %q", rec.Body.String(), tt.wantBody)
+			}
+			if tt.wantStatus == http.StatusCreated && rec.Header().Get("X-Test") != "yes" {
+				t.Error("buffered header X-Test not copied to the response")
+			}
+			if tt.wantStatus == http.StatusRequestTimeout && rec.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		path            string
+		logHealthChecks bool
+		wantLogged      bool
+		wantStatus      int
+	}{
+		{name: "unknown route", path: "/api/v1/missing", wantLogged: true, wantStatus: http.StatusNotFound},
+		{name: "health skipped", path: "/api/v1/health", wantLogged: false},
+		{name: "health logged", path: "/api/v1/health", logHealthChecks: true, wantLogged: true, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/v1/health", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("ok"))
+			})
+
+			var buf bytes.Buffer
+			handler := NewLoggingMiddleware(LoggingConfig{
+				Logger:          slog.New(slog.NewJSONHandler(&buf, nil)),
+				LogHealthChecks: tt.logHealthChecks,
+			})(mux)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if !tt.wantLogged {
+				if buf.Len() != 0 {
+					t.Errorf("logged %q, want nothing", buf.String())
+				}
+				return
+			}
+
+			var record struct {
+				Method string `json:"method"`
+				Path   string `json:"path"`
+				Status int    `json:"status"`
+				Bytes  int64  `json:"bytes"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("decoding log record %q: %v", buf.String(), err)
+			}
+			if record.Status != tt.wantStatus {
+				t.Errorf("logged status = %d, want %d", record.Status, tt.wantStatus)
+			}
+			if record.Method != http.MethodGet || record.Path != tt.path {
+				t.Errorf("logged %s %s, want GET %s", record.Method, record.Path, tt.path)
+			}
+			if record.Bytes != int64(rec.Body.Len()) {
+				t.Errorf("logged bytes = %d, want %d", record.Bytes, rec.Body.Len())
+			}
+		})
+	}
+}
+
+func TestStatusRecorder_PassThrough(t *testing.T) {
+	t.Parallel()
+
+	rec := &statusRecorder{ResponseWriter: httptest.NewRecorder()}
+
+	var w http.ResponseWriter = rec
+	if _, ok := w.(http.Flusher); !ok {
+		t.Error("statusRecorder does not implement http.Flusher")
+	}
+	if _, ok := w.(http.Hijacker); !ok {
+		t.Error("statusRecorder does not implement http.Hijacker")
+	}
+	if _, _, err := rec.Hijack(); err == nil {
+		t.Error("Hijack() on a non-hijackable writer should fail")
+	}
+}
@@ -0,0 +1,381 @@
+// ABOUTME: Storage for async Trivy dependency scan jobs
+// ABOUTME: Provides an in-memory store and a BadgerDB store with retention pruning
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/trivy"
+)
+
+// Key prefix for persisted Trivy jobs.
+const trivyJobPrefix = "trivy-job:"
+
+// errInterruptedByRestart is recorded on jobs that were pending or running
+// when the daemon stopped; nothing resumes them.
+const errInterruptedByRestart = "interrupted by restart"
+
+// TrivyJob represents an async dependency scan job.
+type TrivyJob struct {
+	ID             string             `json:"id"`
+	Status         string             `json:"status"`
+	Packages       []trivy.Package    `json:"packages"`
+	SeverityFilter []string           `json:"severity_filter,omitempty"`
+	Progress       trivy.ScanProgress `json:"progress"`
+	Result         *trivy.ScanResult  `json:"result,omitempty"`
+	Error          string             `json:"error,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+	StartedAt      *time.Time         `json:"started_at,omitempty"`
+	CompletedAt    *time.Time         `json:"completed_at,omitempty"`
+}
+
+// IsTerminal returns true if the job has finished, successfully or not,
+// or was cancelled.
+func (j *TrivyJob) IsTerminal() bool {
+	return j.Status == "completed" || j.Status == "failed" || j.Status == "cancelled"
+}
+
+// expired reports whether a terminal job completed longer than retention ago.
+func (j *TrivyJob) expired(retention time.Duration, now time.Time) bool {
+	if retention <= 0 || !j.IsTerminal() || j.CompletedAt == nil {
+		return false
+	}
+	return j.CompletedAt.Before(now.Add(-retention))
+}
+
+// TrivyJobStorage stores Trivy scan jobs for the dependency scan endpoints.
+type TrivyJobStorage interface {
+	Set(id string, job *TrivyJob)
+	Get(id string) (*TrivyJob, bool)
+	Delete(id string)
+	List() []*TrivyJob
+}
+
+// TrivyJobStore is an in-memory store for Trivy scan jobs.
+// Jobs are lost on restart; use BadgerTrivyJobStore to persist them.
+type TrivyJobStore struct {
+	mu   sync.RWMutex
+	jobs map[string]*TrivyJob
+	now  func() time.Time
+}
+
+// NewTrivyJobStore creates a new in-memory job store.
+func NewTrivyJobStore() *TrivyJobStore {
+	return &TrivyJobStore{
+		jobs: make(map[string]*TrivyJob),
+		now:  time.Now,
+	}
+}
+
+// Set stores a job.
+func (s *TrivyJobStore) Set(id string, job *TrivyJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[id] = job
+}
+
+// Get retrieves a job by ID.
+func (s *TrivyJobStore) Get(id string) (*TrivyJob, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[id]
+	return job, ok
+}
+
+// Delete removes a job.
+func (s *TrivyJobStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, id)
+}
+
+// List returns all stored jobs.
+func (s *TrivyJobStore) List() []*TrivyJob {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jobs := make([]*TrivyJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+// Prune deletes terminal jobs that completed longer than retention ago.
+// Returns the number of jobs deleted.
+func (s *TrivyJobStore) Prune(retention time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	deleted := 0
+	for id, job := range s.jobs {
+		if job.expired(retention, now) {
+			delete(s.jobs, id)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// StartGC starts a goroutine that prunes terminal jobs older than retention
+// every interval until ctx is cancelled.
+func (s *TrivyJobStore) StartGC(ctx context.Context, retention, interval time.Duration) {
+	go runTrivyJobGC(ctx, interval, func() {
+		s.Prune(retention)
+	})
+}
+
+// runTrivyJobGC calls prune every interval until ctx is cancelled.
+func runTrivyJobGC(ctx context.Context, interval time.Duration, prune func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			prune()
+		}
+	}
+}
+
+// BadgerTrivyJobStoreConfig holds configuration for BadgerTrivyJobStore.
+type BadgerTrivyJobStoreConfig struct {
+	// Path to the BadgerDB directory. Ignored if InMemory is true.
+	Path string
+
+	// InMemory enables in-memory storage (for testing).
+	InMemory bool
+
+	// Retention is how long completed and failed jobs are kept.
+	// Zero keeps them forever.
+	Retention time.Duration
+
+	// Logger receives storage errors, which the TrivyJobStorage methods
+	// cannot return. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// BadgerTrivyJobStore persists Trivy scan jobs in BadgerDB as JSON so
+// results survive daemon restarts.
+type BadgerTrivyJobStore struct {
+	db        *badger.DB
+	retention time.Duration
+	logger    *slog.Logger
+	now       func() time.Time
+}
+
+// NewBadgerTrivyJobStore opens a BadgerDB-backed job store. Jobs left
+// pending or running by a previous process are marked failed, since their
+// scans died with it.
+func NewBadgerTrivyJobStore(cfg BadgerTrivyJobStoreConfig) (*BadgerTrivyJobStore, error) {
+	opts := badger.DefaultOptions(cfg.Path).WithLogger(nil)
+	if cfg.InMemory {
+		opts = opts.WithInMemory(true)
+	}
+
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, fmt.Errorf("opening badger db: %w", err)
+	}
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	store := &BadgerTrivyJobStore{
+		db:        db,
+		retention: cfg.Retention,
+		logger:    logger,
+		now:       time.Now,
+	}
+	if err := store.failInterrupted(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// failInterrupted marks every pending or running job as failed.
+func (s *BadgerTrivyJobStore) failInterrupted() error {
+	jobs, err := s.list()
+	if err != nil {
+		return fmt.Errorf("listing jobs: %w", err)
+	}
+
+	now := s.now().UTC()
+	err = s.db.Update(func(txn *badger.Txn) error {
+		for _, job := range jobs {
+			if job.IsTerminal() {
+				continue
+			}
+			job.Status = "failed"
+			job.Error = errInterruptedByRestart
+			job.CompletedAt = &now
+
+			data, err := json.Marshal(job)
+			if err != nil {
+				return err
+			}
+			if err := txn.Set([]byte(trivyJobPrefix+job.ID), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failing interrupted jobs: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the database.
+func (s *BadgerTrivyJobStore) Close() error {
+	return s.db.Close()
+}
+
+// Set stores a job.
+func (s *BadgerTrivyJobStore) Set(id string, job *TrivyJob) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		s.logger.Error("marshaling trivy job", slog.String("job_id", id), slog.String("error", err.Error()))
+		return
+	}
+
+	err = s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(trivyJobPrefix+id), data)
+	})
+	if err != nil {
+		s.logger.Error("storing trivy job", slog.String("job_id", id), slog.String("error", err.Error()))
+	}
+}
+
+// Get retrieves a job by ID. Jobs past their retention are reported as
+// missing even before Prune removes them.
+func (s *BadgerTrivyJobStore) Get(id string) (*TrivyJob, bool) {
+	var job *TrivyJob
+
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(trivyJobPrefix + id))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			job = &TrivyJob{}
+			return json.Unmarshal(val, job)
+		})
+	})
+	if err != nil {
+		s.logger.Error("getting trivy job", slog.String("job_id", id), slog.String("error", err.Error()))
+		return nil, false
+	}
+
+	if job == nil || job.expired(s.retention, s.now()) {
+		return nil, false
+	}
+	return job, true
+}
+
+// Delete removes a job.
+func (s *BadgerTrivyJobStore) Delete(id string) {
+	err := s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(trivyJobPrefix + id))
+	})
+	if err != nil {
+		s.logger.Error("deleting trivy job", slog.String("job_id", id), slog.String("error", err.Error()))
+	}
+}
+
+// List returns all stored jobs, including ones past their retention that
+// have not been pruned yet.
+func (s *BadgerTrivyJobStore) List() []*TrivyJob {
+	jobs, err := s.list()
+	if err != nil {
+		s.logger.Error("listing trivy jobs", slog.String("error", err.Error()))
+	}
+	return jobs
+}
+
+func (s *BadgerTrivyJobStore) list() ([]*TrivyJob, error) {
+	var jobs []*TrivyJob
+
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(trivyJobPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				var job TrivyJob
+				if err := json.Unmarshal(val, &job); err != nil {
+					return nil // Skip malformed entries.
+				}
+				jobs = append(jobs, &job)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return jobs, err
+}
+
+// Prune deletes terminal jobs that completed longer than the retention ago.
+// Returns the number of jobs deleted.
+func (s *BadgerTrivyJobStore) Prune() (int, error) {
+	jobs, err := s.list()
+	if err != nil {
+		return 0, fmt.Errorf("listing jobs: %w", err)
+	}
+
+	now := s.now()
+	deleted := 0
+	err = s.db.Update(func(txn *badger.Txn) error {
+		for _, job := range jobs {
+			if !job.expired(s.retention, now) {
+				continue
+			}
+			if err := txn.Delete([]byte(trivyJobPrefix + job.ID)); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("deleting expired jobs: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// StartGC starts a goroutine that prunes jobs past the configured retention
+// every interval until ctx is cancelled.
+func (s *BadgerTrivyJobStore) StartGC(ctx context.Context, interval time.Duration) {
+	go runTrivyJobGC(ctx, interval, func() {
+		if _, err := s.Prune(); err != nil {
+			s.logger.Warn("pruning trivy jobs", slog.String("error", err.Error()))
+		}
+	})
+}
@@ -0,0 +1,178 @@
+// ABOUTME: Tests for the Trivy job stores
+// ABOUTME: Validates BadgerDB round-trips, persistence across reopen, retention pruning, and GC
+
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/trivy"
+)
+
+func TestBadgerTrivyJobStore_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	path := t.TempDir()
+	store, err := NewBadgerTrivyJobStore(BadgerTrivyJobStoreConfig{Path: path})
+	if err != nil {
+		t.Fatalf("NewBadgerTrivyJobStore() error = %v", err)
+	}
+
+	completed := time.Now().UTC().Truncate(time.Second)
+	job := &TrivyJob{
+		ID:       "job-1",
+		Status:   "completed",
+		Packages: []trivy.Package{{Name: "lodash", Version: "4.17.20", Ecosystem: "npm"}},
+		Result: &trivy.ScanResult{
+			Summary: trivy.ScanSummary{TotalVulnerabilities: 1, High: 1, PackagesScanned: 1},
+		},
+		CreatedAt:   completed.Add(-time.Minute),
+		CompletedAt: &completed,
+	}
+	store.Set(job.ID, job)
+	store.Set("job-2", &TrivyJob{ID: "job-2", Status: "pending"})
+	store.Set("job-3", &TrivyJob{ID: "job-3", Status: "running"})
+
+	// Reopen to verify the jobs survive a restart.
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	store, err = NewBadgerTrivyJobStore(BadgerTrivyJobStoreConfig{Path: path})
+	if err != nil {
+		t.Fatalf("reopening store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	got, ok := store.Get(job.ID)
+	if !ok {
+		t.Fatal("Get() found = false, want true")
+	}
+	if got.Status != job.Status || len(got.Packages) != 1 || got.Packages[0].Name != "lodash" {
+		t.Errorf("Get() = %+v, want %+v", got, job)
+	}
+	if got.Result == nil || got.Result.Summary.High != 1 {
+		t.Errorf("Get().Result = %+v, want summary with one high", got.Result)
+	}
+	if got.CompletedAt == nil || !got.CompletedAt.Equal(completed) {
+		t.Errorf("Get().CompletedAt = %v, want %v", got.CompletedAt, completed)
+	}
+
+	if jobs := store.List(); len(jobs) != 3 {
+		t.Errorf("List() returned %d jobs, want 3", len(jobs))
+	}
+
+	// Scans in flight at shutdown are never resumed.
+	for _, id := range []string{"job-2", "job-3"} {
+		got, ok := store.Get(id)
+		if !ok {
+			t.Fatalf("Get(%s) found = false, want true", id)
+		}
+		if got.Status != "failed" || got.Error != errInterruptedByRestart || got.CompletedAt == nil {
+			t.Errorf("Get(%s) = %+v, want failed with %q", id, got, errInterruptedByRestart)
+		}
+	}
+
+	store.Delete(job.ID)
+	if _, ok := store.Get(job.ID); ok {
+		t.Error("Get() after Delete() found = true, want false")
+	}
+	if _, ok := store.Get("missing"); ok {
+		t.Error("Get(missing) found = true, want false")
+	}
+}
+
+func TestBadgerTrivyJobStore_Prune(t *testing.T) {
+	t.Parallel()
+
+	store, err := NewBadgerTrivyJobStore(BadgerTrivyJobStoreConfig{
+		InMemory:  true,
+		Retention: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewBadgerTrivyJobStore() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	old := now.Add(-2 * time.Hour)
+	recent := now.Add(-time.Minute)
+	jobs := []*TrivyJob{
+		{ID: "old-completed", Status: "completed", CompletedAt: &old},
+		{ID: "old-failed", Status: "failed", CompletedAt: &old},
+		{ID: "recent-completed", Status: "completed", CompletedAt: &recent},
+		{ID: "old-running", Status: "running", CreatedAt: old},
+		{ID: "pending", Status: "pending", CreatedAt: old},
+	}
+	for _, job := range jobs {
+		store.Set(job.ID, job)
+	}
+
+	// Expired jobs are hidden before they are pruned.
+	if _, ok := store.Get("old-completed"); ok {
+		t.Error("Get(old-completed) found = true, want false")
+	}
+
+	deleted, err := store.Prune()
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Prune() deleted %d jobs, want 2", deleted)
+	}
+
+	remaining := make(map[string]bool)
+	for _, job := range store.List() {
+		remaining[job.ID] = true
+	}
+	for _, id := range []string{"recent-completed", "old-running", "pending"} {
+		if !remaining[id] {
+			t.Errorf("job %q was pruned, want kept", id)
+		}
+	}
+	if len(remaining) != 3 {
+		t.Errorf("List() returned %d jobs, want 3", len(remaining))
+	}
+}
+
+func TestTrivyJobStore_StartGC(t *testing.T) {
+	t.Parallel()
+
+	store := NewTrivyJobStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	old := now.Add(-2 * time.Hour)
+	recent := now.Add(-time.Minute)
+	store.Set("old-completed", &TrivyJob{ID: "old-completed", Status: "completed", CompletedAt: &old})
+	store.Set("old-failed", &TrivyJob{ID: "old-failed", Status: "failed", CompletedAt: &old})
+	store.Set("recent-completed", &TrivyJob{ID: "recent-completed", Status: "completed", CompletedAt: &recent})
+	store.Set("pending", &TrivyJob{ID: "pending", Status: "pending", CreatedAt: old})
+	store.Set("running", &TrivyJob{ID: "running", Status: "running", CreatedAt: old})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	store.StartGC(ctx, time.Hour, 5*time.Millisecond)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(store.List()) > 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("GC did not prune old jobs, %d jobs remain", len(store.List()))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	for _, id := range []string{"old-completed", "old-failed"} {
+		if _, ok := store.Get(id); ok {
+			t.Errorf("job %q survived GC, want pruned", id)
+		}
+	}
+	for _, id := range []string{"recent-completed", "pending", "running"} {
+		if _, ok := store.Get(id); !ok {
+			t.Errorf("job %q was pruned, want kept", id)
+		}
+	}
+}
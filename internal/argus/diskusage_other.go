@@ -0,0 +1,13 @@
+//go:build !unix
+
+// ABOUTME: Fallback disk usage check for platforms without statfs
+// ABOUTME: Always fails, so temp disk backpressure never pauses reads
+
+package argus
+
+import "errors"
+
+// diskUsage is not supported on this platform.
+func diskUsage(path string) (float64, error) {
+	return 0, errors.New("disk usage is not supported on this platform")
+}
@@ -0,0 +1,24 @@
+//go:build unix
+
+// ABOUTME: Reports filesystem usage for the worker's temp disk backpressure
+// ABOUTME: Uses statfs to compute the used fraction of a filesystem
+
+package argus
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// diskUsage returns the used fraction of the filesystem holding path, as
+// seen by unprivileged users.
+func diskUsage(path string) (float64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, fmt.Errorf("statfs %s: %w", path, err)
+	}
+	if st.Blocks == 0 {
+		return 0, nil
+	}
+	return 1 - float64(st.Bavail)/float64(st.Blocks), nil
+}
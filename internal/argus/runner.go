@@ -26,6 +26,13 @@ type ClamAVScanner interface {
 	ScanDirectory(ctx context.Context, path string) ([]*types.ScanResult, error)
 }
 
+// ProgressClamAVScanner is a ClamAVScanner that reports directory scan
+// progress. progress receives the number of files completed since its
+// previous call and the total number of files, possibly concurrently.
+type ProgressClamAVScanner interface {
+	ScanDirectoryWithProgress(ctx context.Context, path string, progress func(scanned, total int)) ([]*types.ScanResult, error)
+}
+
 // RunnerConfig holds configuration for the scanner runner.
 type RunnerConfig struct {
 	TrivyScanner  TrivyScanner
@@ -46,18 +53,19 @@ func NewRunner(cfg RunnerConfig) *Runner {
 	}
 }
 
-// RunTrivy runs the Trivy scanner on the given path.
+// RunTrivy runs the Trivy scanner on the given path with default options.
 func (r *Runner) RunTrivy(ctx context.Context, path string) (*TrivyResults, error) {
+	return r.RunTrivyWithOptions(ctx, path, nil)
+}
+
+// RunTrivyWithOptions runs the Trivy scanner on the given path with the
+// task's scan options. A nil opts uses the defaults.
+func (r *Runner) RunTrivyWithOptions(ctx context.Context, path string, opts *ScanOptions) (*TrivyResults, error) {
 	if r.trivyScanner == nil {
 		return nil, fmt.Errorf("trivy scanner not configured")
 	}
 
-	opts := trivy.ScanOptions{
-		SeverityFilter: []string{trivy.SeverityCritical, trivy.SeverityHigh},
-		ScanSecrets:    true,
-	}
-
-	result, err := r.trivyScanner.ScanPath(ctx, path, opts)
+	result, err := r.trivyScanner.ScanPath(ctx, path, opts.TrivyOptions())
 	if err != nil {
 		return nil, fmt.Errorf("trivy scan: %w", err)
 	}
@@ -67,6 +75,14 @@ func (r *Runner) RunTrivy(ctx context.Context, path string) (*TrivyResults, erro
 
 // RunClamAV runs the ClamAV scanner on the given directory.
 func (r *Runner) RunClamAV(ctx context.Context, path string) (*ClamAVResults, error) {
+	return r.RunClamAVWithProgress(ctx, path, nil)
+}
+
+// RunClamAVWithProgress runs the ClamAV scanner on the given directory,
+// calling progress as files are scanned (see ProgressClamAVScanner).
+// Scanners that cannot report progress report every file at the end. A nil
+// progress is ignored.
+func (r *Runner) RunClamAVWithProgress(ctx context.Context, path string, progress func(scanned, total int)) (*ClamAVResults, error) {
 	if r.clamavScanner == nil {
 		return nil, fmt.Errorf("clamav scanner not configured")
 	}
@@ -74,7 +90,16 @@ func (r *Runner) RunClamAV(ctx context.Context, path string) (*ClamAVResults, er
 	start := time.Now()
 
 	// Scan the directory.
-	results, err := r.clamavScanner.ScanDirectory(ctx, path)
+	var results []*types.ScanResult
+	var err error
+	if ps, ok := r.clamavScanner.(ProgressClamAVScanner); ok && progress != nil {
+		results, err = ps.ScanDirectoryWithProgress(ctx, path, progress)
+	} else {
+		results, err = r.clamavScanner.ScanDirectory(ctx, path)
+		if err == nil && progress != nil && len(results) > 0 {
+			progress(len(results), len(results))
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("clamav scan: %w", err)
 	}
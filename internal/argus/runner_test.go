@@ -6,6 +6,9 @@ package argus
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
@@ -18,9 +21,13 @@ type MockTrivyScanner struct {
 	Result *trivy.ScanResult
 	Err    error
 	Delay  time.Duration
+
+	// Opts records the options of the last ScanPath call.
+	Opts trivy.ScanOptions
 }
 
 func (m *MockTrivyScanner) ScanPath(ctx context.Context, path string, opts trivy.ScanOptions) (*trivy.ScanResult, error) {
+	m.Opts = opts
 	if m.Delay > 0 {
 		select {
 		case <-time.After(m.Delay):
@@ -142,6 +149,57 @@ func TestRunner_RunTrivy_Error(t *testing.T) {
 	}
 }
 
+func TestRunner_RunTrivyWithOptions(t *testing.T) {
+	t.Parallel()
+
+	scanSecrets := false
+	tests := []struct {
+		name         string
+		opts         *ScanOptions
+		wantSeverity []string
+		wantSecrets  bool
+	}{
+		{
+			name:         "nil options use defaults",
+			opts:         nil,
+			wantSeverity: []string{"CRITICAL", "HIGH"},
+			wantSecrets:  true,
+		},
+		{
+			name:         "severity filter only",
+			opts:         &ScanOptions{SeverityFilter: []string{"critical", "HIGH", "Medium"}},
+			wantSeverity: []string{"CRITICAL", "HIGH", "MEDIUM"},
+			wantSecrets:  true,
+		},
+		{
+			name:         "secrets disabled",
+			opts:         &ScanOptions{ScanSecrets: &scanSecrets},
+			wantSeverity: []string{"CRITICAL", "HIGH"},
+			wantSecrets:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &MockTrivyScanner{Result: &trivy.ScanResult{}}
+			runner := NewRunner(RunnerConfig{TrivyScanner: mock})
+
+			if _, err := runner.RunTrivyWithOptions(context.Background(), "/tmp/skill", tt.opts); err != nil {
+				t.Fatalf("RunTrivyWithOptions() error = %v", err)
+			}
+
+			if !slices.Equal(mock.Opts.SeverityFilter, tt.wantSeverity) {
+				t.Errorf("SeverityFilter = %v, want %v", mock.Opts.SeverityFilter, tt.wantSeverity)
+			}
+			if mock.Opts.ScanSecrets != tt.wantSecrets {
+				t.Errorf("ScanSecrets = %v, want %v", mock.Opts.ScanSecrets, tt.wantSecrets)
+			}
+		})
+	}
+}
+
 func TestRunner_RunClamAV(t *testing.T) {
 	t.Parallel()
 
@@ -195,6 +253,80 @@ func TestRunner_RunClamAV_Clean(t *testing.T) {
 	}
 }
 
+// progressClamAVScanner reports each of its files through the progress
+// callback from its own goroutine.
+type progressClamAVScanner struct {
+	MockClamAVScanner
+	files int
+}
+
+func (m *progressClamAVScanner) ScanDirectoryWithProgress(ctx context.Context, path string, progress func(scanned, total int)) ([]*types.ScanResult, error) {
+	var wg sync.WaitGroup
+	results := make([]*types.ScanResult, m.files)
+	for i := range m.files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = &types.ScanResult{Status: types.ScanStatusClean, FilePath: fmt.Sprintf("%s/%d.txt", path, i)}
+			progress(1, m.files)
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
+
+func TestRunner_RunClamAVWithProgress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		scanner     ClamAVScanner
+		wantScanned int
+		wantCalls   int
+	}{
+		{
+			name:        "scanner reports progress per file",
+			scanner:     &progressClamAVScanner{files: 5},
+			wantScanned: 5,
+			wantCalls:   5,
+		},
+		{
+			name:        "scanner without progress reports at the end",
+			scanner:     &MockClamAVScanner{Result: &types.ScanResult{Status: types.ScanStatusClean}},
+			wantScanned: 1,
+			wantCalls:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu             sync.Mutex
+				scanned, calls int
+			)
+			runner := NewRunner(RunnerConfig{ClamAVScanner: tt.scanner})
+			result, err := runner.RunClamAVWithProgress(context.Background(), "/tmp/skill", func(n, total int) {
+				mu.Lock()
+				defer mu.Unlock()
+				scanned += n
+				calls++
+			})
+			if err != nil {
+				t.Fatalf("RunClamAVWithProgress() error = %v", err)
+			}
+
+			if scanned != tt.wantScanned || calls != tt.wantCalls {
+				t.Errorf("progress = %d files in %d calls, want %d in %d", scanned, calls, tt.wantScanned, tt.wantCalls)
+			}
+			if result.ScanSummary.FilesScanned != tt.wantScanned {
+				t.Errorf("FilesScanned = %d, want %d", result.ScanSummary.FilesScanned, tt.wantScanned)
+			}
+		})
+	}
+}
+
 func TestRunner_RunAll(t *testing.T) {
 	t.Parallel()
 
@@ -7,7 +7,11 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"strings"
 	"time"
+	"unicode"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/trivy"
 )
 
 // ScannerName represents a supported scanner type.
@@ -70,9 +74,16 @@ type TaskMessage struct {
 	// Format: gs://bucket/org_id/skills/sha256.zip
 	GCSURI string `json:"gcs_uri"`
 
+	// Checksum is the expected SHA256 of the skill archive (optional).
+	// When set, the downloaded file is verified against it.
+	Checksum string `json:"checksum,omitempty"`
+
 	// Scanners is the list of scanners to run (e.g., ["trivy", "clamav"]).
 	Scanners []string `json:"scanners"`
 
+	// ScanOptions overrides the default scanner settings (optional).
+	ScanOptions *ScanOptions `json:"scan_options,omitempty"`
+
 	// RetryCount tracks how many times this task has been retried.
 	RetryCount int `json:"retry_count"`
 
@@ -91,6 +102,9 @@ func (m *TaskMessage) Validate() error {
 	if m.JobID == "" {
 		return errors.New("job_id is required")
 	}
+	if !isPathSegment(m.JobID) {
+		return fmt.Errorf("invalid job_id %q: must be a single path segment", m.JobID)
+	}
 	if m.OrganizationID == "" {
 		return errors.New("organization_id is required")
 	}
@@ -105,9 +119,24 @@ func (m *TaskMessage) Validate() error {
 			return fmt.Errorf("invalid scanner: %q (valid: trivy, clamav)", s)
 		}
 	}
+	if m.ScanOptions != nil {
+		if err := m.ScanOptions.Validate(); err != nil {
+			return fmt.Errorf("scan_options: %w", err)
+		}
+	}
 	return nil
 }
 
+// isPathSegment reports whether s can be used as one object path segment,
+// such as the job directory of a report: it must not contain separators,
+// "..", or control characters, and must not be ".".
+func isPathSegment(s string) bool {
+	if s == "." || strings.Contains(s, "..") || strings.ContainsAny(s, `/\`) {
+		return false
+	}
+	return !strings.ContainsFunc(s, unicode.IsControl)
+}
+
 // HasScanner checks if the task includes a specific scanner.
 func (m *TaskMessage) HasScanner(name ScannerName) bool {
 	for _, s := range m.Scanners {
@@ -126,6 +155,50 @@ func (m *TaskMessage) Timeout() time.Duration {
 	return 15 * time.Minute // Default: 15 minutes.
 }
 
+// ScanOptions carries per-task scanner settings. Unset fields keep the
+// runner defaults: CRITICAL and HIGH vulnerabilities, with secret scanning.
+type ScanOptions struct {
+	// SeverityFilter lists the vulnerability severities to report
+	// (e.g., ["CRITICAL", "HIGH", "MEDIUM"]). Matching is case-insensitive.
+	SeverityFilter []string `json:"severity_filter,omitempty"`
+
+	// ScanSecrets enables secret detection.
+	ScanSecrets *bool `json:"scan_secrets,omitempty"`
+}
+
+// Validate checks that every severity in the filter is known.
+func (o *ScanOptions) Validate() error {
+	for _, sev := range o.SeverityFilter {
+		if !trivy.IsValidSeverity(strings.ToUpper(sev)) {
+			return fmt.Errorf("invalid severity: %q (valid: CRITICAL, HIGH, MEDIUM, LOW, UNKNOWN)", sev)
+		}
+	}
+	return nil
+}
+
+// TrivyOptions returns the Trivy scan options for o, falling back to the
+// defaults for unset fields. A nil o yields the defaults.
+func (o *ScanOptions) TrivyOptions() trivy.ScanOptions {
+	opts := trivy.ScanOptions{
+		SeverityFilter: []string{trivy.SeverityCritical, trivy.SeverityHigh},
+		ScanSecrets:    true,
+	}
+	if o == nil {
+		return opts
+	}
+
+	if len(o.SeverityFilter) > 0 {
+		opts.SeverityFilter = make([]string, len(o.SeverityFilter))
+		for i, sev := range o.SeverityFilter {
+			opts.SeverityFilter[i] = strings.ToUpper(sev)
+		}
+	}
+	if o.ScanSecrets != nil {
+		opts.ScanSecrets = *o.ScanSecrets
+	}
+	return opts
+}
+
 // ArgusStatus tracks the status of each scanner.
 type ArgusStatus struct {
 	Trivy  ScannerStatus `json:"trivy"`
@@ -258,10 +331,89 @@ func (r *ArgusResults) AddError(scanner, errMsg string) {
 	r.Errors[scanner] = errMsg
 }
 
+// Verdict values summarize a scan in a single actionable field.
+const (
+	VerdictClean      = "clean"
+	VerdictMalicious  = "malicious"
+	VerdictVulnerable = "vulnerable"
+	VerdictError      = "error"
+)
+
+// Verdict derives the overall outcome of the scan. Findings take precedence
+// over errors: any ClamAV infection is malicious, and otherwise any critical
+// or high Trivy vulnerability or secret is vulnerable. A scan without
+// findings is an error if a scanner failed or ClamAV could not read some
+// files, since it cannot be vouched for as clean.
+func (r ArgusResults) Verdict() string {
+	switch {
+	case r.ClamAV != nil && (r.ClamAV.ScanSummary.InfectedCount > 0 || len(r.ClamAV.InfectedFiles) > 0):
+		return VerdictMalicious
+	case r.severityCount(trivy.SeverityCritical)+r.severityCount(trivy.SeverityHigh) > 0:
+		return VerdictVulnerable
+	case r.HasErrors() || (r.ClamAV != nil && r.ClamAV.ScanSummary.ErrorCount > 0):
+		return VerdictError
+	default:
+		return VerdictClean
+	}
+}
+
+// HighestSeverity returns the most severe finding as a Trivy severity, or ""
+// when nothing was found. ClamAV infections count as CRITICAL.
+func (r ArgusResults) HighestSeverity() string {
+	if r.ClamAV != nil && (r.ClamAV.ScanSummary.InfectedCount > 0 || len(r.ClamAV.InfectedFiles) > 0) {
+		return trivy.SeverityCritical
+	}
+
+	for _, severity := range []string{trivy.SeverityCritical, trivy.SeverityHigh, trivy.SeverityMedium, trivy.SeverityLow} {
+		if r.severityCount(severity) > 0 {
+			return severity
+		}
+	}
+	return ""
+}
+
+// severityCount returns the number of Trivy vulnerabilities and secrets
+// with the given severity.
+func (r ArgusResults) severityCount(severity string) int {
+	if r.Trivy == nil {
+		return 0
+	}
+
+	var count int
+	summary, secrets := r.Trivy.Summary, r.Trivy.SecretSummary
+	switch severity {
+	case trivy.SeverityCritical:
+		count = summary.Critical
+		if secrets != nil {
+			count += secrets.Critical
+		}
+	case trivy.SeverityHigh:
+		count = summary.High
+		if secrets != nil {
+			count += secrets.High
+		}
+	case trivy.SeverityMedium:
+		count = summary.Medium
+		if secrets != nil {
+			count += secrets.Medium
+		}
+	case trivy.SeverityLow:
+		count = summary.Low
+		if secrets != nil {
+			count += secrets.Low
+		}
+	}
+	return count
+}
+
 // CompletionSignal is published to Redis when scanning completes.
 type CompletionSignal struct {
 	JobID       string        `json:"job_id"`
 	Status      string        `json:"status"` // "completed" or "failed"
 	CompletedAt time.Time     `json:"completed_at"`
 	Results     *ArgusResults `json:"results,omitempty"`
+
+	// Verdict is the overall outcome: clean, malicious, vulnerable, or
+	// error. It is empty for cancelled jobs.
+	Verdict string `json:"verdict,omitempty"`
 }
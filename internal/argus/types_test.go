@@ -5,6 +5,7 @@ package argus
 
 import (
 	"encoding/json"
+	"slices"
 	"testing"
 	"time"
 )
@@ -59,6 +60,56 @@ func TestArgusTaskMessage_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "job id with slash",
+			msg: TaskMessage{
+				JobID:          "job/../../other-org/job",
+				OrganizationID: "org-789",
+				GCSURI:         "gs://bucket/org-789/skills/skill.zip",
+				Scanners:       []string{"trivy"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "job id with backslash",
+			msg: TaskMessage{
+				JobID:          `job\123`,
+				OrganizationID: "org-789",
+				GCSURI:         "gs://bucket/org-789/skills/skill.zip",
+				Scanners:       []string{"trivy"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "job id dot-dot",
+			msg: TaskMessage{
+				JobID:          "..",
+				OrganizationID: "org-789",
+				GCSURI:         "gs://bucket/org-789/skills/skill.zip",
+				Scanners:       []string{"trivy"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "job id dot",
+			msg: TaskMessage{
+				JobID:          ".",
+				OrganizationID: "org-789",
+				GCSURI:         "gs://bucket/org-789/skills/skill.zip",
+				Scanners:       []string{"trivy"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "job id with control character",
+			msg: TaskMessage{
+				JobID:          "job-123\n",
+				OrganizationID: "org-789",
+				GCSURI:         "gs://bucket/org-789/skills/skill.zip",
+				Scanners:       []string{"trivy"},
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid scanner",
 			msg: TaskMessage{
@@ -69,6 +120,28 @@ func TestArgusTaskMessage_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid scan options",
+			msg: TaskMessage{
+				JobID:          "job-123",
+				OrganizationID: "org-789",
+				GCSURI:         "gs://bucket/org-789/skills/skill.zip",
+				Scanners:       []string{"trivy"},
+				ScanOptions:    &ScanOptions{SeverityFilter: []string{"critical", "MEDIUM"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid severity",
+			msg: TaskMessage{
+				JobID:          "job-123",
+				OrganizationID: "org-789",
+				GCSURI:         "gs://bucket/org-789/skills/skill.zip",
+				Scanners:       []string{"trivy"},
+				ScanOptions:    &ScanOptions{SeverityFilter: []string{"SEVERE"}},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -270,6 +343,61 @@ func TestTaskMessage_JSON(t *testing.T) {
 	}
 }
 
+func TestTaskMessage_ScanOptionsJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		data         string
+		wantOptions  bool
+		wantSeverity []string
+		wantSecrets  *bool
+	}{
+		{
+			name: "absent",
+			data: `{"job_id":"job-123","scanners":["trivy"]}`,
+		},
+		{
+			name:         "severity filter and secrets",
+			data:         `{"job_id":"job-123","scanners":["trivy"],"scan_options":{"severity_filter":["CRITICAL","HIGH","MEDIUM"],"scan_secrets":false}}`,
+			wantOptions:  true,
+			wantSeverity: []string{"CRITICAL", "HIGH", "MEDIUM"},
+			wantSecrets:  new(bool),
+		},
+		{
+			name:         "severity filter only",
+			data:         `{"job_id":"job-123","scanners":["trivy"],"scan_options":{"severity_filter":["LOW"]}}`,
+			wantOptions:  true,
+			wantSeverity: []string{"LOW"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var msg TaskMessage
+			if err := json.Unmarshal([]byte(tt.data), &msg); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+
+			if (msg.ScanOptions != nil) != tt.wantOptions {
+				t.Fatalf("ScanOptions = %+v, want present = %v", msg.ScanOptions, tt.wantOptions)
+			}
+			if msg.ScanOptions == nil {
+				return
+			}
+			if !slices.Equal(msg.ScanOptions.SeverityFilter, tt.wantSeverity) {
+				t.Errorf("SeverityFilter = %v, want %v", msg.ScanOptions.SeverityFilter, tt.wantSeverity)
+			}
+			got, want := msg.ScanOptions.ScanSecrets, tt.wantSecrets
+			if (got == nil) != (want == nil) || (got != nil && *got != *want) {
+				t.Errorf("ScanSecrets = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
 func TestArgusResults_HasErrors(t *testing.T) {
 	t.Parallel()
 
@@ -303,6 +431,95 @@ func TestArgusResults_HasErrors(t *testing.T) {
 	}
 }
 
+func TestArgusResults_Verdict(t *testing.T) {
+	t.Parallel()
+
+	infected := &ClamAVResults{
+		InfectedFiles: []InfectedFile{{Path: "evil.exe", ThreatName: "Eicar-Test-Signature"}},
+		ScanSummary:   ClamScanSummary{FilesScanned: 2, InfectedCount: 1},
+	}
+	cleanClam := &ClamAVResults{ScanSummary: ClamScanSummary{FilesScanned: 2}}
+	cleanTrivy := &TrivyResults{Summary: TrivySummary{PackagesScanned: 3}}
+	criticalTrivy := &TrivyResults{Summary: TrivySummary{TotalVulnerabilities: 2, Critical: 1, Low: 1}}
+
+	tests := []struct {
+		name         string
+		results      ArgusResults
+		wantVerdict  string
+		wantSeverity string
+	}{
+		{
+			name:         "clamav infected, trivy clean",
+			results:      ArgusResults{ClamAV: infected, Trivy: cleanTrivy},
+			wantVerdict:  VerdictMalicious,
+			wantSeverity: "CRITICAL",
+		},
+		{
+			name:         "clamav clean, trivy critical",
+			results:      ArgusResults{ClamAV: cleanClam, Trivy: criticalTrivy},
+			wantVerdict:  VerdictVulnerable,
+			wantSeverity: "CRITICAL",
+		},
+		{
+			name: "both scanners errored",
+			results: ArgusResults{
+				Errors: map[string]string{"trivy": "timeout", "clamav": "connection refused"},
+			},
+			wantVerdict: VerdictError,
+		},
+		{
+			name:        "both clean",
+			results:     ArgusResults{ClamAV: cleanClam, Trivy: cleanTrivy},
+			wantVerdict: VerdictClean,
+		},
+		{
+			name: "medium vulnerabilities only",
+			results: ArgusResults{
+				Trivy: &TrivyResults{Summary: TrivySummary{TotalVulnerabilities: 1, Medium: 1}},
+			},
+			wantVerdict:  VerdictClean,
+			wantSeverity: "MEDIUM",
+		},
+		{
+			name: "high severity secret",
+			results: ArgusResults{
+				Trivy: &TrivyResults{SecretSummary: &SecretSummary{TotalSecrets: 1, High: 1}},
+			},
+			wantVerdict:  VerdictVulnerable,
+			wantSeverity: "HIGH",
+		},
+		{
+			name: "trivy critical despite clamav error",
+			results: ArgusResults{
+				Trivy:  criticalTrivy,
+				Errors: map[string]string{"clamav": "connection refused"},
+			},
+			wantVerdict:  VerdictVulnerable,
+			wantSeverity: "CRITICAL",
+		},
+		{
+			name: "clamav could not read some files",
+			results: ArgusResults{
+				ClamAV: &ClamAVResults{ScanSummary: ClamScanSummary{FilesScanned: 2, ErrorCount: 1}},
+			},
+			wantVerdict: VerdictError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.results.Verdict(); got != tt.wantVerdict {
+				t.Errorf("Verdict() = %q, want %q", got, tt.wantVerdict)
+			}
+			if got := tt.results.HighestSeverity(); got != tt.wantSeverity {
+				t.Errorf("HighestSeverity() = %q, want %q", got, tt.wantSeverity)
+			}
+		})
+	}
+}
+
 func TestInfectedFile(t *testing.T) {
 	t.Parallel()
 
@@ -4,14 +4,15 @@
 package argus
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"os"
-	"path/filepath"
-	"strings"
+	"path"
+	"strconv"
 	"sync"
 	"time"
 
@@ -38,12 +39,35 @@ type WorkerConfig struct {
 	// CancelPrefix is the prefix for cancellation Pub/Sub channels.
 	CancelPrefix string
 
-	// Workers is the number of concurrent processing goroutines.
+	// Workers is the number of goroutines reading tasks from the stream.
 	Workers int
 
+	// MaxConcurrentScans bounds how many tasks download and scan at once
+	// across all read loops, including reclaimed ones. A loop waits for a
+	// free slot before reading, so a saturated node leaves tasks in the
+	// stream for other consumers. Zero allows one scan per worker.
+	MaxConcurrentScans int
+
+	// TempDir is the directory whose filesystem is checked against
+	// MaxTempDiskUsage, normally the GCS download directory. Empty uses
+	// os.TempDir().
+	TempDir string
+
+	// MaxTempDiskUsage pauses reads while the filesystem holding TempDir is
+	// fuller than this fraction (e.g. 0.9 for 90%). Zero disables the check.
+	MaxTempDiskUsage float64
+
+	// DiskCheckInterval is how often disk usage is rechecked while reads
+	// are paused.
+	DiskCheckInterval time.Duration
+
 	// DefaultTimeout for scan operations.
 	DefaultTimeout time.Duration
 
+	// MaxTaskTimeout caps the timeout a task may request with
+	// timeout_seconds (default: DefaultTimeout).
+	MaxTaskTimeout time.Duration
+
 	// MaxRetries before giving up on a task.
 	MaxRetries int
 
@@ -52,6 +76,28 @@ type WorkerConfig struct {
 
 	// StateTTL is the TTL for job state entries.
 	StateTTL time.Duration
+
+	// ClaimMinIdle is how long a message must sit unacknowledged before it is
+	// considered abandoned by a dead consumer and reclaimed. It must exceed
+	// MaxTaskTimeout so in-flight scans are not claimed twice (default:
+	// MaxTaskTimeout + 5m).
+	ClaimMinIdle time.Duration
+
+	// ClaimInterval is how often abandoned messages are checked for.
+	ClaimInterval time.Duration
+
+	// DeadLetterStream is the Redis stream that receives tasks which failed
+	// to parse or exhausted MaxRetries, with the failure reason attached.
+	DeadLetterStream string
+
+	// ReportPrefix is the GCS object prefix under which the full JSON report
+	// of each completed scan is archived as {prefix}/{org}/{job}/report.json.
+	// Empty disables report uploads.
+	ReportPrefix string
+
+	// DrainTimeout is how long Stop lets in-flight tasks run to completion
+	// before cancelling them.
+	DrainTimeout time.Duration
 }
 
 // Validate checks that required fields are set and applies defaults.
@@ -74,6 +120,18 @@ func (c *WorkerConfig) Validate() error {
 	if c.Workers <= 0 {
 		c.Workers = 2
 	}
+	if c.MaxConcurrentScans <= 0 {
+		c.MaxConcurrentScans = c.Workers
+	}
+	if c.MaxTempDiskUsage < 0 || c.MaxTempDiskUsage >= 1 {
+		return errors.New("max_temp_disk_usage must be between 0 and 1")
+	}
+	if c.TempDir == "" {
+		c.TempDir = os.TempDir()
+	}
+	if c.DiskCheckInterval <= 0 {
+		c.DiskCheckInterval = 10 * time.Second
+	}
 	if c.DefaultTimeout <= 0 {
 		c.DefaultTimeout = 15 * time.Minute
 	}
@@ -83,9 +141,33 @@ func (c *WorkerConfig) Validate() error {
 	if c.StateTTL <= 0 {
 		c.StateTTL = 7 * 24 * time.Hour // 7 days
 	}
+	if c.MaxTaskTimeout < c.DefaultTimeout {
+		c.MaxTaskTimeout = c.DefaultTimeout
+	}
+	if c.ClaimMinIdle <= 0 {
+		c.ClaimMinIdle = c.MaxTaskTimeout + 5*time.Minute
+	}
+	if c.ClaimMinIdle <= c.MaxTaskTimeout {
+		return errors.New("claim_min_idle must exceed the longest task timeout")
+	}
+	if c.ClaimInterval <= 0 {
+		c.ClaimInterval = time.Minute
+	}
+	if c.DeadLetterStream == "" {
+		c.DeadLetterStream = "argus_dead_letter"
+	}
+	if c.DrainTimeout <= 0 {
+		c.DrainTimeout = 30 * time.Second
+	}
 	return nil
 }
 
+// attemptsField is the job state field counting failed processing attempts.
+const attemptsField = "attempts"
+
+// errInvalidTask marks failures that retrying cannot fix.
+var errInvalidTask = errors.New("invalid task")
+
 // TaskProcessor processes a single task.
 type TaskProcessor interface {
 	Process(ctx context.Context, msg *TaskMessage) (*ArgusResults, error)
@@ -103,6 +185,18 @@ type Worker struct {
 
 	stopCh chan struct{}
 	wg     sync.WaitGroup
+
+	// scanSlots holds one token per running task, bounding concurrent
+	// scans to MaxConcurrentScans.
+	scanSlots chan struct{}
+
+	// diskUsage reports the used fraction of the filesystem holding a path.
+	diskUsage func(path string) (float64, error)
+
+	// stopReading cancels stream reads when Stop is called; cancelTasks
+	// cancels in-flight tasks once the drain timeout expires.
+	stopReading context.CancelFunc
+	cancelTasks context.CancelFunc
 }
 
 // NewWorker creates a new Argus worker.
@@ -147,6 +241,10 @@ func NewWorker(
 		runner:       runner,
 		logger:       logger,
 		stopCh:       make(chan struct{}),
+		scanSlots:    make(chan struct{}, cfg.MaxConcurrentScans),
+		diskUsage:    diskUsage,
+		stopReading:  func() {},
+		cancelTasks:  func() {},
 	}, nil
 }
 
@@ -162,21 +260,64 @@ func (w *Worker) Start(ctx context.Context) error {
 		slog.String("group", w.config.ConsumerGroup),
 		slog.String("name", w.config.ConsumerName),
 		slog.Int("workers", w.config.Workers),
+		slog.Int("max_concurrent_scans", w.config.MaxConcurrentScans),
 	)
 
+	// Reads stop as soon as Stop is called, while tasks already read keep
+	// running until they finish or the drain timeout expires.
+	readCtx, stopReading := context.WithCancel(ctx)
+	taskCtx, cancelTasks := context.WithCancel(ctx)
+	w.stopReading, w.cancelTasks = stopReading, cancelTasks
+
 	// Start worker goroutines.
 	for i := 0; i < w.config.Workers; i++ {
 		w.wg.Add(1)
-		go w.processLoop(ctx, i)
+		go w.processLoop(readCtx, taskCtx, i)
 	}
 
+	// Recover messages left pending by crashed consumers.
+	w.wg.Add(1)
+	go w.reclaimLoop(readCtx, taskCtx)
+
 	return nil
 }
 
-// Stop signals the worker to stop processing.
+// RedisHealth returns the Redis connection state from the worker's most
+// recent ping; the read loop pings whenever a read fails.
+func (w *Worker) RedisHealth() redis.Health {
+	return w.redisClient.Health()
+}
+
+// Stop stops reading new tasks and gives in-flight tasks up to
+// DrainTimeout to finish, including publishing their completion signals.
+// Tasks still running after that are cancelled; their messages stay
+// pending so another consumer can reclaim them. Stop also waits for any
+// blocking stream read to return, which takes at most the read block
+// timeout.
 func (w *Worker) Stop() {
 	close(w.stopCh)
-	w.wg.Wait()
+	w.stopReading()
+
+	drained := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(drained)
+	}()
+
+	timer := time.NewTimer(w.config.DrainTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+	case <-timer.C:
+		w.logger.Warn("drain timeout exceeded, cancelling in-flight tasks",
+			slog.Duration("drain_timeout", w.config.DrainTimeout),
+		)
+		w.cancelTasks()
+		<-drained
+	}
+
+	w.cancelTasks()
 	w.logger.Info("argus worker stopped")
 }
 
@@ -189,7 +330,10 @@ const (
 	readBackoffFactor  = 2
 )
 
-func (w *Worker) processLoop(ctx context.Context, workerID int) {
+// processLoop reads messages with readCtx and processes them with taskCtx,
+// so Stop can interrupt a blocking read without cancelling the task being
+// processed.
+func (w *Worker) processLoop(readCtx, taskCtx context.Context, workerID int) {
 	defer w.wg.Done()
 
 	logger := w.logger.With(slog.Int("worker_id", workerID))
@@ -202,15 +346,39 @@ func (w *Worker) processLoop(ctx context.Context, workerID int) {
 		case <-w.stopCh:
 			logger.Debug("worker stopping")
 			return
-		case <-ctx.Done():
+		case <-readCtx.Done():
 			logger.Debug("context cancelled")
 			return
 		default:
 		}
 
+		if !w.acquireScanSlot(readCtx, logger) {
+			logger.Debug("worker stopping")
+			return
+		}
+
 		// Read messages.
-		messages, err := w.consumer.Read(ctx, 1)
+		messages, err := w.consumer.Read(readCtx, 1)
 		if err != nil {
+			w.releaseScanSlot()
+			if readCtx.Err() != nil {
+				logger.Debug("worker stopping")
+				return
+			}
+
+			// A lost connection is waited out here rather than logged on
+			// every retry.
+			if w.redisClient.Ping(readCtx) != nil {
+				logger.Warn("redis connection lost, waiting to reconnect", slog.Any("error", err))
+				if w.redisClient.WaitForConnection(readCtx, readBackoffInitial, readBackoffMax) != nil {
+					logger.Debug("worker stopping")
+					return
+				}
+				logger.Info("redis connection restored")
+				backoff = readBackoffInitial
+				continue
+			}
+
 			logger.Error("reading from stream",
 				slog.Any("error", err),
 				slog.Duration("backoff", backoff),
@@ -221,18 +389,96 @@ func (w *Worker) processLoop(ctx context.Context, workerID int) {
 			case <-time.After(backoff):
 			case <-w.stopCh:
 				return
-			case <-ctx.Done():
+			case <-readCtx.Done():
 				return
 			}
 			backoff = min(backoff*readBackoffFactor, readBackoffMax)
 			continue
 		}
 
+		// A blocking read can outlast Stop; leave anything it returned
+		// pending for another consumer rather than start new work.
+		if readCtx.Err() != nil {
+			w.releaseScanSlot()
+			logger.Debug("worker stopping")
+			return
+		}
+
 		// Reset backoff on successful read (including empty reads).
 		backoff = readBackoffInitial
 
 		for _, msg := range messages {
-			w.processMessage(ctx, logger, msg)
+			w.processMessage(taskCtx, logger, msg)
+		}
+		w.releaseScanSlot()
+	}
+}
+
+// acquireScanSlot blocks until a scan slot is free and temp disk usage is
+// under MaxTempDiskUsage. It returns false if the worker stops first.
+func (w *Worker) acquireScanSlot(ctx context.Context, logger *slog.Logger) bool {
+	select {
+	case w.scanSlots <- struct{}{}:
+	case <-w.stopCh:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+
+	if !w.waitForTempDisk(ctx, logger) {
+		w.releaseScanSlot()
+		return false
+	}
+	return true
+}
+
+// releaseScanSlot frees a slot taken by acquireScanSlot.
+func (w *Worker) releaseScanSlot() {
+	<-w.scanSlots
+}
+
+// waitForTempDisk pauses while the filesystem holding TempDir is fuller
+// than MaxTempDiskUsage. Usage that cannot be read does not pause reads.
+// It returns false if the worker stops while paused.
+func (w *Worker) waitForTempDisk(ctx context.Context, logger *slog.Logger) bool {
+	if w.config.MaxTempDiskUsage <= 0 {
+		return true
+	}
+
+	paused := false
+	for {
+		usage, err := w.diskUsage(w.config.TempDir)
+		if err != nil {
+			logger.Warn("checking temp disk usage",
+				slog.String("dir", w.config.TempDir),
+				slog.Any("error", err),
+			)
+			return true
+		}
+		if usage < w.config.MaxTempDiskUsage {
+			if paused {
+				logger.Info("temp disk usage below threshold, resuming reads",
+					slog.Float64("usage", usage),
+				)
+			}
+			return true
+		}
+
+		if !paused {
+			logger.Warn("temp disk usage above threshold, pausing reads",
+				slog.String("dir", w.config.TempDir),
+				slog.Float64("usage", usage),
+				slog.Float64("max_usage", w.config.MaxTempDiskUsage),
+			)
+			paused = true
+		}
+
+		select {
+		case <-time.After(w.config.DiskCheckInterval):
+		case <-w.stopCh:
+			return false
+		case <-ctx.Done():
+			return false
 		}
 	}
 }
@@ -243,6 +489,7 @@ func (w *Worker) processMessage(ctx context.Context, logger *slog.Logger, msg re
 	data, ok := msg.Values["data"]
 	if !ok {
 		logger.Error("message missing data field", slog.String("msg_id", msg.ID))
+		w.deadLetter(ctx, msg, "message missing data field", 1)
 		_ = w.consumer.Ack(ctx, msg.ID)
 		return
 	}
@@ -250,6 +497,7 @@ func (w *Worker) processMessage(ctx context.Context, logger *slog.Logger, msg re
 	task, err := ParseTaskMessage(data)
 	if err != nil {
 		logger.Error("parsing task message", slog.Any("error", err), slog.String("msg_id", msg.ID))
+		w.deadLetter(ctx, msg, fmt.Sprintf("parsing task message: %v", err), 1)
 		_ = w.consumer.Ack(ctx, msg.ID)
 		return
 	}
@@ -259,31 +507,184 @@ func (w *Worker) processMessage(ctx context.Context, logger *slog.Logger, msg re
 		slog.String("org_id", task.OrganizationID),
 	)
 
-	// Acknowledge immediately to prevent redelivery during long processing.
+	// Process the task, then acknowledge. If this consumer dies mid-scan the
+	// message stays pending and is reclaimed by reclaimLoop.
+	if err := w.processTask(ctx, logger, task); err != nil {
+		if ctx.Err() != nil {
+			// Cancelled at shutdown; another consumer reclaims the message.
+			logger.Warn("task interrupted by shutdown, leaving it pending", slog.Any("error", err))
+			return
+		}
+
+		attempts := w.recordAttempt(ctx, task.JobID)
+		if attempts < w.config.MaxRetries && !errors.Is(err, errInvalidTask) {
+			// Leave the message pending; reclaimLoop retries it.
+			logger.Warn("task failed, will retry",
+				slog.Any("error", err),
+				slog.Int("attempt", attempts),
+			)
+			return
+		}
+
+		w.failTask(ctx, task.JobID, err.Error())
+		w.deadLetter(ctx, msg, err.Error(), attempts)
+	}
+
 	if err := w.consumer.Ack(ctx, msg.ID); err != nil {
 		logger.Error("acknowledging message", slog.Any("error", err))
 	}
+}
 
-	// Process the task.
-	w.processTask(ctx, logger, task)
+// recordAttempt increments the failed attempt counter in the job state and
+// returns the new count. If the counter cannot be updated the attempt is
+// treated as the first, so the task is retried rather than dropped.
+func (w *Worker) recordAttempt(ctx context.Context, jobID string) int {
+	attempts, err := w.stateManager.IncrField(ctx, jobID, attemptsField, 1)
+	if err != nil {
+		w.logger.Error("recording task attempt",
+			slog.String("job_id", jobID),
+			slog.Any("error", err),
+		)
+		return 1
+	}
+
+	return int(attempts)
+}
+
+// deadLetter copies a message that will not be processed again, along with
+// why and after how many attempts, to the dead-letter stream.
+func (w *Worker) deadLetter(ctx context.Context, msg redis.StreamMessage, reason string, attempts int) {
+	values := make(map[string]any, len(msg.Values)+4)
+	for k, v := range msg.Values {
+		values[k] = v
+	}
+	values["original_id"] = msg.ID
+	values["reason"] = reason
+	values["attempts"] = attempts
+	values["failed_at"] = time.Now().UTC().Format(time.RFC3339)
+
+	_, err := w.redisClient.Redis().XAdd(ctx, &goredis.XAddArgs{
+		Stream: w.redisClient.PrefixedKey(w.config.DeadLetterStream),
+		Values: values,
+	}).Result()
+	if err != nil {
+		w.logger.Error("writing to dead-letter stream",
+			slog.String("msg_id", msg.ID),
+			slog.Any("error", err),
+		)
+		return
+	}
+
+	w.logger.Warn("moved message to dead-letter stream",
+		slog.String("msg_id", msg.ID),
+		slog.String("reason", reason),
+	)
+}
+
+// reclaimLoop periodically claims messages that have been pending longer
+// than ClaimMinIdle and reprocesses them, giving up after MaxRetries. It
+// stops claiming when readCtx is done; claimed messages run with taskCtx.
+func (w *Worker) reclaimLoop(readCtx, taskCtx context.Context) {
+	defer w.wg.Done()
+
+	logger := w.logger.With(slog.String("component", "reclaim"))
+
+	ticker := time.NewTicker(w.config.ClaimInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-readCtx.Done():
+			return
+		case <-ticker.C:
+			w.reclaimPending(taskCtx, logger)
+		}
+	}
+}
+
+// reclaimPending claims abandoned messages one at a time and processes
+// them until none are left. Each claim is made only once a scan slot is
+// held, so a claimed message never waits in this worker long enough to go
+// idle past ClaimMinIdle and be claimed again elsewhere. Messages left
+// unclaimed at shutdown stay pending for the next claim.
+func (w *Worker) reclaimPending(ctx context.Context, logger *slog.Logger) {
+	for {
+		if !w.acquireScanSlot(ctx, logger) {
+			return
+		}
+
+		messages, err := w.consumer.AutoClaim(ctx, w.config.ClaimMinIdle, 1)
+		if err != nil {
+			w.releaseScanSlot()
+			logger.Error("reclaiming pending messages", slog.Any("error", err))
+			return
+		}
+		if len(messages) == 0 {
+			w.releaseScanSlot()
+			return
+		}
+
+		msg := messages[0]
+		// The first delivery is not a retry.
+		retries := int(msg.DeliveryCount) - 1
+		if retries > w.config.MaxRetries {
+			w.abandonMessage(ctx, logger, msg, retries)
+		} else {
+			logger.Warn("reprocessing abandoned message",
+				slog.String("msg_id", msg.ID),
+				slog.Int("retry", retries),
+			)
+			w.processMessage(ctx, logger, msg)
+		}
+		w.releaseScanSlot()
+	}
+}
+
+// abandonMessage fails the job behind a message that exhausted its retries,
+// dead-letters it, and acknowledges it so it is not claimed again.
+func (w *Worker) abandonMessage(ctx context.Context, logger *slog.Logger, msg redis.StreamMessage, retries int) {
+	logger.Error("giving up on message after max retries",
+		slog.String("msg_id", msg.ID),
+		slog.Int("retries", retries),
+	)
+
+	reason := fmt.Sprintf("exceeded max retries (%d)", w.config.MaxRetries)
+	if task, err := ParseTaskMessage(msg.Values["data"]); err == nil {
+		w.failTask(ctx, task.JobID, reason)
+	}
+	w.deadLetter(ctx, msg, reason, retries+1)
+
+	if err := w.consumer.Ack(ctx, msg.ID); err != nil {
+		logger.Error("acknowledging message", slog.Any("error", err))
+	}
 }
 
-// processTask handles the full scan workflow.
-func (w *Worker) processTask(ctx context.Context, logger *slog.Logger, task *TaskMessage) {
+// processTask handles the full scan workflow. It returns an error when the
+// task failed and should be retried or dead-lettered; cancelled tasks and
+// completed scans (including partial ones) return nil.
+func (w *Worker) processTask(ctx context.Context, logger *slog.Logger, task *TaskMessage) error {
 	startTime := time.Now()
 
-	// Create context with timeout.
-	timeout := task.Timeout()
-	if timeout == 0 {
-		timeout = w.config.DefaultTimeout
+	// Create context with timeout. Requests beyond MaxTaskTimeout are
+	// capped so the task finishes before ClaimMinIdle lets another worker
+	// claim it.
+	timeout := w.config.DefaultTimeout
+	if task.TimeoutSeconds > 0 {
+		timeout = min(task.Timeout(), w.config.MaxTaskTimeout)
 	}
 	taskCtx, taskCancel := context.WithTimeout(ctx, timeout)
 	defer taskCancel()
 
-	// Start cancellation listener.
-	cancelListener := w.listenForCancel(taskCtx, task.JobID)
+	// Start cancellation listener. Its subscription lives for the whole task
+	// and is torn down on return, whatever path the task takes.
+	listenCtx, stopListening := context.WithCancel(ctx)
+	defer stopListening()
+	cancelListener := w.listenForCancel(listenCtx, task.JobID)
 
-	// Create a cancellable context that responds to either timeout or cancellation signal.
+	// Cancel the task context when a cancellation signal arrives so that
+	// in-flight downloads and scanners stop promptly.
 	go func() {
 		select {
 		case <-cancelListener.Done():
@@ -295,14 +696,13 @@ func (w *Worker) processTask(ctx context.Context, logger *slog.Logger, task *Tas
 	// Initialize state.
 	if err := w.initializeState(taskCtx, task); err != nil {
 		logger.Error("initializing state", slog.Any("error", err))
-		w.publishCompletion(ctx, task.JobID, "failed", nil)
-		return
+		return fmt.Errorf("initializing state: %w", err)
 	}
 
 	// Check for cancellation before proceeding.
 	if w.isCancelled(taskCtx, cancelListener) {
 		w.cancelTask(ctx, task.JobID)
-		return
+		return nil
 	}
 
 	// Validate organization path.
@@ -310,38 +710,38 @@ func (w *Worker) processTask(ctx context.Context, logger *slog.Logger, task *Tas
 		logger.Error("organization path validation failed",
 			slog.String("gcs_uri", task.GCSURI),
 		)
-		w.failTask(ctx, task.JobID, "invalid GCS path for organization")
-		return
+		return fmt.Errorf("%w: invalid GCS path for organization", errInvalidTask)
 	}
 
 	// Download from GCS.
 	logger.Info("downloading skill from GCS", slog.String("gcs_uri", task.GCSURI))
-	downloadResult, err := w.gcsClient.DownloadFromURI(taskCtx, task.GCSURI, task.JobID)
+	downloadResult, err := w.gcsClient.DownloadFromURIWithChecksum(taskCtx, task.GCSURI, task.JobID, task.Checksum)
 	if err != nil {
 		// Check if error is due to cancellation.
 		if w.isCancelled(taskCtx, cancelListener) {
 			w.cancelTask(ctx, task.JobID)
-			return
+			return nil
 		}
 		logger.Error("downloading from GCS", slog.Any("error", err))
-		w.failTask(ctx, task.JobID, fmt.Sprintf("download failed: %v", err))
-		return
+		if errors.Is(err, gcs.ErrChecksumMismatch) {
+			return fmt.Errorf("%w: download failed: %w", errInvalidTask, err)
+		}
+		return fmt.Errorf("download failed: %w", err)
 	}
 
 	// Check for cancellation after download.
 	if w.isCancelled(taskCtx, cancelListener) {
 		w.cancelTask(ctx, task.JobID)
-		return
+		return nil
 	}
 
 	// Extract if archive.
 	scanPath := downloadResult.LocalPath
-	if isArchive(scanPath) {
+	if trivy.IsArchive(scanPath) {
 		extractDir, err := trivy.ExtractArchive(scanPath)
 		if err != nil {
 			logger.Error("extracting archive", slog.Any("error", err))
-			w.failTask(ctx, task.JobID, fmt.Sprintf("extraction failed: %v", err))
-			return
+			return fmt.Errorf("extraction failed: %w", err)
 		}
 		scanPath = extractDir
 		defer func() {
@@ -354,7 +754,7 @@ func (w *Worker) processTask(ctx context.Context, logger *slog.Logger, task *Tas
 	// Check for cancellation after extraction.
 	if w.isCancelled(taskCtx, cancelListener) {
 		w.cancelTask(ctx, task.JobID)
-		return
+		return nil
 	}
 
 	// Run scanners.
@@ -364,17 +764,22 @@ func (w *Worker) processTask(ctx context.Context, logger *slog.Logger, task *Tas
 		// Check if error is due to cancellation.
 		if w.isCancelled(taskCtx, cancelListener) {
 			w.cancelTask(ctx, task.JobID)
-			return
+			return nil
 		}
 		logger.Error("running scanners", slog.Any("error", err))
-		w.failTask(ctx, task.JobID, fmt.Sprintf("scan failed: %v", err))
-		return
+		return fmt.Errorf("scan failed: %w", err)
 	}
 
 	// Check for cancellation after scanners complete.
 	if w.isCancelled(taskCtx, cancelListener) {
 		w.cancelTask(ctx, task.JobID)
-		return
+		return nil
+	}
+
+	// Scanner errors are recorded in the results, so a worker shutdown
+	// would otherwise be reported as a partial scan.
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("task interrupted: %w", err)
 	}
 
 	// Update final state.
@@ -382,6 +787,11 @@ func (w *Worker) processTask(ctx context.Context, logger *slog.Logger, task *Tas
 		logger.Error("updating final state", slog.Any("error", err))
 	}
 
+	// Archive the full report; failures do not fail the scan.
+	if w.config.ReportPrefix != "" {
+		w.uploadReport(ctx, logger, task, results)
+	}
+
 	// Publish completion.
 	status := "completed"
 	if results.HasErrors() {
@@ -399,6 +809,8 @@ func (w *Worker) processTask(ctx context.Context, logger *slog.Logger, task *Tas
 		slog.String("status", status),
 		slog.Duration("duration", elapsed),
 	)
+
+	return nil
 }
 
 // isCancelled checks if the task has been cancelled via the cancellation listener.
@@ -420,7 +832,8 @@ func (w *Worker) isCancelled(ctx context.Context, listener *CancellationListener
 	}
 }
 
-// initializeState sets up the initial job state in Redis.
+// initializeState sets up the initial job state in Redis. A retried task
+// restarts its ClamAV progress count instead of adding to the last attempt's.
 func (w *Worker) initializeState(ctx context.Context, task *TaskMessage) error {
 	status := InitialArgusStatus(task.Scanners)
 	statusJSON, err := json.Marshal(status)
@@ -432,6 +845,9 @@ func (w *Worker) initializeState(ctx context.Context, task *TaskMessage) error {
 		"argus_status": string(statusJSON),
 		"started_at":   time.Now().UTC().Format(time.RFC3339),
 	}
+	if task.HasScanner(ScannerClamAV) {
+		fields[clamAVFilesScannedField] = "0"
+	}
 
 	return w.stateManager.InitState(ctx, task.JobID, fields)
 }
@@ -446,7 +862,7 @@ func (w *Worker) runScanners(ctx context.Context, logger *slog.Logger, task *Tas
 	if task.HasScanner(ScannerTrivy) {
 		w.updateScannerStatus(ctx, task.JobID, "trivy", StatusRunning)
 
-		trivyResult, err := w.runner.RunTrivy(ctx, path)
+		trivyResult, err := w.runner.RunTrivyWithOptions(ctx, path, task.ScanOptions)
 		if err != nil {
 			logger.Error("trivy scan failed", slog.Any("error", err))
 			results.Errors["trivy"] = err.Error()
@@ -462,7 +878,7 @@ func (w *Worker) runScanners(ctx context.Context, logger *slog.Logger, task *Tas
 	if task.HasScanner(ScannerClamAV) {
 		w.updateScannerStatus(ctx, task.JobID, "clamav", StatusRunning)
 
-		clamResult, err := w.runner.RunClamAV(ctx, path)
+		clamResult, err := w.runner.RunClamAVWithProgress(ctx, path, w.clamAVProgress(ctx, logger, task.JobID))
 		if err != nil {
 			logger.Error("clamav scan failed", slog.Any("error", err))
 			results.Errors["clamav"] = err.Error()
@@ -482,6 +898,29 @@ func (w *Worker) runScanners(ctx context.Context, logger *slog.Logger, task *Tas
 	return results, nil
 }
 
+// Job state fields tracking ClamAV directory scan progress.
+const (
+	clamAVFilesTotalField   = "clamav_files_total"
+	clamAVFilesScannedField = "clamav_files_scanned"
+)
+
+// clamAVProgress returns a progress callback that records the total file
+// count once and atomically adds each batch of scanned files to the job
+// state, so progress reads as clamav_files_scanned/clamav_files_total.
+func (w *Worker) clamAVProgress(ctx context.Context, logger *slog.Logger, jobID string) func(scanned, total int) {
+	var once sync.Once
+	return func(scanned, total int) {
+		once.Do(func() {
+			if err := w.stateManager.SetField(ctx, jobID, clamAVFilesTotalField, strconv.Itoa(total)); err != nil {
+				logger.Error("recording clamav file count", slog.Any("error", err))
+			}
+		})
+		if _, err := w.stateManager.IncrField(ctx, jobID, clamAVFilesScannedField, int64(scanned)); err != nil {
+			logger.Error("recording clamav progress", slog.Any("error", err))
+		}
+	}
+}
+
 // updateScannerStatus updates a single scanner's status in Redis.
 func (w *Worker) updateScannerStatus(ctx context.Context, jobID, scanner string, status ScannerStatus) {
 	field := scanner + "_status"
@@ -520,6 +959,35 @@ func (w *Worker) updateFinalState(ctx context.Context, jobID string, results *Ar
 	return w.stateManager.SetFields(ctx, jobID, fields)
 }
 
+// uploadReport archives the marshaled results to GCS and records the
+// resulting URI in the job state.
+func (w *Worker) uploadReport(ctx context.Context, logger *slog.Logger, task *TaskMessage, results *ArgusResults) {
+	report, err := json.Marshal(results)
+	if err != nil {
+		logger.Error("marshaling scan report", slog.Any("error", err))
+		return
+	}
+
+	// Validate limits job IDs to a single segment; refuse any path that
+	// would still leave the organization's report directory.
+	orgDir := path.Join(w.config.ReportPrefix, task.OrganizationID)
+	objectPath := path.Join(orgDir, task.JobID, "report.json")
+	if path.Dir(path.Dir(objectPath)) != orgDir {
+		logger.Error("refusing report path outside the organization prefix", slog.String("object", objectPath))
+		return
+	}
+	uri, err := w.gcsClient.Upload(ctx, objectPath, bytes.NewReader(report))
+	if err != nil {
+		logger.Error("uploading scan report", slog.String("object", objectPath), slog.Any("error", err))
+		return
+	}
+
+	if err := w.stateManager.SetField(ctx, task.JobID, "report_uri", uri); err != nil {
+		logger.Error("recording report URI", slog.Any("error", err))
+	}
+	logger.Info("scan report uploaded", slog.String("report_uri", uri))
+}
+
 // failTask marks a task as failed and publishes completion.
 func (w *Worker) failTask(ctx context.Context, jobID, errMsg string) {
 	fields := map[string]string{
@@ -558,6 +1026,12 @@ func (w *Worker) publishCompletion(ctx context.Context, jobID, status string, re
 		CompletedAt: time.Now().UTC(),
 		Results:     results,
 	}
+	switch {
+	case results != nil:
+		signal.Verdict = results.Verdict()
+	case status == "failed":
+		signal.Verdict = VerdictError
+	}
 
 	signalJSON, err := json.Marshal(signal)
 	if err != nil {
@@ -614,12 +1088,6 @@ func InitialArgusStatus(scanners []string) ArgusStatus {
 	return status
 }
 
-// isArchive checks if a file is an archive based on extension.
-func isArchive(path string) bool {
-	ext := strings.ToLower(filepath.Ext(path))
-	return ext == ".zip" || ext == ".tar" || ext == ".gz" || ext == ".tgz"
-}
-
 // CancelChannelName returns the Redis Pub/Sub channel name for cancellation signals.
 func CancelChannelName(prefix, jobID string) string {
 	return prefix + ":" + jobID
@@ -651,15 +1119,27 @@ func (c *CancellationListener) Stop() {
 }
 
 // listenForCancel subscribes to the cancellation Pub/Sub channel and signals
-// when a cancellation message is received.
+// when a cancellation message is received. It returns once the subscription
+// is confirmed so signals published after the task starts are not missed;
+// the subscription is released when ctx is done.
 func (w *Worker) listenForCancel(ctx context.Context, jobID string) *CancellationListener {
 	listener := NewCancellationListener()
 
 	channelName := CancelChannelName(w.config.CancelPrefix, jobID)
 	prefixedChannel := w.redisClient.PrefixedKey(channelName)
 
+	pubsub := w.redisClient.Redis().Subscribe(ctx, prefixedChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		w.logger.Warn("subscribing to cancellation channel",
+			slog.String("job_id", jobID),
+			slog.String("channel", channelName),
+			slog.Any("error", err),
+		)
+		_ = pubsub.Close()
+		return listener
+	}
+
 	go func() {
-		pubsub := w.redisClient.Redis().Subscribe(ctx, prefixedChannel)
 		defer pubsub.Close()
 
 		ch := pubsub.Channel()
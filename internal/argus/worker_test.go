@@ -6,8 +6,23 @@ package argus
 import (
 	"context"
 	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/gcs"
+	"github.com/hikmaai-io/hikmaai-argus/internal/redis"
+	"github.com/hikmaai-io/hikmaai-argus/internal/trivy"
 )
 
 func TestWorkerConfig_Validate(t *testing.T) {
@@ -57,6 +72,28 @@ func TestWorkerConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "claim min idle within task timeout",
+			cfg: WorkerConfig{
+				TaskQueue:      "queue",
+				ConsumerGroup:  "group",
+				ConsumerName:   "worker-1",
+				DefaultTimeout: 15 * time.Minute,
+				MaxTaskTimeout: time.Hour,
+				ClaimMinIdle:   20 * time.Minute,
+			},
+			wantErr: true,
+		},
+		{
+			name: "temp disk threshold out of range",
+			cfg: WorkerConfig{
+				TaskQueue:        "queue",
+				ConsumerGroup:    "group",
+				ConsumerName:     "worker-1",
+				MaxTempDiskUsage: 1.5,
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -150,6 +187,7 @@ func TestCompletionSignal_JSON(t *testing.T) {
 				Summary: TrivySummary{TotalVulnerabilities: 2},
 			},
 		},
+		Verdict: VerdictVulnerable,
 	}
 
 	data, err := json.Marshal(signal)
@@ -168,6 +206,69 @@ func TestCompletionSignal_JSON(t *testing.T) {
 	if got.Status != signal.Status {
 		t.Errorf("Status = %q, want %q", got.Status, signal.Status)
 	}
+	if got.Verdict != signal.Verdict {
+		t.Errorf("Verdict = %q, want %q", got.Verdict, signal.Verdict)
+	}
+}
+
+func TestWorker_PublishCompletion_Verdict(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		status      string
+		results     *ArgusResults
+		wantVerdict string
+	}{
+		{
+			name:   "completed with infection",
+			status: "completed",
+			results: &ArgusResults{
+				ClamAV: &ClamAVResults{ScanSummary: ClamScanSummary{FilesScanned: 1, InfectedCount: 1}},
+			},
+			wantVerdict: VerdictMalicious,
+		},
+		{
+			name:        "completed clean",
+			status:      "completed",
+			results:     &ArgusResults{Trivy: &TrivyResults{}},
+			wantVerdict: VerdictClean,
+		},
+		{
+			name:        "failed",
+			status:      "failed",
+			wantVerdict: VerdictError,
+		},
+		{
+			name:   "cancelled",
+			status: "cancelled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			worker, _, redisClient := newTestWorker(t, WorkerConfig{}, NewRunner(RunnerConfig{}))
+			ctx := context.Background()
+
+			worker.publishCompletion(ctx, "job-verdict-1", tt.status, tt.results)
+
+			msgs, err := redisClient.Redis().XRange(ctx,
+				redisClient.PrefixedKey(worker.config.CompletionPrefix+":job-verdict-1"), "-", "+").Result()
+			if err != nil || len(msgs) != 1 {
+				t.Fatalf("XRange() = %d messages, error = %v; want 1", len(msgs), err)
+			}
+
+			var got CompletionSignal
+			if err := json.Unmarshal([]byte(msgs[0].Values["data"].(string)), &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got.Verdict != tt.wantVerdict {
+				t.Errorf("Verdict = %q, want %q", got.Verdict, tt.wantVerdict)
+			}
+		})
+	}
 }
 
 func TestInitialArgusStatus(t *testing.T) {
@@ -379,3 +480,915 @@ func TestCancellationListener_SignalReceived(t *testing.T) {
 		t.Error("Done() channel should be closed after Stop()")
 	}
 }
+
+// TestIsArchive pins the archive classification the worker relies on
+// before extracting downloads; the trivy scanner uses the same function.
+func TestIsArchive(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/tmp/skill.zip", true},
+		{"/tmp/skill.tar", true},
+		{"/tmp/skill.tar.gz", true},
+		{"/tmp/SKILL.TAR.GZ", true},
+		{"/tmp/v1.2/skill.tar.gz", true},
+		{"/tmp/skill.tgz", true},
+		{"/tmp/skill.tar.xz", true},
+		{"/tmp/skill.txz", true},
+		{"/tmp/skill.tar.bz2", true},
+		{"/tmp/skill.tbz2", true},
+		{"/tmp/skill.tbz", true},
+		{"/tmp/skill.py", false},
+		{"/tmp/skill.tar.gz.sha256", false},
+		{"/tmp/v1.2/skill", false},
+	}
+
+	for _, tt := range tests {
+		if got := trivy.IsArchive(tt.path); got != tt.want {
+			t.Errorf("trivy.IsArchive(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+// newTestWorker builds a Worker backed by miniredis and a GCS emulator that
+// serves a small Python file for every object whose name does not contain
+// "missing".
+func newTestWorker(t *testing.T, cfg WorkerConfig, runner *Runner) (*Worker, *miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+
+	// Serve the skill archive through the GCS emulator protocol.
+	gcsHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "missing") {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("print('hello')\n"))
+	})
+
+	return newTestWorkerWithGCS(t, cfg, runner, gcsHandler)
+}
+
+// newTestWorkerWithGCS builds a Worker backed by miniredis and a GCS
+// emulator served by gcsHandler.
+func newTestWorkerWithGCS(t *testing.T, cfg WorkerConfig, runner *Runner, gcsHandler http.Handler) (*Worker, *miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	redisClient, err := redis.NewClient(redis.Config{
+		Addr:   mr.Addr(),
+		Prefix: "argus:",
+	})
+	if err != nil {
+		t.Fatalf("redis.NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { redisClient.Close() })
+
+	srv := httptest.NewServer(gcsHandler)
+	t.Cleanup(srv.Close)
+
+	gcsClient, err := gcs.NewClient(context.Background(), gcs.Config{
+		Bucket:       "skills",
+		DownloadDir:  t.TempDir(),
+		EmulatorHost: strings.TrimPrefix(srv.URL, "http://"),
+	})
+	if err != nil {
+		t.Fatalf("gcs.NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { gcsClient.Close() })
+
+	if cfg.TaskQueue == "" {
+		cfg.TaskQueue = "argus_task_queue"
+	}
+	if cfg.ConsumerGroup == "" {
+		cfg.ConsumerGroup = "argus-workers"
+	}
+	if cfg.ConsumerName == "" {
+		cfg.ConsumerName = "worker-1"
+	}
+
+	worker, err := NewWorker(cfg, redisClient, gcsClient, runner, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("NewWorker() error = %v", err)
+	}
+
+	return worker, mr, redisClient
+}
+
+// useShortReads swaps in a stream consumer with a short block timeout, since
+// Stop waits for blocked reads to return.
+func useShortReads(t *testing.T, worker *Worker, redisClient *redis.Client) {
+	t.Helper()
+
+	consumer, err := redis.NewStreamConsumer(redisClient, redis.StreamConsumerConfig{
+		Stream:        worker.config.TaskQueue,
+		ConsumerGroup: worker.config.ConsumerGroup,
+		ConsumerName:  worker.config.ConsumerName,
+		BlockTimeout:  100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewStreamConsumer() error = %v", err)
+	}
+	worker.consumer = consumer
+}
+
+// publishTestTasks publishes a trivy-only task for each job ID.
+func publishTestTasks(t *testing.T, worker *Worker, jobIDs ...string) {
+	t.Helper()
+
+	for _, jobID := range jobIDs {
+		task := TaskMessage{
+			JobID:          jobID,
+			OrganizationID: "org-1",
+			GCSURI:         "gs://skills/org-1/skills/skill.py",
+			Scanners:       []string{"trivy"},
+		}
+		data, _ := json.Marshal(task)
+		if _, err := worker.consumer.Publish(context.Background(), map[string]any{"data": string(data)}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+}
+
+// waitForCompletion waits until a completion signal exists for jobID.
+func waitForCompletion(t *testing.T, worker *Worker, redisClient *redis.Client, jobID string, timeout time.Duration) {
+	t.Helper()
+
+	key := redisClient.PrefixedKey(worker.config.CompletionPrefix + ":" + jobID)
+	deadline := time.Now().Add(timeout)
+	for {
+		n, _ := redisClient.Redis().XLen(context.Background(), key).Result()
+		if n > 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for completion of %s", jobID)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWorker_ProcessTask_CancelMidScan(t *testing.T) {
+	t.Parallel()
+
+	runner := NewRunner(RunnerConfig{
+		TrivyScanner: &MockTrivyScanner{Result: &trivy.ScanResult{}, Delay: time.Minute},
+	})
+	worker, mr, redisClient := newTestWorker(t, WorkerConfig{}, runner)
+	ctx := context.Background()
+
+	task := &TaskMessage{
+		JobID:          "job-cancel-1",
+		OrganizationID: "org-1",
+		GCSURI:         "gs://skills/org-1/skills/skill.py",
+		Scanners:       []string{"trivy"},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		worker.processTask(ctx, worker.logger, task)
+	}()
+
+	// Wait until the slow scanner is running before cancelling.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, _ := worker.stateManager.GetField(ctx, task.JobID, "trivy_status")
+		if status == string(StatusRunning) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for trivy scan to start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	channel := redisClient.PrefixedKey(CancelChannelName(worker.config.CancelPrefix, task.JobID))
+	if err := redisClient.Redis().Publish(ctx, channel, "cancel").Err(); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("processTask did not return after cancellation")
+	}
+
+	fields, err := worker.stateManager.GetAllFields(ctx, task.JobID)
+	if err != nil {
+		t.Fatalf("GetAllFields() error = %v", err)
+	}
+	if fields["cancelled"] != "true" {
+		t.Errorf("cancelled = %q, want %q", fields["cancelled"], "true")
+	}
+	if fields["trivy_status"] != string(StatusCancelled) {
+		t.Errorf("trivy_status = %q, want %q", fields["trivy_status"], StatusCancelled)
+	}
+	if _, ok := fields["error"]; ok {
+		t.Errorf("unexpected failure recorded: %q", fields["error"])
+	}
+
+	// The listener subscription is released once the task returns.
+	deadline = time.Now().Add(5 * time.Second)
+	for mr.PubSubNumSub(channel)[channel] != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("cancellation listener still subscribed after task returned")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWorker_ProcessTask_ClamAVProgress(t *testing.T) {
+	t.Parallel()
+
+	runner := NewRunner(RunnerConfig{
+		ClamAVScanner: &progressClamAVScanner{files: 20},
+	})
+	worker, _, _ := newTestWorker(t, WorkerConfig{}, runner)
+	ctx := context.Background()
+
+	task := &TaskMessage{
+		JobID:          "job-progress-1",
+		OrganizationID: "org-1",
+		GCSURI:         "gs://skills/org-1/skills/skill.py",
+		Scanners:       []string{"clamav"},
+	}
+	// A retry counts from zero again rather than adding to the first run.
+	for range 2 {
+		if err := worker.processTask(ctx, worker.logger, task); err != nil {
+			t.Fatalf("processTask() error = %v", err)
+		}
+	}
+
+	fields, err := worker.stateManager.GetAllFields(ctx, task.JobID)
+	if err != nil {
+		t.Fatalf("GetAllFields() error = %v", err)
+	}
+	if fields[clamAVFilesTotalField] != "20" {
+		t.Errorf("%s = %q, want %q", clamAVFilesTotalField, fields[clamAVFilesTotalField], "20")
+	}
+	if fields[clamAVFilesScannedField] != "20" {
+		t.Errorf("%s = %q, want %q", clamAVFilesScannedField, fields[clamAVFilesScannedField], "20")
+	}
+}
+
+func TestWorker_ProcessTask_UploadsReport(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		uploads = make(map[string][]byte)
+	)
+	gcsHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			uploads[r.URL.Path+"?name="+r.URL.Query().Get("name")] = body
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		_, _ = w.Write([]byte("print('hello')\n"))
+	})
+
+	runner := NewRunner(RunnerConfig{
+		TrivyScanner: &MockTrivyScanner{Result: &trivy.ScanResult{}},
+	})
+	worker, _, _ := newTestWorkerWithGCS(t, WorkerConfig{ReportPrefix: "reports"}, runner, gcsHandler)
+	ctx := context.Background()
+
+	task := &TaskMessage{
+		JobID:          "job-report-1",
+		OrganizationID: "org-1",
+		GCSURI:         "gs://skills/org-1/skills/skill.py",
+		Scanners:       []string{"trivy"},
+	}
+	if err := worker.processTask(ctx, worker.logger, task); err != nil {
+		t.Fatalf("processTask() error = %v", err)
+	}
+
+	mu.Lock()
+	report, ok := uploads["/upload/storage/v1/b/skills/o?name=reports/org-1/job-report-1/report.json"]
+	mu.Unlock()
+	if !ok {
+		t.Fatalf("report not uploaded; uploads = %v", uploads)
+	}
+
+	var results ArgusResults
+	if err := json.Unmarshal(report, &results); err != nil {
+		t.Fatalf("decoding uploaded report: %v", err)
+	}
+	if results.Trivy == nil {
+		t.Error("uploaded report has no trivy results")
+	}
+
+	uri, err := worker.stateManager.GetField(ctx, task.JobID, "report_uri")
+	if err != nil {
+		t.Fatalf("GetField() error = %v", err)
+	}
+	if want := "gs://skills/reports/org-1/job-report-1/report.json"; uri != want {
+		t.Errorf("report_uri = %q, want %q", uri, want)
+	}
+}
+
+func TestWorker_UploadReport_StaysUnderOrganization(t *testing.T) {
+	t.Parallel()
+
+	var uploads atomic.Int32
+	gcsHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uploads.Add(1)
+		_, _ = w.Write([]byte(`{}`))
+	})
+
+	worker, _, _ := newTestWorkerWithGCS(t, WorkerConfig{ReportPrefix: "reports"}, NewRunner(RunnerConfig{}), gcsHandler)
+
+	// A job ID that Validate would reject must not reach another tenant.
+	task := &TaskMessage{JobID: "../other-org/job-1", OrganizationID: "org-1"}
+	worker.uploadReport(context.Background(), worker.logger, task, &ArgusResults{})
+
+	if n := uploads.Load(); n != 0 {
+		t.Errorf("uploads = %d, want 0 for a path outside reports/org-1/", n)
+	}
+}
+
+func TestWorker_ReclaimPending(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		deliveries    int
+		wantProcessed bool
+	}{
+		{name: "reprocesses abandoned message", deliveries: 1, wantProcessed: true},
+		{name: "gives up after max retries", deliveries: 3, wantProcessed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			runner := NewRunner(RunnerConfig{
+				TrivyScanner: &MockTrivyScanner{Result: &trivy.ScanResult{}},
+			})
+			worker, _, redisClient := newTestWorker(t, WorkerConfig{
+				MaxRetries: 1,
+			}, runner)
+			// Validate requires ClaimMinIdle to outlast task timeouts; shorten
+			// it afterwards so the test need not wait that long.
+			worker.config.ClaimMinIdle = 10 * time.Millisecond
+
+			ctx := context.Background()
+			if err := worker.consumer.EnsureGroup(ctx); err != nil {
+				t.Fatalf("EnsureGroup() error = %v", err)
+			}
+
+			task := TaskMessage{
+				JobID:          "job-reclaim-1",
+				OrganizationID: "org-1",
+				GCSURI:         "gs://skills/org-1/skills/skill.py",
+				Scanners:       []string{"trivy"},
+			}
+			data, _ := json.Marshal(task)
+			if _, err := worker.consumer.Publish(ctx, map[string]any{"data": string(data)}); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+
+			// A consumer reads the task and dies without acknowledging it;
+			// extra deliveries simulate earlier failed recovery attempts.
+			dead, err := redis.NewStreamConsumer(redisClient, redis.StreamConsumerConfig{
+				Stream:        worker.config.TaskQueue,
+				ConsumerGroup: worker.config.ConsumerGroup,
+				ConsumerName:  "worker-dead",
+				BlockTimeout:  100 * time.Millisecond,
+			})
+			if err != nil {
+				t.Fatalf("NewStreamConsumer() error = %v", err)
+			}
+			if _, err := dead.Read(ctx, 1); err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			for i := 1; i < tt.deliveries; i++ {
+				time.Sleep(20 * time.Millisecond)
+				if _, err := dead.AutoClaim(ctx, 10*time.Millisecond, 1); err != nil {
+					t.Fatalf("AutoClaim() error = %v", err)
+				}
+			}
+
+			time.Sleep(20 * time.Millisecond)
+			worker.reclaimPending(ctx, worker.logger)
+
+			fields, err := worker.stateManager.GetAllFields(ctx, task.JobID)
+			if err != nil {
+				t.Fatalf("GetAllFields() error = %v", err)
+			}
+
+			processed := fields["trivy_status"] == string(StatusCompleted)
+			if processed != tt.wantProcessed {
+				t.Errorf("processed = %v, want %v (state %v)", processed, tt.wantProcessed, fields)
+			}
+			if !tt.wantProcessed && fields["error"] == "" {
+				t.Error("expected job to be failed after max retries")
+			}
+
+			// Either way the message must no longer be pending.
+			pending, err := redisClient.Redis().XPending(ctx, worker.consumer.StreamKey(), worker.config.ConsumerGroup).Result()
+			if err != nil {
+				t.Fatalf("XPending() error = %v", err)
+			}
+			if pending.Count != 0 {
+				t.Errorf("pending count = %d, want 0", pending.Count)
+			}
+		})
+	}
+}
+
+func TestWorker_ReclaimPending_ClaimsOnlyWithFreeSlot(t *testing.T) {
+	t.Parallel()
+
+	runner := NewRunner(RunnerConfig{
+		TrivyScanner: &MockTrivyScanner{Result: &trivy.ScanResult{}},
+	})
+	worker, _, redisClient := newTestWorker(t, WorkerConfig{}, runner)
+	worker.config.ClaimMinIdle = 10 * time.Millisecond
+
+	ctx := context.Background()
+	if err := worker.consumer.EnsureGroup(ctx); err != nil {
+		t.Fatalf("EnsureGroup() error = %v", err)
+	}
+
+	jobs := []string{"job-slot-1", "job-slot-2", "job-slot-3"}
+	for _, jobID := range jobs {
+		data, _ := json.Marshal(TaskMessage{
+			JobID:          jobID,
+			OrganizationID: "org-1",
+			GCSURI:         "gs://skills/org-1/skills/skill.py",
+			Scanners:       []string{"trivy"},
+		})
+		if _, err := worker.consumer.Publish(ctx, map[string]any{"data": string(data)}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	dead, err := redis.NewStreamConsumer(redisClient, redis.StreamConsumerConfig{
+		Stream:        worker.config.TaskQueue,
+		ConsumerGroup: worker.config.ConsumerGroup,
+		ConsumerName:  "worker-dead",
+		BlockTimeout:  100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewStreamConsumer() error = %v", err)
+	}
+	if _, err := dead.Read(ctx, int64(len(jobs))); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// With every scan slot busy, nothing may be claimed.
+	for range cap(worker.scanSlots) {
+		worker.scanSlots <- struct{}{}
+	}
+	busyCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	worker.reclaimPending(busyCtx, worker.logger)
+	cancel()
+
+	pending, err := redisClient.Redis().XPending(ctx, worker.consumer.StreamKey(), worker.config.ConsumerGroup).Result()
+	if err != nil {
+		t.Fatalf("XPending() error = %v", err)
+	}
+	if pending.Consumers["worker-dead"] != int64(len(jobs)) {
+		t.Fatalf("pending by consumer = %v, want all %d still with worker-dead", pending.Consumers, len(jobs))
+	}
+
+	// Once slots free up, all messages are claimed and processed in turn.
+	for range cap(worker.scanSlots) {
+		worker.releaseScanSlot()
+	}
+	worker.reclaimPending(ctx, worker.logger)
+
+	for _, jobID := range jobs {
+		fields, err := worker.stateManager.GetAllFields(ctx, jobID)
+		if err != nil {
+			t.Fatalf("GetAllFields() error = %v", err)
+		}
+		if fields["trivy_status"] != string(StatusCompleted) {
+			t.Errorf("%s trivy_status = %q, want completed", jobID, fields["trivy_status"])
+		}
+	}
+	pending, err = redisClient.Redis().XPending(ctx, worker.consumer.StreamKey(), worker.config.ConsumerGroup).Result()
+	if err != nil {
+		t.Fatalf("XPending() error = %v", err)
+	}
+	if pending.Count != 0 {
+		t.Errorf("pending count = %d, want 0", pending.Count)
+	}
+}
+
+// deadLetters returns the entries in the worker's dead-letter stream.
+func deadLetters(t *testing.T, worker *Worker, redisClient *redis.Client) []goredis.XMessage {
+	t.Helper()
+
+	msgs, err := redisClient.Redis().XRange(context.Background(),
+		redisClient.PrefixedKey(worker.config.DeadLetterStream), "-", "+").Result()
+	if err != nil {
+		t.Fatalf("XRange() error = %v", err)
+	}
+	return msgs
+}
+
+func TestWorker_DeadLetter_PoisonMessage(t *testing.T) {
+	t.Parallel()
+
+	worker, _, redisClient := newTestWorker(t, WorkerConfig{}, NewRunner(RunnerConfig{}))
+
+	ctx := context.Background()
+	if err := worker.consumer.EnsureGroup(ctx); err != nil {
+		t.Fatalf("EnsureGroup() error = %v", err)
+	}
+	if _, err := worker.consumer.Publish(ctx, map[string]any{"data": "{not json"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	messages, err := worker.consumer.Read(ctx, 1)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("Read() = %d messages, error = %v", len(messages), err)
+	}
+	worker.processMessage(ctx, worker.logger, messages[0])
+
+	dlq := deadLetters(t, worker, redisClient)
+	if len(dlq) != 1 {
+		t.Fatalf("dead-letter entries = %d, want 1", len(dlq))
+	}
+
+	entry := dlq[0].Values
+	if entry["data"] != "{not json" {
+		t.Errorf("data = %v, want original payload", entry["data"])
+	}
+	if entry["original_id"] != messages[0].ID {
+		t.Errorf("original_id = %v, want %s", entry["original_id"], messages[0].ID)
+	}
+	if !strings.Contains(entry["reason"].(string), "parsing task message") {
+		t.Errorf("reason = %v, want parse failure", entry["reason"])
+	}
+	if entry["failed_at"] == "" {
+		t.Error("failed_at is empty")
+	}
+
+	// Acknowledged, so it cannot be reclaimed and dead-lettered again.
+	time.Sleep(20 * time.Millisecond)
+	claimed, err := worker.consumer.AutoClaim(ctx, time.Millisecond, 10)
+	if err != nil {
+		t.Fatalf("AutoClaim() error = %v", err)
+	}
+	if len(claimed) != 0 {
+		t.Errorf("AutoClaim() reclaimed %d messages, want 0", len(claimed))
+	}
+}
+
+func TestWorker_DeadLetter_AfterMaxRetries(t *testing.T) {
+	t.Parallel()
+
+	runner := NewRunner(RunnerConfig{
+		TrivyScanner: &MockTrivyScanner{Result: &trivy.ScanResult{}},
+	})
+	worker, _, redisClient := newTestWorker(t, WorkerConfig{MaxRetries: 2}, runner)
+
+	ctx := context.Background()
+	if err := worker.consumer.EnsureGroup(ctx); err != nil {
+		t.Fatalf("EnsureGroup() error = %v", err)
+	}
+
+	task := TaskMessage{
+		JobID:          "job-dlq-1",
+		OrganizationID: "org-1",
+		GCSURI:         "gs://skills/org-1/skills/missing.zip",
+		Scanners:       []string{"trivy"},
+	}
+	data, _ := json.Marshal(task)
+	if _, err := worker.consumer.Publish(ctx, map[string]any{"data": string(data)}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	messages, err := worker.consumer.Read(ctx, 1)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("Read() = %d messages, error = %v", len(messages), err)
+	}
+	msg := messages[0]
+
+	// First attempt fails and is left pending for a retry.
+	worker.processMessage(ctx, worker.logger, msg)
+	if n := len(deadLetters(t, worker, redisClient)); n != 0 {
+		t.Fatalf("dead-letter entries after first attempt = %d, want 0", n)
+	}
+	pending, err := redisClient.Redis().XPending(ctx, worker.consumer.StreamKey(), worker.config.ConsumerGroup).Result()
+	if err != nil {
+		t.Fatalf("XPending() error = %v", err)
+	}
+	if pending.Count != 1 {
+		t.Fatalf("pending count after first attempt = %d, want 1", pending.Count)
+	}
+
+	// Second attempt exhausts MaxRetries and dead-letters the task.
+	worker.processMessage(ctx, worker.logger, msg)
+
+	dlq := deadLetters(t, worker, redisClient)
+	if len(dlq) != 1 {
+		t.Fatalf("dead-letter entries = %d, want 1", len(dlq))
+	}
+	if dlq[0].Values["attempts"] != "2" {
+		t.Errorf("attempts = %v, want 2", dlq[0].Values["attempts"])
+	}
+	if dlq[0].Values["data"] != string(data) {
+		t.Errorf("data = %v, want original payload", dlq[0].Values["data"])
+	}
+
+	errMsg, err := worker.stateManager.GetField(ctx, task.JobID, "error")
+	if err != nil || !strings.Contains(errMsg, "download failed") {
+		t.Errorf("error field = %q (%v), want download failure", errMsg, err)
+	}
+
+	pending, err = redisClient.Redis().XPending(ctx, worker.consumer.StreamKey(), worker.config.ConsumerGroup).Result()
+	if err != nil {
+		t.Fatalf("XPending() error = %v", err)
+	}
+	if pending.Count != 0 {
+		t.Errorf("pending count = %d, want 0", pending.Count)
+	}
+}
+
+func TestWorker_Stop_Drain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		scanDelay     time.Duration
+		drainTimeout  time.Duration
+		wantCompleted bool
+	}{
+		{
+			name:          "in-flight task completes within drain timeout",
+			scanDelay:     300 * time.Millisecond,
+			drainTimeout:  10 * time.Second,
+			wantCompleted: true,
+		},
+		{
+			name:          "task cancelled after drain timeout",
+			scanDelay:     time.Minute,
+			drainTimeout:  100 * time.Millisecond,
+			wantCompleted: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			runner := NewRunner(RunnerConfig{
+				TrivyScanner: &MockTrivyScanner{Result: &trivy.ScanResult{}, Delay: tt.scanDelay},
+			})
+			worker, _, redisClient := newTestWorker(t, WorkerConfig{
+				DrainTimeout: tt.drainTimeout,
+			}, runner)
+			useShortReads(t, worker, redisClient)
+
+			ctx := context.Background()
+			if err := worker.Start(ctx); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			task := TaskMessage{
+				JobID:          "job-drain-1",
+				OrganizationID: "org-1",
+				GCSURI:         "gs://skills/org-1/skills/skill.py",
+				Scanners:       []string{"trivy"},
+			}
+			data, _ := json.Marshal(task)
+			if _, err := worker.consumer.Publish(ctx, map[string]any{"data": string(data)}); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+
+			// Stop only once the slow scanner is running.
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				status, _ := worker.stateManager.GetField(ctx, task.JobID, "trivy_status")
+				if status == string(StatusRunning) {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatal("timed out waiting for trivy scan to start")
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			stopped := make(chan struct{})
+			go func() {
+				defer close(stopped)
+				worker.Stop()
+			}()
+			select {
+			case <-stopped:
+			case <-time.After(tt.drainTimeout + 5*time.Second):
+				t.Fatal("Stop() did not return within the drain window")
+			}
+
+			completions, err := redisClient.Redis().XLen(ctx,
+				redisClient.PrefixedKey(worker.config.CompletionPrefix+":"+task.JobID)).Result()
+			if err != nil {
+				t.Fatalf("XLen() error = %v", err)
+			}
+			pending, err := redisClient.Redis().XPending(ctx, worker.consumer.StreamKey(), worker.config.ConsumerGroup).Result()
+			if err != nil {
+				t.Fatalf("XPending() error = %v", err)
+			}
+			fields, err := worker.stateManager.GetAllFields(ctx, task.JobID)
+			if err != nil {
+				t.Fatalf("GetAllFields() error = %v", err)
+			}
+
+			if tt.wantCompleted {
+				if completions != 1 {
+					t.Errorf("completion signals = %d, want 1", completions)
+				}
+				if fields["trivy_status"] != string(StatusCompleted) {
+					t.Errorf("trivy_status = %q, want %q", fields["trivy_status"], StatusCompleted)
+				}
+				if pending.Count != 0 {
+					t.Errorf("pending count = %d, want 0", pending.Count)
+				}
+				return
+			}
+
+			// A cancelled task publishes nothing, records no failed attempt,
+			// and stays pending for another consumer.
+			if completions != 0 {
+				t.Errorf("completion signals = %d, want 0", completions)
+			}
+			if _, ok := fields[attemptsField]; ok {
+				t.Errorf("attempt recorded for cancelled task: %q", fields[attemptsField])
+			}
+			if pending.Count != 1 {
+				t.Errorf("pending count = %d, want 1", pending.Count)
+			}
+		})
+	}
+}
+
+// concurrencyTrivyScanner records the highest number of overlapping scans.
+type concurrencyTrivyScanner struct {
+	delay   time.Duration
+	active  atomic.Int32
+	maxSeen atomic.Int32
+}
+
+func (m *concurrencyTrivyScanner) ScanPath(ctx context.Context, path string, opts trivy.ScanOptions) (*trivy.ScanResult, error) {
+	n := m.active.Add(1)
+	defer m.active.Add(-1)
+	for {
+		seen := m.maxSeen.Load()
+		if n <= seen || m.maxSeen.CompareAndSwap(seen, n) {
+			break
+		}
+	}
+
+	select {
+	case <-time.After(m.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &trivy.ScanResult{}, nil
+}
+
+func (m *concurrencyTrivyScanner) Ping(ctx context.Context) error {
+	return nil
+}
+
+func TestWorker_MaxConcurrentScans(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name               string
+		maxConcurrentScans int
+		wantMax            int32
+	}{
+		{name: "one slot serializes scans", maxConcurrentScans: 1, wantMax: 1},
+		{name: "two slots overlap scans", maxConcurrentScans: 2, wantMax: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			scanner := &concurrencyTrivyScanner{delay: 300 * time.Millisecond}
+			worker, _, redisClient := newTestWorker(t, WorkerConfig{
+				Workers:            2,
+				MaxConcurrentScans: tt.maxConcurrentScans,
+			}, NewRunner(RunnerConfig{TrivyScanner: scanner}))
+			useShortReads(t, worker, redisClient)
+
+			if err := worker.consumer.EnsureGroup(context.Background()); err != nil {
+				t.Fatalf("EnsureGroup() error = %v", err)
+			}
+			publishTestTasks(t, worker, "job-slots-1", "job-slots-2")
+
+			if err := worker.Start(context.Background()); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			defer worker.Stop()
+
+			waitForCompletion(t, worker, redisClient, "job-slots-1", 10*time.Second)
+			waitForCompletion(t, worker, redisClient, "job-slots-2", 10*time.Second)
+
+			if got := scanner.maxSeen.Load(); got != tt.wantMax {
+				t.Errorf("max concurrent scans = %d, want %d", got, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestWorker_PausesOnTempDiskUsage(t *testing.T) {
+	t.Parallel()
+
+	runner := NewRunner(RunnerConfig{
+		TrivyScanner: &MockTrivyScanner{Result: &trivy.ScanResult{}},
+	})
+	worker, _, redisClient := newTestWorker(t, WorkerConfig{
+		MaxTempDiskUsage:  0.9,
+		DiskCheckInterval: 20 * time.Millisecond,
+	}, runner)
+	useShortReads(t, worker, redisClient)
+
+	var usage atomic.Uint64
+	usage.Store(math.Float64bits(0.95))
+	worker.diskUsage = func(string) (float64, error) {
+		return math.Float64frombits(usage.Load()), nil
+	}
+
+	ctx := context.Background()
+	if err := worker.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer worker.Stop()
+
+	publishTestTasks(t, worker, "job-disk-1")
+
+	// Reads stay paused while the disk is full, so the task is not picked up.
+	time.Sleep(300 * time.Millisecond)
+	if state, _ := worker.stateManager.GetAllFields(ctx, "job-disk-1"); len(state) != 0 {
+		t.Fatalf("task started while temp disk was full: %v", state)
+	}
+
+	usage.Store(math.Float64bits(0.5))
+	waitForCompletion(t, worker, redisClient, "job-disk-1", 5*time.Second)
+}
+
+func TestWorker_RecoversAfterRedisRestart(t *testing.T) {
+	t.Parallel()
+
+	runner := NewRunner(RunnerConfig{
+		TrivyScanner: &MockTrivyScanner{Result: &trivy.ScanResult{}},
+	})
+	worker, mr, redisClient := newTestWorker(t, WorkerConfig{Workers: 1}, runner)
+	useShortReads(t, worker, redisClient)
+
+	ctx := context.Background()
+	if err := worker.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer worker.Stop()
+
+	// Drop Redis long enough for the read loop to notice.
+	mr.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for redisClient.Health().Connected {
+		if time.Now().After(deadline) {
+			t.Fatal("worker did not detect the lost redis connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if health := worker.RedisHealth(); health.Connected || health.LastError == "" {
+		t.Fatalf("RedisHealth() = %+v with redis down", health)
+	}
+
+	// Close cancels the context miniredis uses for blocking commands and
+	// Restart does not renew it, so renew it here.
+	mr.Ctx, mr.CtxCancel = context.WithCancel(context.Background())
+	if err := mr.Restart(); err != nil {
+		t.Fatalf("Restart() error = %v", err)
+	}
+
+	// The pool keeps failing fast until its background redial succeeds.
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := redisClient.WaitForConnection(waitCtx, 10*time.Millisecond, 100*time.Millisecond); err != nil {
+		t.Fatalf("WaitForConnection() error = %v", err)
+	}
+
+	// The read loop reconnects and picks up new work.
+	publishTestTasks(t, worker, "job-reconnect-1")
+	waitForCompletion(t, worker, redisClient, "job-reconnect-1", 10*time.Second)
+
+	if !redisClient.Health().Connected {
+		t.Error("Health().Connected = false after recovery")
+	}
+}
@@ -4,6 +4,8 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
@@ -68,11 +70,20 @@ type NATSConfig struct {
 	URL     string `yaml:"url"`
 	Subject string `yaml:"subject"`
 	Queue   string `yaml:"queue"`
+
+	// ScanRoot is the directory whose files scan requests may name with
+	// file_path. Paths are checked after symlinks are resolved. When empty,
+	// requests with file_path are rejected.
+	ScanRoot string `yaml:"scan_root"`
 }
 
 // HTTPConfig holds HTTP server settings.
 type HTTPConfig struct {
 	Addr string `yaml:"addr"`
+
+	// APIKeys enables API-key authentication when non-empty.
+	// The health endpoint stays unauthenticated.
+	APIKeys []string `yaml:"api_keys"`
 }
 
 // LogConfig holds logging settings.
@@ -91,8 +102,47 @@ type TracingConfig struct {
 
 // FeedsConfig holds feed settings.
 type FeedsConfig struct {
-	UpdateInterval string   `yaml:"update_interval"`
-	Sources        []string `yaml:"sources"`
+	// UpdateInterval is how often feeds are refreshed, as a Go duration
+	// string (e.g., "1h", "30m"). Use Interval to read it.
+	UpdateInterval string `yaml:"update_interval"`
+
+	// Sources names the daemon's signature feeds to update (eicar,
+	// malwarebazaar, threatfox). Empty enables every feed.
+	Sources []string `yaml:"sources"`
+
+	// ProxyURL routes feed and database downloads through an HTTP(S)
+	// proxy. Empty uses the HTTP_PROXY/HTTPS_PROXY environment variables.
+	ProxyURL string `yaml:"proxy_url"`
+
+	// CACertFile is a PEM bundle of additional CAs trusted for mirrors.
+	CACertFile string `yaml:"ca_cert_file"`
+
+	// InsecureSkipVerify disables TLS certificate verification for
+	// downloads. Only use it for testing.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+
+	// ClamAVBodySignatures makes the clamav feed also catalog the body-based
+	// .ndb and .ldb signatures of the CVD files. They carry no hashes, so
+	// they are stored apart from the hash signatures used for lookups.
+	ClamAVBodySignatures bool `yaml:"clamav_body_signatures"`
+}
+
+// Interval parses UpdateInterval. It returns an error if the value is not a
+// valid positive duration.
+func (c *FeedsConfig) Interval() (time.Duration, error) {
+	if c.UpdateInterval == "" {
+		return 0, errors.New("update_interval is required")
+	}
+
+	interval, err := time.ParseDuration(c.UpdateInterval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid update_interval %q: use a duration such as \"1h\" or \"30m\"", c.UpdateInterval)
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("invalid update_interval %q: must be positive", c.UpdateInterval)
+	}
+
+	return interval, nil
 }
 
 // ClamAVConfig holds ClamAV scanner settings.
@@ -116,6 +166,12 @@ type ClamAVConfig struct {
 	// Format: "unix:///path/to/clamd.sock" or "tcp://host:port".
 	Address string `yaml:"address"`
 
+	// SharedFilesystem declares that clamd sees the same filesystem at the
+	// same paths as argus, allowing directory scans to use MULTISCAN. clamd
+	// does not list clean files, so without a shared filesystem every file
+	// is streamed with INSTREAM instead.
+	SharedFilesystem bool `yaml:"shared_filesystem"`
+
 	// Timeout for scan operations.
 	Timeout time.Duration `yaml:"timeout"`
 
@@ -197,12 +253,24 @@ type ArgusWorkerConfig struct {
 	// Cancellation signals are published to: {cancel_prefix}:{job_id}
 	CancelPrefix string `yaml:"cancel_prefix"`
 
-	// Workers is the number of concurrent scan workers.
+	// Workers is the number of goroutines reading tasks from the queue.
 	Workers int `yaml:"workers"`
 
+	// MaxConcurrentScans bounds how many tasks download and scan at once,
+	// independently of Workers (default: Workers).
+	MaxConcurrentScans int `yaml:"max_concurrent_scans"`
+
+	// MaxTempDiskUsage pauses task reads while the download directory's
+	// filesystem is fuller than this fraction, e.g. 0.9. Zero disables it.
+	MaxTempDiskUsage float64 `yaml:"max_temp_disk_usage"`
+
 	// DefaultTimeout for scan operations.
 	DefaultTimeout time.Duration `yaml:"default_timeout"`
 
+	// MaxTaskTimeout caps the timeout a task may request (default:
+	// DefaultTimeout).
+	MaxTaskTimeout time.Duration `yaml:"max_task_timeout"`
+
 	// MaxRetries before giving up on a task.
 	MaxRetries int `yaml:"max_retries"`
 
@@ -211,6 +279,22 @@ type ArgusWorkerConfig struct {
 
 	// StateTTL is the TTL for job state entries (default: 7 days).
 	StateTTL time.Duration `yaml:"state_ttl"`
+
+	// ClaimMinIdle is how long a task may stay unacknowledged before another
+	// worker reclaims it. It must exceed MaxTaskTimeout (default:
+	// MaxTaskTimeout + 5m).
+	ClaimMinIdle time.Duration `yaml:"claim_min_idle"`
+
+	// ClaimInterval is how often abandoned tasks are reclaimed (default: 1m).
+	ClaimInterval time.Duration `yaml:"claim_interval"`
+
+	// DeadLetterStream receives tasks that failed to parse or exhausted
+	// MaxRetries, together with the failure reason.
+	DeadLetterStream string `yaml:"dead_letter_stream"`
+
+	// DrainTimeout is how long shutdown waits for in-flight tasks to finish
+	// before cancelling them (default: 30s).
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
 }
 
 // TrivyConfig holds Trivy dependency scanner settings.
@@ -280,7 +364,6 @@ func DefaultConfig() *Config {
 		},
 		Feeds: FeedsConfig{
 			UpdateInterval: "1h",
-			Sources:        []string{"eicar"},
 		},
 		ClamAV: ClamAVConfig{
 			Enabled:     false, // Disabled by default
@@ -324,22 +407,35 @@ func DefaultConfig() *Config {
 			DownloadDir:     "/tmp/argus/downloads",
 		},
 		ArgusWorker: ArgusWorkerConfig{
-			Enabled:           false, // Disabled by default
-			TaskQueue:         "argus_task_queue",
-			ConsumerGroup:     "argus-workers",
-			ConsumerName:      "", // Auto-generated from hostname
-			CompletionPrefix:  "argus_completion",
-			CancelPrefix:      "argus_cancel",
-			Workers:           2,
-			DefaultTimeout:    15 * time.Minute,
-			MaxRetries:        3,
-			CleanupOnComplete: true,
-			StateTTL:          7 * 24 * time.Hour, // 7 days
+			Enabled:            false, // Disabled by default
+			TaskQueue:          "argus_task_queue",
+			ConsumerGroup:      "argus-workers",
+			ConsumerName:       "", // Auto-generated from hostname
+			CompletionPrefix:   "argus_completion",
+			CancelPrefix:       "argus_cancel",
+			Workers:            2,
+			MaxConcurrentScans: 0, // Same as Workers
+			DefaultTimeout:     15 * time.Minute,
+			MaxRetries:         3,
+			CleanupOnComplete:  true,
+			StateTTL:           7 * 24 * time.Hour, // 7 days
+			ClaimInterval:      time.Minute,
+			DeadLetterStream:   "argus_dead_letter",
+			DrainTimeout:       30 * time.Second,
 		},
 		DBUpdate: DefaultDBUpdateConfig(),
 	}
 }
 
+// Validate checks the configuration for values that cannot be used,
+// such as unparseable durations.
+func (c *Config) Validate() error {
+	if _, err := c.Feeds.Interval(); err != nil {
+		return fmt.Errorf("feeds: %w", err)
+	}
+	return nil
+}
+
 // DefaultDataDir returns the default data directory for HikmaAI signatures.
 func DefaultDataDir() string {
 	return "data/hikmaaidb"
@@ -0,0 +1,66 @@
+// ABOUTME: Tests for configuration defaults and validation
+// ABOUTME: Covers feed interval parsing and Config.Validate errors
+
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFeedsConfig_Interval(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		interval string
+		want     time.Duration
+		wantErr  string
+	}{
+		{name: "hours", interval: "1h", want: time.Hour},
+		{name: "minutes", interval: "5m", want: 5 * time.Minute},
+		{name: "compound", interval: "1h30m", want: 90 * time.Minute},
+		{name: "typo", interval: "1hour", wantErr: `invalid update_interval "1hour"`},
+		{name: "missing unit", interval: "60", wantErr: `invalid update_interval "60"`},
+		{name: "zero", interval: "0s", wantErr: "must be positive"},
+		{name: "negative", interval: "-1h", wantErr: "must be positive"},
+		{name: "empty", interval: "", wantErr: "update_interval is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := FeedsConfig{UpdateInterval: tt.interval}
+			got, err := cfg.Interval()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Interval() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Interval() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Interval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("DefaultConfig().Validate() error = %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Feeds.UpdateInterval = "1hour"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "feeds: invalid update_interval") {
+		t.Errorf("Validate() error = %v, want a feeds update_interval error", err)
+	}
+}
@@ -18,6 +18,19 @@ type DBUpdateConfig struct {
 
 	// Signatures configures BadgerDB signature feed updates.
 	Signatures DBUpdateSourceConfig `yaml:"signatures"`
+
+	// StatusPublish configures publishing update results to NATS.
+	StatusPublish DBUpdateStatusPublishConfig `yaml:"status_publish"`
+}
+
+// DBUpdateStatusPublishConfig configures publishing a JSON status message
+// to NATS each time an update completes or fails.
+type DBUpdateStatusPublishConfig struct {
+	// Enabled controls whether status messages are published.
+	Enabled bool `yaml:"enabled"`
+
+	// Subject is the NATS subject messages are published to.
+	Subject string `yaml:"subject"`
 }
 
 // DBUpdateSourceConfig configures a specific update source.
@@ -31,6 +44,10 @@ type DBUpdateSourceConfig struct {
 	// Retry configures retry behavior for failed updates.
 	// If nil, uses DefaultRetryConfig().
 	Retry *RetryConfig `yaml:"retry,omitempty"`
+
+	// VerifyOnly checks the installed databases instead of downloading
+	// new ones. Only the clamav source supports it.
+	VerifyOnly bool `yaml:"verify_only"`
 }
 
 // GetRetry returns the retry configuration, using defaults if not set.
@@ -66,6 +83,10 @@ func DefaultDBUpdateConfig() DBUpdateConfig {
 		ClamAV:     DefaultDBUpdateSourceConfig(),
 		Trivy:      DBUpdateSourceConfig{Enabled: true, Interval: 6 * time.Hour},
 		Signatures: DefaultDBUpdateSourceConfig(),
+		StatusPublish: DBUpdateStatusPublishConfig{
+			Enabled: false,
+			Subject: "hikmaai.argus.dbupdate.status",
+		},
 	}
 }
 
@@ -34,6 +34,12 @@ func TestDBUpdateConfig_Defaults(t *testing.T) {
 	if cfg.Signatures.Interval != 1*time.Hour {
 		t.Errorf("Signatures.Interval = %v, want 1h", cfg.Signatures.Interval)
 	}
+	if cfg.StatusPublish.Enabled {
+		t.Error("StatusPublish.Enabled should be false by default")
+	}
+	if cfg.StatusPublish.Subject != "hikmaai.argus.dbupdate.status" {
+		t.Errorf("StatusPublish.Subject = %q, want hikmaai.argus.dbupdate.status", cfg.StatusPublish.Subject)
+	}
 }
 
 func TestDBUpdateSourceConfig_Defaults(t *testing.T) {
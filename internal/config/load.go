@@ -0,0 +1,82 @@
+// ABOUTME: Loads the YAML config file over defaults and applies env overrides
+// ABOUTME: HIKMAAI_ARGUS_* variables take precedence over values from the file
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of environment variables that override config
+// values, e.g. HIKMAAI_ARGUS_DATA_DIR.
+const EnvPrefix = "HIKMAAI_ARGUS_"
+
+// LoadConfig builds the configuration from DefaultConfig, the YAML file at
+// path, and HIKMAAI_ARGUS_* environment variables, in increasing order of
+// precedence. A missing file (or an empty path) leaves the defaults in
+// place. The merged configuration is validated before it is returned.
+func LoadConfig(path string) (*Config, error) {
+	cfg := DefaultConfig()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			// No config file; defaults and environment only.
+		case err != nil:
+			return nil, fmt.Errorf("reading config file: %w", err)
+		default:
+			if err := decodeYAML(data, cfg); err != nil {
+				return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+			}
+		}
+	}
+
+	cfg.applyEnvOverrides(os.LookupEnv)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// decodeYAML unmarshals data over cfg. Keys absent from the file keep
+// their current values; an empty file is not an error.
+func decodeYAML(data []byte, cfg *Config) error {
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// applyEnvOverrides replaces config values with the environment variables
+// that are set, looked up through lookup.
+func (c *Config) applyEnvOverrides(lookup func(string) (string, bool)) {
+	overrides := []struct {
+		name  string
+		field *string
+	}{
+		{"DATA_DIR", &c.DataDir},
+		{"NATS_URL", &c.NATS.URL},
+		{"HTTP_ADDR", &c.HTTP.Addr},
+		{"REDIS_ADDR", &c.Redis.Addr},
+		{"REDIS_PASSWORD", &c.Redis.Password},
+		{"GCS_BUCKET", &c.GCS.Bucket},
+		{"FEEDS_PROXY_URL", &c.Feeds.ProxyURL},
+		{"FEEDS_CA_CERT_FILE", &c.Feeds.CACertFile},
+	}
+
+	for _, o := range overrides {
+		if value, ok := lookup(EnvPrefix + o.name); ok {
+			*o.field = value
+		}
+	}
+}
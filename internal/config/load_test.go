@@ -0,0 +1,167 @@
+// ABOUTME: Tests for loading the config file and environment overrides
+// ABOUTME: Covers file-over-default and env-over-file precedence and validation
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig writes contents to a config file in a temp directory.
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig_FileOverDefaults(t *testing.T) {
+	path := writeConfig(t, `
+data_dir: /var/lib/argus
+http:
+  addr: ":9090"
+feeds:
+  update_interval: 30m
+redis:
+  addr: redis:6379
+  read_timeout: 2s
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if cfg.DataDir != "/var/lib/argus" {
+		t.Errorf("DataDir = %q, want /var/lib/argus", cfg.DataDir)
+	}
+	if cfg.HTTP.Addr != ":9090" {
+		t.Errorf("HTTP.Addr = %q, want :9090", cfg.HTTP.Addr)
+	}
+	if interval, _ := cfg.Feeds.Interval(); interval != 30*time.Minute {
+		t.Errorf("Feeds.Interval() = %v, want 30m", interval)
+	}
+	if cfg.Redis.ReadTimeout != 2*time.Second {
+		t.Errorf("Redis.ReadTimeout = %v, want 2s", cfg.Redis.ReadTimeout)
+	}
+
+	// Keys missing from the file keep their defaults.
+	defaults := DefaultConfig()
+	if cfg.Redis.Prefix != defaults.Redis.Prefix {
+		t.Errorf("Redis.Prefix = %q, want default %q", cfg.Redis.Prefix, defaults.Redis.Prefix)
+	}
+	if cfg.Log.Level != defaults.Log.Level {
+		t.Errorf("Log.Level = %q, want default %q", cfg.Log.Level, defaults.Log.Level)
+	}
+	if strings.Join(cfg.Feeds.Sources, ",") != strings.Join(defaults.Feeds.Sources, ",") {
+		t.Errorf("Feeds.Sources = %v, want default %v", cfg.Feeds.Sources, defaults.Feeds.Sources)
+	}
+}
+
+func TestLoadConfig_EnvOverFile(t *testing.T) {
+	path := writeConfig(t, `
+data_dir: /var/lib/argus
+nats:
+  url: nats://file:4222
+redis:
+  addr: file-redis:6379
+gcs:
+  bucket: file-bucket
+`)
+
+	t.Setenv("HIKMAAI_ARGUS_DATA_DIR", "/srv/argus")
+	t.Setenv("HIKMAAI_ARGUS_NATS_URL", "nats://env:4222")
+	t.Setenv("HIKMAAI_ARGUS_HTTP_ADDR", ":7070")
+	t.Setenv("HIKMAAI_ARGUS_REDIS_ADDR", "env-redis:6379")
+	t.Setenv("HIKMAAI_ARGUS_REDIS_PASSWORD", "s3cret")
+	t.Setenv("HIKMAAI_ARGUS_GCS_BUCKET", "env-bucket")
+	t.Setenv("HIKMAAI_ARGUS_FEEDS_PROXY_URL", "http://proxy:3128")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	tests := []struct {
+		field string
+		got   string
+		want  string
+	}{
+		{"DataDir", cfg.DataDir, "/srv/argus"},
+		{"NATS.URL", cfg.NATS.URL, "nats://env:4222"},
+		{"HTTP.Addr", cfg.HTTP.Addr, ":7070"},
+		{"Redis.Addr", cfg.Redis.Addr, "env-redis:6379"},
+		{"Redis.Password", cfg.Redis.Password, "s3cret"},
+		{"GCS.Bucket", cfg.GCS.Bucket, "env-bucket"},
+		{"Feeds.ProxyURL", cfg.Feeds.ProxyURL, "http://proxy:3128"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.field, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfig_MissingFile(t *testing.T) {
+	t.Setenv("HIKMAAI_ARGUS_GCS_BUCKET", "env-bucket")
+
+	cfg, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.DataDir != DefaultDataDir() {
+		t.Errorf("DataDir = %q, want default %q", cfg.DataDir, DefaultDataDir())
+	}
+	if cfg.GCS.Bucket != "env-bucket" {
+		t.Errorf("GCS.Bucket = %q, want env-bucket", cfg.GCS.Bucket)
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		contents string
+		wantErr  string
+	}{
+		{
+			name:     "malformed yaml",
+			contents: "http: [",
+			wantErr:  "parsing config file",
+		},
+		{
+			name:     "invalid feeds interval",
+			contents: "feeds:\n  update_interval: 1hour\n",
+			wantErr:  `invalid config: feeds: invalid update_interval "1hour"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := LoadConfig(writeConfig(t, tt.contents))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadConfig() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Examples(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"config.yaml", "config-dev.yaml"} {
+		if _, err := LoadConfig(filepath.Join("..", "..", "examples", name)); err != nil {
+			t.Errorf("LoadConfig(examples/%s) error = %v", name, err)
+		}
+	}
+}
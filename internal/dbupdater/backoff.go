@@ -1,9 +1,10 @@
-// ABOUTME: Exponential backoff with jitter for retry logic
+// ABOUTME: Exponential and decorrelated-jitter backoff for retry logic
 // ABOUTME: Configurable delays, max retries, and multiplicative growth
 
 package dbupdater
 
 import (
+	"context"
 	"errors"
 	"math/rand/v2"
 	"sync"
@@ -19,8 +20,42 @@ const (
 	DefaultJitterFraction = 0.2 // 20% jitter.
 )
 
+// ErrMaxRetriesExceeded is returned by Wait when no retries remain.
+var ErrMaxRetriesExceeded = errors.New("max retries exceeded")
+
+// BackoffStrategy selects how retry delays grow.
+type BackoffStrategy int
+
+const (
+	// StrategyExponential multiplies the delay by Multiplier on each retry
+	// and applies JitterFraction. This is the default.
+	StrategyExponential BackoffStrategy = iota
+
+	// StrategyDecorrelated picks each delay at random between InitialDelay
+	// and three times the previous delay, capped at MaxDelay. It spreads
+	// retries from many clients better than proportional jitter.
+	// Multiplier and JitterFraction are ignored.
+	StrategyDecorrelated
+)
+
+// String returns the name of the strategy.
+func (s BackoffStrategy) String() string {
+	switch s {
+	case StrategyExponential:
+		return "exponential"
+	case StrategyDecorrelated:
+		return "decorrelated"
+	default:
+		return "unknown"
+	}
+}
+
 // BackoffConfig configures exponential backoff behavior.
 type BackoffConfig struct {
+	// Strategy selects the delay algorithm.
+	// Zero uses StrategyExponential.
+	Strategy BackoffStrategy
+
 	// MaxRetries is the maximum number of retry attempts.
 	// Zero uses DefaultMaxRetries.
 	MaxRetries int
@@ -41,6 +76,10 @@ type BackoffConfig struct {
 	// 0.2 means ±20% variation. Must be in [0, 1].
 	// Zero disables jitter.
 	JitterFraction float64
+
+	// Rand is the random source for jitter. Nil uses the global source;
+	// set a seeded source for reproducible delays.
+	Rand *rand.Rand
 }
 
 // Validate checks if the configuration is valid.
@@ -51,6 +90,9 @@ func (c *BackoffConfig) Validate() error {
 	if c.Multiplier != 0 && c.Multiplier < 1 {
 		return errors.New("multiplier must be at least 1")
 	}
+	if c.Strategy != StrategyExponential && c.Strategy != StrategyDecorrelated {
+		return errors.New("unknown backoff strategy")
+	}
 	return nil
 }
 
@@ -111,6 +153,12 @@ func (b *Backoff) NextDelay() (time.Duration, bool) {
 		return 0, false
 	}
 
+	if b.config.Strategy == StrategyDecorrelated {
+		b.attempts++
+		b.currentDelay = b.decorrelatedDelay()
+		return b.currentDelay, true
+	}
+
 	// Calculate delay for this attempt.
 	delay := b.currentDelay
 
@@ -130,15 +178,55 @@ func (b *Backoff) NextDelay() (time.Duration, bool) {
 	return delay, true
 }
 
+// Wait sleeps for the next delay. It returns ErrMaxRetriesExceeded when no
+// retries remain and ctx.Err() if ctx is done before the delay elapses.
+func (b *Backoff) Wait(ctx context.Context) error {
+	delay, ok := b.NextDelay()
+	if !ok {
+		return ErrMaxRetriesExceeded
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 // applyJitter adds random variation to the delay.
 func (b *Backoff) applyJitter(delay time.Duration) time.Duration {
 	// Jitter: delay * (1 - fraction) to delay * (1 + fraction).
 	jitterRange := float64(delay) * b.config.JitterFraction
-	jitter := (rand.Float64()*2 - 1) * jitterRange
+	jitter := (b.float64()*2 - 1) * jitterRange
 	return time.Duration(float64(delay) + jitter)
 }
 
-// Reset resets the backoff to its initial state.
+// decorrelatedDelay returns min(MaxDelay, random(InitialDelay, previous*3)).
+func (b *Backoff) decorrelatedDelay() time.Duration {
+	lower := b.config.InitialDelay
+	upper := 3 * b.currentDelay
+
+	delay := lower
+	if upper > lower {
+		delay = lower + time.Duration(b.float64()*float64(upper-lower))
+	}
+	return min(delay, b.config.MaxDelay)
+}
+
+// float64 returns a random number in [0, 1) from the configured source.
+func (b *Backoff) float64() float64 {
+	if b.config.Rand != nil {
+		return b.config.Rand.Float64()
+	}
+	return rand.Float64()
+}
+
+// Reset returns the backoff to its initial state so it can be reused after
+// a success, restoring the full retry budget and the initial delay.
 func (b *Backoff) Reset() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -147,7 +235,8 @@ func (b *Backoff) Reset() {
 	b.currentDelay = b.config.InitialDelay
 }
 
-// Attempts returns the number of attempts made so far.
+// Attempts returns the number of NextDelay calls that granted a retry since
+// creation or the last Reset. Calls past MaxRetries are not counted.
 func (b *Backoff) Attempts() int {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -1,9 +1,14 @@
 // ABOUTME: Tests for exponential backoff with jitter for retry logic
-// ABOUTME: Validates delay calculation, reset, and configurable parameters
+// ABOUTME: Validates delay calculation, strategies, reset, and configurable parameters
 
 package dbupdater
 
 import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 )
@@ -181,6 +186,55 @@ func TestBackoff_Reset(t *testing.T) {
 	}
 }
 
+func TestBackoff_ResetAfterExhaustion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		strategy BackoffStrategy
+	}{
+		{name: "exponential", strategy: StrategyExponential},
+		{name: "decorrelated", strategy: StrategyDecorrelated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := NewBackoff(BackoffConfig{
+				Strategy:     tt.strategy,
+				MaxRetries:   3,
+				InitialDelay: time.Second,
+				MaxDelay:     time.Hour,
+			})
+
+			for b.Attempts() < 3 {
+				b.NextDelay()
+			}
+			if _, ok := b.NextDelay(); ok {
+				t.Fatal("NextDelay() ok = true after MaxRetries, want false")
+			}
+
+			b.Reset()
+
+			// The full retry budget is available again, starting from the
+			// initial delay.
+			for i := range 3 {
+				delay, ok := b.NextDelay()
+				if !ok {
+					t.Fatalf("retry %d after Reset: ok = false, want true", i)
+				}
+				if i == 0 && (delay < time.Second || delay > 3*time.Second) {
+					t.Errorf("first delay after Reset = %v, want within [1s, 3s]", delay)
+				}
+			}
+			if _, ok := b.NextDelay(); ok {
+				t.Error("NextDelay() ok = true after MaxRetries following Reset, want false")
+			}
+		})
+	}
+}
+
 func TestBackoff_NextDelay_WithJitter(t *testing.T) {
 	t.Parallel()
 
@@ -204,6 +258,49 @@ func TestBackoff_NextDelay_WithJitter(t *testing.T) {
 	}
 }
 
+func TestBackoff_NextDelay_Decorrelated(t *testing.T) {
+	t.Parallel()
+
+	newBackoff := func(seed uint64) *Backoff {
+		return NewBackoff(BackoffConfig{
+			Strategy:     StrategyDecorrelated,
+			MaxRetries:   20,
+			InitialDelay: time.Second,
+			MaxDelay:     time.Minute,
+			Rand:         rand.New(rand.NewPCG(seed, seed)),
+		})
+	}
+
+	run := func(b *Backoff) []time.Duration {
+		var delays []time.Duration
+		prev := time.Second
+		for {
+			delay, ok := b.NextDelay()
+			if !ok {
+				break
+			}
+			upper := min(3*prev, time.Minute)
+			if delay < time.Second || delay > upper {
+				t.Errorf("delay %d = %v, want within [1s, %v]", len(delays), delay, upper)
+			}
+			delays = append(delays, delay)
+			prev = delay
+		}
+		return delays
+	}
+
+	first := run(newBackoff(1))
+	if len(first) != 20 {
+		t.Fatalf("got %d delays, want MaxRetries (20)", len(first))
+	}
+	if again := run(newBackoff(1)); !slices.Equal(first, again) {
+		t.Error("same seed produced different delays")
+	}
+	if other := run(newBackoff(2)); slices.Equal(first, other) {
+		t.Error("different seeds produced identical delays")
+	}
+}
+
 func TestBackoff_Attempts(t *testing.T) {
 	t.Parallel()
 
@@ -233,6 +330,124 @@ func TestBackoff_Attempts(t *testing.T) {
 	}
 }
 
+func TestBackoff_Attempts_StopsAtMaxRetries(t *testing.T) {
+	t.Parallel()
+
+	b := NewBackoff(BackoffConfig{MaxRetries: 2, InitialDelay: time.Second})
+
+	for range 5 {
+		b.NextDelay()
+	}
+	if b.Attempts() != 2 {
+		t.Errorf("Attempts() = %d, want 2 (calls past MaxRetries are not attempts)", b.Attempts())
+	}
+}
+
+func TestBackoff_ConcurrentUse(t *testing.T) {
+	t.Parallel()
+
+	b := NewBackoff(BackoffConfig{
+		Strategy:     StrategyDecorrelated,
+		MaxRetries:   1000,
+		InitialDelay: time.Millisecond,
+		MaxDelay:     time.Second,
+	})
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				b.NextDelay()
+				_ = b.Attempts()
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 10 {
+			b.Reset()
+		}
+	}()
+	wg.Wait()
+
+	if got := b.Attempts(); got < 0 || got > 400 {
+		t.Errorf("Attempts() = %d, want within [0, 400]", got)
+	}
+}
+
+func TestBackoff_Wait(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		config   BackoffConfig
+		cancel   bool
+		wantErr  error
+		maxSleep time.Duration
+	}{
+		{
+			name:     "delay elapses",
+			config:   BackoffConfig{MaxRetries: 1, InitialDelay: time.Millisecond},
+			wantErr:  nil,
+			maxSleep: time.Second,
+		},
+		{
+			name:     "cancelled during delay",
+			config:   BackoffConfig{MaxRetries: 1, InitialDelay: time.Hour},
+			cancel:   true,
+			wantErr:  context.Canceled,
+			maxSleep: time.Second,
+		},
+		{
+			name:     "retries exhausted",
+			config:   BackoffConfig{MaxRetries: -1, InitialDelay: time.Hour},
+			wantErr:  ErrMaxRetriesExceeded,
+			maxSleep: time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := NewBackoff(tt.config)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				time.AfterFunc(10*time.Millisecond, cancel)
+			}
+
+			start := time.Now()
+			err := b.Wait(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Wait() error = %v, want %v", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > tt.maxSleep {
+				t.Errorf("Wait() took %v, want under %v", elapsed, tt.maxSleep)
+			}
+		})
+	}
+}
+
+func TestBackoff_Wait_ExhaustsRetries(t *testing.T) {
+	t.Parallel()
+
+	b := NewBackoff(BackoffConfig{MaxRetries: 2, InitialDelay: time.Millisecond})
+	ctx := context.Background()
+
+	for i := range 2 {
+		if err := b.Wait(ctx); err != nil {
+			t.Fatalf("Wait() %d error = %v, want nil", i, err)
+		}
+	}
+	if err := b.Wait(ctx); !errors.Is(err, ErrMaxRetriesExceeded) {
+		t.Errorf("Wait() after MaxRetries error = %v, want ErrMaxRetriesExceeded", err)
+	}
+}
+
 func TestBackoffConfig_Validate(t *testing.T) {
 	t.Parallel()
 
@@ -271,6 +486,20 @@ func TestBackoffConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "decorrelated strategy",
+			config: BackoffConfig{
+				Strategy: StrategyDecorrelated,
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown strategy",
+			config: BackoffConfig{
+				Strategy: BackoffStrategy(99),
+			},
+			wantErr: true,
+		},
 		{
 			name: "multiplier less than 1",
 			config: BackoffConfig{
@@ -1,13 +1,15 @@
 // ABOUTME: ClamAV database updater implementation
-// ABOUTME: Downloads CVD files with version checking and optional clamd reload
+// ABOUTME: Applies .cdiff patches or downloads CVD files, with optional clamd reload
 
 package dbupdater
 
 import (
-	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
@@ -22,6 +24,10 @@ const (
 	ClamAVDailyDB = "daily.cvd"
 )
 
+// maxCDIFFChain is the largest version gap bridged with incremental
+// patches; bigger gaps download the full CVD instead.
+const maxCDIFFChain = 50
+
 // Default ClamAV mirrors.
 var DefaultClamAVMirrors = []string{
 	"https://database.clamav.net",
@@ -45,12 +51,23 @@ type ClamAVUpdaterConfig struct {
 	// If empty, reload is skipped.
 	// Format: "unix:///path/to/clamd.sock" or "tcp://host:port"
 	ClamdAddress string
+
+	// OnUpdated, if set, is called after Update installs new databases,
+	// for example to drop scan results cached under the old signatures.
+	OnUpdated func(ctx context.Context)
+
+	// VerifyOnly makes Update check the MD5 of the local databases against
+	// their headers without downloading anything. Corrupted or missing
+	// databases are reported as failures.
+	VerifyOnly bool
 }
 
 // ClamAVUpdater updates ClamAV databases.
 type ClamAVUpdater struct {
-	config ClamAVUpdaterConfig
-	feed   *feeds.ClamAVDBFeed
+	config     ClamAVUpdaterConfig
+	feed       *feeds.ClamAVDBFeed
+	downloader *feeds.Downloader
+	httpClient *http.Client
 }
 
 // NewClamAVUpdater creates a new ClamAV updater.
@@ -68,11 +85,30 @@ func NewClamAVUpdater(config ClamAVUpdaterConfig) *ClamAVUpdater {
 	feed.SetDatabases(config.Databases)
 
 	return &ClamAVUpdater{
-		config: config,
-		feed:   feed,
+		config:     config,
+		feed:       feed,
+		downloader: feeds.NewDownloader(nil),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
 	}
 }
 
+// SetDownloaderConfig applies network settings such as proxy and TLS trust
+// to every mirror request, including CVD header probes.
+func (u *ClamAVUpdater) SetDownloaderConfig(cfg feeds.DownloaderConfig) error {
+	if err := u.feed.SetDownloaderConfig(cfg); err != nil {
+		return err
+	}
+	if err := u.downloader.Configure(cfg); err != nil {
+		return err
+	}
+
+	// Header probes keep their short timeout.
+	client := *u.downloader.HTTPClient()
+	client.Timeout = u.httpClient.Timeout
+	u.httpClient = &client
+	return nil
+}
+
 // Name returns the updater identifier.
 func (u *ClamAVUpdater) Name() string {
 	return "clamav"
@@ -87,36 +123,114 @@ func (u *ClamAVUpdater) Update(ctx context.Context) (*UpdateResult, error) {
 		return nil, err
 	}
 
-	stats, err := u.feed.Update(ctx)
-	if err != nil {
-		return &UpdateResult{
-			Success:  false,
-			Duration: time.Since(start),
-			Error:    err.Error(),
-		}, err
+	if u.config.VerifyOnly {
+		return u.verify(ctx, start)
+	}
+
+	// Bring outdated databases forward with .cdiff patches where possible;
+	// everything else goes through a full CVD download.
+	patched, skipped := 0, 0
+	var full []string
+	for _, db := range u.config.Databases {
+		if err := ctx.Err(); err != nil {
+			return &UpdateResult{
+				Success:  false,
+				Duration: time.Since(start),
+				Error:    err.Error(),
+			}, err
+		}
+
+		localVersion, _ := u.feed.GetLocalVersion(db)
+		remoteVersion, err := u.checkRemoteVersion(ctx, db)
+		if err != nil || localVersion == 0 {
+			full = append(full, db)
+			continue
+		}
+
+		if remoteVersion <= localVersion {
+			skipped++
+			continue
+		}
+
+		if err := u.applyCDIFFs(ctx, db, localVersion, remoteVersion); err != nil {
+			full = append(full, db)
+			continue
+		}
+		patched++
+	}
+
+	stats := &feeds.UpdateStats{}
+	if len(full) > 0 {
+		var err error
+		stats, err = u.feed.UpdateDatabases(ctx, full)
+		if err != nil {
+			return &UpdateResult{
+				Success:  false,
+				Duration: time.Since(start),
+				Error:    err.Error(),
+			}, err
+		}
 	}
 
 	result := &UpdateResult{
 		Success:    stats.Failed == 0,
-		Downloaded: stats.Downloaded,
-		Skipped:    stats.Skipped,
+		Downloaded: stats.Downloaded + patched,
+		Patched:    patched,
+		Skipped:    stats.Skipped + skipped,
 		Failed:     stats.Failed,
 		Duration:   time.Since(start),
 		Versions:   u.feed.GetVersionInfo(),
 	}
 
 	// If databases were updated and clamd address is configured, send reload.
-	if stats.Downloaded > 0 && u.config.ClamdAddress != "" {
+	if result.Downloaded > 0 && u.config.ClamdAddress != "" {
 		if err := u.reloadClamd(ctx); err != nil {
 			// Don't fail the update, just log/track the error.
 			result.Error = fmt.Sprintf("update succeeded but reload failed: %v", err)
 		}
 	}
+	if result.Downloaded > 0 && u.config.OnUpdated != nil {
+		u.config.OnUpdated(ctx)
+	}
+
+	return result, nil
+}
+
+// verify checks the integrity of each local database.
+func (u *ClamAVUpdater) verify(ctx context.Context, start time.Time) (*UpdateResult, error) {
+	result := &UpdateResult{Success: true}
+
+	var problems []string
+	for _, db := range u.config.Databases {
+		if err := ctx.Err(); err != nil {
+			return &UpdateResult{
+				Success:  false,
+				Duration: time.Since(start),
+				Error:    err.Error(),
+			}, err
+		}
+
+		if _, err := u.feed.VerifyLocalDatabase(db); err != nil {
+			result.Failed++
+			problems = append(problems, fmt.Sprintf("%s: %v", db, err))
+			continue
+		}
+		result.Skipped++
+	}
+
+	if len(problems) > 0 {
+		result.Success = false
+		result.Error = strings.Join(problems, "; ")
+	}
+	result.Duration = time.Since(start)
+	result.Versions = u.feed.GetVersionInfo()
 
 	return result, nil
 }
 
 // CheckForUpdates checks if updates are available without downloading.
+// It returns an error when a database's version cannot be read from any
+// mirror, since its state is then unknown rather than up to date.
 func (u *ClamAVUpdater) CheckForUpdates(ctx context.Context) (*CheckResult, error) {
 	// Check context first.
 	if err := ctx.Err(); err != nil {
@@ -132,10 +246,12 @@ func (u *ClamAVUpdater) CheckForUpdates(ctx context.Context) (*CheckResult, erro
 
 		remoteVersion, err := u.checkRemoteVersion(ctx, db)
 		if err != nil {
-			result.Details[db] = fmt.Sprintf("error: %v", err)
-			continue
+			return nil, fmt.Errorf("checking %s: %w", db, err)
 		}
 
+		result.CurrentVersion = max(result.CurrentVersion, localVersion)
+		result.AvailableVersion = max(result.AvailableVersion, remoteVersion)
+
 		if remoteVersion > localVersion {
 			result.UpdateAvailable = true
 			result.Details[db] = fmt.Sprintf("local=%d remote=%d", localVersion, remoteVersion)
@@ -147,10 +263,10 @@ func (u *ClamAVUpdater) CheckForUpdates(ctx context.Context) (*CheckResult, erro
 	return result, nil
 }
 
-// checkRemoteVersion checks the version of a remote database.
+// checkRemoteVersion reads the version of a remote database from its CVD
+// header, requesting only the first 512 bytes from each mirror.
 func (u *ClamAVUpdater) checkRemoteVersion(ctx context.Context, database string) (int, error) {
-	downloader := feeds.NewDownloader(nil)
-
+	var lastErr error
 	for _, mirror := range u.config.Mirrors {
 		select {
 		case <-ctx.Done():
@@ -160,48 +276,116 @@ func (u *ClamAVUpdater) checkRemoteVersion(ctx context.Context, database string)
 
 		url := fmt.Sprintf("%s/%s", strings.TrimSuffix(mirror, "/"), database)
 
-		// Download only the header (first 512 bytes would be ideal, but we download full).
-		data, err := downloader.Download(ctx, url)
+		header, err := u.fetchHeader(ctx, url)
 		if err != nil {
+			lastErr = err
 			continue
 		}
 
-		if len(data) < 512 {
-			continue
-		}
-
-		version, err := parseCVDHeaderVersion(data[:512])
+		cvdHeader, err := feeds.ParseCVDHeader(header)
 		if err != nil {
+			lastErr = err
 			continue
 		}
 
-		return version, nil
+		return cvdHeader.Version, nil
 	}
 
-	return 0, fmt.Errorf("could not check version from any mirror")
+	return 0, fmt.Errorf("could not check version from any mirror: %w", lastErr)
 }
 
-// parseCVDHeaderVersion extracts the version from CVD header bytes.
-func parseCVDHeaderVersion(data []byte) (int, error) {
-	if len(data) < 512 {
-		return 0, fmt.Errorf("data too small for CVD header")
+// fetchHeader downloads the 512-byte CVD header at url. Mirrors that ignore
+// the Range request are read only as far as the header.
+func (u *ClamAVUpdater) fetchHeader(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
 	}
+	req.Header.Set("Range", "bytes=0-511")
+	req.Header.Set("User-Agent", feeds.DefaultDownloaderConfig().UserAgent)
 
-	headerStr := string(bytes.TrimRight(data[:512], "\x00"))
-	parts := strings.Split(headerStr, ":")
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting %s: %w", url, err)
+	}
+	defer resp.Body.Close()
 
-	if len(parts) < 3 {
-		return 0, fmt.Errorf("invalid CVD header format")
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("unexpected status %d for %s", resp.StatusCode, url)
 	}
 
-	if !strings.HasPrefix(parts[0], "ClamAV-VDB") {
-		return 0, fmt.Errorf("not a valid CVD file")
+	header := make([]byte, 512)
+	if _, err := io.ReadFull(resp.Body, header); err != nil {
+		return nil, fmt.Errorf("reading CVD header from %s: %w", url, err)
 	}
 
-	var version int
-	fmt.Sscanf(parts[2], "%d", &version)
+	return header, nil
+}
+
+// applyCDIFFs upgrades a local database from one version to another by
+// downloading and applying each intermediate .cdiff in order, then saving
+// the result as a CLD. The local database is left untouched on failure so
+// the caller can fall back to a full download.
+func (u *ClamAVUpdater) applyCDIFFs(ctx context.Context, database string, from, to int) error {
+	if to-from > maxCDIFFChain {
+		return fmt.Errorf("%s is %d versions behind, exceeding the cdiff limit of %d", database, to-from, maxCDIFFChain)
+	}
+
+	data, err := u.feed.ReadLocalDatabase(database)
+	if err != nil {
+		return fmt.Errorf("reading local %s: %w", database, err)
+	}
+
+	workDir, err := os.MkdirTemp("", "argus-cdiff-*")
+	if err != nil {
+		return fmt.Errorf("creating work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	header, err := feeds.UnpackCVD(data, workDir)
+	if err != nil {
+		return fmt.Errorf("unpacking %s: %w", database, err)
+	}
+	if header.Version != from {
+		return fmt.Errorf("local %s is version %d, expected %d", database, header.Version, from)
+	}
+
+	for version := from + 1; version <= to; version++ {
+		name := feeds.CDIFFName(database, version)
+
+		diff, err := u.downloadCDIFF(ctx, name)
+		if err != nil {
+			return err
+		}
+		if err := feeds.ApplyCDIFF(workDir, diff); err != nil {
+			return fmt.Errorf("applying %s: %w", name, err)
+		}
+	}
+
+	header.Version = to
+	cld, err := feeds.BuildCLD(workDir, header)
+	if err != nil {
+		return fmt.Errorf("building %s: %w", feeds.CLDName(database), err)
+	}
+
+	return u.feed.SaveCLD(database, cld)
+}
+
+// downloadCDIFF fetches a .cdiff file from the first mirror that has it.
+func (u *ClamAVUpdater) downloadCDIFF(ctx context.Context, name string) ([]byte, error) {
+	var lastErr error
+	for _, mirror := range u.config.Mirrors {
+		url := fmt.Sprintf("%s/%s", strings.TrimSuffix(mirror, "/"), name)
+
+		data, err := u.downloader.Download(ctx, url)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return data, nil
+	}
 
-	return version, nil
+	return nil, fmt.Errorf("downloading %s: %w", name, lastErr)
 }
 
 // GetVersionInfo returns the current database versions.
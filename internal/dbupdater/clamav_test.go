@@ -4,28 +4,43 @@
 package dbupdater
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
-)
 
-// createTestCVD creates a minimal valid CVD file for testing.
-func createTestCVD(version int) []byte {
-	header := make([]byte, 512)
-	headerStr := fmt.Sprintf("ClamAV-VDB:01 Jan 2024 00-00 +0000:%d:100000:77:abc123:def456:builder:1704067200", version)
-	copy(header, headerStr)
+	"github.com/hikmaai-io/hikmaai-argus/internal/feeds"
+)
 
-	gzipData := []byte{
+// testCVDBody is a minimal valid gzip stream (empty content) and
+// testCVDBodyMD5 its MD5, as recorded in CVD headers.
+var (
+	testCVDBody = []byte{
 		0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03,
 		0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
 	}
+	testCVDBodyMD5 = func() string {
+		sum := md5.Sum(testCVDBody)
+		return hex.EncodeToString(sum[:])
+	}()
+)
+
+// createTestCVD creates a minimal valid CVD file for testing.
+func createTestCVD(version int) []byte {
+	header := make([]byte, 512)
+	copy(header, fmt.Sprintf("ClamAV-VDB:01 Jan 2024 00-00 +0000:%d:100000:77:%s:def456:builder:1704067200", version, testCVDBodyMD5))
 
-	return append(header, gzipData...)
+	return append(header, testCVDBody...)
 }
 
 func TestClamAVUpdater_Name(t *testing.T) {
@@ -51,10 +66,12 @@ func TestClamAVUpdater_Update_Success(t *testing.T) {
 	defer server.Close()
 
 	dbDir := t.TempDir()
+	var notified atomic.Int32
 	updater := NewClamAVUpdater(ClamAVUpdaterConfig{
 		DatabaseDir: dbDir,
 		Mirrors:     []string{server.URL},
 		Databases:   []string{"test.cvd"},
+		OnUpdated:   func(context.Context) { notified.Add(1) },
 	})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -71,6 +88,9 @@ func TestClamAVUpdater_Update_Success(t *testing.T) {
 	if result.Downloaded != 1 {
 		t.Errorf("Downloaded = %d, want 1", result.Downloaded)
 	}
+	if got := notified.Load(); got != 1 {
+		t.Errorf("OnUpdated calls = %d, want 1", got)
+	}
 
 	// File should exist.
 	if _, err := os.Stat(filepath.Join(dbDir, "test.cvd")); err != nil {
@@ -78,6 +98,45 @@ func TestClamAVUpdater_Update_Success(t *testing.T) {
 	}
 }
 
+func TestClamAVUpdater_SetDownloaderConfig(t *testing.T) {
+	t.Parallel()
+
+	testData := createTestCVD(100)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testData)
+	}))
+	defer server.Close()
+
+	newUpdater := func() *ClamAVUpdater {
+		return NewClamAVUpdater(ClamAVUpdaterConfig{
+			DatabaseDir: t.TempDir(),
+			Mirrors:     []string{server.URL},
+			Databases:   []string{"test.cvd"},
+		})
+	}
+
+	// The test server's certificate is not trusted by default.
+	if result, err := newUpdater().Update(context.Background()); err == nil && result.Downloaded != 0 {
+		t.Errorf("Downloaded = %d from an untrusted mirror, want 0", result.Downloaded)
+	}
+
+	updater := newUpdater()
+	if err := updater.SetDownloaderConfig(feeds.DownloaderConfig{InsecureSkipVerify: true}); err != nil {
+		t.Fatalf("SetDownloaderConfig() error = %v", err)
+	}
+	result, err := updater.Update(context.Background())
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if result.Downloaded != 1 {
+		t.Errorf("Downloaded = %d, want 1", result.Downloaded)
+	}
+
+	if err := newUpdater().SetDownloaderConfig(feeds.DownloaderConfig{ProxyURL: "proxy.internal:3128"}); err == nil {
+		t.Error("SetDownloaderConfig() with invalid proxy error = nil, want error")
+	}
+}
+
 func TestClamAVUpdater_Update_AlreadyUpToDate(t *testing.T) {
 	t.Parallel()
 
@@ -95,10 +154,12 @@ func TestClamAVUpdater_Update_AlreadyUpToDate(t *testing.T) {
 		t.Fatalf("Failed to create test file: %v", err)
 	}
 
+	var notified atomic.Int32
 	updater := NewClamAVUpdater(ClamAVUpdaterConfig{
 		DatabaseDir: dbDir,
 		Mirrors:     []string{server.URL},
 		Databases:   []string{"test.cvd"},
+		OnUpdated:   func(context.Context) { notified.Add(1) },
 	})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -112,6 +173,9 @@ func TestClamAVUpdater_Update_AlreadyUpToDate(t *testing.T) {
 	if result.Downloaded != 0 {
 		t.Errorf("Downloaded = %d, want 0 (already up to date)", result.Downloaded)
 	}
+	if got := notified.Load(); got != 0 {
+		t.Errorf("OnUpdated calls = %d, want 0", got)
+	}
 	if result.Skipped != 1 {
 		t.Errorf("Skipped = %d, want 1", result.Skipped)
 	}
@@ -239,6 +303,119 @@ func TestClamAVUpdater_IsReady(t *testing.T) {
 	}
 }
 
+func TestClamAVUpdater_Update_VerifyOnly(t *testing.T) {
+	t.Parallel()
+
+	// Any request to the mirror is a failure: VerifyOnly must not download.
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write(createTestCVD(999))
+	}))
+	defer server.Close()
+
+	dbDir := t.TempDir()
+	installed := map[string][]byte{
+		"main.cvd": createTestCVD(1),
+	}
+	corrupted := createTestCVD(2)
+	installed["daily.cvd"] = corrupted[:len(corrupted)-6]
+	for name, data := range installed {
+		if err := os.WriteFile(filepath.Join(dbDir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var notified atomic.Int32
+	updater := NewClamAVUpdater(ClamAVUpdaterConfig{
+		DatabaseDir: dbDir,
+		Mirrors:     []string{server.URL},
+		Databases:   []string{"main.cvd", "daily.cvd"},
+		OnUpdated:   func(context.Context) { notified.Add(1) },
+		VerifyOnly:  true,
+	})
+
+	result, err := updater.Update(context.Background())
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if result.Success {
+		t.Error("Update() should fail verification for truncated daily.cvd")
+	}
+	if result.Failed != 1 || result.Skipped != 1 || result.Downloaded != 0 {
+		t.Errorf("Update() = %s, want 1 verified and 1 failed", result)
+	}
+	if !strings.Contains(result.Error, "daily.cvd") {
+		t.Errorf("Error = %q, want it to name daily.cvd", result.Error)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("mirror requests = %d, want 0", n)
+	}
+	if n := notified.Load(); n != 0 {
+		t.Errorf("OnUpdated calls = %d, want 0", n)
+	}
+
+	// Nothing in the database directory is written, not even the corrupted file.
+	entries, err := os.ReadDir(dbDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(installed) {
+		t.Errorf("database dir has %d entries, want %d", len(entries), len(installed))
+	}
+	for name, want := range installed {
+		data, err := os.ReadFile(filepath.Join(dbDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, want) {
+			t.Errorf("%s was modified by a verify-only run", name)
+		}
+	}
+}
+
+func TestClamAVUpdater_Update_CorruptedDownload(t *testing.T) {
+	t.Parallel()
+
+	// The mirror serves a truncated CVD that fails its MD5 check.
+	corrupted := createTestCVD(999)
+	corrupted = corrupted[:len(corrupted)-6]
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(corrupted)
+	}))
+	defer server.Close()
+
+	dbDir := t.TempDir()
+	installed := createTestCVD(1)
+	if err := os.WriteFile(filepath.Join(dbDir, "main.cvd"), installed, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var notified atomic.Int32
+	updater := NewClamAVUpdater(ClamAVUpdaterConfig{
+		DatabaseDir: dbDir,
+		Mirrors:     []string{server.URL},
+		Databases:   []string{"main.cvd"},
+		OnUpdated:   func(context.Context) { notified.Add(1) },
+	})
+
+	result, err := updater.Update(context.Background())
+	if err == nil && result.Success {
+		t.Error("Update() should fail for a corrupted download")
+	}
+	if got := notified.Load(); got != 0 {
+		t.Errorf("OnUpdated calls = %d, want 0", got)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbDir, "main.cvd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, installed) {
+		t.Error("installed main.cvd was replaced by the corrupted download")
+	}
+}
+
 func TestClamAVUpdater_ImplementsUpdater(t *testing.T) {
 	t.Parallel()
 
@@ -260,3 +437,200 @@ func TestClamAVUpdaterConfig_Defaults(t *testing.T) {
 		t.Error("Should have default databases")
 	}
 }
+
+// cdiffMirror serves daily.cvd at remoteVersion and the given cdiff scripts,
+// recording how many full CVD downloads were made.
+type cdiffMirror struct {
+	server        *httptest.Server
+	fullDownloads atomic.Int32
+}
+
+func newCDIFFMirror(t *testing.T, remote []byte, cdiffs map[string]string) *cdiffMirror {
+	t.Helper()
+
+	m := &cdiffMirror{}
+	m.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		if name == "daily.cvd" {
+			if r.Header.Get("Range") == "" {
+				m.fullDownloads.Add(1)
+			}
+			w.Write(remote)
+			return
+		}
+		script, ok := cdiffs[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write([]byte(script))
+		gz.Close()
+		w.Write(buf.Bytes())
+	}))
+	t.Cleanup(m.server.Close)
+
+	return m
+}
+
+// buildTestDatabase packs files into a CVD-format database at version.
+func buildTestDatabase(t *testing.T, version int, files map[string]string) []byte {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := feeds.BuildCLD(dir, &feeds.CVDHeader{Version: version, Signatures: len(files)})
+	if err != nil {
+		t.Fatalf("BuildCLD() error = %v", err)
+	}
+	return data
+}
+
+func TestClamAVUpdater_Update_AppliesCDIFFs(t *testing.T) {
+	t.Parallel()
+
+	remote := buildTestDatabase(t, 102, map[string]string{"daily.hdb": "unused\n"})
+	mirror := newCDIFFMirror(t, remote, map[string]string{
+		"daily-101.cdiff": "OPEN daily.hdb\nDEL 1 aaaa\nADD cccc:30:Sig.C\nCLOSE\n",
+		"daily-102.cdiff": "OPEN daily.hdb\nXCHG 1 bbbb bbbb:21:Sig.B.v2\nCLOSE\n",
+	})
+
+	dbDir := t.TempDir()
+	local := buildTestDatabase(t, 100, map[string]string{"daily.hdb": "aaaa:10:Sig.A\nbbbb:20:Sig.B\n"})
+	if err := os.WriteFile(filepath.Join(dbDir, "daily.cvd"), local, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	updater := NewClamAVUpdater(ClamAVUpdaterConfig{
+		DatabaseDir: dbDir,
+		Mirrors:     []string{mirror.server.URL},
+		Databases:   []string{"daily.cvd"},
+	})
+
+	result, err := updater.Update(context.Background())
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if !result.Success || result.Patched != 1 || result.Downloaded != 1 {
+		t.Errorf("Update() = %s, want one patched database", result)
+	}
+	if n := mirror.fullDownloads.Load(); n != 0 {
+		t.Errorf("full CVD downloads = %d, want 0", n)
+	}
+
+	if v, err := updater.GetLocalVersion("daily.cvd"); err != nil || v != 102 {
+		t.Errorf("GetLocalVersion() = %d, %v; want 102", v, err)
+	}
+	if _, err := os.Stat(filepath.Join(dbDir, "daily.cvd")); !os.IsNotExist(err) {
+		t.Error("daily.cvd should be replaced by daily.cld")
+	}
+
+	cld, err := os.ReadFile(filepath.Join(dbDir, "daily.cld"))
+	if err != nil {
+		t.Fatalf("reading daily.cld: %v", err)
+	}
+	unpacked := t.TempDir()
+	if _, err := feeds.UnpackCVD(cld, unpacked); err != nil {
+		t.Fatalf("UnpackCVD() error = %v", err)
+	}
+	got, _ := os.ReadFile(filepath.Join(unpacked, "daily.hdb"))
+	if want := "bbbb:21:Sig.B.v2\ncccc:30:Sig.C\n"; string(got) != want {
+		t.Errorf("patched daily.hdb = %q, want %q", got, want)
+	}
+
+	// A second run finds the CLD current and does nothing.
+	result, err = updater.Update(context.Background())
+	if err != nil {
+		t.Fatalf("second Update() error = %v", err)
+	}
+	if result.Skipped != 1 || result.Downloaded != 0 {
+		t.Errorf("second Update() = %s, want skipped", result)
+	}
+}
+
+func TestClamAVUpdater_Update_CDIFFFallback(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		cdiffs map[string]string
+	}{
+		{
+			name:   "missing diff",
+			cdiffs: map[string]string{"daily-101.cdiff": "OPEN daily.hdb\nADD cccc:30:Sig.C\nCLOSE\n"},
+		},
+		{
+			name: "corrupt diff",
+			cdiffs: map[string]string{
+				"daily-101.cdiff": "OPEN daily.hdb\nDEL 1 zzzz\nCLOSE\n",
+				"daily-102.cdiff": "OPEN daily.hdb\nADD dddd:40:Sig.D\nCLOSE\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			remote := buildTestDatabase(t, 102, map[string]string{"daily.hdb": "full:1:Full\n"})
+			mirror := newCDIFFMirror(t, remote, tt.cdiffs)
+
+			dbDir := t.TempDir()
+			local := buildTestDatabase(t, 100, map[string]string{"daily.hdb": "aaaa:10:Sig.A\n"})
+			if err := os.WriteFile(filepath.Join(dbDir, "daily.cvd"), local, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			updater := NewClamAVUpdater(ClamAVUpdaterConfig{
+				DatabaseDir: dbDir,
+				Mirrors:     []string{mirror.server.URL},
+				Databases:   []string{"daily.cvd"},
+			})
+
+			result, err := updater.Update(context.Background())
+			if err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if result.Patched != 0 || result.Downloaded != 1 {
+				t.Errorf("Update() = %s, want one full download", result)
+			}
+			if n := mirror.fullDownloads.Load(); n != 1 {
+				t.Errorf("full CVD downloads = %d, want 1", n)
+			}
+
+			got, err := os.ReadFile(filepath.Join(dbDir, "daily.cvd"))
+			if err != nil || !bytes.Equal(got, remote) {
+				t.Error("daily.cvd should be the full remote database")
+			}
+			if _, err := os.Stat(filepath.Join(dbDir, "daily.cld")); !os.IsNotExist(err) {
+				t.Error("no CLD should be written when patching fails")
+			}
+		})
+	}
+}
+
+func TestClamAVUpdater_CheckForUpdates_MirrorsUnreachable(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	updater := NewClamAVUpdater(ClamAVUpdaterConfig{
+		DatabaseDir: t.TempDir(),
+		Mirrors:     []string{server.URL},
+		Databases:   []string{"test.cvd"},
+	})
+
+	// Failing mirrors must not read as "no update available".
+	if result, err := updater.CheckForUpdates(context.Background()); err == nil {
+		t.Errorf("CheckForUpdates() = %+v, want error", result)
+	}
+}
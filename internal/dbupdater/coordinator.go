@@ -1,5 +1,5 @@
 // ABOUTME: Scan coordinator with RWLock semantics for scan/update coordination
-// ABOUTME: Multiple scans concurrent, updates exclusive with context support
+// ABOUTME: Multiple scans concurrent, updates exclusive with context support and try variants
 
 package dbupdater
 
@@ -15,12 +15,18 @@ type CoordinatorStatus struct {
 
 	// UpdateInProgress indicates if a database update is running.
 	UpdateInProgress bool
+
+	// PendingUpdates is the number of updates waiting for scans to drain.
+	PendingUpdates int
 }
 
 // ScanCoordinator manages concurrent access between scans and database updates.
 // It implements RWLock semantics: multiple scans can run concurrently,
 // but updates are exclusive (no scans during update, no updates during scan).
 //
+// The coordinator is write-preferring: once an update is waiting, new scans
+// block until it completes, so steady scan load cannot starve updates.
+//
 // Uses channel-based signaling for context-aware waiting without goroutine leaks.
 type ScanCoordinator struct {
 	mu sync.Mutex
@@ -31,6 +37,9 @@ type ScanCoordinator struct {
 	// updating indicates if an update operation is in progress.
 	updating bool
 
+	// pendingUpdates is the number of updates waiting for scans to drain.
+	pendingUpdates int
+
 	// broadcast is closed to wake all waiters, then recreated.
 	broadcast chan struct{}
 }
@@ -50,7 +59,8 @@ func (c *ScanCoordinator) signal() {
 }
 
 // AcquireForScan acquires the lock for a scan operation.
-// Multiple scans can run concurrently. Blocks if an update is in progress.
+// Multiple scans can run concurrently. Blocks if an update is in progress
+// or waiting to start.
 // Returns a release function that must be called when the scan completes.
 func (c *ScanCoordinator) AcquireForScan(ctx context.Context) (release func(), err error) {
 	// Check context first.
@@ -60,8 +70,8 @@ func (c *ScanCoordinator) AcquireForScan(ctx context.Context) (release func(), e
 
 	c.mu.Lock()
 
-	// Wait while update is in progress.
-	for c.updating {
+	// Wait while an update is in progress or waiting.
+	for c.updating || c.pendingUpdates > 0 {
 		// Capture current broadcast channel while holding lock.
 		wait := c.broadcast
 		c.mu.Unlock()
@@ -81,7 +91,26 @@ func (c *ScanCoordinator) AcquireForScan(ctx context.Context) (release func(), e
 	c.activeScans++
 	c.mu.Unlock()
 
-	// Return release function with once guard.
+	return c.scanRelease(), nil
+}
+
+// TryAcquireForScan acquires the lock for a scan operation without blocking.
+// Returns ok=false if an update is in progress or waiting.
+func (c *ScanCoordinator) TryAcquireForScan() (release func(), ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.updating || c.pendingUpdates > 0 {
+		return nil, false
+	}
+
+	c.activeScans++
+	return c.scanRelease(), true
+}
+
+// scanRelease returns the release function for an acquired scan.
+// The function is safe to call more than once.
+func (c *ScanCoordinator) scanRelease() func() {
 	var once sync.Once
 	return func() {
 		once.Do(func() {
@@ -92,7 +121,7 @@ func (c *ScanCoordinator) AcquireForScan(ctx context.Context) (release func(), e
 			}
 			c.mu.Unlock()
 		})
-	}, nil
+	}
 }
 
 // AcquireForUpdate acquires the lock for an update operation.
@@ -106,6 +135,9 @@ func (c *ScanCoordinator) AcquireForUpdate(ctx context.Context) (release func(),
 
 	c.mu.Lock()
 
+	// Register as pending so new scans wait behind this update.
+	c.pendingUpdates++
+
 	// Wait while another update is in progress or scans are active.
 	for c.updating || c.activeScans > 0 {
 		// Capture current broadcast channel while holding lock.
@@ -117,6 +149,11 @@ func (c *ScanCoordinator) AcquireForUpdate(ctx context.Context) (release func(),
 		case <-wait:
 			// State changed, re-acquire lock and re-check condition.
 		case <-ctx.Done():
+			// Withdraw the pending update and wake scans blocked behind it.
+			c.mu.Lock()
+			c.pendingUpdates--
+			c.signal()
+			c.mu.Unlock()
 			return nil, ctx.Err()
 		}
 
@@ -124,10 +161,30 @@ func (c *ScanCoordinator) AcquireForUpdate(ctx context.Context) (release func(),
 	}
 
 	// Mark update as in progress while holding lock.
+	c.pendingUpdates--
 	c.updating = true
 	c.mu.Unlock()
 
-	// Return release function with once guard.
+	return c.updateRelease(), nil
+}
+
+// TryAcquireForUpdate acquires the lock for an update operation without
+// blocking. Returns ok=false if another update or any scan is in progress.
+func (c *ScanCoordinator) TryAcquireForUpdate() (release func(), ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.updating || c.activeScans > 0 {
+		return nil, false
+	}
+
+	c.updating = true
+	return c.updateRelease(), true
+}
+
+// updateRelease returns the release function for an acquired update.
+// The function is safe to call more than once.
+func (c *ScanCoordinator) updateRelease() func() {
 	var once sync.Once
 	return func() {
 		once.Do(func() {
@@ -136,7 +193,7 @@ func (c *ScanCoordinator) AcquireForUpdate(ctx context.Context) (release func(),
 			c.signal()
 			c.mu.Unlock()
 		})
-	}, nil
+	}
 }
 
 // HasActiveScans returns true if there are any active scan operations.
@@ -160,5 +217,6 @@ func (c *ScanCoordinator) Status() CoordinatorStatus {
 	return CoordinatorStatus{
 		ActiveScans:      int(c.activeScans),
 		UpdateInProgress: c.updating,
+		PendingUpdates:   c.pendingUpdates,
 	}
 }
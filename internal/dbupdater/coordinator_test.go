@@ -172,6 +172,133 @@ func TestScanCoordinator_AcquireForUpdate_WaitsForScans(t *testing.T) {
 	release()
 }
 
+func TestScanCoordinator_PendingUpdateBlocksNewScans(t *testing.T) {
+	t.Parallel()
+
+	c := NewScanCoordinator()
+	ctx := context.Background()
+
+	// Start a long scan.
+	releaseLongScan, err := c.AcquireForScan(ctx)
+	if err != nil {
+		t.Fatalf("AcquireForScan() error = %v", err)
+	}
+
+	// Request an update; it waits for the long scan.
+	var updateDone atomic.Bool
+	updateAcquired := make(chan struct{})
+	go func() {
+		release, err := c.AcquireForUpdate(ctx)
+		if err != nil {
+			t.Errorf("AcquireForUpdate() error = %v", err)
+			close(updateAcquired)
+			return
+		}
+		close(updateAcquired)
+		time.Sleep(20 * time.Millisecond)
+		updateDone.Store(true)
+		release()
+	}()
+
+	waitFor(t, func() bool { return c.Status().PendingUpdates == 1 })
+
+	// A new scan must block behind the pending update.
+	scanAcquired := make(chan bool, 1)
+	go func() {
+		release, err := c.AcquireForScan(ctx)
+		if err != nil {
+			t.Errorf("AcquireForScan() error = %v", err)
+			scanAcquired <- false
+			return
+		}
+		scanAcquired <- updateDone.Load()
+		release()
+	}()
+
+	select {
+	case <-scanAcquired:
+		t.Fatal("new scan acquired while an update was pending")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if _, ok := c.TryAcquireForScan(); ok {
+		t.Error("TryAcquireForScan() ok = true while an update was pending")
+	}
+
+	// Draining the long scan lets the update run, then the new scan.
+	releaseLongScan()
+	<-updateAcquired
+
+	select {
+	case afterUpdate := <-scanAcquired:
+		if !afterUpdate {
+			t.Error("new scan acquired before the update finished")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("new scan did not acquire after the update finished")
+	}
+}
+
+func TestScanCoordinator_CancelledPendingUpdateReleasesScans(t *testing.T) {
+	t.Parallel()
+
+	c := NewScanCoordinator()
+	ctx := context.Background()
+
+	releaseScan, err := c.AcquireForScan(ctx)
+	if err != nil {
+		t.Fatalf("AcquireForScan() error = %v", err)
+	}
+	defer releaseScan()
+
+	updateCtx, cancelUpdate := context.WithCancel(ctx)
+	updateErr := make(chan error, 1)
+	go func() {
+		_, err := c.AcquireForUpdate(updateCtx)
+		updateErr <- err
+	}()
+	waitFor(t, func() bool { return c.Status().PendingUpdates == 1 })
+
+	scanErr := make(chan error, 1)
+	go func() {
+		release, err := c.AcquireForScan(ctx)
+		if err == nil {
+			release()
+		}
+		scanErr <- err
+	}()
+
+	// Cancelling the pending update must unblock the waiting scan.
+	cancelUpdate()
+	if err := <-updateErr; err == nil {
+		t.Error("AcquireForUpdate() error = nil, want context error")
+	}
+
+	select {
+	case err := <-scanErr:
+		if err != nil {
+			t.Errorf("AcquireForScan() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("scan stayed blocked after the pending update was cancelled")
+	}
+	if pending := c.Status().PendingUpdates; pending != 0 {
+		t.Errorf("PendingUpdates = %d, want 0", pending)
+	}
+}
+
+// waitFor polls cond until it returns true or a second elapses.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
 func TestScanCoordinator_AcquireForScan_ContextCancelled(t *testing.T) {
 	t.Parallel()
 
@@ -265,6 +392,90 @@ func TestScanCoordinator_DoubleRelease_Safe(t *testing.T) {
 	release2()
 }
 
+func TestScanCoordinator_TryAcquire(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		holdScan   bool
+		holdUpdate bool
+		wantScan   bool
+		wantUpdate bool
+	}{
+		{name: "idle", wantScan: true, wantUpdate: true},
+		{name: "scan held", holdScan: true, wantScan: true, wantUpdate: false},
+		{name: "update held", holdUpdate: true, wantScan: false, wantUpdate: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := NewScanCoordinator()
+			ctx := context.Background()
+
+			if tt.holdScan {
+				release, _ := c.AcquireForScan(ctx)
+				defer release()
+			}
+			if tt.holdUpdate {
+				release, _ := c.AcquireForUpdate(ctx)
+				defer release()
+			}
+
+			releaseScan, ok := c.TryAcquireForScan()
+			if ok != tt.wantScan {
+				t.Errorf("TryAcquireForScan() ok = %v, want %v", ok, tt.wantScan)
+			}
+			if ok {
+				releaseScan()
+			}
+
+			releaseUpdate, ok := c.TryAcquireForUpdate()
+			if ok != tt.wantUpdate {
+				t.Errorf("TryAcquireForUpdate() ok = %v, want %v", ok, tt.wantUpdate)
+			}
+			if ok {
+				releaseUpdate()
+			}
+		})
+	}
+}
+
+func TestScanCoordinator_TryAcquire_DoubleRelease_Safe(t *testing.T) {
+	t.Parallel()
+
+	c := NewScanCoordinator()
+
+	releaseScan, ok := c.TryAcquireForScan()
+	if !ok {
+		t.Fatal("TryAcquireForScan() ok = false, want true")
+	}
+	releaseScan()
+	releaseScan()
+
+	if status := c.Status(); status.ActiveScans != 0 {
+		t.Errorf("ActiveScans after double release = %d, want 0", status.ActiveScans)
+	}
+
+	releaseUpdate, ok := c.TryAcquireForUpdate()
+	if !ok {
+		t.Fatal("TryAcquireForUpdate() after scan release ok = false, want true")
+	}
+	releaseUpdate()
+	releaseUpdate()
+
+	// A scan held after a double update release must keep the update lock out.
+	releaseScan, ok = c.TryAcquireForScan()
+	if !ok {
+		t.Fatal("TryAcquireForScan() after update release ok = false, want true")
+	}
+	defer releaseScan()
+	if _, ok := c.TryAcquireForUpdate(); ok {
+		t.Error("TryAcquireForUpdate() with active scan ok = true, want false")
+	}
+}
+
 func TestScanCoordinator_UpdateExclusive(t *testing.T) {
 	t.Parallel()
 
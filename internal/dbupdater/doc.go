@@ -28,13 +28,14 @@ Backoff implements exponential backoff with jitter for retry logic:
 			break
 		}
 
-		delay, ok := b.NextDelay()
-		if !ok {
-			return errors.New("max retries exceeded")
+		if err := b.Wait(ctx); err != nil {
+			return err // ErrMaxRetriesExceeded or ctx.Err().
 		}
-		time.Sleep(delay)
 	}
 
+Set Strategy to StrategyDecorrelated for AWS-style decorrelated jitter,
+which spreads retries from many replicas better than proportional jitter.
+
 ScanCoordinator manages concurrent access between scans and database updates
 using RWLock semantics:
 
@@ -56,6 +57,10 @@ using RWLock semantics:
 	defer release()
 	// ... perform update ...
 
+The coordinator is write-preferring: while an update waits for running scans
+to drain, new scans block behind it. TryAcquireForScan and TryAcquireForUpdate
+return immediately instead of waiting.
+
 StatusTracker monitors the health and version of multiple updaters:
 
 	tracker := dbupdater.NewStatusTracker()
@@ -115,9 +120,9 @@ TrivyUpdater downloads the Trivy vulnerability database:
 		SkipJavaDB: true,
 	})
 
-	// Check if update is needed
-	check, _ := updater.CheckForUpdates(ctx)
-	if check.NeedsUpdate() {
+	// Check if update is needed; a failed check leaves the state unknown
+	check, err := updater.CheckForUpdates(ctx)
+	if err != nil || check.NeedsUpdate() {
 		result, err := updater.Update(ctx)
 		// ...
 	}
@@ -131,6 +136,7 @@ scheduled updates, retry logic, and scan coordination:
 	svc := dbupdater.NewDBUpdateService(dbupdater.DBUpdateServiceConfig{
 		Coordinator:      coordinator,
 		RunInitialUpdate: true,
+		StateDir:         "/var/lib/argus", // remembers last updates across restarts
 		RetryConfig: dbupdater.BackoffConfig{
 			MaxRetries:   5,
 			InitialDelay: 30 * time.Second,
@@ -146,7 +152,11 @@ scheduled updates, retry logic, and scan coordination:
 	trivy := dbupdater.NewTrivyUpdater(dbupdater.TrivyUpdaterConfig{
 		CacheDir: "/var/cache/trivy",
 	})
-	svc.RegisterUpdater(trivy, 6*time.Hour)
+	// Skip scheduled runs when CheckForUpdates reports no new data
+	svc.RegisterUpdaterWithOptions(trivy, dbupdater.UpdaterOptions{
+		Interval:          6 * time.Hour,
+		CheckBeforeUpdate: true,
+	})
 
 	// Start service
 	ctx := context.Background()
@@ -159,6 +169,16 @@ scheduled updates, retry logic, and scan coordination:
 	// Manually trigger an update
 	svc.TriggerUpdate(ctx, "clamav")
 
+Set Publisher (a *nats.Conn works) to publish a JSON StatusMessage with the
+updater name, version, and outcome each time an update completes or fails,
+so a central consumer can track database freshness across nodes:
+
+	svc := dbupdater.NewDBUpdateService(dbupdater.DBUpdateServiceConfig{
+		Publisher:     natsConn,
+		StatusSubject: dbupdater.DefaultStatusSubject,
+		NodeName:      hostname,
+	})
+
 # Thread Safety
 
 All components in this package are thread-safe and can be used from
@@ -0,0 +1,102 @@
+// ABOUTME: Publishes updater completion and failure events as JSON messages
+// ABOUTME: Gives operators a central view of DB freshness across argus nodes
+
+package dbupdater
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+// DefaultStatusSubject is the subject status messages are published to
+// when DBUpdateServiceConfig.StatusSubject is empty.
+const DefaultStatusSubject = "hikmaai.argus.dbupdate.status"
+
+// MessagePublisher publishes a message to a subject. *nats.Conn satisfies it.
+type MessagePublisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// StatusMessage is the JSON payload published when an update completes or
+// fails.
+type StatusMessage struct {
+	// Node identifies the argus instance that ran the update.
+	Node string `json:"node,omitempty"`
+
+	// Updater is the updater name, e.g. "clamav".
+	Updater string `json:"updater"`
+
+	// Version is the database version after the update.
+	Version int `json:"version"`
+
+	// Success reports whether the update completed.
+	Success bool `json:"success"`
+
+	// Error is the last error message for a failed update.
+	Error string `json:"error,omitempty"`
+
+	// Timestamp is when the update finished.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// statusMessage builds the message for event. It reports false for events
+// that are not an update completion or failure.
+func statusMessage(node string, event StatusEvent) (StatusMessage, bool) {
+	if event.Kind != EventStatus {
+		return StatusMessage{}, false
+	}
+
+	status := event.Status
+	switch status.Status {
+	case StatusIdle, StatusFailed:
+	default:
+		return StatusMessage{}, false
+	}
+
+	return StatusMessage{
+		Node:      node,
+		Updater:   status.Name,
+		Version:   status.Version.Version,
+		Success:   status.Status == StatusIdle,
+		Error:     status.LastError,
+		Timestamp: time.Now().UTC(),
+	}, true
+}
+
+// publishStatus publishes a StatusMessage for each completed or failed
+// update until ctx is done.
+func (s *DBUpdateService) publishStatus(ctx context.Context, events <-chan StatusEvent, unsubscribe func()) {
+	defer s.wg.Done()
+	defer unsubscribe()
+
+	subject := s.config.StatusSubject
+	if subject == "" {
+		subject = DefaultStatusSubject
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			msg, ok := statusMessage(s.config.NodeName, event)
+			if !ok {
+				continue
+			}
+
+			data, err := json.Marshal(msg)
+			if err != nil {
+				continue
+			}
+			if err := s.config.Publisher.Publish(subject, data); err != nil {
+				s.config.Logger.Warn("failed to publish update status",
+					slog.String("updater", msg.Updater),
+					slog.String("subject", subject),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
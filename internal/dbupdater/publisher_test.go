@@ -0,0 +1,171 @@
+// ABOUTME: Tests for publishing DB update status messages
+// ABOUTME: Uses an embedded NATS server to verify completions and failures are sent
+
+package dbupdater
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+)
+
+func TestStatusMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		event       StatusEvent
+		wantOK      bool
+		wantSuccess bool
+	}{
+		{
+			name:        "update completed",
+			event:       StatusEvent{Kind: EventStatus, Status: UpdaterStatus{Name: "clamav", Status: StatusIdle}},
+			wantOK:      true,
+			wantSuccess: true,
+		},
+		{
+			name:   "update failed",
+			event:  StatusEvent{Kind: EventStatus, Status: UpdaterStatus{Name: "clamav", Status: StatusFailed, LastError: "boom"}},
+			wantOK: true,
+		},
+		{
+			name:  "update started",
+			event: StatusEvent{Kind: EventStatus, Status: UpdaterStatus{Name: "clamav", Status: StatusUpdating}},
+		},
+		{
+			name:  "update skipped",
+			event: StatusEvent{Kind: EventStatus, Status: UpdaterStatus{Name: "clamav", Status: StatusSkipped}},
+		},
+		{
+			name:  "version change",
+			event: StatusEvent{Kind: EventVersion, Status: UpdaterStatus{Name: "clamav", Status: StatusIdle}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			msg, ok := statusMessage("node-1", tt.event)
+			if ok != tt.wantOK {
+				t.Fatalf("statusMessage() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if msg.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v", msg.Success, tt.wantSuccess)
+			}
+			if msg.Node != "node-1" || msg.Updater != "clamav" {
+				t.Errorf("message = %+v, want node-1/clamav", msg)
+			}
+			if msg.Error != tt.event.Status.LastError {
+				t.Errorf("Error = %q, want %q", msg.Error, tt.event.Status.LastError)
+			}
+		})
+	}
+}
+
+func TestDBUpdateService_PublishesStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		shouldFail  bool
+		wantSuccess bool
+		wantError   string
+	}{
+		{name: "completion", wantSuccess: true},
+		{name: "failure", shouldFail: true, wantError: "mock failure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := natsserver.DefaultTestOptions
+			opts.Port = -1
+			srv := natsserver.RunServer(&opts)
+			t.Cleanup(srv.Shutdown)
+
+			publisher, err := nats.Connect(srv.ClientURL())
+			if err != nil {
+				t.Fatalf("nats.Connect() error = %v", err)
+			}
+			t.Cleanup(publisher.Close)
+
+			listener, err := nats.Connect(srv.ClientURL())
+			if err != nil {
+				t.Fatalf("nats.Connect() error = %v", err)
+			}
+			t.Cleanup(listener.Close)
+
+			sub, err := listener.SubscribeSync("test.dbupdate.status")
+			if err != nil {
+				t.Fatalf("SubscribeSync() error = %v", err)
+			}
+			if err := listener.Flush(); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+
+			svc := NewDBUpdateService(DBUpdateServiceConfig{
+				Coordinator: NewScanCoordinator(),
+				RetryConfig: BackoffConfig{
+					MaxRetries:   1,
+					InitialDelay: time.Millisecond,
+					MaxDelay:     time.Millisecond,
+				},
+				Publisher:     publisher,
+				StatusSubject: "test.dbupdate.status",
+				NodeName:      "node-1",
+			})
+
+			mock := newMockUpdater("clamav")
+			mock.shouldFail = tt.shouldFail
+			mock.versionInfo = VersionInfo{Version: 27100}
+			svc.RegisterUpdater(mock, time.Hour)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			if err := svc.Start(ctx); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			defer svc.Stop()
+
+			if err := svc.TriggerUpdate(ctx, "clamav"); err != nil {
+				t.Fatalf("TriggerUpdate() error = %v", err)
+			}
+
+			raw, err := sub.NextMsg(5 * time.Second)
+			if err != nil {
+				t.Fatalf("no status message published: %v", err)
+			}
+
+			var msg StatusMessage
+			if err := json.Unmarshal(raw.Data, &msg); err != nil {
+				t.Fatalf("decoding status message: %v", err)
+			}
+			if msg.Node != "node-1" || msg.Updater != "clamav" {
+				t.Errorf("message = %+v, want node-1/clamav", msg)
+			}
+			if msg.Version != 27100 {
+				t.Errorf("Version = %d, want 27100", msg.Version)
+			}
+			if msg.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v", msg.Success, tt.wantSuccess)
+			}
+			if msg.Error != tt.wantError {
+				t.Errorf("Error = %q, want %q", msg.Error, tt.wantError)
+			}
+			if msg.Timestamp.IsZero() {
+				t.Error("Timestamp is zero")
+			}
+		})
+	}
+}
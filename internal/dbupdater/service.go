@@ -5,10 +5,17 @@ package dbupdater
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"path/filepath"
 	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/observability"
 )
 
 // DBUpdateServiceConfig configures the DB update service.
@@ -22,15 +29,45 @@ type DBUpdateServiceConfig struct {
 	// RetryConfig configures retry behavior for failed updates.
 	RetryConfig BackoffConfig
 
-	// RunInitialUpdate triggers an update immediately on Start.
+	// RunInitialUpdate triggers an update immediately on Start, unless the
+	// persisted state shows the updater ran within its interval.
 	RunInitialUpdate bool
+
+	// StateDir holds a JSON file recording each updater's last successful
+	// update, so schedules survive restarts. Empty disables persistence.
+	StateDir string
+
+	// Publisher, when set, receives a JSON StatusMessage each time an
+	// update completes or fails.
+	Publisher MessagePublisher
+
+	// StatusSubject is the subject status messages are published to.
+	// Defaults to DefaultStatusSubject.
+	StatusSubject string
+
+	// NodeName identifies this instance in status messages.
+	NodeName string
+}
+
+// UpdaterOptions configures how the service schedules a registered updater.
+type UpdaterOptions struct {
+	// Interval is the time between scheduled updates.
+	Interval time.Duration
+
+	// CheckBeforeUpdate calls CheckForUpdates before each scheduled update
+	// and skips the update when no new data is available. Manual triggers
+	// always update.
+	CheckBeforeUpdate bool
 }
 
 // updaterEntry holds an updater and its configuration.
 type updaterEntry struct {
-	updater  Updater
-	interval time.Duration
-	trigger  chan struct{}
+	updater Updater
+	opts    UpdaterOptions
+	trigger chan struct{}
+
+	// reschedule wakes the worker after opts.Interval changes.
+	reschedule chan struct{}
 }
 
 // DBUpdateService orchestrates database updates for all registered updaters.
@@ -43,6 +80,9 @@ type DBUpdateService struct {
 	running bool
 	cancel  context.CancelFunc
 	wg      sync.WaitGroup
+
+	// stateMu serializes writes to the state file.
+	stateMu sync.Mutex
 }
 
 // NewDBUpdateService creates a new DB update service.
@@ -61,16 +101,22 @@ func NewDBUpdateService(config DBUpdateServiceConfig) *DBUpdateService {
 	}
 }
 
-// RegisterUpdater registers an updater with the service.
+// RegisterUpdater registers an updater that runs every interval.
 func (s *DBUpdateService) RegisterUpdater(updater Updater, interval time.Duration) {
+	s.RegisterUpdaterWithOptions(updater, UpdaterOptions{Interval: interval})
+}
+
+// RegisterUpdaterWithOptions registers an updater with the service.
+func (s *DBUpdateService) RegisterUpdaterWithOptions(updater Updater, opts UpdaterOptions) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	name := updater.Name()
 	s.updaters[name] = &updaterEntry{
-		updater:  updater,
-		interval: interval,
-		trigger:  make(chan struct{}, 1),
+		updater:    updater,
+		opts:       opts,
+		trigger:    make(chan struct{}, 1),
+		reschedule: make(chan struct{}, 1),
 	}
 	s.status.Register(name)
 
@@ -92,6 +138,14 @@ func (s *DBUpdateService) Start(ctx context.Context) error {
 	s.running = true
 	s.mu.Unlock()
 
+	s.restoreState()
+
+	if s.config.Publisher != nil {
+		events, unsubscribe := s.status.Subscribe()
+		s.wg.Add(1)
+		go s.publishStatus(ctx, events, unsubscribe)
+	}
+
 	// Start worker goroutines for each updater.
 	for name, entry := range s.updaters {
 		s.wg.Add(1)
@@ -149,11 +203,43 @@ func (s *DBUpdateService) TriggerUpdate(ctx context.Context, name string) error
 	}
 }
 
+// Reschedule changes how often an updater runs. When the service is
+// running, the next scheduled update moves to one new interval from now.
+func (s *DBUpdateService) Reschedule(name string, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid interval %v for updater %q: must be positive", interval, name)
+	}
+
+	s.mu.Lock()
+	entry, ok := s.updaters[name]
+	if ok {
+		entry.opts.Interval = interval
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("updater %q not found", name)
+	}
+
+	// Non-blocking send; the worker reads the latest interval.
+	select {
+	case entry.reschedule <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
 // GetStatus returns the status of all updaters.
 func (s *DBUpdateService) GetStatus() map[string]*UpdaterStatus {
 	return s.status.GetAll()
 }
 
+// SubscribeStatus returns a channel of updater status changes and a function
+// that unsubscribes. See StatusTracker.Subscribe.
+func (s *DBUpdateService) SubscribeStatus() (<-chan StatusEvent, func()) {
+	return s.status.Subscribe()
+}
+
 // Coordinator returns the scan coordinator.
 func (s *DBUpdateService) Coordinator() *ScanCoordinator {
 	return s.config.Coordinator
@@ -163,42 +249,98 @@ func (s *DBUpdateService) Coordinator() *ScanCoordinator {
 func (s *DBUpdateService) runUpdaterWorker(ctx context.Context, name string, entry *updaterEntry) {
 	defer s.wg.Done()
 
-	ticker := time.NewTicker(entry.interval)
-	defer ticker.Stop()
-
+	s.mu.Lock()
+	interval := entry.opts.Interval
+	s.mu.Unlock()
 	logger := s.config.Logger.With(slog.String("updater", name))
 
+	// Schedule from the persisted last update when there is one, so a
+	// restart does not re-run an update that just completed. The initial
+	// update still runs if the data on disk is missing or unusable.
+	firstDelay := interval
+	runNow := s.config.RunInitialUpdate
+	if status := s.status.Get(name); status != nil && !status.LastUpdate.IsZero() {
+		if until := time.Until(status.LastUpdate.Add(interval)); until > 0 {
+			firstDelay = until
+			runNow = runNow && !entry.updater.IsReady()
+		} else {
+			runNow = true
+		}
+	}
+
 	// Schedule next update.
-	s.status.SetNextScheduled(name, time.Now().Add(entry.interval))
+	s.status.SetNextScheduled(name, time.Now().Add(firstDelay))
 
-	// Run initial update if configured.
-	if s.config.RunInitialUpdate {
-		s.executeUpdate(ctx, name, entry, logger)
+	// Run initial update if configured or overdue.
+	if runNow {
+		s.executeScheduledUpdate(ctx, name, entry, logger)
 	}
 
+	timer := time.NewTimer(firstDelay)
+	defer timer.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			logger.Debug("updater worker stopped")
 			return
 
-		case <-ticker.C:
-			s.executeUpdate(ctx, name, entry, logger)
-			s.status.SetNextScheduled(name, time.Now().Add(entry.interval))
+		case <-timer.C:
+			s.executeScheduledUpdate(ctx, name, entry, logger)
+			timer.Reset(interval)
+			s.status.SetNextScheduled(name, time.Now().Add(interval))
 
 		case <-entry.trigger:
 			logger.Info("manual update triggered")
 			s.executeUpdate(ctx, name, entry, logger)
+
+		case <-entry.reschedule:
+			s.mu.Lock()
+			interval = entry.opts.Interval
+			s.mu.Unlock()
+			timer.Reset(interval)
+			s.status.SetNextScheduled(name, time.Now().Add(interval))
+			logger.Info("update interval changed", slog.Duration("interval", interval))
 		}
 	}
 }
 
+// executeScheduledUpdate runs a scheduled update, first checking for new
+// data when the updater is registered with CheckBeforeUpdate.
+func (s *DBUpdateService) executeScheduledUpdate(ctx context.Context, name string, entry *updaterEntry, logger *slog.Logger) {
+	if entry.opts.CheckBeforeUpdate {
+		check, err := entry.updater.CheckForUpdates(ctx)
+		s.status.SetLastCheck(name, time.Now())
+
+		switch {
+		case err != nil:
+			// Fall through to the update rather than miss new data; if
+			// the sources are unreachable, the update fails and records
+			// that instead of a skip.
+			logger.Warn("update check failed, updating anyway", slog.String("error", err.Error()))
+		case !check.NeedsUpdate():
+			s.status.SetStatus(name, StatusSkipped)
+			logger.Debug("no update available, skipping",
+				slog.Int("current_version", check.CurrentVersion),
+			)
+			return
+		}
+	}
+
+	s.executeUpdate(ctx, name, entry, logger)
+}
+
 // executeUpdate performs the update with retry logic.
 func (s *DBUpdateService) executeUpdate(ctx context.Context, name string, entry *updaterEntry, logger *slog.Logger) {
+	ctx, span := observability.StartSpan(ctx, "dbupdate.run",
+		trace.WithAttributes(attribute.String("updater.name", name)))
+	defer span.End()
+
 	// Acquire update lock.
 	release, err := s.config.Coordinator.AcquireForUpdate(ctx)
 	if err != nil {
 		logger.Warn("failed to acquire update lock", slog.String("error", err.Error()))
+		observability.RecordSpanError(span, err)
 		return
 	}
 	defer release()
@@ -212,16 +354,23 @@ func (s *DBUpdateService) executeUpdate(ctx context.Context, name string, entry
 		result, err := entry.updater.Update(ctx)
 
 		if err == nil && result.Success {
-			// Success.
-			s.status.SetStatus(name, StatusIdle)
+			// Success. The status is set last so its event carries the
+			// new version.
 			s.status.SetLastUpdate(name, time.Now())
 			s.status.SetError(name, "")
 			s.status.SetVersion(name, entry.updater.GetVersionInfo())
 			s.status.SetReady(name, entry.updater.IsReady())
+			s.status.SetStatus(name, StatusIdle)
+			s.persistState(logger)
 
+			span.SetAttributes(
+				attribute.Int("update.attempts", backoff.Attempts()+1),
+				attribute.Int("update.downloaded", result.Downloaded),
+			)
 			logger.Info("update completed",
 				slog.Int("downloaded", result.Downloaded),
 				slog.Int("skipped", result.Skipped),
+				slog.Int("deduplicated", result.Deduplicated),
 				slog.Duration("duration", result.Duration),
 			)
 			return
@@ -241,24 +390,86 @@ func (s *DBUpdateService) executeUpdate(ctx context.Context, name string, entry
 			slog.Int("attempt", backoff.Attempts()+1),
 		)
 
-		// Get next retry delay.
-		delay, ok := backoff.NextDelay()
-		if !ok {
-			// Max retries exceeded.
+		// Wait before retry.
+		if err := backoff.Wait(ctx); err != nil {
 			s.status.SetStatus(name, StatusFailed)
-			logger.Error("update failed after max retries",
-				slog.Int("attempts", backoff.Attempts()),
-			)
+			span.SetAttributes(attribute.Int("update.attempts", backoff.Attempts()))
+			observability.RecordSpanError(span, fmt.Errorf("update failed: %s", errMsg))
+			if errors.Is(err, ErrMaxRetriesExceeded) {
+				logger.Error("update failed after max retries",
+					slog.Int("attempts", backoff.Attempts()),
+				)
+			}
 			return
 		}
+		logger.Debug("retrying update", slog.Int("attempt", backoff.Attempts()+1))
+	}
+}
 
-		// Wait before retry.
-		select {
-		case <-ctx.Done():
-			s.status.SetStatus(name, StatusFailed)
-			return
-		case <-time.After(delay):
-			logger.Debug("retrying update", slog.Duration("delay", delay))
+// statePath returns the state file path, or "" if persistence is disabled.
+func (s *DBUpdateService) statePath() string {
+	if s.config.StateDir == "" {
+		return ""
+	}
+	return filepath.Join(s.config.StateDir, stateFileName)
+}
+
+// restoreState loads persisted last-update times into the status tracker.
+func (s *DBUpdateService) restoreState() {
+	path := s.statePath()
+	if path == "" {
+		return
+	}
+
+	state, err := loadState(path)
+	if err != nil {
+		s.config.Logger.Warn("ignoring db update state", slog.String("error", err.Error()))
+		return
+	}
+
+	for name, entry := range s.updaters {
+		st, ok := state[name]
+		if !ok || st.LastUpdate.IsZero() {
+			continue
+		}
+		s.status.SetLastUpdate(name, st.LastUpdate)
+
+		// Prefer the updater's own version info; fall back to the persisted one.
+		if entry.updater.GetVersionInfo().Version == 0 {
+			s.status.SetVersion(name, VersionInfo{
+				Version:   st.Version,
+				BuildTime: st.BuildTime,
+				DBFiles:   st.DBFiles,
+			})
 		}
 	}
 }
+
+// persistState writes every updater's last update time and version to the
+// state file.
+func (s *DBUpdateService) persistState(logger *slog.Logger) {
+	path := s.statePath()
+	if path == "" {
+		return
+	}
+
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	state := make(map[string]updaterState)
+	for name, status := range s.status.GetAll() {
+		if status.LastUpdate.IsZero() {
+			continue
+		}
+		state[name] = updaterState{
+			LastUpdate: status.LastUpdate,
+			Version:    status.Version.Version,
+			BuildTime:  status.Version.BuildTime,
+			DBFiles:    status.Version.DBFiles,
+		}
+	}
+
+	if err := saveState(path, state); err != nil {
+		logger.Warn("failed to persist db update state", slog.String("error", err.Error()))
+	}
+}
@@ -6,6 +6,7 @@ package dbupdater
 import (
 	"context"
 	"errors"
+	"path/filepath"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -17,6 +18,8 @@ type mockUpdater struct {
 	updateCount    atomic.Int32
 	checkCount     atomic.Int32
 	shouldFail     bool
+	checkFails     bool
+	upToDate       bool
 	updateDelay    time.Duration
 	ready          bool
 	versionInfo    VersionInfo
@@ -64,12 +67,12 @@ func (m *mockUpdater) Update(ctx context.Context) (*UpdateResult, error) {
 func (m *mockUpdater) CheckForUpdates(ctx context.Context) (*CheckResult, error) {
 	m.checkCount.Add(1)
 
-	if m.shouldFail {
+	if m.shouldFail || m.checkFails {
 		return nil, errors.New("mock check failure")
 	}
 
 	return &CheckResult{
-		UpdateAvailable: true,
+		UpdateAvailable: !m.upToDate,
 	}, nil
 }
 
@@ -231,6 +234,304 @@ func TestDBUpdateService_TriggerUpdate(t *testing.T) {
 	}
 }
 
+func TestDBUpdateService_CheckBeforeUpdate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		upToDate    bool
+		checkFails  bool
+		wantUpdates bool
+		wantStatus  Status
+	}{
+		{name: "update available", upToDate: false, wantUpdates: true, wantStatus: StatusIdle},
+		{name: "up to date", upToDate: true, wantUpdates: false, wantStatus: StatusSkipped},
+		{name: "check fails", upToDate: true, checkFails: true, wantUpdates: true, wantStatus: StatusIdle},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := NewDBUpdateService(DBUpdateServiceConfig{
+				Coordinator: NewScanCoordinator(),
+			})
+
+			mock := newMockUpdater("test")
+			mock.upToDate = tt.upToDate
+			mock.checkFails = tt.checkFails
+			svc.RegisterUpdaterWithOptions(mock, UpdaterOptions{
+				Interval:          20 * time.Millisecond,
+				CheckBeforeUpdate: true,
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			if err := svc.Start(ctx); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			// Wait for a few periodic ticks.
+			time.Sleep(100 * time.Millisecond)
+			svc.Stop()
+
+			if mock.checkCount.Load() < 2 {
+				t.Errorf("CheckForUpdates called %d times, want at least 2", mock.checkCount.Load())
+			}
+			if got := mock.updateCount.Load() > 0; got != tt.wantUpdates {
+				t.Errorf("Update called %d times, want updates = %v", mock.updateCount.Load(), tt.wantUpdates)
+			}
+
+			status := svc.GetStatus()["test"]
+			if status.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", status.Status, tt.wantStatus)
+			}
+			if status.LastCheck.IsZero() {
+				t.Error("LastCheck not recorded")
+			}
+		})
+	}
+}
+
+func TestDBUpdateService_CheckBeforeUpdate_TriggerBypassesCheck(t *testing.T) {
+	t.Parallel()
+
+	svc := NewDBUpdateService(DBUpdateServiceConfig{
+		Coordinator: NewScanCoordinator(),
+	})
+
+	mock := newMockUpdater("test")
+	mock.upToDate = true
+	svc.RegisterUpdaterWithOptions(mock, UpdaterOptions{
+		Interval:          1 * time.Hour,
+		CheckBeforeUpdate: true,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := svc.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if err := svc.TriggerUpdate(ctx, "test"); err != nil {
+		t.Errorf("TriggerUpdate() error = %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	svc.Stop()
+
+	if mock.updateCount.Load() != 1 {
+		t.Errorf("Update called %d times, want 1", mock.updateCount.Load())
+	}
+	if mock.checkCount.Load() != 0 {
+		t.Errorf("CheckForUpdates called %d times on manual trigger, want 0", mock.checkCount.Load())
+	}
+}
+
+func TestDBUpdateService_PersistedState_Schedule(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		lastUpdate  time.Duration
+		wantUpdates bool
+	}{
+		{name: "recent update waits for interval", lastUpdate: 5 * time.Minute, wantUpdates: false},
+		{name: "overdue update runs immediately", lastUpdate: 2 * time.Hour, wantUpdates: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			lastUpdate := time.Now().Add(-tt.lastUpdate).Truncate(time.Second)
+			err := saveState(filepath.Join(dir, stateFileName), map[string]updaterState{
+				"test": {LastUpdate: lastUpdate, Version: 42},
+			})
+			if err != nil {
+				t.Fatalf("saveState() error = %v", err)
+			}
+
+			svc := NewDBUpdateService(DBUpdateServiceConfig{
+				Coordinator:      NewScanCoordinator(),
+				RunInitialUpdate: true,
+				StateDir:         dir,
+			})
+			mock := newMockUpdater("test")
+			svc.RegisterUpdater(mock, 1*time.Hour)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			if err := svc.Start(ctx); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			time.Sleep(100 * time.Millisecond)
+			svc.Stop()
+
+			if got := mock.updateCount.Load() > 0; got != tt.wantUpdates {
+				t.Errorf("Update called %d times, want updates = %v", mock.updateCount.Load(), tt.wantUpdates)
+			}
+
+			status := svc.GetStatus()["test"]
+			if tt.wantUpdates {
+				return
+			}
+			if !status.LastUpdate.Equal(lastUpdate) {
+				t.Errorf("LastUpdate = %v, want %v", status.LastUpdate, lastUpdate)
+			}
+			if status.Version.Version != 42 {
+				t.Errorf("Version = %d, want 42 from state file", status.Version.Version)
+			}
+			wantNext := lastUpdate.Add(1 * time.Hour)
+			if diff := status.NextScheduled.Sub(wantNext).Abs(); diff > time.Second {
+				t.Errorf("NextScheduled = %v, want %v", status.NextScheduled, wantNext)
+			}
+		})
+	}
+}
+
+func TestDBUpdateService_PersistedState_SurvivesRestart(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	newService := func(mock *mockUpdater) *DBUpdateService {
+		svc := NewDBUpdateService(DBUpdateServiceConfig{
+			Coordinator:      NewScanCoordinator(),
+			RunInitialUpdate: true,
+			StateDir:         dir,
+		})
+		svc.RegisterUpdater(mock, 1*time.Hour)
+		return svc
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// First run performs the initial update and records it.
+	first := newMockUpdater("test")
+	first.versionInfo = VersionInfo{Version: 7}
+	svc := newService(first)
+	if err := svc.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	svc.Stop()
+
+	if first.updateCount.Load() != 1 {
+		t.Fatalf("first run: Update called %d times, want 1", first.updateCount.Load())
+	}
+	lastUpdate := svc.GetStatus()["test"].LastUpdate
+
+	// After a restart the recent update is remembered and not repeated.
+	second := newMockUpdater("test")
+	svc = newService(second)
+	if err := svc.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	svc.Stop()
+
+	if second.updateCount.Load() != 0 {
+		t.Errorf("after restart: Update called %d times, want 0", second.updateCount.Load())
+	}
+	status := svc.GetStatus()["test"]
+	if !status.LastUpdate.Equal(lastUpdate) {
+		t.Errorf("after restart: LastUpdate = %v, want %v", status.LastUpdate, lastUpdate)
+	}
+	if status.Version.Version != 7 {
+		t.Errorf("after restart: Version = %d, want 7", status.Version.Version)
+	}
+
+	// A recent update does not help if its data has since gone missing.
+	third := newMockUpdater("test")
+	third.ready = false
+	svc = newService(third)
+	if err := svc.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	svc.Stop()
+
+	if third.updateCount.Load() != 1 {
+		t.Errorf("after restart without data: Update called %d times, want 1", third.updateCount.Load())
+	}
+}
+
+func TestDBUpdateService_Reschedule(t *testing.T) {
+	t.Parallel()
+
+	svc := NewDBUpdateService(DBUpdateServiceConfig{
+		Coordinator: NewScanCoordinator(),
+	})
+
+	mock := newMockUpdater("test")
+	svc.RegisterUpdater(mock, 1*time.Hour) // Long interval.
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := svc.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer svc.Stop()
+
+	// No update is due within the original interval.
+	time.Sleep(50 * time.Millisecond)
+	if got := mock.updateCount.Load(); got != 0 {
+		t.Fatalf("updates before reschedule = %d, want 0", got)
+	}
+
+	before := time.Now()
+	if err := svc.Reschedule("test", 30*time.Millisecond); err != nil {
+		t.Fatalf("Reschedule() error = %v", err)
+	}
+
+	// The shorter interval takes effect without a restart.
+	deadline := time.Now().Add(2 * time.Second)
+	for mock.updateCount.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("updates after reschedule = %d, want at least 2", mock.updateCount.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	next := svc.GetStatus()["test"].NextScheduled
+	if next.Before(before) || next.After(time.Now().Add(time.Second)) {
+		t.Errorf("NextScheduled = %v, want within the new interval", next)
+	}
+
+	// Lengthening the interval stops the frequent updates.
+	if err := svc.Reschedule("test", time.Hour); err != nil {
+		t.Fatalf("Reschedule() error = %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	settled := mock.updateCount.Load()
+	time.Sleep(100 * time.Millisecond)
+	if got := mock.updateCount.Load(); got != settled {
+		t.Errorf("updates after lengthening interval = %d, want %d", got, settled)
+	}
+}
+
+func TestDBUpdateService_Reschedule_Errors(t *testing.T) {
+	t.Parallel()
+
+	svc := NewDBUpdateService(DBUpdateServiceConfig{
+		Coordinator: NewScanCoordinator(),
+	})
+	svc.RegisterUpdater(newMockUpdater("test"), time.Hour)
+
+	if err := svc.Reschedule("nonexistent", time.Minute); err == nil {
+		t.Error("Reschedule() should error for nonexistent updater")
+	}
+	if err := svc.Reschedule("test", 0); err == nil {
+		t.Error("Reschedule() should error for a zero interval")
+	}
+}
+
 func TestDBUpdateService_TriggerUpdate_NotFound(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,164 @@
+// ABOUTME: In-memory deduplication of signatures collected from several feeds
+// ABOUTME: Merges records sharing a hash, keeping the most severe and descriptive one
+
+package dbupdater
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/types"
+)
+
+// signatureDeduper merges signatures that share any hash. Hashes are checked
+// strongest first (SHA256, SHA1, MD5), so a record with only an MD5 still
+// merges into a richer record for the same file.
+type signatureDeduper struct {
+	// index maps "<type>:<value>" for every known hash to its record.
+	index map[string]*types.Signature
+
+	// order holds the unique records in insertion order.
+	order []*types.Signature
+
+	// keepSources combines records with types.Signature.Merge, keeping
+	// every source's contribution, instead of keeping the preferred one.
+	keepSources bool
+}
+
+// newSignatureDeduper creates an empty deduper. With keepSources, merged
+// records list all their sources, so a source can later be pruned alone.
+func newSignatureDeduper(keepSources bool) *signatureDeduper {
+	return &signatureDeduper{index: make(map[string]*types.Signature), keepSources: keepSources}
+}
+
+// Add stores sig, merging it into an existing record that shares a hash.
+// Reports whether sig was a duplicate.
+func (d *signatureDeduper) Add(sig *types.Signature) bool {
+	if sig == nil {
+		return false
+	}
+	if d.Merge(sig) {
+		return true
+	}
+
+	cp := *sig
+	d.order = append(d.order, &cp)
+	d.indexHashes(&cp)
+	return false
+}
+
+// Merge merges sig into an existing record that shares a hash, without
+// storing sig otherwise. Reports whether a match was found.
+func (d *signatureDeduper) Merge(sig *types.Signature) bool {
+	if sig == nil {
+		return false
+	}
+
+	existing := d.find(sig)
+	if existing == nil {
+		return false
+	}
+
+	if d.keepSources {
+		*existing = *existing.Merge(sig)
+	} else {
+		mergeSignature(existing, sig)
+	}
+	d.indexHashes(existing)
+	return true
+}
+
+// Len returns the number of unique records.
+func (d *signatureDeduper) Len() int {
+	return len(d.order)
+}
+
+// Signatures returns the unique records in insertion order.
+func (d *signatureDeduper) Signatures() []*types.Signature {
+	return d.order
+}
+
+// Reset removes all records.
+func (d *signatureDeduper) Reset() {
+	clear(d.index)
+	d.order = d.order[:0]
+}
+
+func (d *signatureDeduper) find(sig *types.Signature) *types.Signature {
+	for _, hash := range sig.GetHashes() {
+		if existing, ok := d.index[dedupeKey(hash)]; ok {
+			return existing
+		}
+	}
+	return nil
+}
+
+func (d *signatureDeduper) indexHashes(sig *types.Signature) {
+	for _, hash := range sig.GetHashes() {
+		d.index[dedupeKey(hash)] = sig
+	}
+}
+
+func dedupeKey(hash types.Hash) string {
+	return hash.Type.String() + ":" + strings.ToLower(hash.Value)
+}
+
+// mergeSignature folds other into dst. dst takes other's detection fields if
+// other is more severe or has a more descriptive detection name; missing
+// hashes, tags, references, and the seen window are combined either way.
+func mergeSignature(dst, other *types.Signature) {
+	if preferSignature(other, dst) {
+		dst.DetectionName = other.DetectionName
+		dst.ThreatType = other.ThreatType
+		dst.Severity = other.Severity
+		dst.Source = other.Source
+		if other.Description != "" {
+			dst.Description = other.Description
+		}
+	}
+
+	if dst.SHA256 == "" {
+		dst.SHA256 = other.SHA256
+	}
+	if dst.SHA1 == "" {
+		dst.SHA1 = other.SHA1
+	}
+	if dst.MD5 == "" {
+		dst.MD5 = other.MD5
+	}
+	if dst.Description == "" {
+		dst.Description = other.Description
+	}
+	if dst.ThreatType == types.ThreatTypeUnknown {
+		dst.ThreatType = other.ThreatType
+	}
+
+	if !other.FirstSeen.IsZero() && (dst.FirstSeen.IsZero() || other.FirstSeen.Before(dst.FirstSeen)) {
+		dst.FirstSeen = other.FirstSeen
+	}
+	if other.LastSeen.After(dst.LastSeen) {
+		dst.LastSeen = other.LastSeen
+	}
+
+	dst.Tags = appendMissing(dst.Tags, other.Tags)
+	dst.References = appendMissing(dst.References, other.References)
+}
+
+// preferSignature reports whether a should replace b's detection fields:
+// higher severity wins, then the longer (more specific) detection name.
+func preferSignature(a, b *types.Signature) bool {
+	if a.Severity != b.Severity {
+		return a.Severity > b.Severity
+	}
+	return len(a.DetectionName) > len(b.DetectionName)
+}
+
+// appendMissing appends the values of src that dst does not contain.
+func appendMissing(dst, src []string) []string {
+	for _, v := range src {
+		if !slices.Contains(dst, v) {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,128 @@
+// ABOUTME: Tests for cross-feed signature deduplication
+// ABOUTME: Validates hash matching, merge preference, and metadata combination
+
+package dbupdater
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/types"
+)
+
+func TestSignatureDeduper_Add(t *testing.T) {
+	t.Parallel()
+
+	sha256 := "aa11223344556677889900aabbccddeeff00112233445566778899aabbccddee"
+	tests := []struct {
+		name      string
+		sigs      []*types.Signature
+		wantLen   int
+		wantDups  int
+		wantName  string
+		wantSev   types.Severity
+		wantMD5   string
+		wantTags  []string
+		wantFirst time.Time
+	}{
+		{
+			name: "distinct hashes",
+			sigs: []*types.Signature{
+				{SHA256: sha256, DetectionName: "A"},
+				{SHA256: "bb" + sha256[2:], DetectionName: "B"},
+			},
+			wantLen:  2,
+			wantName: "A",
+		},
+		{
+			name: "higher severity wins",
+			sigs: []*types.Signature{
+				{SHA256: sha256, DetectionName: "Win.Trojan.Agent-12345", Severity: types.SeverityMedium, Source: "threatfox"},
+				{SHA256: sha256, DetectionName: "Emotet", Severity: types.SeverityCritical, Source: "malwarebazaar"},
+			},
+			wantLen:  1,
+			wantDups: 1,
+			wantName: "Emotet",
+			wantSev:  types.SeverityCritical,
+		},
+		{
+			name: "richer name wins on equal severity",
+			sigs: []*types.Signature{
+				{SHA256: sha256, DetectionName: "Malware", Severity: types.SeverityHigh},
+				{SHA256: sha256, DetectionName: "Win.Ransomware.LockBit", Severity: types.SeverityHigh},
+			},
+			wantLen:  1,
+			wantDups: 1,
+			wantName: "Win.Ransomware.LockBit",
+			wantSev:  types.SeverityHigh,
+		},
+		{
+			name: "matches on weaker hash and fills missing hashes",
+			sigs: []*types.Signature{
+				{SHA256: sha256, DetectionName: "Emotet", Tags: []string{"emotet"}, FirstSeen: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+				{SHA256: sha256, MD5: "D41D8CD98F00B204E9800998ECF8427E", Tags: []string{"emotet", "epoch5"}, FirstSeen: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+				{MD5: "d41d8cd98f00b204e9800998ecf8427e", DetectionName: "E"},
+			},
+			wantLen:   1,
+			wantDups:  2,
+			wantName:  "Emotet",
+			wantMD5:   "D41D8CD98F00B204E9800998ECF8427E",
+			wantTags:  []string{"emotet", "epoch5"},
+			wantFirst: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			d := newSignatureDeduper(false)
+			dups := 0
+			for _, sig := range tt.sigs {
+				if d.Add(sig) {
+					dups++
+				}
+			}
+
+			if d.Len() != tt.wantLen {
+				t.Fatalf("Len() = %d, want %d", d.Len(), tt.wantLen)
+			}
+			if dups != tt.wantDups {
+				t.Errorf("duplicates = %d, want %d", dups, tt.wantDups)
+			}
+
+			got := d.Signatures()[0]
+			if got.DetectionName != tt.wantName {
+				t.Errorf("DetectionName = %q, want %q", got.DetectionName, tt.wantName)
+			}
+			if got.Severity != tt.wantSev {
+				t.Errorf("Severity = %v, want %v", got.Severity, tt.wantSev)
+			}
+			if got.MD5 != tt.wantMD5 {
+				t.Errorf("MD5 = %q, want %q", got.MD5, tt.wantMD5)
+			}
+			if tt.wantTags != nil && !slices.Equal(got.Tags, tt.wantTags) {
+				t.Errorf("Tags = %v, want %v", got.Tags, tt.wantTags)
+			}
+			if !got.FirstSeen.Equal(tt.wantFirst) {
+				t.Errorf("FirstSeen = %v, want %v", got.FirstSeen, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestSignatureDeduper_DoesNotModifyInput(t *testing.T) {
+	t.Parallel()
+
+	first := &types.Signature{SHA256: "abc", DetectionName: "Generic"}
+	second := &types.Signature{SHA256: "abc", DetectionName: "Win.Trojan.Specific", Severity: types.SeverityHigh}
+
+	d := newSignatureDeduper(false)
+	d.Add(first)
+	d.Add(second)
+
+	if first.DetectionName != "Generic" || first.Severity != types.SeverityUnknown {
+		t.Errorf("input signature modified: %+v", first)
+	}
+}
@@ -5,10 +5,13 @@ package dbupdater
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/hikmaai-io/hikmaai-argus/internal/feeds"
 	"github.com/hikmaai-io/hikmaai-argus/internal/types"
 )
 
@@ -21,6 +24,40 @@ type SignatureFeed interface {
 	Fetch(ctx context.Context) ([]*types.Signature, error)
 }
 
+// StreamingSignatureFeed is a SignatureFeed that can deliver signatures
+// incrementally. The updater prefers FetchStream and stores signatures in
+// batches so large feeds are never held in memory at once.
+type StreamingSignatureFeed interface {
+	SignatureFeed
+
+	// FetchStream calls fn for each signature as it is parsed.
+	FetchStream(ctx context.Context, fn func(*types.Signature) error) error
+}
+
+// ModalSignatureFeed is a SignatureFeed with a cheaper recent-additions
+// mode. The updater fetches it in full until one full load has been stored,
+// then switches to recent mode for periodic runs.
+type ModalSignatureFeed interface {
+	SignatureFeed
+
+	// SetMode selects the full export or recent additions.
+	SetMode(mode feeds.FeedMode)
+}
+
+// ValidatorCommitter is a SignatureFeed that downloads conditionally. The
+// updater commits its cache validators only after the fetched signatures
+// are stored, so a failed store is retried with a full download.
+type ValidatorCommitter interface {
+	SignatureFeed
+
+	// CommitValidators records the validators of the last fetch.
+	CommitValidators() error
+}
+
+// signatureBatchSize is how many streamed signatures are buffered before
+// being written to the engine.
+const signatureBatchSize = 10000
+
 // SignatureEngine stores signatures in the database.
 type SignatureEngine interface {
 	// BatchAddSignatures adds multiple signatures to the database.
@@ -34,6 +71,12 @@ type SignatureEngine interface {
 type SignatureFeedUpdaterConfig struct {
 	// Engine is the signature storage engine.
 	Engine SignatureEngine
+
+	// KeepSources merges signatures listed by several feeds into one
+	// record carrying each feed's contribution, instead of keeping only the
+	// most severe one. Set it when the engine merges colliding signatures,
+	// so pruning one feed keeps the hashes other feeds still list.
+	KeepSources bool
 }
 
 // SignatureFeedStats contains statistics about signature updates.
@@ -65,18 +108,25 @@ type SignatureFeedUpdater struct {
 	config SignatureFeedUpdaterConfig
 	feeds  []SignatureFeed
 
+	// enabled limits updates to the named feeds; nil enables all feeds.
+	enabled map[string]bool
+
 	// Statistics.
 	lastUpdateTime       time.Time
 	lastUpdateSignatures int64
 	feedStats            map[string]*FeedStat
+
+	// fullyLoaded records modal feeds whose full export has been stored.
+	fullyLoaded map[string]bool
 }
 
 // NewSignatureFeedUpdater creates a new signature feed updater.
 func NewSignatureFeedUpdater(config SignatureFeedUpdaterConfig) *SignatureFeedUpdater {
 	return &SignatureFeedUpdater{
-		config:    config,
-		feeds:     make([]SignatureFeed, 0),
-		feedStats: make(map[string]*FeedStat),
+		config:      config,
+		feeds:       make([]SignatureFeed, 0),
+		feedStats:   make(map[string]*FeedStat),
+		fullyLoaded: make(map[string]bool),
 	}
 }
 
@@ -94,7 +144,42 @@ func (u *SignatureFeedUpdater) RegisterFeed(feed SignatureFeed) {
 	u.feedStats[feed.Name()] = &FeedStat{Name: feed.Name()}
 }
 
-// Update fetches signatures from all registered feeds and stores them.
+// SetEnabledFeeds limits updates to the registered feeds whose names are
+// listed. An empty list enables every registered feed again. A name without
+// a registered feed is an error and leaves the selection unchanged. It may
+// be called while updates are running; the change applies from the next
+// update.
+func (u *SignatureFeedUpdater) SetEnabledFeeds(names []string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if len(names) == 0 {
+		u.enabled = nil
+		return nil
+	}
+
+	enabled := make(map[string]bool, len(names))
+	var unknown []string
+	for _, name := range names {
+		if _, ok := u.feedStats[name]; !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		enabled[name] = true
+	}
+	if len(unknown) > 0 {
+		registered := make([]string, 0, len(u.feeds))
+		for _, feed := range u.feeds {
+			registered = append(registered, feed.Name())
+		}
+		return fmt.Errorf("unknown feeds %s (registered: %s)", strings.Join(unknown, ", "), strings.Join(registered, ", "))
+	}
+
+	u.enabled = enabled
+	return nil
+}
+
+// Update fetches signatures from all enabled feeds and stores them.
 func (u *SignatureFeedUpdater) Update(ctx context.Context) (*UpdateResult, error) {
 	// Check context first.
 	select {
@@ -104,8 +189,12 @@ func (u *SignatureFeedUpdater) Update(ctx context.Context) (*UpdateResult, error
 	}
 
 	u.mu.RLock()
-	feeds := make([]SignatureFeed, len(u.feeds))
-	copy(feeds, u.feeds)
+	feeds := make([]SignatureFeed, 0, len(u.feeds))
+	for _, feed := range u.feeds {
+		if u.enabled == nil || u.enabled[feed.Name()] {
+			feeds = append(feeds, feed)
+		}
+	}
 	engine := u.config.Engine
 	u.mu.RUnlock()
 
@@ -115,9 +204,21 @@ func (u *SignatureFeedUpdater) Update(ctx context.Context) (*UpdateResult, error
 		Failed:     0,
 	}
 
-	var totalSignatures []*types.Signature
+	// Feeds often list the same file, so signatures are merged across
+	// feeds before being stored. Non-streaming feeds are collected first;
+	// streamed signatures are then merged into those records instead of
+	// being written twice.
+	dedupe := newSignatureDeduper(u.config.KeepSources)
+	var streaming []StreamingSignatureFeed
+
+	// Modal feeds fetched in full this run, marked loaded once stored.
+	var fullFetches []string
+
+	// Feeds fetched this run. Streamed signatures may be merged into
+	// records stored last, so all are committed after the final write.
+	var fetched []SignatureFeed
 
-	// Fetch from all feeds.
+	// Fetch from all non-streaming feeds.
 	for _, feed := range feeds {
 		select {
 		case <-ctx.Done():
@@ -125,35 +226,82 @@ func (u *SignatureFeedUpdater) Update(ctx context.Context) (*UpdateResult, error
 		default:
 		}
 
-		sigs, err := feed.Fetch(ctx)
+		if sf, ok := feed.(StreamingSignatureFeed); ok {
+			streaming = append(streaming, sf)
+			continue
+		}
 
-		u.mu.Lock()
-		stat := u.feedStats[feed.Name()]
-		stat.LastFetchTime = time.Now()
+		fullFetch := u.selectMode(feed)
+
+		sigs, err := feed.Fetch(ctx)
+		u.recordFetch(feed.Name(), len(sigs), err)
 		if err != nil {
-			stat.LastError = err.Error()
 			result.Failed++
-			u.mu.Unlock()
 			continue
 		}
-		stat.LastFetchCount = int64(len(sigs))
-		stat.LastError = ""
-		u.mu.Unlock()
 
-		totalSignatures = append(totalSignatures, sigs...)
+		for _, sig := range sigs {
+			if dedupe.Add(sig) {
+				result.Deduplicated++
+			}
+		}
 		result.Downloaded += len(sigs)
+		fetched = append(fetched, feed)
+		if fullFetch {
+			fullFetches = append(fullFetches, feed.Name())
+		}
+	}
+
+	// Stream the remaining feeds into the engine in batches.
+	for _, sf := range streaming {
+		select {
+		case <-ctx.Done():
+			return &UpdateResult{Success: false}, ctx.Err()
+		default:
+		}
+
+		fullFetch := u.selectMode(sf)
+
+		count, duplicates, err := u.fetchStream(ctx, sf, engine, dedupe)
+		result.Deduplicated += duplicates
+		u.recordFetch(sf.Name(), count, err)
+		if err != nil {
+			var engineErr *engineError
+			if errors.As(err, &engineErr) {
+				return &UpdateResult{
+					Success:      false,
+					Downloaded:   result.Downloaded + count,
+					Failed:       result.Failed,
+					Deduplicated: result.Deduplicated,
+				}, fmt.Errorf("failed to add signatures to engine: %w", engineErr.err)
+			}
+			result.Failed++
+			continue
+		}
+		result.Downloaded += count
+		if fullFetch {
+			u.markFullyLoaded(sf.Name())
+		}
+		fetched = append(fetched, sf)
 	}
 
 	// Add to engine if we have signatures.
-	if len(totalSignatures) > 0 && engine != nil {
-		if err := engine.BatchAddSignatures(ctx, totalSignatures); err != nil {
+	if dedupe.Len() > 0 && engine != nil {
+		if err := engine.BatchAddSignatures(ctx, dedupe.Signatures()); err != nil {
 			return &UpdateResult{
-				Success:    false,
-				Downloaded: result.Downloaded,
-				Failed:     result.Failed,
+				Success:      false,
+				Downloaded:   result.Downloaded,
+				Failed:       result.Failed,
+				Deduplicated: result.Deduplicated,
 			}, fmt.Errorf("failed to add signatures to engine: %w", err)
 		}
 	}
+	for _, name := range fullFetches {
+		u.markFullyLoaded(name)
+	}
+	for _, feed := range fetched {
+		u.commitValidators(feed)
+	}
 
 	// Update statistics.
 	u.mu.Lock()
@@ -164,6 +312,113 @@ func (u *SignatureFeedUpdater) Update(ctx context.Context) (*UpdateResult, error
 	return result, nil
 }
 
+// selectMode puts a modal feed in full mode until its first full load has
+// been stored, and in recent mode afterwards. It reports whether the feed
+// will be fetched in full.
+func (u *SignatureFeedUpdater) selectMode(feed SignatureFeed) bool {
+	mf, ok := feed.(ModalSignatureFeed)
+	if !ok {
+		return false
+	}
+
+	u.mu.RLock()
+	loaded := u.fullyLoaded[feed.Name()]
+	u.mu.RUnlock()
+
+	if loaded {
+		mf.SetMode(feeds.FeedModeRecent)
+		return false
+	}
+	mf.SetMode(feeds.FeedModeFull)
+	return true
+}
+
+// markFullyLoaded records that a modal feed's full export has been stored.
+func (u *SignatureFeedUpdater) markFullyLoaded(name string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.fullyLoaded[name] = true
+}
+
+// commitValidators commits the cache validators of a feed whose signatures
+// have been stored. A failure only costs a full download next time, so it
+// is recorded in the feed's statistics rather than failing the update.
+func (u *SignatureFeedUpdater) commitValidators(feed SignatureFeed) {
+	vc, ok := feed.(ValidatorCommitter)
+	if !ok {
+		return
+	}
+	if err := vc.CommitValidators(); err != nil {
+		u.mu.Lock()
+		u.feedStats[feed.Name()].LastError = err.Error()
+		u.mu.Unlock()
+	}
+}
+
+// engineError marks a failure to store signatures, as opposed to a feed
+// failure, while streaming.
+type engineError struct {
+	err error
+}
+
+func (e *engineError) Error() string {
+	return e.err.Error()
+}
+
+// fetchStream streams a feed into the engine in batches and returns how
+// many signatures were received and how many were duplicates. Signatures
+// matching a record in merged are folded into it rather than stored.
+func (u *SignatureFeedUpdater) fetchStream(ctx context.Context, feed StreamingSignatureFeed, engine SignatureEngine, merged *signatureDeduper) (int, int, error) {
+	count := 0
+	duplicates := 0
+	batch := newSignatureDeduper(u.config.KeepSources)
+
+	flush := func() error {
+		if batch.Len() == 0 || engine == nil {
+			batch.Reset()
+			return nil
+		}
+		if err := engine.BatchAddSignatures(ctx, batch.Signatures()); err != nil {
+			return &engineError{err: err}
+		}
+		batch.Reset()
+		return nil
+	}
+
+	err := feed.FetchStream(ctx, func(sig *types.Signature) error {
+		count++
+		if merged.Merge(sig) || batch.Add(sig) {
+			duplicates++
+			return nil
+		}
+		if batch.Len() >= signatureBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return count, duplicates, err
+	}
+
+	return count, duplicates, flush()
+}
+
+// recordFetch updates the statistics of a feed after a fetch.
+func (u *SignatureFeedUpdater) recordFetch(name string, count int, err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	stat := u.feedStats[name]
+	stat.LastFetchTime = time.Now()
+	if err != nil {
+		stat.LastError = err.Error()
+		return
+	}
+	stat.LastFetchCount = int64(count)
+	stat.LastError = ""
+}
+
 // CheckForUpdates checks if updates are available.
 // For signature feeds, we always return true since feeds are dynamic.
 func (u *SignatureFeedUpdater) CheckForUpdates(ctx context.Context) (*CheckResult, error) {
@@ -6,10 +6,13 @@ package dbupdater
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/hikmaai-io/hikmaai-argus/internal/feeds"
 	"github.com/hikmaai-io/hikmaai-argus/internal/types"
 )
 
@@ -38,6 +41,7 @@ func (m *mockSignatureFeed) Fetch(ctx context.Context) ([]*types.Signature, erro
 // mockSignatureEngine is a test implementation of the SignatureEngine interface.
 type mockSignatureEngine struct {
 	addCount   atomic.Int32
+	batches    atomic.Int32
 	signatures []*types.Signature
 	shouldFail bool
 }
@@ -48,6 +52,7 @@ func (m *mockSignatureEngine) BatchAddSignatures(ctx context.Context, sigs []*ty
 	}
 
 	m.addCount.Add(int32(len(sigs)))
+	m.batches.Add(1)
 	m.signatures = append(m.signatures, sigs...)
 	return nil
 }
@@ -150,6 +155,171 @@ func TestSignatureFeedUpdater_Update_MultipleFeeds(t *testing.T) {
 	}
 }
 
+func TestSignatureFeedUpdater_SetEnabledFeeds(t *testing.T) {
+	t.Parallel()
+
+	updater := NewSignatureFeedUpdater(SignatureFeedUpdaterConfig{
+		Engine: &mockSignatureEngine{},
+	})
+
+	feed1 := &mockSignatureFeed{name: "feed1", signatures: []*types.Signature{{SHA256: "abc123"}}}
+	feed2 := &mockSignatureFeed{name: "feed2", signatures: []*types.Signature{{SHA256: "def456"}}}
+	updater.RegisterFeed(feed1)
+	updater.RegisterFeed(feed2)
+
+	ctx := context.Background()
+
+	// Unknown names are rejected and change nothing.
+	if err := updater.SetEnabledFeeds([]string{"feed2", "unknown"}); err == nil {
+		t.Error("SetEnabledFeeds() with an unknown feed error = nil, want error")
+	}
+
+	// Only the enabled feed is fetched.
+	if err := updater.SetEnabledFeeds([]string{"feed2"}); err != nil {
+		t.Fatalf("SetEnabledFeeds() error = %v", err)
+	}
+	if _, err := updater.Update(ctx); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if feed1.fetchCount.Load() != 0 || feed2.fetchCount.Load() != 1 {
+		t.Errorf("fetches = (%d, %d), want (0, 1)", feed1.fetchCount.Load(), feed2.fetchCount.Load())
+	}
+
+	// A nil list enables every feed again.
+	if err := updater.SetEnabledFeeds(nil); err != nil {
+		t.Fatalf("SetEnabledFeeds(nil) error = %v", err)
+	}
+	if _, err := updater.Update(ctx); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if feed1.fetchCount.Load() != 1 || feed2.fetchCount.Load() != 2 {
+		t.Errorf("fetches = (%d, %d), want (1, 2)", feed1.fetchCount.Load(), feed2.fetchCount.Load())
+	}
+}
+
+func TestSignatureFeedUpdater_Update_DeduplicatesAcrossFeeds(t *testing.T) {
+	t.Parallel()
+
+	engine := &mockSignatureEngine{}
+	updater := NewSignatureFeedUpdater(SignatureFeedUpdaterConfig{Engine: engine})
+
+	shared := fmt.Sprintf("%064x", 1)
+	updater.RegisterFeed(&mockSignatureFeed{
+		name: "threatfox",
+		signatures: []*types.Signature{
+			{SHA256: shared, DetectionName: "Malware", Severity: types.SeverityMedium, Source: "threatfox"},
+			{SHA256: "def456", DetectionName: "Test.Trojan", Source: "threatfox"},
+		},
+	})
+	updater.RegisterFeed(&mockSignatureFeed{
+		name: "malwarebazaar",
+		signatures: []*types.Signature{
+			{SHA256: shared, MD5: "d41d8cd98f00b204e9800998ecf8427e", DetectionName: "Emotet", Severity: types.SeverityHigh, Source: "malwarebazaar"},
+		},
+	})
+
+	result, err := updater.Update(context.Background())
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if result.Downloaded != 3 {
+		t.Errorf("Downloaded = %d, want 3", result.Downloaded)
+	}
+	if result.Deduplicated != 1 {
+		t.Errorf("Deduplicated = %d, want 1", result.Deduplicated)
+	}
+	if got := engine.Count(); got != 2 {
+		t.Fatalf("engine stored %d signatures, want 2", got)
+	}
+
+	var merged *types.Signature
+	for _, sig := range engine.signatures {
+		if sig.SHA256 == shared {
+			merged = sig
+		}
+	}
+	if merged == nil {
+		t.Fatal("merged signature not stored")
+	}
+	if merged.DetectionName != "Emotet" || merged.Severity != types.SeverityHigh {
+		t.Errorf("merged = %q/%v, want Emotet/high", merged.DetectionName, merged.Severity)
+	}
+	if merged.MD5 == "" {
+		t.Error("merged signature lost the MD5 from the second feed")
+	}
+}
+
+func TestSignatureFeedUpdater_Update_KeepSources(t *testing.T) {
+	t.Parallel()
+
+	engine := &mockSignatureEngine{}
+	updater := NewSignatureFeedUpdater(SignatureFeedUpdaterConfig{Engine: engine, KeepSources: true})
+
+	shared := fmt.Sprintf("%064x", 1)
+	md5 := "d41d8cd98f00b204e9800998ecf8427e"
+	updater.RegisterFeed(&mockSignatureFeed{
+		name: "threatfox",
+		signatures: []*types.Signature{
+			{SHA256: shared, DetectionName: "Malware", Severity: types.SeverityMedium, Source: "threatfox"},
+		},
+	})
+	updater.RegisterFeed(&mockSignatureFeed{
+		name: "malwarebazaar",
+		signatures: []*types.Signature{
+			{SHA256: shared, MD5: md5, DetectionName: "Emotet", Severity: types.SeverityHigh, Source: "malwarebazaar"},
+		},
+	})
+
+	if _, err := updater.Update(context.Background()); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got := engine.Count(); got != 1 {
+		t.Fatalf("engine stored %d signatures, want 1", got)
+	}
+
+	merged := engine.signatures[0]
+	if !merged.HasSource("threatfox") || !merged.HasSource("malwarebazaar") {
+		t.Fatalf("merged Source = %q, want both feeds", merged.Source)
+	}
+
+	// Pruning threatfox must keep what malwarebazaar listed.
+	rest := merged.WithoutSource("threatfox")
+	if rest == nil {
+		t.Fatal("WithoutSource(threatfox) = nil, want malwarebazaar's record")
+	}
+	if rest.Source != "malwarebazaar" || rest.DetectionName != "Emotet" || rest.MD5 != md5 {
+		t.Errorf("after pruning threatfox = %q/%q/md5 %q, want malwarebazaar/Emotet/%s", rest.Source, rest.DetectionName, rest.MD5, md5)
+	}
+}
+
+func TestSignatureFeedUpdater_Update_DeduplicatesStreamedFeed(t *testing.T) {
+	t.Parallel()
+
+	engine := &mockSignatureEngine{}
+	updater := NewSignatureFeedUpdater(SignatureFeedUpdaterConfig{Engine: engine})
+
+	// The streaming feed produces %064x hashes 0..9; the other feed repeats two.
+	updater.RegisterFeed(&mockStreamingFeed{mockSignatureFeed: mockSignatureFeed{name: "stream"}, n: 10})
+	updater.RegisterFeed(&mockSignatureFeed{
+		name: "list",
+		signatures: []*types.Signature{
+			{SHA256: fmt.Sprintf("%064x", 3), DetectionName: "Listed.A"},
+			{SHA256: fmt.Sprintf("%064x", 7), DetectionName: "Listed.B"},
+		},
+	})
+
+	result, err := updater.Update(context.Background())
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if result.Deduplicated != 2 {
+		t.Errorf("Deduplicated = %d, want 2", result.Deduplicated)
+	}
+	if got := engine.Count(); got != 10 {
+		t.Errorf("engine stored %d signatures, want 10", got)
+	}
+}
+
 func TestSignatureFeedUpdater_Update_FeedFailure(t *testing.T) {
 	t.Parallel()
 
@@ -213,6 +383,54 @@ func TestSignatureFeedUpdater_Update_EngineFailure(t *testing.T) {
 	}
 }
 
+// mockCommittingFeed is a mockSignatureFeed that counts validator commits.
+type mockCommittingFeed struct {
+	mockSignatureFeed
+	commits atomic.Int32
+}
+
+func (m *mockCommittingFeed) CommitValidators() error {
+	m.commits.Add(1)
+	return nil
+}
+
+func TestSignatureFeedUpdater_Update_CommitsValidators(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		fetchFails  bool
+		engineFails bool
+		wantCommits int32
+	}{
+		{name: "stored", wantCommits: 1},
+		{name: "fetch failure", fetchFails: true},
+		{name: "engine failure", engineFails: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			updater := NewSignatureFeedUpdater(SignatureFeedUpdaterConfig{
+				Engine: &mockSignatureEngine{shouldFail: tt.engineFails},
+			})
+			feed := &mockCommittingFeed{mockSignatureFeed: mockSignatureFeed{
+				name:       "test",
+				signatures: []*types.Signature{{SHA256: "abc123"}},
+				shouldFail: tt.fetchFails,
+			}}
+			updater.RegisterFeed(feed)
+
+			_, _ = updater.Update(context.Background())
+
+			if got := feed.commits.Load(); got != tt.wantCommits {
+				t.Errorf("CommitValidators() called %d times, want %d", got, tt.wantCommits)
+			}
+		})
+	}
+}
+
 func TestSignatureFeedUpdater_Update_ContextCancellation(t *testing.T) {
 	t.Parallel()
 
@@ -324,3 +542,214 @@ func TestSignatureFeedUpdater_GetStats(t *testing.T) {
 		t.Errorf("LastUpdateSignatures = %d, want 1", stats.LastUpdateSignatures)
 	}
 }
+
+// mockStreamingFeed is a StreamingSignatureFeed producing n signatures.
+type mockStreamingFeed struct {
+	mockSignatureFeed
+	n int
+}
+
+func (m *mockStreamingFeed) FetchStream(ctx context.Context, fn func(*types.Signature) error) error {
+	m.fetchCount.Add(1)
+
+	if m.shouldFail {
+		return errors.New("mock stream failure")
+	}
+
+	for i := 0; i < m.n; i++ {
+		if err := fn(&types.Signature{SHA256: fmt.Sprintf("%064x", i)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mockCommittingStreamingFeed is a mockStreamingFeed that counts validator
+// commits.
+type mockCommittingStreamingFeed struct {
+	mockStreamingFeed
+	commits atomic.Int32
+}
+
+func (m *mockCommittingStreamingFeed) CommitValidators() error {
+	m.commits.Add(1)
+	return nil
+}
+
+func TestSignatureFeedUpdater_Update_CommitsStreamedValidators(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		streamFails bool
+		engineFails bool
+		wantCommits int32
+	}{
+		{name: "stored", wantCommits: 1},
+		{name: "stream failure", streamFails: true},
+		{name: "engine failure", engineFails: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			updater := NewSignatureFeedUpdater(SignatureFeedUpdaterConfig{
+				Engine: &mockSignatureEngine{shouldFail: tt.engineFails},
+			})
+			feed := &mockCommittingStreamingFeed{mockStreamingFeed: mockStreamingFeed{
+				mockSignatureFeed: mockSignatureFeed{name: "stream", shouldFail: tt.streamFails},
+				n:                 3,
+			}}
+			updater.RegisterFeed(feed)
+
+			_, _ = updater.Update(context.Background())
+
+			if got := feed.commits.Load(); got != tt.wantCommits {
+				t.Errorf("CommitValidators() called %d times, want %d", got, tt.wantCommits)
+			}
+		})
+	}
+}
+
+func TestSignatureFeedUpdater_Update_StreamingFeed(t *testing.T) {
+	t.Parallel()
+
+	engine := &mockSignatureEngine{}
+	updater := NewSignatureFeedUpdater(SignatureFeedUpdaterConfig{Engine: engine})
+
+	n := signatureBatchSize*2 + 5
+	feed := &mockStreamingFeed{mockSignatureFeed: mockSignatureFeed{name: "stream"}, n: n}
+	updater.RegisterFeed(feed)
+
+	result, err := updater.Update(context.Background())
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if !result.Success || result.Downloaded != n {
+		t.Errorf("Update() = %s, want %d downloaded", result, n)
+	}
+	if got := engine.addCount.Load(); int(got) != n {
+		t.Errorf("engine received %d signatures, want %d", got, n)
+	}
+	if got := engine.batches.Load(); got != 3 {
+		t.Errorf("engine batches = %d, want 3", got)
+	}
+	if stat := updater.GetStats().FeedStats["stream"]; stat.LastFetchCount != int64(n) {
+		t.Errorf("LastFetchCount = %d, want %d", stat.LastFetchCount, n)
+	}
+}
+
+func TestSignatureFeedUpdater_Update_StreamingFeedErrors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("feed failure", func(t *testing.T) {
+		t.Parallel()
+
+		updater := NewSignatureFeedUpdater(SignatureFeedUpdaterConfig{Engine: &mockSignatureEngine{}})
+		updater.RegisterFeed(&mockStreamingFeed{mockSignatureFeed: mockSignatureFeed{name: "stream", shouldFail: true}})
+
+		result, err := updater.Update(context.Background())
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		if result.Failed != 1 {
+			t.Errorf("Failed = %d, want 1", result.Failed)
+		}
+		if stat := updater.GetStats().FeedStats["stream"]; stat.LastError == "" {
+			t.Error("LastError should be recorded")
+		}
+	})
+
+	t.Run("engine failure", func(t *testing.T) {
+		t.Parallel()
+
+		updater := NewSignatureFeedUpdater(SignatureFeedUpdaterConfig{Engine: &mockSignatureEngine{shouldFail: true}})
+		updater.RegisterFeed(&mockStreamingFeed{mockSignatureFeed: mockSignatureFeed{name: "stream"}, n: 10})
+
+		result, err := updater.Update(context.Background())
+		if err == nil {
+			t.Fatal("Update() expected error when the engine fails")
+		}
+		if result.Success {
+			t.Error("Success should be false")
+		}
+	})
+}
+
+// mockModalFeed records the mode it was fetched in.
+type mockModalFeed struct {
+	mockSignatureFeed
+	mode  feeds.FeedMode
+	modes []feeds.FeedMode
+}
+
+func (m *mockModalFeed) SetMode(mode feeds.FeedMode) {
+	m.mode = mode
+}
+
+func (m *mockModalFeed) Fetch(ctx context.Context) ([]*types.Signature, error) {
+	m.modes = append(m.modes, m.mode)
+	return m.mockSignatureFeed.Fetch(ctx)
+}
+
+func TestSignatureFeedUpdater_Update_ModalFeed(t *testing.T) {
+	t.Parallel()
+
+	engine := &mockSignatureEngine{}
+	updater := NewSignatureFeedUpdater(SignatureFeedUpdaterConfig{Engine: engine})
+
+	feed := &mockModalFeed{mockSignatureFeed: mockSignatureFeed{
+		name:       "modal",
+		signatures: []*types.Signature{{SHA256: "abc123"}},
+		shouldFail: true,
+	}}
+	updater.RegisterFeed(feed)
+
+	// A failed initial load is retried in full mode.
+	for range 2 {
+		if _, err := updater.Update(context.Background()); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+
+	// Once the full load is stored, later runs use recent mode.
+	feed.shouldFail = false
+	for range 2 {
+		if _, err := updater.Update(context.Background()); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+
+	want := []feeds.FeedMode{feeds.FeedModeFull, feeds.FeedModeFull, feeds.FeedModeFull, feeds.FeedModeRecent}
+	if !slices.Equal(feed.modes, want) {
+		t.Errorf("fetch modes = %v, want %v", feed.modes, want)
+	}
+}
+
+func TestSignatureFeedUpdater_Update_ModalFeedEngineFailure(t *testing.T) {
+	t.Parallel()
+
+	engine := &mockSignatureEngine{shouldFail: true}
+	updater := NewSignatureFeedUpdater(SignatureFeedUpdaterConfig{Engine: engine})
+
+	feed := &mockModalFeed{mockSignatureFeed: mockSignatureFeed{
+		name:       "modal",
+		signatures: []*types.Signature{{SHA256: "abc123"}},
+	}}
+	updater.RegisterFeed(feed)
+
+	// The full load is not stored, so the next run must be full again.
+	if _, err := updater.Update(context.Background()); err == nil {
+		t.Fatal("Update() expected engine error")
+	}
+	engine.shouldFail = false
+	if _, err := updater.Update(context.Background()); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	want := []feeds.FeedMode{feeds.FeedModeFull, feeds.FeedModeFull}
+	if !slices.Equal(feed.modes, want) {
+		t.Errorf("fetch modes = %v, want %v", feed.modes, want)
+	}
+}
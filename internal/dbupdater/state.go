@@ -0,0 +1,65 @@
+// ABOUTME: JSON state file persisting DB updater timestamps across restarts
+// ABOUTME: Records each updater's last successful update time and version
+
+package dbupdater
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// stateFileName is the name of the state file inside StateDir.
+const stateFileName = "dbupdate-state.json"
+
+// updaterState is the persisted state of a single updater.
+type updaterState struct {
+	LastUpdate time.Time      `json:"last_update"`
+	Version    int            `json:"version"`
+	BuildTime  time.Time      `json:"build_time,omitzero"`
+	DBFiles    map[string]int `json:"db_files,omitempty"`
+}
+
+// loadState reads the state file at path. A missing file yields an empty state.
+func loadState(path string) (map[string]updaterState, error) {
+	state := make(map[string]updaterState)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading state file: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing state file: %w", err)
+	}
+
+	return state, nil
+}
+
+// saveState writes state to path atomically.
+func saveState(path string, state map[string]updaterState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding state file: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("renaming state file: %w", err)
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@
 package dbupdater
 
 import (
+	"maps"
 	"sync"
 	"time"
 )
@@ -24,6 +25,10 @@ const (
 
 	// StatusFailed indicates the last update failed.
 	StatusFailed Status = "failed"
+
+	// StatusSkipped indicates the last scheduled update was skipped
+	// because CheckForUpdates reported no new data.
+	StatusSkipped Status = "skipped"
 )
 
 // VersionInfo holds version information for a database.
@@ -53,6 +58,9 @@ type UpdaterStatus struct {
 	// NextScheduled is when the next update is scheduled.
 	NextScheduled time.Time
 
+	// LastCheck is when CheckForUpdates last ran before a scheduled update.
+	LastCheck time.Time
+
 	// LastError is the error message from the last failed update.
 	LastError string
 
@@ -77,19 +85,100 @@ func (s *UpdaterStatus) TimeSinceLastUpdate() time.Duration {
 	return time.Since(s.LastUpdate)
 }
 
+// StatusEventKind identifies which tracked value changed.
+type StatusEventKind string
+
+// Status event kinds.
+const (
+	// EventStatus indicates the operational status changed.
+	EventStatus StatusEventKind = "status"
+
+	// EventReady indicates the ready state changed.
+	EventReady StatusEventKind = "ready"
+
+	// EventVersion indicates the database version info changed.
+	EventVersion StatusEventKind = "version"
+)
+
+// subscriberBuffer is the channel buffer for each subscriber. Events are
+// dropped for subscribers whose buffer is full.
+const subscriberBuffer = 16
+
+// StatusEvent reports a change to a tracked updater value.
+type StatusEvent struct {
+	// Kind identifies which value changed.
+	Kind StatusEventKind
+
+	// Status is a snapshot of the updater status after the change.
+	Status UpdaterStatus
+}
+
 // StatusTracker manages status for multiple updaters.
 type StatusTracker struct {
 	mu       sync.RWMutex
 	statuses map[string]*UpdaterStatus
+
+	subscribers map[int]chan StatusEvent
+	nextSubID   int
 }
 
 // NewStatusTracker creates a new status tracker.
 func NewStatusTracker() *StatusTracker {
 	return &StatusTracker{
-		statuses: make(map[string]*UpdaterStatus),
+		statuses:    make(map[string]*UpdaterStatus),
+		subscribers: make(map[int]chan StatusEvent),
 	}
 }
 
+// Subscribe returns a channel receiving an event whenever SetStatus,
+// SetReady, or SetVersion changes a tracked value, and a function that
+// unsubscribes and closes the channel. Sends never block: events are
+// dropped for a subscriber that falls behind.
+func (t *StatusTracker) Subscribe() (<-chan StatusEvent, func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id := t.nextSubID
+	t.nextSubID++
+	ch := make(chan StatusEvent, subscriberBuffer)
+	t.subscribers[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			delete(t.subscribers, id)
+			close(ch)
+		})
+	}
+}
+
+// publish sends an event for s to all subscribers.
+// Must be called with mu held.
+func (t *StatusTracker) publish(kind StatusEventKind, s *UpdaterStatus) {
+	if len(t.subscribers) == 0 {
+		return
+	}
+
+	event := StatusEvent{Kind: kind, Status: *copyStatus(s)}
+	for _, ch := range t.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// copyStatus returns a deep copy of s.
+func copyStatus(s *UpdaterStatus) *UpdaterStatus {
+	cp := *s
+	if s.Version.DBFiles != nil {
+		cp.Version.DBFiles = maps.Clone(s.Version.DBFiles)
+	}
+	return &cp
+}
+
 // Register registers a new updater with the tracker.
 func (t *StatusTracker) Register(name string) {
 	t.mu.Lock()
@@ -112,14 +201,7 @@ func (t *StatusTracker) Get(name string) *UpdaterStatus {
 	}
 
 	// Return a copy to prevent modification.
-	cp := *status
-	if status.Version.DBFiles != nil {
-		cp.Version.DBFiles = make(map[string]int, len(status.Version.DBFiles))
-		for k, v := range status.Version.DBFiles {
-			cp.Version.DBFiles[k] = v
-		}
-	}
-	return &cp
+	return copyStatus(status)
 }
 
 // GetAll returns a copy of all updater statuses.
@@ -129,14 +211,7 @@ func (t *StatusTracker) GetAll() map[string]*UpdaterStatus {
 
 	result := make(map[string]*UpdaterStatus, len(t.statuses))
 	for name, status := range t.statuses {
-		cp := *status
-		if status.Version.DBFiles != nil {
-			cp.Version.DBFiles = make(map[string]int, len(status.Version.DBFiles))
-			for k, v := range status.Version.DBFiles {
-				cp.Version.DBFiles[k] = v
-			}
-		}
-		result[name] = &cp
+		result[name] = copyStatus(status)
 	}
 	return result
 }
@@ -146,8 +221,9 @@ func (t *StatusTracker) SetStatus(name string, status Status) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if s, ok := t.statuses[name]; ok {
+	if s, ok := t.statuses[name]; ok && s.Status != status {
 		s.Status = status
+		t.publish(EventStatus, s)
 	}
 }
 
@@ -156,8 +232,9 @@ func (t *StatusTracker) SetReady(name string, ready bool) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if s, ok := t.statuses[name]; ok {
+	if s, ok := t.statuses[name]; ok && s.Ready != ready {
 		s.Ready = ready
+		t.publish(EventReady, s)
 	}
 }
 
@@ -181,6 +258,16 @@ func (t *StatusTracker) SetNextScheduled(name string, next time.Time) {
 	}
 }
 
+// SetLastCheck updates the last update check time for an updater.
+func (t *StatusTracker) SetLastCheck(name string, lastCheck time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if s, ok := t.statuses[name]; ok {
+		s.LastCheck = lastCheck
+	}
+}
+
 // SetError updates the last error for an updater.
 func (t *StatusTracker) SetError(name string, err string) {
 	t.mu.Lock()
@@ -196,15 +283,20 @@ func (t *StatusTracker) SetVersion(name string, version VersionInfo) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if s, ok := t.statuses[name]; ok {
+	if s, ok := t.statuses[name]; ok && !versionEqual(s.Version, version) {
 		// Deep copy the DBFiles map.
 		cp := version
 		if version.DBFiles != nil {
-			cp.DBFiles = make(map[string]int, len(version.DBFiles))
-			for k, v := range version.DBFiles {
-				cp.DBFiles[k] = v
-			}
+			cp.DBFiles = maps.Clone(version.DBFiles)
 		}
 		s.Version = cp
+		t.publish(EventVersion, s)
 	}
 }
+
+// versionEqual reports whether two version infos are the same.
+func versionEqual(a, b VersionInfo) bool {
+	return a.Version == b.Version &&
+		a.BuildTime.Equal(b.BuildTime) &&
+		maps.Equal(a.DBFiles, b.DBFiles)
+}
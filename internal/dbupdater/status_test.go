@@ -4,6 +4,7 @@
 package dbupdater
 
 import (
+	"runtime"
 	"testing"
 	"time"
 )
@@ -261,6 +262,131 @@ func TestStatusTracker_GetAll_Copy(t *testing.T) {
 	}
 }
 
+func TestStatusTracker_Subscribe(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		change   func(*StatusTracker)
+		wantKind StatusEventKind
+		check    func(*testing.T, UpdaterStatus)
+	}{
+		{
+			name:     "status transition",
+			change:   func(tr *StatusTracker) { tr.SetStatus("clamav", StatusFailed) },
+			wantKind: EventStatus,
+			check: func(t *testing.T, s UpdaterStatus) {
+				if s.Status != StatusFailed {
+					t.Errorf("event Status = %q, want %q", s.Status, StatusFailed)
+				}
+			},
+		},
+		{
+			name:     "becomes ready",
+			change:   func(tr *StatusTracker) { tr.SetReady("clamav", true) },
+			wantKind: EventReady,
+			check: func(t *testing.T, s UpdaterStatus) {
+				if !s.Ready {
+					t.Error("event Ready = false, want true")
+				}
+			},
+		},
+		{
+			name:     "version change",
+			change:   func(tr *StatusTracker) { tr.SetVersion("clamav", VersionInfo{Version: 27000}) },
+			wantKind: EventVersion,
+			check: func(t *testing.T, s UpdaterStatus) {
+				if s.Version.Version != 27000 {
+					t.Errorf("event Version = %d, want 27000", s.Version.Version)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tracker := NewStatusTracker()
+			tracker.Register("clamav")
+
+			events, unsubscribe := tracker.Subscribe()
+			defer unsubscribe()
+
+			tt.change(tracker)
+
+			select {
+			case event := <-events:
+				if event.Kind != tt.wantKind {
+					t.Errorf("event Kind = %q, want %q", event.Kind, tt.wantKind)
+				}
+				if event.Status.Name != "clamav" {
+					t.Errorf("event Name = %q, want clamav", event.Status.Name)
+				}
+				tt.check(t, event.Status)
+			case <-time.After(time.Second):
+				t.Fatal("no event delivered")
+			}
+
+			// Setting the same value again is not a change.
+			tt.change(tracker)
+			select {
+			case event := <-events:
+				t.Errorf("unexpected event for unchanged value: %+v", event)
+			default:
+			}
+		})
+	}
+}
+
+func TestStatusTracker_Subscribe_Unsubscribe(t *testing.T) {
+	// Not parallel: counts goroutines.
+	tracker := NewStatusTracker()
+	tracker.Register("clamav")
+
+	runtime.GC()
+	initial := runtime.NumGoroutine()
+
+	events, unsubscribe := tracker.Subscribe()
+	unsubscribe()
+	unsubscribe() // Safe to call twice.
+
+	tracker.SetStatus("clamav", StatusUpdating)
+
+	if _, ok := <-events; ok {
+		t.Error("received event after unsubscribe, want closed channel")
+	}
+
+	if leaked := runtime.NumGoroutine() - initial; leaked > 0 {
+		t.Errorf("goroutines leaked after unsubscribe: %d", leaked)
+	}
+}
+
+func TestStatusTracker_Subscribe_SlowSubscriber(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewStatusTracker()
+	tracker.Register("clamav")
+
+	_, unsubscribe := tracker.Subscribe()
+	defer unsubscribe()
+
+	// Never reading must not block updates past the buffer.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range subscriberBuffer * 4 {
+			tracker.SetReady("clamav", i%2 == 0)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("SetReady blocked on a slow subscriber")
+	}
+}
+
 func TestStatus_Constants(t *testing.T) {
 	t.Parallel()
 
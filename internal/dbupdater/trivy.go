@@ -6,6 +6,7 @@ package dbupdater
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -29,6 +30,9 @@ type TrivyMetadata struct {
 
 	// UpdatedAt is when the database was last updated.
 	UpdatedAt time.Time `json:"UpdatedAt"`
+
+	// DownloadedAt is when this copy of the database was downloaded.
+	DownloadedAt time.Time `json:"DownloadedAt"`
 }
 
 // TrivyUpdaterConfig configures the Trivy updater.
@@ -146,13 +150,18 @@ func (u *TrivyUpdater) CheckForUpdates(ctx context.Context) (*CheckResult, error
 	// Read current metadata.
 	metadata, err := u.ReadMetadata()
 	if err != nil {
-		// No metadata means we definitely need an update.
+		// Missing or unreadable metadata means we definitely need an update.
 		result.UpdateAvailable = true
-		result.Details["status"] = "no metadata file, update required"
+		if errors.Is(err, os.ErrNotExist) {
+			result.Details["status"] = "no metadata file, update required"
+		} else {
+			result.Details["status"] = fmt.Sprintf("invalid metadata file (%v), update required", err)
+		}
 		return result, nil
 	}
 
 	result.CurrentVersion = metadata.Version
+	result.Details["updated_at"] = metadata.UpdatedAt.Format(time.RFC3339)
 
 	// Check if NextUpdate time has passed.
 	if time.Now().After(metadata.NextUpdate) {
@@ -192,10 +201,9 @@ func (u *TrivyUpdater) IsReady() bool {
 }
 
 // ReadMetadata reads the trivy metadata.json file.
+// A file without a schema version is reported as invalid.
 func (u *TrivyUpdater) ReadMetadata() (*TrivyMetadata, error) {
-	metadataPath := filepath.Join(u.config.CacheDir, "db", "metadata.json")
-
-	data, err := os.ReadFile(metadataPath)
+	data, err := os.ReadFile(u.MetadataPath())
 	if err != nil {
 		return nil, fmt.Errorf("reading metadata.json: %w", err)
 	}
@@ -204,6 +212,9 @@ func (u *TrivyUpdater) ReadMetadata() (*TrivyMetadata, error) {
 	if err := json.Unmarshal(data, &metadata); err != nil {
 		return nil, fmt.Errorf("parsing metadata.json: %w", err)
 	}
+	if metadata.Version <= 0 {
+		return nil, fmt.Errorf("parsing metadata.json: missing Version")
+	}
 
 	return &metadata, nil
 }
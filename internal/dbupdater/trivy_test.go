@@ -224,3 +224,112 @@ func TestTrivyUpdater_ParseMetadata(t *testing.T) {
 		t.Errorf("NextUpdate = %v, want %v", metadata.NextUpdate, expectedUpdate)
 	}
 }
+
+func TestTrivyUpdater_CheckForUpdates_Metadata(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().UTC()
+	tests := []struct {
+		name        string
+		metadata    string // Empty means no metadata.json.
+		wantUpdate  bool
+		wantVersion int
+	}{
+		{
+			name:        "fresh",
+			metadata:    `{"Version": 2, "NextUpdate": "` + now.Add(6*time.Hour).Format(time.RFC3339) + `", "UpdatedAt": "` + now.Add(-time.Hour).Format(time.RFC3339) + `"}`,
+			wantUpdate:  false,
+			wantVersion: 2,
+		},
+		{
+			name:        "stale",
+			metadata:    `{"Version": 2, "NextUpdate": "` + now.Add(-time.Hour).Format(time.RFC3339) + `", "UpdatedAt": "` + now.Add(-7*time.Hour).Format(time.RFC3339) + `"}`,
+			wantUpdate:  true,
+			wantVersion: 2,
+		},
+		{
+			name:       "missing",
+			wantUpdate: true,
+		},
+		{
+			name:       "corrupt",
+			metadata:   `{"Version": 2, "NextUpdate":`,
+			wantUpdate: true,
+		},
+		{
+			name:       "no version",
+			metadata:   `{}`,
+			wantUpdate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cacheDir := t.TempDir()
+			if tt.metadata != "" {
+				writeTrivyMetadata(t, cacheDir, tt.metadata)
+			}
+
+			updater := NewTrivyUpdater(TrivyUpdaterConfig{CacheDir: cacheDir})
+
+			result, err := updater.CheckForUpdates(context.Background())
+			if err != nil {
+				t.Fatalf("CheckForUpdates() error = %v", err)
+			}
+			if result.NeedsUpdate() != tt.wantUpdate {
+				t.Errorf("NeedsUpdate() = %v, want %v (details: %v)", result.NeedsUpdate(), tt.wantUpdate, result.Details)
+			}
+			if result.CurrentVersion != tt.wantVersion {
+				t.Errorf("CurrentVersion = %d, want %d", result.CurrentVersion, tt.wantVersion)
+			}
+
+			// GetVersionInfo reports the same version, or zero if unreadable.
+			if got := updater.GetVersionInfo().Version; got != tt.wantVersion {
+				t.Errorf("GetVersionInfo().Version = %d, want %d", got, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestTrivyUpdater_GetVersionInfo_BuildTime(t *testing.T) {
+	t.Parallel()
+
+	cacheDir := t.TempDir()
+	writeTrivyMetadata(t, cacheDir, `{
+		"Version": 2,
+		"NextUpdate": "2024-01-15T12:00:00Z",
+		"UpdatedAt": "2024-01-14T12:00:00Z",
+		"DownloadedAt": "2024-01-14T13:30:00Z"
+	}`)
+
+	updater := NewTrivyUpdater(TrivyUpdaterConfig{CacheDir: cacheDir})
+
+	info := updater.GetVersionInfo()
+	want := time.Date(2024, 1, 14, 12, 0, 0, 0, time.UTC)
+	if !info.BuildTime.Equal(want) {
+		t.Errorf("BuildTime = %v, want UpdatedAt %v", info.BuildTime, want)
+	}
+
+	metadata, err := updater.ReadMetadata()
+	if err != nil {
+		t.Fatalf("ReadMetadata() error = %v", err)
+	}
+	if want := time.Date(2024, 1, 14, 13, 30, 0, 0, time.UTC); !metadata.DownloadedAt.Equal(want) {
+		t.Errorf("DownloadedAt = %v, want %v", metadata.DownloadedAt, want)
+	}
+}
+
+// writeTrivyMetadata writes metadata.json into the cache's db directory.
+func writeTrivyMetadata(t *testing.T, cacheDir, metadata string) {
+	t.Helper()
+
+	dbDir := filepath.Join(cacheDir, "db")
+	if err := os.MkdirAll(dbDir, 0o755); err != nil {
+		t.Fatalf("Failed to create db dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dbDir, "metadata.json"), []byte(metadata), 0o644); err != nil {
+		t.Fatalf("Failed to create metadata.json: %v", err)
+	}
+}
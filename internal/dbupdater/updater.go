@@ -38,12 +38,20 @@ type UpdateResult struct {
 	// Downloaded is the number of databases downloaded.
 	Downloaded int
 
+	// Patched is the number of databases brought up to date with
+	// incremental patches; these are also counted in Downloaded.
+	Patched int
+
 	// Skipped is the number of databases skipped (already up-to-date).
 	Skipped int
 
 	// Failed is the number of databases that failed to update.
 	Failed int
 
+	// Deduplicated is the number of downloaded records merged into another
+	// record for the same item instead of being stored separately.
+	Deduplicated int
+
 	// Duration is how long the update took.
 	Duration time.Duration
 
@@ -70,7 +78,13 @@ func (r *UpdateResult) String() string {
 	}
 
 	parts = append(parts, fmt.Sprintf("downloaded=%d", r.Downloaded))
+	if r.Patched > 0 {
+		parts = append(parts, fmt.Sprintf("patched=%d", r.Patched))
+	}
 	parts = append(parts, fmt.Sprintf("skipped=%d", r.Skipped))
+	if r.Deduplicated > 0 {
+		parts = append(parts, fmt.Sprintf("deduplicated=%d", r.Deduplicated))
+	}
 
 	if r.Failed > 0 {
 		parts = append(parts, fmt.Sprintf("failed=%d", r.Failed))
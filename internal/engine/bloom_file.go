@@ -0,0 +1,131 @@
+// ABOUTME: Versioned on-disk snapshots of the bloom filter for fast startup
+// ABOUTME: A header records the format version, config, and hash count to detect stale files
+
+package engine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/bits-and-blooms/bloom/v3"
+)
+
+// BloomSnapshotFile is the name of the bloom filter snapshot kept in the
+// store directory.
+const BloomSnapshotFile = "bloom.bin"
+
+// bloomSnapshotVersion is bumped whenever the snapshot layout changes.
+const bloomSnapshotVersion uint32 = 1
+
+// bloomSnapshotMagic identifies a bloom filter snapshot file.
+var bloomSnapshotMagic = [8]byte{'A', 'R', 'G', 'B', 'L', 'O', 'O', 'M'}
+
+// ErrStaleBloomSnapshot is returned by LoadBloomSnapshot when the snapshot
+// was written by another format version, for another bloom configuration,
+// or for a different number of hashes than the store now holds.
+var ErrStaleBloomSnapshot = errors.New("stale bloom filter snapshot")
+
+// bloomSnapshotHeader precedes the serialized filter.
+type bloomSnapshotHeader struct {
+	Magic             [8]byte
+	Version           uint32
+	HashCount         uint64
+	ExpectedItems     uint64
+	FalsePositiveRate uint64 // math.Float64bits of the configured rate.
+}
+
+func newBloomSnapshotHeader(cfg BloomConfig, hashCount int64) bloomSnapshotHeader {
+	return bloomSnapshotHeader{
+		Magic:             bloomSnapshotMagic,
+		Version:           bloomSnapshotVersion,
+		HashCount:         uint64(hashCount),
+		ExpectedItems:     uint64(cfg.ExpectedItems),
+		FalsePositiveRate: math.Float64bits(cfg.FalsePositiveRate),
+	}
+}
+
+// SaveBloomSnapshot writes bf to path with a header recording its
+// configuration and the number of hashes it was built from. The file is
+// replaced atomically.
+func SaveBloomSnapshot(path string, bf *BloomFilter, hashCount int64) error {
+	f := bf.GetFilter()
+	if f == nil {
+		return fmt.Errorf("filter is nil")
+	}
+
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	w := bufio.NewWriter(file)
+	err = binary.Write(w, binary.BigEndian, newBloomSnapshotHeader(bf.config, hashCount))
+	if err == nil {
+		_, err = f.WriteTo(w)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// LoadBloomSnapshot reads the snapshot at path. It returns
+// ErrStaleBloomSnapshot unless the snapshot matches the current format,
+// cfg, and hashCount.
+func LoadBloomSnapshot(path string, cfg BloomConfig, hashCount int64) (*BloomFilter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+
+	var header bloomSnapshotHeader
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("%w: truncated header", ErrStaleBloomSnapshot)
+		}
+		return nil, fmt.Errorf("failed to read snapshot header: %w", err)
+	}
+
+	want := newBloomSnapshotHeader(cfg, hashCount)
+	switch {
+	case header.Magic != want.Magic:
+		return nil, fmt.Errorf("%w: not a bloom snapshot", ErrStaleBloomSnapshot)
+	case header.Version != want.Version:
+		return nil, fmt.Errorf("%w: version %d, want %d", ErrStaleBloomSnapshot, header.Version, want.Version)
+	case header.ExpectedItems != want.ExpectedItems || header.FalsePositiveRate != want.FalsePositiveRate:
+		return nil, fmt.Errorf("%w: built for a different bloom configuration", ErrStaleBloomSnapshot)
+	case header.HashCount != want.HashCount:
+		return nil, fmt.Errorf("%w: built from %d hashes, store has %d", ErrStaleBloomSnapshot, header.HashCount, hashCount)
+	}
+
+	f := &bloom.BloomFilter{}
+	if _, err := f.ReadFrom(r); err != nil {
+		return nil, fmt.Errorf("%w: reading filter: %v", ErrStaleBloomSnapshot, err)
+	}
+
+	bf := &BloomFilter{config: cfg}
+	bf.filter.Store(f)
+	return bf, nil
+}
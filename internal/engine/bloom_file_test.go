@@ -0,0 +1,255 @@
+// ABOUTME: Tests for on-disk bloom filter snapshots
+// ABOUTME: Covers save/load round-trip, stale-file detection, and engine startup reuse
+
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/engine"
+	"github.com/hikmaai-io/hikmaai-argus/internal/types"
+)
+
+var snapshotBloomConfig = engine.BloomConfig{
+	ExpectedItems:     1000,
+	FalsePositiveRate: 0.01,
+}
+
+func TestBloomSnapshot_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), engine.BloomSnapshotFile)
+
+	bf := engine.NewBloomFilter(snapshotBloomConfig)
+	for i := range 10 {
+		bf.Add(types.Hash{Type: types.HashTypeSHA256, Value: hashFromInt(i)})
+	}
+
+	if err := engine.SaveBloomSnapshot(path, bf, 10); err != nil {
+		t.Fatalf("SaveBloomSnapshot() error: %v", err)
+	}
+
+	loaded, err := engine.LoadBloomSnapshot(path, snapshotBloomConfig, 10)
+	if err != nil {
+		t.Fatalf("LoadBloomSnapshot() error: %v", err)
+	}
+
+	for i := range 10 {
+		hash := types.Hash{Type: types.HashTypeSHA256, Value: hashFromInt(i)}
+		if !loaded.Test(hash) {
+			t.Errorf("loaded filter missing %s", hash.Value)
+		}
+	}
+	if loaded.Test(types.Hash{Type: types.HashTypeSHA256, Value: eicarSHA256}) {
+		t.Error("loaded filter reports a hash that was never added")
+	}
+}
+
+func TestBloomSnapshot_Stale(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		cfg       engine.BloomConfig
+		hashCount int64
+		corrupt   func(t *testing.T, path string)
+	}{
+		{
+			name:      "hash count changed",
+			cfg:       snapshotBloomConfig,
+			hashCount: 4,
+		},
+		{
+			name:      "bloom config changed",
+			cfg:       engine.BloomConfig{ExpectedItems: 2000, FalsePositiveRate: 0.01},
+			hashCount: 3,
+		},
+		{
+			name:      "bad magic",
+			cfg:       snapshotBloomConfig,
+			hashCount: 3,
+			corrupt: func(t *testing.T, path string) {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				copy(data, "NOTBLOOM")
+				if err := os.WriteFile(path, data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name:      "truncated",
+			cfg:       snapshotBloomConfig,
+			hashCount: 3,
+			corrupt: func(t *testing.T, path string) {
+				if err := os.Truncate(path, 12); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name:      "truncated filter",
+			cfg:       snapshotBloomConfig,
+			hashCount: 3,
+			corrupt: func(t *testing.T, path string) {
+				if err := os.Truncate(path, 40); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), engine.BloomSnapshotFile)
+			if err := engine.SaveBloomSnapshot(path, engine.NewBloomFilter(snapshotBloomConfig), 3); err != nil {
+				t.Fatalf("SaveBloomSnapshot() error: %v", err)
+			}
+			if tt.corrupt != nil {
+				tt.corrupt(t, path)
+			}
+
+			_, err := engine.LoadBloomSnapshot(path, tt.cfg, tt.hashCount)
+			if !errors.Is(err, engine.ErrStaleBloomSnapshot) {
+				t.Errorf("LoadBloomSnapshot() error = %v, want ErrStaleBloomSnapshot", err)
+			}
+		})
+	}
+}
+
+func TestEngine_BloomSnapshotStartup(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, engine.BloomSnapshotFile)
+	hash := types.Hash{Type: types.HashTypeSHA256, Value: eicarSHA256}
+
+	// First run: add a signature and rebuild, which writes the snapshot.
+	eng := openPersistentEngine(t, dir)
+	sig := &types.Signature{
+		SHA256:        eicarSHA256,
+		DetectionName: "EICAR-Test-File",
+		Source:        "eicar",
+		FirstSeen:     time.Now().UTC(),
+	}
+	if err := eng.AddSignature(ctx, sig); err != nil {
+		t.Fatalf("AddSignature() error: %v", err)
+	}
+	if err := eng.RebuildBloomFilter(ctx); err != nil {
+		t.Fatalf("RebuildBloomFilter() error: %v", err)
+	}
+	if err := eng.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if _, err := engine.LoadBloomSnapshot(path, snapshotBloomConfig, 1); err != nil {
+		t.Fatalf("snapshot after rebuild: %v", err)
+	}
+
+	// Replace it with an empty filter that still matches the hash count.
+	// Loading it instead of rebuilding makes the signature invisible.
+	if err := engine.SaveBloomSnapshot(path, engine.NewBloomFilter(snapshotBloomConfig), 1); err != nil {
+		t.Fatalf("SaveBloomSnapshot() error: %v", err)
+	}
+	eng = openPersistentEngine(t, dir)
+	result, err := eng.Lookup(ctx, hash)
+	if err != nil {
+		t.Fatalf("Lookup() error: %v", err)
+	}
+	if result.Status != types.StatusUnknown {
+		t.Errorf("Status = %v, want %v (snapshot not reused)", result.Status, types.StatusUnknown)
+	}
+
+	// Any change to the signatures removes the snapshot.
+	other := &types.Signature{SHA256: hashFromInt(1), DetectionName: "Other", Source: "test"}
+	if err := eng.AddSignature(ctx, other); err != nil {
+		t.Fatalf("AddSignature() error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("snapshot still present after AddSignature (stat error %v)", err)
+	}
+	if err := eng.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	// A stale snapshot is ignored and the filter rebuilt from the store.
+	if err := engine.SaveBloomSnapshot(path, engine.NewBloomFilter(snapshotBloomConfig), 99); err != nil {
+		t.Fatalf("SaveBloomSnapshot() error: %v", err)
+	}
+	eng = openPersistentEngine(t, dir)
+	defer eng.Close()
+
+	result, err = eng.Lookup(ctx, hash)
+	if err != nil {
+		t.Fatalf("Lookup() error: %v", err)
+	}
+	if result.Status != types.StatusMalware {
+		t.Errorf("Status = %v, want %v after rebuild", result.Status, types.StatusMalware)
+	}
+	if _, err := engine.LoadBloomSnapshot(path, snapshotBloomConfig, 2); err != nil {
+		t.Errorf("snapshot not rewritten after rebuild: %v", err)
+	}
+}
+
+func TestEngine_BloomSnapshotSavedOnClose(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, engine.BloomSnapshotFile)
+	hash := types.Hash{Type: types.HashTypeSHA256, Value: eicarSHA256}
+
+	// Signatures added after startup are in the snapshot saved on Close.
+	eng := openPersistentEngine(t, dir)
+	sig := &types.Signature{SHA256: eicarSHA256, DetectionName: "EICAR-Test-File", Source: "eicar"}
+	if err := eng.BatchAddSignatures(ctx, []*types.Signature{sig}); err != nil {
+		t.Fatalf("BatchAddSignatures() error: %v", err)
+	}
+	if err := eng.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	loaded, err := engine.LoadBloomSnapshot(path, snapshotBloomConfig, 1)
+	if err != nil {
+		t.Fatalf("snapshot after Close: %v", err)
+	}
+	if !loaded.Test(hash) {
+		t.Error("snapshot is missing the added hash")
+	}
+
+	// Deletions leave tombstones, so Close leaves the rebuild to the next start.
+	eng = openPersistentEngine(t, dir)
+	if err := eng.DeleteSignature(ctx, hash); err != nil {
+		t.Fatalf("DeleteSignature() error: %v", err)
+	}
+	if err := eng.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("snapshot saved with tombstones (stat error %v)", err)
+	}
+}
+
+// openPersistentEngine opens an engine on dir that rebuilds its bloom
+// filter on start unless a current snapshot exists.
+func openPersistentEngine(t *testing.T, dir string) *engine.Engine {
+	t.Helper()
+
+	eng, err := engine.NewEngine(engine.EngineConfig{
+		StoreConfig:         engine.StoreConfig{Path: dir},
+		BloomConfig:         snapshotBloomConfig,
+		RebuildBloomOnStart: true,
+	})
+	if err != nil {
+		t.Fatalf("NewEngine() error: %v", err)
+	}
+	return eng
+}
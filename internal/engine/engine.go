@@ -5,12 +5,67 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/observability"
 	"github.com/hikmaai-io/hikmaai-argus/internal/types"
 )
 
+// ErrSignatureNotFound is returned by DeleteSignature when no signature is
+// stored under the hash.
+var ErrSignatureNotFound = errors.New("signature not found")
+
+// MergePolicy decides what happens when an added signature shares a hash
+// with one already stored.
+type MergePolicy int
+
+const (
+	// MergeOverwrite replaces the stored signature with the new one.
+	MergeOverwrite MergePolicy = iota
+	// MergeCombine merges the new signature into the stored one with
+	// types.Signature.Merge.
+	MergeCombine
+	// MergeKeepFirst keeps the stored signature and drops the new one.
+	MergeKeepFirst
+)
+
+// String returns the configuration name of the merge policy.
+func (p MergePolicy) String() string {
+	switch p {
+	case MergeCombine:
+		return "merge"
+	case MergeKeepFirst:
+		return "keep-first"
+	default:
+		return "overwrite"
+	}
+}
+
+// ParseMergePolicy parses a merge policy name ("overwrite", "merge",
+// "keep-first"). An empty name selects MergeOverwrite.
+func ParseMergePolicy(name string) (MergePolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "overwrite":
+		return MergeOverwrite, nil
+	case "merge":
+		return MergeCombine, nil
+	case "keep-first":
+		return MergeKeepFirst, nil
+	default:
+		return MergeOverwrite, fmt.Errorf("unknown merge policy %q (valid: overwrite, merge, keep-first)", name)
+	}
+}
+
 // EngineConfig holds configuration for the lookup engine.
 type EngineConfig struct {
 	// BadgerDB store configuration.
@@ -21,7 +76,14 @@ type EngineConfig struct {
 
 	// RebuildBloomOnStart rebuilds the bloom filter from the database on startup.
 	// This ensures the bloom filter is populated with existing signatures.
+	// The rebuild is skipped when a current bloom.bin snapshot is found in
+	// the store directory.
 	RebuildBloomOnStart bool
+
+	// MergePolicy controls how added signatures that collide with stored
+	// ones, or with each other within a batch, are combined. The default
+	// overwrites.
+	MergePolicy MergePolicy
 }
 
 // EngineStats contains statistics about the engine.
@@ -37,6 +99,10 @@ type EngineStats struct {
 	BloomFalsePositiveRate float64
 	BloomBitSetSize        uint64
 
+	// BloomTombstones counts deleted hashes still set in the bloom filter
+	// since the last rebuild.
+	BloomTombstones int64
+
 	// Lookup statistics.
 	TotalLookups     int64
 	BloomRejections  int64
@@ -52,11 +118,26 @@ type Engine struct {
 	config EngineConfig
 
 	// Statistics counters.
-	totalLookups    int64
-	bloomRejections int64
-	bloomHits       int64
-	storeLookups    int64
-	malwareDetected int64
+	totalLookups    atomic.Int64
+	bloomRejections atomic.Int64
+	bloomHits       atomic.Int64
+	storeLookups    atomic.Int64
+	malwareDetected atomic.Int64
+
+	// bloomTombstones counts deleted hashes still set in the bloom filter.
+	bloomTombstones atomic.Int64
+
+	// snapshotPath is where the bloom filter is persisted; empty for
+	// in-memory stores. snapshotCurrent is set while the file on disk
+	// matches the store, and cleared (removing the file) on the first
+	// change to the signatures. bloomComplete is set once the filter holds
+	// every stored hash, so Close may save it.
+	snapshotPath    string
+	snapshotCurrent atomic.Bool
+	bloomComplete   atomic.Bool
+
+	// writeMu serializes the read-modify-write of merging adds.
+	writeMu sync.Mutex
 }
 
 // NewEngine creates a new lookup engine with the given configuration.
@@ -73,8 +154,15 @@ func NewEngine(cfg EngineConfig) (*Engine, error) {
 		bloom:  bloom,
 		config: cfg,
 	}
+	if !cfg.StoreConfig.InMemory && cfg.StoreConfig.Path != "" {
+		e.snapshotPath = filepath.Join(cfg.StoreConfig.Path, BloomSnapshotFile)
+	}
 
-	// Rebuild bloom filter from existing data if requested.
+	// Prefer the persisted bloom filter; rebuild from existing data if
+	// requested and the snapshot is missing or stale.
+	if e.loadBloomSnapshot(context.Background()) {
+		return e, nil
+	}
 	if cfg.RebuildBloomOnStart {
 		if err := e.RebuildBloomFilter(context.Background()); err != nil {
 			store.Close()
@@ -85,34 +173,111 @@ func NewEngine(cfg EngineConfig) (*Engine, error) {
 	return e, nil
 }
 
-// Close closes the engine and releases resources.
+// loadBloomSnapshot swaps in the persisted bloom filter if it matches the
+// store and reports whether it did.
+func (e *Engine) loadBloomSnapshot(ctx context.Context) bool {
+	if e.snapshotPath == "" {
+		return false
+	}
+	if _, err := os.Stat(e.snapshotPath); err != nil {
+		return false
+	}
+
+	count, err := e.store.CountHashes(ctx)
+	if err != nil {
+		return false
+	}
+	loaded, err := LoadBloomSnapshot(e.snapshotPath, e.config.BloomConfig, count)
+	if err != nil {
+		return false
+	}
+
+	e.bloom.Swap(loaded)
+	e.bloomComplete.Store(true)
+	e.snapshotCurrent.Store(true)
+	return true
+}
+
+// invalidateSnapshot removes the bloom snapshot once the store changes, so
+// a restart cannot load a filter missing newly added hashes.
+func (e *Engine) invalidateSnapshot() {
+	if e.snapshotCurrent.CompareAndSwap(true, false) {
+		os.Remove(e.snapshotPath)
+	}
+}
+
+// saveSnapshot persists the bloom filter if the signatures changed since
+// the snapshot was written. A filter with tombstones is not saved, so the
+// next start rebuilds and drops them. This is best effort: without a
+// snapshot the next start just rebuilds.
+func (e *Engine) saveSnapshot(ctx context.Context) {
+	if e.snapshotPath == "" || e.snapshotCurrent.Load() || !e.bloomComplete.Load() {
+		return
+	}
+	if e.bloomTombstones.Load() > 0 {
+		return
+	}
+
+	count, err := e.store.CountHashes(ctx)
+	if err != nil {
+		return
+	}
+	if err := SaveBloomSnapshot(e.snapshotPath, e.bloom, count); err == nil {
+		e.snapshotCurrent.Store(true)
+	}
+}
+
+// Close saves the bloom filter snapshot if it is out of date, then closes
+// the engine and releases resources.
 func (e *Engine) Close() error {
+	e.saveSnapshot(context.Background())
 	return e.store.Close()
 }
 
 // Lookup looks up a hash and returns the result.
+// SHA256, SHA1, and MD5 hashes are all supported: signatures are indexed
+// under every hash they carry, so an MD5-only signature (e.g. from a ClamAV
+// .hdb database) is found by its MD5.
 // The lookup follows a two-tier approach:
 // 1. Check bloom filter (fast rejection if not present).
 // 2. If bloom filter returns positive, check BadgerDB for confirmation.
 func (e *Engine) Lookup(ctx context.Context, hash types.Hash) (types.Result, error) {
+	ctx, span := observability.StartSpan(ctx, "engine.lookup",
+		trace.WithAttributes(attribute.String("hash.type", hash.Type.String())))
+	defer span.End()
+
+	result, err := e.lookup(ctx, hash)
+	span.SetAttributes(
+		attribute.String("lookup.status", result.Status.String()),
+		attribute.Bool("lookup.bloom_hit", result.BloomHit),
+	)
+	observability.RecordSpanError(span, err)
+	return result, err
+}
+
+// lookup performs the two-tier lookup for Lookup.
+func (e *Engine) lookup(ctx context.Context, hash types.Hash) (types.Result, error) {
 	start := time.Now()
-	e.totalLookups++
+	e.totalLookups.Add(1)
+
+	// Signatures are stored under lowercase hex.
+	hash.Value = strings.ToLower(hash.Value)
 
 	// Step 1: Check bloom filter.
 	bloomHit := e.bloom.Test(hash)
 	if !bloomHit {
 		// Bloom filter says "definitely not present".
-		e.bloomRejections++
+		e.bloomRejections.Add(1)
 		result := types.NewUnknownResult(hash).
 			WithLookupTime(float64(time.Since(start).Microseconds()) / 1000).
 			WithBloomHit(false)
 		return result, nil
 	}
 
-	e.bloomHits++
+	e.bloomHits.Add(1)
 
 	// Step 2: Bloom filter says "maybe present", check store.
-	e.storeLookups++
+	e.storeLookups.Add(1)
 	sig, err := e.store.Get(ctx, hash)
 	if err != nil {
 		result := types.NewErrorResult(hash, err.Error()).
@@ -123,8 +288,8 @@ func (e *Engine) Lookup(ctx context.Context, hash types.Hash) (types.Result, err
 
 	elapsed := float64(time.Since(start).Microseconds()) / 1000
 
-	if sig == nil {
-		// False positive from bloom filter.
+	if sig == nil || sig.IsExpired(time.Now()) {
+		// False positive from bloom filter, or a signature past its expiry.
 		result := types.NewUnknownResult(hash).
 			WithLookupTime(elapsed).
 			WithBloomHit(true)
@@ -132,19 +297,47 @@ func (e *Engine) Lookup(ctx context.Context, hash types.Hash) (types.Result, err
 	}
 
 	// Malware detected.
-	e.malwareDetected++
+	e.malwareDetected.Add(1)
 	result := types.NewMalwareResult(hash, sig).
 		WithLookupTime(elapsed).
 		WithBloomHit(true)
 	return result, nil
 }
 
+// LookupAll looks up each of a file's hashes in turn and returns the first
+// malware result. If none matches, the result for the first hash is
+// returned. It lets callers that compute several digests of a file match
+// signatures keyed by any of them.
+func (e *Engine) LookupAll(ctx context.Context, hashes ...types.Hash) (types.Result, error) {
+	if len(hashes) == 0 {
+		return types.Result{}, fmt.Errorf("no hashes to look up")
+	}
+
+	var first types.Result
+	for i, hash := range hashes {
+		result, err := e.Lookup(ctx, hash)
+		if err != nil || result.Status == types.StatusMalware {
+			return result, err
+		}
+		if i == 0 {
+			first = result
+		}
+	}
+	return first, nil
+}
+
 // AddSignature adds a signature to both the bloom filter and the store.
 func (e *Engine) AddSignature(ctx context.Context, sig *types.Signature) error {
 	if sig == nil {
 		return fmt.Errorf("signature is nil")
 	}
 
+	e.invalidateSnapshot()
+
+	if e.config.MergePolicy != MergeOverwrite {
+		return e.addResolved(ctx, []*types.Signature{sig})
+	}
+
 	// Add to store first.
 	if err := e.store.Put(ctx, sig); err != nil {
 		return fmt.Errorf("failed to store signature: %w", err)
@@ -164,6 +357,12 @@ func (e *Engine) BatchAddSignatures(ctx context.Context, sigs []*types.Signature
 		return nil
 	}
 
+	e.invalidateSnapshot()
+
+	if e.config.MergePolicy != MergeOverwrite {
+		return e.addResolved(ctx, sigs)
+	}
+
 	// Batch store operation.
 	if err := e.store.BatchPut(ctx, sigs); err != nil {
 		return fmt.Errorf("failed to batch store signatures: %w", err)
@@ -182,16 +381,191 @@ func (e *Engine) BatchAddSignatures(ctx context.Context, sigs []*types.Signature
 	return nil
 }
 
+// addResolved stores sigs after applying the merge policy against stored
+// signatures and earlier signatures in the batch.
+func (e *Engine) addResolved(ctx context.Context, sigs []*types.Signature) error {
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+
+	// pending maps storage keys to the latest record written under them.
+	pending := make(map[string]*types.Signature)
+	var records, catalog []*types.Signature
+
+	for _, sig := range sigs {
+		if sig == nil {
+			continue
+		}
+		if len(sig.GetHashes()) == 0 {
+			// Catalog entries share no hashes to merge on.
+			catalog = append(catalog, sig)
+			continue
+		}
+
+		existing, err := e.collidingSignatures(ctx, sig, pending)
+		if err != nil {
+			return fmt.Errorf("failed to read existing signatures: %w", err)
+		}
+
+		record := sig
+		if len(existing) > 0 {
+			if e.config.MergePolicy == MergeKeepFirst {
+				continue
+			}
+			record = existing[0]
+			for _, other := range existing[1:] {
+				record = record.Merge(other)
+			}
+			record = record.Merge(sig)
+		}
+
+		for _, hash := range record.GetHashes() {
+			pending[hash.Key()] = record
+		}
+		records = append(records, record)
+	}
+
+	// Drop records superseded by a later merge in the same batch. Merged
+	// records cover all hashes of their inputs, so a superseded record's
+	// primary key points at its successor.
+	final := records[:0]
+	for _, record := range records {
+		if pending[record.GetHashes()[0].Key()] == record {
+			final = append(final, record)
+		}
+	}
+
+	if err := e.store.BatchPut(ctx, append(final, catalog...)); err != nil {
+		return fmt.Errorf("failed to batch store signatures: %w", err)
+	}
+
+	for _, record := range final {
+		for _, hash := range record.GetHashes() {
+			e.bloom.Add(hash)
+		}
+	}
+
+	return nil
+}
+
+// collidingSignatures returns the distinct signatures sharing a hash with
+// sig, preferring records pending in the current batch over stored ones.
+func (e *Engine) collidingSignatures(ctx context.Context, sig *types.Signature, pending map[string]*types.Signature) ([]*types.Signature, error) {
+	var found []*types.Signature
+	covered := make(map[string]bool)
+
+	add := func(other *types.Signature) {
+		for _, hash := range other.GetHashes() {
+			covered[hash.Key()] = true
+		}
+		found = append(found, other)
+	}
+
+	for _, hash := range sig.GetHashes() {
+		if other, ok := pending[hash.Key()]; ok && !covered[hash.Key()] {
+			add(other)
+		}
+	}
+
+	for _, hash := range sig.GetHashes() {
+		if covered[hash.Key()] {
+			continue
+		}
+		other, err := e.store.Get(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if other == nil {
+			continue
+		}
+		// A stored record already merged into a pending one is stale.
+		stale := false
+		for _, h := range other.GetHashes() {
+			if _, ok := pending[h.Key()]; ok {
+				stale = true
+			}
+		}
+		if !stale {
+			add(other)
+		}
+	}
+
+	return found, nil
+}
+
+// DeleteSignature removes the signature stored under hash, including its
+// entries under its other hash types, and returns ErrSignatureNotFound if
+// there is none.
+//
+// A bloom filter cannot remove members, so the deleted hashes remain set in
+// the filter as tombstones: lookups for them pass the bloom check, miss in
+// the store, and report unknown. Tombstones only cost a store read; call
+// RebuildBloomFilter to clear them.
+func (e *Engine) DeleteSignature(ctx context.Context, hash types.Hash) error {
+	hash.Value = strings.ToLower(hash.Value)
+
+	e.invalidateSnapshot()
+
+	sig, err := e.store.DeleteSignature(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("failed to delete signature: %w", err)
+	}
+	if sig == nil {
+		return ErrSignatureNotFound
+	}
+
+	e.bloomTombstones.Add(int64(len(sig.GetHashes())))
+	return nil
+}
+
+// PruneBySource removes every signature imported from source (for example
+// "malwarebazaar") and returns how many were removed. As with
+// DeleteSignature, the removed hashes stay in the bloom filter as
+// tombstones until the next RebuildBloomFilter.
+func (e *Engine) PruneBySource(ctx context.Context, source string) (int, error) {
+	if source == "" {
+		return 0, fmt.Errorf("source is required")
+	}
+
+	e.invalidateSnapshot()
+
+	count, hashes, err := e.store.DeleteBySource(ctx, source)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune signatures: %w", err)
+	}
+
+	e.bloomTombstones.Add(int64(len(hashes)))
+	return count, nil
+}
+
+// PruneExpired removes every signature whose ExpiresAt has passed and
+// returns how many were removed. Expired signatures already miss on
+// Lookup; pruning reclaims their storage. As with PruneBySource, the
+// removed hashes stay in the bloom filter until the next RebuildBloomFilter.
+func (e *Engine) PruneExpired(ctx context.Context) (int, error) {
+	e.invalidateSnapshot()
+
+	count, hashes, err := e.store.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune expired signatures: %w", err)
+	}
+
+	e.bloomTombstones.Add(int64(len(hashes)))
+	return count, nil
+}
+
 // RebuildBloomFilter rebuilds the bloom filter from the store.
 // This is useful after importing signatures directly to the store.
+// For persistent stores the new filter is also saved to bloom.bin.
 func (e *Engine) RebuildBloomFilter(ctx context.Context) error {
 	// Create a new bloom filter.
 	newBloom := NewBloomFilter(e.config.BloomConfig)
+	var count int64
 
 	// Iterate over all SHA256 hashes in the store.
 	err := e.store.IterateHashes(ctx, types.HashTypeSHA256, func(hashValue string) error {
 		hash := types.Hash{Type: types.HashTypeSHA256, Value: hashValue}
 		newBloom.Add(hash)
+		count++
 		return nil
 	})
 	if err != nil {
@@ -202,6 +576,7 @@ func (e *Engine) RebuildBloomFilter(ctx context.Context) error {
 	err = e.store.IterateHashes(ctx, types.HashTypeSHA1, func(hashValue string) error {
 		hash := types.Hash{Type: types.HashTypeSHA1, Value: hashValue}
 		newBloom.Add(hash)
+		count++
 		return nil
 	})
 	if err != nil {
@@ -212,6 +587,7 @@ func (e *Engine) RebuildBloomFilter(ctx context.Context) error {
 	err = e.store.IterateHashes(ctx, types.HashTypeMD5, func(hashValue string) error {
 		hash := types.Hash{Type: types.HashTypeMD5, Value: hashValue}
 		newBloom.Add(hash)
+		count++
 		return nil
 	})
 	if err != nil {
@@ -220,6 +596,19 @@ func (e *Engine) RebuildBloomFilter(ctx context.Context) error {
 
 	// Atomic swap.
 	e.bloom.Swap(newBloom)
+	e.bloomTombstones.Store(0)
+	e.bloomComplete.Store(true)
+
+	// Persist the filter so the next start can skip the rebuild. This is
+	// best effort: without a snapshot the next start just rebuilds.
+	if e.snapshotPath != "" {
+		e.snapshotCurrent.Store(false)
+		if err := SaveBloomSnapshot(e.snapshotPath, newBloom, count); err == nil {
+			e.snapshotCurrent.Store(true)
+		} else {
+			os.Remove(e.snapshotPath)
+		}
+	}
 
 	return nil
 }
@@ -239,14 +628,57 @@ func (e *Engine) Stats(ctx context.Context) (*EngineStats, error) {
 		BloomCapacity:          bloomStats.Capacity,
 		BloomFalsePositiveRate: bloomStats.FalsePositiveRate,
 		BloomBitSetSize:        bloomStats.BitSetSize,
-		TotalLookups:           e.totalLookups,
-		BloomRejections:        e.bloomRejections,
-		BloomHits:              e.bloomHits,
-		StoreLookups:           e.storeLookups,
-		MalwareDetected:        e.malwareDetected,
+		BloomTombstones:        e.bloomTombstones.Load(),
+		TotalLookups:           e.totalLookups.Load(),
+		BloomRejections:        e.bloomRejections.Load(),
+		BloomHits:              e.bloomHits.Load(),
+		StoreLookups:           e.storeLookups.Load(),
+		MalwareDetected:        e.malwareDetected.Load(),
 	}, nil
 }
 
+// DetailedStats breaks the signature database down by origin and category.
+type DetailedStats struct {
+	// Number of signatures in the database.
+	SignatureCount int64
+
+	// Signature counts keyed by feed source; signatures without a source
+	// are counted under "unknown". Merged signatures count once for each
+	// of their sources.
+	BySource map[string]int64
+
+	// Signature counts keyed by threat type name (e.g. "trojan").
+	ByThreatType map[string]int64
+}
+
+// StatsDetailed counts signatures per source and per threat type. It scans
+// every signature in the store, so it is much more expensive than Stats
+// and is meant for diagnostics such as checking a feed import.
+func (e *Engine) StatsDetailed(ctx context.Context) (*DetailedStats, error) {
+	stats := &DetailedStats{
+		BySource:     make(map[string]int64),
+		ByThreatType: make(map[string]int64),
+	}
+
+	err := e.store.IterateSignatures(ctx, func(sig *types.Signature) error {
+		sources := sig.Sources()
+		if len(sources) == 0 {
+			sources = []string{"unknown"}
+		}
+		stats.SignatureCount++
+		for _, source := range sources {
+			stats.BySource[source]++
+		}
+		stats.ByThreatType[sig.ThreatType.String()]++
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan signatures: %w", err)
+	}
+
+	return stats, nil
+}
+
 // GetStore returns the underlying store (for advanced operations).
 func (e *Engine) GetStore() *Store {
 	return e.store
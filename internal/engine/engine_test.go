@@ -5,9 +5,17 @@ package engine_test
 
 import (
 	"context"
+	"errors"
+	"maps"
+	"strings"
 	"testing"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
 	"github.com/hikmaai-io/hikmaai-argus/internal/engine"
 	"github.com/hikmaai-io/hikmaai-argus/internal/types"
 )
@@ -175,6 +183,267 @@ func TestEngine_BatchAddSignatures(t *testing.T) {
 	}
 }
 
+func TestEngine_BatchAddSignatures_Dedupes(t *testing.T) {
+	t.Parallel()
+
+	eng := newTestEngine(t)
+	ctx := context.Background()
+
+	batch := func(from, to int) []*types.Signature {
+		var sigs []*types.Signature
+		for i := from; i < to; i++ {
+			sigs = append(sigs, &types.Signature{
+				SHA256:        hashFromInt(i),
+				DetectionName: "Test.Malware",
+				Source:        "test",
+				FirstSeen:     time.Now().UTC(),
+			})
+		}
+		return sigs
+	}
+
+	// A full import followed by an overlapping recent import.
+	if err := eng.BatchAddSignatures(ctx, batch(0, 30)); err != nil {
+		t.Fatalf("BatchAddSignatures() error: %v", err)
+	}
+	if err := eng.BatchAddSignatures(ctx, batch(20, 40)); err != nil {
+		t.Fatalf("BatchAddSignatures() error: %v", err)
+	}
+
+	stats, err := eng.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats() error: %v", err)
+	}
+	if stats.SignatureCount != 40 {
+		t.Errorf("SignatureCount = %d, want 40 unique signatures", stats.SignatureCount)
+	}
+}
+
+func TestEngine_MergePolicy(t *testing.T) {
+	t.Parallel()
+
+	stored := func() *types.Signature {
+		return &types.Signature{SHA256: hashFromInt(1), DetectionName: "Win.Trojan.Agent", Severity: types.SeverityMedium, Source: "clamav"}
+	}
+	// The first batch entry adds an MD5; the second collides on that MD5 only.
+	incoming := func() []*types.Signature {
+		return []*types.Signature{
+			{SHA256: hashFromInt(1), MD5: eicarMD5, DetectionName: "AgentTesla", Severity: types.SeverityHigh, Source: "malwarebazaar"},
+			{MD5: eicarMD5, DetectionName: "Agent.Generic", Severity: types.SeverityLow, Source: "threatfox"},
+		}
+	}
+
+	tests := []struct {
+		policy        engine.MergePolicy
+		wantSource    string
+		wantDetection string
+		wantSeverity  types.Severity
+	}{
+		{
+			policy:        engine.MergeOverwrite,
+			wantSource:    "malwarebazaar",
+			wantDetection: "AgentTesla",
+			wantSeverity:  types.SeverityHigh,
+		},
+		{
+			policy:        engine.MergeCombine,
+			wantSource:    "clamav,malwarebazaar,threatfox",
+			wantDetection: "Win.Trojan.Agent, AgentTesla, Agent.Generic",
+			wantSeverity:  types.SeverityHigh,
+		},
+		{
+			policy:        engine.MergeKeepFirst,
+			wantSource:    "clamav",
+			wantDetection: "Win.Trojan.Agent",
+			wantSeverity:  types.SeverityMedium,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			t.Parallel()
+
+			eng := newTestEngineWithPolicy(t, tt.policy)
+			ctx := context.Background()
+
+			if err := eng.AddSignature(ctx, stored()); err != nil {
+				t.Fatalf("AddSignature() error: %v", err)
+			}
+			if err := eng.BatchAddSignatures(ctx, incoming()); err != nil {
+				t.Fatalf("BatchAddSignatures() error: %v", err)
+			}
+
+			hash, _ := types.ParseHash(hashFromInt(1))
+			result, err := eng.Lookup(ctx, hash)
+			if err != nil {
+				t.Fatalf("Lookup() error: %v", err)
+			}
+			sig := result.Signature
+			if sig == nil {
+				t.Fatalf("Lookup() Status = %v, want malware", result.Status)
+			}
+			if sig.Source != tt.wantSource {
+				t.Errorf("Source = %q, want %q", sig.Source, tt.wantSource)
+			}
+			if sig.DetectionName != tt.wantDetection {
+				t.Errorf("DetectionName = %q, want %q", sig.DetectionName, tt.wantDetection)
+			}
+			if sig.Severity != tt.wantSeverity {
+				t.Errorf("Severity = %v, want %v", sig.Severity, tt.wantSeverity)
+			}
+
+			if tt.policy == engine.MergeCombine {
+				// The MD5 entry holds the same merged record.
+				md5Result, err := eng.Lookup(ctx, types.Hash{Type: types.HashTypeMD5, Value: eicarMD5})
+				if err != nil {
+					t.Fatalf("Lookup(md5) error: %v", err)
+				}
+				if md5Result.Signature == nil || md5Result.Signature.Source != tt.wantSource {
+					t.Errorf("Lookup(md5) signature = %+v, want source %q", md5Result.Signature, tt.wantSource)
+				}
+			}
+		})
+	}
+}
+
+func TestEngine_PruneBySource_Merged(t *testing.T) {
+	t.Parallel()
+
+	eng := newTestEngineWithPolicy(t, engine.MergeCombine)
+	ctx := context.Background()
+
+	sigs := []*types.Signature{
+		{SHA256: hashFromInt(1), DetectionName: "A", Severity: types.SeverityLow, Source: "clamav"},
+		{SHA256: hashFromInt(1), MD5: eicarMD5, DetectionName: "B", Severity: types.SeverityCritical, Source: "malwarebazaar"},
+		{SHA256: hashFromInt(2), DetectionName: "C", Source: "malwarebazaar"},
+	}
+	if err := eng.BatchAddSignatures(ctx, sigs); err != nil {
+		t.Fatalf("BatchAddSignatures() error: %v", err)
+	}
+
+	count, err := eng.PruneBySource(ctx, "malwarebazaar")
+	if err != nil {
+		t.Fatalf("PruneBySource() error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("PruneBySource() = %d, want 1", count)
+	}
+
+	hash, _ := types.ParseHash(hashFromInt(1))
+	result, err := eng.Lookup(ctx, hash)
+	if err != nil {
+		t.Fatalf("Lookup() error: %v", err)
+	}
+	sig := result.Signature
+	if sig == nil || sig.Source != "clamav" {
+		t.Fatalf("merged signature = %+v, want kept with source clamav", sig)
+	}
+	// Only clamav's contribution remains.
+	if sig.DetectionName != "A" || sig.Severity != types.SeverityLow || sig.MD5 != "" {
+		t.Errorf("merged signature = %q/%v/md5 %q, want A/low without md5", sig.DetectionName, sig.Severity, sig.MD5)
+	}
+
+	result, err = eng.Lookup(ctx, types.Hash{Type: types.HashTypeMD5, Value: eicarMD5})
+	if err != nil {
+		t.Fatalf("Lookup(md5) error: %v", err)
+	}
+	if result.Status != types.StatusUnknown {
+		t.Errorf("Lookup(md5) Status = %v, want unknown after pruning its only source", result.Status)
+	}
+}
+
+func TestEngine_CatalogSignatures(t *testing.T) {
+	t.Parallel()
+
+	for _, policy := range []engine.MergePolicy{engine.MergeOverwrite, engine.MergeCombine} {
+		t.Run(policy.String(), func(t *testing.T) {
+			t.Parallel()
+
+			eng := newTestEngineWithPolicy(t, policy)
+			ctx := context.Background()
+
+			sigs := []*types.Signature{
+				{SHA256: hashFromInt(1), DetectionName: "A", Source: "clamav"},
+				{DetectionName: "Win.Trojan.Body-1", Source: "clamav"},
+				{DetectionName: "Win.Trojan.Body-2", Source: "clamav"},
+			}
+			if err := eng.BatchAddSignatures(ctx, sigs); err != nil {
+				t.Fatalf("BatchAddSignatures() error: %v", err)
+			}
+
+			stats, err := eng.Stats(ctx)
+			if err != nil {
+				t.Fatalf("Stats() error: %v", err)
+			}
+			if stats.SignatureCount != 1 {
+				t.Errorf("SignatureCount = %d, want 1 (catalog entries excluded)", stats.SignatureCount)
+			}
+			hashes, err := eng.GetStore().CountHashes(ctx)
+			if err != nil {
+				t.Fatalf("CountHashes() error: %v", err)
+			}
+			if hashes != 1 {
+				t.Errorf("CountHashes() = %d, want 1", hashes)
+			}
+
+			var names []string
+			err = eng.GetStore().IterateCatalog(ctx, func(sig *types.Signature) error {
+				names = append(names, sig.DetectionName)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("IterateCatalog() error: %v", err)
+			}
+			if len(names) != 2 {
+				t.Errorf("IterateCatalog() = %v, want both body signatures", names)
+			}
+
+			count, err := eng.PruneBySource(ctx, "clamav")
+			if err != nil {
+				t.Fatalf("PruneBySource() error: %v", err)
+			}
+			if count != 3 {
+				t.Errorf("PruneBySource() = %d, want 3", count)
+			}
+			names = nil
+			_ = eng.GetStore().IterateCatalog(ctx, func(sig *types.Signature) error {
+				names = append(names, sig.DetectionName)
+				return nil
+			})
+			if len(names) != 0 {
+				t.Errorf("IterateCatalog() after prune = %v, want none", names)
+			}
+		})
+	}
+}
+
+func TestParseMergePolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		want    engine.MergePolicy
+		wantErr bool
+	}{
+		{name: "", want: engine.MergeOverwrite},
+		{name: "overwrite", want: engine.MergeOverwrite},
+		{name: "Merge", want: engine.MergeCombine},
+		{name: "keep-first", want: engine.MergeKeepFirst},
+		{name: "union", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := engine.ParseMergePolicy(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseMergePolicy(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMergePolicy(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
 func TestEngine_RebuildBloomFilter(t *testing.T) {
 	t.Parallel()
 
@@ -215,6 +484,304 @@ func TestEngine_RebuildBloomFilter(t *testing.T) {
 	}
 }
 
+func TestEngine_LookupSingleHashSignatures(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		sig    *types.Signature
+		lookup string
+	}{
+		{
+			name:   "md5 only",
+			sig:    &types.Signature{MD5: eicarMD5, DetectionName: "Hdb.Test", Source: "clamav"},
+			lookup: eicarMD5,
+		},
+		{
+			name:   "sha1 only",
+			sig:    &types.Signature{SHA1: eicarSHA1, DetectionName: "Hsb.Test", Source: "clamav"},
+			lookup: eicarSHA1,
+		},
+		{
+			name:   "uppercase md5 in feed",
+			sig:    &types.Signature{MD5: strings.ToUpper(eicarMD5), DetectionName: "Hdb.Upper", Source: "clamav"},
+			lookup: eicarMD5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			eng := newTestEngine(t)
+			ctx := context.Background()
+
+			if err := eng.AddSignature(ctx, tt.sig); err != nil {
+				t.Fatalf("AddSignature() error: %v", err)
+			}
+
+			hash, err := types.ParseHash(tt.lookup)
+			if err != nil {
+				t.Fatalf("ParseHash() error: %v", err)
+			}
+
+			// The hit must survive a bloom rebuild from the store.
+			for _, stage := range []string{"after add", "after rebuild"} {
+				result, err := eng.Lookup(ctx, hash)
+				if err != nil {
+					t.Fatalf("Lookup() %s error: %v", stage, err)
+				}
+				if result.Status != types.StatusMalware {
+					t.Errorf("Lookup() %s Status = %v, want %v", stage, result.Status, types.StatusMalware)
+				}
+				if result.Signature == nil || result.Signature.DetectionName != tt.sig.DetectionName {
+					t.Errorf("Lookup() %s Signature = %+v, want %s", stage, result.Signature, tt.sig.DetectionName)
+				}
+
+				if err := eng.RebuildBloomFilter(ctx); err != nil {
+					t.Fatalf("RebuildBloomFilter() error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestEngine_LookupAll(t *testing.T) {
+	t.Parallel()
+
+	eng := newTestEngine(t)
+	ctx := context.Background()
+
+	if err := eng.AddSignature(ctx, &types.Signature{MD5: eicarMD5, DetectionName: "Hdb.Test"}); err != nil {
+		t.Fatalf("AddSignature() error: %v", err)
+	}
+
+	sha256Hash, _ := types.ParseHash(eicarSHA256)
+	md5Hash, _ := types.ParseHash(eicarMD5)
+	result, err := eng.LookupAll(ctx, sha256Hash, md5Hash)
+	if err != nil {
+		t.Fatalf("LookupAll() error: %v", err)
+	}
+	if result.Status != types.StatusMalware || result.Hash != md5Hash {
+		t.Errorf("LookupAll() = %v for %v, want malware for the MD5", result.Status, result.Hash)
+	}
+
+	clean, _ := types.ParseHash(hashFromInt(1))
+	result, err = eng.LookupAll(ctx, clean, types.Hash{Type: types.HashTypeMD5, Value: strings.Repeat("0", types.MD5Length)})
+	if err != nil {
+		t.Fatalf("LookupAll() error: %v", err)
+	}
+	if result.Status != types.StatusUnknown || result.Hash != clean {
+		t.Errorf("LookupAll() = %v for %v, want unknown for the first hash", result.Status, result.Hash)
+	}
+
+	if _, err := eng.LookupAll(ctx); err == nil {
+		t.Error("LookupAll() with no hashes error = nil, want error")
+	}
+}
+
+func TestEngine_DeleteSignature(t *testing.T) {
+	t.Parallel()
+
+	eng := newTestEngine(t)
+	ctx := context.Background()
+
+	sig := &types.Signature{
+		SHA256:        eicarSHA256,
+		SHA1:          eicarSHA1,
+		MD5:           eicarMD5,
+		DetectionName: "EICAR-Test-File",
+		Source:        "eicar",
+	}
+	if err := eng.AddSignature(ctx, sig); err != nil {
+		t.Fatalf("AddSignature() error: %v", err)
+	}
+
+	// Deleting by one hash removes the signature under all of them.
+	md5Hash, _ := types.ParseHash(eicarMD5)
+	if err := eng.DeleteSignature(ctx, md5Hash); err != nil {
+		t.Fatalf("DeleteSignature() error: %v", err)
+	}
+
+	for _, value := range []string{eicarSHA256, eicarSHA1, eicarMD5} {
+		hash, _ := types.ParseHash(value)
+		result, err := eng.Lookup(ctx, hash)
+		if err != nil {
+			t.Fatalf("Lookup(%s) error: %v", hash.Type, err)
+		}
+		if result.Status != types.StatusUnknown {
+			t.Errorf("Lookup(%s) Status = %v, want %v", hash.Type, result.Status, types.StatusUnknown)
+		}
+		// The hash is a bloom tombstone until the filter is rebuilt.
+		if !result.BloomHit {
+			t.Errorf("Lookup(%s) BloomHit = false, want true before rebuild", hash.Type)
+		}
+	}
+
+	stats, _ := eng.Stats(ctx)
+	if stats.BloomTombstones != 3 {
+		t.Errorf("BloomTombstones = %d, want 3", stats.BloomTombstones)
+	}
+
+	if err := eng.DeleteSignature(ctx, md5Hash); !errors.Is(err, engine.ErrSignatureNotFound) {
+		t.Errorf("second DeleteSignature() error = %v, want ErrSignatureNotFound", err)
+	}
+
+	if err := eng.RebuildBloomFilter(ctx); err != nil {
+		t.Fatalf("RebuildBloomFilter() error: %v", err)
+	}
+	stats, _ = eng.Stats(ctx)
+	if stats.BloomTombstones != 0 {
+		t.Errorf("BloomTombstones after rebuild = %d, want 0", stats.BloomTombstones)
+	}
+	if result, _ := eng.Lookup(ctx, md5Hash); result.BloomHit {
+		t.Error("Lookup() BloomHit = true after rebuild, want false")
+	}
+}
+
+func TestEngine_PruneBySource(t *testing.T) {
+	t.Parallel()
+
+	eng := newTestEngine(t)
+	ctx := context.Background()
+
+	sigs := []*types.Signature{
+		{SHA256: hashFromInt(1), DetectionName: "A", Source: "malwarebazaar"},
+		{SHA256: hashFromInt(2), MD5: eicarMD5, DetectionName: "B", Source: "malwarebazaar"},
+		{SHA1: eicarSHA1, DetectionName: "C", Source: "malwarebazaar"},
+		{SHA256: hashFromInt(3), DetectionName: "D", Source: "threatfox"},
+		{SHA256: hashFromInt(4), DetectionName: "E", Source: "threatfox"},
+	}
+	if err := eng.BatchAddSignatures(ctx, sigs); err != nil {
+		t.Fatalf("BatchAddSignatures() error: %v", err)
+	}
+
+	tests := []struct {
+		source    string
+		wantCount int
+	}{
+		{source: "malwarebazaar", wantCount: 3},
+		{source: "malwarebazaar", wantCount: 0},
+		{source: "unknown-feed", wantCount: 0},
+	}
+	for _, tt := range tests {
+		count, err := eng.PruneBySource(ctx, tt.source)
+		if err != nil {
+			t.Fatalf("PruneBySource(%q) error: %v", tt.source, err)
+		}
+		if count != tt.wantCount {
+			t.Errorf("PruneBySource(%q) = %d, want %d", tt.source, count, tt.wantCount)
+		}
+	}
+
+	for _, sig := range sigs {
+		hash := sig.GetHashes()[0]
+		result, err := eng.Lookup(ctx, hash)
+		if err != nil {
+			t.Fatalf("Lookup(%s) error: %v", sig.DetectionName, err)
+		}
+		want := types.StatusMalware
+		if sig.Source == "malwarebazaar" {
+			want = types.StatusUnknown
+		}
+		if result.Status != want {
+			t.Errorf("Lookup(%s) Status = %v, want %v", sig.DetectionName, result.Status, want)
+		}
+	}
+
+	if _, err := eng.PruneBySource(ctx, ""); err == nil {
+		t.Error("PruneBySource(\"\") error = nil, want error")
+	}
+}
+
+func TestEngine_LookupExpired(t *testing.T) {
+	t.Parallel()
+
+	eng := newTestEngine(t)
+	ctx := context.Background()
+
+	expired := (&types.Signature{
+		SHA256:        hashFromInt(1),
+		DetectionName: "Retracted",
+		Source:        "threatfox",
+		FirstSeen:     time.Now().UTC().Add(-48 * time.Hour),
+	}).WithTTL(24 * time.Hour)
+	current := (&types.Signature{
+		SHA256:        hashFromInt(2),
+		DetectionName: "Current",
+		Source:        "threatfox",
+		FirstSeen:     time.Now().UTC(),
+	}).WithTTL(24 * time.Hour)
+	if err := eng.BatchAddSignatures(ctx, []*types.Signature{expired, current}); err != nil {
+		t.Fatalf("BatchAddSignatures() error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		sig  *types.Signature
+		want types.Status
+	}{
+		{name: "expired", sig: expired, want: types.StatusUnknown},
+		{name: "current", sig: current, want: types.StatusMalware},
+	}
+	for _, tt := range tests {
+		result, err := eng.Lookup(ctx, tt.sig.GetHashes()[0])
+		if err != nil {
+			t.Fatalf("Lookup(%s) error: %v", tt.name, err)
+		}
+		if result.Status != tt.want {
+			t.Errorf("Lookup(%s).Status = %v, want %v", tt.name, result.Status, tt.want)
+		}
+	}
+}
+
+func TestEngine_PruneExpired(t *testing.T) {
+	t.Parallel()
+
+	eng := newTestEngine(t)
+	ctx := context.Background()
+	past := time.Now().UTC().Add(-48 * time.Hour)
+
+	sigs := []*types.Signature{
+		(&types.Signature{SHA256: hashFromInt(1), MD5: eicarMD5, DetectionName: "A", Source: "threatfox", FirstSeen: past}).WithTTL(time.Hour),
+		(&types.Signature{SHA1: eicarSHA1, DetectionName: "B", Source: "threatfox", FirstSeen: past}).WithTTL(time.Hour),
+		(&types.Signature{SHA256: hashFromInt(2), DetectionName: "C", Source: "threatfox", FirstSeen: past}).WithTTL(365 * 24 * time.Hour),
+		{SHA256: hashFromInt(3), DetectionName: "D", Source: "clamav", FirstSeen: past},
+	}
+	if err := eng.BatchAddSignatures(ctx, sigs); err != nil {
+		t.Fatalf("BatchAddSignatures() error: %v", err)
+	}
+
+	for i, want := range []int{2, 0} {
+		count, err := eng.PruneExpired(ctx)
+		if err != nil {
+			t.Fatalf("PruneExpired() #%d error: %v", i+1, err)
+		}
+		if count != want {
+			t.Errorf("PruneExpired() #%d = %d, want %d", i+1, count, want)
+		}
+	}
+
+	stats, err := eng.StatsDetailed(ctx)
+	if err != nil {
+		t.Fatalf("StatsDetailed() error: %v", err)
+	}
+	if stats.SignatureCount != 2 {
+		t.Errorf("SignatureCount = %d, want 2", stats.SignatureCount)
+	}
+
+	if err := eng.RebuildBloomFilter(ctx); err != nil {
+		t.Fatalf("RebuildBloomFilter() error: %v", err)
+	}
+	engStats, err := eng.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats() error: %v", err)
+	}
+	if engStats.BloomTombstones != 0 {
+		t.Errorf("BloomTombstones = %d after rebuild, want 0", engStats.BloomTombstones)
+	}
+}
+
 func TestEngine_Stats(t *testing.T) {
 	t.Parallel()
 
@@ -245,6 +812,43 @@ func TestEngine_Stats(t *testing.T) {
 	}
 }
 
+func TestEngine_StatsDetailed(t *testing.T) {
+	t.Parallel()
+
+	eng := newTestEngine(t)
+	ctx := context.Background()
+
+	sigs := []*types.Signature{
+		{SHA256: hashFromInt(1), DetectionName: "A", ThreatType: types.ThreatTypeTrojan, Source: "malwarebazaar"},
+		{SHA256: hashFromInt(2), MD5: eicarMD5, DetectionName: "B", ThreatType: types.ThreatTypeRansomware, Source: "malwarebazaar"},
+		{SHA1: eicarSHA1, DetectionName: "C", ThreatType: types.ThreatTypeTrojan, Source: "malwarebazaar"},
+		{SHA256: hashFromInt(3), DetectionName: "D", ThreatType: types.ThreatTypeTrojan, Source: "threatfox"},
+		{SHA256: hashFromInt(4), DetectionName: "E"},
+	}
+	if err := eng.BatchAddSignatures(ctx, sigs); err != nil {
+		t.Fatalf("BatchAddSignatures() error: %v", err)
+	}
+
+	stats, err := eng.StatsDetailed(ctx)
+	if err != nil {
+		t.Fatalf("StatsDetailed() error: %v", err)
+	}
+
+	if stats.SignatureCount != int64(len(sigs)) {
+		t.Errorf("SignatureCount = %d, want %d", stats.SignatureCount, len(sigs))
+	}
+
+	wantSource := map[string]int64{"malwarebazaar": 3, "threatfox": 1, "unknown": 1}
+	if !maps.Equal(stats.BySource, wantSource) {
+		t.Errorf("BySource = %v, want %v", stats.BySource, wantSource)
+	}
+
+	wantThreat := map[string]int64{"trojan": 3, "ransomware": 1, "unknown": 1}
+	if !maps.Equal(stats.ByThreatType, wantThreat) {
+		t.Errorf("ByThreatType = %v, want %v", stats.ByThreatType, wantThreat)
+	}
+}
+
 func TestEngine_LookupWithTracing(t *testing.T) {
 	t.Parallel()
 
@@ -276,9 +880,62 @@ func TestEngine_LookupWithTracing(t *testing.T) {
 	}
 }
 
+// TestEngine_LookupSpan is not parallel: it installs a global tracer provider.
+func TestEngine_LookupSpan(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+
+	eng := newTestEngine(t)
+	ctx := context.Background()
+
+	sig := &types.Signature{SHA256: eicarSHA256, DetectionName: "EICAR-Test-File", Source: "eicar"}
+	if err := eng.AddSignature(ctx, sig); err != nil {
+		t.Fatalf("AddSignature() error: %v", err)
+	}
+
+	hash, _ := types.ParseHash(eicarSHA256)
+	if _, err := eng.Lookup(ctx, hash); err != nil {
+		t.Fatalf("Lookup() error: %v", err)
+	}
+
+	var found bool
+	for _, span := range exporter.GetSpans() {
+		if span.Name != "engine.lookup" {
+			continue
+		}
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, kv := range span.Attributes {
+			attrs[kv.Key] = kv.Value
+		}
+		if attrs["hash.type"].AsString() != "sha256" || attrs["lookup.status"].AsString() != types.StatusMalware.String() {
+			continue
+		}
+		found = true
+		if !attrs["lookup.bloom_hit"].AsBool() {
+			t.Error("lookup.bloom_hit = false, want true")
+		}
+	}
+	if !found {
+		t.Errorf("no engine.lookup span recorded for the malware lookup; got %d spans", len(exporter.GetSpans()))
+	}
+}
+
 // newTestEngine creates a new in-memory engine for testing.
 func newTestEngine(t *testing.T) *engine.Engine {
 	t.Helper()
+	return newTestEngineWithPolicy(t, engine.MergeOverwrite)
+}
+
+// newTestEngineWithPolicy creates a new in-memory engine using policy for
+// colliding signatures.
+func newTestEngineWithPolicy(t *testing.T, policy engine.MergePolicy) *engine.Engine {
+	t.Helper()
 
 	cfg := engine.EngineConfig{
 		StoreConfig: engine.StoreConfig{
@@ -288,6 +945,7 @@ func newTestEngine(t *testing.T) *engine.Engine {
 			ExpectedItems:     10000,
 			FalsePositiveRate: 0.01,
 		},
+		MergePolicy: policy,
 	}
 
 	eng, err := engine.NewEngine(cfg)
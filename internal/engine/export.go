@@ -0,0 +1,105 @@
+// ABOUTME: Streams every stored signature out as CSV or NDJSON
+// ABOUTME: The CSV layout can be re-imported with the feeds CSV importer
+
+package engine
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/types"
+)
+
+// Export formats accepted by Engine.Export.
+const (
+	// ExportFormatCSV writes one signature per line under ExportCSVHeader.
+	ExportFormatCSV = "csv"
+
+	// ExportFormatJSON writes one JSON-encoded signature per line (NDJSON).
+	ExportFormatJSON = "json"
+)
+
+// ExportCSVHeader is the header line of a CSV export. Its columns line up
+// with the feeds CSV importer: sha256 at 0, sha1 at 1, md5 at 2, the
+// detection name at 3, the severity at 4, the threat type at 5, the source
+// at 6, and the RFC 3339 first-seen time at 7.
+const ExportCSVHeader = "sha256,sha1,md5,detection_name,severity,threat_type,source,first_seen"
+
+// csvFieldReplacer strips characters the CSV importer cannot read back.
+// The importer splits on commas without quoting, so a comma inside a field
+// becomes a semicolon.
+var csvFieldReplacer = strings.NewReplacer(",", ";", "\r", " ", "\n", " ")
+
+// Export writes every signature in the store to w in the given format
+// ("csv", or "json"/"ndjson" for newline-delimited JSON) and returns the
+// number of signatures written. Signatures are streamed from the store, so
+// the export is never held in memory.
+func (e *Engine) Export(ctx context.Context, w io.Writer, format string) (int, error) {
+	bw := bufio.NewWriter(w)
+
+	var write func(sig *types.Signature) error
+	switch strings.ToLower(format) {
+	case ExportFormatCSV:
+		if _, err := bw.WriteString(ExportCSVHeader + "\n"); err != nil {
+			return 0, fmt.Errorf("failed to write export: %w", err)
+		}
+		write = func(sig *types.Signature) error {
+			_, err := bw.WriteString(csvExportLine(sig))
+			return err
+		}
+	case ExportFormatJSON, "ndjson":
+		enc := json.NewEncoder(bw)
+		write = func(sig *types.Signature) error {
+			return enc.Encode(sig)
+		}
+	default:
+		return 0, fmt.Errorf("unsupported export format: %s (available: csv, json)", format)
+	}
+
+	count := 0
+	err := e.store.IterateSignatures(ctx, func(sig *types.Signature) error {
+		if err := write(sig); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("failed to export signatures: %w", err)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return count, fmt.Errorf("failed to write export: %w", err)
+	}
+
+	return count, nil
+}
+
+// csvExportLine formats sig as one line of a CSV export.
+func csvExportLine(sig *types.Signature) string {
+	var firstSeen string
+	if !sig.FirstSeen.IsZero() {
+		firstSeen = sig.FirstSeen.UTC().Format(time.RFC3339)
+	}
+
+	fields := []string{
+		strings.ToLower(sig.SHA256),
+		strings.ToLower(sig.SHA1),
+		strings.ToLower(sig.MD5),
+		sig.DetectionName,
+		sig.Severity.String(),
+		sig.ThreatType.String(),
+		sig.Source,
+		firstSeen,
+	}
+	for i, field := range fields {
+		fields[i] = csvFieldReplacer.Replace(field)
+	}
+
+	return strings.Join(fields, ",") + "\n"
+}
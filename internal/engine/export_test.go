@@ -0,0 +1,186 @@
+// ABOUTME: Tests for exporting the signature database
+// ABOUTME: Verifies CSV re-imports through the feeds CSV parser and NDJSON fidelity
+
+package engine_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/engine"
+	"github.com/hikmaai-io/hikmaai-argus/internal/feeds"
+	"github.com/hikmaai-io/hikmaai-argus/internal/types"
+)
+
+// exportSignatures covers full, SHA1-only, and MD5-only signatures.
+func exportSignatures() []*types.Signature {
+	firstSeen := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return []*types.Signature{
+		{
+			SHA256:        eicarSHA256,
+			SHA1:          eicarSHA1,
+			MD5:           eicarMD5,
+			DetectionName: "EICAR-Test-File",
+			ThreatType:    types.ThreatTypeTestFile,
+			Severity:      types.SeverityLow,
+			Source:        "eicar",
+			FirstSeen:     firstSeen,
+			Tags:          []string{"test"},
+		},
+		{
+			SHA256:        hashFromInt(7),
+			DetectionName: "Win.Trojan.Agent",
+			ThreatType:    types.ThreatTypeTrojan,
+			Severity:      types.SeverityCritical,
+			Source:        "malwarebazaar",
+			FirstSeen:     firstSeen,
+		},
+		{
+			SHA1:          "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+			DetectionName: "Sha1.Only",
+			Severity:      types.SeverityHigh,
+			Source:        "vendor",
+			FirstSeen:     firstSeen,
+		},
+		{
+			MD5:           "d41d8cd98f00b204e9800998ecf8427e",
+			DetectionName: "Md5.Only",
+			Severity:      types.SeverityMedium,
+			Source:        "vendor",
+			FirstSeen:     firstSeen,
+		},
+	}
+}
+
+func TestEngine_Export_CSVRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	eng := newTestEngine(t)
+	want := exportSignatures()
+	if err := eng.BatchAddSignatures(ctx, want); err != nil {
+		t.Fatalf("BatchAddSignatures() error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	n, err := eng.Export(ctx, &buf, engine.ExportFormatCSV)
+	if err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	if n != len(want) {
+		t.Errorf("Export() = %d, want %d", n, len(want))
+	}
+	if !strings.HasPrefix(buf.String(), engine.ExportCSVHeader+"\n") {
+		t.Errorf("export does not start with the header: %q", buf.String())
+	}
+
+	// Re-import with the column layout documented on ExportCSVHeader.
+	feed := feeds.NewCSVFeed("import", feeds.CSVConfig{
+		SHA256Column:     0,
+		SHA1Column:       1,
+		MD5Column:        2,
+		DetectionColumn:  3,
+		SeverityColumn:   4,
+		ThreatTypeColumn: 5,
+		SourceColumn:     6,
+		FirstSeenColumn:  7,
+		SkipHeader:       true,
+	})
+	got, err := feed.Parse(ctx, &buf)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	imported := make(map[string]*types.Signature, len(got))
+	for _, sig := range got {
+		imported[sig.DetectionName] = sig
+	}
+	if len(imported) != len(want) {
+		t.Fatalf("re-imported %d signatures, want %d", len(imported), len(want))
+	}
+
+	for _, w := range want {
+		g, ok := imported[w.DetectionName]
+		if !ok {
+			t.Errorf("%s missing after re-import", w.DetectionName)
+			continue
+		}
+		if g.SHA256 != w.SHA256 || g.SHA1 != w.SHA1 || g.MD5 != w.MD5 {
+			t.Errorf("%s hashes = (%q, %q, %q), want (%q, %q, %q)",
+				w.DetectionName, g.SHA256, g.SHA1, g.MD5, w.SHA256, w.SHA1, w.MD5)
+		}
+		if g.Severity != w.Severity {
+			t.Errorf("%s severity = %v, want %v", w.DetectionName, g.Severity, w.Severity)
+		}
+		if g.ThreatType != w.ThreatType {
+			t.Errorf("%s threat type = %v, want %v", w.DetectionName, g.ThreatType, w.ThreatType)
+		}
+		if g.Source != w.Source {
+			t.Errorf("%s source = %q, want %q", w.DetectionName, g.Source, w.Source)
+		}
+		if !g.FirstSeen.Equal(w.FirstSeen) {
+			t.Errorf("%s first seen = %v, want %v", w.DetectionName, g.FirstSeen, w.FirstSeen)
+		}
+	}
+}
+
+func TestEngine_Export_JSON(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	eng := newTestEngine(t)
+	want := exportSignatures()
+	if err := eng.BatchAddSignatures(ctx, want); err != nil {
+		t.Fatalf("BatchAddSignatures() error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	n, err := eng.Export(ctx, &buf, engine.ExportFormatJSON)
+	if err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	if n != len(want) {
+		t.Errorf("Export() = %d, want %d", n, len(want))
+	}
+
+	got := make(map[string]types.Signature)
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var sig types.Signature
+		if err := json.Unmarshal(scanner.Bytes(), &sig); err != nil {
+			t.Fatalf("decoding line %q: %v", scanner.Text(), err)
+		}
+		got[sig.DetectionName] = sig
+	}
+	if len(got) != len(want) {
+		t.Fatalf("decoded %d signatures, want %d", len(got), len(want))
+	}
+
+	for _, w := range want {
+		g := got[w.DetectionName]
+		wantJSON, _ := json.Marshal(w)
+		gotJSON, _ := json.Marshal(g)
+		if !bytes.Equal(gotJSON, wantJSON) {
+			t.Errorf("exported %s, want %s", gotJSON, wantJSON)
+		}
+	}
+}
+
+func TestEngine_Export_UnsupportedFormat(t *testing.T) {
+	t.Parallel()
+
+	eng := newTestEngine(t)
+
+	var buf bytes.Buffer
+	if _, err := eng.Export(context.Background(), &buf, "xml"); err == nil {
+		t.Error("Export(xml) should fail")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Export(xml) wrote %q, want nothing", buf.String())
+	}
+}
@@ -1,12 +1,17 @@
 // ABOUTME: JobStore persists scan jobs in BadgerDB
-// ABOUTME: Provides CRUD operations and status-based queries for async scan jobs
+// ABOUTME: Provides CRUD operations and paginated, filtered listing of async scan jobs
 
 package engine
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dgraph-io/badger/v4"
@@ -17,8 +22,46 @@ import (
 const (
 	jobPrefix     = "job:"
 	jobHashPrefix = "job-hash:"
+
+	// jobTimePrefix indexes jobs by creation time so listing iterates keys
+	// in time order: job-time:<16 hex digits of UnixNano>:<id>.
+	jobTimePrefix = "job-time:"
 )
 
+// ErrInvalidCursor is returned by JobStore.List for a malformed cursor.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ListOptions filters and paginates JobStore.List.
+type ListOptions struct {
+	// Statuses keeps only jobs in one of these states; empty keeps all.
+	Statuses []types.JobStatus
+
+	// Since and Until bound the job creation time (inclusive); zero values
+	// leave that end open.
+	Since time.Time
+	Until time.Time
+
+	// Limit caps the number of jobs returned; zero or less returns all.
+	Limit int
+
+	// Cursor continues a previous listing from the token it returned.
+	Cursor string
+}
+
+// jobTimeKey returns the creation-time index key for job.
+func jobTimeKey(job *types.Job) []byte {
+	return []byte(fmt.Sprintf("%s%016x:%s", jobTimePrefix, jobTimeNanos(job.CreatedAt), job.ID))
+}
+
+// jobTimeNanos returns t as nanoseconds since the epoch, clamped at zero
+// so the index sorts correctly.
+func jobTimeNanos(t time.Time) uint64 {
+	if t.IsZero() || t.UnixNano() < 0 {
+		return 0
+	}
+	return uint64(t.UnixNano())
+}
+
 // JobStore provides persistence for scan jobs.
 type JobStore struct {
 	db *badger.DB
@@ -47,7 +90,54 @@ func NewJobStore(cfg StoreConfig) (*JobStore, error) {
 		return nil, fmt.Errorf("opening badger db: %w", err)
 	}
 
-	return &JobStore{db: db}, nil
+	s := &JobStore{db: db}
+	if err := s.indexCreationTimes(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("indexing jobs: %w", err)
+	}
+
+	return s, nil
+}
+
+// indexCreationTimes adds creation-time index keys for jobs stored before
+// the index existed.
+func (s *JobStore) indexCreationTimes() error {
+	var missing [][]byte
+
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(jobPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			var job types.Job
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &job)
+			})
+			if err != nil {
+				continue // Skip malformed entries.
+			}
+
+			key := jobTimeKey(&job)
+			if _, err := txn.Get(key); errors.Is(err, badger.ErrKeyNotFound) {
+				missing = append(missing, key)
+			}
+		}
+		return nil
+	})
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, key := range missing {
+		if err := wb.Set(key, nil); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
 }
 
 // Close closes the database.
@@ -84,6 +174,11 @@ func (s *JobStore) Create(ctx context.Context, job *types.Job) error {
 			}
 		}
 
+		// Store index by creation time for listing.
+		if err := txn.Set(jobTimeKey(job), nil); err != nil {
+			return fmt.Errorf("setting time index: %w", err)
+		}
+
 		return nil
 	})
 }
@@ -155,6 +250,7 @@ func (s *JobStore) Delete(ctx context.Context, id string) error {
 				hashKey := jobHashPrefix + job.FileHash
 				txn.Delete([]byte(hashKey))
 			}
+			txn.Delete(jobTimeKey(&job))
 			return nil
 		})
 		if err != nil {
@@ -166,47 +262,126 @@ func (s *JobStore) Delete(ctx context.Context, id string) error {
 	})
 }
 
-// List returns jobs, optionally filtered by status.
-// If no status is provided, all jobs are returned.
-func (s *JobStore) List(ctx context.Context, statuses ...types.JobStatus) ([]*types.Job, error) {
-	var jobs []*types.Job
+// List returns jobs newest first, filtered by opts. When more jobs match
+// than opts.Limit, the returned cursor continues the listing; it is empty
+// on the last page.
+func (s *JobStore) List(ctx context.Context, opts ListOptions) ([]*types.Job, string, error) {
+	var after []byte
+	if opts.Cursor != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(opts.Cursor)
+		if err != nil || !bytes.HasPrefix(decoded, []byte(jobTimePrefix)) {
+			return nil, "", ErrInvalidCursor
+		}
+		after = decoded
+	}
 
 	statusSet := make(map[types.JobStatus]bool)
-	for _, status := range statuses {
+	for _, status := range opts.Statuses {
 		statusSet[status] = true
 	}
 
+	var (
+		jobs    []*types.Job
+		lastKey []byte
+		more    bool
+	)
+
 	err := s.db.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.Prefix = []byte(jobPrefix)
-		it := txn.NewIterator(opts)
+		itOpts := badger.DefaultIteratorOptions
+		itOpts.PrefetchValues = false
+		itOpts.Prefix = []byte(jobTimePrefix)
+		itOpts.Reverse = true
+		it := txn.NewIterator(itOpts)
 		defer it.Close()
 
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
+		// Reverse iteration starts at the last key not after the seek key.
+		seek := after
+		if seek == nil {
+			seek = append([]byte(jobTimePrefix), 0xff)
+		}
 
-			err := item.Value(func(val []byte) error {
-				var job types.Job
-				if err := json.Unmarshal(val, &job); err != nil {
-					return nil // Skip malformed entries.
-				}
+		for it.Seek(seek); it.Valid(); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 
-				// Filter by status if specified.
-				if len(statusSet) > 0 && !statusSet[job.Status] {
-					return nil
-				}
+			key := it.Item().Key()
+			if after != nil && bytes.Equal(key, after) {
+				continue
+			}
 
-				jobs = append(jobs, &job)
-				return nil
-			})
+			created, id, ok := parseJobTimeKey(key)
+			if !ok {
+				continue
+			}
+			if !opts.Until.IsZero() && created > jobTimeNanos(opts.Until) {
+				continue
+			}
+			if !opts.Since.IsZero() && created < jobTimeNanos(opts.Since) {
+				break // Older keys only from here on.
+			}
+
+			job, err := getJob(txn, id)
 			if err != nil {
 				return err
 			}
+			if job == nil || (len(statusSet) > 0 && !statusSet[job.Status]) {
+				continue
+			}
+
+			if opts.Limit > 0 && len(jobs) == opts.Limit {
+				more = true
+				break
+			}
+			jobs = append(jobs, job)
+			lastKey = it.Item().KeyCopy(nil)
 		}
 		return nil
 	})
+	if err != nil {
+		return nil, "", err
+	}
 
-	return jobs, err
+	var next string
+	if more {
+		next = base64.RawURLEncoding.EncodeToString(lastKey)
+	}
+
+	return jobs, next, nil
+}
+
+// parseJobTimeKey splits a creation-time index key into its time and job ID.
+func parseJobTimeKey(key []byte) (uint64, string, bool) {
+	rest := strings.TrimPrefix(string(key), jobTimePrefix)
+	nanos, id, ok := strings.Cut(rest, ":")
+	if !ok {
+		return 0, "", false
+	}
+	created, err := strconv.ParseUint(nanos, 16, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return created, id, true
+}
+
+// getJob reads a job within txn, returning nil if it does not exist.
+func getJob(txn *badger.Txn, id string) (*types.Job, error) {
+	item, err := txn.Get([]byte(jobPrefix + id))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting job: %w", err)
+	}
+
+	var job types.Job
+	err = item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &job)
+	})
+	if err != nil {
+		return nil, nil // Skip malformed entries.
+	}
+	return &job, nil
 }
 
 // GetByFileHash finds a job by file hash.
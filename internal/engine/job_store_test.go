@@ -1,10 +1,13 @@
 // ABOUTME: Tests for JobStore that persists scan jobs in BadgerDB
-// ABOUTME: Validates CRUD operations and status-based listing
+// ABOUTME: Validates CRUD operations and filtered, paginated listing
 
 package engine
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"testing"
 	"time"
 
@@ -125,7 +128,7 @@ func TestJobStore_List_All(t *testing.T) {
 	store.Create(context.Background(), job3)
 
 	// List all jobs.
-	jobs, err := store.List(context.Background())
+	jobs, _, err := store.List(context.Background(), ListOptions{})
 	if err != nil {
 		t.Fatalf("List() error = %v", err)
 	}
@@ -155,7 +158,7 @@ func TestJobStore_List_ByStatus(t *testing.T) {
 	store.Create(context.Background(), job3)
 
 	// List only pending jobs.
-	pending, err := store.List(context.Background(), types.JobStatusPending)
+	pending, _, err := store.List(context.Background(), ListOptions{Statuses: []types.JobStatus{types.JobStatusPending}})
 	if err != nil {
 		t.Fatalf("List(pending) error = %v", err)
 	}
@@ -164,7 +167,7 @@ func TestJobStore_List_ByStatus(t *testing.T) {
 	}
 
 	// List only running jobs.
-	running, err := store.List(context.Background(), types.JobStatusRunning)
+	running, _, err := store.List(context.Background(), ListOptions{Statuses: []types.JobStatus{types.JobStatusRunning}})
 	if err != nil {
 		t.Fatalf("List(running) error = %v", err)
 	}
@@ -173,7 +176,7 @@ func TestJobStore_List_ByStatus(t *testing.T) {
 	}
 
 	// List only completed jobs.
-	completed, err := store.List(context.Background(), types.JobStatusCompleted)
+	completed, _, err := store.List(context.Background(), ListOptions{Statuses: []types.JobStatus{types.JobStatusCompleted}})
 	if err != nil {
 		t.Fatalf("List(completed) error = %v", err)
 	}
@@ -182,6 +185,103 @@ func TestJobStore_List_ByStatus(t *testing.T) {
 	}
 }
 
+func TestJobStore_List_Pagination(t *testing.T) {
+	t.Parallel()
+
+	store := setupTestJobStore(t)
+	ctx := context.Background()
+
+	// Five jobs a minute apart, created out of order; odd ones completed.
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ids := make([]string, 5)
+	for _, i := range []int{3, 0, 4, 1, 2} {
+		job := types.NewJob(fmt.Sprintf("hash%d", i), "file.exe", 1024)
+		job.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if i%2 == 1 {
+			job.Start()
+			job.Complete(types.NewCleanScanResult("/path", job.FileHash, 1024))
+		}
+		if err := store.Create(ctx, job); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		ids[i] = job.ID
+	}
+
+	tests := []struct {
+		name  string
+		opts  ListOptions
+		pages [][]string
+	}{
+		{
+			name:  "newest first",
+			opts:  ListOptions{},
+			pages: [][]string{{ids[4], ids[3], ids[2], ids[1], ids[0]}},
+		},
+		{
+			name:  "limit with cursor",
+			opts:  ListOptions{Limit: 2},
+			pages: [][]string{{ids[4], ids[3]}, {ids[2], ids[1]}, {ids[0]}},
+		},
+		{
+			name:  "limit equal to matches",
+			opts:  ListOptions{Limit: 5},
+			pages: [][]string{{ids[4], ids[3], ids[2], ids[1], ids[0]}},
+		},
+		{
+			name:  "status filter",
+			opts:  ListOptions{Statuses: []types.JobStatus{types.JobStatusPending}, Limit: 2},
+			pages: [][]string{{ids[4], ids[2]}, {ids[0]}},
+		},
+		{
+			name: "time range",
+			opts: ListOptions{
+				Since: base.Add(1 * time.Minute),
+				Until: base.Add(3 * time.Minute),
+			},
+			pages: [][]string{{ids[3], ids[2], ids[1]}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := tt.opts
+			for i, want := range tt.pages {
+				jobs, next, err := store.List(ctx, opts)
+				if err != nil {
+					t.Fatalf("page %d: List() error = %v", i, err)
+				}
+
+				got := make([]string, len(jobs))
+				for j, job := range jobs {
+					got[j] = job.ID
+				}
+				if !slices.Equal(got, want) {
+					t.Errorf("page %d = %v, want %v", i, got, want)
+				}
+
+				last := i == len(tt.pages)-1
+				if last != (next == "") {
+					t.Fatalf("page %d: cursor = %q, want last page = %v", i, next, last)
+				}
+				opts.Cursor = next
+			}
+		})
+	}
+}
+
+func TestJobStore_List_InvalidCursor(t *testing.T) {
+	t.Parallel()
+
+	store := setupTestJobStore(t)
+
+	_, _, err := store.List(context.Background(), ListOptions{Cursor: "not a cursor"})
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("List() error = %v, want ErrInvalidCursor", err)
+	}
+}
+
 func TestJobStore_GetByFileHash(t *testing.T) {
 	t.Parallel()
 
@@ -1,12 +1,16 @@
 // ABOUTME: ScanCache caches scan results by file hash in BadgerDB
-// ABOUTME: Avoids re-scanning files with TTL-based expiration
+// ABOUTME: Avoids re-scanning files with TTL expiry and an optional LRU size cap
 
 package engine
 
 import (
+	"container/list"
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/dgraph-io/badger/v4"
@@ -14,16 +18,54 @@ import (
 	"github.com/hikmaai-io/hikmaai-argus/internal/types"
 )
 
-const scanCachePrefix = "scan:"
+const (
+	scanCachePrefix = "scan:"
+
+	// scanAccessPrefix keys hold the last access time of each cached
+	// result, so the LRU order survives restarts.
+	scanAccessPrefix = "scan-access:"
+)
+
+// ScanCacheConfig holds configuration for the scan cache.
+type ScanCacheConfig struct {
+	// Store configures the underlying BadgerDB.
+	Store StoreConfig
+
+	// TTL expires cached results; zero keeps them until evicted.
+	TTL time.Duration
+
+	// MaxEntries caps the number of cached results. When a Put exceeds the
+	// cap, the least recently used results are evicted. Zero means no cap.
+	MaxEntries int
+}
 
 // ScanCache caches scan results by file hash.
 type ScanCache struct {
-	db  *badger.DB
-	ttl time.Duration
+	db         *badger.DB
+	ttl        time.Duration
+	maxEntries int
+
+	// lru orders cached hashes from most to least recently used.
+	mu    sync.Mutex
+	lru   *list.List
+	index map[string]*list.Element
+}
+
+// lruEntry is an element of ScanCache.lru.
+type lruEntry struct {
+	hash       string
+	accessedAt time.Time
 }
 
-// NewScanCache creates a new scan cache.
+// NewScanCache creates a new scan cache without a size cap.
 func NewScanCache(cfg StoreConfig, ttl time.Duration) (*ScanCache, error) {
+	return NewScanCacheWithConfig(ScanCacheConfig{Store: cfg, TTL: ttl})
+}
+
+// NewScanCacheWithConfig creates a new scan cache, restoring the LRU order
+// of results already in the database.
+func NewScanCacheWithConfig(scfg ScanCacheConfig) (*ScanCache, error) {
+	cfg := scfg.Store
 	opts := badger.DefaultOptions(cfg.Path)
 
 	if cfg.InMemory {
@@ -45,10 +87,77 @@ func NewScanCache(cfg StoreConfig, ttl time.Duration) (*ScanCache, error) {
 		return nil, fmt.Errorf("opening badger db: %w", err)
 	}
 
-	return &ScanCache{
-		db:  db,
-		ttl: ttl,
-	}, nil
+	c := &ScanCache{
+		db:         db,
+		ttl:        scfg.TTL,
+		maxEntries: scfg.MaxEntries,
+		lru:        list.New(),
+		index:      make(map[string]*list.Element),
+	}
+	if err := c.loadLRU(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("loading cache index: %w", err)
+	}
+
+	return c, nil
+}
+
+// loadLRU rebuilds the in-memory LRU list from the database. Results
+// without a recorded access time sort as least recently used; access
+// times left behind by expired results are removed.
+func (c *ScanCache) loadLRU() error {
+	var entries []lruEntry
+	var orphans [][]byte
+
+	err := c.db.View(func(txn *badger.Txn) error {
+		accessed := make(map[string]time.Time)
+
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: []byte(scanAccessPrefix), PrefetchValues: true})
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			hash := string(item.Key()[len(scanAccessPrefix):])
+			err := item.Value(func(val []byte) error {
+				if len(val) == 8 {
+					accessed[hash] = time.Unix(0, int64(binary.BigEndian.Uint64(val)))
+				}
+				return nil
+			})
+			if err != nil {
+				it.Close()
+				return err
+			}
+		}
+		it.Close()
+
+		it = txn.NewIterator(badger.IteratorOptions{Prefix: []byte(scanCachePrefix)})
+		for it.Rewind(); it.Valid(); it.Next() {
+			hash := string(it.Item().Key()[len(scanCachePrefix):])
+			entries = append(entries, lruEntry{hash: hash, accessedAt: accessed[hash]})
+			delete(accessed, hash)
+		}
+		it.Close()
+
+		for hash := range accessed {
+			orphans = append(orphans, []byte(scanAccessPrefix+hash))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Most recently used first.
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].accessedAt.After(entries[j].accessedAt)
+	})
+	for _, e := range entries {
+		c.index[e.hash] = c.lru.PushBack(&lruEntry{hash: e.hash, accessedAt: e.accessedAt})
+	}
+
+	if len(orphans) > 0 {
+		return c.deleteKeys(orphans)
+	}
+	return nil
 }
 
 // Close closes the database.
@@ -70,7 +179,8 @@ func (c *ScanCache) Put(ctx context.Context, fileHash string, result *types.Scan
 		return fmt.Errorf("marshaling result: %w", err)
 	}
 
-	return c.db.Update(func(txn *badger.Txn) error {
+	now := time.Now()
+	err = c.db.Update(func(txn *badger.Txn) error {
 		key := scanCachePrefix + fileHash
 		entry := badger.NewEntry([]byte(key), data)
 
@@ -78,8 +188,92 @@ func (c *ScanCache) Put(ctx context.Context, fileHash string, result *types.Scan
 			entry = entry.WithTTL(c.ttl)
 		}
 
-		return txn.SetEntry(entry)
+		if err := txn.SetEntry(entry); err != nil {
+			return err
+		}
+		return txn.Set(accessKey(fileHash), accessValue(now))
 	})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.touch(fileHash, now)
+	victims := c.evictLocked()
+	c.mu.Unlock()
+
+	if len(victims) > 0 {
+		if err := c.deleteEntries(victims); err != nil {
+			return fmt.Errorf("evicting cache entries: %w", err)
+		}
+	}
+	return nil
+}
+
+// touch marks fileHash as the most recently used result. The caller must
+// hold c.mu.
+func (c *ScanCache) touch(fileHash string, at time.Time) {
+	if elem, ok := c.index[fileHash]; ok {
+		elem.Value.(*lruEntry).accessedAt = at
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.index[fileHash] = c.lru.PushFront(&lruEntry{hash: fileHash, accessedAt: at})
+}
+
+// forget drops fileHash from the LRU list. The caller must hold c.mu.
+func (c *ScanCache) forget(fileHash string) {
+	if elem, ok := c.index[fileHash]; ok {
+		c.lru.Remove(elem)
+		delete(c.index, fileHash)
+	}
+}
+
+// evictLocked removes the least recently used results from the LRU list
+// until it fits MaxEntries and returns their hashes for deletion from the
+// database. The caller must hold c.mu.
+func (c *ScanCache) evictLocked() []string {
+	if c.maxEntries <= 0 {
+		return nil
+	}
+
+	var victims []string
+	for c.lru.Len() > c.maxEntries {
+		hash := c.lru.Back().Value.(*lruEntry).hash
+		c.forget(hash)
+		victims = append(victims, hash)
+	}
+	return victims
+}
+
+// deleteEntries removes the results and access times of hashes.
+func (c *ScanCache) deleteEntries(hashes []string) error {
+	keys := make([][]byte, 0, 2*len(hashes))
+	for _, hash := range hashes {
+		keys = append(keys, []byte(scanCachePrefix+hash), accessKey(hash))
+	}
+	return c.deleteKeys(keys)
+}
+
+// deleteKeys removes keys in a single write batch.
+func (c *ScanCache) deleteKeys(keys [][]byte) error {
+	wb := c.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	for _, key := range keys {
+		if err := wb.Delete(key); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+func accessKey(fileHash string) []byte {
+	return []byte(scanAccessPrefix + fileHash)
+}
+
+func accessValue(at time.Time) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(at.UnixNano()))
 }
 
 // Get retrieves a cached scan result.
@@ -110,24 +304,60 @@ func (c *ScanCache) Get(ctx context.Context, fileHash string) (*types.ScanResult
 		return nil, false, err
 	}
 
-	return result, result != nil, nil
+	if result == nil {
+		// Drop results that expired since they were last used.
+		c.mu.Lock()
+		_, tracked := c.index[fileHash]
+		c.forget(fileHash)
+		c.mu.Unlock()
+		if tracked {
+			if err := c.deleteKeys([][]byte{accessKey(fileHash)}); err != nil {
+				return nil, false, fmt.Errorf("deleting cache access time: %w", err)
+			}
+		}
+		return nil, false, nil
+	}
+
+	now := time.Now()
+	if err := c.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(accessKey(fileHash), accessValue(now))
+	}); err != nil {
+		return nil, false, fmt.Errorf("recording cache access: %w", err)
+	}
+
+	c.mu.Lock()
+	c.touch(fileHash, now)
+	c.mu.Unlock()
+
+	return result, true, nil
 }
 
 // Delete removes a cached result.
 func (c *ScanCache) Delete(ctx context.Context, fileHash string) error {
-	return c.db.Update(func(txn *badger.Txn) error {
-		key := scanCachePrefix + fileHash
-		err := txn.Delete([]byte(key))
-		if err == badger.ErrKeyNotFound {
-			return nil
-		}
-		return err
-	})
+	c.mu.Lock()
+	c.forget(fileHash)
+	c.mu.Unlock()
+
+	return c.deleteEntries([]string{fileHash})
 }
 
 // Clear removes all cached results.
 func (c *ScanCache) Clear(ctx context.Context) error {
-	return c.db.DropPrefix([]byte(scanCachePrefix))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.Init()
+	clear(c.index)
+	return c.db.DropPrefix([]byte(scanCachePrefix), []byte(scanAccessPrefix))
+}
+
+// Len returns the number of results tracked by the LRU list. Unlike Count
+// it does not touch the database, but it includes results whose TTL has
+// expired and that have not been looked up since.
+func (c *ScanCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
 }
 
 // Count returns the number of cached results.
@@ -154,3 +384,8 @@ func (c *ScanCache) Count(ctx context.Context) (int64, error) {
 func (c *ScanCache) TTL() time.Duration {
 	return c.ttl
 }
+
+// MaxEntries returns the cache size cap, or zero if there is none.
+func (c *ScanCache) MaxEntries() int {
+	return c.maxEntries
+}
@@ -1,5 +1,5 @@
 // ABOUTME: Tests for ScanCache that caches scan results by file hash
-// ABOUTME: Validates get/put operations and TTL-based expiration
+// ABOUTME: Validates get/put operations, TTL-based expiration, and LRU eviction
 
 package engine
 
@@ -226,6 +226,92 @@ func TestScanCache_Update(t *testing.T) {
 	}
 }
 
+func TestScanCache_MaxEntries_EvictsLRU(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cache, err := NewScanCacheWithConfig(ScanCacheConfig{
+		Store:      StoreConfig{InMemory: true},
+		TTL:        24 * time.Hour,
+		MaxEntries: 3,
+	})
+	if err != nil {
+		t.Fatalf("NewScanCacheWithConfig() error = %v", err)
+	}
+	t.Cleanup(func() { cache.Close() })
+
+	for _, hash := range []string{"hash1", "hash2", "hash3"} {
+		if err := cache.Put(ctx, hash, types.NewCleanScanResult("/"+hash, hash, 1)); err != nil {
+			t.Fatalf("Put(%q) error = %v", hash, err)
+		}
+	}
+
+	// Using hash1 makes hash2 the least recently used.
+	if _, found, _ := cache.Get(ctx, "hash1"); !found {
+		t.Fatal("Get(hash1) found = false, want true")
+	}
+
+	for _, hash := range []string{"hash4", "hash5"} {
+		if err := cache.Put(ctx, hash, types.NewCleanScanResult("/"+hash, hash, 1)); err != nil {
+			t.Fatalf("Put(%q) error = %v", hash, err)
+		}
+	}
+
+	if got := cache.Len(); got != 3 {
+		t.Errorf("Len() = %d, want 3", got)
+	}
+	if count, _ := cache.Count(ctx); count != 3 {
+		t.Errorf("Count() = %d, want 3", count)
+	}
+
+	want := map[string]bool{"hash1": true, "hash2": false, "hash3": false, "hash4": true, "hash5": true}
+	for hash, wantFound := range want {
+		if _, found, _ := cache.Get(ctx, hash); found != wantFound {
+			t.Errorf("Get(%q) found = %v, want %v", hash, found, wantFound)
+		}
+	}
+}
+
+func TestScanCache_MaxEntries_OrderSurvivesRestart(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	cache, err := NewScanCacheWithConfig(ScanCacheConfig{Store: StoreConfig{Path: dir}})
+	if err != nil {
+		t.Fatalf("NewScanCacheWithConfig() error = %v", err)
+	}
+	for _, hash := range []string{"hash1", "hash2", "hash3"} {
+		cache.Put(ctx, hash, types.NewCleanScanResult("/"+hash, hash, 1))
+	}
+	cache.Get(ctx, "hash1")
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Reopen with a cap of two: the next Put evicts hash2 and hash3.
+	cache, err = NewScanCacheWithConfig(ScanCacheConfig{Store: StoreConfig{Path: dir}, MaxEntries: 2})
+	if err != nil {
+		t.Fatalf("NewScanCacheWithConfig() error = %v", err)
+	}
+	t.Cleanup(func() { cache.Close() })
+
+	if got := cache.Len(); got != 3 {
+		t.Errorf("Len() after reopen = %d, want 3", got)
+	}
+	if err := cache.Put(ctx, "hash4", types.NewCleanScanResult("/hash4", "hash4", 1)); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	want := map[string]bool{"hash1": true, "hash2": false, "hash3": false, "hash4": true}
+	for hash, wantFound := range want {
+		if _, found, _ := cache.Get(ctx, hash); found != wantFound {
+			t.Errorf("Get(%q) found = %v, want %v", hash, found, wantFound)
+		}
+	}
+}
+
 // setupTestScanCache creates an in-memory scan cache for testing.
 func setupTestScanCache(t *testing.T, ttl time.Duration) *ScanCache {
 	t.Helper()
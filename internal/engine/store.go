@@ -7,7 +7,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/dgraph-io/badger/v4"
 
@@ -41,6 +43,12 @@ type StoreStats struct {
 	LSMLevels int
 }
 
+// catalogPrefix is the key prefix of signatures without hashes, such as
+// ClamAV body signature catalog entries. They are kept out of the hash
+// keyspace, so lookups, the bloom filter, and signature counts never see
+// them.
+const catalogPrefix = "catalog:"
+
 // Store wraps BadgerDB for signature storage.
 type Store struct {
 	db     *badger.DB
@@ -173,6 +181,169 @@ func (s *Store) Delete(ctx context.Context, hash types.Hash) error {
 	})
 }
 
+// DeleteSignature removes the signature stored under hash along with its
+// entries under its other hash types. It returns the removed signature, or
+// nil if no signature is stored under hash.
+func (s *Store) DeleteSignature(ctx context.Context, hash types.Hash) (*types.Signature, error) {
+	var sig *types.Signature
+
+	err := s.db.Update(func(txn *badger.Txn) error {
+		key := hash.Key()
+		item, err := txn.Get([]byte(key))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get key %s: %w", key, err)
+		}
+
+		err = item.Value(func(val []byte) error {
+			sig = &types.Signature{}
+			return json.Unmarshal(val, sig)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal signature: %w", err)
+		}
+
+		// Delete the requested key too, in case it is not among the
+		// signature's own hashes.
+		for _, k := range append(s.keysForSignature(sig), key) {
+			if err := txn.Delete([]byte(k)); err != nil {
+				return fmt.Errorf("failed to delete key %s: %w", k, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sig, nil
+}
+
+// DeleteBySource removes every signature whose only source is source and
+// returns the number of signatures removed along with their removed
+// hashes. Signatures merged from several feeds are recomputed from the
+// contributions of their other sources, dropping any hash, detection name,
+// or severity only source supplied.
+func (s *Store) DeleteBySource(ctx context.Context, source string) (int, []types.Hash, error) {
+	return s.rewriteMatching(ctx, func(sig *types.Signature) (*types.Signature, bool) {
+		if !sig.HasSource(source) {
+			return nil, false
+		}
+		return sig.WithoutSource(source), true
+	})
+}
+
+// DeleteExpired removes every signature that has expired as of now and
+// returns the number of signatures removed along with their hashes.
+func (s *Store) DeleteExpired(ctx context.Context, now time.Time) (int, []types.Hash, error) {
+	return s.deleteMatching(ctx, func(sig *types.Signature) bool {
+		return sig.IsExpired(now)
+	})
+}
+
+// deleteMatching removes every signature for which match returns true and
+// returns the number of signatures removed along with their hashes.
+func (s *Store) deleteMatching(ctx context.Context, match func(sig *types.Signature) bool) (int, []types.Hash, error) {
+	return s.rewriteMatching(ctx, func(sig *types.Signature) (*types.Signature, bool) {
+		return nil, match(sig)
+	})
+}
+
+// rewriteMatching replaces, in a single pass, every signature for which
+// rewrite reports true with the signature it returns, deleting it when that
+// is nil. Keys whose hash the replacement no longer carries are deleted.
+// It returns the number of signatures deleted along with the removed hashes.
+func (s *Store) rewriteMatching(ctx context.Context, rewrite func(sig *types.Signature) (*types.Signature, bool)) (int, []types.Hash, error) {
+	var (
+		keys   [][]byte
+		hashes []types.Hash
+		count  int
+	)
+	updates := make(map[string][]byte)
+
+	err := s.db.View(func(txn *badger.Txn) error {
+		// HashTypeUnknown stands for the catalog, whose keys are not hashes.
+		for _, hashType := range []types.HashType{types.HashTypeSHA256, types.HashTypeSHA1, types.HashTypeMD5, types.HashTypeUnknown} {
+			prefix := []byte(hashType.String() + ":")
+			if hashType == types.HashTypeUnknown {
+				prefix = []byte(catalogPrefix)
+			}
+			it := txn.NewIterator(badger.IteratorOptions{Prefix: prefix, PrefetchValues: true, PrefetchSize: 100})
+
+			for it.Rewind(); it.Valid(); it.Next() {
+				if err := ctx.Err(); err != nil {
+					it.Close()
+					return err
+				}
+
+				item := it.Item()
+				var sig types.Signature
+				err := item.Value(func(val []byte) error {
+					return json.Unmarshal(val, &sig)
+				})
+				if err != nil {
+					continue // Skip malformed entries.
+				}
+				replacement, ok := rewrite(&sig)
+				if !ok {
+					continue
+				}
+
+				key := item.KeyCopy(nil)
+				if replacement != nil && slices.Contains(s.keysForSignature(replacement), string(key)) {
+					data, err := json.Marshal(replacement)
+					if err != nil {
+						it.Close()
+						return fmt.Errorf("failed to marshal signature: %w", err)
+					}
+					updates[string(key)] = data
+					continue
+				}
+
+				keys = append(keys, key)
+				if hashType != types.HashTypeUnknown {
+					hashes = append(hashes, types.Hash{Type: hashType, Value: strings.TrimPrefix(string(key), string(prefix))})
+				}
+
+				// Count each deleted signature once, at its first storage key.
+				if sigKeys := s.keysForSignature(&sig); replacement == nil && len(sigKeys) > 0 && sigKeys[0] == string(key) {
+					count++
+				}
+			}
+			it.Close()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to scan signatures: %w", err)
+	}
+
+	if len(keys) == 0 && len(updates) == 0 {
+		return 0, nil, nil
+	}
+
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	for _, key := range keys {
+		if err := wb.Delete(key); err != nil {
+			return 0, nil, fmt.Errorf("failed to delete key %s: %w", key, err)
+		}
+	}
+	for key, data := range updates {
+		if err := wb.Set([]byte(key), data); err != nil {
+			return 0, nil, fmt.Errorf("failed to set key %s: %w", key, err)
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		return 0, nil, fmt.Errorf("failed to flush writes: %w", err)
+	}
+
+	return count, hashes, nil
+}
+
 // Stats returns statistics about the store.
 func (s *Store) Stats(ctx context.Context) (*StoreStats, error) {
 	stats := &StoreStats{}
@@ -223,23 +394,119 @@ func (s *Store) IterateHashes(ctx context.Context, hashType types.HashType, fn f
 	})
 }
 
+// IterateSignatures calls fn once for every signature in the store, in key
+// order. Signatures are decoded one at a time, so memory use does not grow
+// with the size of the store. Malformed entries are skipped.
+func (s *Store) IterateSignatures(ctx context.Context, fn func(sig *types.Signature) error) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		for _, hashType := range []types.HashType{types.HashTypeSHA256, types.HashTypeSHA1, types.HashTypeMD5} {
+			prefix := []byte(hashType.String() + ":")
+			it := txn.NewIterator(badger.IteratorOptions{Prefix: prefix, PrefetchValues: true, PrefetchSize: 100})
+
+			for it.Rewind(); it.Valid(); it.Next() {
+				if err := ctx.Err(); err != nil {
+					it.Close()
+					return err
+				}
+
+				item := it.Item()
+				var sig types.Signature
+				err := item.Value(func(val []byte) error {
+					return json.Unmarshal(val, &sig)
+				})
+				if err != nil {
+					continue
+				}
+
+				// Visit each signature once, at its first storage key.
+				if sigKeys := s.keysForSignature(&sig); len(sigKeys) == 0 || sigKeys[0] != string(item.Key()) {
+					continue
+				}
+				if err := fn(&sig); err != nil {
+					it.Close()
+					return err
+				}
+			}
+			it.Close()
+		}
+		return nil
+	})
+}
+
+// CountHashes returns the number of hash keys of every type in the store,
+// which is the number of entries a rebuilt bloom filter holds. Only keys
+// are read, so this is much cheaper than a rebuild.
+func (s *Store) CountHashes(ctx context.Context) (int64, error) {
+	var count int64
+
+	err := s.db.View(func(txn *badger.Txn) error {
+		for _, hashType := range []types.HashType{types.HashTypeSHA256, types.HashTypeSHA1, types.HashTypeMD5} {
+			prefix := []byte(hashType.String() + ":")
+			it := txn.NewIterator(badger.IteratorOptions{Prefix: prefix})
+			for it.Rewind(); it.Valid(); it.Next() {
+				count++
+			}
+			it.Close()
+
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return count, err
+}
+
 // Compact triggers garbage collection on the database.
 func (s *Store) Compact() error {
 	return s.db.RunValueLogGC(0.5)
 }
 
-// keysForSignature returns all storage keys for a signature.
+// IterateCatalog calls fn for each stored signature without hashes.
+func (s *Store) IterateCatalog(ctx context.Context, fn func(sig *types.Signature) error) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: []byte(catalogPrefix), PrefetchValues: true, PrefetchSize: 100})
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			var sig types.Signature
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &sig)
+			})
+			if err != nil {
+				continue
+			}
+			if err := fn(&sig); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// keysForSignature returns all storage keys for a signature. Hash values
+// are lowercased to match the normalization applied by types.ParseHash.
+// A signature without hashes has a single catalog key from its source and
+// detection name.
 func (s *Store) keysForSignature(sig *types.Signature) []string {
 	keys := make([]string, 0, 3)
 
 	if sig.SHA256 != "" {
-		keys = append(keys, "sha256:"+sig.SHA256)
+		keys = append(keys, "sha256:"+strings.ToLower(sig.SHA256))
 	}
 	if sig.SHA1 != "" {
-		keys = append(keys, "sha1:"+sig.SHA1)
+		keys = append(keys, "sha1:"+strings.ToLower(sig.SHA1))
 	}
 	if sig.MD5 != "" {
-		keys = append(keys, "md5:"+sig.MD5)
+		keys = append(keys, "md5:"+strings.ToLower(sig.MD5))
+	}
+	if len(keys) == 0 && sig.DetectionName != "" {
+		keys = append(keys, catalogPrefix+sig.Source+":"+sig.DetectionName)
 	}
 
 	return keys
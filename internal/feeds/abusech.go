@@ -10,8 +10,11 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,37 +24,118 @@ import (
 // abuse.ch feed URLs.
 const (
 	MalwareBazaarDefaultURL = "https://bazaar.abuse.ch/export/txt/sha256/full/"
+	MalwareBazaarRecentURL  = "https://bazaar.abuse.ch/export/txt/sha256/recent/"
 	ThreatFoxDefaultURL     = "https://threatfox.abuse.ch/export/csv/full/"
-	URLhausDefaultURL       = "https://urlhaus.abuse.ch/downloads/csv/"
+	URLhausPayloadsURL      = "https://urlhaus.abuse.ch/downloads/payloads/"
 )
 
+// FeedMode selects between a feed's full export and its recent additions.
+type FeedMode int
+
+const (
+	// FeedModeFull fetches the complete export.
+	FeedModeFull FeedMode = iota
+	// FeedModeRecent fetches only recently added entries.
+	FeedModeRecent
+)
+
+// String returns the string representation of the feed mode.
+func (m FeedMode) String() string {
+	switch m {
+	case FeedModeFull:
+		return "full"
+	case FeedModeRecent:
+		return "recent"
+	default:
+		return "unknown"
+	}
+}
+
 // MalwareBazaarFeed downloads and parses SHA256 hashes from MalwareBazaar.
 type MalwareBazaarFeed struct {
 	url        string
+	recentURL  string
+	mode       FeedMode
 	downloader *Downloader
 }
 
-// NewMalwareBazaarFeed creates a new MalwareBazaar feed parser.
+// NewMalwareBazaarFeed creates a new MalwareBazaar feed parser in full mode.
 func NewMalwareBazaarFeed() *MalwareBazaarFeed {
 	return &MalwareBazaarFeed{
 		url:        MalwareBazaarDefaultURL,
+		recentURL:  MalwareBazaarRecentURL,
+		mode:       FeedModeFull,
 		downloader: NewDownloader(nil),
 	}
 }
 
+// SetMode switches between the full export and the recent additions export.
+// Recent additions are a subset of the full export; the engine stores
+// signatures by hash, so re-importing overlapping entries is harmless.
+func (f *MalwareBazaarFeed) SetMode(mode FeedMode) {
+	f.mode = mode
+}
+
+// Mode returns the current feed mode.
+func (f *MalwareBazaarFeed) Mode() FeedMode {
+	return f.mode
+}
+
+// SetRecentURL overrides the recent additions URL (useful for testing).
+func (f *MalwareBazaarFeed) SetRecentURL(url string) {
+	f.recentURL = url
+}
+
+// currentURL returns the export URL for the current mode.
+func (f *MalwareBazaarFeed) currentURL() string {
+	if f.mode == FeedModeRecent {
+		return f.recentURL
+	}
+	return f.url
+}
+
 // Name returns the name of the feed.
 func (f *MalwareBazaarFeed) Name() string {
 	return "malwarebazaar"
 }
 
-// SetURL overrides the default URL (useful for testing).
+// SetURL overrides the default full export URL (useful for testing).
 func (f *MalwareBazaarFeed) SetURL(url string) {
 	f.url = url
 }
 
+// SetValidatorCache enables conditional downloads: Fetch returns no
+// signatures when the export is unchanged since the last committed fetch.
+func (f *MalwareBazaarFeed) SetValidatorCache(cache ValidatorCache) {
+	f.downloader.SetValidatorCache(cache)
+}
+
+// SetDownloaderConfig applies network settings such as timeout, proxy, and
+// TLS trust to the feed's downloader.
+func (f *MalwareBazaarFeed) SetDownloaderConfig(cfg DownloaderConfig) error {
+	return f.downloader.Configure(cfg)
+}
+
+// CommitValidators records the validators of the last fetch. Call it once
+// the fetched signatures are stored; until then the export is downloaded
+// in full again.
+func (f *MalwareBazaarFeed) CommitValidators() error {
+	return f.downloader.CommitValidators()
+}
+
+// SetStagingDir stages the export download under dir so a transfer
+// interrupted midway resumes where it stopped on the next Fetch.
+func (f *MalwareBazaarFeed) SetStagingDir(dir string) {
+	f.downloader.SetStagingDir(dir)
+}
+
 // Fetch downloads and parses the MalwareBazaar hash list.
 func (f *MalwareBazaarFeed) Fetch(ctx context.Context) ([]*types.Signature, error) {
-	data, err := f.downloader.Download(ctx, f.url)
+	f.downloader.discardValidators()
+	data, err := f.downloader.Download(ctx, f.currentURL())
+	if errors.Is(err, ErrNotModified) {
+		return nil, nil // Unchanged since the last download.
+	}
 	if err != nil {
 		return nil, fmt.Errorf("downloading malwarebazaar feed: %w", err)
 	}
@@ -70,17 +154,39 @@ func (f *MalwareBazaarFeed) ParseData(ctx context.Context, data []byte) ([]*type
 	return f.parseHashList(ctx, content)
 }
 
-// parseHashList parses a plain text hash list (one SHA256 per line).
-func (f *MalwareBazaarFeed) parseHashList(ctx context.Context, data []byte) ([]*types.Signature, error) {
-	var sigs []*types.Signature
+// FetchStream downloads the MalwareBazaar hash list and passes each
+// signature to fn as it is parsed, without holding the export in memory.
+// An unchanged export (see SetValidatorCache) yields no signatures.
+func (f *MalwareBazaarFeed) FetchStream(ctx context.Context, fn func(*types.Signature) error) error {
+	f.downloader.discardValidators()
+	body, err := f.downloader.Stream(ctx, f.currentURL())
+	if errors.Is(err, ErrNotModified) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("downloading malwarebazaar feed: %w", err)
+	}
+	defer body.Close()
+
+	content, err := decompressStream(body)
+	if err != nil {
+		return fmt.Errorf("decompressing data: %w", err)
+	}
+	defer content.Close()
+
+	return f.ParseStream(ctx, content, fn)
+}
 
-	scanner := bufio.NewScanner(bytes.NewReader(data))
+// ParseStream parses a plain text hash list (one SHA256 per line) from r,
+// calling fn for each signature. Parsing stops at the first error from fn.
+func (f *MalwareBazaarFeed) ParseStream(ctx context.Context, r io.Reader, fn func(*types.Signature) error) error {
+	scanner := bufio.NewScanner(r)
 	now := time.Now().UTC()
 
 	for scanner.Scan() {
 		select {
 		case <-ctx.Done():
-			return sigs, ctx.Err()
+			return ctx.Err()
 		default:
 		}
 
@@ -106,14 +212,28 @@ func (f *MalwareBazaarFeed) parseHashList(ctx context.Context, data []byte) ([]*
 			Description:   "Known malware hash from MalwareBazaar",
 		}
 
-		sigs = append(sigs, sig)
+		if err := fn(sig); err != nil {
+			return err
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return sigs, fmt.Errorf("scanning data: %w", err)
+		return fmt.Errorf("scanning data: %w", err)
 	}
 
-	return sigs, nil
+	return nil
+}
+
+// parseHashList parses a plain text hash list (one SHA256 per line).
+func (f *MalwareBazaarFeed) parseHashList(ctx context.Context, data []byte) ([]*types.Signature, error) {
+	var sigs []*types.Signature
+
+	err := f.ParseStream(ctx, bytes.NewReader(data), func(sig *types.Signature) error {
+		sigs = append(sigs, sig)
+		return nil
+	})
+
+	return sigs, err
 }
 
 // ThreatFoxFeed downloads and parses IOCs from ThreatFox.
@@ -140,9 +260,32 @@ func (f *ThreatFoxFeed) SetURL(url string) {
 	f.url = url
 }
 
+// SetValidatorCache enables conditional downloads: Fetch returns no
+// signatures when the export is unchanged since the last committed fetch.
+func (f *ThreatFoxFeed) SetValidatorCache(cache ValidatorCache) {
+	f.downloader.SetValidatorCache(cache)
+}
+
+// SetDownloaderConfig applies network settings such as timeout, proxy, and
+// TLS trust to the feed's downloader.
+func (f *ThreatFoxFeed) SetDownloaderConfig(cfg DownloaderConfig) error {
+	return f.downloader.Configure(cfg)
+}
+
+// CommitValidators records the validators of the last fetch. Call it once
+// the fetched signatures are stored; until then the export is downloaded
+// in full again.
+func (f *ThreatFoxFeed) CommitValidators() error {
+	return f.downloader.CommitValidators()
+}
+
 // Fetch downloads and parses the ThreatFox IOC list.
 func (f *ThreatFoxFeed) Fetch(ctx context.Context) ([]*types.Signature, error) {
+	f.downloader.discardValidators()
 	data, err := f.downloader.Download(ctx, f.url)
+	if errors.Is(err, ErrNotModified) {
+		return nil, nil // Unchanged since the last download.
+	}
 	if err != nil {
 		return nil, fmt.Errorf("downloading threatfox feed: %w", err)
 	}
@@ -245,9 +388,15 @@ func (f *ThreatFoxFeed) parseCSV(ctx context.Context, data []byte) ([]*types.Sig
 			malwareName = "ThreatFox." + strings.TrimSpace(record[7])
 		}
 
+		// Confidence level drives severity when present.
+		confidence := ""
+		if len(record) > 9 {
+			confidence = record[9]
+		}
+
 		sig.DetectionName = malwareName
 		sig.ThreatType = mapThreatType(threatType)
-		sig.Severity = types.SeverityHigh
+		sig.Severity = confidenceToSeverity(confidence)
 		sig.Source = f.Name()
 		sig.FirstSeen = now
 		sig.Description = fmt.Sprintf("ThreatFox IOC: %s", threatType)
@@ -258,17 +407,37 @@ func (f *ThreatFoxFeed) parseCSV(ctx context.Context, data []byte) ([]*types.Sig
 	return sigs, nil
 }
 
-// URLhausFeed downloads and parses malicious URLs from URLhaus.
-// Note: URLhaus primarily provides URLs, not file hashes.
+// confidenceToSeverity maps a ThreatFox confidence level (0-100) to a
+// severity: 90 and above is high, 50-89 medium, and below 50 low. Missing
+// or unparsable values default to high.
+func confidenceToSeverity(confidence string) types.Severity {
+	level, err := strconv.Atoi(strings.TrimSpace(confidence))
+	if err != nil {
+		return types.SeverityHigh
+	}
+
+	switch {
+	case level >= 90:
+		return types.SeverityHigh
+	case level >= 50:
+		return types.SeverityMedium
+	default:
+		return types.SeverityLow
+	}
+}
+
+// URLhausFeed downloads payload hashes from URLhaus.
+// The main URLhaus export lists URLs only; signatures come from the payloads
+// export, which records the files served by those URLs.
 type URLhausFeed struct {
-	url        string
+	payloadURL string
 	downloader *Downloader
 }
 
 // NewURLhausFeed creates a new URLhaus feed parser.
 func NewURLhausFeed() *URLhausFeed {
 	return &URLhausFeed{
-		url:        URLhausDefaultURL,
+		payloadURL: URLhausPayloadsURL,
 		downloader: NewDownloader(nil),
 	}
 }
@@ -278,15 +447,37 @@ func (f *URLhausFeed) Name() string {
 	return "urlhaus"
 }
 
-// SetURL overrides the default URL (useful for testing).
-func (f *URLhausFeed) SetURL(url string) {
-	f.url = url
+// SetPayloadURL overrides the default payloads export URL (useful for testing).
+func (f *URLhausFeed) SetPayloadURL(url string) {
+	f.payloadURL = url
+}
+
+// SetValidatorCache enables conditional downloads: Fetch returns no
+// signatures when the export is unchanged since the last committed fetch.
+func (f *URLhausFeed) SetValidatorCache(cache ValidatorCache) {
+	f.downloader.SetValidatorCache(cache)
+}
+
+// SetDownloaderConfig applies network settings such as timeout, proxy, and
+// TLS trust to the feed's downloader.
+func (f *URLhausFeed) SetDownloaderConfig(cfg DownloaderConfig) error {
+	return f.downloader.Configure(cfg)
+}
+
+// CommitValidators records the validators of the last fetch. Call it once
+// the fetched signatures are stored; until then the export is downloaded
+// in full again.
+func (f *URLhausFeed) CommitValidators() error {
+	return f.downloader.CommitValidators()
 }
 
-// Fetch downloads and parses the URLhaus data.
-// Note: URLhaus primarily contains URLs, not hashes. This extracts any payload hashes if present.
+// Fetch downloads and parses the URLhaus payloads export.
 func (f *URLhausFeed) Fetch(ctx context.Context) ([]*types.Signature, error) {
-	data, err := f.downloader.Download(ctx, f.url)
+	f.downloader.discardValidators()
+	data, err := f.downloader.Download(ctx, f.payloadURL)
+	if errors.Is(err, ErrNotModified) {
+		return nil, nil // Unchanged since the last download.
+	}
 	if err != nil {
 		return nil, fmt.Errorf("downloading urlhaus feed: %w", err)
 	}
@@ -294,22 +485,100 @@ func (f *URLhausFeed) Fetch(ctx context.Context) ([]*types.Signature, error) {
 	return f.ParseData(ctx, data)
 }
 
-// ParseData parses the raw URLhaus CSV data.
-// Format: id,dateadded,url,url_status,last_online,threat,tags,urlhaus_link,reporter
-func (f *URLhausFeed) ParseData(ctx context.Context, data []byte) ([]*types.Signature, error) {
-	// URLhaus CSV primarily contains URLs, not file hashes.
-	// We parse it but extract minimal hash data if available.
-	// For hash-based detection, MalwareBazaar is the better source.
+// urlhausPayloadColumns are the payload export columns, in the order used
+// when the export has no header row.
+var urlhausPayloadColumns = []string{"firstseen", "url", "filetype", "md5_hash", "sha256_hash", "signature"}
 
+// ParseData parses the raw URLhaus payloads CSV (optionally ZIP/GZIP).
+// Format: firstseen,url,filetype,md5_hash,sha256_hash,signature
+// A header row, if present, is used to locate the columns. Payloads served
+// from several URLs appear once per URL and are returned once.
+func (f *URLhausFeed) ParseData(ctx context.Context, data []byte) ([]*types.Signature, error) {
 	content, err := decompressIfNeeded(data)
 	if err != nil {
 		return nil, fmt.Errorf("decompressing data: %w", err)
 	}
 
-	// URLhaus CSV doesn't contain direct hashes in the main export.
-	// Return empty for now; the payload hashes require a different API endpoint.
-	_ = content
-	return []*types.Signature{}, nil
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1 // Allow variable fields.
+	reader.LazyQuotes = true
+
+	columns := make(map[string]int, len(urlhausPayloadColumns))
+	for i, name := range urlhausPayloadColumns {
+		columns[name] = i
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var sigs []*types.Signature
+	seen := make(map[string]bool)
+	now := time.Now().UTC()
+	first := true
+
+	for {
+		select {
+		case <-ctx.Done():
+			return sigs, ctx.Err()
+		default:
+		}
+
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			continue // Skip malformed lines.
+		}
+
+		// Use the header row, if any, to locate columns.
+		if first {
+			first = false
+			if len(record) > 0 && strings.Contains(strings.ToLower(strings.Join(record, ",")), "sha256_hash") {
+				clear(columns)
+				for i, name := range record {
+					columns[strings.ToLower(strings.TrimSpace(name))] = i
+				}
+				continue
+			}
+		}
+
+		sha256 := strings.ToLower(field(record, "sha256_hash"))
+		if !isValidSHA256(sha256) || seen[sha256] {
+			continue
+		}
+		seen[sha256] = true
+
+		sig := &types.Signature{
+			SHA256:        sha256,
+			DetectionName: "URLhaus.Malware",
+			ThreatType:    types.ThreatTypeMalware,
+			Severity:      types.SeverityHigh,
+			Source:        f.Name(),
+			FirstSeen:     now,
+			Description:   "Malware payload distributed via URLhaus",
+		}
+
+		if md5 := strings.ToLower(field(record, "md5_hash")); isValidMD5(md5) {
+			sig.MD5 = md5
+		}
+		if family := field(record, "signature"); family != "" && !strings.EqualFold(family, "none") {
+			sig.DetectionName = "URLhaus." + family
+			sig.ThreatType = types.ThreatTypeFromDetection(family)
+		}
+		if fileType := field(record, "filetype"); fileType != "" {
+			sig.Description = fmt.Sprintf("Malware payload distributed via URLhaus (%s)", fileType)
+		}
+
+		sigs = append(sigs, sig)
+	}
+
+	return sigs, nil
 }
 
 // AbusechFeed is an alias for MalwareBazaarFeed for backward compatibility.
@@ -336,6 +605,81 @@ func decompressIfNeeded(data []byte) ([]byte, error) {
 	return data, nil
 }
 
+// decompressStream wraps r with the decompressor matching its magic bytes.
+// ZIP archives need random access, so they are spooled to a temporary file
+// rather than memory; the first file in the archive is returned.
+func decompressStream(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+
+	switch {
+	case len(magic) >= 2 && magic[0] == 'P' && magic[1] == 'K':
+		return spoolZIP(br)
+	case len(magic) >= 2 && magic[0] == 0x1f && magic[1] == 0x8b:
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("opening gzip: %w", err)
+		}
+		return gz, nil
+	default:
+		return io.NopCloser(br), nil
+	}
+}
+
+// spoolZIP copies a ZIP stream to a temporary file and opens its first file.
+// Closing the returned reader removes the temporary file.
+func spoolZIP(r io.Reader) (io.ReadCloser, error) {
+	tmp, err := os.CreateTemp("", "feed-*.zip")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp file: %w", err)
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("spooling zip: %w", err)
+	}
+
+	reader, err := zip.NewReader(tmp, size)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("opening zip: %w", err)
+	}
+	if len(reader.File) == 0 {
+		cleanup()
+		return nil, fmt.Errorf("zip archive is empty")
+	}
+
+	f := reader.File[0]
+	rc, err := f.Open()
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("opening zip file %s: %w", f.Name, err)
+	}
+
+	// Limit size to prevent zip bombs.
+	const maxSize = 500 * 1024 * 1024 // 500MB
+	return &spooledFile{Reader: io.LimitReader(rc, maxSize), rc: rc, cleanup: cleanup}, nil
+}
+
+// spooledFile is a file inside a spooled ZIP archive.
+type spooledFile struct {
+	io.Reader
+	rc      io.ReadCloser
+	cleanup func()
+}
+
+// Close closes the file and removes the spooled archive.
+func (s *spooledFile) Close() error {
+	err := s.rc.Close()
+	s.cleanup()
+	return err
+}
+
 // decompressZIP extracts the first file from a ZIP archive.
 func decompressZIP(data []byte) ([]byte, error) {
 	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
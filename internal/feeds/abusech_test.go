@@ -4,9 +4,15 @@
 package feeds
 
 import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/hikmaai-io/hikmaai-argus/internal/types"
@@ -79,6 +85,156 @@ e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855`
 	}
 }
 
+func TestMalwareBazaarFeed_Fetch_NotModified(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+	server := conditionalServer(t, "275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f\n", &hits)
+
+	feed := NewMalwareBazaarFeed()
+	feed.SetURL(server.URL)
+	feed.SetValidatorCache(NewMemoryValidatorCache())
+
+	sigs, err := feed.Fetch(context.Background())
+	if err != nil || len(sigs) != 1 {
+		t.Fatalf("first Fetch() = %d signatures, %v; want 1", len(sigs), err)
+	}
+	if err := feed.CommitValidators(); err != nil {
+		t.Fatalf("CommitValidators() error = %v", err)
+	}
+
+	sigs, err = feed.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("second Fetch() error = %v", err)
+	}
+	if len(sigs) != 0 {
+		t.Errorf("second Fetch() = %d signatures, want none when not modified", len(sigs))
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("server hits = %d, want 2", n)
+	}
+}
+
+func TestMalwareBazaarFeed_FetchStream_MatchesParseData(t *testing.T) {
+	t.Parallel()
+
+	plain := []byte(`# MalwareBazaar export
+275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f
+E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855
+not-a-hash
+`)
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(plain)
+	gw.Close()
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	w, _ := zw.Create("full_sha256.txt")
+	w.Write(plain)
+	zw.Close()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "plain", data: plain},
+		{name: "gzip", data: gz.Bytes()},
+		{name: "zip", data: zipped.Bytes()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write(tt.data)
+			}))
+			defer server.Close()
+
+			feed := NewMalwareBazaarFeed()
+			feed.SetURL(server.URL)
+
+			want, err := feed.ParseData(context.Background(), tt.data)
+			if err != nil {
+				t.Fatalf("ParseData() error = %v", err)
+			}
+
+			var got []*types.Signature
+			err = feed.FetchStream(context.Background(), func(sig *types.Signature) error {
+				got = append(got, sig)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("FetchStream() error = %v", err)
+			}
+
+			if len(got) != 2 || len(got) != len(want) {
+				t.Fatalf("FetchStream() = %d signatures, ParseData() = %d, want 2", len(got), len(want))
+			}
+			for i := range got {
+				if got[i].SHA256 != want[i].SHA256 || got[i].DetectionName != want[i].DetectionName {
+					t.Errorf("signature %d: stream %+v, in-memory %+v", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMalwareBazaarFeed_ParseStream_CallbackError(t *testing.T) {
+	t.Parallel()
+
+	data := "275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f\n" +
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855\n"
+	stop := errors.New("stop")
+
+	calls := 0
+	err := NewMalwareBazaarFeed().ParseStream(context.Background(), strings.NewReader(data), func(*types.Signature) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("ParseStream() = %v after %d calls, want stop after 1", err, calls)
+	}
+}
+
+func TestMalwareBazaarFeed_SetMode(t *testing.T) {
+	t.Parallel()
+
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		_, _ = w.Write([]byte("275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f\n"))
+	}))
+	defer server.Close()
+
+	feed := NewMalwareBazaarFeed()
+	feed.SetURL(server.URL + "/full/")
+	feed.SetRecentURL(server.URL + "/recent/")
+
+	if feed.Mode() != FeedModeFull {
+		t.Errorf("default Mode() = %v, want full", feed.Mode())
+	}
+
+	if _, err := feed.Fetch(context.Background()); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	feed.SetMode(FeedModeRecent)
+	if _, err := feed.Fetch(context.Background()); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if err := feed.FetchStream(context.Background(), func(*types.Signature) error { return nil }); err != nil {
+		t.Fatalf("FetchStream() error = %v", err)
+	}
+
+	want := []string{"/full/", "/recent/", "/recent/"}
+	if strings.Join(requested, ",") != strings.Join(want, ",") {
+		t.Errorf("requested paths = %v, want %v", requested, want)
+	}
+}
+
 func TestMalwareBazaarFeed_Name(t *testing.T) {
 	feed := NewMalwareBazaarFeed()
 	if feed.Name() != "malwarebazaar" {
@@ -125,6 +281,47 @@ func TestThreatFoxFeed_ParseCSV(t *testing.T) {
 	}
 }
 
+func TestThreatFoxFeed_ParseCSV_ConfidenceSeverity(t *testing.T) {
+	t.Parallel()
+
+	const sha = "275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f"
+
+	tests := []struct {
+		name       string
+		confidence string
+		want       types.Severity
+	}{
+		{name: "full confidence", confidence: `,"100"`, want: types.SeverityHigh},
+		{name: "high boundary", confidence: `,"90"`, want: types.SeverityHigh},
+		{name: "medium upper", confidence: `,"89"`, want: types.SeverityMedium},
+		{name: "medium boundary", confidence: `,"50"`, want: types.SeverityMedium},
+		{name: "low", confidence: `,"49"`, want: types.SeverityLow},
+		{name: "zero", confidence: `,"0"`, want: types.SeverityLow},
+		{name: "unparsable", confidence: `,"n/a"`, want: types.SeverityHigh},
+		{name: "empty", confidence: `,""`, want: types.SeverityHigh},
+		{name: "missing column", confidence: "", want: types.SeverityHigh},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			row := `"2024-01-15 00:00:00",123,"` + sha + `","sha256_hash","payload","emotet","win.emotet","Emotet",""` + tt.confidence + "\n"
+
+			sigs, err := NewThreatFoxFeed().ParseData(context.Background(), []byte(row))
+			if err != nil {
+				t.Fatalf("ParseData() error = %v", err)
+			}
+			if len(sigs) != 1 {
+				t.Fatalf("ParseData() got %d signatures, want 1", len(sigs))
+			}
+			if sigs[0].Severity != tt.want {
+				t.Errorf("Severity = %v, want %v", sigs[0].Severity, tt.want)
+			}
+		})
+	}
+}
+
 func TestThreatFoxFeed_Name(t *testing.T) {
 	feed := NewThreatFoxFeed()
 	if feed.Name() != "threatfox" {
@@ -132,6 +329,99 @@ func TestThreatFoxFeed_Name(t *testing.T) {
 	}
 }
 
+const urlhausPayloadsCSV = `################################################################
+# abuse.ch URLhaus payloads dump (CSV)                         #
+################################################################
+firstseen,url,filetype,md5_hash,sha256_hash,signature
+"2026-10-01 10:00:00","http://bad.example/a.exe","exe","44d88612fea8a8f36de82e1278abb02f","275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f","Mozi"
+"2026-10-01 11:00:00","http://bad.example/b.exe","exe","44d88612fea8a8f36de82e1278abb02f","275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f","Mozi"
+"2026-10-02 09:00:00","http://bad.example/c.bin","elf","d41d8cd98f00b204e9800998ecf8427e","E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855","None"
+"2026-10-02 09:30:00","http://bad.example/d","unknown","","not-a-hash","Mirai"
+`
+
+func TestURLhausFeed_ParseData(t *testing.T) {
+	t.Parallel()
+
+	sigs, err := NewURLhausFeed().ParseData(context.Background(), []byte(urlhausPayloadsCSV))
+	if err != nil {
+		t.Fatalf("ParseData() error = %v", err)
+	}
+
+	// The duplicate payload and the invalid hash are dropped.
+	if len(sigs) != 2 {
+		t.Fatalf("ParseData() got %d signatures, want 2", len(sigs))
+	}
+
+	first := sigs[0]
+	if first.SHA256 != "275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f" {
+		t.Errorf("SHA256 = %q", first.SHA256)
+	}
+	if first.MD5 != "44d88612fea8a8f36de82e1278abb02f" {
+		t.Errorf("MD5 = %q", first.MD5)
+	}
+	if first.DetectionName != "URLhaus.Mozi" {
+		t.Errorf("DetectionName = %q, want URLhaus.Mozi", first.DetectionName)
+	}
+	if first.Source != "urlhaus" {
+		t.Errorf("Source = %q, want urlhaus", first.Source)
+	}
+	if !strings.Contains(first.Description, "exe") {
+		t.Errorf("Description = %q, want file type", first.Description)
+	}
+
+	second := sigs[1]
+	if second.SHA256 != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("SHA256 = %q, want lowercased hash", second.SHA256)
+	}
+	if second.DetectionName != "URLhaus.Malware" {
+		t.Errorf("DetectionName = %q, want generic name for signature None", second.DetectionName)
+	}
+}
+
+func TestURLhausFeed_ParseData_NoHeader(t *testing.T) {
+	t.Parallel()
+
+	row := `"2026-10-01 10:00:00","http://bad.example/a.exe","exe","44d88612fea8a8f36de82e1278abb02f","275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f","AgentTesla"` + "\n"
+
+	sigs, err := NewURLhausFeed().ParseData(context.Background(), []byte(row))
+	if err != nil {
+		t.Fatalf("ParseData() error = %v", err)
+	}
+	if len(sigs) != 1 || sigs[0].DetectionName != "URLhaus.AgentTesla" {
+		t.Errorf("ParseData() = %v, want one AgentTesla signature", sigs)
+	}
+}
+
+func TestURLhausFeed_Fetch(t *testing.T) {
+	t.Parallel()
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	w, _ := zw.Create("payload.txt")
+	w.Write([]byte(urlhausPayloadsCSV))
+	zw.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/payloads/" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(zipped.Bytes())
+	}))
+	defer server.Close()
+
+	feed := NewURLhausFeed()
+	feed.SetPayloadURL(server.URL + "/payloads/")
+
+	sigs, err := feed.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(sigs) != 2 {
+		t.Errorf("Fetch() got %d signatures, want 2", len(sigs))
+	}
+}
+
 func TestURLhausFeed_Name(t *testing.T) {
 	feed := NewURLhausFeed()
 	if feed.Name() != "urlhaus" {
@@ -9,7 +9,10 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/md5"
 	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -42,6 +45,10 @@ type ClamAVFeed struct {
 	databases  []string
 	localDir   string // If set, read CVD files from this directory instead of downloading
 	downloader *Downloader
+
+	// includeBodySignatures also catalogs .ndb/.ldb signatures (see
+	// SetIncludeBodySignatures).
+	includeBodySignatures bool
 }
 
 // NewClamAVFeed creates a new ClamAV feed parser.
@@ -70,6 +77,12 @@ func (f *ClamAVFeed) SetLocalDir(dir string) {
 	f.localDir = dir
 }
 
+// SetDownloaderConfig applies network settings such as timeout, proxy, and
+// TLS trust to the feed's downloader.
+func (f *ClamAVFeed) SetDownloaderConfig(cfg DownloaderConfig) error {
+	return f.downloader.Configure(cfg)
+}
+
 // Name returns the name of the feed.
 func (f *ClamAVFeed) Name() string {
 	return "clamav"
@@ -85,6 +98,13 @@ func (f *ClamAVFeed) SetDatabases(databases []string) {
 	f.databases = databases
 }
 
+// SetIncludeBodySignatures controls whether body-based .ndb and .ldb
+// signatures are cataloged alongside hash signatures. They are returned as
+// hashless signatures tagged ClamAVBodySignatureTag. Off by default.
+func (f *ClamAVFeed) SetIncludeBodySignatures(include bool) {
+	f.includeBodySignatures = include
+}
+
 // Fetch downloads and parses ClamAV databases from mirrors.
 // If localDir is set, it reads from local CVD files instead of downloading.
 func (f *ClamAVFeed) Fetch(ctx context.Context) ([]*types.Signature, error) {
@@ -108,10 +128,13 @@ func (f *ClamAVFeed) Fetch(ctx context.Context) ([]*types.Signature, error) {
 func (f *ClamAVFeed) fetchDatabase(ctx context.Context, database string) ([]*types.Signature, error) {
 	// Try local file first if localDir is set.
 	if f.localDir != "" {
-		localPath := filepath.Join(f.localDir, database)
-		if data, err := os.ReadFile(localPath); err == nil {
-			fmt.Printf("  Reading %s from local file: %s\n", database, localPath)
-			return f.ParseCVD(ctx, data)
+		// Prefer a CLD produced by incremental updates.
+		for _, name := range []string{CLDName(database), database} {
+			localPath := filepath.Join(f.localDir, name)
+			if data, err := os.ReadFile(localPath); err == nil {
+				fmt.Printf("  Reading %s from local file: %s\n", database, localPath)
+				return f.ParseCVD(ctx, data)
+			}
 		}
 		// Local file doesn't exist; fall through to download.
 		fmt.Printf("  Local file not found, downloading %s...\n", database)
@@ -140,18 +163,38 @@ func (f *ClamAVFeed) fetchDatabase(ctx context.Context, database string) ([]*typ
 	return nil, fmt.Errorf("failed to fetch %s from all mirrors: %w", database, lastErr)
 }
 
-// ParseCVD parses a ClamAV CVD file.
-func (f *ClamAVFeed) ParseCVD(ctx context.Context, data []byte) ([]*types.Signature, error) {
+// ErrCVDVerification is returned when a CVD body does not match the MD5
+// recorded in its header, e.g. after a truncated or tampered download.
+var ErrCVDVerification = errors.New("CVD verification failed")
+
+// VerifyCVD parses the header of a CVD or CLD file and checks that the MD5
+// of everything after the 512-byte header matches the header's MD5 field.
+// The digital signature is not checked; it needs ClamAV's public key.
+func VerifyCVD(data []byte) (*CVDHeader, error) {
 	if len(data) < cvdHeaderSize {
 		return nil, fmt.Errorf("data too small for CVD file: %d bytes", len(data))
 	}
 
-	// Parse CVD header (first 512 bytes).
-	header, err := parseCVDHeader(data[:cvdHeaderSize])
+	header, err := ParseCVDHeader(data[:cvdHeaderSize])
 	if err != nil {
 		return nil, fmt.Errorf("parsing CVD header: %w", err)
 	}
 
+	sum := md5.Sum(data[cvdHeaderSize:])
+	if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, header.MD5) {
+		return nil, fmt.Errorf("%w: MD5 mismatch (header %q, body %q)", ErrCVDVerification, header.MD5, actual)
+	}
+
+	return header, nil
+}
+
+// ParseCVD verifies and parses a ClamAV CVD file.
+func (f *ClamAVFeed) ParseCVD(ctx context.Context, data []byte) ([]*types.Signature, error) {
+	header, err := VerifyCVD(data)
+	if err != nil {
+		return nil, err
+	}
+
 	// Rest is tar.gz compressed signature data.
 	tarGzData := data[cvdHeaderSize:]
 
@@ -183,8 +226,8 @@ func (f *ClamAVFeed) ParseCVD(ctx context.Context, data []byte) ([]*types.Signat
 			continue // Skip problematic entries.
 		}
 
-		// Only parse hash signature files.
-		if !isHashFile(hdr.Name) {
+		// Only parse hash signature files, plus body signatures if enabled.
+		if !isHashFile(hdr.Name) && !(f.includeBodySignatures && isBodySignatureFile(hdr.Name)) {
 			continue
 		}
 
@@ -205,6 +248,14 @@ func (f *ClamAVFeed) ParseCVD(ctx context.Context, data []byte) ([]*types.Signat
 			sigs = parseMDB(content, header.Name, now)
 		case strings.HasSuffix(hdr.Name, ".msb"):
 			sigs = parseMSB(content, header.Name, now)
+		case strings.HasSuffix(hdr.Name, ".ndb"):
+			for _, b := range parseNDB(content) {
+				sigs = append(sigs, b.ToSignature(header.Name, now))
+			}
+		case strings.HasSuffix(hdr.Name, ".ldb"):
+			for _, b := range parseLDB(content) {
+				sigs = append(sigs, b.ToSignature(header.Name, now))
+			}
 		}
 
 		allSigs = append(allSigs, sigs...)
@@ -224,8 +275,12 @@ type CVDHeader struct {
 	DigitalSig   string
 }
 
-// parseCVDHeader parses the 512-byte CVD header.
-func parseCVDHeader(data []byte) (*CVDHeader, error) {
+// cvdBuildTimeLayout is the layout of the build time field in CVD headers,
+// e.g. "07 Nov 2023 08-55 -0400".
+const cvdBuildTimeLayout = "02 Jan 2006 15-04 -0700"
+
+// ParseCVDHeader parses the 512-byte CVD header.
+func ParseCVDHeader(data []byte) (*CVDHeader, error) {
 	// CVD header format (colon-separated):
 	// ClamAV-VDB:build_time:version:sigs:functionality:md5:signature:builder:time
 	headerStr := string(bytes.TrimRight(data, "\x00"))
@@ -243,6 +298,11 @@ func parseCVDHeader(data []byte) (*CVDHeader, error) {
 		Name: parts[0],
 	}
 
+	// Build time is informational; tolerate formats we don't recognise.
+	if t, err := time.Parse(cvdBuildTimeLayout, parts[1]); err == nil {
+		header.BuildTime = t
+	}
+
 	// Parse version.
 	if len(parts) > 2 {
 		fmt.Sscanf(parts[2], "%d", &header.Version)
@@ -253,6 +313,11 @@ func parseCVDHeader(data []byte) (*CVDHeader, error) {
 		fmt.Sscanf(parts[3], "%d", &header.Signatures)
 	}
 
+	// Parse functionality level.
+	if len(parts) > 4 {
+		fmt.Sscanf(parts[4], "%d", &header.Functionality)
+	}
+
 	// MD5.
 	if len(parts) > 5 {
 		header.MD5 = parts[5]
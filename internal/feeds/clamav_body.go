@@ -0,0 +1,170 @@
+// ABOUTME: Catalog parser for ClamAV body-based signatures (.ndb and .ldb)
+// ABOUTME: Extracts detection names and target file types without the byte patterns
+
+package feeds
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/types"
+)
+
+// ClamAVBodySignatureTag marks signatures cataloged from .ndb/.ldb files.
+// They carry no hashes, so they describe ClamAV coverage rather than being
+// usable for hash lookups.
+const ClamAVBodySignatureTag = "clamav-body"
+
+// ClamAVTargetType is the file type a ClamAV body signature applies to.
+type ClamAVTargetType int
+
+// ClamAV target types, as documented in ClamAV's signature format.
+const (
+	ClamAVTargetAny ClamAVTargetType = iota
+	ClamAVTargetPE
+	ClamAVTargetOLE2
+	ClamAVTargetHTML
+	ClamAVTargetMail
+	ClamAVTargetGraphics
+	ClamAVTargetELF
+	ClamAVTargetASCII
+	ClamAVTargetUnused
+	ClamAVTargetMachO
+	ClamAVTargetPDF
+	ClamAVTargetFlash
+	ClamAVTargetJava
+)
+
+// String returns the name of the target type.
+func (t ClamAVTargetType) String() string {
+	switch t {
+	case ClamAVTargetAny:
+		return "any"
+	case ClamAVTargetPE:
+		return "pe"
+	case ClamAVTargetOLE2:
+		return "ole2"
+	case ClamAVTargetHTML:
+		return "html"
+	case ClamAVTargetMail:
+		return "mail"
+	case ClamAVTargetGraphics:
+		return "graphics"
+	case ClamAVTargetELF:
+		return "elf"
+	case ClamAVTargetASCII:
+		return "ascii"
+	case ClamAVTargetMachO:
+		return "macho"
+	case ClamAVTargetPDF:
+		return "pdf"
+	case ClamAVTargetFlash:
+		return "flash"
+	case ClamAVTargetJava:
+		return "java"
+	default:
+		return "unknown"
+	}
+}
+
+// ClamAVBodySignature is the catalog entry for one .ndb or .ldb signature.
+type ClamAVBodySignature struct {
+	Name       string
+	TargetType ClamAVTargetType
+}
+
+// ToSignature converts the entry to a hashless signature tagged with
+// ClamAVBodySignatureTag and its target type.
+func (b ClamAVBodySignature) ToSignature(source string, now time.Time) *types.Signature {
+	return &types.Signature{
+		DetectionName: "ClamAV." + b.Name,
+		ThreatType:    types.ThreatTypeFromDetection(b.Name),
+		Severity:      types.SeverityHigh,
+		Source:        source,
+		FirstSeen:     now,
+		Description:   "ClamAV body-based detection",
+		Tags:          []string{ClamAVBodySignatureTag, "target:" + b.TargetType.String()},
+	}
+}
+
+// isBodySignatureFile checks if the file contains body-based signatures.
+func isBodySignatureFile(name string) bool {
+	return strings.HasSuffix(name, ".ndb") || strings.HasSuffix(name, ".ldb")
+}
+
+// parseNDB parses extended body signature files.
+// Format: MalwareName:TargetType:Offset:HexSignature[:MinFL[:MaxFL]]
+func parseNDB(content []byte) []ClamAVBodySignature {
+	var sigs []ClamAVBodySignature
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 5)
+		if len(parts) < 4 || parts[0] == "" {
+			continue
+		}
+
+		target, ok := parseClamAVTarget(parts[1])
+		if !ok {
+			continue
+		}
+
+		sigs = append(sigs, ClamAVBodySignature{Name: parts[0], TargetType: target})
+	}
+
+	return sigs
+}
+
+// parseLDB parses logical signature files.
+// Format: SignatureName;TargetDescriptionBlock;LogicalExpression;Subsig0;...
+// The target type comes from the "Target:N" attribute of the description block.
+func parseLDB(content []byte) []ClamAVBodySignature {
+	var sigs []ClamAVBodySignature
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ";", 4)
+		if len(parts) < 4 || parts[0] == "" {
+			continue
+		}
+
+		target, ok := ClamAVTargetAny, false
+		for _, attr := range strings.Split(parts[1], ",") {
+			if value, found := strings.CutPrefix(attr, "Target:"); found {
+				target, ok = parseClamAVTarget(value)
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		sigs = append(sigs, ClamAVBodySignature{Name: parts[0], TargetType: target})
+	}
+
+	return sigs
+}
+
+// parseClamAVTarget parses a numeric target type.
+func parseClamAVTarget(s string) (ClamAVTargetType, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < int(ClamAVTargetAny) || n > int(ClamAVTargetJava) {
+		return ClamAVTargetAny, false
+	}
+	return ClamAVTargetType(n), true
+}
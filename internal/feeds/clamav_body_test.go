@@ -0,0 +1,137 @@
+// ABOUTME: Tests for the ClamAV body-based signature catalog parser
+// ABOUTME: Validates .ndb/.ldb parsing and the opt-in ParseCVD behavior
+
+package feeds
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/types"
+)
+
+const testNDB = `# Extended signatures
+Win.Trojan.Agent-1:1:*:4d5a90000300000004000000ffff
+Html.Exploit.CVE_2024_0001:3:EOF-200:3c736372697074{-20}6576616c28
+Unix.Malware.Mirai-2:6:EP+0:7f454c46::120
+Bad.NoTarget:x:*:aabb
+Bad.UnknownTarget:42:*:aabb
+Bad.TooShort:1
+`
+
+const testLDB = `Win.Ransomware.Locky-1;Engine:51-255,Target:1;0&1;6c6f636b79;2e6c6f636b79
+Doc.Dropper.Agent-2;Target:2,Engine:81-255;0;4d6163726f
+Bad.NoTarget;Engine:51-255;0;aabb
+Bad.TooShort;Target:1;0
+`
+
+func TestParseNDB(t *testing.T) {
+	t.Parallel()
+
+	got := parseNDB([]byte(testNDB))
+	want := []ClamAVBodySignature{
+		{Name: "Win.Trojan.Agent-1", TargetType: ClamAVTargetPE},
+		{Name: "Html.Exploit.CVE_2024_0001", TargetType: ClamAVTargetHTML},
+		{Name: "Unix.Malware.Mirai-2", TargetType: ClamAVTargetELF},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseNDB() = %v, want %v", got, want)
+	}
+}
+
+func TestParseLDB(t *testing.T) {
+	t.Parallel()
+
+	got := parseLDB([]byte(testLDB))
+	want := []ClamAVBodySignature{
+		{Name: "Win.Ransomware.Locky-1", TargetType: ClamAVTargetPE},
+		{Name: "Doc.Dropper.Agent-2", TargetType: ClamAVTargetOLE2},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseLDB() = %v, want %v", got, want)
+	}
+}
+
+func TestClamAVBodySignature_ToSignature(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().UTC()
+	sig := ClamAVBodySignature{Name: "Win.Ransomware.Locky-1", TargetType: ClamAVTargetPE}.ToSignature("ClamAV-VDB", now)
+
+	if sig.DetectionName != "ClamAV.Win.Ransomware.Locky-1" {
+		t.Errorf("DetectionName = %q", sig.DetectionName)
+	}
+	if sig.ThreatType != types.ThreatTypeRansomware {
+		t.Errorf("ThreatType = %v, want ransomware", sig.ThreatType)
+	}
+	if len(sig.GetHashes()) != 0 {
+		t.Errorf("GetHashes() = %v, want none", sig.GetHashes())
+	}
+	if !slices.Equal(sig.Tags, []string{ClamAVBodySignatureTag, "target:pe"}) {
+		t.Errorf("Tags = %v", sig.Tags)
+	}
+	if sig.Source != "ClamAV-VDB" || !sig.FirstSeen.Equal(now) {
+		t.Errorf("Source/FirstSeen = %q/%v", sig.Source, sig.FirstSeen)
+	}
+}
+
+func TestClamAVFeed_ParseCVD_BodySignatures(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"daily.hdb": "44d88612fea8a8f36de82e1278abb02f:68:Eicar-Test-Signature\n",
+		"daily.ndb": testNDB,
+		"daily.ldb": testLDB,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cvd, err := BuildCLD(dir, &CVDHeader{Version: 1})
+	if err != nil {
+		t.Fatalf("BuildCLD() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		includeBody bool
+		wantTotal   int
+		wantBody    int
+	}{
+		{name: "hash only by default", includeBody: false, wantTotal: 1, wantBody: 0},
+		{name: "opt-in body signatures", includeBody: true, wantTotal: 6, wantBody: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			feed := NewClamAVFeed()
+			feed.SetIncludeBodySignatures(tt.includeBody)
+
+			sigs, err := feed.ParseCVD(context.Background(), cvd)
+			if err != nil {
+				t.Fatalf("ParseCVD() error = %v", err)
+			}
+			if len(sigs) != tt.wantTotal {
+				t.Errorf("ParseCVD() returned %d signatures, want %d", len(sigs), tt.wantTotal)
+			}
+
+			body := 0
+			for _, sig := range sigs {
+				if slices.Contains(sig.Tags, ClamAVBodySignatureTag) {
+					body++
+				}
+			}
+			if body != tt.wantBody {
+				t.Errorf("body signatures = %d, want %d", body, tt.wantBody)
+			}
+		})
+	}
+}
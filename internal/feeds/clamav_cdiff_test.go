@@ -0,0 +1,206 @@
+// ABOUTME: Tests for ClamAV incremental updates: cdiff scripts and CLD packing
+// ABOUTME: Applies synthetic cdiff scripts to unpacked databases and round-trips CLDs
+
+package feeds
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gzipCDIFF compresses a cdiff script and appends a fake signature, as
+// mirrors serve them.
+func gzipCDIFF(t *testing.T, script string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(script)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	buf.WriteString(":FAKESIGNATURE")
+	return buf.Bytes()
+}
+
+func TestCDIFFNames(t *testing.T) {
+	t.Parallel()
+
+	if got := CLDName("daily.cvd"); got != "daily.cld" {
+		t.Errorf("CLDName() = %q, want daily.cld", got)
+	}
+	if got := CDIFFName("daily.cvd", 27001); got != "daily-27001.cdiff" {
+		t.Errorf("CDIFFName() = %q, want daily-27001.cdiff", got)
+	}
+}
+
+func TestApplyCDIFF(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	hdb := "aaaa:10:Sig.A\nbbbb:20:Sig.B\ncccc:30:Sig.C\n"
+	if err := os.WriteFile(filepath.Join(dir, "daily.hdb"), []byte(hdb), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "daily.old"), []byte("x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	script := strings.Join([]string{
+		"OPEN daily.hdb",
+		"DEL 1 aaaa",
+		"XCHG 3 cccc cccc:31:Sig.C.Updated",
+		"ADD dddd:40:Sig.D",
+		"CLOSE",
+		"OPEN daily.hsb",
+		"ADD eeee:50:Sig.E",
+		"CLOSE",
+		"UNLINK daily.old",
+	}, "\n") + "\n"
+
+	if err := ApplyCDIFF(dir, gzipCDIFF(t, script)); err != nil {
+		t.Fatalf("ApplyCDIFF() error = %v", err)
+	}
+
+	got, _ := os.ReadFile(filepath.Join(dir, "daily.hdb"))
+	want := "bbbb:20:Sig.B\ncccc:31:Sig.C.Updated\ndddd:40:Sig.D\n"
+	if string(got) != want {
+		t.Errorf("daily.hdb = %q, want %q", got, want)
+	}
+
+	got, _ = os.ReadFile(filepath.Join(dir, "daily.hsb"))
+	if string(got) != "eeee:50:Sig.E\n" {
+		t.Errorf("daily.hsb = %q, want new file with one line", got)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "daily.old")); !os.IsNotExist(err) {
+		t.Error("daily.old should have been unlinked")
+	}
+}
+
+func TestApplyCDIFF_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{name: "DEL mismatch", script: "OPEN daily.hdb\nDEL 1 zzzz\nCLOSE\n"},
+		{name: "XCHG beyond end", script: "OPEN daily.hdb\nXCHG 9 aaaa new\nCLOSE\n"},
+		{name: "ADD without OPEN", script: "ADD aaaa:1:X\n"},
+		{name: "unterminated", script: "OPEN daily.hdb\nADD aaaa:1:X\n"},
+		{name: "unsupported command", script: "MOVE daily.hdb daily.ndb 1 a 2 b\n"},
+		{name: "path traversal", script: "OPEN ../daily.hdb\nCLOSE\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			original := "aaaa:10:Sig.A\n"
+			if err := os.WriteFile(filepath.Join(dir, "daily.hdb"), []byte(original), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := ApplyCDIFF(dir, []byte(tt.script)); err == nil {
+				t.Fatal("ApplyCDIFF() expected error")
+			}
+
+			got, _ := os.ReadFile(filepath.Join(dir, "daily.hdb"))
+			if string(got) != original {
+				t.Errorf("daily.hdb modified on failure: %q", got)
+			}
+		})
+	}
+}
+
+func TestBuildCLD_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	hdb := "44d88612fea8a8f36de82e1278abb02f:68:Eicar-Test-Signature\n"
+	if err := os.WriteFile(filepath.Join(src, "daily.hdb"), []byte(hdb), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cld, err := BuildCLD(src, &CVDHeader{Version: 27001, Signatures: 1, Functionality: 90})
+	if err != nil {
+		t.Fatalf("BuildCLD() error = %v", err)
+	}
+
+	header, err := ParseCVDHeader(cld[:cvdHeaderSize])
+	if err != nil {
+		t.Fatalf("ParseCVDHeader() error = %v", err)
+	}
+	if header.Version != 27001 || header.Signatures != 1 || header.Functionality != 90 {
+		t.Errorf("header = %+v, want version 27001, 1 signature, functionality 90", header)
+	}
+
+	sum := md5.Sum(cld[cvdHeaderSize:])
+	if header.MD5 != hex.EncodeToString(sum[:]) {
+		t.Errorf("header MD5 = %q, want MD5 of body", header.MD5)
+	}
+
+	// The CLD unpacks to the same files.
+	dst := t.TempDir()
+	if _, err := UnpackCVD(cld, dst); err != nil {
+		t.Fatalf("UnpackCVD() error = %v", err)
+	}
+	got, _ := os.ReadFile(filepath.Join(dst, "daily.hdb"))
+	if string(got) != hdb {
+		t.Errorf("unpacked daily.hdb = %q, want %q", got, hdb)
+	}
+
+	// And parses as a regular CVD.
+	sigs, err := NewClamAVFeed().ParseCVD(context.Background(), cld)
+	if err != nil {
+		t.Fatalf("ParseCVD() error = %v", err)
+	}
+	if len(sigs) != 1 || sigs[0].DetectionName != "ClamAV.Eicar-Test-Signature" {
+		t.Errorf("ParseCVD() = %v, want the EICAR signature", sigs)
+	}
+}
+
+func TestClamAVDBFeed_SaveCLD(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	feed := NewClamAVDBFeed(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "daily.cvd"), createTestCVD(100), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "daily.hdb"), []byte("aaaa:1:X\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cld, err := BuildCLD(src, &CVDHeader{Version: 105})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := feed.SaveCLD("daily.cvd", cld); err != nil {
+		t.Fatalf("SaveCLD() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "daily.cvd")); !os.IsNotExist(err) {
+		t.Error("daily.cvd should be removed once superseded by daily.cld")
+	}
+	if v, err := feed.GetLocalVersion("daily.cvd"); err != nil || v != 105 {
+		t.Errorf("GetLocalVersion() = %d, %v; want 105 from the CLD", v, err)
+	}
+	if !feed.IsReady() {
+		t.Error("IsReady() should be true with daily.cld present")
+	}
+}
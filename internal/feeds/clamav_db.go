@@ -64,9 +64,21 @@ func (f *ClamAVDBFeed) SetDatabases(databases []string) {
 	f.databases = databases
 }
 
+// SetDownloaderConfig applies network settings such as timeout, proxy, and
+// TLS trust to the feed's downloader.
+func (f *ClamAVDBFeed) SetDownloaderConfig(cfg DownloaderConfig) error {
+	return f.downloader.Configure(cfg)
+}
+
 // Update downloads and saves ClamAV databases.
 // It checks local versions and only downloads if updates are available.
 func (f *ClamAVDBFeed) Update(ctx context.Context) (*UpdateStats, error) {
+	return f.UpdateDatabases(ctx, f.databases)
+}
+
+// UpdateDatabases downloads and saves the given databases in full, skipping
+// any whose local copy is already current.
+func (f *ClamAVDBFeed) UpdateDatabases(ctx context.Context, databases []string) (*UpdateStats, error) {
 	// Create database directory if it doesn't exist.
 	if err := os.MkdirAll(f.databaseDir, 0o755); err != nil {
 		return nil, fmt.Errorf("creating database directory: %w", err)
@@ -74,7 +86,7 @@ func (f *ClamAVDBFeed) Update(ctx context.Context) (*UpdateStats, error) {
 
 	stats := &UpdateStats{}
 
-	for _, db := range f.databases {
+	for _, db := range databases {
 		select {
 		case <-ctx.Done():
 			return stats, ctx.Err()
@@ -120,15 +132,10 @@ func (f *ClamAVDBFeed) updateDatabase(ctx context.Context, database string) (boo
 			continue
 		}
 
-		// Parse header to get version.
-		if len(data) < cvdHeaderSize {
-			lastErr = fmt.Errorf("downloaded data too small: %d bytes", len(data))
-			continue
-		}
-
-		header, err := parseCVDHeader(data[:cvdHeaderSize])
+		// Verify integrity before trusting the header's version.
+		header, err := VerifyCVD(data)
 		if err != nil {
-			lastErr = fmt.Errorf("parsing CVD header: %w", err)
+			lastErr = err
 			continue
 		}
 
@@ -149,9 +156,51 @@ func (f *ClamAVDBFeed) updateDatabase(ctx context.Context, database string) (boo
 	return false, fmt.Errorf("failed to update %s from all mirrors: %w", database, lastErr)
 }
 
-// saveDatabase saves data to the database file atomically.
+// saveDatabase saves a full CVD download atomically, removing any CLD left
+// by earlier incremental updates so clamscan doesn't load both.
 func (f *ClamAVDBFeed) saveDatabase(database string, data []byte) error {
-	targetPath := filepath.Join(f.databaseDir, database)
+	if err := f.writeAtomic(database, data); err != nil {
+		return err
+	}
+	_ = os.Remove(filepath.Join(f.databaseDir, CLDName(database)))
+	return nil
+}
+
+// SaveCLD atomically saves a patched database as its CLD and removes the CVD
+// it replaces.
+func (f *ClamAVDBFeed) SaveCLD(database string, data []byte) error {
+	if err := f.writeAtomic(CLDName(database), data); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(f.databaseDir, database)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing superseded %s: %w", database, err)
+	}
+	return nil
+}
+
+// ReadLocalDatabase returns the local copy of a database, preferring the CLD
+// produced by incremental updates over the CVD.
+func (f *ClamAVDBFeed) ReadLocalDatabase(database string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(f.databaseDir, CLDName(database)))
+	if err == nil {
+		return data, nil
+	}
+	return os.ReadFile(filepath.Join(f.databaseDir, database))
+}
+
+// VerifyLocalDatabase checks the integrity of the local copy of a database
+// and returns its header.
+func (f *ClamAVDBFeed) VerifyLocalDatabase(database string) (*CVDHeader, error) {
+	data, err := f.ReadLocalDatabase(database)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", database, err)
+	}
+	return VerifyCVD(data)
+}
+
+// writeAtomic writes data to name in the database directory via a rename.
+func (f *ClamAVDBFeed) writeAtomic(name string, data []byte) error {
+	targetPath := filepath.Join(f.databaseDir, name)
 	tmpPath := targetPath + ".tmp"
 
 	// Write to temporary file.
@@ -168,11 +217,18 @@ func (f *ClamAVDBFeed) saveDatabase(database string, data []byte) error {
 	return nil
 }
 
-// GetLocalVersion reads the version from a local CVD file.
-// Returns 0 and an error if the file doesn't exist or is invalid.
+// GetLocalVersion reads the version of a local database, from its CLD if
+// incremental updates produced one, otherwise from the CVD file.
+// Returns 0 and an error if neither file exists or is valid.
 func (f *ClamAVDBFeed) GetLocalVersion(database string) (int, error) {
-	path := filepath.Join(f.databaseDir, database)
+	if version, err := readHeaderVersion(filepath.Join(f.databaseDir, CLDName(database))); err == nil {
+		return version, nil
+	}
+	return readHeaderVersion(filepath.Join(f.databaseDir, database))
+}
 
+// readHeaderVersion reads the version from the header of a CVD or CLD file.
+func readHeaderVersion(path string) (int, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return 0, fmt.Errorf("opening database file: %w", err)
@@ -190,7 +246,7 @@ func (f *ClamAVDBFeed) GetLocalVersion(database string) (int, error) {
 	}
 
 	// Parse header.
-	cvdHeader, err := parseCVDHeader(header)
+	cvdHeader, err := ParseCVDHeader(header)
 	if err != nil {
 		return 0, fmt.Errorf("parsing header: %w", err)
 	}
@@ -210,14 +266,16 @@ func (f *ClamAVDBFeed) DatabaseDir() string {
 
 // IsReady checks if the minimum required databases are present.
 func (f *ClamAVDBFeed) IsReady() bool {
-	// At minimum, we need main.cvd or daily.cvd.
-	mainPath := filepath.Join(f.databaseDir, ClamAVMainDB)
-	dailyPath := filepath.Join(f.databaseDir, ClamAVDailyDB)
-
-	_, mainErr := os.Stat(mainPath)
-	_, dailyErr := os.Stat(dailyPath)
+	// At minimum, we need main or daily, as a CVD or a patched CLD.
+	for _, db := range []string{ClamAVMainDB, ClamAVDailyDB} {
+		for _, name := range []string{db, CLDName(db)} {
+			if _, err := os.Stat(filepath.Join(f.databaseDir, name)); err == nil {
+				return true
+			}
+		}
+	}
 
-	return mainErr == nil || dailyErr == nil
+	return false
 }
 
 // GetVersionInfo returns version information for all databases.
@@ -5,6 +5,8 @@ package feeds
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -15,21 +17,27 @@ import (
 	"time"
 )
 
+// testCVDBody is a minimal valid gzip stream (empty content) and
+// testCVDBodyMD5 its MD5, as recorded in CVD headers.
+var (
+	testCVDBody = []byte{
+		0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03,
+		0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	testCVDBodyMD5 = func() string {
+		sum := md5.Sum(testCVDBody)
+		return hex.EncodeToString(sum[:])
+	}()
+)
+
 // createTestCVD creates a minimal valid CVD file for testing.
 // CVD format: 512-byte header (colon-separated) + tar.gz data.
 func createTestCVD(version int) []byte {
 	// Header format: ClamAV-VDB:build_time:version:sigs:functionality:md5:signature:builder:time
 	header := make([]byte, 512)
-	headerStr := fmt.Sprintf("ClamAV-VDB:01 Jan 2024 00-00 +0000:%d:100000:77:abc123:def456:builder:1704067200", version)
-	copy(header, headerStr)
-
-	// Minimal gzip content (empty but valid).
-	gzipData := []byte{
-		0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03,
-		0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-	}
+	copy(header, fmt.Sprintf("ClamAV-VDB:01 Jan 2024 00-00 +0000:%d:100000:77:%s:def456:builder:1704067200", version, testCVDBodyMD5))
 
-	return append(header, gzipData...)
+	return append(header, testCVDBody...)
 }
 
 func TestClamAVDBFeed_Name(t *testing.T) {
@@ -110,6 +118,40 @@ func TestClamAVDBFeed_Update_SavesFile(t *testing.T) {
 	}
 }
 
+func TestClamAVDBFeed_Update_RejectsCorruptedDownload(t *testing.T) {
+	t.Parallel()
+
+	data := createTestCVD(2)
+	truncated := data[:len(data)-4]
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(truncated)
+	}))
+	defer server.Close()
+
+	dbDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dbDir, "test.cvd"), createTestCVD(1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	feed := NewClamAVDBFeed(dbDir)
+	feed.SetMirrors([]string{server.URL})
+	feed.SetDatabases([]string{"test.cvd"})
+
+	stats, err := feed.Update(context.Background())
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if stats.Failed != 1 || stats.Downloaded != 0 {
+		t.Errorf("Update() stats = %s, want one failure", stats)
+	}
+
+	// The existing database must be left in place.
+	if v, err := feed.GetLocalVersion("test.cvd"); err != nil || v != 1 {
+		t.Errorf("GetLocalVersion() = %d, %v; want 1", v, err)
+	}
+}
+
 func TestClamAVDBFeed_Update_SkipsUpToDate(t *testing.T) {
 	t.Parallel()
 
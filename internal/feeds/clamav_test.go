@@ -5,6 +5,7 @@ package feeds
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 )
@@ -41,9 +42,9 @@ func TestParseCVDHeader_Valid(t *testing.T) {
 	headerData := make([]byte, 512)
 	copy(headerData, []byte("ClamAV-VDB:07 Nov 2023:100:1000000:63:abcdef1234567890:signature:builder:1699372800"))
 
-	header, err := parseCVDHeader(headerData)
+	header, err := ParseCVDHeader(headerData)
 	if err != nil {
-		t.Fatalf("parseCVDHeader() error = %v", err)
+		t.Fatalf("ParseCVDHeader() error = %v", err)
 	}
 
 	if header.Name != "ClamAV-VDB" {
@@ -79,9 +80,9 @@ func TestParseCVDHeader_Invalid(t *testing.T) {
 			headerData := make([]byte, 512)
 			copy(headerData, tt.data)
 
-			_, err := parseCVDHeader(headerData)
+			_, err := ParseCVDHeader(headerData)
 			if err == nil {
-				t.Error("parseCVDHeader() expected error")
+				t.Error("ParseCVDHeader() expected error")
 			}
 		})
 	}
@@ -198,3 +199,54 @@ func TestClamAVFeed_ParseCVD_TooSmall(t *testing.T) {
 		t.Error("ParseCVD() expected error for small data")
 	}
 }
+
+func TestVerifyCVD(t *testing.T) {
+	t.Parallel()
+
+	valid := createTestCVD(42)
+
+	corrupted := append([]byte(nil), valid...)
+	corrupted[len(corrupted)-1] ^= 0xff
+
+	tests := []struct {
+		name     string
+		data     []byte
+		wantErr  bool
+		mismatch bool
+	}{
+		{name: "valid", data: valid},
+		{name: "truncated body", data: valid[:len(valid)-5], wantErr: true, mismatch: true},
+		{name: "corrupted body", data: corrupted, wantErr: true, mismatch: true},
+		{name: "header only", data: valid[:cvdHeaderSize], wantErr: true, mismatch: true},
+		{name: "too small", data: valid[:100], wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			header, err := VerifyCVD(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyCVD() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrCVDVerification) != tt.mismatch {
+				t.Errorf("VerifyCVD() error = %v, want ErrCVDVerification: %v", err, tt.mismatch)
+			}
+			if !tt.wantErr && header.Version != 42 {
+				t.Errorf("Version = %d, want 42", header.Version)
+			}
+		})
+	}
+}
+
+func TestClamAVFeed_ParseCVD_Corrupted(t *testing.T) {
+	t.Parallel()
+
+	data := createTestCVD(1)
+	data = data[:len(data)-3]
+
+	_, err := NewClamAVFeed().ParseCVD(context.Background(), data)
+	if !errors.Is(err, ErrCVDVerification) {
+		t.Errorf("ParseCVD() error = %v, want ErrCVDVerification", err)
+	}
+}
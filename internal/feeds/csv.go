@@ -15,12 +15,28 @@ import (
 
 // CSVConfig holds configuration for CSV parsing.
 type CSVConfig struct {
-	// Column indices (0-based, -1 means not present).
+	// Column indices (0-based, -1 means not present). A row is imported
+	// when at least one hash column holds a valid hash; hashes are
+	// validated by length. Detection and severity columns left at 0 are
+	// treated as absent when a hash column is at 0.
 	SHA256Column    int
 	SHA1Column      int
 	MD5Column       int
 	DetectionColumn int
 
+	// SeverityColumn holds a severity name (low, medium, high, critical).
+	// Unrecognised values fall back to DefaultSeverity.
+	SeverityColumn int
+
+	// ThreatTypeColumn, SourceColumn, and FirstSeenColumn are optional and
+	// cannot be column 0: 0 (the zero value) and -1 both mean absent.
+	// Unrecognised threat types fall back to DefaultThreatType, an empty
+	// source to the feed name, and a first-seen time that is not RFC 3339
+	// to the import time.
+	ThreatTypeColumn int
+	SourceColumn     int
+	FirstSeenColumn  int
+
 	// Skip the first line (header).
 	SkipHeader bool
 
@@ -34,6 +50,9 @@ type CSVConfig struct {
 	DefaultThreatType  types.ThreatType
 	DefaultSeverity    types.Severity
 	DefaultDescription string
+
+	// TTL, when non-zero, makes each signature expire TTL after FirstSeen.
+	TTL time.Duration
 }
 
 // CSVFeed parses CSV formatted signature feeds.
@@ -45,15 +64,20 @@ type CSVFeed struct {
 // NewCSVFeed creates a new CSV feed parser.
 func NewCSVFeed(name string, config CSVConfig) *CSVFeed {
 	// Set defaults.
-	if config.SHA1Column == 0 && config.SHA256Column != 0 {
+	if config.SHA1Column == 0 && config.SHA256Column > 0 {
 		config.SHA1Column = -1
 	}
-	if config.MD5Column == 0 && config.SHA256Column != 0 {
+	if config.MD5Column == 0 && config.SHA256Column > 0 {
 		config.MD5Column = -1
 	}
-	if config.DetectionColumn == 0 && config.SHA256Column != 0 {
+	// Detection and severity columns left at 0 would read a hash.
+	hashAtZero := config.SHA256Column == 0 || config.SHA1Column == 0 || config.MD5Column == 0
+	if config.DetectionColumn == 0 && hashAtZero {
 		config.DetectionColumn = -1
 	}
+	if config.SeverityColumn == 0 && hashAtZero {
+		config.SeverityColumn = -1
+	}
 	if config.Delimiter == 0 {
 		config.Delimiter = ','
 	}
@@ -119,15 +143,7 @@ func (f *CSVFeed) Parse(ctx context.Context, r io.Reader) ([]*types.Signature, e
 func (f *CSVFeed) parseLine(line string) *types.Signature {
 	fields := strings.Split(line, string(f.config.Delimiter))
 
-	// Extract SHA256 (required).
-	sha256 := f.getField(fields, f.config.SHA256Column)
-	if sha256 == "" || !isValidSHA256(sha256) {
-		return nil
-	}
-
-	// Create signature.
 	sig := &types.Signature{
-		SHA256:      strings.ToLower(sha256),
 		ThreatType:  f.config.DefaultThreatType,
 		Severity:    f.config.DefaultSeverity,
 		Source:      f.name,
@@ -135,18 +151,41 @@ func (f *CSVFeed) parseLine(line string) *types.Signature {
 		Description: f.config.DefaultDescription,
 	}
 
-	// Extract optional fields.
-	if sha1 := f.getField(fields, f.config.SHA1Column); sha1 != "" && isValidSHA1(sha1) {
+	// Extract hashes; at least one must be valid.
+	if sha256 := f.getField(fields, f.config.SHA256Column); isValidSHA256(sha256) {
+		sig.SHA256 = strings.ToLower(sha256)
+	}
+	if sha1 := f.getField(fields, f.config.SHA1Column); isValidSHA1(sha1) {
 		sig.SHA1 = strings.ToLower(sha1)
 	}
-	if md5 := f.getField(fields, f.config.MD5Column); md5 != "" && isValidMD5(md5) {
+	if md5 := f.getField(fields, f.config.MD5Column); isValidMD5(md5) {
 		sig.MD5 = strings.ToLower(md5)
 	}
+	if sig.SHA256 == "" && sig.SHA1 == "" && sig.MD5 == "" {
+		return nil
+	}
+	if firstSeen, err := time.Parse(time.RFC3339, f.getOptionalField(fields, f.config.FirstSeenColumn)); err == nil {
+		sig.FirstSeen = firstSeen.UTC()
+	}
+	if f.config.TTL > 0 {
+		sig.WithTTL(f.config.TTL)
+	}
+
+	// Extract optional fields.
 	if detection := f.getField(fields, f.config.DetectionColumn); detection != "" {
 		sig.DetectionName = detection
 	} else {
 		sig.DetectionName = f.name + ".Malware"
 	}
+	if severity, ok := types.ParseSeverity(f.getField(fields, f.config.SeverityColumn)); ok {
+		sig.Severity = severity
+	}
+	if threatType, ok := types.ParseThreatType(f.getOptionalField(fields, f.config.ThreatTypeColumn)); ok {
+		sig.ThreatType = threatType
+	}
+	if source := f.getOptionalField(fields, f.config.SourceColumn); source != "" {
+		sig.Source = source
+	}
 
 	return sig
 }
@@ -159,6 +198,14 @@ func (f *CSVFeed) getField(fields []string, index int) string {
 	return strings.TrimSpace(fields[index])
 }
 
+// getOptionalField is getField for columns where 0 means absent.
+func (f *CSVFeed) getOptionalField(fields []string, index int) string {
+	if index == 0 {
+		return ""
+	}
+	return f.getField(fields, index)
+}
+
 // isValidSHA256 checks if a string is a valid SHA256 hash.
 func isValidSHA256(s string) bool {
 	if len(s) != 64 {
@@ -7,6 +7,7 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hikmaai-io/hikmaai-argus/internal/feeds"
 	"github.com/hikmaai-io/hikmaai-argus/internal/types"
@@ -180,3 +181,123 @@ func TestCSVFeed_ThreatType(t *testing.T) {
 		t.Errorf("Description = %v, want 'Test malware'", sigs[0].Description)
 	}
 }
+
+func TestCSVFeed_ParseMD5Only(t *testing.T) {
+	t.Parallel()
+
+	csvData := `# vendor MD5 list
+44d88612fea8a8f36de82e1278abb02f
+D41D8CD98F00B204E9800998ECF8427E
+275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f
+not-a-hash
+`
+
+	feed := feeds.NewCSVFeed("vendor", feeds.CSVConfig{
+		SHA256Column: -1,
+		MD5Column:    0,
+		CommentChar:  '#',
+	})
+
+	sigs, err := feed.Parse(context.Background(), strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	// The SHA256 line is not a valid MD5 and is skipped.
+	if len(sigs) != 2 {
+		t.Fatalf("len(sigs) = %d, want 2", len(sigs))
+	}
+	if sigs[0].MD5 != "44d88612fea8a8f36de82e1278abb02f" || sigs[0].SHA256 != "" {
+		t.Errorf("sigs[0] = MD5 %q SHA256 %q, want MD5 only", sigs[0].MD5, sigs[0].SHA256)
+	}
+	if sigs[1].MD5 != "d41d8cd98f00b204e9800998ecf8427e" {
+		t.Errorf("sigs[1].MD5 = %q, want lowercased", sigs[1].MD5)
+	}
+	if sigs[0].DetectionName != "vendor.Malware" {
+		t.Errorf("DetectionName = %q, want default vendor.Malware", sigs[0].DetectionName)
+	}
+}
+
+func TestCSVFeed_ParseDetectionAndSeverity(t *testing.T) {
+	t.Parallel()
+
+	csvData := `sha1,name,severity
+3395856ce81f2b7382dee72602f798b642f14140,Win.Trojan.Emotet,critical
+da39a3ee5e6b4b0d3255bfef95601890afd80709,Unix.Miner.XMRig,LOW
+a94a8fe5ccb19ba61c4c0873d391e987982fbbd3,PUA.Adware,bogus
+`
+
+	feed := feeds.NewCSVFeed("vendor", feeds.CSVConfig{
+		SHA256Column:    -1,
+		SHA1Column:      0,
+		DetectionColumn: 1,
+		SeverityColumn:  2,
+		SkipHeader:      true,
+		DefaultSeverity: types.SeverityMedium,
+	})
+
+	sigs, err := feed.Parse(context.Background(), strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if len(sigs) != 3 {
+		t.Fatalf("len(sigs) = %d, want 3", len(sigs))
+	}
+
+	tests := []struct {
+		detection string
+		severity  types.Severity
+	}{
+		{detection: "Win.Trojan.Emotet", severity: types.SeverityCritical},
+		{detection: "Unix.Miner.XMRig", severity: types.SeverityLow},
+		{detection: "PUA.Adware", severity: types.SeverityMedium},
+	}
+	for i, tt := range tests {
+		if sigs[i].DetectionName != tt.detection {
+			t.Errorf("sigs[%d].DetectionName = %q, want %q", i, sigs[i].DetectionName, tt.detection)
+		}
+		if sigs[i].Severity != tt.severity {
+			t.Errorf("sigs[%d].Severity = %v, want %v", i, sigs[i].Severity, tt.severity)
+		}
+		if sigs[i].SHA1 == "" {
+			t.Errorf("sigs[%d].SHA1 is empty", i)
+		}
+	}
+}
+
+func TestCSVFeed_SHA256OnlyUsesDefaultDetection(t *testing.T) {
+	t.Parallel()
+
+	feed := feeds.NewCSVFeed("test", feeds.CSVConfig{SHA256Column: 0})
+
+	sigs, err := feed.Parse(context.Background(), strings.NewReader("275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f\n"))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if len(sigs) != 1 {
+		t.Fatalf("len(sigs) = %d, want 1", len(sigs))
+	}
+	if sigs[0].DetectionName != "test.Malware" {
+		t.Errorf("DetectionName = %q, want test.Malware rather than the hash", sigs[0].DetectionName)
+	}
+}
+
+func TestCSVFeed_TTL(t *testing.T) {
+	t.Parallel()
+
+	feed := feeds.NewCSVFeed("test", feeds.CSVConfig{SHA256Column: 0, TTL: 90 * 24 * time.Hour})
+
+	sigs, err := feed.Parse(context.Background(), strings.NewReader("275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f\n"))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if len(sigs) != 1 {
+		t.Fatalf("len(sigs) = %d, want 1", len(sigs))
+	}
+	if sigs[0].ExpiresAt == nil {
+		t.Fatal("ExpiresAt = nil, want FirstSeen + TTL")
+	}
+	if got := sigs[0].ExpiresAt.Sub(sigs[0].FirstSeen); got != 90*24*time.Hour {
+		t.Errorf("ExpiresAt - FirstSeen = %v, want %v", got, 90*24*time.Hour)
+	}
+}
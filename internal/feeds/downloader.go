@@ -1,16 +1,26 @@
 // ABOUTME: HTTP downloader for fetching feed data from remote URLs
-// ABOUTME: Supports configurable timeouts and user-agent
+// ABOUTME: Supports configurable timeouts, user-agent, conditional GETs, and resumable downloads
 
 package feeds
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"sync"
 	"time"
 )
 
+// ErrNotModified is returned by Download when the server answers a
+// conditional request with 304 Not Modified.
+var ErrNotModified = errors.New("not modified")
+
 // DownloaderConfig holds configuration for the HTTP downloader.
 type DownloaderConfig struct {
 	// Timeout for HTTP requests.
@@ -21,6 +31,75 @@ type DownloaderConfig struct {
 
 	// MaxSize limits the maximum download size in bytes (0 = unlimited).
 	MaxSize int64
+
+	// Validators stores ETag/Last-Modified per URL. When set, downloads are
+	// conditional and unchanged resources return ErrNotModified.
+	Validators ValidatorCache
+
+	// StagingDir, when set, stages downloads in files under this directory.
+	// A download interrupted midway is resumed with a Range request on the
+	// next attempt, provided the server supports ranges and the remote
+	// content is unchanged.
+	StagingDir string
+
+	// ProxyURL routes requests through an HTTP(S) proxy, e.g.
+	// "http://proxy.internal:3128". Empty uses the HTTP_PROXY, HTTPS_PROXY,
+	// and NO_PROXY environment variables.
+	ProxyURL string
+
+	// CACertFile is a PEM bundle of additional CAs trusted for TLS, for
+	// mirrors signed by a private CA. The system roots stay trusted.
+	CACertFile string
+
+	// InsecureSkipVerify disables TLS certificate verification. Only use
+	// it for testing.
+	InsecureSkipVerify bool
+}
+
+// newHTTPClient builds an HTTP client with the config's timeout, proxy, and
+// TLS settings.
+func (c DownloaderConfig) newHTTPClient() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if c.ProxyURL != "" {
+		proxy, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing proxy URL: %w", err)
+		}
+		if proxy.Scheme == "" || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q: scheme and host are required", c.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if c.CACertFile != "" || c.InsecureSkipVerify {
+		tlsConfig := &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: c.InsecureSkipVerify,
+		}
+
+		if c.CACertFile != "" {
+			pem, err := os.ReadFile(c.CACertFile)
+			if err != nil {
+				return nil, fmt.Errorf("reading CA certificate file: %w", err)
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s", c.CACertFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &http.Client{
+		Timeout:   c.Timeout,
+		Transport: transport,
+	}, nil
 }
 
 // DefaultDownloaderConfig returns sensible default configuration.
@@ -36,52 +115,284 @@ func DefaultDownloaderConfig() DownloaderConfig {
 type Downloader struct {
 	client *http.Client
 	config DownloaderConfig
+
+	// err is the error building client from config, returned by every
+	// download so a bad proxy or CA setting is not silently ignored.
+	err error
+
+	// pending holds validators of completed downloads until the caller
+	// has stored their content and calls CommitValidators.
+	mu      sync.Mutex
+	pending map[string]Validators
 }
 
 // NewDownloader creates a new HTTP downloader.
-// If config is nil, default configuration is used.
+// If config is nil, default configuration is used. An invalid proxy or CA
+// setting makes every download fail; use Configure to check it up front.
 func NewDownloader(config *DownloaderConfig) *Downloader {
 	cfg := DefaultDownloaderConfig()
 	if config != nil {
 		cfg = *config
 	}
 
-	return &Downloader{
-		client: &http.Client{
-			Timeout: cfg.Timeout,
-		},
-		config: cfg,
+	d := &Downloader{config: cfg}
+	d.err = d.Configure(cfg)
+	return d
+}
+
+// Configure replaces the downloader's configuration. Timeout, UserAgent,
+// MaxSize, Validators, and StagingDir keep their current setting when left
+// at their zero value, so feeds can apply proxy and TLS settings after
+// SetValidatorCache/SetStagingDir without losing them. The proxy and TLS
+// fields are always replaced: zero values restore the environment proxy
+// and default certificate verification.
+func (d *Downloader) Configure(cfg DownloaderConfig) error {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = d.config.Timeout
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = d.config.UserAgent
+	}
+	if cfg.MaxSize == 0 {
+		cfg.MaxSize = d.config.MaxSize
+	}
+	if cfg.Validators == nil {
+		cfg.Validators = d.config.Validators
+	}
+	if cfg.StagingDir == "" {
+		cfg.StagingDir = d.config.StagingDir
+	}
+
+	client, err := cfg.newHTTPClient()
+	if err != nil {
+		return err
 	}
+
+	d.client = client
+	d.config = cfg
+	d.err = nil
+	return nil
+}
+
+// HTTPClient returns the client used for downloads, for requests the
+// Downloader does not cover itself such as ranged header probes.
+func (d *Downloader) HTTPClient() *http.Client {
+	return d.client
+}
+
+// SetValidatorCache enables conditional downloads backed by cache.
+// A nil cache disables them.
+func (d *Downloader) SetValidatorCache(cache ValidatorCache) {
+	d.config.Validators = cache
+}
+
+// SetStagingDir enables resumable downloads staged under dir.
+// An empty dir disables them.
+func (d *Downloader) SetStagingDir(dir string) {
+	d.config.StagingDir = dir
 }
 
-// Download fetches data from the given URL.
+// Download fetches data from the given URL. With a validator cache set it
+// sends If-None-Match/If-Modified-Since from the previous committed
+// download and returns ErrNotModified if the server reports no change. The
+// response's validators are held until CommitValidators is called.
 func (d *Downloader) Download(ctx context.Context, url string) ([]byte, error) {
+	if d.err != nil {
+		return nil, fmt.Errorf("configuring HTTP client: %w", d.err)
+	}
+	if d.config.StagingDir != "" {
+		path, resp, err := d.downloadStaged(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(path)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading staged download: %w", err)
+		}
+		d.stageValidators(url, resp)
+		return data, nil
+	}
+
+	resp, err := d.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var reader io.Reader = resp.Body
+	if d.config.MaxSize > 0 {
+		reader = io.LimitReader(resp.Body, d.config.MaxSize)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	// Remember validators only once the body has been read in full.
+	d.stageValidators(url, resp)
+
+	return data, nil
+}
+
+// Stream fetches url and returns the response body for incremental reading,
+// bounded by MaxSize. The caller must close it. Conditional requests work as
+// in Download; validators are held once the body has been read to EOF and
+// saved by CommitValidators.
+func (d *Downloader) Stream(ctx context.Context, url string) (io.ReadCloser, error) {
+	if d.err != nil {
+		return nil, fmt.Errorf("configuring HTTP client: %w", d.err)
+	}
+	if d.config.StagingDir != "" {
+		// The whole body is staged on disk first so an interrupted
+		// transfer can be resumed, then streamed from the staging file.
+		path, resp, err := d.downloadStaged(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			os.Remove(path)
+			return nil, fmt.Errorf("opening staged download: %w", err)
+		}
+		return &downloadStream{Reader: f, resp: resp, url: url, d: d, file: f}, nil
+	}
+
+	resp, err := d.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader = resp.Body
+	if d.config.MaxSize > 0 {
+		reader = io.LimitReader(resp.Body, d.config.MaxSize)
+	}
+
+	return &downloadStream{Reader: reader, resp: resp, url: url, d: d}, nil
+}
+
+// get performs a (possibly conditional) GET and returns a 200 response.
+func (d *Downloader) get(ctx context.Context, url string) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", d.config.UserAgent)
+	d.setConditionalHeaders(req, url)
 
 	resp, err := d.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("performing request: %w", err)
 	}
-	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && d.config.Validators != nil {
+		resp.Body.Close()
+		return nil, ErrNotModified
+	}
 	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	var reader io.Reader = resp.Body
-	if d.config.MaxSize > 0 {
-		reader = io.LimitReader(resp.Body, d.config.MaxSize)
+	return resp, nil
+}
+
+// setConditionalHeaders adds the validators of the previous download of url.
+func (d *Downloader) setConditionalHeaders(req *http.Request, url string) {
+	if d.config.Validators == nil {
+		return
+	}
+	if v, ok := d.config.Validators.Get(url); ok {
+		if v.ETag != "" {
+			req.Header.Set("If-None-Match", v.ETag)
+		}
+		if v.LastModified != "" {
+			req.Header.Set("If-Modified-Since", v.LastModified)
+		}
 	}
+}
 
-	data, err := io.ReadAll(reader)
-	if err != nil {
-		return nil, fmt.Errorf("reading response: %w", err)
+// stageValidators holds the response's ETag/Last-Modified for url until
+// CommitValidators is called.
+func (d *Downloader) stageValidators(url string, resp *http.Response) {
+	if d.config.Validators == nil {
+		return
 	}
 
-	return data, nil
+	v := Validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	if v.ETag == "" && v.LastModified == "" {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.pending == nil {
+		d.pending = make(map[string]Validators)
+	}
+	d.pending[url] = v
+}
+
+// CommitValidators saves the validators of downloads completed since the
+// last commit, so later downloads of those URLs are conditional. Call it
+// only once their content has been stored: a failed store must not turn the
+// next download into a 304 that skips the content.
+func (d *Downloader) CommitValidators() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for url, v := range d.pending {
+		if err := d.config.Validators.Set(url, v); err != nil {
+			return fmt.Errorf("saving cache validators: %w", err)
+		}
+		delete(d.pending, url)
+	}
+	return nil
+}
+
+// discardValidators drops validators that were never committed, e.g.
+// because storing the previous fetch failed.
+func (d *Downloader) discardValidators() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	clear(d.pending)
+}
+
+// downloadStream is the body returned by Stream.
+type downloadStream struct {
+	io.Reader
+	resp *http.Response
+	url  string
+	d    *Downloader
+	done bool
+
+	// file is the staging file being streamed, if any.
+	file *os.File
+}
+
+// Read reads from the body and stages validators at EOF.
+func (s *downloadStream) Read(p []byte) (int, error) {
+	n, err := s.Reader.Read(p)
+	if errors.Is(err, io.EOF) && !s.done {
+		s.done = true
+		s.d.stageValidators(s.url, s.resp)
+	}
+	return n, err
+}
+
+// Close closes the response body, or closes and removes the staging file.
+func (s *downloadStream) Close() error {
+	if s.file != nil {
+		err := s.file.Close()
+		os.Remove(s.file.Name())
+		return err
+	}
+	return s.resp.Body.Close()
 }
@@ -0,0 +1,210 @@
+// ABOUTME: Resumable downloads staged on disk using HTTP Range requests
+// ABOUTME: Partial files are resumed only while the remote ETag/Last-Modified is unchanged
+
+package feeds
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// partialMeta describes a partially downloaded staging file.
+type partialMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// ifRange returns the validator to send in If-Range. Weak ETags cannot be
+// used for range requests, so Last-Modified is the fallback.
+func (m partialMeta) ifRange() string {
+	if m.ETag != "" && !strings.HasPrefix(m.ETag, "W/") {
+		return m.ETag
+	}
+	return m.LastModified
+}
+
+// errRangeMismatch means a 206 response does not continue the staged file.
+var errRangeMismatch = errors.New("partial content does not match staged download")
+
+// stagingPath returns the staging file for url.
+func (d *Downloader) stagingPath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(d.config.StagingDir, hex.EncodeToString(sum[:8])+".part")
+}
+
+// downloadStaged fetches url into its staging file and returns the path of
+// the completed file along with the response that finished it. The caller
+// owns the file and must remove it.
+func (d *Downloader) downloadStaged(ctx context.Context, url string) (string, *http.Response, error) {
+	if err := os.MkdirAll(d.config.StagingDir, 0o755); err != nil {
+		return "", nil, fmt.Errorf("creating staging directory: %w", err)
+	}
+
+	path := d.stagingPath(url)
+	resp, err := d.fetchToFile(ctx, url, path, true)
+	if errors.Is(err, errRangeMismatch) {
+		// The remote changed under us; start over.
+		resp, err = d.fetchToFile(ctx, url, path, false)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	return path, resp, nil
+}
+
+// fetchToFile downloads url into path, resuming from the existing partial
+// file when resume is set and the partial is still valid. On a read error
+// the partial file is kept so the next attempt can resume it.
+func (d *Downloader) fetchToFile(ctx context.Context, url, path string, resume bool) (*http.Response, error) {
+	metaPath := path + ".json"
+
+	var offset int64
+	var meta partialMeta
+	if resume {
+		offset, meta = loadPartial(path, metaPath, url)
+	}
+	if offset == 0 {
+		os.Remove(metaPath)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", d.config.UserAgent)
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", meta.ifRange())
+	} else {
+		d.setConditionalHeaders(req, url)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("performing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		if !continuesPartial(resp, meta, offset) {
+			os.Remove(path)
+			os.Remove(metaPath)
+			return nil, errRangeMismatch
+		}
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusOK:
+		// Full content: the server ignored the range or the remote changed.
+		offset = 0
+		flags |= os.O_TRUNC
+		if err := savePartialMeta(metaPath, url, resp); err != nil {
+			return nil, err
+		}
+	case resp.StatusCode == http.StatusNotModified && offset == 0 && d.config.Validators != nil:
+		return nil, ErrNotModified
+	default:
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening staging file: %w", err)
+	}
+
+	var body io.Reader = resp.Body
+	if d.config.MaxSize > 0 {
+		body = io.LimitReader(resp.Body, max(d.config.MaxSize-offset, 0))
+	}
+
+	_, copyErr := io.Copy(f, body)
+	closeErr := f.Close()
+	if copyErr != nil {
+		return nil, fmt.Errorf("reading response: %w", copyErr)
+	}
+	if closeErr != nil {
+		return nil, fmt.Errorf("writing staging file: %w", closeErr)
+	}
+
+	os.Remove(metaPath)
+	return resp, nil
+}
+
+// loadPartial returns the size and metadata of a resumable partial download
+// of url, or zero if there is none. Unusable partial files are removed.
+func loadPartial(path, metaPath, url string) (int64, partialMeta) {
+	var meta partialMeta
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, meta
+	}
+
+	data, err := os.ReadFile(metaPath)
+	if err == nil {
+		err = json.Unmarshal(data, &meta)
+	}
+	if err != nil || meta.URL != url || meta.ifRange() == "" || info.Size() == 0 {
+		os.Remove(path)
+		return 0, partialMeta{}
+	}
+
+	return info.Size(), meta
+}
+
+// savePartialMeta records what is needed to resume the download of url
+// from resp. Servers that do not advertise byte ranges or send no usable
+// validator get no metadata, so their partial files are never resumed.
+func savePartialMeta(metaPath, url string, resp *http.Response) error {
+	meta := partialMeta{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	if resp.Header.Get("Accept-Ranges") != "bytes" || meta.ifRange() == "" {
+		os.Remove(metaPath)
+		return nil
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("encoding staging metadata: %w", err)
+	}
+	if err := os.WriteFile(metaPath, data, 0o644); err != nil {
+		return fmt.Errorf("writing staging metadata: %w", err)
+	}
+	return nil
+}
+
+// continuesPartial reports whether a 206 response resumes the staged file
+// at offset and still describes the same remote content.
+func continuesPartial(resp *http.Response, meta partialMeta, offset int64) bool {
+	if etag := resp.Header.Get("ETag"); etag != "" && meta.ETag != "" && etag != meta.ETag {
+		return false
+	}
+
+	// Content-Range: bytes <start>-<end>/<size>
+	unit, rest, ok := strings.Cut(resp.Header.Get("Content-Range"), " ")
+	if !ok || unit != "bytes" {
+		return false
+	}
+	start, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return false
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	return err == nil && n == offset
+}
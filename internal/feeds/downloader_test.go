@@ -5,9 +5,19 @@ package feeds
 
 import (
 	"context"
+	"encoding/pem"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestDownloader_Download(t *testing.T) {
@@ -94,3 +104,433 @@ func TestDownloader_ContextCancellation(t *testing.T) {
 		t.Error("Download() expected error for cancelled context")
 	}
 }
+
+// conditionalServer serves body with an ETag and Last-Modified, answering 304
+// when the request carries a matching validator.
+func conditionalServer(t *testing.T, body string, hits *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	const etag = `"v1"`
+	const lastModified = "Mon, 12 Oct 2026 10:00:00 GMT"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.Header.Get("If-None-Match") == etag || r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestDownloader_ConditionalGet(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+	server := conditionalServer(t, "payload", &hits)
+
+	cache := NewMemoryValidatorCache()
+	d := NewDownloader(&DownloaderConfig{Validators: cache})
+
+	data, err := d.Download(context.Background(), server.URL)
+	if err != nil || string(data) != "payload" {
+		t.Fatalf("first Download() = %q, %v; want payload", data, err)
+	}
+	if _, ok := cache.Get(server.URL); ok {
+		t.Error("validators should not be saved before CommitValidators")
+	}
+
+	// An uncommitted download, e.g. one whose store failed, is fetched again.
+	if data, err := d.Download(context.Background(), server.URL); err != nil || string(data) != "payload" {
+		t.Fatalf("uncommitted Download() = %q, %v; want payload", data, err)
+	}
+
+	if err := d.CommitValidators(); err != nil {
+		t.Fatalf("CommitValidators() error = %v", err)
+	}
+	v, ok := cache.Get(server.URL)
+	if !ok || v.ETag != `"v1"` || v.LastModified == "" {
+		t.Errorf("cached validators = %+v, %v", v, ok)
+	}
+
+	if _, err := d.Download(context.Background(), server.URL); !errors.Is(err, ErrNotModified) {
+		t.Errorf("second Download() error = %v, want ErrNotModified", err)
+	}
+
+	// Without a cache, downloads are unconditional.
+	plain := NewDownloader(nil)
+	if data, err := plain.Download(context.Background(), server.URL); err != nil || string(data) != "payload" {
+		t.Errorf("unconditional Download() = %q, %v; want payload", data, err)
+	}
+
+	if n := hits.Load(); n != 4 {
+		t.Errorf("server hits = %d, want 4", n)
+	}
+}
+
+// flakyRangeServer serves first, aborting the first response halfway through,
+// then serves the second request with second. It records each request's
+// Range header.
+func flakyRangeServer(t *testing.T, first, second string, acceptRanges bool, secondETag string, ranges *[]string) *httptest.Server {
+	t.Helper()
+
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		*ranges = append(*ranges, r.Header.Get("Range"))
+		call := len(*ranges)
+		mu.Unlock()
+
+		if call == 1 {
+			w.Header().Set("ETag", `"v1"`)
+			if acceptRanges {
+				w.Header().Set("Accept-Ranges", "bytes")
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(first)))
+			_, _ = w.Write([]byte(first[:len(first)/2]))
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+
+		w.Header().Set("ETag", secondETag)
+		if !acceptRanges {
+			_, _ = w.Write([]byte(second))
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(second))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestDownloader_ResumeStagedDownload(t *testing.T) {
+	t.Parallel()
+
+	const payload = "0123456789abcdefghijklmnopqrstuvwxyz"
+	const changed = "a completely different export body"
+
+	tests := []struct {
+		name         string
+		acceptRanges bool
+		secondETag   string
+		second       string
+		wantRange    string
+		want         string
+	}{
+		{
+			name:         "resumes from partial file",
+			acceptRanges: true,
+			secondETag:   `"v1"`,
+			second:       payload,
+			wantRange:    "bytes=18-",
+			want:         payload,
+		},
+		{
+			name:         "server without range support",
+			acceptRanges: false,
+			secondETag:   `"v1"`,
+			second:       payload,
+			wantRange:    "",
+			want:         payload,
+		},
+		{
+			name:         "remote changed since partial download",
+			acceptRanges: true,
+			secondETag:   `"v2"`,
+			second:       changed,
+			wantRange:    "bytes=18-",
+			want:         changed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var ranges []string
+			server := flakyRangeServer(t, payload, tt.second, tt.acceptRanges, tt.secondETag, &ranges)
+
+			dir := t.TempDir()
+			d := NewDownloader(&DownloaderConfig{StagingDir: dir})
+
+			if _, err := d.Download(context.Background(), server.URL); err == nil {
+				t.Fatal("first Download() error = nil, want interrupted transfer error")
+			}
+
+			data, err := d.Download(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("second Download() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Download() = %q, want %q", data, tt.want)
+			}
+
+			if len(ranges) != 2 || ranges[1] != tt.wantRange {
+				t.Errorf("Range headers = %q, want second request with %q", ranges, tt.wantRange)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("reading staging dir: %v", err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("staging dir holds %d files after download, want 0", len(entries))
+			}
+		})
+	}
+}
+
+func TestDownloader_StreamStaged(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+	server := conditionalServer(t, "staged payload", &hits)
+
+	dir := t.TempDir()
+	cache := NewMemoryValidatorCache()
+	d := NewDownloader(&DownloaderConfig{Validators: cache, StagingDir: dir})
+
+	body, err := d.Stream(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil || string(data) != "staged payload" {
+		t.Fatalf("reading stream = %q, %v", data, err)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("staging dir holds %d files after Close, want 0", len(entries))
+	}
+	if err := d.CommitValidators(); err != nil {
+		t.Fatalf("CommitValidators() error = %v", err)
+	}
+	if _, err := d.Stream(context.Background(), server.URL); !errors.Is(err, ErrNotModified) {
+		t.Errorf("second Stream() error = %v, want ErrNotModified", err)
+	}
+}
+
+// writeServerCA writes the TLS test server's certificate as a PEM file.
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o644); err != nil {
+		t.Fatalf("writing CA file: %v", err)
+	}
+	return path
+}
+
+func TestDownloader_TLS(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("mirror content"))
+	}))
+	t.Cleanup(server.Close)
+
+	caFile := writeServerCA(t, server)
+	garbage := filepath.Join(t.TempDir(), "garbage.pem")
+	if err := os.WriteFile(garbage, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		config       DownloaderConfig
+		wantConfErr  bool
+		wantFetchErr bool
+	}{
+		{name: "untrusted self-signed cert", config: DownloaderConfig{}, wantFetchErr: true},
+		{name: "trusted via CA file", config: DownloaderConfig{CACertFile: caFile}},
+		{name: "insecure skip verify", config: DownloaderConfig{InsecureSkipVerify: true}},
+		{name: "missing CA file", config: DownloaderConfig{CACertFile: filepath.Join(t.TempDir(), "missing.pem")}, wantConfErr: true},
+		{name: "CA file without certificates", config: DownloaderConfig{CACertFile: garbage}, wantConfErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			d := NewDownloader(nil)
+			err := d.Configure(tt.config)
+			if (err != nil) != tt.wantConfErr {
+				t.Fatalf("Configure() error = %v, wantErr %v", err, tt.wantConfErr)
+			}
+			if tt.wantConfErr {
+				// NewDownloader surfaces the same error on download.
+				if _, err := NewDownloader(&tt.config).Download(context.Background(), server.URL); err == nil {
+					t.Error("Download() with invalid config error = nil, want error")
+				}
+				return
+			}
+
+			data, err := d.Download(context.Background(), server.URL)
+			if (err != nil) != tt.wantFetchErr {
+				t.Fatalf("Download() error = %v, wantErr %v", err, tt.wantFetchErr)
+			}
+			if !tt.wantFetchErr && string(data) != "mirror content" {
+				t.Errorf("Download() = %q, want %q", data, "mirror content")
+			}
+		})
+	}
+}
+
+func TestDownloader_Proxy(t *testing.T) {
+	t.Parallel()
+
+	// The proxy answers for any absolute-form request it receives.
+	var proxied atomic.Value
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Store(r.URL.String())
+		_, _ = w.Write([]byte("via proxy"))
+	}))
+	t.Cleanup(proxy.Close)
+
+	d := NewDownloader(nil)
+	if err := d.Configure(DownloaderConfig{ProxyURL: proxy.URL}); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+
+	const target = "http://feeds.example.invalid/export.txt"
+	data, err := d.Download(context.Background(), target)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if string(data) != "via proxy" {
+		t.Errorf("Download() = %q, want %q", data, "via proxy")
+	}
+	if got, _ := proxied.Load().(string); got != target {
+		t.Errorf("proxy saw request for %q, want %q", got, target)
+	}
+
+	if err := d.Configure(DownloaderConfig{ProxyURL: "proxy.internal:3128"}); err == nil {
+		t.Error("Configure() with proxy URL lacking a scheme error = nil, want error")
+	}
+
+	// A zero config drops the proxy but keeps the staging directory.
+	direct := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("direct"))
+	}))
+	t.Cleanup(direct.Close)
+
+	d.SetStagingDir(t.TempDir())
+	if err := d.Configure(DownloaderConfig{ProxyURL: proxy.URL}); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	if err := d.Configure(DownloaderConfig{}); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	data, err = d.Download(context.Background(), direct.URL)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if string(data) != "direct" {
+		t.Errorf("Download() after clearing the proxy = %q, want %q", data, "direct")
+	}
+	if d.config.StagingDir == "" {
+		t.Error("Configure() with a zero StagingDir dropped the staging directory")
+	}
+}
+
+func TestMalwareBazaarFeed_SetDownloaderConfig(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	feed := NewMalwareBazaarFeed()
+	feed.SetURL(server.URL)
+	feed.SetValidatorCache(NewMemoryValidatorCache())
+	if err := feed.SetDownloaderConfig(DownloaderConfig{CACertFile: writeServerCA(t, server)}); err != nil {
+		t.Fatalf("SetDownloaderConfig() error = %v", err)
+	}
+
+	sigs, err := feed.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(sigs) != 1 {
+		t.Errorf("Fetch() returned %d signatures, want 1", len(sigs))
+	}
+	if feed.downloader.config.Validators == nil {
+		t.Error("SetDownloaderConfig() dropped the validator cache")
+	}
+	if feed.downloader.config.UserAgent != DefaultDownloaderConfig().UserAgent {
+		t.Errorf("UserAgent = %q, want the default kept", feed.downloader.config.UserAgent)
+	}
+}
+
+func TestFileValidatorCache_Persists(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state", "validators.json")
+
+	cache, err := NewFileValidatorCache(path)
+	if err != nil {
+		t.Fatalf("NewFileValidatorCache() error = %v", err)
+	}
+	if _, ok := cache.Get("https://example.com/a"); ok {
+		t.Error("new cache should be empty")
+	}
+
+	want := Validators{ETag: `"abc"`, LastModified: "Mon, 12 Oct 2026 10:00:00 GMT"}
+	if err := cache.Set("https://example.com/a", want); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	reloaded, err := NewFileValidatorCache(path)
+	if err != nil {
+		t.Fatalf("reloading cache: %v", err)
+	}
+	if got, ok := reloaded.Get("https://example.com/a"); !ok || got != want {
+		t.Errorf("Get() after reload = %+v, %v; want %+v", got, ok, want)
+	}
+}
+
+func TestDownloader_Stream(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+	server := conditionalServer(t, "streamed payload", &hits)
+
+	cache := NewMemoryValidatorCache()
+	d := NewDownloader(&DownloaderConfig{Validators: cache})
+
+	body, err := d.Stream(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	if _, ok := cache.Get(server.URL); ok {
+		t.Error("validators should not be saved before the body is read")
+	}
+
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil || string(data) != "streamed payload" {
+		t.Fatalf("reading stream = %q, %v", data, err)
+	}
+	if _, ok := cache.Get(server.URL); ok {
+		t.Error("validators should not be saved before CommitValidators")
+	}
+	if err := d.CommitValidators(); err != nil {
+		t.Fatalf("CommitValidators() error = %v", err)
+	}
+	if _, ok := cache.Get(server.URL); !ok {
+		t.Error("validators should be saved once committed")
+	}
+
+	if _, err := d.Stream(context.Background(), server.URL); !errors.Is(err, ErrNotModified) {
+		t.Errorf("second Stream() error = %v, want ErrNotModified", err)
+	}
+}
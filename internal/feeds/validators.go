@@ -0,0 +1,124 @@
+// ABOUTME: HTTP cache validator storage (ETag/Last-Modified) for conditional GETs
+// ABOUTME: Provides in-memory and JSON file backed caches keyed by URL
+
+package feeds
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Validators are the HTTP cache validators of a previous download.
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// ValidatorCache stores validators per URL. Validators are recorded when the
+// caller commits a download, so a later 304 means its content was already
+// stored once.
+type ValidatorCache interface {
+	Get(url string) (Validators, bool)
+	Set(url string, v Validators) error
+}
+
+// MemoryValidatorCache is a ValidatorCache that lives for the process.
+type MemoryValidatorCache struct {
+	mu      sync.RWMutex
+	entries map[string]Validators
+}
+
+// NewMemoryValidatorCache creates an empty in-memory cache.
+func NewMemoryValidatorCache() *MemoryValidatorCache {
+	return &MemoryValidatorCache{entries: make(map[string]Validators)}
+}
+
+// Get returns the validators stored for url.
+func (c *MemoryValidatorCache) Get(url string) (Validators, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	v, ok := c.entries[url]
+	return v, ok
+}
+
+// Set stores the validators for url.
+func (c *MemoryValidatorCache) Set(url string, v Validators) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[url] = v
+	return nil
+}
+
+// FileValidatorCache is a ValidatorCache persisted as a JSON file so
+// conditional downloads survive restarts.
+type FileValidatorCache struct {
+	path string
+
+	mu      sync.RWMutex
+	entries map[string]Validators
+}
+
+// NewFileValidatorCache loads the cache stored at path. A missing file
+// starts an empty cache.
+func NewFileValidatorCache(path string) (*FileValidatorCache, error) {
+	c := &FileValidatorCache{
+		path:    path,
+		entries: make(map[string]Validators),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading validator cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, fmt.Errorf("parsing validator cache: %w", err)
+	}
+
+	return c, nil
+}
+
+// Get returns the validators stored for url.
+func (c *FileValidatorCache) Get(url string) (Validators, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	v, ok := c.entries[url]
+	return v, ok
+}
+
+// Set stores the validators for url and rewrites the file atomically.
+func (c *FileValidatorCache) Set(url string, v Validators) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[url] = v
+
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding validator cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("writing validator cache: %w", err)
+	}
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("renaming validator cache: %w", err)
+	}
+
+	return nil
+}
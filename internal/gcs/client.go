@@ -1,5 +1,5 @@
-// ABOUTME: GCS client for downloading skill archives with checksum verification
-// ABOUTME: Supports ADC authentication, emulator mode, and organization path validation
+// ABOUTME: GCS client for downloading or streaming skill archives and uploading scan reports
+// ABOUTME: Supports ADC authentication, emulator mode, checksum verification, and org path validation
 
 package gcs
 
@@ -9,17 +9,39 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/dbupdater"
+)
+
+// Default download retry values.
+const (
+	// DefaultMaxRetries is the number of times a download is retried after
+	// a transient failure.
+	DefaultMaxRetries = 3
+
+	// DefaultRetryDelay is the delay before the first retry; it doubles on
+	// each subsequent retry up to maxRetryDelay.
+	DefaultRetryDelay = 500 * time.Millisecond
+
+	maxRetryDelay = 10 * time.Second
 )
 
+// ErrChecksumMismatch is returned when a downloaded file does not match its
+// expected SHA256 checksum.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
 // Config holds GCS client configuration.
 type Config struct {
 	// Bucket is the GCS bucket name.
@@ -40,6 +62,15 @@ type Config struct {
 	// This works around googleapis/google-cloud-go#6139 where the SDK
 	// uses path-style URLs that fake-gcs-server doesn't support.
 	EmulatorHost string
+
+	// MaxRetries is how many times a download is retried after a
+	// connection error, a 429, or a 5xx response. Zero uses
+	// DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+
+	// RetryDelay is the delay before the first retry. Zero uses
+	// DefaultRetryDelay.
+	RetryDelay time.Duration
 }
 
 // Validate checks that required fields are set.
@@ -72,6 +103,8 @@ type Client struct {
 	bucket        string
 	downloadDir   string
 	emulatorHost  string // Non-empty when using emulator mode
+	maxRetries    int
+	retryDelay    time.Duration
 }
 
 // NewClient creates a new GCS client.
@@ -88,6 +121,16 @@ func NewClient(ctx context.Context, cfg Config) (*Client, error) {
 		emulatorHost = os.Getenv("STORAGE_EMULATOR_HOST")
 	}
 
+	maxRetries := cfg.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = DefaultMaxRetries
+	}
+
+	retryDelay := cfg.RetryDelay
+	if retryDelay == 0 {
+		retryDelay = DefaultRetryDelay
+	}
+
 	// If using emulator, use HTTP client directly
 	if emulatorHost != "" {
 		return &Client{
@@ -95,6 +138,8 @@ func NewClient(ctx context.Context, cfg Config) (*Client, error) {
 			bucket:       cfg.Bucket,
 			downloadDir:  cfg.DownloadDir,
 			emulatorHost: emulatorHost,
+			maxRetries:   maxRetries,
+			retryDelay:   retryDelay,
 		}, nil
 	}
 
@@ -113,6 +158,8 @@ func NewClient(ctx context.Context, cfg Config) (*Client, error) {
 		storageClient: client,
 		bucket:        cfg.Bucket,
 		downloadDir:   cfg.DownloadDir,
+		maxRetries:    maxRetries,
+		retryDelay:    retryDelay,
 	}, nil
 }
 
@@ -130,8 +177,17 @@ func (c *Client) IsEmulatorMode() bool {
 }
 
 // Download downloads an object from GCS to the local filesystem.
-// The file is saved to DownloadDir/jobID/filename.
+// The file is saved to DownloadDir/jobID/filename. Transient failures are
+// retried with exponential backoff.
 func (c *Client) Download(ctx context.Context, objectPath, jobID string) (*DownloadResult, error) {
+	return c.DownloadWithChecksum(ctx, objectPath, jobID, "")
+}
+
+// DownloadWithChecksum downloads an object like Download and, when
+// expectedChecksum is non-empty, verifies the SHA256 of the downloaded file
+// against it. On a mismatch the file is removed and an error wrapping
+// ErrChecksumMismatch is returned.
+func (c *Client) DownloadWithChecksum(ctx context.Context, objectPath, jobID, expectedChecksum string) (*DownloadResult, error) {
 	// Create job-specific directory.
 	jobDir := filepath.Join(c.downloadDir, jobID)
 	if err := os.MkdirAll(jobDir, 0o755); err != nil {
@@ -142,18 +198,107 @@ func (c *Client) Download(ctx context.Context, objectPath, jobID string) (*Downl
 	filename := filepath.Base(objectPath)
 	localPath := filepath.Join(jobDir, filename)
 
+	result, err := withRetry(ctx, c, func() (*DownloadResult, bool, error) {
+		return c.downloadOnce(ctx, objectPath, localPath)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedChecksum != "" && !strings.EqualFold(result.Checksum, expectedChecksum) {
+		_ = os.Remove(localPath)
+		return nil, fmt.Errorf("%w for %s/%s: got %s, expected %s",
+			ErrChecksumMismatch, c.bucket, objectPath, result.Checksum, expectedChecksum)
+	}
+
+	return result, nil
+}
+
+// OpenReader opens a streaming reader for an object so callers can extract
+// or scan it without writing it to disk first. It returns the object size,
+// or -1 when the size is unknown. Opening the object is retried like
+// Download; failures while reading the stream are not. Wrap the reader with
+// NewChecksumReader to compute its SHA256 while it is consumed. The caller
+// must close the reader.
+func (c *Client) OpenReader(ctx context.Context, objectPath string) (io.ReadCloser, int64, error) {
+	type object struct {
+		body io.ReadCloser
+		size int64
+	}
+
+	obj, err := withRetry(ctx, c, func() (object, bool, error) {
+		body, size, retryable, err := c.openObject(ctx, objectPath)
+		return object{body: body, size: size}, retryable, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return obj.body, obj.size, nil
+}
+
+// withRetry runs op, retrying transient failures with exponential backoff.
+// Object reads are idempotent, so retrying them is safe.
+func withRetry[T any](ctx context.Context, c *Client, op func() (T, bool, error)) (T, error) {
+	if c.maxRetries < 0 {
+		result, _, err := op()
+		return result, err
+	}
+
+	backoff := dbupdater.NewBackoff(dbupdater.BackoffConfig{
+		MaxRetries:     c.maxRetries,
+		InitialDelay:   c.retryDelay,
+		MaxDelay:       maxRetryDelay,
+		JitterFraction: dbupdater.DefaultJitterFraction,
+	})
+
+	for attempt := 1; ; attempt++ {
+		result, retryable, err := op()
+		if err == nil || !retryable {
+			return result, err
+		}
+
+		if waitErr := backoff.Wait(ctx); waitErr != nil {
+			var zero T
+			if errors.Is(waitErr, dbupdater.ErrMaxRetriesExceeded) {
+				return zero, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+			}
+			return zero, fmt.Errorf("%w (retry cancelled: %w)", err, waitErr)
+		}
+	}
+}
+
+// downloadOnce performs a single download attempt and reports whether a
+// failure is transient.
+func (c *Client) downloadOnce(ctx context.Context, objectPath, localPath string) (*DownloadResult, bool, error) {
+	body, _, retryable, err := c.openObject(ctx, objectPath)
+	if err != nil {
+		return nil, retryable, err
+	}
+	defer body.Close()
+
+	result, err := saveObject(body, localPath)
+	if err != nil {
+		return nil, isRetryableError(ctx, err), err
+	}
+	return result, false, nil
+}
+
+// openObject opens an object for reading and reports whether a failure is
+// transient.
+func (c *Client) openObject(ctx context.Context, objectPath string) (io.ReadCloser, int64, bool, error) {
 	// Use HTTP for emulator, SDK for production
 	if c.emulatorHost != "" {
-		return c.downloadViaHTTP(ctx, objectPath, localPath)
+		return c.openViaHTTP(ctx, objectPath)
 	}
 
-	return c.downloadViaSDK(ctx, objectPath, localPath)
+	return c.openViaSDK(ctx, objectPath)
 }
 
-// downloadViaHTTP downloads an object using HTTP directly.
+// openViaHTTP opens an object using HTTP directly.
 // This works around googleapis/google-cloud-go#6139 where the Go SDK
 // uses path-style URLs that fake-gcs-server doesn't support for reads.
-func (c *Client) downloadViaHTTP(ctx context.Context, objectPath, localPath string) (*DownloadResult, error) {
+func (c *Client) openViaHTTP(ctx context.Context, objectPath string) (io.ReadCloser, int64, bool, error) {
 	// Build the JSON API URL that fake-gcs-server expects
 	// Format: http://{host}/storage/v1/b/{bucket}/o/{object}?alt=media
 	encodedObject := url.PathEscape(objectPath)
@@ -162,19 +307,38 @@ func (c *Client) downloadViaHTTP(ctx context.Context, objectPath, localPath stri
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return nil, 0, false, fmt.Errorf("creating request: %w", err)
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("executing request to %s: %w", downloadURL, err)
+		// Connection errors are transient unless the caller gave up.
+		return nil, 0, ctx.Err() == nil, fmt.Errorf("executing request to %s: %w", downloadURL, err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("downloading object %s/%s: HTTP %d", c.bucket, objectPath, resp.StatusCode)
+		resp.Body.Close()
+		return nil, 0, isRetryableStatus(resp.StatusCode),
+			fmt.Errorf("downloading object %s/%s: HTTP %d", c.bucket, objectPath, resp.StatusCode)
+	}
+
+	return resp.Body, resp.ContentLength, false, nil
+}
+
+// openViaSDK opens an object using the Go GCS SDK.
+func (c *Client) openViaSDK(ctx context.Context, objectPath string) (io.ReadCloser, int64, bool, error) {
+	obj := c.storageClient.Bucket(c.bucket).Object(objectPath)
+	reader, err := obj.NewReader(ctx)
+	if err != nil {
+		return nil, 0, isRetryableError(ctx, err), fmt.Errorf("opening object %s/%s: %w", c.bucket, objectPath, err)
 	}
 
+	return reader, reader.Attrs.Size, false, nil
+}
+
+// saveObject copies r to localPath while computing its SHA256. A partially
+// written file is removed on failure.
+func saveObject(r io.Reader, localPath string) (*DownloadResult, error) {
 	// Create local file.
 	file, err := os.Create(localPath)
 	if err != nil {
@@ -183,62 +347,163 @@ func (c *Client) downloadViaHTTP(ctx context.Context, objectPath, localPath stri
 	defer file.Close()
 
 	// Download with hash computation.
-	hasher := sha256.New()
-	writer := io.MultiWriter(file, hasher)
-
-	size, err := io.Copy(writer, resp.Body)
-	if err != nil {
+	cr := NewChecksumReader(r)
+	if _, err := io.Copy(file, cr); err != nil {
 		_ = os.Remove(localPath)
 		return nil, fmt.Errorf("downloading object: %w", err)
 	}
 
-	checksum := hex.EncodeToString(hasher.Sum(nil))
-
 	return &DownloadResult{
 		LocalPath: localPath,
-		Checksum:  checksum,
-		Size:      size,
+		Checksum:  cr.Checksum(),
+		Size:      cr.Size(),
 	}, nil
 }
 
-// downloadViaSDK downloads an object using the Go GCS SDK.
-func (c *Client) downloadViaSDK(ctx context.Context, objectPath, localPath string) (*DownloadResult, error) {
-	// Open GCS object.
-	obj := c.storageClient.Bucket(c.bucket).Object(objectPath)
-	reader, err := obj.NewReader(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("opening object %s/%s: %w", c.bucket, objectPath, err)
+// ChecksumReader computes the SHA256 of everything read through it.
+type ChecksumReader struct {
+	io.Reader
+	hasher hash.Hash
+	size   int64
+}
+
+// NewChecksumReader wraps r so that its SHA256 is computed as it is read.
+func NewChecksumReader(r io.Reader) *ChecksumReader {
+	hasher := sha256.New()
+	cr := &ChecksumReader{hasher: hasher}
+	cr.Reader = io.TeeReader(r, countingWriter{hasher, &cr.size})
+	return cr
+}
+
+// Checksum returns the hex-encoded SHA256 of the bytes read so far.
+func (r *ChecksumReader) Checksum() string {
+	return hex.EncodeToString(r.hasher.Sum(nil))
+}
+
+// Size returns the number of bytes read so far.
+func (r *ChecksumReader) Size() int64 {
+	return r.size
+}
+
+// Verify checks the bytes read so far against an expected SHA256. It
+// returns an error wrapping ErrChecksumMismatch when they differ.
+func (r *ChecksumReader) Verify(expected string) error {
+	if actual := r.Checksum(); !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("%w: got %s, expected %s", ErrChecksumMismatch, actual, expected)
 	}
-	defer reader.Close()
+	return nil
+}
 
-	// Create local file.
-	file, err := os.Create(localPath)
+// countingWriter forwards writes to w and adds their length to n.
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (c countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.n += int64(n)
+	return n, err
+}
+
+// isRetryableStatus reports whether a response status indicates a transient
+// failure.
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// isRetryableError reports whether an SDK error is transient. Missing
+// objects and client errors are permanent; other failures, such as dropped
+// connections, are retried unless the caller gave up.
+func isRetryableError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, storage.ErrObjectNotExist) || errors.Is(err, storage.ErrBucketNotExist) {
+		return false
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return isRetryableStatus(apiErr.Code)
+	}
+	return true
+}
+
+// Upload writes the contents of r to objectPath in the configured bucket and
+// returns the gs:// URI of the stored object. Uploads are not retried since
+// r cannot be replayed.
+func (c *Client) Upload(ctx context.Context, objectPath string, r io.Reader) (string, error) {
+	if objectPath == "" {
+		return "", errors.New("object path is required")
+	}
+
+	var err error
+	if c.emulatorHost != "" {
+		err = c.uploadViaHTTP(ctx, objectPath, r)
+	} else {
+		err = c.uploadViaSDK(ctx, objectPath, r)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("creating local file %s: %w", localPath, err)
+		return "", err
 	}
-	defer file.Close()
 
-	// Download with hash computation.
-	hasher := sha256.New()
-	writer := io.MultiWriter(file, hasher)
+	return "gs://" + c.bucket + "/" + objectPath, nil
+}
+
+// uploadViaHTTP uploads an object with a simple media upload to the
+// emulator's JSON API.
+func (c *Client) uploadViaHTTP(ctx context.Context, objectPath string, r io.Reader) error {
+	// Format: http://{host}/upload/storage/v1/b/{bucket}/o?uploadType=media&name={object}
+	uploadURL := fmt.Sprintf("http://%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		c.emulatorHost, c.bucket, url.QueryEscape(objectPath))
 
-	size, err := io.Copy(writer, reader)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, r)
 	if err != nil {
-		_ = os.Remove(localPath)
-		return nil, fmt.Errorf("downloading object: %w", err)
+		return fmt.Errorf("creating request: %w", err)
 	}
+	req.Header.Set("Content-Type", "application/octet-stream")
 
-	checksum := hex.EncodeToString(hasher.Sum(nil))
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing request to %s: %w", uploadURL, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
 
-	return &DownloadResult{
-		LocalPath: localPath,
-		Checksum:  checksum,
-		Size:      size,
-	}, nil
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("uploading object %s/%s: HTTP %d", c.bucket, objectPath, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// uploadViaSDK uploads an object using the Go GCS SDK.
+func (c *Client) uploadViaSDK(ctx context.Context, objectPath string, r io.Reader) error {
+	// Cancelling the writer's context aborts a partial upload.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	writer := c.storageClient.Bucket(c.bucket).Object(objectPath).NewWriter(ctx)
+	if _, err := io.Copy(writer, r); err != nil {
+		cancel()
+		_ = writer.Close()
+		return fmt.Errorf("uploading object %s/%s: %w", c.bucket, objectPath, err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("uploading object %s/%s: %w", c.bucket, objectPath, err)
+	}
+
+	return nil
 }
 
 // DownloadFromURI downloads an object using a gs:// URI.
 func (c *Client) DownloadFromURI(ctx context.Context, uri, jobID string) (*DownloadResult, error) {
+	return c.DownloadFromURIWithChecksum(ctx, uri, jobID, "")
+}
+
+// DownloadFromURIWithChecksum downloads an object using a gs:// URI and
+// verifies it against expectedChecksum like DownloadWithChecksum.
+func (c *Client) DownloadFromURIWithChecksum(ctx context.Context, uri, jobID, expectedChecksum string) (*DownloadResult, error) {
 	bucket, objectPath, err := ParseGCSURI(uri)
 	if err != nil {
 		return nil, fmt.Errorf("parsing URI: %w", err)
@@ -249,7 +514,7 @@ func (c *Client) DownloadFromURI(ctx context.Context, uri, jobID string) (*Downl
 		return nil, fmt.Errorf("bucket mismatch: URI has %q, client configured for %q", bucket, c.bucket)
 	}
 
-	return c.Download(ctx, objectPath, jobID)
+	return c.DownloadWithChecksum(ctx, objectPath, jobID, expectedChecksum)
 }
 
 // ParseGCSURI parses a gs:// URI into bucket and object path.
@@ -282,20 +547,49 @@ func ParseGCSURI(uri string) (bucket, object string, err error) {
 // ValidateOrganizationPath checks if the GCS URI belongs to the expected organization.
 // Prevents path traversal attacks and cross-tenant access.
 func ValidateOrganizationPath(uri, orgID string) bool {
+	return ValidateOrganizationPaths(uri, []string{orgID})
+}
+
+// ValidateOrganizationPaths checks if the GCS URI belongs to any of the
+// allowed organizations, for workers that serve several tenants.
+//
+// Backslashes are treated as path separators. The object must be
+// "{orgID}/..." with at least one name below the organization, and is
+// rejected outright if it is absolute or has empty, "." or ".." segments.
+// Empty organization IDs, or IDs containing separators, never match.
+func ValidateOrganizationPaths(uri string, orgIDs []string) bool {
 	_, object, err := ParseGCSURI(uri)
 	if err != nil {
 		return false
 	}
 
-	// Clean the path to prevent traversal.
-	cleanPath := filepath.Clean(object)
-	if cleanPath != object {
-		return false // Path contains traversal sequences.
+	object = strings.ReplaceAll(object, `\`, "/")
+	if strings.HasPrefix(object, "/") {
+		return false // Absolute object path.
+	}
+
+	segments := strings.Split(object, "/")
+	if len(segments) < 2 {
+		return false // No object below the organization.
+	}
+	for _, segment := range segments {
+		if segment == "" || segment == "." || segment == ".." {
+			return false // Path contains traversal or ambiguous segments.
+		}
 	}
 
-	// Check if path starts with org ID.
-	expectedPrefix := orgID + "/"
-	return strings.HasPrefix(object, expectedPrefix)
+	for _, orgID := range orgIDs {
+		if validOrganizationID(orgID) && segments[0] == orgID {
+			return true
+		}
+	}
+	return false
+}
+
+// validOrganizationID reports whether orgID can safely be used as the first
+// path segment of an object.
+func validOrganizationID(orgID string) bool {
+	return orgID != "" && orgID != "." && orgID != ".." && !strings.ContainsAny(orgID, `/\`)
 }
 
 // ComputeSHA256 computes the SHA256 hash of a file.
@@ -322,7 +616,7 @@ func VerifyChecksum(filePath, expected string) error {
 	}
 
 	if actual != expected {
-		return fmt.Errorf("checksum mismatch: got %s, expected %s", actual, expected)
+		return fmt.Errorf("%w: got %s, expected %s", ErrChecksumMismatch, actual, expected)
 	}
 
 	return nil
@@ -7,10 +7,24 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
 )
 
 func TestParseGCSURI(t *testing.T) {
@@ -125,6 +139,96 @@ func TestValidateOrganizationPath(t *testing.T) {
 			orgID:  "org-123",
 			wantOK: false,
 		},
+		{
+			name:   "nested path",
+			uri:    "gs://bucket/org-123/skills/v2/sha256/file.zip",
+			orgID:  "org-123",
+			wantOK: true,
+		},
+		{
+			name:   "empty segment",
+			uri:    "gs://bucket/org-123//file.zip",
+			orgID:  "org-123",
+			wantOK: false,
+		},
+		{
+			name:   "traversal to other org",
+			uri:    "gs://bucket/org-123/../other/file.zip",
+			orgID:  "org-123",
+			wantOK: false,
+		},
+		{
+			name:   "trailing traversal",
+			uri:    "gs://bucket/org-123/skills/..",
+			orgID:  "org-123",
+			wantOK: false,
+		},
+		{
+			name:   "dot segment",
+			uri:    "gs://bucket/org-123/./file.zip",
+			orgID:  "org-123",
+			wantOK: false,
+		},
+		{
+			name:   "backslash separators",
+			uri:    `gs://bucket/org-123\skills\file.zip`,
+			orgID:  "org-123",
+			wantOK: true,
+		},
+		{
+			name:   "backslash traversal",
+			uri:    `gs://bucket/org-123\..\other\file.zip`,
+			orgID:  "org-123",
+			wantOK: false,
+		},
+		{
+			name:   "absolute object",
+			uri:    "gs://bucket//org-123/file.zip",
+			orgID:  "org-123",
+			wantOK: false,
+		},
+		{
+			name:   "org prefix of another org",
+			uri:    "gs://bucket/org-1234/file.zip",
+			orgID:  "org-123",
+			wantOK: false,
+		},
+		{
+			name:   "org directory only",
+			uri:    "gs://bucket/org-123/",
+			orgID:  "org-123",
+			wantOK: false,
+		},
+		{
+			name:   "org without object",
+			uri:    "gs://bucket/org-123",
+			orgID:  "org-123",
+			wantOK: false,
+		},
+		{
+			name:   "empty org",
+			uri:    "gs://bucket/file.zip",
+			orgID:  "",
+			wantOK: false,
+		},
+		{
+			name:   "empty org with leading slash",
+			uri:    "gs://bucket//file.zip",
+			orgID:  "",
+			wantOK: false,
+		},
+		{
+			name:   "org containing separator",
+			uri:    "gs://bucket/org-123/skills/file.zip",
+			orgID:  "org-123/skills",
+			wantOK: false,
+		},
+		{
+			name:   "invalid URI",
+			uri:    "s3://bucket/org-123/file.zip",
+			orgID:  "org-123",
+			wantOK: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -139,6 +243,64 @@ func TestValidateOrganizationPath(t *testing.T) {
 	}
 }
 
+func TestValidateOrganizationPaths(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		uri    string
+		orgIDs []string
+		wantOK bool
+	}{
+		{
+			name:   "first allowed org",
+			uri:    "gs://bucket/org-a/skills/file.zip",
+			orgIDs: []string{"org-a", "org-b"},
+			wantOK: true,
+		},
+		{
+			name:   "second allowed org",
+			uri:    "gs://bucket/org-b/skills/file.zip",
+			orgIDs: []string{"org-a", "org-b"},
+			wantOK: true,
+		},
+		{
+			name:   "org not in allowlist",
+			uri:    "gs://bucket/org-c/skills/file.zip",
+			orgIDs: []string{"org-a", "org-b"},
+			wantOK: false,
+		},
+		{
+			name:   "empty org in allowlist is ignored",
+			uri:    "gs://bucket//file.zip",
+			orgIDs: []string{"", "org-a"},
+			wantOK: false,
+		},
+		{
+			name:   "traversal between allowed orgs",
+			uri:    "gs://bucket/org-a/../org-b/file.zip",
+			orgIDs: []string{"org-a", "org-b"},
+			wantOK: false,
+		},
+		{
+			name:   "empty allowlist",
+			uri:    "gs://bucket/org-a/file.zip",
+			orgIDs: nil,
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ValidateOrganizationPaths(tt.uri, tt.orgIDs); got != tt.wantOK {
+				t.Errorf("ValidateOrganizationPaths() = %v, want %v", got, tt.wantOK)
+			}
+		})
+	}
+}
+
 func TestComputeSHA256(t *testing.T) {
 	t.Parallel()
 
@@ -313,6 +475,421 @@ func TestNewClient_EmulatorMode(t *testing.T) {
 	}
 }
 
+// newFlakyEmulator starts a fake emulator that answers the first failures
+// requests with status and every later one with body. The returned counter
+// tracks the number of requests served.
+func newFlakyEmulator(t *testing.T, failures int, status int, body string) (string, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/storage/v1/b/test-bucket/o/") || r.URL.Query().Get("alt") != "media" {
+			http.NotFound(w, r)
+			return
+		}
+		if int(requests.Add(1)) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return strings.TrimPrefix(srv.URL, "http://"), &requests
+}
+
+func TestClient_Download_Retry(t *testing.T) {
+	t.Parallel()
+
+	const content = "skill archive"
+
+	tests := []struct {
+		name         string
+		failures     int
+		status       int
+		maxRetries   int
+		wantErr      bool
+		wantRequests int32
+	}{
+		{
+			name:         "recovers after 5xx",
+			failures:     2,
+			status:       http.StatusServiceUnavailable,
+			maxRetries:   3,
+			wantRequests: 3,
+		},
+		{
+			name:         "recovers after 429",
+			failures:     1,
+			status:       http.StatusTooManyRequests,
+			maxRetries:   3,
+			wantRequests: 2,
+		},
+		{
+			name:         "gives up after max retries",
+			failures:     10,
+			status:       http.StatusBadGateway,
+			maxRetries:   2,
+			wantErr:      true,
+			wantRequests: 3,
+		},
+		{
+			name:         "not found is not retried",
+			failures:     10,
+			status:       http.StatusNotFound,
+			maxRetries:   3,
+			wantErr:      true,
+			wantRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			host, requests := newFlakyEmulator(t, tt.failures, tt.status, content)
+			client, err := NewClient(context.Background(), Config{
+				Bucket:       "test-bucket",
+				DownloadDir:  t.TempDir(),
+				EmulatorHost: host,
+				MaxRetries:   tt.maxRetries,
+				RetryDelay:   time.Millisecond,
+			})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			defer client.Close()
+
+			result, err := client.Download(context.Background(), "org-1/skills/skill.zip", "job-1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Download() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", got, tt.wantRequests)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			data, err := os.ReadFile(result.LocalPath)
+			if err != nil {
+				t.Fatalf("reading download: %v", err)
+			}
+			if string(data) != content {
+				t.Errorf("downloaded %q, want %q", data, content)
+			}
+		})
+	}
+}
+
+func TestClient_DownloadWithChecksum(t *testing.T) {
+	t.Parallel()
+
+	const content = "skill archive"
+	sum := sha256.Sum256([]byte(content))
+	checksum := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+		wantErr  error
+	}{
+		{
+			name:     "matching checksum",
+			body:     content,
+			expected: checksum,
+		},
+		{
+			name:     "uppercase checksum",
+			body:     content,
+			expected: strings.ToUpper(checksum),
+		},
+		{
+			name: "no checksum",
+			body: "anything",
+		},
+		{
+			name:     "wrong bytes",
+			body:     "tampered archive",
+			expected: checksum,
+			wantErr:  ErrChecksumMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			host, _ := newFlakyEmulator(t, 0, http.StatusOK, tt.body)
+			downloadDir := t.TempDir()
+			client, err := NewClient(context.Background(), Config{
+				Bucket:       "test-bucket",
+				DownloadDir:  downloadDir,
+				EmulatorHost: host,
+				RetryDelay:   time.Millisecond,
+			})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			defer client.Close()
+
+			result, err := client.DownloadFromURIWithChecksum(context.Background(),
+				"gs://test-bucket/org-1/skills/skill.zip", "job-1", tt.expected)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DownloadFromURIWithChecksum() error = %v, want %v", err, tt.wantErr)
+			}
+
+			localPath := downloadPath(downloadDir, "job-1", "org-1/skills/skill.zip")
+			if tt.wantErr != nil {
+				if _, err := os.Stat(localPath); !os.IsNotExist(err) {
+					t.Errorf("mismatched download not removed (stat error %v)", err)
+				}
+				return
+			}
+			if result.LocalPath != localPath {
+				t.Errorf("LocalPath = %q, want %q", result.LocalPath, localPath)
+			}
+		})
+	}
+}
+
+// newObjectServer starts a fake GCS JSON API backed by objects in
+// test-bucket. Both the emulator HTTP path and the SDK with JSON reads use
+// this URL layout. Media and multipart uploads are stored in objects.
+func newObjectServer(t *testing.T, objects map[string]string) *httptest.Server {
+	t.Helper()
+
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/test-bucket/o" {
+			name := r.URL.Query().Get("name")
+			body, err := uploadBody(r)
+			if name == "" || err != nil {
+				http.Error(w, fmt.Sprintf("bad upload: %v", err), http.StatusBadRequest)
+				return
+			}
+			mu.Lock()
+			objects[name] = body
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"bucket":"test-bucket","name":%q,"size":"%d"}`, name, len(body))
+			return
+		}
+
+		name, ok := strings.CutPrefix(r.URL.Path, "/storage/v1/b/test-bucket/o/")
+		mu.Lock()
+		body, found := objects[name]
+		mu.Unlock()
+		if !ok || !found || r.URL.Query().Get("alt") != "media" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// uploadBody returns the object contents of a media upload, or the second
+// part of a multipart upload as sent by the SDK.
+func uploadBody(r *http.Request) (string, error) {
+	if r.URL.Query().Get("uploadType") != "multipart" {
+		body, err := io.ReadAll(r.Body)
+		return string(body), err
+	}
+
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return "", err
+	}
+	mr := multipart.NewReader(r.Body, params["boundary"])
+	if _, err := mr.NextPart(); err != nil { // Object metadata.
+		return "", err
+	}
+	media, err := mr.NextPart()
+	if err != nil {
+		return "", err
+	}
+	body, err := io.ReadAll(media)
+	return string(body), err
+}
+
+// newSDKClient returns a Client that reads through the storage SDK from
+// the fake server at srv.
+func newSDKClient(t *testing.T, srv *httptest.Server) *Client {
+	t.Helper()
+
+	storageClient, err := storage.NewClient(context.Background(),
+		option.WithEndpoint(srv.URL+"/storage/v1/"),
+		option.WithoutAuthentication(),
+		storage.WithJSONReads(),
+	)
+	if err != nil {
+		t.Fatalf("storage.NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { _ = storageClient.Close() })
+
+	return &Client{
+		storageClient: storageClient,
+		bucket:        "test-bucket",
+		downloadDir:   t.TempDir(),
+		maxRetries:    -1,
+	}
+}
+
+func TestClient_OpenReader(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("skill archive bytes\n", 4096)
+	sum := sha256.Sum256([]byte(content))
+	checksum := hex.EncodeToString(sum[:])
+
+	objects := map[string]string{"org-1/skills/skill.zip": content}
+
+	tests := []struct {
+		name      string
+		newClient func(t *testing.T, srv *httptest.Server) *Client
+	}{
+		{
+			name: "emulator",
+			newClient: func(t *testing.T, srv *httptest.Server) *Client {
+				client, err := NewClient(context.Background(), Config{
+					Bucket:       "test-bucket",
+					DownloadDir:  t.TempDir(),
+					EmulatorHost: strings.TrimPrefix(srv.URL, "http://"),
+					MaxRetries:   -1,
+				})
+				if err != nil {
+					t.Fatalf("NewClient() error = %v", err)
+				}
+				return client
+			},
+		},
+		{
+			name:      "sdk",
+			newClient: newSDKClient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := tt.newClient(t, newObjectServer(t, objects))
+			defer client.Close()
+
+			body, size, err := client.OpenReader(context.Background(), "org-1/skills/skill.zip")
+			if err != nil {
+				t.Fatalf("OpenReader() error = %v", err)
+			}
+			defer body.Close()
+
+			if size != int64(len(content)) {
+				t.Errorf("size = %d, want %d", size, len(content))
+			}
+
+			cr := NewChecksumReader(body)
+			data, err := io.ReadAll(cr)
+			if err != nil {
+				t.Fatalf("reading stream: %v", err)
+			}
+			if string(data) != content {
+				t.Errorf("stream yielded %d bytes, want the full %d-byte object", len(data), len(content))
+			}
+			if cr.Size() != int64(len(content)) {
+				t.Errorf("Size() = %d, want %d", cr.Size(), len(content))
+			}
+			if err := cr.Verify(checksum); err != nil {
+				t.Errorf("Verify() error = %v", err)
+			}
+			if err := cr.Verify(strings.Repeat("0", 64)); !errors.Is(err, ErrChecksumMismatch) {
+				t.Errorf("Verify(wrong) error = %v, want ErrChecksumMismatch", err)
+			}
+
+			if _, _, err := client.OpenReader(context.Background(), "org-1/missing.zip"); err == nil {
+				t.Error("OpenReader() on a missing object should fail")
+			}
+		})
+	}
+}
+
+func TestClient_Upload(t *testing.T) {
+	t.Parallel()
+
+	const report = `{"job_id":"job-1","trivy":{"summary":{"total":0}}}`
+
+	tests := []struct {
+		name      string
+		newClient func(t *testing.T, srv *httptest.Server) *Client
+	}{
+		{
+			name: "emulator",
+			newClient: func(t *testing.T, srv *httptest.Server) *Client {
+				client, err := NewClient(context.Background(), Config{
+					Bucket:       "test-bucket",
+					DownloadDir:  t.TempDir(),
+					EmulatorHost: strings.TrimPrefix(srv.URL, "http://"),
+					MaxRetries:   -1,
+				})
+				if err != nil {
+					t.Fatalf("NewClient() error = %v", err)
+				}
+				return client
+			},
+		},
+		{
+			name:      "sdk",
+			newClient: newSDKClient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			objects := make(map[string]string)
+			client := tt.newClient(t, newObjectServer(t, objects))
+			defer client.Close()
+
+			ctx := context.Background()
+			uri, err := client.Upload(ctx, "reports/org-1/job-1/report.json", strings.NewReader(report))
+			if err != nil {
+				t.Fatalf("Upload() error = %v", err)
+			}
+			if want := "gs://test-bucket/reports/org-1/job-1/report.json"; uri != want {
+				t.Errorf("Upload() = %q, want %q", uri, want)
+			}
+
+			// Round trip the uploaded report through OpenReader.
+			_, objectPath, err := ParseGCSURI(uri)
+			if err != nil {
+				t.Fatalf("ParseGCSURI() error = %v", err)
+			}
+			body, _, err := client.OpenReader(ctx, objectPath)
+			if err != nil {
+				t.Fatalf("OpenReader() error = %v", err)
+			}
+			defer body.Close()
+
+			data, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("reading report: %v", err)
+			}
+			if string(data) != report {
+				t.Errorf("round trip = %q, want %q", data, report)
+			}
+
+			if _, err := client.Upload(ctx, "", strings.NewReader(report)); err == nil {
+				t.Error("Upload() with an empty object path should fail")
+			}
+		})
+	}
+}
+
 // TestBuildEmulatorDownloadURL tests the URL construction for emulator downloads.
 func TestBuildEmulatorDownloadURL(t *testing.T) {
 	t.Parallel()
@@ -9,6 +9,8 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // LoggingConfig holds configuration for structured logging.
@@ -35,8 +37,12 @@ func NewLogger(cfg LoggingConfig, w io.Writer) *slog.Logger {
 		w = os.Stdout
 	}
 
-	level := ParseLogLevel(cfg.Level)
+	return slog.New(newHandler(cfg, w, ParseLogLevel(cfg.Level)))
+}
 
+// newHandler builds the JSON or text handler described by cfg, filtering
+// records below level.
+func newHandler(cfg LoggingConfig, w io.Writer, level slog.Leveler) slog.Handler {
 	opts := &slog.HandlerOptions{
 		Level:     level,
 		AddSource: cfg.AddSource,
@@ -62,7 +68,108 @@ func NewLogger(cfg LoggingConfig, w io.Writer) *slog.Logger {
 		handler = handler.WithAttrs(attrs)
 	}
 
-	return slog.New(handler)
+	return handler
+}
+
+// LogReloader changes the level and format of a logger created by
+// NewReloadableLogger, including loggers derived from it with With or
+// WithGroup.
+type LogReloader struct {
+	cfg   LoggingConfig
+	w     io.Writer
+	level slog.LevelVar
+
+	mu      sync.Mutex
+	handler atomic.Pointer[reloadTarget]
+}
+
+// reloadTarget is the handler currently receiving records, tagged with a
+// generation so derived handlers know when to rebuild.
+type reloadTarget struct {
+	handler    slog.Handler
+	generation uint64
+}
+
+// NewReloadableLogger creates a logger like NewLogger whose level and format
+// can later be changed through the returned LogReloader.
+func NewReloadableLogger(cfg LoggingConfig, w io.Writer) (*slog.Logger, *LogReloader) {
+	if w == nil {
+		w = os.Stdout
+	}
+
+	r := &LogReloader{cfg: cfg, w: w}
+	r.level.Set(ParseLogLevel(cfg.Level))
+	r.handler.Store(&reloadTarget{handler: newHandler(cfg, w, &r.level)})
+
+	return slog.New(&reloadableHandler{reloader: r}), r
+}
+
+// Reload applies a new level and format. The level changes immediately; a
+// format change swaps the handler for all loggers sharing the reloader.
+func (r *LogReloader) Reload(level, format string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.level.Set(ParseLogLevel(level))
+	if strings.EqualFold(format, r.cfg.Format) {
+		return
+	}
+
+	r.cfg.Format = format
+	current := r.handler.Load()
+	r.handler.Store(&reloadTarget{
+		handler:    newHandler(r.cfg, r.w, &r.level),
+		generation: current.generation + 1,
+	})
+}
+
+// reloadableHandler forwards records to the reloader's current handler,
+// replaying the attributes and groups added through With and WithGroup.
+type reloadableHandler struct {
+	reloader *LogReloader
+	derive   []func(slog.Handler) slog.Handler
+
+	// cache holds the derived handler for the current generation.
+	cache atomic.Pointer[reloadTarget]
+}
+
+func (h *reloadableHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.reloader.level.Level()
+}
+
+func (h *reloadableHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.current().Handle(ctx, record)
+}
+
+func (h *reloadableHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(inner slog.Handler) slog.Handler { return inner.WithAttrs(attrs) })
+}
+
+func (h *reloadableHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(inner slog.Handler) slog.Handler { return inner.WithGroup(name) })
+}
+
+// with returns a handler that applies derive after h's own derivations.
+func (h *reloadableHandler) with(derive func(slog.Handler) slog.Handler) slog.Handler {
+	chain := make([]func(slog.Handler) slog.Handler, len(h.derive), len(h.derive)+1)
+	copy(chain, h.derive)
+	return &reloadableHandler{reloader: h.reloader, derive: append(chain, derive)}
+}
+
+// current returns the reloader's handler with h's derivations applied,
+// rebuilding it only after a reload.
+func (h *reloadableHandler) current() slog.Handler {
+	target := h.reloader.handler.Load()
+	if cached := h.cache.Load(); cached != nil && cached.generation == target.generation {
+		return cached.handler
+	}
+
+	handler := target.handler
+	for _, derive := range h.derive {
+		handler = derive(handler)
+	}
+	h.cache.Store(&reloadTarget{handler: handler, generation: target.generation})
+	return handler
 }
 
 // ParseLogLevel parses a log level string into a slog.Level.
@@ -125,6 +125,47 @@ func TestNewLogger_Levels(t *testing.T) {
 	}
 }
 
+func TestNewReloadableLogger_Reload(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger, reloader := observability.NewReloadableLogger(observability.LoggingConfig{
+		Level:       "info",
+		Format:      "text",
+		ServiceName: "argus",
+	}, &buf)
+	child := logger.With(slog.String("component", "worker")).WithGroup("job")
+
+	child.Debug("hidden", slog.String("id", "1"))
+	child.Info("visible", slog.String("id", "2"))
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, "component=worker job.id=2") {
+		t.Fatalf("text output before reload = %q", out)
+	}
+
+	// Loggers derived before the reload pick up the new level and format.
+	buf.Reset()
+	reloader.Reload("debug", "json")
+	child.Debug("now visible", slog.String("id", "3"))
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("output after reload is not JSON: %v\nOutput: %s", err, buf.String())
+	}
+	if entry["msg"] != "now visible" || entry["component"] != "worker" || entry["service"] != "argus" {
+		t.Errorf("entry = %v, want msg, component, and service preserved", entry)
+	}
+	if job, _ := entry["job"].(map[string]any); job["id"] != "3" {
+		t.Errorf("job group = %v, want id 3", entry["job"])
+	}
+
+	buf.Reset()
+	reloader.Reload("error", "json")
+	logger.Warn("suppressed")
+	if buf.Len() != 0 {
+		t.Errorf("warn logged at error level: %q", buf.String())
+	}
+}
+
 func TestLoggerWithTraceID(t *testing.T) {
 	t.Parallel()
 
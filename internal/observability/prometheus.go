@@ -0,0 +1,234 @@
+// ABOUTME: Prometheus collectors for scans, worker queue and stats, Trivy jobs, and DB updaters
+// ABOUTME: Exposes a private registry through an HTTP handler for /metrics scraping
+
+package observability
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsNamespace prefixes every exported metric name.
+const metricsNamespace = "argus"
+
+// UpdaterStatus is the state of one database updater at scrape time.
+type UpdaterStatus struct {
+	Name       string
+	Ready      bool
+	Failed     bool
+	LastUpdate time.Time
+}
+
+// WorkerStats is the scan worker's processing counters at scrape time.
+type WorkerStats struct {
+	Processed   int64
+	Errors      int64
+	Infected    int64
+	AvgScanTime time.Duration
+}
+
+// Metrics holds the Prometheus collectors exported on /metrics.
+type Metrics struct {
+	registry     *prometheus.Registry
+	scansTotal   *prometheus.CounterVec
+	scanDuration prometheus.Histogram
+	trivyJobs    *prometheus.CounterVec
+}
+
+// NewMetrics creates the collectors and registers them, along with the Go
+// runtime and process collectors, on a private registry.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		scansTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "scans_total",
+			Help:      "File scans processed by the scan worker, by result status.",
+		}, []string{"status"}),
+		scanDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "scan_duration_seconds",
+			Help:      "Duration of file scans processed by the scan worker.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 14),
+		}),
+		trivyJobs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "trivy_jobs_total",
+			Help:      "Finished dependency scan jobs, by final status.",
+		}, []string{"status"}),
+	}
+
+	m.registry.MustRegister(
+		m.scansTotal,
+		m.scanDuration,
+		m.trivyJobs,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// Handler returns the HTTP handler serving the registry in the Prometheus
+// exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveScan records a finished file scan.
+func (m *Metrics) ObserveScan(status string, duration time.Duration) {
+	m.scansTotal.WithLabelValues(status).Inc()
+	m.scanDuration.Observe(duration.Seconds())
+}
+
+// ObserveTrivyJob records a dependency scan job reaching a final status.
+func (m *Metrics) ObserveTrivyJob(status string) {
+	m.trivyJobs.WithLabelValues(status).Inc()
+}
+
+// RegisterQueueLength exports the scan worker queue length, read from fn at
+// scrape time.
+func (m *Metrics) RegisterQueueLength(fn func() int) error {
+	gauge := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "worker_queue_length",
+		Help:      "Scan jobs waiting in the worker queue.",
+	}, func() float64 {
+		return float64(fn())
+	})
+	if err := m.registry.Register(gauge); err != nil {
+		return fmt.Errorf("registering queue length gauge: %w", err)
+	}
+	return nil
+}
+
+// RegisterWorkerStats exports the scan worker's processing counters and
+// moving average scan time, read from fn at scrape time.
+func (m *Metrics) RegisterWorkerStats(fn func() WorkerStats) error {
+	if err := m.registry.Register(newWorkerStatsCollector(fn)); err != nil {
+		return fmt.Errorf("registering worker stats collector: %w", err)
+	}
+	return nil
+}
+
+// RegisterUpdaterStatus exports per-updater DB update gauges, read from fn
+// at scrape time.
+func (m *Metrics) RegisterUpdaterStatus(fn func() []UpdaterStatus) error {
+	if err := m.registry.Register(newUpdaterCollector(fn)); err != nil {
+		return fmt.Errorf("registering updater collector: %w", err)
+	}
+	return nil
+}
+
+// updaterCollector reports DB updater state as gauges labeled by updater.
+type updaterCollector struct {
+	statuses      func() []UpdaterStatus
+	ready         *prometheus.Desc
+	failed        *prometheus.Desc
+	lastUpdateAge *prometheus.Desc
+}
+
+func newUpdaterCollector(fn func() []UpdaterStatus) *updaterCollector {
+	labels := []string{"updater"}
+	return &updaterCollector{
+		statuses: fn,
+		ready: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "db_updater", "ready"),
+			"Whether the updater's database is ready for use (1) or not (0).",
+			labels, nil,
+		),
+		failed: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "db_updater", "failed"),
+			"Whether the updater's last run failed (1) or not (0).",
+			labels, nil,
+		),
+		lastUpdateAge: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "db_updater", "last_update_age_seconds"),
+			"Seconds since the updater last completed an update.",
+			labels, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *updaterCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.ready
+	ch <- c.failed
+	ch <- c.lastUpdateAge
+}
+
+// Collect implements prometheus.Collector.
+func (c *updaterCollector) Collect(ch chan<- prometheus.Metric) {
+	now := time.Now()
+	for _, s := range c.statuses() {
+		ch <- prometheus.MustNewConstMetric(c.ready, prometheus.GaugeValue, boolToFloat(s.Ready), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.failed, prometheus.GaugeValue, boolToFloat(s.Failed), s.Name)
+		if !s.LastUpdate.IsZero() {
+			ch <- prometheus.MustNewConstMetric(c.lastUpdateAge, prometheus.GaugeValue, now.Sub(s.LastUpdate).Seconds(), s.Name)
+		}
+	}
+}
+
+// workerStatsCollector reports the scan worker's own counters.
+type workerStatsCollector struct {
+	stats       func() WorkerStats
+	processed   *prometheus.Desc
+	errors      *prometheus.Desc
+	infected    *prometheus.Desc
+	avgScanTime *prometheus.Desc
+}
+
+func newWorkerStatsCollector(fn func() WorkerStats) *workerStatsCollector {
+	return &workerStatsCollector{
+		stats: fn,
+		processed: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "worker", "jobs_processed_total"),
+			"Scan jobs finished by the worker, including cache hits and failures.",
+			nil, nil,
+		),
+		errors: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "worker", "job_errors_total"),
+			"Scan jobs that failed or whose scan reported an error.",
+			nil, nil,
+		),
+		infected: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "worker", "infected_total"),
+			"Scan jobs whose result was infected.",
+			nil, nil,
+		),
+		avgScanTime: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "worker", "scan_duration_avg_seconds"),
+			"Exponential moving average of worker scan durations.",
+			nil, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *workerStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.processed
+	ch <- c.errors
+	ch <- c.infected
+	ch <- c.avgScanTime
+}
+
+// Collect implements prometheus.Collector.
+func (c *workerStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.processed, prometheus.CounterValue, float64(s.Processed))
+	ch <- prometheus.MustNewConstMetric(c.errors, prometheus.CounterValue, float64(s.Errors))
+	ch <- prometheus.MustNewConstMetric(c.infected, prometheus.CounterValue, float64(s.Infected))
+	ch <- prometheus.MustNewConstMetric(c.avgScanTime, prometheus.GaugeValue, s.AvgScanTime.Seconds())
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,70 @@
+// ABOUTME: Tests for the Prometheus collectors
+// ABOUTME: Validates scan, queue length, worker stats, and DB updater metrics in the scrape output
+
+package observability
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics_Handler(t *testing.T) {
+	t.Parallel()
+
+	m := NewMetrics()
+	m.ObserveScan("infected", 250*time.Millisecond)
+	m.ObserveTrivyJob("completed")
+	if err := m.RegisterQueueLength(func() int { return 7 }); err != nil {
+		t.Fatalf("RegisterQueueLength() error = %v", err)
+	}
+	err := m.RegisterWorkerStats(func() WorkerStats {
+		return WorkerStats{Processed: 10, Errors: 2, Infected: 3, AvgScanTime: 1500 * time.Millisecond}
+	})
+	if err != nil {
+		t.Fatalf("RegisterWorkerStats() error = %v", err)
+	}
+	err = m.RegisterUpdaterStatus(func() []UpdaterStatus {
+		return []UpdaterStatus{
+			{Name: "clamav", Ready: true, LastUpdate: time.Now().Add(-time.Minute)},
+			{Name: "trivy", Failed: true},
+		}
+	})
+	if err != nil {
+		t.Fatalf("RegisterUpdaterStatus() error = %v", err)
+	}
+
+	srv := httptest.NewServer(m.Handler())
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("scraping metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	for _, want := range []string{
+		`argus_scans_total{status="infected"} 1`,
+		`argus_scan_duration_seconds_count 1`,
+		`argus_trivy_jobs_total{status="completed"} 1`,
+		`argus_worker_queue_length 7`,
+		`argus_worker_jobs_processed_total 10`,
+		`argus_worker_job_errors_total 2`,
+		`argus_worker_infected_total 3`,
+		`argus_worker_scan_duration_avg_seconds 1.5`,
+		`argus_db_updater_ready{updater="clamav"} 1`,
+		`argus_db_updater_failed{updater="trivy"} 1`,
+		`argus_db_updater_last_update_age_seconds{updater="clamav"}`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("scrape output missing %q", want)
+		}
+	}
+	if strings.Contains(string(body), `argus_db_updater_last_update_age_seconds{updater="trivy"}`) {
+		t.Error("last update age reported for an updater that never completed")
+	}
+}
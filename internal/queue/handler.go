@@ -5,6 +5,16 @@ package queue
 
 import (
 	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/hikmaai-io/hikmaai-argus/internal/engine"
@@ -12,10 +22,20 @@ import (
 	"github.com/hikmaai-io/hikmaai-argus/internal/types"
 )
 
+// FileScanner scans files on the local filesystem.
+type FileScanner interface {
+	ScanFile(ctx context.Context, path string) (*types.ScanResult, error)
+}
+
 // Handler processes scan requests using the lookup engine.
 type Handler struct {
 	engine       *engine.Engine
 	trivyScanner *trivy.Scanner
+	fileScanner  FileScanner
+
+	// scanRoot is the resolved directory file requests are confined to;
+	// file requests are rejected when it is empty.
+	scanRoot string
 }
 
 // NewHandler creates a new message handler.
@@ -33,6 +53,32 @@ func NewHandlerWithTrivy(eng *engine.Engine, trivyScanner *trivy.Scanner) *Handl
 	}
 }
 
+// SetFileScanner sets the scanner used for file requests whose hash is not
+// a known signature.
+func (h *Handler) SetFileScanner(s FileScanner) {
+	h.fileScanner = s
+}
+
+// SetScanRoot confines file requests to files under root, after symlinks
+// are resolved. An empty root rejects all file requests.
+func (h *Handler) SetScanRoot(root string) error {
+	if root == "" {
+		h.scanRoot = ""
+		return nil
+	}
+
+	resolved, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return fmt.Errorf("resolving scan root: %w", err)
+	}
+	resolved, err = filepath.Abs(resolved)
+	if err != nil {
+		return fmt.Errorf("resolving scan root: %w", err)
+	}
+	h.scanRoot = resolved
+	return nil
+}
+
 // ProcessRequest processes a single scan request and returns the response.
 func (h *Handler) ProcessRequest(ctx context.Context, req ScanRequest) ScanResponse {
 	resp := ScanResponse{
@@ -41,6 +87,10 @@ func (h *Handler) ProcessRequest(ctx context.Context, req ScanRequest) ScanRespo
 		ScannedAt: time.Now().UTC(),
 	}
 
+	if req.FilePath != "" {
+		return h.processFile(ctx, req.FilePath, resp)
+	}
+
 	// Parse the hash.
 	hash, err := types.ParseHash(req.Hash)
 	if err != nil {
@@ -49,10 +99,19 @@ func (h *Handler) ProcessRequest(ctx context.Context, req ScanRequest) ScanRespo
 		return resp
 	}
 
-	resp.HashType = hash.Type.String()
+	return h.lookup(ctx, resp, hash)
+}
+
+// lookup fills resp with the engine result for hashes, reporting the first
+// one that matches a signature.
+func (h *Handler) lookup(ctx context.Context, resp ScanResponse, hashes ...types.Hash) ScanResponse {
+	resp.HashType = hashes[0].Type.String()
 
 	// Perform lookup.
-	result, err := h.engine.Lookup(ctx, hash)
+	result, err := h.engine.LookupAll(ctx, hashes...)
+	if result.Status == types.StatusMalware {
+		resp.HashType = result.Hash.Type.String()
+	}
 	if err != nil {
 		resp.Status = "error"
 		resp.Error = err.Error()
@@ -76,6 +135,111 @@ func (h *Handler) ProcessRequest(ctx context.Context, req ScanRequest) ScanRespo
 	return resp
 }
 
+// processFile hashes the file for a signature lookup and falls back to the
+// file scanner when the hash is not a known signature. The SHA256, SHA1,
+// and MD5 are all checked, since feeds such as ClamAV .hdb databases only
+// carry MD5s.
+func (h *Handler) processFile(ctx context.Context, path string, resp ScanResponse) ScanResponse {
+	path, err := h.resolveFilePath(path)
+	if err != nil {
+		resp.Status = "error"
+		resp.Error = err.Error()
+		return resp
+	}
+
+	hashes, err := hashFile(path)
+	if err != nil {
+		resp.Status = "error"
+		resp.Error = err.Error()
+		return resp
+	}
+
+	resp.Hash = hashes[0].Value
+	resp = h.lookup(ctx, resp, hashes...)
+	if resp.Status == types.StatusMalware.String() || resp.Status == types.StatusError.String() || h.fileScanner == nil {
+		return resp
+	}
+
+	result, err := h.fileScanner.ScanFile(ctx, path)
+	if err != nil {
+		resp.Status = "error"
+		resp.Error = fmt.Sprintf("scanning file: %v", err)
+		return resp
+	}
+
+	switch result.Status {
+	case types.ScanStatusInfected:
+		resp.Status = types.StatusMalware.String()
+		resp.Detection = result.Detection
+		resp.Threat = result.ThreatType.String()
+		resp.Severity = result.Severity.String()
+		resp.Source = result.Engine
+	case types.ScanStatusClean:
+		resp.Status = types.StatusClean.String()
+	default:
+		resp.Status = "error"
+		resp.Error = result.Error
+	}
+
+	return resp
+}
+
+// resolveFilePath resolves path, relative to the scan root unless it is
+// absolute, and checks that it stays inside the root both as written and
+// once symlinks are followed.
+func (h *Handler) resolveFilePath(path string) (string, error) {
+	if h.scanRoot == "" {
+		return "", errors.New("file requests are disabled: no scan root is configured")
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(h.scanRoot, path)
+	}
+	if !h.insideScanRoot(filepath.Clean(path)) {
+		return "", fmt.Errorf("file path %q is outside the scan root", path)
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("resolving file path: %w", err)
+	}
+	if !h.insideScanRoot(resolved) {
+		return "", fmt.Errorf("file path %q is outside the scan root", path)
+	}
+	return resolved, nil
+}
+
+// insideScanRoot reports whether the absolute, clean path is the scan root
+// or lies beneath it.
+func (h *Handler) insideScanRoot(path string) bool {
+	rel, err := filepath.Rel(h.scanRoot, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// hashFile returns the SHA256, SHA1, and MD5 of the file at path, in that
+// order, computed in a single pass.
+func hashFile(path string) ([]types.Hash, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	defer f.Close()
+
+	h256, h1, h5 := sha256.New(), sha1.New(), md5.New()
+	if _, err := io.Copy(io.MultiWriter(h256, h1, h5), f); err != nil {
+		return nil, fmt.Errorf("hashing file: %w", err)
+	}
+
+	return []types.Hash{
+		{Type: types.HashTypeSHA256, Value: hex.EncodeToString(h256.Sum(nil))},
+		{Type: types.HashTypeSHA1, Value: hex.EncodeToString(h1.Sum(nil))},
+		{Type: types.HashTypeMD5, Value: hex.EncodeToString(h5.Sum(nil))},
+	}, nil
+}
+
 // ProcessBatch processes multiple scan requests and returns all responses.
 func (h *Handler) ProcessBatch(ctx context.Context, reqs []ScanRequest) []ScanResponse {
 	responses := make([]ScanResponse, 0, len(reqs))
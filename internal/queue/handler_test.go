@@ -5,12 +5,18 @@ package queue_test
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/hikmaai-io/hikmaai-argus/internal/engine"
 	"github.com/hikmaai-io/hikmaai-argus/internal/feeds"
 	"github.com/hikmaai-io/hikmaai-argus/internal/queue"
+	"github.com/hikmaai-io/hikmaai-argus/internal/types"
 )
 
 func TestScanRequest_JSON(t *testing.T) {
@@ -179,6 +185,99 @@ func TestHandler_ProcessBatch(t *testing.T) {
 	}
 }
 
+func TestHandler_ProcessRequest_FileMatchesMD5Signature(t *testing.T) {
+	t.Parallel()
+
+	eng := newTestEngine(t)
+	ctx := context.Background()
+
+	// An MD5-only signature, as imported from a ClamAV .hdb database.
+	content := []byte("md5 keyed sample")
+	sum := md5.Sum(content)
+	md5Hex := hex.EncodeToString(sum[:])
+	if err := eng.AddSignature(ctx, &types.Signature{MD5: md5Hex, DetectionName: "Hdb.Sample", Source: "clamav"}); err != nil {
+		t.Fatalf("AddSignature() error: %v", err)
+	}
+
+	root := t.TempDir()
+	path := filepath.Join(root, "sample.bin")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := queue.NewHandler(eng)
+	if err := handler.SetScanRoot(root); err != nil {
+		t.Fatalf("SetScanRoot() error: %v", err)
+	}
+	resp := handler.ProcessRequest(ctx, queue.ScanRequest{FilePath: path, RequestID: "md5-file"})
+
+	if resp.Status != "malware" {
+		t.Fatalf("Status = %v, want malware (error %q)", resp.Status, resp.Error)
+	}
+	if resp.Detection != "Hdb.Sample" {
+		t.Errorf("Detection = %v, want Hdb.Sample", resp.Detection)
+	}
+	if resp.HashType != "md5" {
+		t.Errorf("HashType = %v, want md5", resp.HashType)
+	}
+	if len(resp.Hash) != types.SHA256Length {
+		t.Errorf("Hash = %q, want the file's SHA256", resp.Hash)
+	}
+}
+
+func TestHandler_ProcessRequest_FileConfinedToScanRoot(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	outside := t.TempDir()
+	for _, path := range []string{filepath.Join(root, "inside.bin"), filepath.Join(outside, "outside.bin")} {
+		if err := os.WriteFile(path, []byte("sample"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(outside, "outside.bin"), filepath.Join(root, "escape.bin")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "escape-dir")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		scanRoot   string
+		path       string
+		wantStatus string
+		wantError  string
+	}{
+		{name: "inside root", scanRoot: root, path: filepath.Join(root, "inside.bin"), wantStatus: "unknown"},
+		{name: "relative to root", scanRoot: root, path: "inside.bin", wantStatus: "unknown"},
+		{name: "outside root", scanRoot: root, path: filepath.Join(outside, "outside.bin"), wantStatus: "error", wantError: "outside the scan root"},
+		{name: "dot-dot escape", scanRoot: root, path: "../" + filepath.Base(outside) + "/outside.bin", wantStatus: "error", wantError: "outside the scan root"},
+		{name: "symlinked file escape", scanRoot: root, path: filepath.Join(root, "escape.bin"), wantStatus: "error", wantError: "outside the scan root"},
+		{name: "symlinked dir escape", scanRoot: root, path: filepath.Join(root, "escape-dir", "outside.bin"), wantStatus: "error", wantError: "outside the scan root"},
+		{name: "no scan root", path: filepath.Join(root, "inside.bin"), wantStatus: "error", wantError: "no scan root"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := queue.NewHandler(newTestEngine(t))
+			if err := handler.SetScanRoot(tt.scanRoot); err != nil {
+				t.Fatalf("SetScanRoot() error: %v", err)
+			}
+
+			resp := handler.ProcessRequest(context.Background(), queue.ScanRequest{FilePath: tt.path})
+			if resp.Status != tt.wantStatus {
+				t.Fatalf("Status = %v, want %v (error %q)", resp.Status, tt.wantStatus, resp.Error)
+			}
+			if !strings.Contains(resp.Error, tt.wantError) {
+				t.Errorf("Error = %q, want it to contain %q", resp.Error, tt.wantError)
+			}
+		})
+	}
+}
+
 func newTestEngine(t *testing.T) *engine.Engine {
 	t.Helper()
 
@@ -9,10 +9,17 @@ import (
 	"github.com/hikmaai-io/hikmaai-argus/internal/trivy"
 )
 
-// ScanRequest is the message sent to request a hash scan.
+// ScanRequest is the message sent to request a hash or file scan.
 type ScanRequest struct {
 	// The hash to scan (SHA256, SHA1, or MD5).
-	Hash string `json:"hash"`
+	Hash string `json:"hash,omitempty"`
+
+	// Path of a file on the daemon's filesystem to scan instead of a hash.
+	// The file is hashed for a signature lookup and, if a file scanner is
+	// configured, scanned with it when the lookup finds nothing. The path
+	// must resolve to a file under the daemon's scan root (nats.scan_root);
+	// without a scan root, file requests are rejected.
+	FilePath string `json:"file_path,omitempty"`
 
 	// Optional request ID for correlation.
 	RequestID string `json:"request_id,omitempty"`
@@ -1,5 +1,5 @@
 // ABOUTME: NATS client wrapper for queue subscriptions
-// ABOUTME: Handles connection, subscription with queue groups, and graceful shutdown
+// ABOUTME: Handles connection, subscription with queue groups, and graceful drain
 
 package queue
 
@@ -57,6 +57,9 @@ type Client struct {
 	handler *Handler
 	config  NATSConfig
 	logger  *slog.Logger
+
+	// closed is closed once the connection has fully shut down.
+	closed chan struct{}
 }
 
 // NewClient creates a new NATS client with the given configuration.
@@ -74,6 +77,7 @@ func NewClient(cfg NATSConfig, handler *Handler, logger *slog.Logger) (*Client,
 
 // Connect establishes the NATS connection.
 func (c *Client) Connect(ctx context.Context) error {
+	closed := make(chan struct{})
 	opts := []nats.Option{
 		nats.Name(c.config.Name),
 		nats.MaxReconnects(c.config.MaxReconnects),
@@ -86,11 +90,16 @@ func (c *Client) Connect(ctx context.Context) error {
 		}),
 		nats.ClosedHandler(func(nc *nats.Conn) {
 			c.logger.Info("NATS connection closed")
+			close(closed)
 		}),
 		nats.ErrorHandler(func(nc *nats.Conn, sub *nats.Subscription, err error) {
+			subject := ""
+			if sub != nil {
+				subject = sub.Subject
+			}
 			c.logger.Error("NATS error",
 				slog.Any("error", err),
-				slog.String("subject", sub.Subject),
+				slog.String("subject", subject),
 			)
 		}),
 	}
@@ -101,6 +110,7 @@ func (c *Client) Connect(ctx context.Context) error {
 	}
 
 	c.conn = conn
+	c.closed = closed
 	c.logger.Info("connected to NATS",
 		slog.String("url", conn.ConnectedUrl()),
 		slog.String("server_id", conn.ConnectedServerId()),
@@ -207,6 +217,27 @@ func (c *Client) replyError(msg *nats.Msg, requestID, errMsg string) {
 	}
 }
 
+// Drain stops receiving new messages, lets in-flight requests finish and
+// reply, then closes the connection. It waits until the connection is
+// closed or ctx is done.
+func (c *Client) Drain(ctx context.Context) error {
+	if c.conn == nil {
+		return nil
+	}
+
+	if err := c.conn.Drain(); err != nil {
+		return fmt.Errorf("draining NATS connection: %w", err)
+	}
+
+	select {
+	case <-c.closed:
+		return nil
+	case <-ctx.Done():
+		c.conn.Close()
+		return fmt.Errorf("draining NATS connection: %w", ctx.Err())
+	}
+}
+
 // Close closes the NATS connection.
 func (c *Client) Close() error {
 	if c.sub != nil {
@@ -0,0 +1,188 @@
+// ABOUTME: Tests for the NATS client against an embedded NATS server
+// ABOUTME: Covers request/reply for hash and file scans, bad input, and drain
+
+package queue_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/feeds"
+	"github.com/hikmaai-io/hikmaai-argus/internal/queue"
+	"github.com/hikmaai-io/hikmaai-argus/internal/types"
+)
+
+// stubFileScanner reports every file as infected.
+type stubFileScanner struct{}
+
+func (stubFileScanner) ScanFile(ctx context.Context, path string) (*types.ScanResult, error) {
+	return types.NewInfectedScanResult(path, "", 0, "Win.Test.Stub-1"), nil
+}
+
+func TestClient_RequestReply(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	eng := newTestEngine(t)
+	if err := eng.BatchAddSignatures(ctx, feeds.EICARSignatures()); err != nil {
+		t.Fatalf("BatchAddSignatures() error: %v", err)
+	}
+
+	dir := t.TempDir()
+	handler := queue.NewHandler(eng)
+	handler.SetFileScanner(stubFileScanner{})
+	if err := handler.SetScanRoot(dir); err != nil {
+		t.Fatalf("SetScanRoot() error: %v", err)
+	}
+	client, requester := startTestClient(t, handler)
+
+	eicarPath := filepath.Join(dir, "eicar.com")
+	eicar := `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+	if err := os.WriteFile(eicarPath, []byte(eicar), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	otherPath := filepath.Join(dir, "other.bin")
+	if err := os.WriteFile(otherPath, []byte("not a known signature"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		payload       string
+		wantStatus    string
+		wantDetection string
+	}{
+		{
+			name:          "known hash",
+			payload:       `{"hash": "275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f", "request_id": "r1"}`,
+			wantStatus:    "malware",
+			wantDetection: "EICAR-Test-File",
+		},
+		{
+			name:       "unknown hash",
+			payload:    `{"hash": "` + zeroSHA256 + `"}`,
+			wantStatus: "unknown",
+		},
+		{
+			name:          "file matching a signature",
+			payload:       mustJSON(t, queue.ScanRequest{FilePath: eicarPath}),
+			wantStatus:    "malware",
+			wantDetection: "EICAR-Test-File",
+		},
+		{
+			name:          "file scanned by the file scanner",
+			payload:       mustJSON(t, queue.ScanRequest{FilePath: otherPath}),
+			wantStatus:    "malware",
+			wantDetection: "Win.Test.Stub-1",
+		},
+		{
+			name:       "missing file",
+			payload:    mustJSON(t, queue.ScanRequest{FilePath: filepath.Join(dir, "missing")}),
+			wantStatus: "error",
+		},
+		{
+			name:       "malformed request",
+			payload:    `{"hash":`,
+			wantStatus: "error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := requester.Request(queue.DefaultNATSConfig().Subject, []byte(tt.payload), 5*time.Second)
+			if err != nil {
+				t.Fatalf("Request() error: %v", err)
+			}
+
+			var resp queue.ScanResponse
+			if err := json.Unmarshal(msg.Data, &resp); err != nil {
+				t.Fatalf("decoding reply: %v", err)
+			}
+			if resp.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q (error: %s)", resp.Status, tt.wantStatus, resp.Error)
+			}
+			if resp.Detection != tt.wantDetection {
+				t.Errorf("Detection = %q, want %q", resp.Detection, tt.wantDetection)
+			}
+		})
+	}
+
+	if !client.IsConnected() {
+		t.Error("IsConnected() = false, want true")
+	}
+}
+
+func TestClient_Drain(t *testing.T) {
+	t.Parallel()
+
+	client, requester := startTestClient(t, queue.NewHandler(newTestEngine(t)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Drain(ctx); err != nil {
+		t.Fatalf("Drain() error: %v", err)
+	}
+	if client.IsConnected() {
+		t.Error("IsConnected() after Drain() = true, want false")
+	}
+
+	// No subscriber remains to answer requests.
+	_, err := requester.Request(queue.DefaultNATSConfig().Subject, []byte(`{"hash": "`+zeroSHA256+`"}`), 200*time.Millisecond)
+	if err == nil {
+		t.Error("Request() after Drain() succeeded, want no responders")
+	}
+}
+
+const zeroSHA256 = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// startTestClient runs an embedded NATS server, subscribes a client with
+// handler on it, and returns the client plus a separate requester connection.
+func startTestClient(t *testing.T, handler *queue.Handler) (*queue.Client, *nats.Conn) {
+	t.Helper()
+
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	srv := natsserver.RunServer(&opts)
+	t.Cleanup(srv.Shutdown)
+
+	cfg := queue.DefaultNATSConfig()
+	cfg.URL = srv.ClientURL()
+
+	client, err := queue.NewClient(cfg, handler, nil)
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	if err := client.Subscribe(ctx); err != nil {
+		t.Fatalf("Subscribe() error: %v", err)
+	}
+
+	requester, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("connecting requester: %v", err)
+	}
+	t.Cleanup(requester.Close)
+
+	return client, requester
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -6,6 +6,7 @@ package redis
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -49,10 +50,25 @@ func (c *Config) setDefaults() {
 	}
 }
 
+// Health is the outcome of the most recent connectivity check.
+type Health struct {
+	// Connected reports whether the last PING succeeded.
+	Connected bool `json:"connected"`
+
+	// LastError is the error of the last failed PING, if any.
+	LastError string `json:"last_error,omitempty"`
+
+	// CheckedAt is when the last PING completed.
+	CheckedAt time.Time `json:"checked_at"`
+}
+
 // Client wraps a Redis client with prefix support.
 type Client struct {
 	rdb    *redis.Client
 	prefix string
+
+	mu     sync.Mutex
+	health Health
 }
 
 // NewClient creates a new Redis client with the given configuration.
@@ -81,6 +97,7 @@ func NewClient(cfg Config) (*Client, error) {
 	return &Client{
 		rdb:    rdb,
 		prefix: cfg.Prefix,
+		health: Health{Connected: true, CheckedAt: time.Now().UTC()},
 	}, nil
 }
 
@@ -89,14 +106,54 @@ func (c *Client) PrefixedKey(key string) string {
 	return c.prefix + key
 }
 
-// Ping verifies connectivity to Redis.
+// Ping verifies connectivity to Redis and records the outcome, which
+// Health reports. A ping cut short by ctx is not recorded.
 func (c *Client) Ping(ctx context.Context) error {
-	if err := c.rdb.Ping(ctx).Err(); err != nil {
+	err := c.rdb.Ping(ctx).Err()
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("pinging redis: %w", err)
+	}
+
+	c.mu.Lock()
+	c.health = Health{Connected: err == nil, CheckedAt: time.Now().UTC()}
+	if err != nil {
+		c.health.LastError = err.Error()
+	}
+	c.mu.Unlock()
+
+	if err != nil {
 		return fmt.Errorf("pinging redis: %w", err)
 	}
 	return nil
 }
 
+// Health returns the outcome of the most recent Ping.
+func (c *Client) Health() Health {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.health
+}
+
+// WaitForConnection pings Redis until it answers, waiting between attempts
+// with exponential backoff from initial up to maxDelay. The connection pool
+// redials on its own, so once a ping succeeds the client is usable again.
+// It returns ctx's error if ctx is done first.
+func (c *Client) WaitForConnection(ctx context.Context, initial, maxDelay time.Duration) error {
+	delay := initial
+	for {
+		if err := c.Ping(ctx); err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxDelay)
+	}
+}
+
 // Set stores a value with the given key and TTL.
 // The key is automatically prefixed.
 func (c *Client) Set(ctx context.Context, key, value string, ttl time.Duration) error {
@@ -91,6 +91,76 @@ func TestClient_Ping(t *testing.T) {
 	}
 }
 
+func TestClient_Reconnect(t *testing.T) {
+	t.Parallel()
+
+	mr := miniredis.RunT(t)
+
+	client, err := NewClient(Config{
+		Addr:        mr.Addr(),
+		Prefix:      "test:",
+		ReadTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if !client.Health().Connected {
+		t.Fatal("Health().Connected = false after NewClient")
+	}
+
+	// Drop the server; pings fail and health records the outage.
+	mr.Close()
+	if err := client.Ping(ctx); err == nil {
+		t.Fatal("Ping() succeeded with redis down")
+	}
+	if health := client.Health(); health.Connected || health.LastError == "" {
+		t.Errorf("Health() = %+v, want disconnected with an error", health)
+	}
+
+	// Bring it back while WaitForConnection is retrying.
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_ = mr.Restart()
+	}()
+
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := client.WaitForConnection(waitCtx, 10*time.Millisecond, 100*time.Millisecond); err != nil {
+		t.Fatalf("WaitForConnection() error = %v", err)
+	}
+	if health := client.Health(); !health.Connected || health.LastError != "" {
+		t.Errorf("Health() = %+v, want connected", health)
+	}
+
+	// The pool redials, so normal commands work again.
+	if err := client.Set(ctx, "key", "value", time.Minute); err != nil {
+		t.Errorf("Set() after reconnect error = %v", err)
+	}
+}
+
+func TestClient_WaitForConnection_ContextDone(t *testing.T) {
+	t.Parallel()
+
+	mr := miniredis.RunT(t)
+
+	client, err := NewClient(Config{Addr: mr.Addr(), ReadTimeout: time.Second})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	mr.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := client.WaitForConnection(ctx, 10*time.Millisecond, 50*time.Millisecond); err == nil {
+		t.Fatal("WaitForConnection() succeeded with redis down")
+	}
+}
+
 func TestClient_PrefixedKey(t *testing.T) {
 	t.Parallel()
 
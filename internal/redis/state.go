@@ -73,6 +73,27 @@ func (m *StateManager) SetFields(ctx context.Context, jobID string, fields map[s
 	return nil
 }
 
+// IncrField atomically adds delta to an integer field in the job state hash
+// and returns the new value; a missing field starts from zero. The entry's
+// TTL is reset to the default TTL in the same transaction, so a counter
+// never creates a state entry that does not expire.
+func (m *StateManager) IncrField(ctx context.Context, jobID, field string, delta int64) (int64, error) {
+	key := m.JobKey(jobID)
+
+	var incr *redis.IntCmd
+	_, err := m.client.Redis().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.HIncrBy(ctx, key, field, delta)
+		if m.defaultTTL > 0 {
+			pipe.Expire(ctx, key, m.defaultTTL)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("incrementing field %s on %s: %w", field, key, err)
+	}
+	return incr.Val(), nil
+}
+
 // SetJSON sets a field with a JSON-encoded value.
 func (m *StateManager) SetJSON(ctx context.Context, jobID, field string, value any) error {
 	data, err := json.Marshal(value)
@@ -6,6 +6,8 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -409,3 +411,77 @@ func TestStateManager_InitState(t *testing.T) {
 		t.Errorf("TTL not set on key %q", key)
 	}
 }
+
+func TestStateManager_IncrField(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		goroutines int
+		increments int
+		delta      int64
+		want       int64
+	}{
+		{name: "single increment", goroutines: 1, increments: 1, delta: 1, want: 1},
+		{name: "concurrent increments", goroutines: 10, increments: 50, delta: 1, want: 500},
+		{name: "concurrent batches", goroutines: 8, increments: 10, delta: 3, want: 240},
+		{name: "decrement", goroutines: 2, increments: 5, delta: -1, want: -10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mr := miniredis.RunT(t)
+
+			client, err := NewClient(Config{
+				Addr:   mr.Addr(),
+				Prefix: "argus:",
+			})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			defer client.Close()
+
+			mgr := NewStateManager(client, StateManagerConfig{
+				KeyPrefix:  "job_state:",
+				DefaultTTL: time.Hour,
+			})
+
+			ctx := context.Background()
+			jobID := "job-progress"
+
+			var wg sync.WaitGroup
+			errs := make(chan error, tt.goroutines*tt.increments)
+			for range tt.goroutines {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range tt.increments {
+						if _, err := mgr.IncrField(ctx, jobID, "files_scanned", tt.delta); err != nil {
+							errs <- err
+						}
+					}
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				t.Fatalf("IncrField() error = %v", err)
+			}
+
+			got, err := mgr.GetField(ctx, jobID, "files_scanned")
+			if err != nil {
+				t.Fatalf("GetField() error = %v", err)
+			}
+			if want := strconv.FormatInt(tt.want, 10); got != want {
+				t.Errorf("files_scanned = %s, want %s", got, want)
+			}
+
+			// The counter alone created the entry; it must still expire.
+			if ttl := mr.TTL(mgr.JobKey(jobID)); ttl <= 0 || ttl > time.Hour {
+				t.Errorf("TTL = %v, want (0, 1h]", ttl)
+			}
+		})
+	}
+}
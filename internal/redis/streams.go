@@ -40,6 +40,10 @@ type StreamMessage struct {
 
 	// Values contains the message fields.
 	Values map[string]string
+
+	// DeliveryCount is how many times the message has been delivered to a
+	// consumer. It is only populated for messages returned by AutoClaim.
+	DeliveryCount int64
 }
 
 // StreamConsumer reads messages from a Redis Stream using consumer groups.
@@ -154,17 +158,71 @@ func (c *StreamConsumer) Read(ctx context.Context, count int64) ([]StreamMessage
 	// Convert to our message type.
 	var messages []StreamMessage
 	for _, stream := range streams {
-		for _, msg := range stream.Messages {
-			values := make(map[string]string, len(msg.Values))
-			for k, v := range msg.Values {
-				if s, ok := v.(string); ok {
-					values[k] = s
-				}
-			}
-			messages = append(messages, StreamMessage{
-				ID:     msg.ID,
-				Values: values,
-			})
+		messages = append(messages, toStreamMessages(stream.Messages)...)
+	}
+
+	return messages, nil
+}
+
+// AutoClaim transfers up to count messages that have been pending for at
+// least minIdle, typically on a crashed consumer, to this consumer using
+// XAUTOCLAIM. The whole pending entries list is scanned. Returned messages
+// carry their DeliveryCount, which includes this claim.
+func (c *StreamConsumer) AutoClaim(ctx context.Context, minIdle time.Duration, count int64) ([]StreamMessage, error) {
+	rdb := c.client.Redis()
+
+	var claimed []redis.XMessage
+	start := "0-0"
+	for int64(len(claimed)) < count {
+		msgs, next, err := rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   c.streamKey,
+			Group:    c.consumerGroup,
+			Consumer: c.consumerName,
+			MinIdle:  minIdle,
+			Start:    start,
+			Count:    count - int64(len(claimed)),
+		}).Result()
+		if err != nil {
+			return nil, fmt.Errorf("auto-claiming from stream %s: %w", c.streamKey, err)
+		}
+
+		claimed = append(claimed, msgs...)
+
+		// A cursor of 0-0 means the pending entries list was fully scanned.
+		if next == "0-0" || next == "" {
+			break
+		}
+		start = next
+	}
+
+	if len(claimed) == 0 {
+		return nil, nil
+	}
+
+	messages := toStreamMessages(claimed)
+
+	// Look up delivery counts so callers can enforce retry limits. Each
+	// message is looked up by its own ID: a range query would also return
+	// other entries between the claimed IDs and, capped by Count, could
+	// leave claimed messages without a count.
+	pipe := rdb.Pipeline()
+	lookups := make([]*redis.XPendingExtCmd, len(messages))
+	for i, msg := range messages {
+		lookups[i] = pipe.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream: c.streamKey,
+			Group:  c.consumerGroup,
+			Start:  msg.ID,
+			End:    msg.ID,
+			Count:  1,
+		})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("reading pending entries on %s: %w", c.streamKey, err)
+	}
+
+	for i, lookup := range lookups {
+		if pending := lookup.Val(); len(pending) > 0 {
+			messages[i].DeliveryCount = pending[0].RetryCount
 		}
 	}
 
@@ -214,6 +272,25 @@ func (c *StreamConsumer) Publish(ctx context.Context, values map[string]any) (st
 	return id, nil
 }
 
+// toStreamMessages converts go-redis stream messages to StreamMessage,
+// keeping only string field values.
+func toStreamMessages(msgs []redis.XMessage) []StreamMessage {
+	messages := make([]StreamMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		values := make(map[string]string, len(msg.Values))
+		for k, v := range msg.Values {
+			if s, ok := v.(string); ok {
+				values[k] = s
+			}
+		}
+		messages = append(messages, StreamMessage{
+			ID:     msg.ID,
+			Values: values,
+		})
+	}
+	return messages
+}
+
 // isBusyGroupError checks if the error is BUSYGROUP (group already exists).
 func isBusyGroupError(err error) bool {
 	if err == nil {
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
 )
 
 func TestNewStreamConsumer(t *testing.T) {
@@ -157,6 +158,101 @@ func TestStreamConsumer_ReadAndAck(t *testing.T) {
 	}
 }
 
+func TestStreamConsumer_AutoClaim(t *testing.T) {
+	t.Parallel()
+
+	mr := miniredis.RunT(t)
+
+	client, err := NewClient(Config{
+		Addr:   mr.Addr(),
+		Prefix: "argus:",
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	newConsumer := func(name string) *StreamConsumer {
+		consumer, err := NewStreamConsumer(client, StreamConsumerConfig{
+			Stream:        "task_queue",
+			ConsumerGroup: "workers",
+			ConsumerName:  name,
+			BlockTimeout:  100 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatalf("NewStreamConsumer() error = %v", err)
+		}
+		return consumer
+	}
+
+	dead := newConsumer("worker-dead")
+	alive := newConsumer("worker-alive")
+
+	ctx := context.Background()
+	if err := dead.EnsureGroup(ctx); err != nil {
+		t.Fatalf("EnsureGroup() error = %v", err)
+	}
+
+	id, err := dead.Publish(ctx, map[string]any{"data": "task"})
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	// The dead consumer reads the message but never acknowledges it.
+	if _, err := dead.Read(ctx, 1); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	// Not idle long enough yet.
+	messages, err := alive.AutoClaim(ctx, time.Hour, 10)
+	if err != nil {
+		t.Fatalf("AutoClaim() error = %v", err)
+	}
+	if len(messages) != 0 {
+		t.Fatalf("AutoClaim() claimed %d messages before min idle, want 0", len(messages))
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	messages, err = alive.AutoClaim(ctx, 10*time.Millisecond, 10)
+	if err != nil {
+		t.Fatalf("AutoClaim() error = %v", err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("AutoClaim() got %d messages, want 1", len(messages))
+	}
+
+	msg := messages[0]
+	if msg.ID != id {
+		t.Errorf("claimed ID = %q, want %q", msg.ID, id)
+	}
+	if msg.Values["data"] != "task" {
+		t.Errorf("claimed data = %q, want %q", msg.Values["data"], "task")
+	}
+	if msg.DeliveryCount != 2 {
+		t.Errorf("DeliveryCount = %d, want 2", msg.DeliveryCount)
+	}
+
+	// The message now belongs to the live consumer.
+	pending, err := client.Redis().XPendingExt(ctx, &goredis.XPendingExtArgs{
+		Stream: alive.StreamKey(),
+		Group:  "workers",
+		Start:  "-",
+		End:    "+",
+		Count:  10,
+	}).Result()
+	if err != nil {
+		t.Fatalf("XPendingExt() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].Consumer != "worker-alive" {
+		t.Errorf("pending entries = %+v, want one owned by worker-alive", pending)
+	}
+
+	if err := alive.Ack(ctx, msg.ID); err != nil {
+		t.Errorf("Ack() error = %v", err)
+	}
+}
+
 func TestStreamConsumer_PrefixedStream(t *testing.T) {
 	t.Parallel()
 
@@ -356,3 +452,76 @@ func TestIsNoGroupError(t *testing.T) {
 	}
 }
 
+func TestStreamConsumer_AutoClaim_DeliveryCounts(t *testing.T) {
+	t.Parallel()
+
+	mr := miniredis.RunT(t)
+
+	client, err := NewClient(Config{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	newConsumer := func(name string) *StreamConsumer {
+		consumer, err := NewStreamConsumer(client, StreamConsumerConfig{
+			Stream:        "task_queue",
+			ConsumerGroup: "workers",
+			ConsumerName:  name,
+			BlockTimeout:  100 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatalf("NewStreamConsumer() error = %v", err)
+		}
+		return consumer
+	}
+
+	dead := newConsumer("worker-dead")
+	alive := newConsumer("worker-alive")
+
+	ctx := context.Background()
+	if err := dead.EnsureGroup(ctx); err != nil {
+		t.Fatalf("EnsureGroup() error = %v", err)
+	}
+
+	var ids []string
+	for range 3 {
+		id, err := dead.Publish(ctx, map[string]any{"data": "task"})
+		if err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if _, err := dead.Read(ctx, 3); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	// The live consumer already holds the middle message, twice delivered
+	// and recently active, so it sits between the claimed IDs.
+	if err := client.Redis().XClaim(ctx, &goredis.XClaimArgs{
+		Stream:   alive.StreamKey(),
+		Group:    "workers",
+		Consumer: "worker-alive",
+		Messages: []string{ids[1]},
+	}).Err(); err != nil {
+		t.Fatalf("XClaim() error = %v", err)
+	}
+
+	messages, err := alive.AutoClaim(ctx, 10*time.Millisecond, 2)
+	if err != nil {
+		t.Fatalf("AutoClaim() error = %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("AutoClaim() got %d messages, want 2", len(messages))
+	}
+	for i, msg := range messages {
+		if want := ids[i*2]; msg.ID != want {
+			t.Errorf("messages[%d].ID = %q, want %q", i, msg.ID, want)
+		}
+		if msg.DeliveryCount != 2 {
+			t.Errorf("messages[%d].DeliveryCount = %d, want 2", i, msg.DeliveryCount)
+		}
+	}
+}
@@ -13,16 +13,28 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/hikmaai-io/hikmaai-argus/internal/config"
+	"github.com/hikmaai-io/hikmaai-argus/internal/engine"
+	"github.com/hikmaai-io/hikmaai-argus/internal/observability"
 	"github.com/hikmaai-io/hikmaai-argus/internal/types"
 )
 
 // ClamAVScanner provides malware scanning using ClamAV.
 type ClamAVScanner struct {
 	config *config.ClamAVConfig
+
+	// ScanCache caches results by file SHA256 (optional). When set, ScanFile
+	// returns the cached result for content it has already scanned instead
+	// of invoking ClamAV. Results older than config.CacheTTL are rescanned.
+	ScanCache *engine.ScanCache
 }
 
 // NewClamAVScanner creates a new ClamAV scanner.
@@ -42,6 +54,23 @@ func (s *ClamAVScanner) Mode() string {
 
 // ScanFile scans a single file for malware.
 func (s *ClamAVScanner) ScanFile(ctx context.Context, path string) (*types.ScanResult, error) {
+	ctx, span := observability.StartSpan(ctx, "clamav.scan_file",
+		trace.WithAttributes(attribute.String("scanner.mode", s.Mode())))
+	defer span.End()
+
+	result, err := s.scanFile(ctx, path)
+	if result != nil {
+		span.SetAttributes(
+			attribute.Int64("file.size", result.FileSize),
+			attribute.String("scan.status", result.Status.String()),
+		)
+	}
+	observability.RecordSpanError(span, err)
+	return result, err
+}
+
+// scanFile performs the scan for ScanFile.
+func (s *ClamAVScanner) scanFile(ctx context.Context, path string) (*types.ScanResult, error) {
 	start := time.Now()
 
 	// Get file info for hash and size.
@@ -64,6 +93,12 @@ func (s *ClamAVScanner) ScanFile(ctx context.Context, path string) (*types.ScanR
 			WithFileInfo(fileSize, ""), nil
 	}
 
+	// Serve identical content from the cache.
+	if cached := s.cachedResult(ctx, path, fileHash); cached != nil {
+		cached.ScanTimeMs = float64(time.Since(start).Milliseconds())
+		return cached, nil
+	}
+
 	// Scan based on mode.
 	var result *types.ScanResult
 	switch s.Mode() {
@@ -82,9 +117,125 @@ func (s *ClamAVScanner) ScanFile(ctx context.Context, path string) (*types.ScanR
 	elapsed := time.Since(start)
 	result.ScanTimeMs = float64(elapsed.Milliseconds())
 
+	s.cacheResult(ctx, fileHash, result)
+
+	return result, nil
+}
+
+// cachedResult returns the cached result for fileHash, re-labelled for
+// path, or nil on a miss or when caching is disabled.
+func (s *ClamAVScanner) cachedResult(ctx context.Context, path, fileHash string) *types.ScanResult {
+	if s.ScanCache == nil {
+		return nil
+	}
+
+	cached, found, err := s.ScanCache.Get(ctx, fileHash)
+	if err != nil || !found {
+		return nil
+	}
+	if s.config.CacheTTL > 0 && time.Since(cached.ScannedAt) > s.config.CacheTTL {
+		return nil
+	}
+
+	cached.FilePath = path
+	cached.Cached = true
+	return cached
+}
+
+// cacheResult stores a clean or infected result; errors are never cached
+// so the file is retried on the next scan.
+func (s *ClamAVScanner) cacheResult(ctx context.Context, fileHash string, result *types.ScanResult) {
+	if s.ScanCache == nil || result.Status == types.ScanStatusError {
+		return
+	}
+
+	// Caching is best-effort; a failed write only costs a rescan.
+	_ = s.ScanCache.Put(ctx, fileHash, result)
+}
+
+// ScanReader scans the content read from r, reporting it under name. In
+// clamd mode the content is streamed with INSTREAM and never touches disk;
+// in clamscan mode it is staged to a temporary file that is removed
+// afterwards. The file hash is computed from the stream.
+func (s *ClamAVScanner) ScanReader(ctx context.Context, r io.Reader, name string) (*types.ScanResult, error) {
+	if s.Mode() != "clamd" {
+		return s.scanReaderViaTempFile(ctx, r, name)
+	}
+
+	start := time.Now()
+
+	// Hash and count the stream as clamd consumes it. Reading one byte past
+	// the size limit is enough to tell that the content is too large.
+	hasher := sha256.New()
+	counter := &countingReader{r: io.TeeReader(r, hasher)}
+	var src io.Reader = counter
+	if s.config.MaxFileSize > 0 {
+		src = io.LimitReader(counter, s.config.MaxFileSize+1)
+	}
+
+	result, err := s.scanStreamWithClamd(ctx, name, src)
+	fileHash := hex.EncodeToString(hasher.Sum(nil))
+	fileSize := counter.n
+
+	if s.config.MaxFileSize > 0 && fileSize > s.config.MaxFileSize {
+		return types.NewErrorScanResult(name, fmt.Sprintf("file too large: more than %d bytes (max: %d)", s.config.MaxFileSize, s.config.MaxFileSize)), nil
+	}
+	if err != nil {
+		return types.NewErrorScanResult(name, err.Error()).
+			WithFileInfo(fileSize, fileHash), nil
+	}
+
+	result.FileHash = fileHash
+	result.FileSize = fileSize
+	result.ScanTimeMs = float64(time.Since(start).Milliseconds())
+
+	s.cacheResult(ctx, fileHash, result)
+
 	return result, nil
 }
 
+// scanReaderViaTempFile stages r to a temporary file for clamscan, which
+// can only scan paths.
+func (s *ClamAVScanner) scanReaderViaTempFile(ctx context.Context, r io.Reader, name string) (*types.ScanResult, error) {
+	tmp, err := os.CreateTemp("", "argus-scan-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	var src io.Reader = r
+	if s.config.MaxFileSize > 0 {
+		src = io.LimitReader(r, s.config.MaxFileSize+1)
+	}
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("staging content: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("staging content: %w", err)
+	}
+
+	result, err := s.ScanFile(ctx, tmp.Name())
+	if err != nil {
+		return nil, err
+	}
+	result.FilePath = name
+
+	return result, nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
 // scanWithClamscan uses the clamscan binary to scan a file.
 func (s *ClamAVScanner) scanWithClamscan(ctx context.Context, path, fileHash string, fileSize int64) (*types.ScanResult, error) {
 	binary := s.config.Binary
@@ -133,13 +284,6 @@ func (s *ClamAVScanner) scanWithClamscan(ctx context.Context, path, fileHash str
 	return result, nil
 }
 
-// scanWithClamd uses the clamd daemon to scan a file.
-func (s *ClamAVScanner) scanWithClamd(ctx context.Context, path, fileHash string, fileSize int64) (*types.ScanResult, error) {
-	// TODO: Implement clamd scanning using baruwa-enterprise/clamd.
-	// For now, fall back to clamscan.
-	return s.scanWithClamscan(ctx, path, fileHash, fileSize)
-}
-
 // buildClamscanArgs builds the command-line arguments for clamscan.
 func (s *ClamAVScanner) buildClamscanArgs(path string) []string {
 	args := []string{}
@@ -167,6 +311,10 @@ func (s *ClamAVScanner) buildClamscanArgs(path string) []string {
 
 // Version returns the ClamAV engine version.
 func (s *ClamAVScanner) Version(ctx context.Context) (string, error) {
+	if s.Mode() == "clamd" {
+		return s.clamdVersion(ctx)
+	}
+
 	binary := s.config.Binary
 	if binary == "" {
 		binary = "clamscan"
@@ -190,6 +338,10 @@ func (s *ClamAVScanner) Version(ctx context.Context) (string, error) {
 
 // Ping checks if the scanner is available.
 func (s *ClamAVScanner) Ping(ctx context.Context) error {
+	if s.Mode() == "clamd" {
+		return s.clamdPing(ctx)
+	}
+
 	_, err := s.Version(ctx)
 	return err
 }
@@ -288,9 +440,30 @@ func hashFile(path string) (string, error) {
 	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
-// ScanDir scans a directory for malware.
+// ScanDir scans a directory for malware, scanning up to config.Workers
+// files at a time. Results are sorted by path, and files that fail to scan
+// are reported as error results rather than stopping the scan. If ctx is
+// cancelled, no further files are started and the results gathered so far
+// are returned with ctx.Err().
+//
+// In clamd mode a recursive scan is first handed to clamd as a single
+// MULTISCAN, bounded by ctx rather than config.Timeout. Files the daemon
+// cannot read, or the whole directory when it lives outside the daemon's
+// filesystem, are then streamed one at a time with INSTREAM.
 func (s *ClamAVScanner) ScanDir(ctx context.Context, path string, recursive bool) ([]*types.ScanResult, error) {
-	var results []*types.ScanResult
+	return s.ScanDirWithProgress(ctx, path, recursive, nil)
+}
+
+// ScanDirWithProgress is ScanDir that reports progress as files finish.
+// progress receives the number of files completed since its previous call
+// and the total number of files found; it is called from the scanning
+// goroutines, possibly concurrently. A nil progress is ignored.
+func (s *ClamAVScanner) ScanDirWithProgress(ctx context.Context, path string, recursive bool, progress func(scanned, total int)) ([]*types.ScanResult, error) {
+	if progress == nil {
+		progress = func(int, int) {}
+	}
+
+	var files []string
 
 	walkFn := func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -305,26 +478,82 @@ func (s *ClamAVScanner) ScanDir(ctx context.Context, path string, recursive bool
 			return nil
 		}
 
-		// Check context.
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+		if err := ctx.Err(); err != nil {
+			return err
 		}
 
-		// Scan the file.
-		result, err := s.ScanFile(ctx, filePath)
-		if err != nil {
-			result = types.NewErrorScanResult(filePath, err.Error())
-		}
-		results = append(results, result)
-
+		files = append(files, filePath)
 		return nil
 	}
 
 	if err := filepath.Walk(path, walkFn); err != nil {
-		return results, err
+		return nil, err
+	}
+	sort.Strings(files)
+
+	// Each worker writes only its own slots, so no locking is needed.
+	scanned := make([]*types.ScanResult, len(files))
+	if s.Mode() == "clamd" && s.config.SharedFilesystem && recursive && len(files) > 0 {
+		// On failure no slot is filled and every file falls back to INSTREAM.
+		_ = s.clamdMultiscan(ctx, path, files, scanned)
+	}
+
+	var pending []int
+	for i, result := range scanned {
+		if result == nil {
+			pending = append(pending, i)
+		}
+	}
+	if done := len(files) - len(pending); done > 0 {
+		progress(done, len(files))
+	}
+
+	workers := s.config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if ctx.Err() != nil {
+					continue
+				}
+				result, err := s.ScanFile(ctx, files[i])
+				if err != nil {
+					result = types.NewErrorScanResult(files[i], err.Error())
+				}
+				scanned[i] = result
+				progress(1, len(files))
+			}
+		}()
+	}
+
+feed:
+	for _, i := range pending {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	results := make([]*types.ScanResult, 0, len(files))
+	for _, result := range scanned {
+		if result != nil {
+			results = append(results, result)
+		}
 	}
 
-	return results, nil
+	return results, ctx.Err()
 }
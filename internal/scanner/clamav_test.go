@@ -5,13 +5,18 @@ package scanner
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/hikmaai-io/hikmaai-argus/internal/config"
+	"github.com/hikmaai-io/hikmaai-argus/internal/engine"
 	"github.com/hikmaai-io/hikmaai-argus/internal/types"
 )
 
@@ -307,6 +312,163 @@ func TestClamAVScanner_scanWithClamscan_BinaryNotFound(t *testing.T) {
 	}
 }
 
+// fakeClamscan writes a clamscan stand-in that reports files containing
+// "EICAR" as infected and everything else clean. Each invocation appends the
+// scanned path as a line to the returned log.
+func fakeClamscan(t *testing.T) (binary, invocations string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	invocations = filepath.Join(dir, "invocations.log")
+	binary = filepath.Join(dir, "clamscan")
+
+	script := "#!/bin/sh\n" +
+		"for last; do :; done\n" +
+		"echo \"$last\" >> " + invocations + "\n" +
+		"if grep -q EICAR \"$last\"; then echo \"$last: Eicar-Signature FOUND\"; exit 1; fi\n" +
+		"echo \"$last: OK\"\n" +
+		"echo 'Engine version: 1.0.0'\n"
+	if err := os.WriteFile(binary, []byte(script), 0o755); err != nil {
+		t.Fatalf("writing fake clamscan: %v", err)
+	}
+	return binary, invocations
+}
+
+func TestClamAVScanner_ScanFile_Cache(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		cacheTTL    time.Duration
+		wantCached  bool
+		wantInvokes int
+	}{
+		{name: "second scan served from cache", wantCached: true, wantInvokes: 1},
+		{name: "expired entry rescanned", cacheTTL: time.Nanosecond, wantCached: false, wantInvokes: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			binary, invocations := fakeClamscan(t)
+
+			cache, err := engine.NewScanCache(engine.StoreConfig{InMemory: true}, 0)
+			if err != nil {
+				t.Fatalf("NewScanCache() error: %v", err)
+			}
+			defer cache.Close()
+
+			scanner := NewClamAVScanner(&config.ClamAVConfig{
+				Mode:     "clamscan",
+				Binary:   binary,
+				Timeout:  10 * time.Second,
+				CacheTTL: tt.cacheTTL,
+			})
+			scanner.ScanCache = cache
+
+			// Two files with identical content share one cache entry.
+			dir := t.TempDir()
+			first := filepath.Join(dir, "first.txt")
+			second := filepath.Join(dir, "second.txt")
+			for _, path := range []string{first, second} {
+				if err := os.WriteFile(path, []byte("same content"), 0o644); err != nil {
+					t.Fatalf("writing %s: %v", path, err)
+				}
+			}
+
+			result, err := scanner.ScanFile(ctx, first)
+			if err != nil {
+				t.Fatalf("ScanFile(first) error: %v", err)
+			}
+			if result.Status != types.ScanStatusClean || result.Cached {
+				t.Fatalf("first scan = (%v, cached %v), want (clean, false)", result.Status, result.Cached)
+			}
+
+			result, err = scanner.ScanFile(ctx, second)
+			if err != nil {
+				t.Fatalf("ScanFile(second) error: %v", err)
+			}
+			if result.Cached != tt.wantCached {
+				t.Errorf("second scan Cached = %v, want %v", result.Cached, tt.wantCached)
+			}
+			if result.FilePath != second {
+				t.Errorf("FilePath = %q, want %q", result.FilePath, second)
+			}
+			if result.Status != types.ScanStatusClean {
+				t.Errorf("Status = %v, want %v", result.Status, types.ScanStatusClean)
+			}
+
+			log, err := os.ReadFile(invocations)
+			if err != nil {
+				t.Fatalf("reading invocation log: %v", err)
+			}
+			if got := strings.Count(string(log), "\n"); got != tt.wantInvokes {
+				t.Errorf("clamscan invoked %d times, want %d", got, tt.wantInvokes)
+			}
+		})
+	}
+}
+
+func TestClamAVScanner_ScanReader_Clamscan(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		content       string
+		wantStatus    types.ScanStatus
+		wantDetection string
+	}{
+		{name: "clean", content: "hello world", wantStatus: types.ScanStatusClean},
+		{name: "eicar", content: eicarStream, wantStatus: types.ScanStatusInfected, wantDetection: "Eicar-Signature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			binary, invocations := fakeClamscan(t)
+			scanner := NewClamAVScanner(&config.ClamAVConfig{
+				Mode:    "clamscan",
+				Binary:  binary,
+				Timeout: 10 * time.Second,
+			})
+
+			result, err := scanner.ScanReader(context.Background(), strings.NewReader(tt.content), "stdin")
+			if err != nil {
+				t.Fatalf("ScanReader() error: %v", err)
+			}
+			if result.Status != tt.wantStatus {
+				t.Fatalf("Status = %v, want %v (error: %s)", result.Status, tt.wantStatus, result.Error)
+			}
+			if result.Detection != tt.wantDetection {
+				t.Errorf("Detection = %q, want %q", result.Detection, tt.wantDetection)
+			}
+			if result.FilePath != "stdin" {
+				t.Errorf("FilePath = %q, want stdin", result.FilePath)
+			}
+			wantHash := sha256.Sum256([]byte(tt.content))
+			if result.FileHash != hex.EncodeToString(wantHash[:]) || result.FileSize != int64(len(tt.content)) {
+				t.Errorf("file info = (%q, %d), want hash of content and size %d", result.FileHash, result.FileSize, len(tt.content))
+			}
+
+			// clamscan saw a temp file, which must be gone afterwards.
+			log, err := os.ReadFile(invocations)
+			if err != nil {
+				t.Fatalf("reading invocation log: %v", err)
+			}
+			staged := strings.TrimSpace(string(log))
+			if staged == "" {
+				t.Fatal("clamscan was not invoked")
+			}
+			if _, err := os.Stat(staged); !os.IsNotExist(err) {
+				t.Errorf("temp file %s not removed (stat error %v)", staged, err)
+			}
+		})
+	}
+}
+
 // TestClamAVScanner_ScanFile_Integration tests actual scanning if clamscan is available.
 // Skip if clamscan is not installed.
 func TestClamAVScanner_ScanFile_Integration(t *testing.T) {
@@ -397,3 +559,164 @@ func TestClamAVScanner_ScanFile_EICAR(t *testing.T) {
 		t.Errorf("Detection = %q, should contain 'eicar'", result.Detection)
 	}
 }
+
+func TestClamAVScanner_ScanDirWithProgress(t *testing.T) {
+	t.Parallel()
+
+	binary, _ := fakeClamscan(t)
+	scanner := NewClamAVScanner(&config.ClamAVConfig{
+		Mode:    "clamscan",
+		Binary:  binary,
+		Timeout: 10 * time.Second,
+		Workers: 4,
+	})
+
+	dir := t.TempDir()
+	const numFiles = 12
+	for i := range numFiles {
+		path := filepath.Join(dir, string(rune('a'+i))+".txt")
+		if err := os.WriteFile(path, []byte("clean file"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var scanned, calls, badTotals atomic.Int32
+	results, err := scanner.ScanDirWithProgress(context.Background(), dir, true, func(n, total int) {
+		scanned.Add(int32(n))
+		calls.Add(1)
+		if total != numFiles {
+			badTotals.Add(1)
+		}
+	})
+	if err != nil {
+		t.Fatalf("ScanDirWithProgress() error = %v", err)
+	}
+
+	if len(results) != numFiles {
+		t.Fatalf("ScanDirWithProgress() returned %d results, want %d", len(results), numFiles)
+	}
+	if got := scanned.Load(); got != numFiles {
+		t.Errorf("progress reported %d files scanned, want %d", got, numFiles)
+	}
+	if got := calls.Load(); got != numFiles {
+		t.Errorf("progress called %d times, want %d", got, numFiles)
+	}
+	if got := badTotals.Load(); got != 0 {
+		t.Errorf("progress reported a wrong total %d times", got)
+	}
+}
+
+func TestClamAVScanner_ScanDir_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	binary, invocations := fakeClamscan(t)
+	scanner := NewClamAVScanner(&config.ClamAVConfig{
+		Mode:    "clamscan",
+		Binary:  binary,
+		Timeout: 10 * time.Second,
+		Workers: 4,
+	})
+
+	dir := t.TempDir()
+	const numFiles = 25
+	var want []string
+	for i := range numFiles {
+		sub := filepath.Join(dir, string(rune('a'+i%3)))
+		if err := os.MkdirAll(sub, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		content := "clean file " + string(rune('a'+i))
+		if i%5 == 0 {
+			content = "EICAR " + content
+		}
+		path := filepath.Join(sub, string(rune('a'+i))+".txt")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, path)
+	}
+	sort.Strings(want)
+
+	results, err := scanner.ScanDir(context.Background(), dir, true)
+	if err != nil {
+		t.Fatalf("ScanDir() error = %v", err)
+	}
+
+	if len(results) != numFiles {
+		t.Fatalf("ScanDir() returned %d results, want %d", len(results), numFiles)
+	}
+	var infected int
+	for i, result := range results {
+		if result.FilePath != want[i] {
+			t.Errorf("results[%d].FilePath = %q, want %q", i, result.FilePath, want[i])
+		}
+		if result.Status == types.ScanStatusInfected {
+			infected++
+		}
+	}
+	if infected != numFiles/5 {
+		t.Errorf("infected = %d, want %d", infected, numFiles/5)
+	}
+
+	log, err := os.ReadFile(invocations)
+	if err != nil {
+		t.Fatalf("reading invocation log: %v", err)
+	}
+	if got := strings.Count(string(log), "\n"); got != numFiles {
+		t.Errorf("clamscan invoked %d times, want %d", got, numFiles)
+	}
+}
+
+func TestClamAVScanner_ScanDir_Cancel(t *testing.T) {
+	t.Parallel()
+
+	// A slow clamscan stand-in so the scan is still running when cancelled.
+	bin := t.TempDir()
+	invocations := filepath.Join(bin, "invocations.log")
+	binary := filepath.Join(bin, "clamscan")
+	script := "#!/bin/sh\n" +
+		"for last; do :; done\n" +
+		"echo \"$last\" >> " + invocations + "\n" +
+		"sleep 0.2\n" +
+		"echo \"$last: OK\"\n"
+	if err := os.WriteFile(binary, []byte(script), 0o755); err != nil {
+		t.Fatalf("writing fake clamscan: %v", err)
+	}
+
+	scanner := NewClamAVScanner(&config.ClamAVConfig{
+		Mode:    "clamscan",
+		Binary:  binary,
+		Timeout: 10 * time.Second,
+		Workers: 2,
+	})
+
+	dir := t.TempDir()
+	const numFiles = 40
+	for i := range numFiles {
+		path := filepath.Join(dir, "file"+string(rune('A'+i))+".txt")
+		if err := os.WriteFile(path, []byte("clean"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	results, err := scanner.ScanDir(ctx, dir, false)
+	if err != context.DeadlineExceeded {
+		t.Errorf("ScanDir() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if len(results) >= numFiles {
+		t.Errorf("ScanDir() returned %d results, want fewer than %d after cancellation", len(results), numFiles)
+	}
+
+	// In-flight scans finish, but no new ones start after cancellation.
+	time.Sleep(300 * time.Millisecond)
+	log, err := os.ReadFile(invocations)
+	if err != nil {
+		t.Fatalf("reading invocation log: %v", err)
+	}
+	if got := strings.Count(string(log), "\n"); got >= numFiles/2 {
+		t.Errorf("clamscan invoked %d times, want scanning to stop early", got)
+	}
+}
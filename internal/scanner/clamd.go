@@ -0,0 +1,353 @@
+// ABOUTME: clamd daemon client speaking the INSTREAM, MULTISCAN, PING, and VERSION commands
+// ABOUTME: Streams files over unix or tcp sockets and parses clamd replies
+
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/types"
+)
+
+// clamdChunkSize is the size of each INSTREAM chunk. It must stay below
+// clamd's StreamMaxLength (25MB by default).
+const clamdChunkSize = 64 * 1024
+
+// clamdDialTimeout bounds connection setup when the context has no deadline.
+const clamdDialTimeout = 10 * time.Second
+
+// parseClamdAddress splits a clamd address into a network and address for
+// net.Dial. Accepted forms are "unix:///path/to/clamd.sock", "tcp://host:port",
+// a bare absolute socket path, or a bare host:port.
+func parseClamdAddress(address string) (network, addr string, err error) {
+	switch {
+	case address == "":
+		return "", "", errors.New("clamd address is required")
+	case strings.HasPrefix(address, "unix://"):
+		addr = strings.TrimPrefix(address, "unix://")
+		network = "unix"
+	case strings.HasPrefix(address, "tcp://"):
+		addr = strings.TrimPrefix(address, "tcp://")
+		network = "tcp"
+	case strings.HasPrefix(address, "/"):
+		addr = address
+		network = "unix"
+	default:
+		addr = address
+		network = "tcp"
+	}
+
+	if addr == "" {
+		return "", "", fmt.Errorf("invalid clamd address %q", address)
+	}
+
+	return network, addr, nil
+}
+
+// clamdCommand dials clamd, sends a null-terminated command, lets send write
+// any payload, and returns clamd's reply without its terminator.
+func (s *ClamAVScanner) clamdCommand(ctx context.Context, command string, send func(io.Writer) error) (string, error) {
+	if s.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.Timeout)
+		defer cancel()
+	}
+
+	replies, err := s.clamdExchange(ctx, command, send, false)
+	if err != nil {
+		return "", err
+	}
+	return replies[0], nil
+}
+
+// clamdExchange dials clamd, sends a null-terminated command, and lets send
+// write any payload. It returns the first reply, or with multi set every
+// reply until clamd closes the connection, each without its terminator.
+func (s *ClamAVScanner) clamdExchange(ctx context.Context, command string, send func(io.Writer) error, multi bool) ([]string, error) {
+	network, addr, err := parseClamdAddress(s.config.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := net.Dialer{Timeout: clamdDialTimeout}
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("clamd unreachable at %s: %w", s.config.Address, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	// Unblock reads and writes if the context is cancelled mid-command.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	w := bufio.NewWriterSize(conn, clamdChunkSize+4)
+	if _, err := w.WriteString("z" + command + "\x00"); err != nil {
+		return nil, clamdIOError(ctx, err)
+	}
+	if send != nil {
+		if err := send(w); err != nil {
+			return nil, clamdIOError(ctx, err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return nil, clamdIOError(ctx, err)
+	}
+
+	r := bufio.NewReader(conn)
+	var replies []string
+	for {
+		reply, err := r.ReadString('\x00')
+		if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+			if multi && errors.Is(err, io.EOF) && len(replies) > 0 {
+				return replies, nil
+			}
+			return nil, clamdIOError(ctx, err)
+		}
+
+		replies = append(replies, strings.TrimSpace(strings.TrimSuffix(reply, "\x00")))
+		if !multi || err != nil {
+			return replies, nil
+		}
+	}
+}
+
+// clamdIOError reports a context error in preference to the I/O error it
+// caused, so timeouts and cancellations are recognisable.
+func clamdIOError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("clamd scan timeout: %w", ctxErr)
+	}
+	return fmt.Errorf("clamd communication failed: %w", err)
+}
+
+// writeInstream streams r to clamd using the INSTREAM chunk protocol: each
+// chunk is prefixed by its length as a 4-byte big-endian integer, and a
+// zero-length chunk terminates the stream.
+func writeInstream(w io.Writer, r io.Reader) error {
+	buf := make([]byte, clamdChunkSize)
+	var size [4]byte
+
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			if _, werr := w.Write(size[:]); werr != nil {
+				return werr
+			}
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading file: %w", err)
+		}
+	}
+
+	binary.BigEndian.PutUint32(size[:], 0)
+	_, err := w.Write(size[:])
+	return err
+}
+
+// scanWithClamd streams a file to the clamd daemon with INSTREAM.
+func (s *ClamAVScanner) scanWithClamd(ctx context.Context, path, fileHash string, fileSize int64) (*types.ScanResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+
+	result, err := s.scanStreamWithClamd(ctx, path, file)
+	if err != nil {
+		return nil, err
+	}
+
+	result.FileHash = fileHash
+	result.FileSize = fileSize
+
+	return result, nil
+}
+
+// scanStreamWithClamd streams r to clamd with INSTREAM and reports the
+// result under name. File hash and size are left for the caller to fill in.
+func (s *ClamAVScanner) scanStreamWithClamd(ctx context.Context, name string, r io.Reader) (*types.ScanResult, error) {
+	reply, err := s.clamdCommand(ctx, "INSTREAM", func(w io.Writer) error {
+		return writeInstream(w, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseClamdResponse(name, reply)
+}
+
+// clamdMultiscan scans root with MULTISCAN, letting clamd walk the directory
+// with its own thread pool, and fills results for files, the regular files
+// found under root. clamd only reports infected files and errors, so every
+// other file is recorded as clean. That verdict is only implied, so it is
+// never cached, and callers use MULTISCAN only when clamd is configured to
+// share argus's filesystem. Slots are left nil for files clamd could not
+// read or that exceed MaxFileSize, so the caller can scan them one at a
+// time. If clamd cannot see root at all, typically because it runs in a
+// different filesystem namespace, or reports a path it cannot be matched
+// to, an error is returned and no slot is filled.
+func (s *ClamAVScanner) clamdMultiscan(ctx context.Context, root string, files []string, results []*types.ScanResult) error {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+
+	// clamd reports the absolute paths it scanned.
+	index := make(map[string]int, len(files))
+	for i, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		index[abs] = i
+	}
+
+	replies, err := s.clamdExchange(ctx, "MULTISCAN "+absRoot, nil, true)
+	if err != nil {
+		return err
+	}
+
+	reported := make(map[int]*types.ScanResult)
+	for _, reply := range replies {
+		if reply == "" {
+			continue
+		}
+
+		// Error messages may themselves contain ": ", so the path ends at
+		// the first separator for errors and at the last one otherwise.
+		sep := strings.LastIndex(reply, ": ")
+		if strings.HasSuffix(reply, "ERROR") {
+			sep = strings.Index(reply, ": ")
+		}
+		if sep < 0 {
+			return fmt.Errorf("unexpected clamd response: %q", reply)
+		}
+		path, status := reply[:sep], reply[sep+2:]
+
+		i, ok := index[path]
+		if !ok {
+			if status == "OK" {
+				continue // clamd reports "<root>: OK" when nothing was found.
+			}
+			return fmt.Errorf("clamd multiscan of %s: %s", absRoot, reply)
+		}
+
+		result, err := parseClamdResponse(files[i], status)
+		if err != nil {
+			return err
+		}
+		reported[i] = result
+	}
+
+	for i, file := range files {
+		result, ok := reported[i]
+		if ok && result.Status == types.ScanStatusError {
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil || (s.config.MaxFileSize > 0 && info.Size() > s.config.MaxFileSize) {
+			continue
+		}
+		fileHash, err := hashFile(file)
+		if err != nil {
+			continue
+		}
+
+		if !ok {
+			result, _ = parseClamdResponse(file, "OK")
+		}
+		result.FileHash = fileHash
+		result.FileSize = info.Size()
+
+		if ok {
+			s.cacheResult(ctx, fileHash, result)
+		}
+		results[i] = result
+	}
+
+	return nil
+}
+
+// parseClamdResponse parses an INSTREAM reply such as "stream: OK",
+// "stream: Eicar-Test-Signature FOUND", or
+// "INSTREAM size limit exceeded. ERROR" into a scan result for filePath.
+func parseClamdResponse(filePath, response string) (*types.ScanResult, error) {
+	if response == "" {
+		return nil, fmt.Errorf("empty clamd response")
+	}
+
+	result := &types.ScanResult{
+		FilePath:  filePath,
+		Engine:    "clamav",
+		ScannedAt: time.Now().UTC(),
+	}
+
+	resultPart := response
+	if _, after, ok := strings.Cut(response, ": "); ok {
+		resultPart = strings.TrimSpace(after)
+	}
+
+	switch {
+	case strings.HasSuffix(resultPart, " FOUND"):
+		detection := strings.TrimSuffix(resultPart, " FOUND")
+		result.Status = types.ScanStatusInfected
+		result.Detection = detection
+		result.ThreatType = types.ThreatTypeFromDetection(detection)
+		result.Severity = types.SeverityFromDetection(detection)
+	case strings.HasSuffix(resultPart, "ERROR"):
+		result.Status = types.ScanStatusError
+		result.Error = resultPart
+	case resultPart == "OK":
+		result.Status = types.ScanStatusClean
+	default:
+		return nil, fmt.Errorf("unexpected clamd response: %q", response)
+	}
+
+	return result, nil
+}
+
+// clamdVersion queries the clamd engine version.
+func (s *ClamAVScanner) clamdVersion(ctx context.Context) (string, error) {
+	reply, err := s.clamdCommand(ctx, "VERSION", nil)
+	if err != nil {
+		return "", fmt.Errorf("getting version: %w", err)
+	}
+
+	// Reply format: "ClamAV 1.2.0/27100/Mon Oct 16 08:00:00 2026"
+	version, _, _ := strings.Cut(reply, "/")
+	return strings.TrimPrefix(version, "ClamAV "), nil
+}
+
+// clamdPing checks that clamd answers PING with PONG.
+func (s *ClamAVScanner) clamdPing(ctx context.Context) error {
+	reply, err := s.clamdCommand(ctx, "PING", nil)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("unexpected clamd ping reply: %q", reply)
+	}
+	return nil
+}
@@ -0,0 +1,618 @@
+// ABOUTME: Tests for the clamd INSTREAM client against a fake clamd server
+// ABOUTME: Covers address parsing, chunked streaming, MULTISCAN, reply parsing, and errors
+
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/config"
+	"github.com/hikmaai-io/hikmaai-argus/internal/engine"
+	"github.com/hikmaai-io/hikmaai-argus/internal/types"
+)
+
+// eicarStream is the EICAR antivirus test string.
+const eicarStream = "X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*"
+
+// fakeClamd is a minimal clamd that speaks the z-prefixed PING, VERSION,
+// INSTREAM, and MULTISCAN commands. Content containing "EICAR" is reported
+// as infected and streams larger than maxStream trigger the size limit error.
+// MULTISCAN reports files named "denied*" as unreadable, and with
+// foreignFS set it cannot see the requested directory at all.
+type fakeClamd struct {
+	listener   net.Listener
+	maxStream  int
+	received   chan []byte
+	foreignFS  bool
+	multiscans atomic.Int32
+}
+
+func newFakeClamd(t *testing.T, network, address string) *fakeClamd {
+	t.Helper()
+
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	f := &fakeClamd{
+		listener:  ln,
+		maxStream: 1 << 20,
+		received:  make(chan []byte, 16),
+	}
+	go f.serve()
+
+	return f
+}
+
+func (f *fakeClamd) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeClamd) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	command, err := r.ReadString('\x00')
+	if err != nil {
+		return
+	}
+
+	command = strings.TrimSuffix(command, "\x00")
+	if root, ok := strings.CutPrefix(command, "zMULTISCAN "); ok {
+		f.multiscan(conn, root)
+		return
+	}
+
+	switch command {
+	case "zPING":
+		conn.Write([]byte("PONG\x00"))
+	case "zVERSION":
+		conn.Write([]byte("ClamAV 1.2.0/27100/Fri Oct 16 08:00:00 2026\x00"))
+	case "zINSTREAM":
+		var data []byte
+		for {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+				return
+			}
+			if size == 0 {
+				break
+			}
+			if len(data)+int(size) > f.maxStream {
+				conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+				return
+			}
+			chunk := make([]byte, size)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				return
+			}
+			data = append(data, chunk...)
+		}
+		f.received <- data
+
+		if bytes.Contains(data, []byte("EICAR")) {
+			conn.Write([]byte("stream: Win.Test.EICAR_HDB-1 FOUND\x00"))
+			return
+		}
+		conn.Write([]byte("stream: OK\x00"))
+	default:
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+	}
+}
+
+func (f *fakeClamd) multiscan(conn net.Conn, root string) {
+	f.multiscans.Add(1)
+
+	if f.foreignFS {
+		conn.Write([]byte(root + ": lstat() failed: No such file or directory. ERROR\x00"))
+		return
+	}
+
+	found := false
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), "denied") {
+			conn.Write([]byte(path + ": Access denied. ERROR\x00"))
+			found = true
+			return nil
+		}
+		if data, err := os.ReadFile(path); err == nil && bytes.Contains(data, []byte("EICAR")) {
+			conn.Write([]byte(path + ": Win.Test.EICAR_HDB-1 FOUND\x00"))
+			found = true
+		}
+		return nil
+	})
+	if !found {
+		conn.Write([]byte(root + ": OK\x00"))
+	}
+}
+
+func TestParseClamdAddress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		address     string
+		wantNetwork string
+		wantAddr    string
+		wantErr     bool
+	}{
+		{address: "unix:///var/run/clamav/clamd.ctl", wantNetwork: "unix", wantAddr: "/var/run/clamav/clamd.ctl"},
+		{address: "tcp://127.0.0.1:3310", wantNetwork: "tcp", wantAddr: "127.0.0.1:3310"},
+		{address: "/tmp/clamd.sock", wantNetwork: "unix", wantAddr: "/tmp/clamd.sock"},
+		{address: "clamav:3310", wantNetwork: "tcp", wantAddr: "clamav:3310"},
+		{address: "", wantErr: true},
+		{address: "tcp://", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			t.Parallel()
+
+			network, addr, err := parseClamdAddress(tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseClamdAddress(%q) error = %v, wantErr %v", tt.address, err, tt.wantErr)
+			}
+			if network != tt.wantNetwork || addr != tt.wantAddr {
+				t.Errorf("parseClamdAddress(%q) = (%q, %q), want (%q, %q)",
+					tt.address, network, addr, tt.wantNetwork, tt.wantAddr)
+			}
+		})
+	}
+}
+
+func TestParseClamdResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		response      string
+		wantStatus    types.ScanStatus
+		wantDetection string
+		wantErr       bool
+	}{
+		{name: "clean", response: "stream: OK", wantStatus: types.ScanStatusClean},
+		{name: "infected", response: "stream: Win.Test.EICAR_HDB-1 FOUND", wantStatus: types.ScanStatusInfected, wantDetection: "Win.Test.EICAR_HDB-1"},
+		{name: "size limit", response: "INSTREAM size limit exceeded. ERROR", wantStatus: types.ScanStatusError},
+		{name: "empty", response: "", wantErr: true},
+		{name: "unexpected", response: "UNKNOWN COMMAND", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := parseClamdResponse("/tmp/file.bin", tt.response)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseClamdResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %v, want %v", result.Status, tt.wantStatus)
+			}
+			if result.Detection != tt.wantDetection {
+				t.Errorf("Detection = %q, want %q", result.Detection, tt.wantDetection)
+			}
+			if result.FilePath != "/tmp/file.bin" {
+				t.Errorf("FilePath = %q, want /tmp/file.bin", result.FilePath)
+			}
+		})
+	}
+}
+
+func TestClamAVScanner_ScanFile_Clamd(t *testing.T) {
+	t.Parallel()
+
+	clamd := newFakeClamd(t, "tcp", "127.0.0.1:0")
+	scanner := NewClamAVScanner(&config.ClamAVConfig{
+		Mode:    "clamd",
+		Address: "tcp://" + clamd.listener.Addr().String(),
+		Timeout: 5 * time.Second,
+	})
+
+	dir := t.TempDir()
+
+	t.Run("clean file spanning several chunks", func(t *testing.T) {
+		content := bytes.Repeat([]byte("a"), clamdChunkSize*2+123)
+		path := filepath.Join(dir, "clean.bin")
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := scanner.ScanFile(context.Background(), path)
+		if err != nil {
+			t.Fatalf("ScanFile() error = %v", err)
+		}
+		if result.Status != types.ScanStatusClean {
+			t.Errorf("Status = %v, want clean (error: %s)", result.Status, result.Error)
+		}
+		if result.FilePath != path {
+			t.Errorf("FilePath = %q, want %q", result.FilePath, path)
+		}
+		if result.FileHash == "" || result.FileSize != int64(len(content)) {
+			t.Errorf("file info = (%q, %d), want hash and size %d", result.FileHash, result.FileSize, len(content))
+		}
+		if got := <-clamd.received; !bytes.Equal(got, content) {
+			t.Errorf("clamd received %d bytes, want %d", len(got), len(content))
+		}
+	})
+
+	t.Run("infected file", func(t *testing.T) {
+		path := filepath.Join(dir, "eicar.txt")
+		if err := os.WriteFile(path, []byte("X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := scanner.ScanFile(context.Background(), path)
+		if err != nil {
+			t.Fatalf("ScanFile() error = %v", err)
+		}
+		<-clamd.received
+		if result.Status != types.ScanStatusInfected {
+			t.Fatalf("Status = %v, want infected (error: %s)", result.Status, result.Error)
+		}
+		if result.Detection != "Win.Test.EICAR_HDB-1" {
+			t.Errorf("Detection = %q, want Win.Test.EICAR_HDB-1", result.Detection)
+		}
+	})
+}
+
+func TestClamAVScanner_ScanReader_Clamd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		content       []byte
+		maxFileSize   int64
+		wantStatus    types.ScanStatus
+		wantDetection string
+	}{
+		{
+			name:       "clean stream spanning several chunks",
+			content:    bytes.Repeat([]byte("a"), clamdChunkSize*2+123),
+			wantStatus: types.ScanStatusClean,
+		},
+		{
+			name:          "eicar",
+			content:       []byte(eicarStream),
+			wantStatus:    types.ScanStatusInfected,
+			wantDetection: "Win.Test.EICAR_HDB-1",
+		},
+		{
+			name:        "larger than max file size",
+			content:     bytes.Repeat([]byte("a"), 100),
+			maxFileSize: 10,
+			wantStatus:  types.ScanStatusError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clamd := newFakeClamd(t, "tcp", "127.0.0.1:0")
+			scanner := NewClamAVScanner(&config.ClamAVConfig{
+				Mode:        "clamd",
+				Address:     "tcp://" + clamd.listener.Addr().String(),
+				Timeout:     5 * time.Second,
+				MaxFileSize: tt.maxFileSize,
+			})
+
+			// Hide bytes.Reader's other methods so only Read is available.
+			r := struct{ io.Reader }{bytes.NewReader(tt.content)}
+			result, err := scanner.ScanReader(context.Background(), r, "upload.bin")
+			if err != nil {
+				t.Fatalf("ScanReader() error = %v", err)
+			}
+			if result.Status != tt.wantStatus {
+				t.Fatalf("Status = %v, want %v (error: %s)", result.Status, tt.wantStatus, result.Error)
+			}
+			if result.FilePath != "upload.bin" {
+				t.Errorf("FilePath = %q, want upload.bin", result.FilePath)
+			}
+			if tt.wantStatus == types.ScanStatusError {
+				return
+			}
+
+			if result.Detection != tt.wantDetection {
+				t.Errorf("Detection = %q, want %q", result.Detection, tt.wantDetection)
+			}
+			wantHash := sha256.Sum256(tt.content)
+			if result.FileHash != hex.EncodeToString(wantHash[:]) || result.FileSize != int64(len(tt.content)) {
+				t.Errorf("file info = (%q, %d), want hash of content and size %d", result.FileHash, result.FileSize, len(tt.content))
+			}
+			if got := <-clamd.received; !bytes.Equal(got, tt.content) {
+				t.Errorf("clamd received %d bytes, want %d", len(got), len(tt.content))
+			}
+		})
+	}
+}
+
+func TestClamAVScanner_ScanFile_ClamdSizeLimit(t *testing.T) {
+	t.Parallel()
+
+	clamd := newFakeClamd(t, "tcp", "127.0.0.1:0")
+	clamd.maxStream = 1024
+
+	scanner := NewClamAVScanner(&config.ClamAVConfig{
+		Mode:    "clamd",
+		Address: clamd.listener.Addr().String(),
+		Timeout: 5 * time.Second,
+	})
+
+	path := filepath.Join(t.TempDir(), "big.bin")
+	if err := os.WriteFile(path, bytes.Repeat([]byte("b"), 4096), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := scanner.ScanFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("ScanFile() error = %v", err)
+	}
+	if result.Status != types.ScanStatusError {
+		t.Errorf("Status = %v, want error", result.Status)
+	}
+}
+
+func TestClamAVScanner_ScanFile_ClamdUnixSocket(t *testing.T) {
+	t.Parallel()
+
+	// Unix socket paths are length-limited, so avoid the long test temp dir.
+	dir, err := os.MkdirTemp("", "clamd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "clamd.sock")
+	clamd := newFakeClamd(t, "unix", socket)
+
+	scanner := NewClamAVScanner(&config.ClamAVConfig{
+		Mode:    "clamd",
+		Address: "unix://" + socket,
+	})
+
+	path := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := scanner.ScanFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("ScanFile() error = %v", err)
+	}
+	<-clamd.received
+	if result.Status != types.ScanStatusClean {
+		t.Errorf("Status = %v, want clean (error: %s)", result.Status, result.Error)
+	}
+}
+
+func TestClamAVScanner_ScanFile_ClamdUnreachable(t *testing.T) {
+	t.Parallel()
+
+	// Grab a free port and close it so nothing is listening.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := "tcp://" + ln.Addr().String()
+	ln.Close()
+
+	scanner := NewClamAVScanner(&config.ClamAVConfig{
+		Mode:    "clamd",
+		Address: address,
+	})
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := scanner.ScanFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("ScanFile() error = %v", err)
+	}
+	if result.Status != types.ScanStatusError {
+		t.Fatalf("Status = %v, want error", result.Status)
+	}
+	if !strings.Contains(result.Error, "clamd unreachable") {
+		t.Errorf("Error = %q, want clamd unreachable", result.Error)
+	}
+
+	if err := scanner.Ping(context.Background()); err == nil {
+		t.Error("Ping() expected error for unreachable clamd")
+	}
+}
+
+func TestClamAVScanner_ClamdPingVersion(t *testing.T) {
+	t.Parallel()
+
+	clamd := newFakeClamd(t, "tcp", "127.0.0.1:0")
+	scanner := NewClamAVScanner(&config.ClamAVConfig{
+		Mode:    "clamd",
+		Address: "tcp://" + clamd.listener.Addr().String(),
+	})
+
+	if err := scanner.Ping(context.Background()); err != nil {
+		t.Errorf("Ping() error = %v", err)
+	}
+
+	version, err := scanner.Version(context.Background())
+	if err != nil {
+		t.Fatalf("Version() error = %v", err)
+	}
+	if version != "1.2.0" {
+		t.Errorf("Version() = %q, want 1.2.0", version)
+	}
+}
+
+func TestClamAVScanner_ScanDir_ClamdMultiscan(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		sharedFS     bool
+		foreignFS    bool
+		recursive    bool
+		wantStreamed int
+	}{
+		{name: "multiscan with unreadable file streamed", sharedFS: true, recursive: true, wantStreamed: 1},
+		{name: "daemon cannot see directory", sharedFS: true, foreignFS: true, recursive: true, wantStreamed: 5},
+		{name: "non-recursive scan streams each file", sharedFS: true, recursive: false, wantStreamed: 3},
+		{name: "no multiscan without shared filesystem", recursive: true, wantStreamed: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clamd := newFakeClamd(t, "tcp", "127.0.0.1:0")
+			clamd.foreignFS = tt.foreignFS
+			scanner := NewClamAVScanner(&config.ClamAVConfig{
+				Mode:             "clamd",
+				Address:          "tcp://" + clamd.listener.Addr().String(),
+				SharedFilesystem: tt.sharedFS,
+				Timeout:          5 * time.Second,
+				Workers:          2,
+			})
+
+			dir := t.TempDir()
+			if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			files := map[string]string{
+				"a.txt":         "hello",
+				"b.txt":         eicarStream,
+				"denied.txt":    "secret",
+				"sub/c.txt":     "world",
+				"sub/eicar.com": eicarStream,
+			}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var progressed atomic.Int32
+			results, err := scanner.ScanDirWithProgress(context.Background(), dir, tt.recursive, func(n, total int) {
+				progressed.Add(int32(n))
+			})
+			if err != nil {
+				t.Fatalf("ScanDir() error = %v", err)
+			}
+
+			want := []string{"a.txt", "b.txt", "denied.txt"}
+			if tt.recursive {
+				want = append(want, "sub/c.txt", "sub/eicar.com")
+			}
+			if len(results) != len(want) {
+				t.Fatalf("ScanDir() returned %d results, want %d", len(results), len(want))
+			}
+			// Files settled by MULTISCAN and by INSTREAM both count.
+			if got := int(progressed.Load()); got != len(want) {
+				t.Errorf("progress reported %d files scanned, want %d", got, len(want))
+			}
+			for i, result := range results {
+				name := want[i]
+				if result.FilePath != filepath.Join(dir, name) {
+					t.Errorf("results[%d].FilePath = %q, want %q", i, result.FilePath, filepath.Join(dir, name))
+				}
+
+				wantStatus := types.ScanStatusClean
+				if strings.Contains(files[name], "EICAR") {
+					wantStatus = types.ScanStatusInfected
+				}
+				if result.Status != wantStatus {
+					t.Errorf("%s: Status = %v, want %v (error: %s)", name, result.Status, wantStatus, result.Error)
+				}
+				wantHash := sha256.Sum256([]byte(files[name]))
+				if result.FileHash != hex.EncodeToString(wantHash[:]) || result.FileSize != int64(len(files[name])) {
+					t.Errorf("%s: file info = (%q, %d), want hash of content and size %d", name, result.FileHash, result.FileSize, len(files[name]))
+				}
+			}
+
+			wantMultiscans := int32(0)
+			if tt.sharedFS && tt.recursive {
+				wantMultiscans = 1
+			}
+			if got := clamd.multiscans.Load(); got != wantMultiscans {
+				t.Errorf("MULTISCAN commands = %d, want %d", got, wantMultiscans)
+			}
+			if got := len(clamd.received); got != tt.wantStreamed {
+				t.Errorf("INSTREAM scans = %d, want %d", got, tt.wantStreamed)
+			}
+		})
+	}
+}
+
+func TestClamAVScanner_ClamdMultiscan_CachesReportedOnly(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clamd := newFakeClamd(t, "tcp", "127.0.0.1:0")
+	scanner := NewClamAVScanner(&config.ClamAVConfig{
+		Mode:             "clamd",
+		Address:          "tcp://" + clamd.listener.Addr().String(),
+		SharedFilesystem: true,
+		Timeout:          5 * time.Second,
+	})
+
+	cache, err := engine.NewScanCache(engine.StoreConfig{InMemory: true}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewScanCache() error = %v", err)
+	}
+	defer cache.Close()
+	scanner.ScanCache = cache
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"clean.txt": "hello",
+		"eicar.com": eicarStream,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := scanner.ScanDir(ctx, dir, true); err != nil {
+		t.Fatalf("ScanDir() error = %v", err)
+	}
+
+	for name, content := range files {
+		hash := sha256.Sum256([]byte(content))
+		_, found, err := cache.Get(ctx, hex.EncodeToString(hash[:]))
+		if err != nil {
+			t.Fatalf("cache.Get(%s) error = %v", name, err)
+		}
+		// Only verdicts clamd actually reported are cached.
+		if want := name == "eicar.com"; found != want {
+			t.Errorf("%s cached = %v, want %v", name, found, want)
+		}
+	}
+}
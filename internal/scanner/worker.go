@@ -1,5 +1,5 @@
 // ABOUTME: Background scan worker for processing async scan jobs
-// ABOUTME: Pulls jobs from queue, executes scans, caches results, persists malware
+// ABOUTME: Pulls jobs from a priority queue, executes scans, caches results, persists malware
 
 package scanner
 
@@ -7,8 +7,11 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/hikmaai-io/hikmaai-argus/internal/engine"
+	"github.com/hikmaai-io/hikmaai-argus/internal/observability"
 	"github.com/hikmaai-io/hikmaai-argus/internal/types"
 )
 
@@ -28,23 +31,82 @@ type WorkerConfig struct {
 
 	// Concurrency is the number of concurrent workers.
 	Concurrency int
+
+	// AgingInterval is how long a queued job waits to gain one priority
+	// level, so low-priority jobs are not starved by a steady stream of
+	// high-priority ones. Defaults to DefaultAgingInterval.
+	AgingInterval time.Duration
+
+	// Metrics records scan outcomes and durations (optional).
+	Metrics *observability.Metrics
+}
+
+// Priority orders queued scan jobs; higher values are processed first.
+type Priority int
+
+const (
+	// PriorityLow is for bulk and background scans.
+	PriorityLow Priority = iota
+	// PriorityNormal is the priority used by Submit.
+	PriorityNormal
+	// PriorityHigh is for interactive scans a user is waiting on.
+	PriorityHigh
+)
+
+const (
+	// DefaultAgingInterval is the default WorkerConfig.AgingInterval.
+	DefaultAgingInterval = 30 * time.Second
+
+	// maxQueuedJobs caps the number of jobs waiting to be processed.
+	maxQueuedJobs = 100
+
+	// scanTimeSmoothing is the weight of each new scan in the moving
+	// average scan time reported by Stats.
+	scanTimeSmoothing = 0.2
+)
+
+// WorkerStats summarizes the jobs a worker has processed since it was created.
+type WorkerStats struct {
+	// Processed counts finished jobs, including cache hits and failures.
+	Processed int64 `json:"processed"`
+
+	// Errors counts jobs that failed or whose scan reported an error.
+	Errors int64 `json:"errors"`
+
+	// Infected counts jobs whose result was infected.
+	Infected int64 `json:"infected"`
+
+	// AvgScanTime is an exponential moving average of scan durations.
+	// Cache hits are not scans and do not affect it.
+	AvgScanTime time.Duration `json:"avg_scan_time"`
 }
 
 // Worker processes scan jobs asynchronously.
 type Worker struct {
 	config WorkerConfig
 
-	// Job queue for pending scans.
-	jobQueue chan *scanJob
+	// Pending scans, guarded by mu. Each queued job has one token in ready,
+	// which idle workers wait on.
+	mu       sync.Mutex
+	pending  []*scanJob
+	ready    chan struct{}
 	wg       sync.WaitGroup
 	stopOnce sync.Once
 	stopCh   chan struct{}
+
+	// Processing counters reported by Stats.
+	processed   atomic.Int64
+	errors      atomic.Int64
+	infected    atomic.Int64
+	avgScanTime atomic.Int64
 }
 
 // scanJob represents a job with its file path.
 type scanJob struct {
 	jobID    string
 	filePath string
+	priority Priority
+	queuedAt time.Time
 }
 
 // NewWorker creates a new scan worker.
@@ -52,11 +114,14 @@ func NewWorker(cfg WorkerConfig) *Worker {
 	if cfg.Concurrency <= 0 {
 		cfg.Concurrency = 2
 	}
+	if cfg.AgingInterval <= 0 {
+		cfg.AgingInterval = DefaultAgingInterval
+	}
 
 	return &Worker{
-		config:   cfg,
-		jobQueue: make(chan *scanJob, 100),
-		stopCh:   make(chan struct{}),
+		config: cfg,
+		ready:  make(chan struct{}, maxQueuedJobs),
+		stopCh: make(chan struct{}),
 	}
 }
 
@@ -72,21 +137,71 @@ func (w *Worker) Start(ctx context.Context) {
 func (w *Worker) Stop() {
 	w.stopOnce.Do(func() {
 		close(w.stopCh)
-		close(w.jobQueue)
 	})
 	w.wg.Wait()
 }
 
-// Submit adds a job to the queue for processing.
+// Submit adds a job to the queue with PriorityNormal.
 func (w *Worker) Submit(jobID, filePath string) error {
+	return w.SubmitWithPriority(jobID, filePath, PriorityNormal)
+}
+
+// SubmitWithPriority adds a job to the queue. Higher-priority jobs are
+// processed first; jobs of equal priority run in submission order.
+func (w *Worker) SubmitWithPriority(jobID, filePath string, priority Priority) error {
 	select {
-	case w.jobQueue <- &scanJob{jobID: jobID, filePath: filePath}:
-		return nil
 	case <-w.stopCh:
 		return fmt.Errorf("worker stopped")
 	default:
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) >= maxQueuedJobs {
 		return fmt.Errorf("job queue full")
 	}
+
+	w.pending = append(w.pending, &scanJob{
+		jobID:    jobID,
+		filePath: filePath,
+		priority: priority,
+		queuedAt: time.Now(),
+	})
+	// Never blocks: ready holds at most one token per queued job.
+	w.ready <- struct{}{}
+
+	return nil
+}
+
+// next removes and returns the queued job with the highest effective
+// priority: its priority plus one level per AgingInterval spent waiting.
+// Pending jobs are kept in submission order, so ties go to the oldest.
+func (w *Worker) next() *scanJob {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	effective := func(job *scanJob) int64 {
+		return int64(job.priority) + int64(now.Sub(job.queuedAt)/w.config.AgingInterval)
+	}
+
+	best := 0
+	bestScore := effective(w.pending[0])
+	for i, job := range w.pending[1:] {
+		score := effective(job)
+		if score > bestScore {
+			best, bestScore = i+1, score
+		}
+	}
+
+	job := w.pending[best]
+	w.pending = append(w.pending[:best], w.pending[best+1:]...)
+	return job
 }
 
 // workerLoop processes jobs from the queue.
@@ -99,9 +214,10 @@ func (w *Worker) workerLoop(ctx context.Context) {
 			return
 		case <-w.stopCh:
 			return
-		case job, ok := <-w.jobQueue:
-			if !ok {
-				return
+		case <-w.ready:
+			job := w.next()
+			if job == nil {
+				continue
 			}
 			if err := w.ProcessJob(ctx, job.jobID, job.filePath); err != nil {
 				// Log error but continue processing.
@@ -133,6 +249,7 @@ func (w *Worker) ProcessJob(ctx context.Context, jobID, filePath string) error {
 			if err := job.Complete(cached); err != nil {
 				return fmt.Errorf("completing job: %w", err)
 			}
+			w.recordOutcome(cached, false)
 			return w.config.JobStore.Update(ctx, job)
 		}
 	}
@@ -148,7 +265,9 @@ func (w *Worker) ProcessJob(ctx context.Context, jobID, filePath string) error {
 	// Scan the file.
 	var result *types.ScanResult
 	if w.config.Scanner != nil {
+		start := time.Now()
 		result, err = w.config.Scanner.ScanFile(ctx, filePath)
+		w.observeScan(result, err, time.Since(start))
 		if err != nil {
 			if failErr := job.Fail(err.Error()); failErr != nil {
 				return fmt.Errorf("failing job: %w", failErr)
@@ -160,6 +279,7 @@ func (w *Worker) ProcessJob(ctx context.Context, jobID, filePath string) error {
 		if err := job.Fail("scanner not available"); err != nil {
 			return fmt.Errorf("failing job: %w", err)
 		}
+		w.recordOutcome(nil, true)
 		return w.config.JobStore.Update(ctx, job)
 	}
 
@@ -189,7 +309,63 @@ func (w *Worker) ProcessJob(ctx context.Context, jobID, filePath string) error {
 	return w.config.JobStore.Update(ctx, job)
 }
 
+// observeScan records a scan outcome in the worker stats and the
+// configured metrics.
+func (w *Worker) observeScan(result *types.ScanResult, err error, duration time.Duration) {
+	w.recordOutcome(result, err != nil)
+	w.recordScanTime(duration)
+
+	if w.config.Metrics == nil {
+		return
+	}
+
+	status := types.ScanStatusError.String()
+	if err == nil && result != nil {
+		status = result.Status.String()
+	}
+	w.config.Metrics.ObserveScan(status, duration)
+}
+
+// recordOutcome counts a finished job.
+func (w *Worker) recordOutcome(result *types.ScanResult, failed bool) {
+	w.processed.Add(1)
+
+	switch {
+	case failed || result == nil || result.Status == types.ScanStatusError:
+		w.errors.Add(1)
+	case result.Status.IsInfected():
+		w.infected.Add(1)
+	}
+}
+
+// recordScanTime folds duration into the moving average scan time.
+func (w *Worker) recordScanTime(duration time.Duration) {
+	for {
+		old := w.avgScanTime.Load()
+		next := int64(duration)
+		if old != 0 {
+			next = old + int64(scanTimeSmoothing*float64(int64(duration)-old))
+		}
+		if w.avgScanTime.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// Stats returns the worker's processing counters.
+func (w *Worker) Stats() WorkerStats {
+	return WorkerStats{
+		Processed:   w.processed.Load(),
+		Errors:      w.errors.Load(),
+		Infected:    w.infected.Load(),
+		AvgScanTime: time.Duration(w.avgScanTime.Load()),
+	}
+}
+
 // QueueLength returns the current number of jobs in the queue.
 func (w *Worker) QueueLength() int {
-	return len(w.jobQueue)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return len(w.pending)
 }
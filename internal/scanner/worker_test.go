@@ -1,12 +1,14 @@
 // ABOUTME: Tests for background scan worker processing jobs asynchronously
-// ABOUTME: Validates job processing, result caching, and signature persistence
+// ABOUTME: Validates job processing, result caching, priority ordering, and stats
 
 package scanner
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -169,6 +171,249 @@ func TestWorker_ProcessJob_NotFound(t *testing.T) {
 	}
 }
 
+func TestWorker_Priority(t *testing.T) {
+	t.Parallel()
+
+	type submission struct {
+		name     string
+		priority Priority
+		// delay waits before this submission, letting earlier jobs age.
+		delay time.Duration
+	}
+
+	tests := []struct {
+		name      string
+		aging     time.Duration
+		submitted []submission
+		wantOrder []string
+	}{
+		{
+			name:  "high priority runs before earlier low priority jobs",
+			aging: time.Hour,
+			submitted: []submission{
+				{name: "low-1", priority: PriorityLow},
+				{name: "low-2", priority: PriorityLow},
+				{name: "normal", priority: PriorityNormal},
+				{name: "low-3", priority: PriorityLow},
+				{name: "high", priority: PriorityHigh},
+			},
+			wantOrder: []string{"high", "normal", "low-1", "low-2", "low-3"},
+		},
+		{
+			name:  "aged low priority job is not starved",
+			aging: 10 * time.Millisecond,
+			submitted: []submission{
+				{name: "low", priority: PriorityLow},
+				{name: "high-1", priority: PriorityHigh, delay: 50 * time.Millisecond},
+				{name: "high-2", priority: PriorityHigh},
+			},
+			wantOrder: []string{"low", "high-1", "high-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			jobStore, err := engine.NewJobStore(engine.StoreConfig{InMemory: true})
+			if err != nil {
+				t.Fatalf("Failed to create job store: %v", err)
+			}
+			defer jobStore.Close()
+
+			binary, invocations := fakeClamscan(t)
+			worker := NewWorker(WorkerConfig{
+				Scanner: NewClamAVScanner(&config.ClamAVConfig{
+					Mode:    "clamscan",
+					Binary:  binary,
+					Timeout: 10 * time.Second,
+				}),
+				JobStore:      jobStore,
+				Concurrency:   1,
+				AgingInterval: tt.aging,
+			})
+
+			// Queue everything before starting so the order is decided by
+			// priority alone.
+			dir := t.TempDir()
+			jobIDs := make([]string, 0, len(tt.submitted))
+			for _, sub := range tt.submitted {
+				time.Sleep(sub.delay)
+
+				path := filepath.Join(dir, sub.name)
+				if err := os.WriteFile(path, []byte(sub.name), 0o644); err != nil {
+					t.Fatalf("Failed to create test file: %v", err)
+				}
+				job := types.NewJob(sub.name, sub.name, int64(len(sub.name)))
+				if err := jobStore.Create(ctx, job); err != nil {
+					t.Fatalf("Failed to create job: %v", err)
+				}
+				if err := worker.SubmitWithPriority(job.ID, path, sub.priority); err != nil {
+					t.Fatalf("SubmitWithPriority() error = %v", err)
+				}
+				jobIDs = append(jobIDs, job.ID)
+			}
+			if got := worker.QueueLength(); got != len(tt.submitted) {
+				t.Errorf("QueueLength() = %d, want %d", got, len(tt.submitted))
+			}
+
+			worker.Start(ctx)
+			defer worker.Stop()
+
+			for _, id := range jobIDs {
+				waitForJob(ctx, t, jobStore, id)
+			}
+			if got := worker.QueueLength(); got != 0 {
+				t.Errorf("QueueLength() after draining = %d, want 0", got)
+			}
+
+			log, err := os.ReadFile(invocations)
+			if err != nil {
+				t.Fatalf("reading invocation log: %v", err)
+			}
+			var order []string
+			for _, path := range strings.Fields(string(log)) {
+				order = append(order, filepath.Base(path))
+			}
+			if strings.Join(order, ",") != strings.Join(tt.wantOrder, ",") {
+				t.Errorf("scan order = %v, want %v", order, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestWorker_SubmitQueueFull(t *testing.T) {
+	t.Parallel()
+
+	worker := NewWorker(WorkerConfig{})
+	for i := range maxQueuedJobs {
+		if err := worker.Submit(fmt.Sprintf("job-%d", i), "/any/path"); err != nil {
+			t.Fatalf("Submit() #%d error = %v", i, err)
+		}
+	}
+	if err := worker.Submit("one-too-many", "/any/path"); err == nil {
+		t.Error("Submit() on a full queue should fail")
+	}
+	if got := worker.QueueLength(); got != maxQueuedJobs {
+		t.Errorf("QueueLength() = %d, want %d", got, maxQueuedJobs)
+	}
+
+	worker.Stop()
+	if err := worker.Submit("after-stop", "/any/path"); err == nil {
+		t.Error("Submit() after Stop() should fail")
+	}
+}
+
+func TestWorker_Stats(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	jobStore, err := engine.NewJobStore(engine.StoreConfig{InMemory: true})
+	if err != nil {
+		t.Fatalf("Failed to create job store: %v", err)
+	}
+	defer jobStore.Close()
+
+	binary, _ := fakeClamscan(t)
+	worker := NewWorker(WorkerConfig{
+		Scanner: NewClamAVScanner(&config.ClamAVConfig{
+			Mode:    "clamscan",
+			Binary:  binary,
+			Timeout: 10 * time.Second,
+		}),
+		JobStore: jobStore,
+	})
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"clean-1.txt": "hello",
+		"clean-2.txt": "world",
+		"eicar.txt":   eicarStream,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	// "missing.txt" is never written, so its scan reports an error.
+	for _, name := range []string{"clean-1.txt", "eicar.txt", "missing.txt", "clean-2.txt"} {
+		job := types.NewJob(name, name, 0)
+		if err := jobStore.Create(ctx, job); err != nil {
+			t.Fatalf("Failed to create job: %v", err)
+		}
+		if err := worker.ProcessJob(ctx, job.ID, filepath.Join(dir, name)); err != nil {
+			t.Fatalf("ProcessJob(%s) error = %v", name, err)
+		}
+	}
+
+	stats := worker.Stats()
+	if stats.Processed != 4 || stats.Infected != 1 || stats.Errors != 1 {
+		t.Errorf("Stats() = %+v, want 4 processed, 1 infected, 1 error", stats)
+	}
+	if stats.AvgScanTime <= 0 {
+		t.Errorf("AvgScanTime = %v, want > 0", stats.AvgScanTime)
+	}
+}
+
+func TestWorker_RecordScanTime(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		durations []time.Duration
+		want      time.Duration
+	}{
+		{name: "no scans", want: 0},
+		{name: "first scan sets the average", durations: []time.Duration{100 * time.Millisecond}, want: 100 * time.Millisecond},
+		{
+			name:      "later scans are smoothed",
+			durations: []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 200 * time.Millisecond},
+			// 100ms, then 100 + 0.2*(200-100) = 120ms, then 120 + 0.2*(200-120) = 136ms.
+			want: 136 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			worker := NewWorker(WorkerConfig{})
+			for _, d := range tt.durations {
+				worker.recordScanTime(d)
+			}
+			if got := worker.Stats().AvgScanTime; got != tt.want {
+				t.Errorf("AvgScanTime = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// waitForJob polls the job store until the job reaches a terminal status.
+func waitForJob(ctx context.Context, t *testing.T, store *engine.JobStore, jobID string) {
+	t.Helper()
+
+	for {
+		job, err := store.Get(ctx, jobID)
+		if err != nil {
+			t.Fatalf("Failed to get job: %v", err)
+		}
+		if job != nil && job.Status.IsTerminal() {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("job %s did not finish: %v", jobID, ctx.Err())
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
 func isClamscanAvailable() bool {
 	paths := []string{
 		"/usr/bin/clamscan",
@@ -1,12 +1,15 @@
 // ABOUTME: Per-package vulnerability cache using BadgerDB
-// ABOUTME: Caches scan results with TTL to avoid redundant Trivy queries
+// ABOUTME: Caches scan results with TTL, LRU size cap, and background eviction
 
 package trivy
 
 import (
+	"container/list"
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/dgraph-io/badger/v4"
@@ -25,6 +28,14 @@ type CacheConfig struct {
 
 	// TTL is the time-to-live for cached entries.
 	TTL time.Duration
+
+	// MaxEntries caps the number of cached packages. When exceeded, the
+	// least recently used entries are evicted. Zero means unlimited.
+	MaxEntries int
+
+	// CleanupInterval controls how often the background goroutine removes
+	// expired entries and compacts the value log. Zero disables it.
+	CleanupInterval time.Duration
 }
 
 // CacheEntry represents a cached vulnerability scan result.
@@ -36,8 +47,19 @@ type CacheEntry struct {
 
 // Cache stores per-package vulnerability scan results.
 type Cache struct {
-	db  *badger.DB
-	ttl time.Duration
+	db         *badger.DB
+	ttl        time.Duration
+	inMemory   bool
+	maxEntries int
+
+	// lru orders cache keys from most (front) to least (back) recently used.
+	mu    sync.Mutex
+	lru   *list.List
+	index map[string]*list.Element
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
 }
 
 // NewCache creates a new vulnerability cache with the given configuration.
@@ -59,10 +81,164 @@ func NewCache(cfg CacheConfig) (*Cache, error) {
 		ttl = 1 * time.Hour
 	}
 
-	return &Cache{
-		db:  db,
-		ttl: ttl,
-	}, nil
+	c := &Cache{
+		db:         db,
+		ttl:        ttl,
+		inMemory:   cfg.InMemory,
+		maxEntries: cfg.MaxEntries,
+		lru:        list.New(),
+		index:      make(map[string]*list.Element),
+		stopCh:     make(chan struct{}),
+	}
+
+	if err := c.loadIndex(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Apply the cap to entries persisted under a larger limit
+	if err := c.evictOverflow(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if cfg.CleanupInterval > 0 {
+		c.wg.Add(1)
+		go c.cleanupLoop(cfg.CleanupInterval)
+	}
+
+	return c, nil
+}
+
+// loadIndex rebuilds the LRU order from persisted entries, oldest scan first.
+func (c *Cache) loadIndex() error {
+	type indexed struct {
+		key       string
+		scannedAt time.Time
+	}
+	var entries []indexed
+
+	err := c.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte(cacheKeyPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			var entry CacheEntry
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &entry)
+			}); err != nil {
+				continue // Skip malformed entries
+			}
+			entries = append(entries, indexed{key: string(item.Key()), scannedAt: entry.ScannedAt})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load cache index: %w", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].scannedAt.Before(entries[j].scannedAt)
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range entries {
+		c.index[e.key] = c.lru.PushFront(e.key)
+	}
+
+	return nil
+}
+
+// touch marks key as most recently used.
+func (c *Cache) touch(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.index[key]; ok {
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.index[key] = c.lru.PushFront(key)
+}
+
+// forget removes key from the LRU index.
+func (c *Cache) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.index[key]; ok {
+		c.lru.Remove(elem)
+		delete(c.index, key)
+	}
+}
+
+// evictOverflow deletes least recently used entries beyond MaxEntries.
+func (c *Cache) evictOverflow() error {
+	if c.maxEntries <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	var victims [][]byte
+	for c.lru.Len() > c.maxEntries {
+		elem := c.lru.Back()
+		key := elem.Value.(string)
+		c.lru.Remove(elem)
+		delete(c.index, key)
+		victims = append(victims, []byte(key))
+	}
+	c.mu.Unlock()
+
+	if len(victims) == 0 {
+		return nil
+	}
+
+	err := c.db.Update(func(txn *badger.Txn) error {
+		for _, key := range victims {
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to evict cache entries: %w", err)
+	}
+
+	return nil
+}
+
+// cleanupLoop periodically removes expired entries and reclaims disk space.
+func (c *Cache) cleanupLoop(interval time.Duration) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+			_, _ = c.Cleanup(context.Background())
+			_ = c.evictOverflow()
+			c.compact()
+		}
+	}
+}
+
+// compact runs BadgerDB value log garbage collection until nothing is left
+// to rewrite. It is a no-op for in-memory caches.
+func (c *Cache) compact() {
+	if c.inMemory {
+		return
+	}
+	for c.db.RunValueLogGC(0.5) == nil {
+		// Keep collecting while files are being rewritten
+	}
 }
 
 // Get retrieves cached vulnerabilities for a package.
@@ -83,6 +259,7 @@ func (c *Cache) Get(_ context.Context, pkg Package) ([]Vulnerability, bool, erro
 	})
 
 	if err == badger.ErrKeyNotFound {
+		c.forget(string(key))
 		return nil, false, nil
 	}
 	if err != nil {
@@ -94,6 +271,8 @@ func (c *Cache) Get(_ context.Context, pkg Package) ([]Vulnerability, bool, erro
 		return nil, false, nil
 	}
 
+	c.touch(string(key))
+
 	return entry.Vulnerabilities, true, nil
 }
 
@@ -113,15 +292,18 @@ func (c *Cache) Set(_ context.Context, pkg Package, vulns []Vulnerability) error
 		return fmt.Errorf("failed to encode cache entry: %w", err)
 	}
 
+	// Expiry is tracked via ExpiresAt rather than a BadgerDB TTL so Cleanup
+	// can see expired entries and keep the LRU index in sync.
 	err = c.db.Update(func(txn *badger.Txn) error {
-		e := badger.NewEntry(key, data).WithTTL(c.ttl)
-		return txn.SetEntry(e)
+		return txn.Set(key, data)
 	})
 	if err != nil {
 		return fmt.Errorf("failed to set cache entry: %w", err)
 	}
 
-	return nil
+	c.touch(string(key))
+
+	return c.evictOverflow()
 }
 
 // Delete removes a package from the cache.
@@ -135,6 +317,8 @@ func (c *Cache) Delete(_ context.Context, pkg Package) error {
 		return fmt.Errorf("failed to delete cache entry: %w", err)
 	}
 
+	c.forget(string(key))
+
 	return nil
 }
 
@@ -196,6 +380,7 @@ func (c *Cache) Cleanup(_ context.Context) (int, error) {
 			if err := txn.Delete(key); err != nil {
 				continue
 			}
+			c.forget(string(key))
 			deleted++
 		}
 
@@ -208,8 +393,18 @@ func (c *Cache) Cleanup(_ context.Context) (int, error) {
 	return deleted, nil
 }
 
-// Close closes the cache database.
+// Len returns the number of entries tracked by the cache, including
+// expired entries not yet removed by Cleanup.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Close stops background cleanup and closes the cache database.
 func (c *Cache) Close() error {
+	c.stopOnce.Do(func() { close(c.stopCh) })
+	c.wg.Wait()
 	return c.db.Close()
 }
 
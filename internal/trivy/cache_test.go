@@ -362,3 +362,162 @@ func TestCacheKey(t *testing.T) {
 		})
 	}
 }
+
+func TestCache_PersistsAcrossReopen(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ctx := context.Background()
+	pkg := Package{Name: "lodash", Version: "4.17.20", Ecosystem: EcosystemNpm}
+
+	cache, err := NewCache(CacheConfig{Path: dir, TTL: 1 * time.Hour})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	if err := cache.Set(ctx, pkg, []Vulnerability{{CVEID: "CVE-2021-23337"}}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	reopened, err := NewCache(CacheConfig{Path: dir, TTL: 1 * time.Hour})
+	if err != nil {
+		t.Fatalf("failed to reopen cache: %v", err)
+	}
+	defer reopened.Close()
+
+	vulns, found, err := reopened.Get(ctx, pkg)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !found {
+		t.Fatal("expected entry to survive reopen")
+	}
+	if len(vulns) != 1 || vulns[0].CVEID != "CVE-2021-23337" {
+		t.Errorf("unexpected vulnerabilities after reopen: %v", vulns)
+	}
+	if reopened.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", reopened.Len())
+	}
+}
+
+func TestCache_TTLExpiry(t *testing.T) {
+	t.Parallel()
+
+	cache, err := NewCache(CacheConfig{Path: t.TempDir(), TTL: 1 * time.Second})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	ctx := context.Background()
+	pkg := Package{Name: "requests", Version: "2.25.0", Ecosystem: EcosystemPip}
+
+	if err := cache.Set(ctx, pkg, []Vulnerability{{CVEID: "CVE-1"}}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+
+	_, found, err := cache.Get(ctx, pkg)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if found {
+		t.Error("expected entry to expire after TTL")
+	}
+}
+
+func TestCache_MaxEntriesEvictsLRU(t *testing.T) {
+	t.Parallel()
+
+	cache, err := NewCache(CacheConfig{InMemory: true, TTL: 1 * time.Hour, MaxEntries: 2})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	ctx := context.Background()
+	a := Package{Name: "a", Version: "1.0.0", Ecosystem: EcosystemNpm}
+	b := Package{Name: "b", Version: "1.0.0", Ecosystem: EcosystemNpm}
+	c := Package{Name: "c", Version: "1.0.0", Ecosystem: EcosystemNpm}
+
+	cache.Set(ctx, a, nil)
+	cache.Set(ctx, b, nil)
+
+	// Touch a so b becomes least recently used
+	if _, found, _ := cache.Get(ctx, a); !found {
+		t.Fatal("expected a to be cached")
+	}
+
+	cache.Set(ctx, c, nil)
+
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", cache.Len())
+	}
+	if _, found, _ := cache.Get(ctx, b); found {
+		t.Error("expected b to be evicted")
+	}
+	if _, found, _ := cache.Get(ctx, a); !found {
+		t.Error("expected a to remain cached")
+	}
+	if _, found, _ := cache.Get(ctx, c); !found {
+		t.Error("expected c to remain cached")
+	}
+}
+
+func TestCache_MaxEntriesAppliedOnReopen(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	cache, err := NewCache(CacheConfig{Path: dir, TTL: 1 * time.Hour})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		cache.Set(ctx, Package{Name: name, Version: "1.0.0", Ecosystem: EcosystemNpm}, nil)
+		time.Sleep(5 * time.Millisecond) // Distinct scan times
+	}
+	cache.Close()
+
+	reopened, err := NewCache(CacheConfig{Path: dir, TTL: 1 * time.Hour, MaxEntries: 1})
+	if err != nil {
+		t.Fatalf("failed to reopen cache: %v", err)
+	}
+	defer reopened.Close()
+
+	if reopened.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", reopened.Len())
+	}
+	if _, found, _ := reopened.Get(ctx, Package{Name: "c", Version: "1.0.0", Ecosystem: EcosystemNpm}); !found {
+		t.Error("expected most recent entry to survive")
+	}
+}
+
+func TestCache_BackgroundCleanup(t *testing.T) {
+	t.Parallel()
+
+	cache, err := NewCache(CacheConfig{
+		Path:            t.TempDir(),
+		TTL:             1 * time.Second,
+		CleanupInterval: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	cache.Set(context.Background(), Package{Name: "x", Version: "1.0.0", Ecosystem: EcosystemPip}, nil)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for cache.Len() != 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if cache.Len() != 0 {
+		t.Errorf("expected background cleanup to drop expired entry, Len() = %d", cache.Len())
+	}
+}
@@ -1,5 +1,5 @@
 // ABOUTME: Twirp HTTP client for communicating with Trivy server
-// ABOUTME: Implements PutBlob, PutArtifact, and Scan methods for vulnerability scanning
+// ABOUTME: Implements PutBlob, PutArtifact, and Scan with retries on transient failures
 
 package trivy
 
@@ -12,6 +12,8 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/dbupdater"
 )
 
 // Twirp endpoint paths.
@@ -24,6 +26,16 @@ const (
 // Default client configuration values.
 const (
 	defaultTimeout = 2 * time.Minute
+
+	// DefaultMaxRetries is the number of times a request is retried after a
+	// transient failure.
+	DefaultMaxRetries = 3
+
+	// DefaultRetryDelay is the delay before the first retry; it doubles on
+	// each subsequent retry up to maxRetryDelay.
+	DefaultRetryDelay = 250 * time.Millisecond
+
+	maxRetryDelay = 5 * time.Second
 )
 
 // ClientConfig holds configuration for the Trivy client.
@@ -36,6 +48,15 @@ type ClientConfig struct {
 
 	// HTTPClient is an optional custom HTTP client. If nil, a default client is created.
 	HTTPClient *http.Client
+
+	// MaxRetries is how many times a request is retried after a connection
+	// error or a 500, 502, 503, or 504 response. Zero uses
+	// DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+
+	// RetryDelay is the delay before the first retry. Zero uses
+	// DefaultRetryDelay.
+	RetryDelay time.Duration
 }
 
 // Client is a Twirp HTTP client for the Trivy server.
@@ -43,6 +64,8 @@ type Client struct {
 	serverURL  string
 	httpClient *http.Client
 	timeout    time.Duration
+	maxRetries int
+	retryDelay time.Duration
 }
 
 // NewClient creates a new Trivy client with the given configuration.
@@ -59,10 +82,22 @@ func NewClient(cfg ClientConfig) *Client {
 		}
 	}
 
+	maxRetries := cfg.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = DefaultMaxRetries
+	}
+
+	retryDelay := cfg.RetryDelay
+	if retryDelay == 0 {
+		retryDelay = DefaultRetryDelay
+	}
+
 	return &Client{
 		serverURL:  cfg.ServerURL,
 		httpClient: httpClient,
 		timeout:    timeout,
+		maxRetries: maxRetries,
+		retryDelay: retryDelay,
 	}
 }
 
@@ -103,17 +138,48 @@ func (c *Client) Scan(ctx context.Context, req TwirpScanRequest) (*TwirpScanResp
 	return &resp, nil
 }
 
-// doRequest performs an HTTP POST request to the given path with JSON body.
+// doRequest performs an HTTP POST request to the given path with JSON body,
+// retrying transient failures with exponential backoff. All Twirp calls made
+// by the client are idempotent, so retrying them is safe.
 func (c *Client) doRequest(ctx context.Context, path string, reqBody interface{}) ([]byte, error) {
 	body, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode request: %w", err)
 	}
 
+	if c.maxRetries < 0 {
+		respBody, _, err := c.send(ctx, path, body)
+		return respBody, err
+	}
+
+	backoff := dbupdater.NewBackoff(dbupdater.BackoffConfig{
+		MaxRetries:     c.maxRetries,
+		InitialDelay:   c.retryDelay,
+		MaxDelay:       maxRetryDelay,
+		JitterFraction: dbupdater.DefaultJitterFraction,
+	})
+
+	for attempt := 1; ; attempt++ {
+		respBody, retryable, err := c.send(ctx, path, body)
+		if err == nil || !retryable {
+			return respBody, err
+		}
+
+		if waitErr := backoff.Wait(ctx); waitErr != nil {
+			if errors.Is(waitErr, dbupdater.ErrMaxRetriesExceeded) {
+				return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+			}
+			return nil, fmt.Errorf("%w (retry cancelled: %w)", err, waitErr)
+		}
+	}
+}
+
+// send performs a single request and reports whether a failure is transient.
+func (c *Client) send(ctx context.Context, path string, body []byte) ([]byte, bool, error) {
 	url := c.serverURL + path
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -121,24 +187,40 @@ func (c *Client) doRequest(ctx context.Context, path string, reqBody interface{}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request to %s: %w", path, err)
+		// Connection errors are transient unless the caller gave up.
+		return nil, ctx.Err() == nil, fmt.Errorf("failed to send request to %s: %w", path, err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, ctx.Err() == nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
+		retryable := isRetryableStatus(resp.StatusCode)
 		var twirpErr TwirpError
 		if err := json.Unmarshal(respBody, &twirpErr); err == nil && twirpErr.Code != "" {
-			return nil, twirpErr
+			return nil, retryable, twirpErr
 		}
-		return nil, errors.New("server returned status " + resp.Status)
+		return nil, retryable, errors.New("server returned status " + resp.Status)
 	}
 
-	return respBody, nil
+	return respBody, false, nil
+}
+
+// isRetryableStatus reports whether a response status indicates a transient
+// server-side failure.
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
 }
 
 // ServerURL returns the configured server URL.
@@ -6,9 +6,11 @@ package trivy
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -409,6 +411,95 @@ func TestClient_InvalidServerURL(t *testing.T) {
 	}
 }
 
+func TestClient_Retry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		failures     int
+		failStatus   int
+		maxRetries   int
+		wantErr      bool
+		wantAttempts int32
+	}{
+		{name: "recovers after two 503s", failures: 2, failStatus: http.StatusServiceUnavailable, maxRetries: 3, wantAttempts: 3},
+		{name: "recovers after a 500", failures: 1, failStatus: http.StatusInternalServerError, maxRetries: 3, wantAttempts: 2},
+		{name: "gives up after max retries", failures: 10, failStatus: http.StatusServiceUnavailable, maxRetries: 2, wantErr: true, wantAttempts: 3},
+		{name: "no retry on 4xx", failures: 10, failStatus: http.StatusBadRequest, maxRetries: 3, wantErr: true, wantAttempts: 1},
+		{name: "retries disabled", failures: 1, failStatus: http.StatusServiceUnavailable, maxRetries: -1, wantErr: true, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if int(attempts.Add(1)) <= tt.failures {
+					w.WriteHeader(tt.failStatus)
+					_, _ = w.Write([]byte(`{"code":"unavailable","msg":"server restarting"}`))
+					return
+				}
+				_ = json.NewEncoder(w).Encode(TwirpScanResponse{
+					Results: []TwirpResult{{Target: "dependency-scan"}},
+				})
+			}))
+			defer server.Close()
+
+			client := NewClient(ClientConfig{
+				ServerURL:  server.URL,
+				Timeout:    5 * time.Second,
+				MaxRetries: tt.maxRetries,
+				RetryDelay: time.Millisecond,
+			})
+
+			resp, err := client.Scan(context.Background(), TwirpScanRequest{Target: "dependency-scan"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(resp.Results) != 1 {
+				t.Errorf("Scan() returned %d results, want 1", len(resp.Results))
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("server saw %d attempts, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestClient_Retry_ContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		ServerURL:  server.URL,
+		Timeout:    5 * time.Second,
+		MaxRetries: 5,
+		RetryDelay: time.Hour,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := client.PutArtifact(ctx, TwirpPutArtifactRequest{ArtifactID: "sha256:abc123"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("PutArtifact() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("PutArtifact() took %v, want it to stop waiting when ctx is done", elapsed)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("server saw %d attempts, want 1", got)
+	}
+}
+
 func TestNewClient_Defaults(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,258 @@
+// ABOUTME: CycloneDX 1.5 SBOM exporter for scanned packages and vulnerabilities
+// ABOUTME: Derives package URLs per ecosystem and links vulnerabilities to components
+
+package trivy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CycloneDX document constants.
+const (
+	cycloneDXFormat  = "CycloneDX"
+	cycloneDXVersion = "1.5"
+)
+
+// CycloneDXBOM is the top-level CycloneDX document.
+type CycloneDXBOM struct {
+	BOMFormat       string                   `json:"bomFormat"`
+	SpecVersion     string                   `json:"specVersion"`
+	SerialNumber    string                   `json:"serialNumber"`
+	Version         int                      `json:"version"`
+	Metadata        CycloneDXMetadata        `json:"metadata"`
+	Components      []CycloneDXComponent     `json:"components"`
+	Vulnerabilities []CycloneDXVulnerability `json:"vulnerabilities,omitempty"`
+}
+
+// CycloneDXMetadata records when and by what the BOM was produced.
+type CycloneDXMetadata struct {
+	Timestamp string         `json:"timestamp"`
+	Tools     CycloneDXTools `json:"tools"`
+}
+
+// CycloneDXTools lists the tools that produced the BOM.
+type CycloneDXTools struct {
+	Components []CycloneDXComponent `json:"components"`
+}
+
+// CycloneDXComponent is a software component, here a package.
+type CycloneDXComponent struct {
+	Type    string `json:"type"`
+	BOMRef  string `json:"bom-ref,omitempty"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+// CycloneDXVulnerability is a vulnerability affecting one or more components.
+type CycloneDXVulnerability struct {
+	BOMRef         string              `json:"bom-ref"`
+	ID             string              `json:"id"`
+	Ratings        []CycloneDXRating   `json:"ratings,omitempty"`
+	Description    string              `json:"description,omitempty"`
+	Recommendation string              `json:"recommendation,omitempty"`
+	Advisories     []CycloneDXAdvisory `json:"advisories,omitempty"`
+	Affects        []CycloneDXAffect   `json:"affects"`
+}
+
+// CycloneDXRating is a severity rating for a vulnerability.
+type CycloneDXRating struct {
+	Score    float64 `json:"score,omitempty"`
+	Severity string  `json:"severity"`
+	Method   string  `json:"method,omitempty"`
+	Vector   string  `json:"vector,omitempty"`
+}
+
+// CycloneDXAdvisory is a reference to an advisory for a vulnerability.
+type CycloneDXAdvisory struct {
+	URL string `json:"url"`
+}
+
+// CycloneDXAffect references a component affected by a vulnerability.
+type CycloneDXAffect struct {
+	Ref string `json:"ref"`
+}
+
+// purlTypes maps ecosystems to package URL types.
+var purlTypes = map[string]string{
+	EcosystemPip:      "pypi",
+	EcosystemNpm:      "npm",
+	EcosystemGomod:    "golang",
+	EcosystemCargo:    "cargo",
+	EcosystemComposer: "composer",
+	EcosystemMaven:    "maven",
+	EcosystemNuget:    "nuget",
+	EcosystemRubygems: "gem",
+}
+
+// ToCycloneDX builds a CycloneDX 1.5 JSON BOM listing packages as library
+// components and vulns as vulnerabilities that reference them. Packages that
+// only appear in vulns are added as components so every reference resolves.
+// Vulnerabilities sharing an ID are merged into one entry.
+func ToCycloneDX(packages []Package, vulns []Vulnerability) ([]byte, error) {
+	bom := CycloneDXBOM{
+		BOMFormat:    cycloneDXFormat,
+		SpecVersion:  cycloneDXVersion,
+		SerialNumber: "urn:uuid:" + uuid.New().String(),
+		Version:      1,
+		Metadata: CycloneDXMetadata{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Tools: CycloneDXTools{Components: []CycloneDXComponent{{
+				Type: "application",
+				Name: sarifToolName,
+			}}},
+		},
+		Components: []CycloneDXComponent{},
+	}
+
+	refs := make(map[string]bool)
+	addComponent := func(pkg Package) string {
+		ref := PackageURL(pkg)
+		if !refs[ref] {
+			refs[ref] = true
+			bom.Components = append(bom.Components, CycloneDXComponent{
+				Type:    "library",
+				BOMRef:  ref,
+				Name:    pkg.Name,
+				Version: pkg.Version,
+				PURL:    ref,
+			})
+		}
+		return ref
+	}
+
+	for _, pkg := range packages {
+		addComponent(pkg)
+	}
+
+	vulnIndex := make(map[string]int)
+	for _, v := range vulns {
+		ref := addComponent(Package{Name: v.Package, Version: v.Version, Ecosystem: v.Ecosystem})
+
+		if idx, ok := vulnIndex[v.CVEID]; ok {
+			entry := &bom.Vulnerabilities[idx]
+			if !containsAffect(entry.Affects, ref) {
+				entry.Affects = append(entry.Affects, CycloneDXAffect{Ref: ref})
+			}
+			continue
+		}
+
+		vulnIndex[v.CVEID] = len(bom.Vulnerabilities)
+		bom.Vulnerabilities = append(bom.Vulnerabilities, cycloneDXVulnerability(v, ref))
+	}
+
+	data, err := json.MarshalIndent(bom, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding CycloneDX: %w", err)
+	}
+
+	return data, nil
+}
+
+// cycloneDXVulnerability converts v affecting the component ref.
+func cycloneDXVulnerability(v Vulnerability, ref string) CycloneDXVulnerability {
+	rating := CycloneDXRating{Severity: strings.ToLower(v.Severity)}
+	if v.CVSSScore > 0 {
+		rating.Score = v.CVSSScore
+		rating.Vector = v.CVSSVector
+		rating.Method = cvssMethod(v.CVSSVector)
+	}
+
+	entry := CycloneDXVulnerability{
+		BOMRef:      v.CVEID,
+		ID:          v.CVEID,
+		Ratings:     []CycloneDXRating{rating},
+		Description: sarifText(v.Description, v.Title),
+		Affects:     []CycloneDXAffect{{Ref: ref}},
+	}
+	if v.FixedVersion != "" {
+		entry.Recommendation = fmt.Sprintf("Upgrade %s to %s", v.Package, v.FixedVersion)
+	}
+	for _, u := range v.References {
+		entry.Advisories = append(entry.Advisories, CycloneDXAdvisory{URL: u})
+	}
+	return entry
+}
+
+// cvssMethod returns the CycloneDX rating method for a CVSS vector.
+func cvssMethod(vector string) string {
+	switch {
+	case strings.HasPrefix(vector, "CVSS:4"):
+		return "CVSSv4"
+	case strings.HasPrefix(vector, "CVSS:3"):
+		return "CVSSv31"
+	case vector != "":
+		return "CVSSv2"
+	default:
+		return "other"
+	}
+}
+
+func containsAffect(affects []CycloneDXAffect, ref string) bool {
+	for _, a := range affects {
+		if a.Ref == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// PackageURL returns the package URL (purl) for pkg, e.g.
+// "pkg:pypi/requests@2.25.0" or "pkg:maven/org.apache/commons@1.0".
+// Unknown ecosystems use the ecosystem name as the purl type.
+func PackageURL(pkg Package) string {
+	purlType, ok := purlTypes[pkg.Ecosystem]
+	if !ok {
+		purlType = strings.ToLower(pkg.Ecosystem)
+	}
+
+	name := pkg.Name
+	switch pkg.Ecosystem {
+	case EcosystemPip:
+		// PyPI names are case-insensitive with "_" equivalent to "-".
+		name = strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	case EcosystemMaven:
+		// Maven packages are named "group:artifact".
+		name = strings.Replace(name, ":", "/", 1)
+	case EcosystemComposer, EcosystemNpm:
+		name = strings.ToLower(name)
+	}
+
+	segments := strings.Split(name, "/")
+	for i, s := range segments {
+		segments[i] = purlEscape(s)
+	}
+
+	purl := "pkg:" + purlType + "/" + strings.Join(segments, "/")
+	if pkg.Version != "" {
+		purl += "@" + purlEscape(pkg.Version)
+	}
+	return purl
+}
+
+// purlEscape percent-encodes s for use in a purl segment, leaving only
+// unreserved characters as-is.
+func purlEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isPURLUnreserved(c) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func isPURLUnreserved(c byte) bool {
+	return c >= 'a' && c <= 'z' ||
+		c >= 'A' && c <= 'Z' ||
+		c >= '0' && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
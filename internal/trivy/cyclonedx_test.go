@@ -0,0 +1,134 @@
+// ABOUTME: Unit tests for the CycloneDX SBOM exporter
+// ABOUTME: Validates purl formats per ecosystem and vulnerability references
+
+package trivy
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPackageURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		pkg  Package
+		want string
+	}{
+		{name: "pypi", pkg: Package{Name: "Django_Rest", Version: "3.14.0", Ecosystem: EcosystemPip}, want: "pkg:pypi/django-rest@3.14.0"},
+		{name: "npm", pkg: Package{Name: "lodash", Version: "4.17.20", Ecosystem: EcosystemNpm}, want: "pkg:npm/lodash@4.17.20"},
+		{name: "npm scoped", pkg: Package{Name: "@babel/core", Version: "7.22.0", Ecosystem: EcosystemNpm}, want: "pkg:npm/%40babel/core@7.22.0"},
+		{name: "golang", pkg: Package{Name: "github.com/gorilla/mux", Version: "v1.8.0", Ecosystem: EcosystemGomod}, want: "pkg:golang/github.com/gorilla/mux@v1.8.0"},
+		{name: "cargo", pkg: Package{Name: "serde", Version: "1.0.188", Ecosystem: EcosystemCargo}, want: "pkg:cargo/serde@1.0.188"},
+		{name: "composer", pkg: Package{Name: "Monolog/Monolog", Version: "2.9.1", Ecosystem: EcosystemComposer}, want: "pkg:composer/monolog/monolog@2.9.1"},
+		{name: "maven", pkg: Package{Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1", Ecosystem: EcosystemMaven}, want: "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1"},
+		{name: "nuget", pkg: Package{Name: "Newtonsoft.Json", Version: "13.0.1", Ecosystem: EcosystemNuget}, want: "pkg:nuget/Newtonsoft.Json@13.0.1"},
+		{name: "gem", pkg: Package{Name: "rails", Version: "7.0.4", Ecosystem: EcosystemRubygems}, want: "pkg:gem/rails@7.0.4"},
+		{name: "version escaped", pkg: Package{Name: "semver", Version: "1.0.0+build.1", Ecosystem: EcosystemCargo}, want: "pkg:cargo/semver@1.0.0%2Bbuild.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := PackageURL(tt.pkg); got != tt.want {
+				t.Errorf("PackageURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToCycloneDX(t *testing.T) {
+	t.Parallel()
+
+	packages := []Package{
+		{Name: "requests", Version: "2.25.0", Ecosystem: EcosystemPip},
+		{Name: "lodash", Version: "4.17.20", Ecosystem: EcosystemNpm},
+		{Name: "lodash", Version: "4.17.20", Ecosystem: EcosystemNpm},
+	}
+	vulns := []Vulnerability{
+		{Package: "requests", Version: "2.25.0", Ecosystem: EcosystemPip, CVEID: "CVE-2023-32681", Severity: SeverityMedium, FixedVersion: "2.31.0", CVSSScore: 6.1, CVSSVector: "CVSS:3.1/AV:N/AC:H/PR:N/UI:R/S:C/C:H/I:N/A:N"},
+		{Package: "lodash", Version: "4.17.20", Ecosystem: EcosystemNpm, CVEID: "CVE-2021-23337", Severity: SeverityHigh},
+		// Reported by the scanner for a package the manifest parser missed.
+		{Package: "minimist", Version: "1.2.5", Ecosystem: EcosystemNpm, CVEID: "CVE-2021-44906", Severity: SeverityCritical},
+		// The same CVE on a second package is merged into one entry.
+		{Package: "lodash-es", Version: "4.17.20", Ecosystem: EcosystemNpm, CVEID: "CVE-2021-23337", Severity: SeverityHigh},
+	}
+
+	data, err := ToCycloneDX(packages, vulns)
+	if err != nil {
+		t.Fatalf("ToCycloneDX() error = %v", err)
+	}
+
+	var bom CycloneDXBOM
+	if err := json.Unmarshal(data, &bom); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion != "1.5" {
+		t.Errorf("bomFormat/specVersion = %q/%q, want CycloneDX/1.5", bom.BOMFormat, bom.SpecVersion)
+	}
+	if bom.SerialNumber == "" || bom.Version != 1 {
+		t.Errorf("serialNumber = %q, version = %d", bom.SerialNumber, bom.Version)
+	}
+
+	refs := make(map[string]bool)
+	for _, c := range bom.Components {
+		if refs[c.BOMRef] {
+			t.Errorf("duplicate component bom-ref %q", c.BOMRef)
+		}
+		refs[c.BOMRef] = true
+		if c.PURL != c.BOMRef || c.Type != "library" {
+			t.Errorf("component = %+v, want library with purl as bom-ref", c)
+		}
+	}
+	if len(bom.Components) != 4 {
+		t.Errorf("components = %d, want 4", len(bom.Components))
+	}
+
+	if len(bom.Vulnerabilities) != 3 {
+		t.Fatalf("vulnerabilities = %d, want 3", len(bom.Vulnerabilities))
+	}
+	for _, v := range bom.Vulnerabilities {
+		if len(v.Affects) == 0 {
+			t.Errorf("vulnerability %s affects nothing", v.ID)
+		}
+		for _, a := range v.Affects {
+			if !refs[a.Ref] {
+				t.Errorf("vulnerability %s references unknown component %q", v.ID, a.Ref)
+			}
+		}
+	}
+
+	first := bom.Vulnerabilities[0]
+	if first.Ratings[0].Severity != "medium" || first.Ratings[0].Method != "CVSSv31" {
+		t.Errorf("rating = %+v, want medium CVSSv31", first.Ratings[0])
+	}
+	if first.Recommendation == "" {
+		t.Error("recommendation missing for a vulnerability with a fixed version")
+	}
+	if merged := bom.Vulnerabilities[1]; len(merged.Affects) != 2 {
+		t.Errorf("%s affects %d components, want 2", merged.ID, len(merged.Affects))
+	}
+}
+
+func TestToCycloneDX_Empty(t *testing.T) {
+	t.Parallel()
+
+	data, err := ToCycloneDX(nil, nil)
+	if err != nil {
+		t.Fatalf("ToCycloneDX() error = %v", err)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if components, ok := raw["components"].([]any); !ok || len(components) != 0 {
+		t.Errorf("components = %v, want empty array", raw["components"])
+	}
+	if _, ok := raw["vulnerabilities"]; ok {
+		t.Error("vulnerabilities should be omitted when empty")
+	}
+}
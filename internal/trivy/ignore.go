@@ -0,0 +1,139 @@
+// ABOUTME: .trivyignore suppression file support for triaged vulnerabilities
+// ABOUTME: Matches bare CVE IDs or CVE:package scoped entries
+
+package trivy
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName is the suppression file discovered at the scan root.
+const IgnoreFileName = ".trivyignore"
+
+// IgnoreList holds suppressed vulnerability IDs, optionally scoped to packages.
+type IgnoreList struct {
+	// global holds IDs suppressed for every package.
+	global map[string]bool
+
+	// scoped maps an ID to the set of packages it is suppressed for.
+	scoped map[string]map[string]bool
+}
+
+// ParseIgnoreFile parses .trivyignore content.
+// Each non-comment line is either a vulnerability ID (CVE-2023-1234) or an
+// ID scoped to a package (CVE-2023-1234:requests). Text after # is ignored.
+func ParseIgnoreFile(data []byte) (*IgnoreList, error) {
+	list := &IgnoreList{
+		global: make(map[string]bool),
+		scoped: make(map[string]map[string]bool),
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Remove comments
+		if idx := strings.Index(line, "#"); idx != -1 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		id, pkg, scoped := strings.Cut(line, ":")
+		id = strings.TrimSpace(id)
+		pkg = strings.TrimSpace(pkg)
+
+		if !scoped || pkg == "" {
+			list.global[id] = true
+			continue
+		}
+
+		if list.scoped[id] == nil {
+			list.scoped[id] = make(map[string]bool)
+		}
+		list.scoped[id][pkg] = true
+	}
+
+	return list, scanner.Err()
+}
+
+// LoadIgnoreFile reads and parses a .trivyignore file.
+func LoadIgnoreFile(path string) (*IgnoreList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading ignore file: %w", err)
+	}
+	return ParseIgnoreFile(data)
+}
+
+// Len returns the number of ignore entries.
+func (l *IgnoreList) Len() int {
+	if l == nil {
+		return 0
+	}
+	n := len(l.global)
+	for _, pkgs := range l.scoped {
+		n += len(pkgs)
+	}
+	return n
+}
+
+// Matches returns true if the vulnerability is suppressed.
+func (l *IgnoreList) Matches(v Vulnerability) bool {
+	if l == nil {
+		return false
+	}
+	if l.global[v.CVEID] {
+		return true
+	}
+	return l.scoped[v.CVEID][v.Package]
+}
+
+// Apply removes suppressed vulnerabilities from result in place, recomputing
+// the summary so counts reflect suppressions. The number of removed
+// vulnerabilities is recorded in Summary.Suppressed.
+func (l *IgnoreList) Apply(result *ScanResult) {
+	if l.Len() == 0 || result == nil {
+		return
+	}
+
+	kept := make([]Vulnerability, 0, len(result.Vulnerabilities))
+	for _, v := range result.Vulnerabilities {
+		if !l.Matches(v) {
+			kept = append(kept, v)
+		}
+	}
+
+	suppressed := len(result.Vulnerabilities) - len(kept) + result.Summary.Suppressed
+	result.Vulnerabilities = kept
+	result.Summary = NewScanSummary(kept, result.Summary.PackagesScanned)
+	result.Summary.Suppressed = suppressed
+}
+
+// resolveIgnoreList loads the ignore list for a scan. An explicit
+// opts.IgnoreFile must exist; otherwise a .trivyignore at the root of a
+// directory scan is used when present.
+func resolveIgnoreList(path string, opts ScanOptions) (*IgnoreList, error) {
+	if opts.IgnoreFile != "" {
+		return LoadIgnoreFile(opts.IgnoreFile)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return nil, nil
+	}
+
+	list, err := LoadIgnoreFile(filepath.Join(path, IgnoreFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return list, err
+}
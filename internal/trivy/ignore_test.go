@@ -0,0 +1,193 @@
+// ABOUTME: Unit tests for .trivyignore parsing and vulnerability suppression
+// ABOUTME: Covers bare CVE IDs, package-scoped entries, and summary recomputation
+
+package trivy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/config"
+)
+
+func TestParseIgnoreFile(t *testing.T) {
+	t.Parallel()
+
+	content := `# Accepted risk, see SEC-42
+CVE-2021-23337
+CVE-2023-1234:requests   # only for requests
+
+  GHSA-xxxx-yyyy-zzzz
+`
+
+	list, err := ParseIgnoreFile([]byte(content))
+	if err != nil {
+		t.Fatalf("ParseIgnoreFile() error = %v", err)
+	}
+
+	if list.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", list.Len())
+	}
+
+	tests := []struct {
+		name string
+		vuln Vulnerability
+		want bool
+	}{
+		{"bare ID matches any package", Vulnerability{CVEID: "CVE-2021-23337", Package: "lodash"}, true},
+		{"bare ID matches other package", Vulnerability{CVEID: "CVE-2021-23337", Package: "lodash-es"}, true},
+		{"scoped ID matches its package", Vulnerability{CVEID: "CVE-2023-1234", Package: "requests"}, true},
+		{"scoped ID ignores other packages", Vulnerability{CVEID: "CVE-2023-1234", Package: "urllib3"}, false},
+		{"non-CVE advisory IDs", Vulnerability{CVEID: "GHSA-xxxx-yyyy-zzzz", Package: "x"}, true},
+		{"unlisted ID", Vulnerability{CVEID: "CVE-2020-0001", Package: "requests"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := list.Matches(tt.vuln); got != tt.want {
+			t.Errorf("%s: Matches() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreList_Apply(t *testing.T) {
+	t.Parallel()
+
+	list, _ := ParseIgnoreFile([]byte("CVE-1\nCVE-2:requests\n"))
+
+	vulns := []Vulnerability{
+		{CVEID: "CVE-1", Package: "lodash", Severity: SeverityCritical},
+		{CVEID: "CVE-2", Package: "requests", Severity: SeverityHigh},
+		{CVEID: "CVE-2", Package: "urllib3", Severity: SeverityHigh},
+		{CVEID: "CVE-3", Package: "django", Severity: SeverityMedium},
+	}
+	result := &ScanResult{
+		Summary:         NewScanSummary(vulns, 4),
+		Vulnerabilities: vulns,
+	}
+
+	list.Apply(result)
+
+	if len(result.Vulnerabilities) != 2 {
+		t.Fatalf("expected 2 remaining vulnerabilities, got %d", len(result.Vulnerabilities))
+	}
+	if result.Summary.TotalVulnerabilities != 2 {
+		t.Errorf("TotalVulnerabilities = %d, want 2", result.Summary.TotalVulnerabilities)
+	}
+	if result.Summary.Critical != 0 || result.Summary.High != 1 || result.Summary.Medium != 1 {
+		t.Errorf("unexpected severity counts: %+v", result.Summary)
+	}
+	if result.Summary.Suppressed != 2 {
+		t.Errorf("Suppressed = %d, want 2", result.Summary.Suppressed)
+	}
+	if result.Summary.PackagesScanned != 4 {
+		t.Errorf("PackagesScanned = %d, want 4", result.Summary.PackagesScanned)
+	}
+}
+
+func TestIgnoreList_NilSafe(t *testing.T) {
+	t.Parallel()
+
+	var list *IgnoreList
+	result := &ScanResult{Vulnerabilities: []Vulnerability{{CVEID: "CVE-1"}}}
+
+	list.Apply(result)
+
+	if list.Matches(Vulnerability{CVEID: "CVE-1"}) {
+		t.Error("nil list should match nothing")
+	}
+	if len(result.Vulnerabilities) != 1 {
+		t.Error("nil list should not modify result")
+	}
+}
+
+func TestResolveIgnoreList(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	// No file at root
+	list, err := resolveIgnoreList(dir, ScanOptions{})
+	if err != nil || list != nil {
+		t.Fatalf("expected nil list without .trivyignore, got %v, %v", list, err)
+	}
+
+	// Discovered at root
+	os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte("CVE-1\n"), 0o644)
+	list, err = resolveIgnoreList(dir, ScanOptions{})
+	if err != nil || list.Len() != 1 {
+		t.Fatalf("expected discovered list, got %v, %v", list, err)
+	}
+
+	// Explicit override wins
+	override := filepath.Join(t.TempDir(), "custom-ignore")
+	os.WriteFile(override, []byte("CVE-2\nCVE-3\n"), 0o644)
+	list, err = resolveIgnoreList(dir, ScanOptions{IgnoreFile: override})
+	if err != nil || list.Len() != 2 {
+		t.Fatalf("expected override list, got %v, %v", list, err)
+	}
+
+	// Missing explicit file is an error
+	if _, err := resolveIgnoreList(dir, ScanOptions{IgnoreFile: filepath.Join(dir, "missing")}); err == nil {
+		t.Error("expected error for missing explicit ignore file")
+	}
+}
+
+func TestUnifiedScanner_ScanPath_TrivyIgnore(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/twirp/trivy.cache.v1.Cache/PutBlob",
+			"/twirp/trivy.cache.v1.Cache/PutArtifact":
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{}`))
+		case "/twirp/trivy.scanner.v1.Scanner/Scan":
+			resp := TwirpScanResponse{
+				Results: []TwirpResult{{
+					Target: "dependency-scan",
+					Vulnerabilities: []TwirpVulnerability{
+						{VulnerabilityID: "CVE-2023-32681", PkgName: "requests", InstalledVersion: "2.25.0", Severity: "HIGH"},
+						{VulnerabilityID: "CVE-2023-1234", PkgName: "requests", InstalledVersion: "2.25.0", Severity: "CRITICAL"},
+						{VulnerabilityID: "CVE-2023-1234", PkgName: "flask", InstalledVersion: "2.0.0", Severity: "CRITICAL"},
+					},
+				}},
+			}
+			w.WriteHeader(http.StatusOK)
+			_ = json.NewEncoder(w).Encode(resp)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "requirements.txt"), []byte("requests==2.25.0\nflask==2.0.0\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte("CVE-2023-32681\nCVE-2023-1234:requests\n"), 0o644)
+
+	scanner := NewUnifiedScanner(&config.TrivyConfig{
+		Mode:      "server",
+		ServerURL: server.URL,
+		Timeout:   5 * time.Second,
+	})
+
+	result, err := scanner.ScanPath(context.Background(), dir, ScanOptions{})
+	if err != nil {
+		t.Fatalf("ScanPath() error = %v", err)
+	}
+
+	if len(result.Vulnerabilities) != 1 {
+		t.Fatalf("expected 1 vulnerability after suppression, got %d", len(result.Vulnerabilities))
+	}
+	if result.Vulnerabilities[0].Package != "flask" {
+		t.Errorf("expected flask to remain, got %s", result.Vulnerabilities[0].Package)
+	}
+	if result.Summary.Suppressed != 2 || result.Summary.Critical != 1 {
+		t.Errorf("unexpected summary: %+v", result.Summary)
+	}
+}
@@ -94,6 +94,8 @@ type TrivyJSONResult struct {
 	Packages        []TrivyJSONPackage      `json:"Packages,omitempty"`
 	Vulnerabilities []TrivyJSONVulnItem     `json:"Vulnerabilities,omitempty"`
 	Secrets         []TrivyJSONSecretItem   `json:"Secrets,omitempty"`
+	Misconfigurations []TrivyJSONMisconfItem `json:"Misconfigurations,omitempty"`
+	Licenses        []TwirpLicense          `json:"Licenses,omitempty"`
 }
 
 // TrivyJSONPackage is a package item in the JSON output.
@@ -115,6 +117,7 @@ type TrivyJSONVulnItem struct {
 	Description      string   `json:"Description,omitempty"`
 	References       []string `json:"References,omitempty"`
 	PkgType          string   `json:"PkgType,omitempty"`
+	CVSS             map[string]TwirpCVSS `json:"CVSS,omitempty"`
 }
 
 // TrivyJSONSecretItem is a secret item in the JSON output.
@@ -128,13 +131,118 @@ type TrivyJSONSecretItem struct {
 	Match     string `json:"Match,omitempty"`
 }
 
+// TrivyJSONMisconfItem is a misconfiguration item in the JSON output.
+type TrivyJSONMisconfItem struct {
+	Type          string                 `json:"Type"`
+	ID            string                 `json:"ID"`
+	AVDID         string                 `json:"AVDID,omitempty"`
+	Title         string                 `json:"Title"`
+	Description   string                 `json:"Description,omitempty"`
+	Message       string                 `json:"Message,omitempty"`
+	Resolution    string                 `json:"Resolution,omitempty"`
+	Severity      string                 `json:"Severity"`
+	PrimaryURL    string                 `json:"PrimaryURL,omitempty"`
+	Status        string                 `json:"Status"`
+	CauseMetadata TrivyJSONCauseMetadata `json:"CauseMetadata"`
+}
+
+// TrivyJSONCauseMetadata locates the cause of a misconfiguration.
+type TrivyJSONCauseMetadata struct {
+	Provider  string `json:"Provider,omitempty"`
+	Service   string `json:"Service,omitempty"`
+	StartLine int    `json:"StartLine,omitempty"`
+	EndLine   int    `json:"EndLine,omitempty"`
+}
+
 // ScanFS scans a filesystem path for vulnerabilities and secrets.
 func (s *LocalScanner) ScanFS(ctx context.Context, path string, opts ScanOptions) (*ScanResult, error) {
+	args, err := s.buildArgs(TargetTypeFS, path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.run(ctx, args)
+}
+
+// ScanTarget scans a local path, container image, or git repository.
+// targetType is one of the TargetType constants; TargetTypeAuto detects
+// it from target. Filesystem targets are scanned with ScanPath, so
+// archives are extracted first.
+func (s *LocalScanner) ScanTarget(ctx context.Context, target, targetType string, opts ScanOptions) (*ScanResult, error) {
+	targetType, target, err := ResolveTarget(target, targetType)
+	if err != nil {
+		return nil, err
+	}
+	if targetType == TargetTypeFS {
+		return s.ScanPath(ctx, target, opts)
+	}
+
+	args, err := s.buildArgs(targetType, target, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.run(ctx, args)
+}
+
+// run executes trivy with args and converts its JSON report.
+func (s *LocalScanner) run(ctx context.Context, args []string) (*ScanResult, error) {
 	startTime := time.Now()
 
-	// Build command arguments.
+	// Create context with timeout.
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	// Run trivy.
+	cmd := exec.CommandContext(ctx, s.binary, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		// Check if it's a context timeout.
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("scan timed out after %v", s.timeout)
+		}
+		// Trivy returns non-zero exit code when vulnerabilities are found; that's OK.
+		// Only fail if we got no output.
+		if stdout.Len() == 0 {
+			return nil, fmt.Errorf("trivy scan failed: %w (stderr: %s)", err, stderr.String())
+		}
+	}
+
+	result, err := ParseTrivyJSON(stdout.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	result.ScanTimeMs = float64(time.Since(startTime).Milliseconds())
+
+	return result, nil
+}
+
+// ParseTrivyJSON converts the output of trivy --format json into a
+// ScanResult. Vulnerabilities, secrets, misconfigurations, and licenses are
+// collected from every result target; a report without results is clean.
+// ScannedAt is the report's creation time when present.
+func ParseTrivyJSON(data []byte) (*ScanResult, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("parsing trivy output: empty report")
+	}
+
+	var report TrivyJSONReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("parsing trivy output: %w", err)
+	}
+
+	return convertReport(&report), nil
+}
+
+// buildArgs builds the trivy command line for scanning target with the
+// subcommand for targetType (fs, image, or repo). A SecretConfigPath that
+// cannot be read is reported before trivy runs.
+func (s *LocalScanner) buildArgs(targetType, target string, opts ScanOptions) ([]string, error) {
 	args := []string{
-		"fs",
+		targetType,
 		"--format", "json",
 		"--quiet",
 	}
@@ -142,7 +250,10 @@ func (s *LocalScanner) ScanFS(ctx context.Context, path string, opts ScanOptions
 	// Add scanners.
 	scanners := "vuln"
 	if opts.ScanSecrets {
-		scanners = "vuln,secret"
+		scanners += ",secret"
+	}
+	if opts.ScanLicenses {
+		scanners += ",license"
 	}
 	args = append(args, "--scanners", scanners)
 
@@ -166,46 +277,45 @@ func (s *LocalScanner) ScanFS(ctx context.Context, path string, opts ScanOptions
 		args = append(args, "--skip-db-update")
 	}
 
-	// Add target path.
-	args = append(args, path)
+	// Add custom secret rules.
+	if opts.ScanSecrets && opts.SecretConfigPath != "" {
+		if err := checkReadableFile(opts.SecretConfigPath); err != nil {
+			return nil, fmt.Errorf("secret config: %w", err)
+		}
+		args = append(args, "--secret-config", opts.SecretConfigPath)
+	}
 
-	// Create context with timeout.
-	ctx, cancel := context.WithTimeout(ctx, s.timeout)
-	defer cancel()
+	// Add target.
+	args = append(args, target)
 
-	// Run trivy.
-	cmd := exec.CommandContext(ctx, s.binary, args...)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	return args, nil
+}
 
-	err := cmd.Run()
+// checkReadableFile returns an error unless path is a regular file that
+// can be opened for reading.
+func checkReadableFile(path string) error {
+	f, err := os.Open(path)
 	if err != nil {
-		// Check if it's a context timeout.
-		if ctx.Err() == context.DeadlineExceeded {
-			return nil, fmt.Errorf("scan timed out after %v", s.timeout)
-		}
-		// Trivy returns non-zero exit code when vulnerabilities are found; that's OK.
-		// Only fail if we got no output.
-		if stdout.Len() == 0 {
-			return nil, fmt.Errorf("trivy scan failed: %w (stderr: %s)", err, stderr.String())
-		}
+		return err
 	}
+	defer f.Close()
 
-	// Parse JSON output.
-	var report TrivyJSONReport
-	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
-		return nil, fmt.Errorf("parsing trivy output: %w", err)
+	info, err := f.Stat()
+	if err != nil {
+		return err
 	}
-
-	// Convert to our types.
-	return s.convertReport(&report, startTime), nil
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	return nil
 }
 
 // convertReport converts a Trivy JSON report to our ScanResult.
-func (s *LocalScanner) convertReport(report *TrivyJSONReport, startTime time.Time) *ScanResult {
+func convertReport(report *TrivyJSONReport) *ScanResult {
 	var vulns []Vulnerability
 	var secrets []Secret
+	var misconfigs []Misconfiguration
+	var licenses []License
 	packagesScanned := 0
 
 	for _, result := range report.Results {
@@ -216,16 +326,23 @@ func (s *LocalScanner) convertReport(report *TrivyJSONReport, startTime time.Tim
 
 		// Convert vulnerabilities.
 		for _, v := range result.Vulnerabilities {
+			score, vector := bestCVSS(v.CVSS)
+			vulnEcosystem := ecosystem
+			if result.Type == "" && v.PkgType != "" {
+				vulnEcosystem = mapTypeToEcosystem(v.PkgType)
+			}
 			vulns = append(vulns, Vulnerability{
 				Package:      v.PkgName,
 				Version:      v.InstalledVersion,
-				Ecosystem:    ecosystem,
+				Ecosystem:    vulnEcosystem,
 				CVEID:        v.VulnerabilityID,
 				Severity:     v.Severity,
 				Title:        v.Title,
 				Description:  v.Description,
 				FixedVersion: v.FixedVersion,
 				References:   v.References,
+				CVSSScore:    score,
+				CVSSVector:   vector,
 			})
 		}
 
@@ -242,15 +359,53 @@ func (s *LocalScanner) convertReport(report *TrivyJSONReport, startTime time.Tim
 				Match:     sec.Match,
 			})
 		}
+
+		// Convert failed configuration checks.
+		for _, m := range result.Misconfigurations {
+			if m.Status != "" && m.Status != "FAIL" {
+				continue
+			}
+			id := m.ID
+			if id == "" {
+				id = m.AVDID
+			}
+			misconfigs = append(misconfigs, Misconfiguration{
+				ID:          id,
+				Type:        m.Type,
+				Title:       m.Title,
+				Description: m.Description,
+				Message:     m.Message,
+				Resolution:  m.Resolution,
+				Severity:    m.Severity,
+				Target:      result.Target,
+				StartLine:   m.CauseMetadata.StartLine,
+				EndLine:     m.CauseMetadata.EndLine,
+				PrimaryURL:  m.PrimaryURL,
+			})
+		}
+
+		// Convert licenses.
+		for _, l := range result.Licenses {
+			licenses = append(licenses, l.ToLicense())
+		}
+	}
+
+	vulns = DedupeVulnerabilities(vulns)
+
+	scannedAt := report.CreatedAt
+	if scannedAt.IsZero() {
+		scannedAt = time.Now()
 	}
 
 	return &ScanResult{
-		Summary:         NewScanSummary(vulns, packagesScanned),
-		Vulnerabilities: vulns,
-		Secrets:         secrets,
-		SecretSummary:   NewSecretSummary(secrets),
-		ScannedAt:       time.Now(),
-		ScanTimeMs:      float64(time.Since(startTime).Milliseconds()),
+		Summary:           NewScanSummary(vulns, packagesScanned),
+		Vulnerabilities:   vulns,
+		Secrets:           secrets,
+		SecretSummary:     NewSecretSummary(secrets),
+		Misconfigurations: misconfigs,
+		Licenses:          licenses,
+		LicenseSummary:    NewLicenseSummary(licenses),
+		ScannedAt:         scannedAt,
 	}
 }
 
@@ -307,7 +462,7 @@ func (s *LocalScanner) ScanPath(ctx context.Context, path string, opts ScanOptio
 	}
 
 	// Check if it's an archive.
-	if isArchive(path) {
+	if IsArchive(path) {
 		return s.ScanArchive(ctx, path, opts)
 	}
 
@@ -5,9 +5,12 @@ package trivy
 
 import (
 	"archive/zip"
+	"bytes"
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -109,6 +112,341 @@ func TestLocalScanner_ScanPath_Archive(t *testing.T) {
 	}
 }
 
+func TestLocalScanner_BuildArgs_SecretConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	rules := filepath.Join(dir, "trivy-secret.yaml")
+	content := []byte("rules:\n  - id: hikma-api-key\n    regex: HIKMA-[A-Za-z0-9]{32}\n")
+	if err := os.WriteFile(rules, content, 0o644); err != nil {
+		t.Fatalf("failed to write secret config: %v", err)
+	}
+
+	scanner := NewLocalScanner(LocalScannerConfig{Binary: "trivy"})
+
+	tests := []struct {
+		name     string
+		opts     ScanOptions
+		wantFlag bool
+		wantErr  bool
+	}{
+		{
+			name:     "readable config",
+			opts:     ScanOptions{ScanSecrets: true, SecretConfigPath: rules},
+			wantFlag: true,
+		},
+		{
+			name: "no config",
+			opts: ScanOptions{ScanSecrets: true},
+		},
+		{
+			name: "secrets disabled",
+			opts: ScanOptions{SecretConfigPath: rules},
+		},
+		{
+			name:    "missing config",
+			opts:    ScanOptions{ScanSecrets: true, SecretConfigPath: filepath.Join(dir, "missing.yaml")},
+			wantErr: true,
+		},
+		{
+			name:    "directory",
+			opts:    ScanOptions{ScanSecrets: true, SecretConfigPath: dir},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			args, err := scanner.buildArgs(TargetTypeFS, "/scan/target", tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			gotFlag := false
+			for i, arg := range args {
+				if arg == "--secret-config" {
+					gotFlag = true
+					if i+1 >= len(args) || args[i+1] != rules {
+						t.Errorf("--secret-config not followed by %s: %v", rules, args)
+					}
+				}
+			}
+			if gotFlag != tt.wantFlag {
+				t.Errorf("--secret-config present = %v, want %v: %v", gotFlag, tt.wantFlag, args)
+			}
+			if args[len(args)-1] != "/scan/target" {
+				t.Errorf("target path must be last: %v", args)
+			}
+		})
+	}
+}
+
+func TestLocalScanner_BuildArgs_TargetTypes(t *testing.T) {
+	t.Parallel()
+
+	scanner := NewLocalScanner(LocalScannerConfig{Binary: "trivy", SkipDBUpdate: true})
+	opts := ScanOptions{SeverityFilter: []string{SeverityCritical, SeverityHigh}, ScanSecrets: true}
+
+	tests := []struct {
+		targetType string
+		target     string
+	}{
+		{targetType: TargetTypeFS, target: "/scan/target"},
+		{targetType: TargetTypeImage, target: "alpine:3.19"},
+		{targetType: TargetTypeRepo, target: "https://github.com/org/repo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.targetType, func(t *testing.T) {
+			t.Parallel()
+
+			args, err := scanner.buildArgs(tt.targetType, tt.target, opts)
+			if err != nil {
+				t.Fatalf("buildArgs() error = %v", err)
+			}
+
+			want := []string{
+				tt.targetType,
+				"--format", "json",
+				"--quiet",
+				"--scanners", "vuln,secret",
+				"--severity", "CRITICAL,HIGH",
+				"--skip-db-update",
+				tt.target,
+			}
+			if strings.Join(args, " ") != strings.Join(want, " ") {
+				t.Errorf("buildArgs() = %v, want %v", args, want)
+			}
+		})
+	}
+}
+
+func TestLocalScanner_ScanTarget_Image(t *testing.T) {
+	t.Parallel()
+
+	// A trivy stand-in that logs its arguments and prints a canned report.
+	dir := t.TempDir()
+	invocations := filepath.Join(dir, "invocations.log")
+	binary := filepath.Join(dir, "trivy")
+	report := `{"SchemaVersion":2,"ArtifactName":"alpine:3.19","ArtifactType":"container_image",` +
+		`"Results":[{"Target":"alpine:3.19 (alpine 3.19.0)","Class":"os-pkgs","Type":"alpine",` +
+		`"Packages":[{"ID":"openssl@3.1.4-r1","Name":"openssl","Version":"3.1.4-r1"}],` +
+		`"Vulnerabilities":[{"VulnerabilityID":"CVE-2024-0727","PkgName":"openssl","InstalledVersion":"3.1.4-r1",` +
+		`"FixedVersion":"3.1.4-r5","Severity":"HIGH","Title":"openssl: denial of service via null dereference"}]}]}`
+	script := "#!/bin/sh\n" +
+		"echo \"$@\" >> " + invocations + "\n" +
+		"cat <<'EOF'\n" + report + "\nEOF\n"
+	if err := os.WriteFile(binary, []byte(script), 0o755); err != nil {
+		t.Fatalf("writing fake trivy: %v", err)
+	}
+
+	scanner := NewLocalScanner(LocalScannerConfig{Binary: binary, Timeout: 30 * time.Second})
+	result, err := scanner.ScanTarget(context.Background(), "alpine:3.19", TargetTypeAuto, ScanOptions{})
+	if err != nil {
+		t.Fatalf("ScanTarget() error = %v", err)
+	}
+
+	log, err := os.ReadFile(invocations)
+	if err != nil {
+		t.Fatalf("reading invocation log: %v", err)
+	}
+	if !strings.HasPrefix(string(log), "image ") || !strings.HasSuffix(strings.TrimSpace(string(log)), " alpine:3.19") {
+		t.Errorf("trivy invoked with %q, want image subcommand and alpine:3.19 target", log)
+	}
+
+	if result.Summary.PackagesScanned != 1 {
+		t.Errorf("PackagesScanned = %d, want 1", result.Summary.PackagesScanned)
+	}
+	if len(result.Vulnerabilities) != 1 {
+		t.Fatalf("got %d vulnerabilities, want 1", len(result.Vulnerabilities))
+	}
+	vuln := result.Vulnerabilities[0]
+	if vuln.CVEID != "CVE-2024-0727" || vuln.Ecosystem != "alpine" || vuln.FixedVersion != "3.1.4-r5" {
+		t.Errorf("vulnerability = %+v", vuln)
+	}
+}
+
+func TestParseTrivyJSON_Golden(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"vulnerable_project", "secrets"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := os.ReadFile(filepath.Join("testdata", "trivy", name+".json"))
+			if err != nil {
+				t.Fatalf("reading fixture: %v", err)
+			}
+
+			result, err := ParseTrivyJSON(data)
+			if err != nil {
+				t.Fatalf("ParseTrivyJSON() error = %v", err)
+			}
+
+			got, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", "trivy", name+".golden.json")
+			if *updateGolden {
+				if err := os.WriteFile(golden, append(got, '\n'), 0o644); err != nil {
+					t.Fatalf("writing golden file: %v", err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading golden file: %v", err)
+			}
+			if !bytes.Equal(bytes.TrimSpace(got), bytes.TrimSpace(want)) {
+				t.Errorf("parsed result mismatch (run with -update to refresh)\ngot:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestParseTrivyJSON_VulnerableProject(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "trivy", "vulnerable_project.json"))
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+
+	result, err := ParseTrivyJSON(data)
+	if err != nil {
+		t.Fatalf("ParseTrivyJSON() error = %v", err)
+	}
+
+	if result.Summary.PackagesScanned != 4 {
+		t.Errorf("PackagesScanned = %d, want 4", result.Summary.PackagesScanned)
+	}
+	if result.Summary.TotalVulnerabilities != 3 || result.Summary.Critical != 1 || result.Summary.High != 1 || result.Summary.Medium != 1 {
+		t.Errorf("Summary = %+v, want 1 critical, 1 high, 1 medium", result.Summary)
+	}
+
+	ecosystems := make(map[string]string)
+	for _, v := range result.Vulnerabilities {
+		ecosystems[v.Package] = v.Ecosystem
+	}
+	wantEcosystems := map[string]string{"lodash": EcosystemNpm, "minimist": EcosystemNpm, "requests": EcosystemPip}
+	for pkg, want := range wantEcosystems {
+		if ecosystems[pkg] != want {
+			t.Errorf("%s ecosystem = %q, want %q", pkg, ecosystems[pkg], want)
+		}
+	}
+
+	if len(result.Misconfigurations) != 2 {
+		t.Fatalf("got %d misconfigurations, want 2", len(result.Misconfigurations))
+	}
+	m := result.Misconfigurations[1]
+	if m.ID != "DS005" || m.Target != "Dockerfile" || m.StartLine != 4 || m.EndLine != 4 || m.Severity != SeverityLow {
+		t.Errorf("misconfiguration = %+v", m)
+	}
+	if result.ScannedAt.IsZero() {
+		t.Error("ScannedAt should be taken from the report")
+	}
+}
+
+func TestParseTrivyJSON_Secrets(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "trivy", "secrets.json"))
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+
+	result, err := ParseTrivyJSON(data)
+	if err != nil {
+		t.Fatalf("ParseTrivyJSON() error = %v", err)
+	}
+
+	want := []Secret{
+		{RuleID: "aws-access-key-id", Category: "AWS", Severity: SeverityCritical, Target: "config/deploy.env", StartLine: 3, EndLine: 3},
+		{RuleID: "private-key", Category: "AsymmetricPrivateKey", Severity: SeverityHigh, Target: "config/deploy.env", StartLine: 7, EndLine: 9},
+		{RuleID: "slack-web-hook", Category: "Slack", Severity: SeverityMedium, Target: "config/slack.yaml", StartLine: 2, EndLine: 2},
+	}
+	if len(result.Secrets) != len(want) {
+		t.Fatalf("got %d secrets, want %d", len(result.Secrets), len(want))
+	}
+	for i, w := range want {
+		got := result.Secrets[i]
+		if got.RuleID != w.RuleID || got.Category != w.Category || got.Severity != w.Severity ||
+			got.Target != w.Target || got.StartLine != w.StartLine || got.EndLine != w.EndLine {
+			t.Errorf("secret %d = %+v, want %+v", i, got, w)
+		}
+	}
+
+	summary := result.SecretSummary
+	if summary == nil || summary.TotalSecrets != 3 || summary.Critical != 1 || summary.High != 1 || summary.Medium != 1 {
+		t.Errorf("SecretSummary = %+v", summary)
+	}
+	if len(result.Vulnerabilities) != 0 {
+		t.Errorf("got %d vulnerabilities, want 0", len(result.Vulnerabilities))
+	}
+}
+
+func TestParseTrivyJSON_Edges(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		data      string
+		wantErr   bool
+		wantVulns int
+		wantEco   string
+	}{
+		{name: "empty output", data: "  \n", wantErr: true},
+		{name: "invalid json", data: "trivy: command not found", wantErr: true},
+		{name: "no results", data: `{"SchemaVersion":2,"ArtifactName":"."}`},
+		{name: "null results", data: `{"SchemaVersion":2,"Results":null}`},
+		{
+			name:      "ecosystem from package type",
+			data:      `{"Results":[{"Target":"app","Vulnerabilities":[{"VulnerabilityID":"CVE-1","PkgName":"x","PkgType":"cargo","Severity":"HIGH"}]}]}`,
+			wantVulns: 1,
+			wantEco:   EcosystemCargo,
+		},
+		{
+			name:      "passed misconfigurations skipped",
+			data:      `{"Results":[{"Target":"Dockerfile","Class":"config","Misconfigurations":[{"ID":"DS001","Status":"PASS"}]}]}`,
+			wantVulns: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := ParseTrivyJSON([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTrivyJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(result.Vulnerabilities) != tt.wantVulns {
+				t.Errorf("got %d vulnerabilities, want %d", len(result.Vulnerabilities), tt.wantVulns)
+			}
+			if tt.wantEco != "" && result.Vulnerabilities[0].Ecosystem != tt.wantEco {
+				t.Errorf("Ecosystem = %q, want %q", result.Vulnerabilities[0].Ecosystem, tt.wantEco)
+			}
+			if len(result.Misconfigurations) != 0 || len(result.Secrets) != 0 {
+				t.Errorf("unexpected findings: %+v", result)
+			}
+			if result.ScannedAt.IsZero() {
+				t.Error("ScannedAt should default to now")
+			}
+		})
+	}
+}
+
 func TestMapTypeToEcosystem(t *testing.T) {
 	t.Parallel()
 
@@ -1,13 +1,12 @@
 // ABOUTME: Manifest parser for extracting packages from dependency files
-// ABOUTME: Supports archives (zip, tar.gz) and common package manager manifests
+// ABOUTME: Supports archives (zip, tar.gz, tar.xz, tar.bz2) and common package manager manifests
 
 package trivy
 
 import (
 	"archive/tar"
 	"archive/zip"
-	"bufio"
-	"bytes"
+	"compress/bzip2"
 	"compress/gzip"
 	"encoding/json"
 	"errors"
@@ -16,9 +15,11 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/BurntSushi/toml"
+	"github.com/ulikunitz/xz"
 )
 
 // Manifest file patterns.
@@ -29,15 +30,25 @@ var manifestFiles = map[string]string{
 	"package.json":      "npm",
 	"package-lock.json": "npm",
 	"yarn.lock":         "npm",
+	"pnpm-lock.yaml":    "npm",
 	"go.mod":            "gomod",
 	"go.sum":            "gomod",
 	"Cargo.toml":        "cargo",
 	"Cargo.lock":        "cargo",
 	"composer.json":     "composer",
 	"composer.lock":     "composer",
+	"pom.xml":           "maven",
+	"gradle.lockfile":   "maven",
+	"packages.config":   "nuget",
+	"Gemfile.lock":      "rubygems",
 }
 
-// Directories to skip when scanning.
+// Manifest extensions for files whose names vary by project.
+var manifestExtensions = map[string]string{
+	".csproj": "nuget",
+}
+
+// Directories skipped by default when scanning.
 var skipDirs = map[string]bool{
 	"node_modules": true,
 	"vendor":       true,
@@ -49,14 +60,63 @@ var skipDirs = map[string]bool{
 }
 
 // DetectManifestType returns the ecosystem for a manifest filename.
+// Exact names are matched first, then extensions such as *.csproj.
 func DetectManifestType(filename string) string {
 	base := filepath.Base(filename)
-	return manifestFiles[base]
+	if ecosystem, ok := manifestFiles[base]; ok {
+		return ecosystem
+	}
+	return manifestExtensions[filepath.Ext(base)]
 }
 
-// ScanPathForPackages scans a path (directory or archive) for packages.
+// ScanPackagesOptions narrows which manifests ScanPathForPackagesWithOptions
+// parses.
+type ScanPackagesOptions struct {
+	// Ecosystems limits parsing to manifests of these ecosystems, such as
+	// "gomod" and "npm". Empty means all ecosystems.
+	Ecosystems []string
+
+	// MaxDepth bounds the directory walk; files directly in the scan root
+	// are at depth 1. Zero means unlimited.
+	MaxDepth int
+
+	// SkipDirs names additional directories to skip, such as third_party.
+	SkipDirs []string
+
+	// NoDefaultSkipDirs searches the directories skipped by default, such
+	// as vendor and node_modules, leaving only SkipDirs.
+	NoDefaultSkipDirs bool
+
+	// RequirementsVariants also parses requirements*.txt files.
+	RequirementsVariants bool
+
+	// SniffContent also parses unrecognized lockfile-like files whose
+	// content identifies a manifest format.
+	SniffContent bool
+
+	// SkipGoIndirect drops go.mod requirements marked "// indirect".
+	SkipGoIndirect bool
+}
+
+// ScanPathForPackages scans a path (directory or archive) for packages of
+// every supported ecosystem.
 // If path is an archive, it extracts to a temp directory first.
 func ScanPathForPackages(path string) ([]Package, error) {
+	return ScanPathForPackagesWithOptions(path, ScanPackagesOptions{})
+}
+
+// ScanPathForPackagesWithOptions scans a path (directory or archive) for
+// packages, parsing only the manifests allowed by opts.
+// If path is an archive, it extracts to a temp directory first.
+func ScanPathForPackagesWithOptions(path string, opts ScanPackagesOptions) ([]Package, error) {
+	allowed := make(map[string]bool, len(opts.Ecosystems))
+	for _, ecosystem := range opts.Ecosystems {
+		if !IsValidEcosystem(ecosystem) {
+			return nil, fmt.Errorf("unsupported ecosystem: %s", ecosystem)
+		}
+		allowed[ecosystem] = true
+	}
+
 	info, err := os.Stat(path)
 	if err != nil {
 		return nil, fmt.Errorf("accessing path: %w", err)
@@ -67,7 +127,7 @@ func ScanPathForPackages(path string) ([]Package, error) {
 
 	if info.IsDir() {
 		scanDir = path
-	} else if isArchive(path) {
+	} else if IsArchive(path) {
 		extractDir, err := ExtractArchive(path)
 		if err != nil {
 			return nil, fmt.Errorf("extracting archive: %w", err)
@@ -82,7 +142,15 @@ func ScanPathForPackages(path string) ([]Package, error) {
 		defer cleanup()
 	}
 
-	manifests, err := FindManifests(scanDir)
+	search := ManifestSearchOptions{
+		RequirementsVariants: opts.RequirementsVariants,
+		SniffContent:         opts.SniffContent,
+		MaxDepth:             opts.MaxDepth,
+		SkipDirs:             opts.SkipDirs,
+		NoDefaultSkipDirs:    opts.NoDefaultSkipDirs,
+	}
+
+	manifests, err := FindManifestsWithOptions(scanDir, search)
 	if err != nil {
 		return nil, fmt.Errorf("finding manifests: %w", err)
 	}
@@ -91,7 +159,11 @@ func ScanPathForPackages(path string) ([]Package, error) {
 	seen := make(map[string]bool)
 
 	for _, manifest := range manifests {
-		packages, err := ParseManifest(manifest)
+		if len(allowed) > 0 && !allowed[manifestEcosystem(manifest)] {
+			continue
+		}
+
+		packages, err := parseManifest(manifest, scanDir, opts)
 		if err != nil {
 			continue // Skip unparseable manifests
 		}
@@ -108,9 +180,18 @@ func ScanPathForPackages(path string) ([]Package, error) {
 	return allPackages, nil
 }
 
-// FindManifests recursively finds manifest files in a directory.
+// FindManifests recursively finds manifest files in a directory by their
+// exact names.
 func FindManifests(dir string) ([]string, error) {
+	return FindManifestsWithOptions(dir, ManifestSearchOptions{})
+}
+
+// FindManifestsWithOptions recursively finds manifest files in a directory,
+// also matching requirements*.txt variants and sniffing file content as
+// enabled by opts.
+func FindManifestsWithOptions(dir string, opts ManifestSearchOptions) ([]string, error) {
 	var manifests []string
+	skip := opts.skipSet()
 
 	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
@@ -118,13 +199,21 @@ func FindManifests(dir string) ([]string, error) {
 		}
 
 		if d.IsDir() {
-			if skipDirs[d.Name()] {
+			if path != dir && skip[d.Name()] {
+				return filepath.SkipDir
+			}
+			if opts.MaxDepth > 0 && path != dir && walkDepth(dir, path) >= opts.MaxDepth {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
-		if DetectManifestType(d.Name()) != "" {
+		switch {
+		case DetectManifestType(d.Name()) != "":
+			manifests = append(manifests, path)
+		case opts.RequirementsVariants && isRequirementsVariant(d.Name()):
+			manifests = append(manifests, path)
+		case opts.SniffContent && sniffManifestFile(path) != "":
 			manifests = append(manifests, path)
 		}
 
@@ -135,7 +224,15 @@ func FindManifests(dir string) ([]string, error) {
 }
 
 // ParseManifest parses a manifest file and returns packages.
+// Files with unrecognized names are parsed as requirements files when
+// named requirements*.txt, and otherwise by their sniffed content.
 func ParseManifest(path string) ([]Package, error) {
+	return parseManifest(path, filepath.Dir(path), ScanPackagesOptions{})
+}
+
+// parseManifest parses the manifest at path, confining requirements
+// includes to root and applying the parser settings of opts.
+func parseManifest(path, root string, opts ScanPackagesOptions) ([]Package, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("reading manifest: %w", err)
@@ -143,15 +240,37 @@ func ParseManifest(path string) ([]Package, error) {
 
 	filename := filepath.Base(path)
 
+	if DetectManifestType(filename) == "" {
+		if isRequirementsVariant(filename) {
+			return parseRequirementsFile(path, root, data)
+		}
+		if sniffed := sniffManifestName(data); sniffed != "" {
+			return parseManifestData(path, root, sniffed, data, opts)
+		}
+	}
+
+	return parseManifestData(path, root, filename, data, opts)
+}
+
+// parseManifestData parses manifest data using the parser for filename.
+func parseManifestData(path, root, filename string, data []byte, opts ScanPackagesOptions) ([]Package, error) {
 	switch filename {
 	case "requirements.txt":
-		return ParseRequirementsTxt(data)
+		return parseRequirementsFile(path, root, data)
+	case "poetry.lock":
+		return ParsePoetryLock(data)
+	case "Pipfile.lock":
+		return ParsePipfileLock(data)
 	case "package.json":
 		return ParsePackageJSON(data)
 	case "package-lock.json":
 		return ParsePackageLockJSON(data)
+	case "yarn.lock":
+		return ParseYarnLock(data)
+	case "pnpm-lock.yaml":
+		return ParsePnpmLock(data)
 	case "go.mod":
-		return ParseGoMod(data)
+		return ParseGoModWithOptions(data, GoModOptions{SkipIndirect: opts.SkipGoIndirect})
 	case "Cargo.toml":
 		return ParseCargoToml(data)
 	case "Cargo.lock":
@@ -160,59 +279,24 @@ func ParseManifest(path string) ([]Package, error) {
 		return ParseComposerJSON(data)
 	case "composer.lock":
 		return ParseComposerLock(data)
+	case "pom.xml":
+		return ParsePomXML(data)
+	case "gradle.lockfile":
+		return ParseGradleLockfile(data)
+	case "packages.config":
+		return ParseNuGet(data)
+	case "Gemfile.lock":
+		return ParseGemfileLock(data)
+	}
+
+	switch filepath.Ext(filename) {
+	case ".csproj":
+		return ParseNuGet(data)
 	default:
 		return nil, fmt.Errorf("unsupported manifest: %s", filename)
 	}
 }
 
-// ParseRequirementsTxt parses a Python requirements.txt file.
-func ParseRequirementsTxt(data []byte) ([]Package, error) {
-	var packages []Package
-
-	// Regex for package==version, package>=version, etc.
-	re := regexp.MustCompile(`^([a-zA-Z0-9_-]+)\s*([=<>!~]+)\s*([0-9][^\s#]*)`)
-
-	scanner := bufio.NewScanner(bytes.NewReader(data))
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		// Skip comments, empty lines, editable installs, local paths
-		if line == "" || strings.HasPrefix(line, "#") ||
-			strings.HasPrefix(line, "-e") || strings.HasPrefix(line, "-r") ||
-			strings.HasPrefix(line, ".") || strings.HasPrefix(line, "/") {
-			continue
-		}
-
-		// Remove inline comments
-		if idx := strings.Index(line, "#"); idx != -1 {
-			line = strings.TrimSpace(line[:idx])
-		}
-
-		// Try to parse with version specifier
-		if matches := re.FindStringSubmatch(line); matches != nil {
-			packages = append(packages, Package{
-				Name:      strings.ToLower(matches[1]),
-				Version:   matches[3],
-				Ecosystem: EcosystemPip,
-			})
-			continue
-		}
-
-		// Package without version
-		name := strings.Split(line, "[")[0] // Remove extras like [dev]
-		name = strings.TrimSpace(name)
-		if name != "" && !strings.Contains(name, "/") {
-			packages = append(packages, Package{
-				Name:      strings.ToLower(name),
-				Version:   "latest",
-				Ecosystem: EcosystemPip,
-			})
-		}
-	}
-
-	return packages, scanner.Err()
-}
-
 // ParsePackageJSON parses a Node.js package.json file.
 func ParsePackageJSON(data []byte) ([]Package, error) {
 	var pkg struct {
@@ -226,21 +310,23 @@ func ParsePackageJSON(data []byte) ([]Package, error) {
 
 	var packages []Package
 
-	for name, version := range pkg.Dependencies {
-		packages = append(packages, Package{
-			Name:      name,
-			Version:   cleanNpmVersion(version),
-			Ecosystem: EcosystemNpm,
-		})
+	extract := func(deps map[string]string) {
+		for name, spec := range deps {
+			version := cleanNpmVersion(spec)
+			if version == "" {
+				continue // Skip git, file, and link dependencies
+			}
+			packages = append(packages, Package{
+				Name:       name,
+				Version:    version,
+				Ecosystem:  EcosystemNpm,
+				RawVersion: rawVersion(spec, version),
+			})
+		}
 	}
 
-	for name, version := range pkg.DevDependencies {
-		packages = append(packages, Package{
-			Name:      name,
-			Version:   cleanNpmVersion(version),
-			Ecosystem: EcosystemNpm,
-		})
-	}
+	extract(pkg.Dependencies)
+	extract(pkg.DevDependencies)
 
 	return packages, nil
 }
@@ -296,52 +382,6 @@ func ParsePackageLockJSON(data []byte) ([]Package, error) {
 	return packages, nil
 }
 
-// ParseGoMod parses a Go go.mod file.
-func ParseGoMod(data []byte) ([]Package, error) {
-	var packages []Package
-
-	// Regex for require statements
-	requireRe := regexp.MustCompile(`^\s*([^\s]+)\s+(v[^\s]+)`)
-
-	inRequire := false
-	scanner := bufio.NewScanner(bytes.NewReader(data))
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		if strings.HasPrefix(line, "require (") {
-			inRequire = true
-			continue
-		}
-		if inRequire && line == ")" {
-			inRequire = false
-			continue
-		}
-
-		// Single-line require
-		if strings.HasPrefix(line, "require ") {
-			line = strings.TrimPrefix(line, "require ")
-		} else if !inRequire {
-			continue
-		}
-
-		// Remove // indirect comments
-		if idx := strings.Index(line, "//"); idx != -1 {
-			line = strings.TrimSpace(line[:idx])
-		}
-
-		if matches := requireRe.FindStringSubmatch(line); matches != nil {
-			packages = append(packages, Package{
-				Name:      matches[1],
-				Version:   matches[2],
-				Ecosystem: EcosystemGomod,
-			})
-		}
-	}
-
-	return packages, scanner.Err()
-}
-
 // ParseCargoToml parses a Rust Cargo.toml file.
 func ParseCargoToml(data []byte) ([]Package, error) {
 	var cargo struct {
@@ -424,29 +464,54 @@ func ParseComposerJSON(data []byte) ([]Package, error) {
 		if name == "php" || strings.HasPrefix(name, "ext-") {
 			continue // Skip PHP and extensions
 		}
+		cleaned := cleanComposerVersion(version)
 		packages = append(packages, Package{
-			Name:      name,
-			Version:   cleanComposerVersion(version),
-			Ecosystem: EcosystemComposer,
+			Name:       name,
+			Version:    cleaned,
+			Ecosystem:  EcosystemComposer,
+			RawVersion: rawVersion(version, cleaned),
 		})
 	}
 
 	for name, version := range composer.RequireDev {
+		cleaned := cleanComposerVersion(version)
 		packages = append(packages, Package{
-			Name:      name,
-			Version:   cleanComposerVersion(version),
-			Ecosystem: EcosystemComposer,
+			Name:       name,
+			Version:    cleaned,
+			Ecosystem:  EcosystemComposer,
+			RawVersion: rawVersion(version, cleaned),
 		})
 	}
 
 	return packages, nil
 }
 
+// composerLockPackage is a single entry in composer.lock.
+type composerLockPackage struct {
+	Name    string              `json:"name"`
+	Version string              `json:"version"`
+	Source  *composerPackageRef `json:"source,omitempty"`
+	Dist    *composerPackageRef `json:"dist,omitempty"`
+}
+
+// composerPackageRef records where a locked package was fetched from.
+type composerPackageRef struct {
+	Type      string `json:"type"`
+	URL       string `json:"url"`
+	Reference string `json:"reference"`
+}
+
+// isLocalPath reports whether the package was installed from a local path
+// repository, which has no published version to scan.
+func (p composerLockPackage) isLocalPath() bool {
+	return (p.Dist != nil && p.Dist.Type == "path") || (p.Source != nil && p.Source.Type == "path")
+}
+
 // ParseComposerLock parses a PHP composer.lock file.
 func ParseComposerLock(data []byte) ([]Package, error) {
 	var lock struct {
-		Packages    []struct{ Name, Version string } `json:"packages"`
-		PackagesDev []struct{ Name, Version string } `json:"packages-dev"`
+		Packages    []composerLockPackage `json:"packages"`
+		PackagesDev []composerLockPackage `json:"packages-dev"`
 	}
 
 	if err := json.Unmarshal(data, &lock); err != nil {
@@ -455,29 +520,70 @@ func ParseComposerLock(data []byte) ([]Package, error) {
 
 	var packages []Package
 
-	for _, pkg := range lock.Packages {
-		packages = append(packages, Package{
-			Name:      pkg.Name,
-			Version:   pkg.Version,
-			Ecosystem: EcosystemComposer,
-		})
+	extract := func(entries []composerLockPackage) {
+		for _, pkg := range entries {
+			if pkg.Name == "" || pkg.Version == "" || pkg.isLocalPath() {
+				continue
+			}
+			packages = append(packages, Package{
+				Name:      pkg.Name,
+				Version:   strings.TrimPrefix(pkg.Version, "v"),
+				Ecosystem: EcosystemComposer,
+			})
+		}
 	}
 
-	for _, pkg := range lock.PackagesDev {
-		packages = append(packages, Package{
-			Name:      pkg.Name,
-			Version:   pkg.Version,
-			Ecosystem: EcosystemComposer,
-		})
-	}
+	extract(lock.Packages)
+	extract(lock.PackagesDev)
 
 	return packages, nil
 }
 
-// ExtractArchive extracts an archive to a temporary directory.
-// Supports zip, tar, tar.gz, and tgz formats.
+// Default extraction limits applied by ExtractArchive.
+const (
+	DefaultMaxExtractTotalBytes int64 = 2 << 30 // 2GB
+	DefaultMaxExtractFiles            = 50000
+	DefaultMaxExtractFileBytes  int64 = 512 << 20 // 512MB
+)
+
+// ErrExtractionLimit is returned when an archive exceeds an extraction limit.
+var ErrExtractionLimit = errors.New("archive extraction limit exceeded")
+
+// ExtractOptions bounds archive extraction to guard against decompression bombs.
+// A zero value for any field disables that limit.
+type ExtractOptions struct {
+	// MaxTotalBytes caps the total uncompressed size of all extracted files.
+	MaxTotalBytes int64
+
+	// MaxFiles caps the number of extracted entries (files and directories).
+	MaxFiles int
+
+	// MaxFileBytes caps the uncompressed size of any single file.
+	MaxFileBytes int64
+}
+
+// DefaultExtractOptions returns the limits used by ExtractArchive.
+func DefaultExtractOptions() ExtractOptions {
+	return ExtractOptions{
+		MaxTotalBytes: DefaultMaxExtractTotalBytes,
+		MaxFiles:      DefaultMaxExtractFiles,
+		MaxFileBytes:  DefaultMaxExtractFileBytes,
+	}
+}
+
+// ExtractArchive extracts an archive to a temporary directory using
+// DefaultExtractOptions.
+// Supports zip, tar, tar.gz, tgz, tar.xz, txz, tar.bz2, and tbz2 formats.
 // Returns the path to the extracted directory; caller must clean up.
 func ExtractArchive(path string) (string, error) {
+	return ExtractArchiveWithOptions(path, DefaultExtractOptions())
+}
+
+// ExtractArchiveWithOptions extracts an archive to a temporary directory,
+// aborting with ErrExtractionLimit if any limit in opts is exceeded.
+// The partially extracted directory is removed on failure.
+// Returns the path to the extracted directory; caller must clean up.
+func ExtractArchiveWithOptions(path string, opts ExtractOptions) (string, error) {
 	ext := strings.ToLower(filepath.Ext(path))
 	name := strings.ToLower(filepath.Base(path))
 
@@ -486,15 +592,21 @@ func ExtractArchive(path string) (string, error) {
 		return "", fmt.Errorf("creating temp dir: %w", err)
 	}
 
+	x := &extractor{dest: extractDir, opts: opts}
+
 	var extractErr error
 
 	switch {
 	case ext == ".zip":
-		extractErr = extractZip(path, extractDir)
+		extractErr = x.extractZip(path)
 	case ext == ".gz" || strings.HasSuffix(name, ".tar.gz") || ext == ".tgz":
-		extractErr = extractTarGz(path, extractDir)
+		extractErr = x.extractTarGz(path)
+	case ext == ".xz" || ext == ".txz":
+		extractErr = x.extractTarXz(path)
+	case ext == ".bz2" || ext == ".tbz2" || ext == ".tbz":
+		extractErr = x.extractTarBz2(path)
 	case ext == ".tar":
-		extractErr = extractTar(path, extractDir)
+		extractErr = x.extractTar(path)
 	default:
 		os.RemoveAll(extractDir)
 		return "", fmt.Errorf("unsupported archive format: %s", ext)
@@ -508,7 +620,80 @@ func ExtractArchive(path string) (string, error) {
 	return extractDir, nil
 }
 
-func extractZip(src, dest string) error {
+// extractor writes archive entries to dest while enforcing ExtractOptions.
+type extractor struct {
+	dest  string
+	opts  ExtractOptions
+	files int
+	total int64
+}
+
+// addEntry accounts for one extracted entry against MaxFiles.
+func (x *extractor) addEntry(name string) error {
+	x.files++
+	if x.opts.MaxFiles > 0 && x.files > x.opts.MaxFiles {
+		return fmt.Errorf("%w: more than %d files (at %s)", ErrExtractionLimit, x.opts.MaxFiles, name)
+	}
+	return nil
+}
+
+// writeFile copies src to path, enforcing MaxFileBytes and MaxTotalBytes.
+// Sizes are measured on the decompressed stream rather than trusted from
+// archive headers.
+func (x *extractor) writeFile(path, name string, src io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	outFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	// Read at most one byte past the tightest limit to detect overflow
+	limit := int64(-1)
+	if x.opts.MaxFileBytes > 0 {
+		limit = x.opts.MaxFileBytes
+	}
+	if x.opts.MaxTotalBytes > 0 {
+		remaining := x.opts.MaxTotalBytes - x.total
+		if limit < 0 || remaining < limit {
+			limit = remaining
+		}
+	}
+
+	var n int64
+	if limit < 0 {
+		n, err = io.Copy(outFile, src)
+	} else {
+		n, err = io.Copy(outFile, io.LimitReader(src, limit+1))
+	}
+	x.total += n
+	if err != nil {
+		return err
+	}
+
+	if x.opts.MaxFileBytes > 0 && n > x.opts.MaxFileBytes {
+		return fmt.Errorf("%w: %s exceeds %d bytes", ErrExtractionLimit, name, x.opts.MaxFileBytes)
+	}
+	if x.opts.MaxTotalBytes > 0 && x.total > x.opts.MaxTotalBytes {
+		return fmt.Errorf("%w: total size exceeds %d bytes", ErrExtractionLimit, x.opts.MaxTotalBytes)
+	}
+
+	return nil
+}
+
+// safePath joins name onto dest, reporting false for paths that escape it.
+func (x *extractor) safePath(name string) (string, bool) {
+	path := filepath.Join(x.dest, name)
+	if !strings.HasPrefix(filepath.Clean(path), filepath.Clean(x.dest)+string(os.PathSeparator)) {
+		return "", false
+	}
+	return path, true
+}
+
+func (x *extractor) extractZip(src string) error {
 	r, err := zip.OpenReader(src)
 	if err != nil {
 		return fmt.Errorf("opening zip: %w", err)
@@ -516,7 +701,7 @@ func extractZip(src, dest string) error {
 	defer r.Close()
 
 	for _, f := range r.File {
-		if err := extractZipFile(f, dest); err != nil {
+		if err := x.extractZipFile(f); err != nil {
 			return err
 		}
 	}
@@ -524,19 +709,19 @@ func extractZip(src, dest string) error {
 	return nil
 }
 
-func extractZipFile(f *zip.File, dest string) error {
+func (x *extractor) extractZipFile(f *zip.File) error {
 	// Prevent zip slip
-	path := filepath.Join(dest, f.Name)
-	if !strings.HasPrefix(filepath.Clean(path), filepath.Clean(dest)+string(os.PathSeparator)) {
+	path, ok := x.safePath(f.Name)
+	if !ok {
 		return fmt.Errorf("invalid file path: %s", f.Name)
 	}
 
-	if f.FileInfo().IsDir() {
-		return os.MkdirAll(path, 0o755)
+	if err := x.addEntry(f.Name); err != nil {
+		return err
 	}
 
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		return err
+	if f.FileInfo().IsDir() {
+		return os.MkdirAll(path, 0o755)
 	}
 
 	rc, err := f.Open()
@@ -545,17 +730,10 @@ func extractZipFile(f *zip.File, dest string) error {
 	}
 	defer rc.Close()
 
-	outFile, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer outFile.Close()
-
-	_, err = io.Copy(outFile, rc)
-	return err
+	return x.writeFile(path, f.Name, rc)
 }
 
-func extractTarGz(src, dest string) error {
+func (x *extractor) extractTarGz(src string) error {
 	file, err := os.Open(src)
 	if err != nil {
 		return fmt.Errorf("opening file: %w", err)
@@ -568,20 +746,45 @@ func extractTarGz(src, dest string) error {
 	}
 	defer gzr.Close()
 
-	return extractTarReader(tar.NewReader(gzr), dest)
+	return x.extractTarReader(tar.NewReader(gzr))
+}
+
+func (x *extractor) extractTarXz(src string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+
+	xzr, err := xz.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("creating xz reader: %w", err)
+	}
+
+	return x.extractTarReader(tar.NewReader(xzr))
+}
+
+func (x *extractor) extractTarBz2(src string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+
+	return x.extractTarReader(tar.NewReader(bzip2.NewReader(file)))
 }
 
-func extractTar(src, dest string) error {
+func (x *extractor) extractTar(src string) error {
 	file, err := os.Open(src)
 	if err != nil {
 		return fmt.Errorf("opening file: %w", err)
 	}
 	defer file.Close()
 
-	return extractTarReader(tar.NewReader(file), dest)
+	return x.extractTarReader(tar.NewReader(file))
 }
 
-func extractTarReader(tr *tar.Reader, dest string) error {
+func (x *extractor) extractTarReader(tr *tar.Reader) error {
 	for {
 		header, err := tr.Next()
 		if err == io.EOF {
@@ -592,54 +795,193 @@ func extractTarReader(tr *tar.Reader, dest string) error {
 		}
 
 		// Prevent path traversal
-		path := filepath.Join(dest, header.Name)
-		if !strings.HasPrefix(filepath.Clean(path), filepath.Clean(dest)+string(os.PathSeparator)) {
+		path, ok := x.safePath(header.Name)
+		if !ok {
 			continue // Skip invalid paths
 		}
 
 		switch header.Typeflag {
 		case tar.TypeDir:
-			if err := os.MkdirAll(path, 0o755); err != nil {
+			if err := x.addEntry(header.Name); err != nil {
 				return err
 			}
-		case tar.TypeReg:
-			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			if err := os.MkdirAll(path, 0o755); err != nil {
 				return err
 			}
-			outFile, err := os.Create(path)
-			if err != nil {
+		case tar.TypeReg:
+			if err := x.addEntry(header.Name); err != nil {
 				return err
 			}
-			if _, err := io.Copy(outFile, tr); err != nil {
-				outFile.Close()
+			if err := x.writeFile(path, header.Name, tr); err != nil {
 				return err
 			}
-			outFile.Close()
 		}
 	}
 
 	return nil
 }
 
-// isArchive checks if a file is a supported archive format.
-func isArchive(path string) bool {
+// IsArchive reports whether path names an archive ExtractArchive can unpack,
+// judged by its extension (including two-part ones such as .tar.gz).
+func IsArchive(path string) bool {
 	ext := strings.ToLower(filepath.Ext(path))
 	name := strings.ToLower(filepath.Base(path))
 
 	return ext == ".zip" || ext == ".tar" || ext == ".gz" ||
-		ext == ".tgz" || strings.HasSuffix(name, ".tar.gz")
+		ext == ".tgz" || strings.HasSuffix(name, ".tar.gz") ||
+		ext == ".xz" || ext == ".txz" ||
+		ext == ".bz2" || ext == ".tbz2" || ext == ".tbz"
+}
+
+// npmNonRegistryPrefixes identify package.json specs that do not resolve to
+// a registry version (git, tarball URLs, local paths, workspaces).
+var npmNonRegistryPrefixes = []string{
+	"git+", "git:", "git@", "github:", "gitlab:", "bitbucket:", "gist:",
+	"file:", "link:", "portal:", "workspace:", "http://", "https://",
+	".", "/", "~/",
 }
 
-// cleanNpmVersion removes version prefixes like ^, ~, >=.
+// npmVersionRe matches a (possibly partial or x-range) semver version.
+var npmVersionRe = regexp.MustCompile(`^v?(\d+|[xX*])(?:\.(\d+|[xX*]))?(?:\.(\d+|[xX*]))?([-+][0-9A-Za-z.+-]*)?$`)
+
+// cleanNpmVersion reduces a package.json version spec to a concrete version.
+// Ranges resolve to their lowest bound ("1.2.3 - 2.0.0" and ">=1.2.3 <2"
+// become "1.2.3"), wildcards and dist-tags become "latest", and specs that
+// do not refer to the registry (git, file, link, URLs) return "" so callers
+// can drop them.
 func cleanNpmVersion(version string) string {
-	version = strings.TrimPrefix(version, "^")
-	version = strings.TrimPrefix(version, "~")
-	version = strings.TrimPrefix(version, ">=")
-	version = strings.TrimPrefix(version, ">")
-	version = strings.TrimPrefix(version, "<=")
-	version = strings.TrimPrefix(version, "<")
-	version = strings.TrimPrefix(version, "=")
-	return version
+	version = strings.TrimSpace(version)
+
+	for _, prefix := range npmNonRegistryPrefixes {
+		if strings.HasPrefix(version, prefix) {
+			return ""
+		}
+	}
+
+	// npm aliases: "npm:other-package@^1.2.3"
+	if strings.HasPrefix(version, "npm:") {
+		alias := strings.TrimPrefix(version, "npm:")
+		idx := strings.LastIndex(alias, "@")
+		if idx <= 0 {
+			return "latest"
+		}
+		version = alias[idx+1:]
+	}
+
+	// GitHub shorthand: "user/repo" or "user/repo#ref"
+	if strings.Contains(version, "/") {
+		return ""
+	}
+
+	lowest := ""
+	for _, alternative := range strings.Split(version, "||") {
+		bound, ok := npmLowerBound(strings.TrimSpace(alternative))
+		if !ok {
+			continue
+		}
+		if bound == "latest" {
+			return "latest" // A wildcard alternative matches anything
+		}
+		if lowest == "" || compareVersions(bound, lowest) < 0 {
+			lowest = bound
+		}
+	}
+
+	if lowest == "" {
+		return "latest"
+	}
+	return lowest
+}
+
+// npmLowerBound returns the lowest version satisfying a single npm range
+// (no "||"). It reports false when the range has no usable lower bound.
+func npmLowerBound(spec string) (string, bool) {
+	if spec == "" || spec == "*" || spec == "latest" || spec == "x" || spec == "X" {
+		return "latest", true
+	}
+
+	// Hyphen range: "1.2.3 - 2.0.0"
+	if lo, _, found := strings.Cut(spec, " - "); found {
+		return normalizeNpmVersion(strings.TrimSpace(lo))
+	}
+
+	// Comparator set: ">=1.0.0 <2.0.0"; the lower bound is any non-"<" comparator
+	for _, comparator := range strings.Fields(spec) {
+		if strings.HasPrefix(comparator, "<") {
+			continue
+		}
+		comparator = strings.TrimLeft(comparator, "^~>=v")
+		if v, ok := normalizeNpmVersion(comparator); ok {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+// normalizeNpmVersion validates a version and replaces x-range components
+// with zero ("1.x" becomes "1.0.0"). A bare wildcard becomes "latest".
+// Dist-tags such as "next" or "beta" are also reported as "latest".
+func normalizeNpmVersion(v string) (string, bool) {
+	m := npmVersionRe.FindStringSubmatch(v)
+	if m == nil {
+		if v != "" && !strings.ContainsAny(v, "<>=^~ ") {
+			return "latest", true // dist-tag
+		}
+		return "", false
+	}
+
+	if isNpmWildcard(m[1]) {
+		return "latest", true
+	}
+
+	// Keep fully specified versions as written (minus a leading "v")
+	if m[2] != "" && m[3] != "" && !isNpmWildcard(m[2]) && !isNpmWildcard(m[3]) {
+		return strings.TrimPrefix(v, "v"), true
+	}
+
+	parts := []string{m[1], m[2], m[3]}
+	if m[2] == "" && m[3] == "" {
+		// "1" is equivalent to "1.x"
+		parts[1], parts[2] = "0", "0"
+	}
+	for i, p := range parts {
+		if p == "" || isNpmWildcard(p) {
+			parts[i] = "0"
+		}
+	}
+
+	return strings.Join(parts, "."), true
+}
+
+// isNpmWildcard reports whether a version component is an x-range wildcard.
+func isNpmWildcard(s string) bool {
+	return s == "x" || s == "X" || s == "*"
+}
+
+// compareVersions compares two dotted numeric versions component by
+// component, returning -1, 0, or 1. Non-numeric suffixes are ignored.
+func compareVersions(a, b string) int {
+	pa := strings.Split(strings.SplitN(a, "-", 2)[0], ".")
+	pb := strings.Split(strings.SplitN(b, "-", 2)[0], ".")
+
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			nb, _ = strconv.Atoi(pb[i])
+		}
+		if na != nb {
+			if na < nb {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
 }
 
 // cleanComposerVersion removes version prefixes.
@@ -0,0 +1,259 @@
+// ABOUTME: Content-based manifest detection for renamed or nonstandard lockfiles
+// ABOUTME: Sniffs the leading bytes of a file to infer its manifest format and ecosystem
+
+package trivy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// sniffBytes is how much of a file is inspected by content detection.
+const sniffBytes = 8 << 10 // 8KB
+
+// sniffExtensions are the extensions of files worth sniffing when their
+// name is not a known manifest. Other files are never opened.
+var sniffExtensions = map[string]bool{
+	".lock":   true,
+	".json":   true,
+	".toml":   true,
+	".yaml":   true,
+	".yml":    true,
+	".txt":    true,
+	".xml":    true,
+	".mod":    true,
+	".config": true,
+}
+
+var (
+	// requirementsVariantRe matches requirements*.txt names such as
+	// requirements-dev.txt and requirements_test.txt.
+	requirementsVariantRe = regexp.MustCompile(`^requirements[\w.-]*\.txt$`)
+
+	// goDirectiveRe matches the go version directive of a go.mod file.
+	goDirectiveRe = regexp.MustCompile(`(?m)^go 1\.\d+`)
+
+	// goModuleRe matches the module directive of a go.mod file.
+	goModuleRe = regexp.MustCompile(`(?m)^module \S+`)
+)
+
+// ManifestSearchOptions widens manifest discovery beyond exact filenames.
+// The zero value matches exact manifest names only, since variants and
+// sniffed files can pull unrelated fixtures and examples into a scan.
+type ManifestSearchOptions struct {
+	// RequirementsVariants also matches requirements*.txt files.
+	RequirementsVariants bool
+
+	// SniffContent inspects files with lockfile-like extensions whose
+	// names are unknown and keeps those DetectManifestContent recognizes.
+	SniffContent bool
+
+	// MaxDepth bounds the directory walk; files directly in the searched
+	// directory are at depth 1. Zero means unlimited.
+	MaxDepth int
+
+	// SkipDirs names additional directories to skip, such as third_party.
+	SkipDirs []string
+
+	// NoDefaultSkipDirs searches the directories skipped by default, such
+	// as vendor and node_modules, leaving only SkipDirs.
+	NoDefaultSkipDirs bool
+}
+
+// skipSet returns the directory names skipped during the walk.
+func (o ManifestSearchOptions) skipSet() map[string]bool {
+	skip := make(map[string]bool, len(skipDirs)+len(o.SkipDirs))
+	if !o.NoDefaultSkipDirs {
+		for name := range skipDirs {
+			skip[name] = true
+		}
+	}
+	for _, name := range o.SkipDirs {
+		skip[name] = true
+	}
+	return skip
+}
+
+// DetectManifestContent returns the ecosystem of manifest content by
+// inspecting its leading bytes, for files whose name is not recognized.
+// Returns an empty string if the format cannot be inferred.
+func DetectManifestContent(data []byte) string {
+	return manifestFiles[sniffManifestName(data)]
+}
+
+// manifestEcosystem returns the ecosystem of the manifest at path, by name
+// first and then by content, or an empty string if it is not recognized.
+func manifestEcosystem(path string) string {
+	if ecosystem := DetectManifestType(path); ecosystem != "" {
+		return ecosystem
+	}
+	if isRequirementsVariant(path) {
+		return EcosystemPip
+	}
+	return manifestFiles[sniffManifestFile(path)]
+}
+
+// walkDepth returns how many directory levels path is below root.
+func walkDepth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// isRequirementsVariant reports whether filename looks like a pip
+// requirements file other than requirements.txt itself.
+func isRequirementsVariant(filename string) bool {
+	return requirementsVariantRe.MatchString(filepath.Base(filename))
+}
+
+// sniffManifestName infers the canonical manifest filename for data, such
+// as "Cargo.lock" for a TOML file of [[package]] tables. The result
+// selects the parser used for content-detected manifests.
+func sniffManifestName(data []byte) string {
+	if len(data) > sniffBytes {
+		data = data[:sniffBytes]
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // UTF-8 BOM
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return ""
+	}
+
+	switch trimmed[0] {
+	case '{':
+		return sniffJSONManifest(trimmed)
+	case '<':
+		return sniffXMLManifest(trimmed)
+	}
+
+	text := string(data)
+
+	switch {
+	case strings.Contains(text, "[[package]]"):
+		// Poetry records Python metadata; Cargo does not
+		if strings.Contains(text, "python-versions") || strings.Contains(text, "[package.dependencies]") {
+			return "poetry.lock"
+		}
+		return "Cargo.lock"
+	case goModuleRe.MatchString(text) && goDirectiveRe.MatchString(text):
+		return "go.mod"
+	case hasLinePrefix(text, "lockfileVersion:"):
+		return "pnpm-lock.yaml"
+	case strings.Contains(text, "# yarn lockfile v1") || hasLinePrefix(text, "__metadata:"):
+		return "yarn.lock"
+	case hasLinePrefix(text, "GEM") && strings.Contains(text, "  specs:"):
+		return "Gemfile.lock"
+	case strings.Contains(text, "# This is a Gradle generated file"):
+		return "gradle.lockfile"
+	}
+
+	return ""
+}
+
+// sniffJSONManifest identifies JSON manifests by their top-level keys.
+// Truncated documents are decoded key by key so large lockfiles can still
+// be recognized from their first bytes.
+func sniffJSONManifest(data []byte) string {
+	keys := jsonTopLevelKeys(data)
+
+	switch {
+	case keys["lockfileVersion"]:
+		return "package-lock.json"
+	case keys["_meta"] && (keys["default"] || keys["develop"]):
+		return "Pipfile.lock"
+	case keys["content-hash"] && (keys["packages"] || keys["packages-dev"]):
+		return "composer.lock"
+	case keys["require"] || keys["require-dev"]:
+		return "composer.json"
+	case keys["dependencies"] || keys["devDependencies"]:
+		return "package.json"
+	}
+
+	return ""
+}
+
+// jsonTopLevelKeys returns the keys of the top-level JSON object in data,
+// stopping silently at the first decoding error.
+func jsonTopLevelKeys(data []byte) map[string]bool {
+	keys := make(map[string]bool)
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := decoder.Token(); err != nil || tok != json.Delim('{') {
+		return keys
+	}
+
+	for decoder.More() {
+		tok, err := decoder.Token()
+		if err != nil {
+			return keys
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return keys
+		}
+		keys[key] = true
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return keys
+		}
+	}
+
+	return keys
+}
+
+// sniffXMLManifest identifies NuGet and Maven XML manifests.
+func sniffXMLManifest(data []byte) string {
+	text := string(data)
+
+	switch {
+	case strings.Contains(text, "<PackageReference") || strings.Contains(text, "<packages>"):
+		return "packages.config"
+	case strings.Contains(text, "<project") && strings.Contains(text, "<artifactId>"):
+		return "pom.xml"
+	}
+
+	return ""
+}
+
+// hasLinePrefix reports whether any line of text starts with prefix.
+func hasLinePrefix(text, prefix string) bool {
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// sniffManifestFile reads the head of the file at path and returns its
+// inferred manifest name, or an empty string if it is not recognized.
+func sniffManifestFile(path string) string {
+	if !sniffExtensions[strings.ToLower(filepath.Ext(path))] {
+		return ""
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	head := make([]byte, sniffBytes)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return ""
+	}
+
+	return sniffManifestName(head[:n])
+}
@@ -0,0 +1,248 @@
+// ABOUTME: Unit tests for content-based manifest detection
+// ABOUTME: Covers sniffed formats, requirements variants, and renamed lockfiles
+
+package trivy
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestDetectManifestContent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "cargo lock",
+			content: "version = 3\n\n[[package]]\nname = \"serde\"\nversion = \"1.0.188\"\nsource = \"registry+https://github.com/rust-lang/crates.io-index\"\n",
+			want:    EcosystemCargo,
+		},
+		{
+			name:    "poetry lock",
+			content: "[[package]]\nname = \"requests\"\nversion = \"2.31.0\"\npython-versions = \">=3.7\"\n",
+			want:    EcosystemPip,
+		},
+		{
+			name:    "go module",
+			content: "module example.com/app\n\ngo 1.22\n\nrequire golang.org/x/net v0.23.0\n",
+			want:    EcosystemGomod,
+		},
+		{
+			name:    "package lock",
+			content: `{"name": "app", "lockfileVersion": 3, "packages": {}}`,
+			want:    EcosystemNpm,
+		},
+		{
+			name:    "package json",
+			content: `{"name": "app", "dependencies": {"lodash": "^4.17.21"}}`,
+			want:    EcosystemNpm,
+		},
+		{
+			name:    "composer lock",
+			content: `{"content-hash": "abc", "packages": []}`,
+			want:    EcosystemComposer,
+		},
+		{
+			name:    "pipfile lock",
+			content: `{"_meta": {"pipfile-spec": 6}, "default": {}}`,
+			want:    EcosystemPip,
+		},
+		{
+			name:    "truncated json",
+			content: `{"lockfileVersion": 2, "packages": {"node_modules/lodash": {"version": "4.17`,
+			want:    EcosystemNpm,
+		},
+		{
+			name:    "pnpm lock",
+			content: "lockfileVersion: '6.0'\n\npackages:\n",
+			want:    EcosystemNpm,
+		},
+		{
+			name:    "gemfile lock",
+			content: "GEM\n  remote: https://rubygems.org/\n  specs:\n    rake (13.0.6)\n",
+			want:    EcosystemRubygems,
+		},
+		{
+			name:    "csproj",
+			content: "<Project Sdk=\"Microsoft.NET.Sdk\">\n  <ItemGroup>\n    <PackageReference Include=\"Serilog\" Version=\"3.1.1\" />\n",
+			want:    EcosystemNuget,
+		},
+		{
+			name:    "pom",
+			content: "<?xml version=\"1.0\"?>\n<project>\n  <artifactId>app</artifactId>\n</project>\n",
+			want:    EcosystemMaven,
+		},
+		{
+			name:    "unrelated json",
+			content: `{"compilerOptions": {"strict": true}}`,
+			want:    "",
+		},
+		{
+			name:    "plain text",
+			content: "just some notes about the project\n",
+			want:    "",
+		},
+		{
+			name:    "empty",
+			content: "",
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := DetectManifestContent([]byte(tt.content)); got != tt.want {
+				t.Errorf("DetectManifestContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRequirementsVariant(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filename string
+		want     bool
+	}{
+		{"requirements-dev.txt", true},
+		{"requirements_test.txt", true},
+		{"requirements.prod.txt", true},
+		{"requirements.txt", true},
+		{"requirements.in", false},
+		{"dev-requirements.txt", false},
+		{"notes.txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			t.Parallel()
+
+			if got := isRequirementsVariant(tt.filename); got != tt.want {
+				t.Errorf("isRequirementsVariant(%q) = %v, want %v", tt.filename, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindManifestsWithOptions(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"package.json":         `{"dependencies": {"lodash": "4.17.21"}}`,
+		"requirements-dev.txt": "pytest==7.4.0\n",
+		"deps.lock":            "version = 3\n\n[[package]]\nname = \"serde\"\nversion = \"1.0.188\"\n",
+		"notes.txt":            "nothing to see here\n",
+		"image.png":            "[[package]]\n",
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+	}
+
+	tests := []struct {
+		name string
+		opts ManifestSearchOptions
+		want []string
+	}{
+		{
+			name: "exact names only",
+			opts: ManifestSearchOptions{},
+			want: []string{"package.json"},
+		},
+		{
+			name: "requirements variants",
+			opts: ManifestSearchOptions{RequirementsVariants: true},
+			want: []string{"package.json", "requirements-dev.txt"},
+		},
+		{
+			name: "variants and sniffing",
+			opts: ManifestSearchOptions{RequirementsVariants: true, SniffContent: true},
+			want: []string{"deps.lock", "package.json", "requirements-dev.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			manifests, err := FindManifestsWithOptions(dir, tt.opts)
+			if err != nil {
+				t.Fatalf("FindManifestsWithOptions() error = %v", err)
+			}
+
+			var got []string
+			for _, m := range manifests {
+				got = append(got, filepath.Base(m))
+			}
+			sort.Strings(got)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("manifests = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("manifests = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestScanPath_RenamedManifests(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "requirements-dev.txt"), []byte("pytest==7.4.0\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "deps.lock"), []byte(`version = 3
+
+[[package]]
+name = "serde"
+version = "1.0.188"
+`), 0o644)
+	// Ambiguous name whose content is an npm lockfile
+	os.WriteFile(filepath.Join(dir, "packages.json"), []byte(`{
+  "name": "app",
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "app"},
+    "node_modules/lodash": {"version": "4.17.21"}
+  }
+}`), 0o644)
+
+	packages, err := ScanPathForPackages(dir)
+	if err != nil {
+		t.Fatalf("ScanPathForPackages() error = %v", err)
+	}
+	if len(packages) != 0 {
+		t.Errorf("expected no packages without opt-in, got %v", packages)
+	}
+
+	packages, err = ScanPathForPackagesWithOptions(dir, ScanPackagesOptions{RequirementsVariants: true, SniffContent: true})
+	if err != nil {
+		t.Fatalf("ScanPathForPackagesWithOptions() error = %v", err)
+	}
+
+	want := map[string]string{
+		"pytest": EcosystemPip,
+		"serde":  EcosystemCargo,
+		"lodash": EcosystemNpm,
+	}
+
+	if len(packages) != len(want) {
+		t.Errorf("expected %d packages, got %d: %v", len(want), len(packages), packages)
+	}
+	for _, p := range packages {
+		if eco, ok := want[p.Name]; !ok || p.Ecosystem != eco {
+			t.Errorf("unexpected package %s (%s)", p.Name, p.Ecosystem)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// ABOUTME: Go module parser for go.mod require, replace, and exclude directives
+// ABOUTME: Reports modules at their effective version after replacements and exclusions
+
+package trivy
+
+import (
+	"bufio"
+	"bytes"
+	"slices"
+	"strings"
+)
+
+// GoModOptions controls which requirements ParseGoModWithOptions reports.
+type GoModOptions struct {
+	// SkipIndirect drops requirements marked "// indirect".
+	SkipIndirect bool
+}
+
+// goModRequire is a single require directive entry.
+type goModRequire struct {
+	path     string
+	version  string
+	indirect bool
+}
+
+// goModReplace is a single replace directive entry. An empty oldVersion
+// replaces every version of oldPath; an empty newVersion means the
+// replacement is a local directory.
+type goModReplace struct {
+	oldPath    string
+	oldVersion string
+	newPath    string
+	newVersion string
+}
+
+// ParseGoMod parses a Go go.mod file, including indirect requirements.
+func ParseGoMod(data []byte) ([]Package, error) {
+	return ParseGoModWithOptions(data, GoModOptions{})
+}
+
+// ParseGoModWithOptions parses a Go go.mod file.
+// Requirements are rewritten by matching replace directives: a module
+// replacement reports the new path and version, while a replacement with a
+// local directory drops the module since there is no published version to
+// scan. Requirements whose exact version is excluded are dropped.
+func ParseGoModWithOptions(data []byte, opts GoModOptions) ([]Package, error) {
+	var (
+		requires []goModRequire
+		replaces []goModReplace
+		excluded = make(map[string]bool)
+	)
+
+	var block string // Directive of the enclosing ( ... ) block, if any
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, comment, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		verb := block
+		if block == "" {
+			verb, fields = fields[0], fields[1:]
+			if len(fields) == 1 && fields[0] == "(" {
+				block = verb
+				continue
+			}
+		} else if fields[0] == ")" {
+			block = ""
+			continue
+		}
+
+		for i := range fields {
+			fields[i] = strings.Trim(fields[i], "\"`")
+		}
+
+		switch verb {
+		case "require":
+			if len(fields) < 2 {
+				continue
+			}
+			requires = append(requires, goModRequire{
+				path:     fields[0],
+				version:  fields[1],
+				indirect: isGoModIndirect(comment),
+			})
+		case "exclude":
+			if len(fields) < 2 {
+				continue
+			}
+			excluded[fields[0]+"@"+fields[1]] = true
+		case "replace":
+			if r, ok := parseGoModReplace(fields); ok {
+				replaces = append(replaces, r)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var packages []Package
+	seen := make(map[string]bool)
+
+	for _, req := range requires {
+		if opts.SkipIndirect && req.indirect {
+			continue
+		}
+		if excluded[req.path+"@"+req.version] {
+			continue
+		}
+
+		path, version := applyGoModReplace(req.path, req.version, replaces)
+		if version == "" {
+			continue // Replaced by a local directory
+		}
+
+		key := path + "@" + version
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		packages = append(packages, Package{
+			Name:      path,
+			Version:   version,
+			Ecosystem: EcosystemGomod,
+		})
+	}
+
+	return packages, nil
+}
+
+// parseGoModReplace parses the fields of a replace directive:
+// "old [v] => new [v]".
+func parseGoModReplace(fields []string) (goModReplace, bool) {
+	arrow := slices.Index(fields, "=>")
+	if arrow == -1 {
+		return goModReplace{}, false
+	}
+
+	left, right := fields[:arrow], fields[arrow+1:]
+	if len(left) < 1 || len(left) > 2 || len(right) < 1 || len(right) > 2 {
+		return goModReplace{}, false
+	}
+
+	r := goModReplace{oldPath: left[0], newPath: right[0]}
+	if len(left) == 2 {
+		r.oldVersion = left[1]
+	}
+	if len(right) == 2 {
+		r.newVersion = right[1]
+	}
+	return r, true
+}
+
+// applyGoModReplace returns the effective path and version of a
+// requirement. A version-specific replacement takes precedence over one
+// covering all versions, and the last matching directive wins, mirroring
+// the go command. An empty version means the module is replaced by a
+// local directory.
+func applyGoModReplace(path, version string, replaces []goModReplace) (string, string) {
+	var match *goModReplace
+	for i := range replaces {
+		r := &replaces[i]
+		if r.oldPath != path {
+			continue
+		}
+		if r.oldVersion == version {
+			match = r
+		} else if r.oldVersion == "" && (match == nil || match.oldVersion == "") {
+			match = r
+		}
+	}
+
+	if match == nil {
+		return path, version
+	}
+	return match.newPath, match.newVersion
+}
+
+// isGoModIndirect reports whether a line comment marks an indirect
+// requirement, e.g. "// indirect" or "// indirect; for tests".
+func isGoModIndirect(comment string) bool {
+	comment = strings.TrimSpace(comment)
+	return comment == "indirect" || strings.HasPrefix(comment, "indirect;")
+}
@@ -0,0 +1,164 @@
+// ABOUTME: Unit tests for the go.mod parser
+// ABOUTME: Covers replace and exclude directives and optional indirect filtering
+
+package trivy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const goModWithDirectives = `module github.com/example/myapp
+
+go 1.22
+
+require (
+	github.com/gin-gonic/gin v1.9.1
+	github.com/gorilla/websocket v1.4.0
+	golang.org/x/net v0.10.0
+	github.com/example/internal v0.1.0
+	github.com/old/logger v1.0.0
+)
+
+require github.com/spf13/cobra v1.7.0
+
+require (
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	golang.org/x/sys v0.8.0 // indirect; used by tests
+)
+
+replace github.com/gorilla/websocket v1.4.0 => github.com/gorilla/websocket v1.5.1
+
+replace (
+	golang.org/x/net => golang.org/x/net v0.23.0
+	github.com/example/internal => ../internal
+	github.com/old/logger v1.0.0 => github.com/new/logger v2.1.0
+	github.com/spf13/cobra v1.6.0 => github.com/spf13/cobra v1.6.1
+)
+
+exclude github.com/gin-gonic/gin v1.9.1
+
+exclude (
+	golang.org/x/sys v0.7.0
+)
+`
+
+func TestParseGoModWithOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts GoModOptions
+		want map[string]string
+	}{
+		{
+			name: "all requirements",
+			opts: GoModOptions{},
+			want: map[string]string{
+				"github.com/gorilla/websocket":         "v1.5.1",
+				"golang.org/x/net":                     "v0.23.0",
+				"github.com/new/logger":                "v2.1.0",
+				"github.com/spf13/cobra":               "v1.7.0",
+				"github.com/inconshreveable/mousetrap": "v1.1.0",
+				"golang.org/x/sys":                     "v0.8.0",
+			},
+		},
+		{
+			name: "skip indirect",
+			opts: GoModOptions{SkipIndirect: true},
+			want: map[string]string{
+				"github.com/gorilla/websocket": "v1.5.1",
+				"golang.org/x/net":             "v0.23.0",
+				"github.com/new/logger":        "v2.1.0",
+				"github.com/spf13/cobra":       "v1.7.0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			packages, err := ParseGoModWithOptions([]byte(goModWithDirectives), tt.opts)
+			if err != nil {
+				t.Fatalf("ParseGoModWithOptions() error = %v", err)
+			}
+
+			if len(packages) != len(tt.want) {
+				t.Errorf("expected %d packages, got %d: %v", len(tt.want), len(packages), packages)
+			}
+			for _, p := range packages {
+				if p.Ecosystem != EcosystemGomod {
+					t.Errorf("expected ecosystem gomod, got %s", p.Ecosystem)
+				}
+				if v, ok := tt.want[p.Name]; !ok || p.Version != v {
+					t.Errorf("unexpected package %s@%s", p.Name, p.Version)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyGoModReplace(t *testing.T) {
+	t.Parallel()
+
+	replaces := []goModReplace{
+		{oldPath: "example.com/a", newPath: "example.com/a", newVersion: "v1.1.0"},
+		{oldPath: "example.com/a", oldVersion: "v1.0.0", newPath: "example.com/fork", newVersion: "v1.0.1"},
+		{oldPath: "example.com/a", newPath: "example.com/a", newVersion: "v1.2.0"},
+	}
+
+	tests := []struct {
+		path, version string
+		wantPath      string
+		wantVersion   string
+	}{
+		{"example.com/a", "v1.0.0", "example.com/fork", "v1.0.1"},
+		{"example.com/a", "v0.9.0", "example.com/a", "v1.2.0"},
+		{"example.com/b", "v1.0.0", "example.com/b", "v1.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path+"@"+tt.version, func(t *testing.T) {
+			t.Parallel()
+
+			gotPath, gotVersion := applyGoModReplace(tt.path, tt.version, replaces)
+			if gotPath != tt.wantPath || gotVersion != tt.wantVersion {
+				t.Errorf("applyGoModReplace() = %s@%s, want %s@%s", gotPath, gotVersion, tt.wantPath, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestScanPathForPackagesWithOptions_SkipGoIndirect(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goModWithDirectives), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts ScanOptions
+		want int
+	}{
+		{name: "default", opts: ScanOptions{}, want: 6},
+		{name: "skip indirect", opts: ScanOptions{SkipGoIndirect: true}, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			packages, err := ScanPathForPackagesWithOptions(dir, tt.opts.ManifestOptions())
+			if err != nil {
+				t.Fatalf("ScanPathForPackagesWithOptions() error = %v", err)
+			}
+			if len(packages) != tt.want {
+				t.Errorf("got %d packages, want %d: %v", len(packages), tt.want, packages)
+			}
+		})
+	}
+}
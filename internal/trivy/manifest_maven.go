@@ -0,0 +1,227 @@
+// ABOUTME: Maven manifest parsers for pom.xml and Gradle lockfiles
+// ABOUTME: Resolves ${property} placeholders and dependencyManagement versions
+
+package trivy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// unknownVersion is emitted for dependencies whose version cannot be resolved.
+const unknownVersion = "unknown"
+
+// maxPropertyDepth bounds nested ${property} resolution to avoid cycles.
+const maxPropertyDepth = 10
+
+// pomPropertyRe matches Maven property placeholders like ${spring.version}.
+var pomPropertyRe = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// pomProject is the subset of a Maven POM needed to extract dependencies.
+type pomProject struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Parent     struct {
+		GroupID    string `xml:"groupId"`
+		ArtifactID string `xml:"artifactId"`
+		Version    string `xml:"version"`
+	} `xml:"parent"`
+	Properties struct {
+		Entries []pomProperty `xml:",any"`
+	} `xml:"properties"`
+	Dependencies        []pomDependency `xml:"dependencies>dependency"`
+	ManagedDependencies []pomDependency `xml:"dependencyManagement>dependencies>dependency"`
+}
+
+// pomProperty is a single <properties> child element.
+type pomProperty struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+// pomDependency is a single <dependency> entry.
+type pomDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Scope      string `xml:"scope"`
+}
+
+// ParsePomXML parses a Maven pom.xml file.
+// Both direct and dependencyManagement entries are emitted; dependencies
+// without an explicit version inherit the managed version. Versions that
+// cannot be resolved are reported as "unknown" rather than dropped, and
+// the declared value is kept in RawVersion whenever it was resolved.
+//
+// Only declared dependencies are reported: transitive dependencies and
+// Maven's nearest-wins mediation are not resolved, so the result can
+// differ from the runtime classpath. Use a Gradle lockfile or a resolved
+// dependency tree for exact versions.
+func ParsePomXML(data []byte) ([]Package, error) {
+	var pom pomProject
+	if err := xml.Unmarshal(data, &pom); err != nil {
+		return nil, fmt.Errorf("parsing pom.xml: %w", err)
+	}
+
+	props := pomProperties(&pom)
+
+	// Index managed versions so direct dependencies can inherit them.
+	managed := make(map[string]string)
+	managedRaw := make(map[string]string)
+	for _, dep := range pom.ManagedDependencies {
+		name := pomDependencyName(dep, props)
+		if name == "" {
+			continue
+		}
+		managed[name] = resolvePomProperties(dep.Version, props)
+		managedRaw[name] = strings.TrimSpace(dep.Version)
+	}
+
+	var packages []Package
+	seen := make(map[string]bool)
+
+	add := func(name, version, declared string) {
+		if version == "" || pomPropertyRe.MatchString(version) {
+			version = unknownVersion
+		}
+		key := name + "@" + version
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		packages = append(packages, Package{
+			Name:       name,
+			Version:    version,
+			Ecosystem:  EcosystemMaven,
+			RawVersion: rawVersion(declared, version),
+		})
+	}
+
+	for _, dep := range pom.Dependencies {
+		name := pomDependencyName(dep, props)
+		if name == "" {
+			continue
+		}
+		version := resolvePomProperties(dep.Version, props)
+		declared := dep.Version
+		if version == "" {
+			version = managed[name]
+			declared = managedRaw[name]
+		}
+		add(name, version, declared)
+	}
+
+	for _, dep := range pom.ManagedDependencies {
+		name := pomDependencyName(dep, props)
+		if name == "" {
+			continue
+		}
+		add(name, managed[name], managedRaw[name])
+	}
+
+	return packages, nil
+}
+
+// pomProperties builds the property table used for placeholder resolution,
+// including the implicit project.* and parent.* properties.
+func pomProperties(pom *pomProject) map[string]string {
+	props := make(map[string]string)
+
+	for _, entry := range pom.Properties.Entries {
+		props[entry.XMLName.Local] = strings.TrimSpace(entry.Value)
+	}
+
+	version := strings.TrimSpace(pom.Version)
+	if version == "" {
+		version = strings.TrimSpace(pom.Parent.Version)
+	}
+	groupID := strings.TrimSpace(pom.GroupID)
+	if groupID == "" {
+		groupID = strings.TrimSpace(pom.Parent.GroupID)
+	}
+
+	props["project.version"] = version
+	props["pom.version"] = version
+	props["version"] = version
+	props["project.groupId"] = groupID
+	props["project.artifactId"] = strings.TrimSpace(pom.ArtifactID)
+	props["project.parent.version"] = strings.TrimSpace(pom.Parent.Version)
+	props["project.parent.groupId"] = strings.TrimSpace(pom.Parent.GroupID)
+
+	return props
+}
+
+// pomDependencyName returns the groupId:artifactId coordinate for a dependency.
+func pomDependencyName(dep pomDependency, props map[string]string) string {
+	groupID := resolvePomProperties(dep.GroupID, props)
+	artifactID := resolvePomProperties(dep.ArtifactID, props)
+	if groupID == "" || artifactID == "" {
+		return ""
+	}
+	return groupID + ":" + artifactID
+}
+
+// resolvePomProperties substitutes ${property} placeholders from props.
+// Unknown placeholders are left in place so callers can detect them.
+func resolvePomProperties(value string, props map[string]string) string {
+	value = strings.TrimSpace(value)
+
+	for i := 0; i < maxPropertyDepth && strings.Contains(value, "${"); i++ {
+		resolved := pomPropertyRe.ReplaceAllStringFunc(value, func(match string) string {
+			key := match[2 : len(match)-1]
+			if v, ok := props[key]; ok && v != "" {
+				return v
+			}
+			return match
+		})
+		if resolved == value {
+			break
+		}
+		value = resolved
+	}
+
+	return value
+}
+
+// ParseGradleLockfile parses a Gradle dependency lockfile (gradle.lockfile).
+// Each line has the form group:artifact:version=configurations.
+func ParseGradleLockfile(data []byte) ([]Package, error) {
+	var packages []Package
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip comments and the trailing "empty=" marker
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "empty=") {
+			continue
+		}
+
+		coords := strings.SplitN(line, "=", 2)[0]
+		parts := strings.Split(coords, ":")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			continue
+		}
+
+		name := parts[0] + ":" + parts[1]
+		key := name + "@" + parts[2]
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		packages = append(packages, Package{
+			Name:      name,
+			Version:   parts[2],
+			Ecosystem: EcosystemMaven,
+		})
+	}
+
+	return packages, scanner.Err()
+}
@@ -0,0 +1,298 @@
+// ABOUTME: Unit tests for Maven pom.xml and Gradle lockfile parsers
+// ABOUTME: Covers property resolution, managed dependencies, and unknown versions
+
+package trivy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePomXML(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		pom  string
+		want map[string]string
+	}{
+		{
+			name: "explicit versions",
+			pom: `<project>
+  <dependencies>
+    <dependency>
+      <groupId>com.google.guava</groupId>
+      <artifactId>guava</artifactId>
+      <version>32.1.2-jre</version>
+    </dependency>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <version>4.13.2</version>
+      <scope>test</scope>
+    </dependency>
+  </dependencies>
+</project>`,
+			want: map[string]string{
+				"com.google.guava:guava": "32.1.2-jre",
+				"junit:junit":            "4.13.2",
+			},
+		},
+		{
+			name: "property placeholders",
+			pom: `<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.4.0</version>
+  <properties>
+    <spring.version>6.0.11</spring.version>
+    <jackson.base>2.15</jackson.base>
+    <jackson.version>${jackson.base}.2</jackson.version>
+  </properties>
+  <dependencies>
+    <dependency>
+      <groupId>org.springframework</groupId>
+      <artifactId>spring-core</artifactId>
+      <version>${spring.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>com.fasterxml.jackson.core</groupId>
+      <artifactId>jackson-databind</artifactId>
+      <version>${jackson.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>${project.groupId}</groupId>
+      <artifactId>app-common</artifactId>
+      <version>${project.version}</version>
+    </dependency>
+  </dependencies>
+</project>`,
+			want: map[string]string{
+				"org.springframework:spring-core":             "6.0.11",
+				"com.fasterxml.jackson.core:jackson-databind": "2.15.2",
+				"com.example:app-common":                      "1.4.0",
+			},
+		},
+		{
+			name: "managed dependencies",
+			pom: `<project>
+  <parent>
+    <groupId>org.springframework.boot</groupId>
+    <artifactId>spring-boot-starter-parent</artifactId>
+    <version>3.1.3</version>
+  </parent>
+  <properties>
+    <log4j.version>2.20.0</log4j.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>org.apache.logging.log4j</groupId>
+        <artifactId>log4j-core</artifactId>
+        <version>${log4j.version}</version>
+      </dependency>
+      <dependency>
+        <groupId>org.yaml</groupId>
+        <artifactId>snakeyaml</artifactId>
+        <version>2.0</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>org.apache.logging.log4j</groupId>
+      <artifactId>log4j-core</artifactId>
+    </dependency>
+    <dependency>
+      <groupId>org.example</groupId>
+      <artifactId>internal-lib</artifactId>
+      <version>${project.parent.version}</version>
+    </dependency>
+  </dependencies>
+</project>`,
+			want: map[string]string{
+				"org.apache.logging.log4j:log4j-core": "2.20.0",
+				"org.yaml:snakeyaml":                  "2.0",
+				"org.example:internal-lib":            "3.1.3",
+			},
+		},
+		{
+			name: "unresolved versions",
+			pom: `<project>
+  <dependencies>
+    <dependency>
+      <groupId>org.springframework.boot</groupId>
+      <artifactId>spring-boot-starter-web</artifactId>
+    </dependency>
+    <dependency>
+      <groupId>org.hibernate</groupId>
+      <artifactId>hibernate-core</artifactId>
+      <version>${hibernate.version}</version>
+    </dependency>
+  </dependencies>
+</project>`,
+			want: map[string]string{
+				"org.springframework.boot:spring-boot-starter-web": unknownVersion,
+				"org.hibernate:hibernate-core":                     unknownVersion,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			packages, err := ParsePomXML([]byte(tt.pom))
+			if err != nil {
+				t.Fatalf("ParsePomXML() error = %v", err)
+			}
+
+			if len(packages) != len(tt.want) {
+				t.Errorf("expected %d packages, got %d: %v", len(tt.want), len(packages), packages)
+			}
+
+			for _, p := range packages {
+				if p.Ecosystem != EcosystemMaven {
+					t.Errorf("expected ecosystem maven, got %s", p.Ecosystem)
+				}
+				want, ok := tt.want[p.Name]
+				if !ok {
+					t.Errorf("unexpected package %s", p.Name)
+					continue
+				}
+				if p.Version != want {
+					t.Errorf("%s version = %q, want %q", p.Name, p.Version, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePomXML_RawVersion(t *testing.T) {
+	t.Parallel()
+
+	pom := `<project>
+  <properties>
+    <log4j.version>2.20.0</log4j.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>org.apache.logging.log4j</groupId>
+        <artifactId>log4j-core</artifactId>
+        <version>${log4j.version}</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>org.apache.logging.log4j</groupId>
+      <artifactId>log4j-api</artifactId>
+      <version>${log4j.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <version>4.13.2</version>
+    </dependency>
+    <dependency>
+      <groupId>org.hibernate</groupId>
+      <artifactId>hibernate-core</artifactId>
+      <version>${hibernate.version}</version>
+    </dependency>
+  </dependencies>
+</project>`
+
+	packages, err := ParsePomXML([]byte(pom))
+	if err != nil {
+		t.Fatalf("ParsePomXML() error = %v", err)
+	}
+
+	want := map[string]string{
+		"org.apache.logging.log4j:log4j-core": "${log4j.version}",
+		"org.apache.logging.log4j:log4j-api":  "${log4j.version}",
+		"junit:junit":                         "",
+		"org.hibernate:hibernate-core":        "${hibernate.version}",
+	}
+
+	if len(packages) != len(want) {
+		t.Errorf("expected %d packages, got %d: %v", len(want), len(packages), packages)
+	}
+	for _, p := range packages {
+		if raw, ok := want[p.Name]; !ok || p.RawVersion != raw {
+			t.Errorf("%s raw version = %q, want %q", p.Name, p.RawVersion, raw)
+		}
+	}
+}
+
+func TestParsePomXML_Invalid(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ParsePomXML([]byte("<project><dependencies>")); err == nil {
+		t.Error("expected error for malformed pom.xml")
+	}
+}
+
+func TestParseGradleLockfile(t *testing.T) {
+	t.Parallel()
+
+	content := `# This is a Gradle generated file for dependency locking.
+# Manual edits can break the build and are not advised.
+# This file is expected to be part of source control.
+com.fasterxml.jackson.core:jackson-core:2.15.2=compileClasspath,runtimeClasspath
+org.slf4j:slf4j-api:2.0.7=runtimeClasspath
+org.slf4j:slf4j-api:2.0.7=testRuntimeClasspath
+malformed-line
+empty=annotationProcessor
+`
+
+	packages, err := ParseGradleLockfile([]byte(content))
+	if err != nil {
+		t.Fatalf("ParseGradleLockfile() error = %v", err)
+	}
+
+	if len(packages) != 2 {
+		t.Fatalf("expected 2 packages, got %d: %v", len(packages), packages)
+	}
+
+	found := make(map[string]string)
+	for _, p := range packages {
+		found[p.Name] = p.Version
+		if p.Ecosystem != EcosystemMaven {
+			t.Errorf("expected ecosystem maven, got %s", p.Ecosystem)
+		}
+	}
+
+	if v := found["com.fasterxml.jackson.core:jackson-core"]; v != "2.15.2" {
+		t.Errorf("expected jackson-core 2.15.2, got %s", v)
+	}
+}
+
+func TestScanPath_Maven(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	pom := `<project>
+  <dependencies>
+    <dependency>
+      <groupId>org.apache.commons</groupId>
+      <artifactId>commons-text</artifactId>
+      <version>1.10.0</version>
+    </dependency>
+  </dependencies>
+</project>`
+	os.WriteFile(filepath.Join(dir, "pom.xml"), []byte(pom), 0o644)
+
+	packages, err := ScanPathForPackages(dir)
+	if err != nil {
+		t.Fatalf("ScanPathForPackages() error = %v", err)
+	}
+
+	if len(packages) != 1 {
+		t.Fatalf("expected 1 package, got %d", len(packages))
+	}
+	if packages[0].Name != "org.apache.commons:commons-text" || packages[0].Ecosystem != EcosystemMaven {
+		t.Errorf("unexpected package: %+v", packages[0])
+	}
+}
@@ -0,0 +1,95 @@
+// ABOUTME: NuGet manifest parser for SDK-style *.csproj files and packages.config
+// ABOUTME: Reads PackageReference items and legacy <package> entries as nuget packages
+
+package trivy
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// nugetPackageReference is a <PackageReference> item in a .csproj file.
+// The version may be given as an attribute or as a child element.
+type nugetPackageReference struct {
+	Include     string `xml:"Include,attr"`
+	VersionAttr string `xml:"Version,attr"`
+	Version     string `xml:"Version"`
+}
+
+// ParseNuGet parses a .NET *.csproj or packages.config file.
+// PackageReference items (Include/Version) and packages.config entries
+// (id/version) are both recognized, so the same parser serves either file.
+// Versions are reported as declared, including floating versions such as
+// 1.2.*; references without a version are reported as "unknown".
+func ParseNuGet(data []byte) ([]Package, error) {
+	var packages []Package
+	seen := make(map[string]bool)
+
+	add := func(name, version string) {
+		name = strings.TrimSpace(name)
+		version = strings.TrimSpace(version)
+		if name == "" {
+			return
+		}
+		if version == "" {
+			version = unknownVersion
+		}
+		key := name + "@" + version
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		packages = append(packages, Package{
+			Name:      name,
+			Version:   version,
+			Ecosystem: EcosystemNuget,
+		})
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing NuGet manifest: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "PackageReference":
+			var ref nugetPackageReference
+			if err := decoder.DecodeElement(&ref, &start); err != nil {
+				return nil, fmt.Errorf("parsing NuGet manifest: %w", err)
+			}
+			version := ref.VersionAttr
+			if version == "" {
+				version = ref.Version
+			}
+			add(ref.Include, version)
+		case "package":
+			add(xmlAttr(start, "id"), xmlAttr(start, "version"))
+		}
+	}
+
+	return packages, nil
+}
+
+// xmlAttr returns the value of the named attribute, or an empty string.
+func xmlAttr(start xml.StartElement, name string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+// ABOUTME: Unit tests for the NuGet manifest parser
+// ABOUTME: Covers SDK-style .csproj files, packages.config, and manifest discovery
+
+package trivy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const nugetCsproj = `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>net8.0</TargetFramework>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageReference Include="Newtonsoft.Json" Version="13.0.1" />
+    <PackageReference Include="Serilog" Version="3.1.*" />
+    <PackageReference Include="Dapper">
+      <Version>2.1.24</Version>
+    </PackageReference>
+    <PackageReference Include="Microsoft.Extensions.Logging" />
+    <ProjectReference Include="..\Shared\Shared.csproj" />
+  </ItemGroup>
+</Project>
+`
+
+const nugetPackagesConfig = `<?xml version="1.0" encoding="utf-8"?>
+<packages>
+  <package id="Newtonsoft.Json" version="12.0.3" targetFramework="net472" />
+  <package id="log4net" version="2.0.*" targetFramework="net472" />
+  <package id="log4net" version="2.0.*" targetFramework="net48" />
+</packages>
+`
+
+func TestParseNuGet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+	}{
+		{
+			name:    "csproj",
+			content: nugetCsproj,
+			want: map[string]string{
+				"Newtonsoft.Json":              "13.0.1",
+				"Serilog":                      "3.1.*",
+				"Dapper":                       "2.1.24",
+				"Microsoft.Extensions.Logging": "unknown",
+			},
+		},
+		{
+			name:    "packages.config",
+			content: nugetPackagesConfig,
+			want: map[string]string{
+				"Newtonsoft.Json": "12.0.3",
+				"log4net":         "2.0.*",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			packages, err := ParseNuGet([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("ParseNuGet() error = %v", err)
+			}
+
+			if len(packages) != len(tt.want) {
+				t.Errorf("expected %d packages, got %d: %v", len(tt.want), len(packages), packages)
+			}
+			for _, p := range packages {
+				if p.Ecosystem != EcosystemNuget {
+					t.Errorf("expected ecosystem nuget, got %s", p.Ecosystem)
+				}
+				if want, ok := tt.want[p.Name]; !ok || p.Version != want {
+					t.Errorf("package %s@%s, want version %q", p.Name, p.Version, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseNuGet_InvalidXML(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ParseNuGet([]byte(`<Project><ItemGroup>`)); err == nil {
+		t.Error("ParseNuGet() expected error for truncated XML")
+	}
+}
+
+func TestScanPath_NuGet(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	projectDir := filepath.Join(dir, "src", "MyApp")
+	os.MkdirAll(projectDir, 0o755)
+	os.WriteFile(filepath.Join(projectDir, "MyApp.csproj"), []byte(nugetCsproj), 0o644)
+	os.WriteFile(filepath.Join(dir, "packages.config"), []byte(nugetPackagesConfig), 0o644)
+
+	manifests, err := FindManifests(dir)
+	if err != nil {
+		t.Fatalf("FindManifests() error = %v", err)
+	}
+	if len(manifests) != 2 {
+		t.Errorf("expected 2 manifests, got %d: %v", len(manifests), manifests)
+	}
+
+	packages, err := ScanPathForPackages(dir)
+	if err != nil {
+		t.Fatalf("ScanPathForPackages() error = %v", err)
+	}
+
+	// Newtonsoft.Json appears at two different versions
+	if len(packages) != 6 {
+		t.Errorf("expected 6 packages, got %d: %v", len(packages), packages)
+	}
+}
@@ -0,0 +1,104 @@
+// ABOUTME: pnpm lockfile parser for v5 (/name/version) and v6+ (/name@version) keys
+// ABOUTME: Extracts concrete resolved versions from the packages map of pnpm-lock.yaml
+
+package trivy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParsePnpmLock parses a pnpm pnpm-lock.yaml file.
+// Package keys are read from the top-level packages map in both the v5
+// format (/name/version, /@scope/name/version) and the v6+ format
+// (/name@version, /@scope/name@version). Peer dependency suffixes are
+// dropped and entries are deduplicated by name and version.
+func ParsePnpmLock(data []byte) ([]Package, error) {
+	var lock struct {
+		Packages map[string]yaml.Node `yaml:"packages"`
+	}
+
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("parsing pnpm-lock.yaml: %w", err)
+	}
+
+	// Sort keys so the output order is stable across runs
+	keys := make([]string, 0, len(lock.Packages))
+	for key := range lock.Packages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var packages []Package
+	seen := make(map[string]bool)
+
+	for _, key := range keys {
+		name, version := parsePnpmPackageKey(key)
+		if name == "" || version == "" {
+			continue
+		}
+
+		id := name + "@" + version
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		packages = append(packages, Package{
+			Name:      name,
+			Version:   version,
+			Ecosystem: EcosystemNpm,
+		})
+	}
+
+	return packages, nil
+}
+
+// parsePnpmPackageKey splits a pnpm packages key such as
+// `/@babel/core/7.12.3`, `/lodash/4.17.21_react@17.0.2`, or
+// `/@babel/core@7.22.9(@types/node@20.1.0)` into name and version.
+// Returns empty strings for keys that do not reference a registry version.
+func parsePnpmPackageKey(key string) (string, string) {
+	key = strings.TrimPrefix(key, "/")
+
+	// v6+ peer dependency suffix: name@1.0.0(react@17.0.2)
+	if idx := strings.Index(key, "("); idx != -1 {
+		key = key[:idx]
+	}
+
+	// Scoped names span two path segments
+	nameEnd := 0
+	if strings.HasPrefix(key, "@") {
+		slash := strings.Index(key, "/")
+		if slash == -1 {
+			return "", ""
+		}
+		nameEnd = slash + 1
+	}
+
+	sep := strings.IndexAny(key[nameEnd:], "/@")
+	if sep == -1 {
+		return "", ""
+	}
+	sep += nameEnd
+
+	name := key[:sep]
+	version := key[sep+1:]
+
+	// v5 peer dependency suffix: 1.0.0_react@17.0.2
+	if key[sep] == '/' {
+		if idx := strings.Index(version, "_"); idx != -1 {
+			version = version[:idx]
+		}
+	}
+
+	// Tarball, git, and local references carry no registry version
+	if name == "" || version == "" || version[0] < '0' || version[0] > '9' ||
+		strings.ContainsAny(version, ":/") {
+		return "", ""
+	}
+
+	return name, version
+}
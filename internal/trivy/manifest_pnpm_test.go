@@ -0,0 +1,188 @@
+// ABOUTME: Unit tests for the pnpm-lock.yaml parser
+// ABOUTME: Covers v5 and v6 lockfile key formats with scoped and unscoped packages
+
+package trivy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const pnpmLockV5 = `lockfileVersion: 5.4
+
+specifiers:
+  '@babel/core': ^7.12.3
+  lodash: ^4.17.21
+
+dependencies:
+  '@babel/core': 7.12.3
+  lodash: 4.17.21
+
+packages:
+
+  /@babel/core/7.12.3:
+    resolution: {integrity: sha512-abc}
+    engines: {node: '>=6.9.0'}
+    dev: false
+
+  /lodash/4.17.21:
+    resolution: {integrity: sha512-def}
+    dev: false
+
+  /react-dom/17.0.2_react@17.0.2:
+    resolution: {integrity: sha512-ghi}
+    peerDependencies:
+      react: 17.0.2
+    dev: false
+
+  /react/17.0.2:
+    resolution: {integrity: sha512-jkl}
+    dev: false
+
+  github.com/user/repo/abc123:
+    resolution: {tarball: https://codeload.github.com/user/repo/tar.gz/abc123}
+    name: repo
+    version: 1.0.0
+    dev: false
+`
+
+const pnpmLockV6 = `lockfileVersion: '6.0'
+
+dependencies:
+  '@types/node':
+    specifier: ^20.1.0
+    version: 20.1.0
+  lodash:
+    specifier: ^4.17.21
+    version: 4.17.21
+
+packages:
+
+  /@babel/core@7.22.9:
+    resolution: {integrity: sha512-abc}
+    dev: true
+
+  /@types/node@20.1.0:
+    resolution: {integrity: sha512-def}
+    dev: false
+
+  /lodash@4.17.21:
+    resolution: {integrity: sha512-ghi}
+    dev: false
+
+  /lodash@3.10.1:
+    resolution: {integrity: sha512-jkl}
+    dev: true
+
+  /react-dom@17.0.2(react@17.0.2):
+    resolution: {integrity: sha512-mno}
+    peerDependencies:
+      react: 17.0.2
+    dev: false
+
+  /react-dom@17.0.2(react@17.0.1):
+    resolution: {integrity: sha512-mno}
+    peerDependencies:
+      react: 17.0.1
+    dev: false
+
+  /local-lib@file:../local-lib:
+    resolution: {directory: ../local-lib, type: directory}
+    name: local-lib
+    dev: false
+`
+
+func TestParsePnpmLock(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    map[string][]string
+	}{
+		{
+			name:    "v5",
+			content: pnpmLockV5,
+			want: map[string][]string{
+				"@babel/core": {"7.12.3"},
+				"lodash":      {"4.17.21"},
+				"react-dom":   {"17.0.2"},
+				"react":       {"17.0.2"},
+			},
+		},
+		{
+			name:    "v6",
+			content: pnpmLockV6,
+			want: map[string][]string{
+				"@babel/core": {"7.22.9"},
+				"@types/node": {"20.1.0"},
+				"lodash":      {"3.10.1", "4.17.21"},
+				"react-dom":   {"17.0.2"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			packages, err := ParsePnpmLock([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("ParsePnpmLock() error = %v", err)
+			}
+
+			found := make(map[string][]string)
+			for _, p := range packages {
+				if p.Ecosystem != EcosystemNpm {
+					t.Errorf("expected ecosystem npm, got %s", p.Ecosystem)
+				}
+				found[p.Name] = append(found[p.Name], p.Version)
+			}
+
+			if len(found) != len(tt.want) {
+				t.Errorf("expected %d package names, got %d: %v", len(tt.want), len(found), found)
+			}
+			for name, versions := range tt.want {
+				got := found[name]
+				if len(got) != len(versions) {
+					t.Errorf("%s versions = %v, want %v", name, got, versions)
+					continue
+				}
+				for i := range versions {
+					if got[i] != versions[i] {
+						t.Errorf("%s versions = %v, want %v", name, got, versions)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParsePnpmLock_InvalidYAML(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ParsePnpmLock([]byte("packages: [unterminated")); err == nil {
+		t.Error("ParsePnpmLock() expected error for invalid YAML")
+	}
+}
+
+func TestScanPath_PnpmLock(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "pnpm-lock.yaml"), []byte(pnpmLockV6), 0o644)
+
+	if got := DetectManifestType("pnpm-lock.yaml"); got != EcosystemNpm {
+		t.Errorf("DetectManifestType(pnpm-lock.yaml) = %q, want %q", got, EcosystemNpm)
+	}
+
+	packages, err := ScanPathForPackages(dir)
+	if err != nil {
+		t.Fatalf("ScanPathForPackages() error = %v", err)
+	}
+
+	if len(packages) != 5 {
+		t.Errorf("expected 5 packages, got %d: %v", len(packages), packages)
+	}
+}
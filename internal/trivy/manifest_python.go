@@ -0,0 +1,108 @@
+// ABOUTME: Python lockfile parsers for Poetry (poetry.lock) and Pipenv (Pipfile.lock)
+// ABOUTME: Skips VCS and local path dependencies that have no registry version
+
+package trivy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// poetryLocalSources are Poetry source types that do not refer to an index.
+var poetryLocalSources = map[string]bool{
+	"git":       true,
+	"directory": true,
+	"file":      true,
+	"url":       true,
+}
+
+// ParsePoetryLock parses a Poetry poetry.lock file.
+func ParsePoetryLock(data []byte) ([]Package, error) {
+	var lock struct {
+		Package []struct {
+			Name    string `toml:"name"`
+			Version string `toml:"version"`
+			Source  struct {
+				Type string `toml:"type"`
+			} `toml:"source"`
+		} `toml:"package"`
+	}
+
+	if err := toml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("parsing poetry.lock: %w", err)
+	}
+
+	var packages []Package
+	seen := make(map[string]bool)
+
+	for _, pkg := range lock.Package {
+		if pkg.Name == "" || pkg.Version == "" || poetryLocalSources[pkg.Source.Type] {
+			continue
+		}
+
+		name := strings.ToLower(pkg.Name)
+		key := name + "@" + pkg.Version
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		packages = append(packages, Package{
+			Name:      name,
+			Version:   pkg.Version,
+			Ecosystem: EcosystemPip,
+		})
+	}
+
+	return packages, nil
+}
+
+// ParsePipfileLock parses a Pipenv Pipfile.lock file.
+// Entries without a pinned version (git, path, editable) are skipped.
+func ParsePipfileLock(data []byte) ([]Package, error) {
+	type pipfileEntry struct {
+		Version string `json:"version"`
+	}
+
+	var lock struct {
+		Default map[string]pipfileEntry `json:"default"`
+		Develop map[string]pipfileEntry `json:"develop"`
+	}
+
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("parsing Pipfile.lock: %w", err)
+	}
+
+	var packages []Package
+	seen := make(map[string]bool)
+
+	extract := func(entries map[string]pipfileEntry) {
+		for name, entry := range entries {
+			version := strings.TrimPrefix(entry.Version, "==")
+			if version == "" {
+				continue
+			}
+
+			name = strings.ToLower(name)
+			key := name + "@" + version
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			packages = append(packages, Package{
+				Name:      name,
+				Version:   version,
+				Ecosystem: EcosystemPip,
+			})
+		}
+	}
+
+	extract(lock.Default)
+	extract(lock.Develop)
+
+	return packages, nil
+}
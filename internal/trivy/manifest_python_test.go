@@ -0,0 +1,201 @@
+// ABOUTME: Unit tests for Poetry and Pipenv lockfile parsers
+// ABOUTME: Uses realistic lockfile fixtures including VCS and path dependencies
+
+package trivy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const poetryLockFixture = `# This file is automatically @generated by Poetry 1.6.1 and should not be changed by hand.
+
+[[package]]
+name = "certifi"
+version = "2023.7.22"
+description = "Python package for providing Mozilla's CA Bundle."
+optional = false
+python-versions = ">=3.6"
+files = [
+    {file = "certifi-2023.7.22-py3-none-any.whl", hash = "sha256:92d6037539857d8206b8f6ae472e8b77db8058fec5937a1ef3f54304089edbb9"},
+]
+
+[[package]]
+name = "Requests"
+version = "2.31.0"
+description = "Python HTTP for Humans."
+optional = false
+python-versions = ">=3.7"
+
+[package.dependencies]
+certifi = ">=2017.4.17"
+
+[package.extras]
+socks = ["PySocks (>=1.5.6,!=1.5.7)"]
+
+[[package]]
+name = "mylib"
+version = "0.1.0"
+description = ""
+optional = false
+python-versions = "*"
+develop = false
+
+[package.source]
+type = "git"
+url = "https://github.com/example/mylib.git"
+reference = "main"
+resolved_reference = "abc123"
+
+[[package]]
+name = "localpkg"
+version = "0.0.1"
+description = ""
+optional = false
+python-versions = "*"
+
+[package.source]
+type = "directory"
+url = "../localpkg"
+
+[[package]]
+name = "private-lib"
+version = "1.2.0"
+description = ""
+optional = false
+python-versions = "*"
+
+[package.source]
+type = "legacy"
+url = "https://pypi.example.com/simple"
+reference = "private"
+
+[metadata]
+lock-version = "2.0"
+python-versions = "^3.10"
+content-hash = "deadbeef"
+`
+
+const pipfileLockFixture = `{
+    "_meta": {
+        "hash": {"sha256": "7f7d2e1c"},
+        "pipfile-spec": 6,
+        "requires": {"python_version": "3.11"},
+        "sources": [{"name": "pypi", "url": "https://pypi.org/simple", "verify_ssl": true}]
+    },
+    "default": {
+        "Django": {
+            "hashes": ["sha256:abc"],
+            "index": "pypi",
+            "markers": "python_version >= '3.8'",
+            "version": "==4.2.5"
+        },
+        "sqlparse": {
+            "hashes": ["sha256:def"],
+            "version": "==0.4.4"
+        },
+        "mylib": {
+            "git": "https://github.com/example/mylib.git",
+            "ref": "abc123"
+        },
+        "localpkg": {
+            "editable": true,
+            "path": "./localpkg"
+        }
+    },
+    "develop": {
+        "pytest": {
+            "hashes": ["sha256:ghi"],
+            "version": "==7.4.2"
+        },
+        "sqlparse": {
+            "version": "==0.4.4"
+        }
+    }
+}`
+
+func TestParsePoetryLock(t *testing.T) {
+	t.Parallel()
+
+	packages, err := ParsePoetryLock([]byte(poetryLockFixture))
+	if err != nil {
+		t.Fatalf("ParsePoetryLock() error = %v", err)
+	}
+
+	want := map[string]string{
+		"certifi":     "2023.7.22",
+		"requests":    "2.31.0",
+		"private-lib": "1.2.0",
+	}
+
+	if len(packages) != len(want) {
+		t.Errorf("expected %d packages, got %d: %v", len(want), len(packages), packages)
+	}
+
+	for _, p := range packages {
+		if p.Ecosystem != EcosystemPip {
+			t.Errorf("expected ecosystem pip, got %s", p.Ecosystem)
+		}
+		if v, ok := want[p.Name]; !ok || v != p.Version {
+			t.Errorf("unexpected package %s@%s", p.Name, p.Version)
+		}
+	}
+}
+
+func TestParsePipfileLock(t *testing.T) {
+	t.Parallel()
+
+	packages, err := ParsePipfileLock([]byte(pipfileLockFixture))
+	if err != nil {
+		t.Fatalf("ParsePipfileLock() error = %v", err)
+	}
+
+	want := map[string]string{
+		"django":   "4.2.5",
+		"sqlparse": "0.4.4",
+		"pytest":   "7.4.2",
+	}
+
+	if len(packages) != len(want) {
+		t.Errorf("expected %d packages, got %d: %v", len(want), len(packages), packages)
+	}
+
+	for _, p := range packages {
+		if p.Ecosystem != EcosystemPip {
+			t.Errorf("expected ecosystem pip, got %s", p.Ecosystem)
+		}
+		if v, ok := want[p.Name]; !ok || v != p.Version {
+			t.Errorf("unexpected package %s@%s", p.Name, p.Version)
+		}
+	}
+}
+
+func TestParsePythonLocks_Invalid(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ParsePoetryLock([]byte("[[package]\nname =")); err == nil {
+		t.Error("expected error for malformed poetry.lock")
+	}
+	if _, err := ParsePipfileLock([]byte("{")); err == nil {
+		t.Error("expected error for malformed Pipfile.lock")
+	}
+}
+
+func TestScanPath_PythonLocks(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "poetry.lock"), []byte(poetryLockFixture), 0o644)
+	os.MkdirAll(filepath.Join(dir, "svc"), 0o755)
+	os.WriteFile(filepath.Join(dir, "svc", "Pipfile.lock"), []byte(pipfileLockFixture), 0o644)
+
+	packages, err := ScanPathForPackages(dir)
+	if err != nil {
+		t.Fatalf("ScanPathForPackages() error = %v", err)
+	}
+
+	if len(packages) != 6 {
+		t.Errorf("expected 6 packages, got %d: %v", len(packages), packages)
+	}
+}
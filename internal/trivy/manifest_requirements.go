@@ -0,0 +1,343 @@
+// ABOUTME: Python requirements.txt parser with markers, direct references, and includes
+// ABOUTME: Follows -r includes and -c constraints confined to the scan root
+
+package trivy
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxRequirementsDepth bounds nested -r/-c includes to avoid runaway recursion.
+const maxRequirementsDepth = 5
+
+var (
+	// requirementSpecRe matches name[extras]==version and other specifiers.
+	requirementSpecRe = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[[^\]]*\])?\s*([=<>!~]+)\s*([0-9][^\s,;]*)`)
+
+	// requirementURLRe matches PEP 508 direct references: name[extras] @ url.
+	requirementURLRe = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[[^\]]*\])?\s*@\s*(\S+)`)
+
+	// requirementNameRe matches a bare requirement name with optional extras.
+	requirementNameRe = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[[^\]]*\])?$`)
+
+	// requirementCommentRe matches an inline comment, which pip only
+	// recognizes after whitespace so URL fragments like #egg= survive.
+	requirementCommentRe = regexp.MustCompile(`(^|\s+)#.*$`)
+
+	// pep503SeparatorRe matches the runs of separators PEP 503 normalizes.
+	pep503SeparatorRe = regexp.MustCompile(`[-_.]+`)
+)
+
+// ParseRequirementsTxt parses a Python requirements.txt file.
+// Environment markers after ";" are ignored and direct references
+// (name @ url) are reported with a version taken from the URL when
+// possible. Since there is no base path, -r and -c lines are skipped;
+// use ParseRequirementsFile to follow them.
+func ParseRequirementsTxt(data []byte) ([]Package, error) {
+	p := newRequirementsParser("")
+	packages, err := p.parse(data, "", 0)
+	if err != nil {
+		return nil, err
+	}
+	return p.finish(packages), nil
+}
+
+// ParseRequirementsFile parses the requirements file at path, following
+// -r includes and -c constraints relative to its directory. Constraint
+// files only pin the versions of requirements listed without one.
+// Includes must stay within the file's directory; absolute targets and
+// includes that escape it, are missing, cyclic, or nested deeper than
+// maxRequirementsDepth are skipped.
+func ParseRequirementsFile(path string) ([]Package, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading requirements: %w", err)
+	}
+	return parseRequirementsFile(path, filepath.Dir(path), data)
+}
+
+// parseRequirementsFile parses already-read requirements data from path,
+// following only includes that resolve inside root.
+func parseRequirementsFile(path, root string, data []byte) ([]Package, error) {
+	p := newRequirementsParser(root)
+	p.active[filepath.Clean(path)] = true
+
+	packages, err := p.parse(data, filepath.Dir(path), 0)
+	if err != nil {
+		return nil, err
+	}
+	return p.finish(packages), nil
+}
+
+// requirementsParser carries state shared across included files.
+type requirementsParser struct {
+	// root confines includes; files outside it are never read.
+	root string
+
+	// constraints maps normalized names to versions pinned by -c files.
+	constraints map[string]string
+
+	// active holds the files on the current include chain, for cycle detection.
+	active map[string]bool
+}
+
+func newRequirementsParser(root string) *requirementsParser {
+	return &requirementsParser{
+		root:        filepath.Clean(root),
+		constraints: make(map[string]string),
+		active:      make(map[string]bool),
+	}
+}
+
+// parse extracts packages from requirements data. dir is the directory
+// includes are resolved against; an empty dir disables includes.
+func (p *requirementsParser) parse(data []byte, dir string, depth int) ([]Package, error) {
+	var packages []Package
+
+	lines, err := requirementLines(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		// Options: includes, constraints, and pip flags
+		if strings.HasPrefix(line, "-") {
+			kind, target := parseRequirementsOption(line)
+			if kind == "" || dir == "" || filepath.IsAbs(target) {
+				continue
+			}
+			included := p.parseInclude(filepath.Join(dir, target), depth+1)
+			if kind == "constraint" {
+				for _, pkg := range included {
+					if pkg.Version != "latest" && pkg.Version != unknownVersion {
+						p.constraints[normalizePythonName(pkg.Name)] = pkg.Version
+					}
+				}
+				continue
+			}
+			packages = append(packages, included...)
+			continue
+		}
+
+		// Skip local paths
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "/") {
+			continue
+		}
+
+		// Direct references end at whitespace; PEP 508 requires a space
+		// before their marker since ";" may appear in the URL itself
+		if matches := requirementURLRe.FindStringSubmatch(line); matches != nil {
+			name := strings.ToLower(matches[1])
+			packages = append(packages, Package{
+				Name:      name,
+				Version:   directReferenceVersion(name, matches[2]),
+				Ecosystem: EcosystemPip,
+			})
+			continue
+		}
+
+		// Strip PEP 508 environment markers
+		if idx := strings.Index(line, ";"); idx != -1 {
+			line = strings.TrimSpace(line[:idx])
+		}
+
+		// Try to parse with version specifier
+		if matches := requirementSpecRe.FindStringSubmatch(line); matches != nil {
+			packages = append(packages, Package{
+				Name:      strings.ToLower(matches[1]),
+				Version:   matches[3],
+				Ecosystem: EcosystemPip,
+			})
+			continue
+		}
+
+		// Package without version
+		if matches := requirementNameRe.FindStringSubmatch(line); matches != nil {
+			packages = append(packages, Package{
+				Name:      strings.ToLower(matches[1]),
+				Version:   "latest",
+				Ecosystem: EcosystemPip,
+			})
+		}
+	}
+
+	return packages, nil
+}
+
+// parseInclude parses an included requirements or constraints file,
+// returning nothing for files outside the root, missing files, cycles,
+// and excessive nesting.
+func (p *requirementsParser) parseInclude(path string, depth int) []Package {
+	path = filepath.Clean(path)
+	if depth > maxRequirementsDepth || p.active[path] || !p.withinRoot(path) {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	p.active[path] = true
+	defer delete(p.active, path)
+
+	packages, err := p.parse(data, filepath.Dir(path), depth)
+	if err != nil {
+		return nil
+	}
+	return packages
+}
+
+// withinRoot reports whether path resolves inside the parser's root.
+func (p *requirementsParser) withinRoot(path string) bool {
+	rel, err := filepath.Rel(p.root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// finish applies constraint pins to unversioned requirements and removes
+// duplicates introduced by includes.
+func (p *requirementsParser) finish(packages []Package) []Package {
+	var result []Package
+	seen := make(map[string]bool)
+
+	for _, pkg := range packages {
+		if pkg.Version == "latest" {
+			if version, ok := p.constraints[normalizePythonName(pkg.Name)]; ok {
+				pkg.Version = version
+			}
+		}
+
+		key := pkg.Name + "@" + pkg.Version
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, pkg)
+	}
+
+	return result
+}
+
+// requirementLines returns the logical lines of a requirements file with
+// continuations joined, comments removed, and blank lines dropped.
+func requirementLines(data []byte) ([]string, error) {
+	var lines []string
+	var current strings.Builder
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		raw := strings.TrimRight(scanner.Text(), " \t\r")
+
+		if strings.HasSuffix(raw, "\\") {
+			current.WriteString(strings.TrimSuffix(raw, "\\"))
+			current.WriteString(" ")
+			continue
+		}
+		current.WriteString(raw)
+
+		line := requirementCommentRe.ReplaceAllString(current.String(), "")
+		current.Reset()
+
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseRequirementsOption classifies an option line, returning "include"
+// or "constraint" with the referenced file, or an empty kind otherwise.
+func parseRequirementsOption(line string) (string, string) {
+	options := []struct {
+		flag string
+		kind string
+	}{
+		{"--requirement", "include"},
+		{"--constraint", "constraint"},
+		{"-r", "include"},
+		{"-c", "constraint"},
+	}
+
+	for _, opt := range options {
+		if !strings.HasPrefix(line, opt.flag) {
+			continue
+		}
+		target := strings.TrimPrefix(line, opt.flag)
+		target = strings.TrimSpace(strings.TrimPrefix(target, "="))
+		if target == "" {
+			return "", ""
+		}
+		return opt.kind, target
+	}
+
+	return "", ""
+}
+
+// directReferenceVersion derives a best-effort version from a direct
+// reference URL: the ref of a VCS URL (git+https://...@v1.2.3) or the
+// version segment of a wheel or sdist filename. Returns "unknown" when
+// the URL does not carry a version.
+func directReferenceVersion(name, url string) string {
+	if idx := strings.IndexAny(url, "#?"); idx != -1 {
+		url = url[:idx]
+	}
+
+	var version string
+
+	if strings.Contains(url, "+") && strings.Index(url, "+") < strings.Index(url, "://") {
+		// VCS reference: the ref follows the last @ in the path
+		rest := url[strings.Index(url, "://")+3:]
+		if slash := strings.Index(rest, "/"); slash != -1 {
+			rest = rest[slash:]
+		}
+		if at := strings.LastIndex(rest, "@"); at != -1 {
+			version = rest[at+1:]
+		}
+	} else {
+		file := path.Base(url)
+		switch {
+		case strings.HasSuffix(file, ".whl"):
+			// name-version(-build)?-python-abi-platform.whl
+			if parts := strings.Split(strings.TrimSuffix(file, ".whl"), "-"); len(parts) >= 5 {
+				version = parts[1]
+			}
+		default:
+			for _, ext := range []string{".tar.gz", ".tar.bz2", ".tgz", ".zip"} {
+				if !strings.HasSuffix(file, ext) {
+					continue
+				}
+				stem := strings.TrimSuffix(file, ext)
+				if idx := strings.LastIndex(stem, "-"); idx != -1 &&
+					normalizePythonName(stem[:idx]) == normalizePythonName(name) {
+					version = stem[idx+1:]
+				}
+				break
+			}
+		}
+	}
+
+	// Tags are commonly prefixed with v
+	if len(version) > 1 && version[0] == 'v' && version[1] >= '0' && version[1] <= '9' {
+		version = version[1:]
+	}
+	if version == "" || version[0] < '0' || version[0] > '9' {
+		return unknownVersion
+	}
+	return version
+}
+
+// normalizePythonName normalizes a distribution name per PEP 503.
+func normalizePythonName(name string) string {
+	return pep503SeparatorRe.ReplaceAllString(strings.ToLower(name), "-")
+}
@@ -0,0 +1,221 @@
+// ABOUTME: Unit tests for the requirements.txt parser
+// ABOUTME: Covers environment markers, direct references, includes, and constraints
+
+package trivy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRequirementsTxt_Markers(t *testing.T) {
+	t.Parallel()
+
+	content := `requests==2.31.0; python_version >= "3.8"
+importlib-metadata==4.13.0 ; python_version < "3.8"
+uvicorn[standard]>=0.23.0,<1.0 ; sys_platform != "win32"
+pywin32; sys_platform == "win32"
+zope.interface==6.0  # pinned for plugins
+`
+
+	packages, err := ParseRequirementsTxt([]byte(content))
+	if err != nil {
+		t.Fatalf("ParseRequirementsTxt() error = %v", err)
+	}
+
+	want := map[string]string{
+		"requests":           "2.31.0",
+		"importlib-metadata": "4.13.0",
+		"uvicorn":            "0.23.0",
+		"pywin32":            "latest",
+		"zope.interface":     "6.0",
+	}
+
+	assertRequirements(t, packages, want)
+}
+
+func TestParseRequirementsTxt_DirectReferences(t *testing.T) {
+	t.Parallel()
+
+	content := `mylib @ git+https://github.com/example/mylib.git@v1.4.2
+other @ git+https://github.com/example/other.git@main#egg=other
+pkg-a @ https://files.example.com/packages/pkg_a-2.0.1-py3-none-any.whl ; python_version >= "3.9"
+pkg-b[extra] @ https://files.example.com/pkg-b-0.9.0.tar.gz#sha256=abc123
+archive @ https://files.example.com/download?id=42
+`
+
+	packages, err := ParseRequirementsTxt([]byte(content))
+	if err != nil {
+		t.Fatalf("ParseRequirementsTxt() error = %v", err)
+	}
+
+	want := map[string]string{
+		"mylib":   "1.4.2",
+		"other":   "unknown",
+		"pkg-a":   "2.0.1",
+		"pkg-b":   "0.9.0",
+		"archive": "unknown",
+	}
+
+	assertRequirements(t, packages, want)
+}
+
+func TestParseRequirementsFile_Includes(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile("requirements.txt", `-r requirements/base.txt
+-c constraints.txt
+--requirement=requirements/missing.txt
+flask
+gunicorn==21.2.0
+`)
+	writeFile("requirements/base.txt", `-r ../requirements.txt
+-r nested.txt
+requests==2.31.0
+`)
+	writeFile("requirements/nested.txt", `urllib3
+`)
+	writeFile("constraints.txt", `flask==3.0.0
+urllib3==2.0.7
+unrelated==1.0.0
+`)
+
+	packages, err := ParseRequirementsFile(filepath.Join(dir, "requirements.txt"))
+	if err != nil {
+		t.Fatalf("ParseRequirementsFile() error = %v", err)
+	}
+
+	want := map[string]string{
+		"requests": "2.31.0",
+		"urllib3":  "2.0.7",
+		"flask":    "3.0.0",
+		"gunicorn": "21.2.0",
+	}
+
+	assertRequirements(t, packages, want)
+}
+
+func TestParseRequirementsFile_DepthLimit(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for i := 0; i <= maxRequirementsDepth+1; i++ {
+		content := "-r " + chainFileName(i+1) + "\npkg" + chainFileName(i) + "==1.0\n"
+		if err := os.WriteFile(filepath.Join(dir, chainFileName(i)), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	packages, err := ParseRequirementsFile(filepath.Join(dir, chainFileName(0)))
+	if err != nil {
+		t.Fatalf("ParseRequirementsFile() error = %v", err)
+	}
+
+	if len(packages) != maxRequirementsDepth+1 {
+		t.Errorf("expected %d packages, got %d: %v", maxRequirementsDepth+1, len(packages), packages)
+	}
+}
+
+func TestParseRequirementsFile_ConfinedToRoot(t *testing.T) {
+	t.Parallel()
+
+	base := t.TempDir()
+	outside := filepath.Join(base, "outside.txt")
+	if err := os.WriteFile(outside, []byte("leaked==1.0.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	project := filepath.Join(base, "project")
+	if err := os.MkdirAll(filepath.Join(project, "app"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, "shared.txt"), []byte("shared==2.0.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	content := "-r ../outside.txt\n-c " + outside + "\n-r ../../outside.txt\n-r ../shared.txt\nflask==3.0.0\n"
+	appReqs := filepath.Join(project, "app", "requirements.txt")
+	if err := os.WriteFile(appReqs, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		parse func() ([]Package, error)
+		want  map[string]string
+	}{
+		{
+			name:  "file directory is the root",
+			parse: func() ([]Package, error) { return ParseRequirementsFile(appReqs) },
+			want:  map[string]string{"flask": "3.0.0"},
+		},
+		{
+			name:  "scan root allows sibling includes",
+			parse: func() ([]Package, error) { return ScanPathForPackages(project) },
+			want:  map[string]string{"flask": "3.0.0", "shared": "2.0.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			packages, err := tt.parse()
+			if err != nil {
+				t.Fatalf("parse error = %v", err)
+			}
+			assertRequirements(t, packages, tt.want)
+		})
+	}
+}
+
+func TestParseRequirementsTxt_IgnoresIncludesWithoutPath(t *testing.T) {
+	t.Parallel()
+
+	content := `-r other.txt
+-c constraints.txt
+--index-url https://pypi.example.com/simple
+requests==2.31.0
+`
+
+	packages, err := ParseRequirementsTxt([]byte(content))
+	if err != nil {
+		t.Fatalf("ParseRequirementsTxt() error = %v", err)
+	}
+
+	assertRequirements(t, packages, map[string]string{"requests": "2.31.0"})
+}
+
+// chainFileName returns the name of the i-th file in an include chain.
+func chainFileName(i int) string {
+	return "req" + string(rune('a'+i)) + ".txt"
+}
+
+// assertRequirements checks that packages match want exactly by name and version.
+func assertRequirements(t *testing.T, packages []Package, want map[string]string) {
+	t.Helper()
+
+	if len(packages) != len(want) {
+		t.Errorf("expected %d packages, got %d: %v", len(want), len(packages), packages)
+	}
+	for _, p := range packages {
+		if p.Ecosystem != EcosystemPip {
+			t.Errorf("expected ecosystem pip, got %s", p.Ecosystem)
+		}
+		if v, ok := want[p.Name]; !ok || p.Version != v {
+			t.Errorf("unexpected package %s@%s", p.Name, p.Version)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// ABOUTME: Bundler Gemfile.lock parser for RubyGems dependencies
+// ABOUTME: Reads top-level GEM specs and skips gems sourced from PATH or GIT
+
+package trivy
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// gemSpecRe matches a top-level spec line like "    rails (7.0.4)".
+// Dependency constraints are indented further and do not match.
+var gemSpecRe = regexp.MustCompile(`^ {4}([^\s(]+) \(([^)]+)\)$`)
+
+// ParseGemfileLock parses a Bundler Gemfile.lock file.
+// Only specs listed under GEM sections are reported; gems from PATH and
+// GIT sections point at local or unreleased code and are skipped. Platform
+// suffixes such as -x86_64-linux are dropped from versions.
+func ParseGemfileLock(data []byte) ([]Package, error) {
+	var packages []Package
+	seen := make(map[string]bool)
+
+	inGem := false   // Inside a GEM section
+	inSpecs := false // Inside that section's specs: block
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+
+		if line == "" {
+			continue
+		}
+
+		// Unindented lines start a new section
+		if line[0] != ' ' {
+			inGem = line == "GEM"
+			inSpecs = false
+			continue
+		}
+
+		if !inGem {
+			continue
+		}
+
+		if strings.TrimSpace(line) == "specs:" {
+			inSpecs = true
+			continue
+		}
+
+		if !inSpecs {
+			continue
+		}
+
+		matches := gemSpecRe.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+
+		name := matches[1]
+		version := matches[2]
+		if idx := strings.Index(version, "-"); idx != -1 {
+			version = version[:idx]
+		}
+
+		key := name + "@" + version
+		if !seen[key] {
+			seen[key] = true
+			packages = append(packages, Package{
+				Name:      name,
+				Version:   version,
+				Ecosystem: EcosystemRubygems,
+			})
+		}
+	}
+
+	return packages, scanner.Err()
+}
@@ -0,0 +1,113 @@
+// ABOUTME: Unit tests for the Gemfile.lock parser
+// ABOUTME: Covers GEM specs, platform suffixes, and excluded PATH/GIT gems
+
+package trivy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const gemfileLock = `GIT
+  remote: https://github.com/example/internal-gem.git
+  revision: 4f1c2d3e5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d
+  specs:
+    internal-gem (0.3.0)
+      rack (>= 2.0)
+
+PATH
+  remote: .
+  specs:
+    my_app (1.0.0)
+      rails (~> 7.0)
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    actionpack (7.0.4)
+      actionview (= 7.0.4)
+      rack (~> 2.0, >= 2.2.0)
+    actionview (7.0.4)
+    nokogiri (1.13.10-x86_64-linux)
+      racc (~> 1.4)
+    nokogiri (1.13.10-arm64-darwin)
+      racc (~> 1.4)
+    rack (2.2.4)
+    racc (1.6.1)
+    rails (7.0.4)
+      actionpack (= 7.0.4)
+
+PLATFORMS
+  arm64-darwin-22
+  x86_64-linux
+
+DEPENDENCIES
+  internal-gem!
+  my_app!
+  nokogiri
+  rails (~> 7.0)
+
+BUNDLED WITH
+   2.3.26
+`
+
+func TestParseGemfileLock(t *testing.T) {
+	t.Parallel()
+
+	packages, err := ParseGemfileLock([]byte(gemfileLock))
+	if err != nil {
+		t.Fatalf("ParseGemfileLock() error = %v", err)
+	}
+
+	want := map[string]string{
+		"actionpack": "7.0.4",
+		"actionview": "7.0.4",
+		"nokogiri":   "1.13.10",
+		"rack":       "2.2.4",
+		"racc":       "1.6.1",
+		"rails":      "7.0.4",
+	}
+
+	if len(packages) != len(want) {
+		t.Errorf("expected %d packages, got %d: %v", len(want), len(packages), packages)
+	}
+	for _, p := range packages {
+		if p.Ecosystem != EcosystemRubygems {
+			t.Errorf("expected ecosystem rubygems, got %s", p.Ecosystem)
+		}
+		if v, ok := want[p.Name]; !ok || p.Version != v {
+			t.Errorf("unexpected package %s@%s", p.Name, p.Version)
+		}
+	}
+}
+
+func TestParseGemfileLock_CRLF(t *testing.T) {
+	t.Parallel()
+
+	content := "GEM\r\n  remote: https://rubygems.org/\r\n  specs:\r\n    rake (13.0.6)\r\n"
+
+	packages, err := ParseGemfileLock([]byte(content))
+	if err != nil {
+		t.Fatalf("ParseGemfileLock() error = %v", err)
+	}
+	if len(packages) != 1 || packages[0].Name != "rake" || packages[0].Version != "13.0.6" {
+		t.Errorf("expected rake@13.0.6, got %v", packages)
+	}
+}
+
+func TestScanPath_GemfileLock(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "Gemfile.lock"), []byte(gemfileLock), 0o644)
+
+	packages, err := ScanPathForPackages(dir)
+	if err != nil {
+		t.Fatalf("ScanPathForPackages() error = %v", err)
+	}
+
+	if len(packages) != 6 {
+		t.Errorf("expected 6 packages, got %d: %v", len(packages), packages)
+	}
+}
@@ -7,9 +7,16 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+
+	"github.com/ulikunitz/xz"
 )
 
 func TestParseRequirementsTxt(t *testing.T) {
@@ -74,16 +81,19 @@ func TestParsePackageJSON(t *testing.T) {
 		t.Errorf("expected 3 packages, got %d", len(packages))
 	}
 
-	found := make(map[string]string)
+	found := make(map[string]Package)
 	for _, p := range packages {
-		found[p.Name] = p.Version
+		found[p.Name] = p
 		if p.Ecosystem != EcosystemNpm {
 			t.Errorf("expected ecosystem npm, got %s", p.Ecosystem)
 		}
 	}
 
-	if v, ok := found["lodash"]; !ok || v != "4.17.21" {
-		t.Errorf("expected lodash 4.17.21, got %s", v)
+	if p, ok := found["lodash"]; !ok || p.Version != "4.17.21" || p.RawVersion != "^4.17.21" {
+		t.Errorf("expected lodash 4.17.21 from ^4.17.21, got %s from %s", p.Version, p.RawVersion)
+	}
+	if p := found["express"]; p.RawVersion != "" {
+		t.Errorf("expected no raw version for exact express, got %s", p.RawVersion)
 	}
 }
 
@@ -197,6 +207,93 @@ func TestParseComposerJSON(t *testing.T) {
 		if p.Ecosystem != EcosystemComposer {
 			t.Errorf("expected ecosystem composer, got %s", p.Ecosystem)
 		}
+		if p.Name == "symfony/console" && (p.Version != "6.0" || p.RawVersion != "^6.0") {
+			t.Errorf("expected symfony/console 6.0 from ^6.0, got %s from %s", p.Version, p.RawVersion)
+		}
+	}
+}
+
+func TestParseComposerLock(t *testing.T) {
+	t.Parallel()
+
+	content := `{
+    "_readme": ["This file locks the dependencies of your project to a known state"],
+    "content-hash": "0b1c2d3e",
+    "packages": [
+        {
+            "name": "guzzlehttp/guzzle",
+            "version": "7.8.0",
+            "source": {
+                "type": "git",
+                "url": "https://github.com/guzzle/guzzle.git",
+                "reference": "1110f66a6530a40fe7aea0378fe608ee2b2248f9"
+            },
+            "dist": {
+                "type": "zip",
+                "url": "https://api.github.com/repos/guzzle/guzzle/zipball/1110f66a6530a40fe7aea0378fe608ee2b2248f9",
+                "reference": "1110f66a6530a40fe7aea0378fe608ee2b2248f9",
+                "shasum": ""
+            },
+            "require": {"php": "^7.2.5 || ^8.0"},
+            "type": "library"
+        },
+        {
+            "name": "symfony/console",
+            "version": "v6.3.4",
+            "source": {
+                "type": "git",
+                "url": "https://github.com/symfony/console.git",
+                "reference": "eca495f2ee845130855ddf1cf18460c38966c8b6"
+            },
+            "type": "library"
+        },
+        {
+            "name": "acme/internal",
+            "version": "dev-main",
+            "dist": {
+                "type": "path",
+                "url": "../internal",
+                "reference": "abc123"
+            },
+            "type": "library"
+        }
+    ],
+    "packages-dev": [
+        {
+            "name": "phpunit/phpunit",
+            "version": "10.3.5",
+            "type": "library"
+        }
+    ],
+    "aliases": [],
+    "minimum-stability": "stable"
+}`
+
+	packages, err := ParseComposerLock([]byte(content))
+	if err != nil {
+		t.Fatalf("ParseComposerLock() error = %v", err)
+	}
+
+	want := map[string]string{
+		"guzzlehttp/guzzle": "7.8.0",
+		"symfony/console":   "6.3.4",
+		"phpunit/phpunit":   "10.3.5",
+	}
+
+	if len(packages) != len(want) {
+		t.Fatalf("expected %d packages, got %d: %v", len(want), len(packages), packages)
+	}
+
+	for _, p := range packages {
+		if p.Ecosystem != EcosystemComposer {
+			t.Errorf("expected ecosystem composer, got %s", p.Ecosystem)
+		}
+		if p.Name == "" || p.Version == "" {
+			t.Errorf("expected populated name and version, got %+v", p)
+		}
+		if v, ok := want[p.Name]; !ok || v != p.Version {
+			t.Errorf("unexpected package %s@%s", p.Name, p.Version)
+		}
 	}
 }
 
@@ -228,6 +325,187 @@ func TestFindManifests(t *testing.T) {
 	}
 }
 
+func TestScanPathForPackagesWithOptions(t *testing.T) {
+	t.Parallel()
+
+	// Mixed-ecosystem monorepo:
+	//   go.mod                         (depth 1, gomod)
+	//   web/package.json               (depth 2, npm)
+	//   services/ml/requirements.txt   (depth 3, pip)
+	//   services/ml/vendor/Cargo.lock  (skipped dir)
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                        "module example.com/mono\n\ngo 1.22\n\nrequire golang.org/x/net v0.23.0\n",
+		"web/package.json":              `{"dependencies":{"lodash":"4.17.21"}}`,
+		"services/ml/requirements.txt":  "numpy==1.26.0\n",
+		"services/ml/vendor/Cargo.lock": "[[package]]\nname = \"serde\"\nversion = \"1.0.188\"\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte(content), 0o644)
+	}
+
+	tests := []struct {
+		name    string
+		opts    ScanPackagesOptions
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "all ecosystems",
+			opts: ScanPackagesOptions{},
+			want: []string{"golang.org/x/net", "lodash", "numpy"},
+		},
+		{
+			name: "gomod and npm only",
+			opts: ScanPackagesOptions{Ecosystems: []string{EcosystemGomod, EcosystemNpm}},
+			want: []string{"golang.org/x/net", "lodash"},
+		},
+		{
+			name: "root only",
+			opts: ScanPackagesOptions{MaxDepth: 1},
+			want: []string{"golang.org/x/net"},
+		},
+		{
+			name: "two levels",
+			opts: ScanPackagesOptions{MaxDepth: 2},
+			want: []string{"golang.org/x/net", "lodash"},
+		},
+		{
+			name: "pip within depth",
+			opts: ScanPackagesOptions{Ecosystems: []string{EcosystemPip}, MaxDepth: 3},
+			want: []string{"numpy"},
+		},
+		{
+			name:    "unknown ecosystem",
+			opts:    ScanPackagesOptions{Ecosystems: []string{"cocoapods"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			packages, err := ScanPathForPackagesWithOptions(dir, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ScanPathForPackagesWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var got []string
+			for _, p := range packages {
+				got = append(got, p.Name)
+			}
+			sort.Strings(got)
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("packages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindManifestsWithOptions_SkipDirs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := []string{
+		"go.mod",
+		"vendor/github.com/pkg/errors/go.mod",
+		"third_party/lib/package.json",
+		"testdata/fixtures/requirements.txt",
+		"node_modules/lodash/package.json",
+	}
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte("module example.com/x\n"), 0o644)
+	}
+
+	tests := []struct {
+		name string
+		opts ManifestSearchOptions
+		want []string
+	}{
+		{
+			name: "defaults",
+			opts: ManifestSearchOptions{},
+			want: []string{
+				"go.mod",
+				"testdata/fixtures/requirements.txt",
+				"third_party/lib/package.json",
+			},
+		},
+		{
+			name: "custom skip dirs merged with defaults",
+			opts: ManifestSearchOptions{SkipDirs: []string{"third_party", "testdata"}},
+			want: []string{"go.mod"},
+		},
+		{
+			name: "defaults disabled",
+			opts: ManifestSearchOptions{NoDefaultSkipDirs: true, SkipDirs: []string{"node_modules"}},
+			want: []string{
+				"go.mod",
+				"testdata/fixtures/requirements.txt",
+				"third_party/lib/package.json",
+				"vendor/github.com/pkg/errors/go.mod",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			manifests, err := FindManifestsWithOptions(dir, tt.opts)
+			if err != nil {
+				t.Fatalf("FindManifestsWithOptions() error = %v", err)
+			}
+
+			var got []string
+			for _, m := range manifests {
+				rel, _ := filepath.Rel(dir, m)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			sort.Strings(got)
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("manifests = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanPathForPackagesWithOptions_Vendor(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.22\n\nrequire golang.org/x/net v0.23.0\n"), 0o644)
+	vendored := filepath.Join(dir, "vendor", "github.com", "pkg", "errors")
+	os.MkdirAll(vendored, 0o755)
+	os.WriteFile(filepath.Join(vendored, "go.mod"), []byte("module github.com/pkg/errors\n\ngo 1.22\n\nrequire golang.org/x/sys v0.8.0\n"), 0o644)
+
+	packages, err := ScanPathForPackages(dir)
+	if err != nil {
+		t.Fatalf("ScanPathForPackages() error = %v", err)
+	}
+	if len(packages) != 1 {
+		t.Errorf("expected vendor/ to be skipped by default, got %v", packages)
+	}
+
+	packages, err = ScanPathForPackagesWithOptions(dir, ScanPackagesOptions{NoDefaultSkipDirs: true})
+	if err != nil {
+		t.Fatalf("ScanPathForPackagesWithOptions() error = %v", err)
+	}
+	if len(packages) != 2 {
+		t.Errorf("expected vendored go.mod to be scanned, got %v", packages)
+	}
+}
+
 func TestExtractArchive_Zip(t *testing.T) {
 	t.Parallel()
 
@@ -306,6 +584,262 @@ func TestExtractArchive_TarGz(t *testing.T) {
 	}
 }
 
+func TestExtractArchive_TarXz(t *testing.T) {
+	t.Parallel()
+
+	tarPath := filepath.Join(t.TempDir(), "test.tar.xz")
+	tarFile, err := os.Create(tarPath)
+	if err != nil {
+		t.Fatalf("failed to create tar.xz: %v", err)
+	}
+
+	xzw, err := xz.NewWriter(tarFile)
+	if err != nil {
+		t.Fatalf("failed to create xz writer: %v", err)
+	}
+	tw := tar.NewWriter(xzw)
+
+	content := []byte("requests==2.25.0")
+	tw.WriteHeader(&tar.Header{
+		Name: "requirements.txt",
+		Mode: 0o644,
+		Size: int64(len(content)),
+	})
+	tw.Write(content)
+	tw.Close()
+	xzw.Close()
+	tarFile.Close()
+
+	extractDir, err := ExtractArchive(tarPath)
+	if err != nil {
+		t.Fatalf("ExtractArchive() error = %v", err)
+	}
+	defer os.RemoveAll(extractDir)
+
+	data, err := os.ReadFile(filepath.Join(extractDir, "requirements.txt"))
+	if err != nil {
+		t.Fatalf("failed to read extracted file: %v", err)
+	}
+	if string(data) != "requests==2.25.0" {
+		t.Errorf("unexpected content: %s", string(data))
+	}
+}
+
+// tarBz2Fixture is a tar.bz2 containing requirements.txt with
+// "requests==2.25.0"; the standard library has no bzip2 writer.
+const tarBz2Fixture = "QlpoOTFBWSZTWXNauCcAAHj7gMqAEABAAXcCQABiIz5ACAggAHUNQ0majTRmoPSHqCSSMjQeoANB9SZBCBqkIRd3jYRm0kCGBh3o8cq4Ikw4JBwItUtEp1SJzpAXsrx6FAXDYMSk1Pn3YMe6SD8XckU4UJBzWrgn"
+
+func TestExtractArchive_TarBz2(t *testing.T) {
+	t.Parallel()
+
+	raw, err := base64.StdEncoding.DecodeString(tarBz2Fixture)
+	if err != nil {
+		t.Fatalf("decoding fixture: %v", err)
+	}
+
+	tarPath := filepath.Join(t.TempDir(), "test.tar.bz2")
+	if err := os.WriteFile(tarPath, raw, 0o644); err != nil {
+		t.Fatalf("failed to write tar.bz2: %v", err)
+	}
+
+	extractDir, err := ExtractArchive(tarPath)
+	if err != nil {
+		t.Fatalf("ExtractArchive() error = %v", err)
+	}
+	defer os.RemoveAll(extractDir)
+
+	data, err := os.ReadFile(filepath.Join(extractDir, "requirements.txt"))
+	if err != nil {
+		t.Fatalf("failed to read extracted file: %v", err)
+	}
+	if string(data) != "requests==2.25.0" {
+		t.Errorf("unexpected content: %s", string(data))
+	}
+}
+
+// writeGzipBomb writes a tar.gz containing count files of size zero bytes
+// each. Runs of zeros compress roughly 1000:1, so a few KB on disk expand to
+// many MB on extraction.
+func writeGzipBomb(t *testing.T, path string, count int, size int64) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create bomb: %v", err)
+	}
+	defer f.Close()
+
+	gzw, _ := gzip.NewWriterLevel(f, gzip.BestCompression)
+	tw := tar.NewWriter(gzw)
+	zeros := make([]byte, 64*1024)
+
+	for i := 0; i < count; i++ {
+		tw.WriteHeader(&tar.Header{
+			Name: fmt.Sprintf("payload-%d.bin", i),
+			Mode: 0o644,
+			Size: size,
+		})
+		for written := int64(0); written < size; {
+			chunk := int64(len(zeros))
+			if size-written < chunk {
+				chunk = size - written
+			}
+			tw.Write(zeros[:chunk])
+			written += chunk
+		}
+	}
+
+	tw.Close()
+	gzw.Close()
+}
+
+func TestExtractArchiveWithOptions_Limits(t *testing.T) {
+	t.Parallel()
+
+	const mb = 1 << 20
+
+	tests := []struct {
+		name    string
+		count   int
+		size    int64
+		opts    ExtractOptions
+		wantErr bool
+	}{
+		{
+			name:  "within limits",
+			count: 2,
+			size:  mb,
+			opts:  ExtractOptions{MaxTotalBytes: 4 * mb, MaxFiles: 10, MaxFileBytes: 2 * mb},
+		},
+		{
+			name:    "single file too large",
+			count:   1,
+			size:    16 * mb,
+			opts:    ExtractOptions{MaxFileBytes: mb},
+			wantErr: true,
+		},
+		{
+			name:    "total size exceeded",
+			count:   8,
+			size:    mb,
+			opts:    ExtractOptions{MaxTotalBytes: 4 * mb, MaxFileBytes: 2 * mb},
+			wantErr: true,
+		},
+		{
+			name:    "too many files",
+			count:   20,
+			size:    1,
+			opts:    ExtractOptions{MaxFiles: 10},
+			wantErr: true,
+		},
+		{
+			name:  "zero value disables limits",
+			count: 3,
+			size:  mb,
+			opts:  ExtractOptions{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			bombPath := filepath.Join(t.TempDir(), "bomb.tar.gz")
+			writeGzipBomb(t, bombPath, tt.count, tt.size)
+
+			extractDir, err := ExtractArchiveWithOptions(bombPath, tt.opts)
+			if tt.wantErr {
+				if !errors.Is(err, ErrExtractionLimit) {
+					t.Fatalf("expected ErrExtractionLimit, got %v", err)
+				}
+				if extractDir != "" {
+					t.Errorf("expected empty dir on failure, got %q", extractDir)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ExtractArchiveWithOptions() error = %v", err)
+			}
+			defer os.RemoveAll(extractDir)
+
+			entries, _ := os.ReadDir(extractDir)
+			if len(entries) != tt.count {
+				t.Errorf("expected %d files, got %d", tt.count, len(entries))
+			}
+		})
+	}
+}
+
+func TestExtractArchiveWithOptions_ZipFileCount(t *testing.T) {
+	t.Parallel()
+
+	zipPath := filepath.Join(t.TempDir(), "many.zip")
+	zipFile, _ := os.Create(zipPath)
+	w := zip.NewWriter(zipFile)
+	for i := 0; i < 20; i++ {
+		f, _ := w.Create(fmt.Sprintf("file-%d.txt", i))
+		f.Write([]byte("x"))
+	}
+	w.Close()
+	zipFile.Close()
+
+	_, err := ExtractArchiveWithOptions(zipPath, ExtractOptions{MaxFiles: 5})
+	if !errors.Is(err, ErrExtractionLimit) {
+		t.Fatalf("expected ErrExtractionLimit, got %v", err)
+	}
+}
+
+func TestExtractArchiveWithOptions_CleansUpOnFailure(t *testing.T) {
+	// Not parallel: redirects the temp dir used by os.MkdirTemp.
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	bombPath := filepath.Join(t.TempDir(), "bomb.tar.gz")
+	writeGzipBomb(t, bombPath, 1, 8<<20)
+
+	if _, err := ExtractArchiveWithOptions(bombPath, ExtractOptions{MaxFileBytes: 1 << 20}); err == nil {
+		t.Fatal("expected extraction to fail")
+	}
+
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatalf("reading temp dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected partial extraction to be removed, found %d entries", len(entries))
+	}
+}
+
+func TestIsArchive(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"app.zip", true},
+		{"app.tar", true},
+		{"app.tar.gz", true},
+		{"APP.TAR.GZ", true},
+		{"app.gz", true},
+		{"app.tgz", true},
+		{"app.tar.xz", true},
+		{"app.txz", true},
+		{"app.tar.bz2", true},
+		{"app.tbz2", true},
+		{"app.tbz", true},
+		{"requirements.txt", false},
+		{"app.tar.gz.asc", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsArchive(tt.path); got != tt.want {
+			t.Errorf("IsArchive(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
 func TestScanPath_Directory(t *testing.T) {
 	t.Parallel()
 
@@ -364,6 +898,13 @@ func TestDetectManifestType(t *testing.T) {
 		{"Cargo.lock", "cargo"},
 		{"composer.json", "composer"},
 		{"composer.lock", "composer"},
+		{"pom.xml", "maven"},
+		{"gradle.lockfile", "maven"},
+		{"packages.config", "nuget"},
+		{"MyApp.csproj", "nuget"},
+		{"src/MyApp/MyApp.csproj", "nuget"},
+		{"MyApp.csproj.user", ""},
+		{"Gemfile.lock", "rubygems"},
 		{"unknown.txt", ""},
 	}
 
@@ -377,3 +918,105 @@ func TestDetectManifestType(t *testing.T) {
 		})
 	}
 }
+
+func TestCleanNpmVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		spec string
+		want string
+	}{
+		// Exact and prefixed versions
+		{"4.18.2", "4.18.2"},
+		{"^4.17.21", "4.17.21"},
+		{"~1.2.3", "1.2.3"},
+		{"=1.2.3", "1.2.3"},
+		{"v1.2.3", "1.2.3"},
+		{"1.0.0-beta.2", "1.0.0-beta.2"},
+
+		// Ranges resolve to their lowest bound
+		{"1.2.3 - 2.0.0", "1.2.3"},
+		{">=1.0.0 <2.0.0", "1.0.0"},
+		{">= 1.0.0", "1.0.0"},
+		{">1.0.0", "1.0.0"},
+		{"^2.0.0 || ^1.5.0", "1.5.0"},
+		{"<2.0.0", "latest"},
+
+		// X-ranges and partial versions
+		{"1.x", "1.0.0"},
+		{"1.2.x", "1.2.0"},
+		{"~1.2", "1.2.0"},
+		{"2", "2.0.0"},
+
+		// Wildcards and dist-tags
+		{"*", "latest"},
+		{"", "latest"},
+		{"x", "latest"},
+		{"latest", "latest"},
+		{"next", "latest"},
+		{"^1.0.0 || *", "latest"},
+
+		// npm aliases
+		{"npm:string-width@^4.2.0", "4.2.0"},
+
+		// Non-registry specs are skipped
+		{"github:user/repo", ""},
+		{"user/repo#v1.0.0", ""},
+		{"git+https://github.com/user/repo.git", ""},
+		{"git://github.com/user/repo.git#v1.0.0", ""},
+		{"https://example.com/pkg.tgz", ""},
+		{"file:../x", ""},
+		{"link:../y", ""},
+		{"workspace:*", ""},
+		{"./local", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			t.Parallel()
+			if got := cleanNpmVersion(tt.spec); got != tt.want {
+				t.Errorf("cleanNpmVersion(%q) = %q, want %q", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePackageJSON_SkipsNonRegistrySpecs(t *testing.T) {
+	t.Parallel()
+
+	content := `{
+  "dependencies": {
+    "lodash": "^4.17.21",
+    "my-fork": "github:user/repo",
+    "local-lib": "file:../local-lib",
+    "range": ">=1.0.0 <2.0.0"
+  },
+  "devDependencies": {
+    "linked": "link:../linked",
+    "anything": "*"
+  }
+}`
+
+	packages, err := ParsePackageJSON([]byte(content))
+	if err != nil {
+		t.Fatalf("ParsePackageJSON() error = %v", err)
+	}
+
+	found := make(map[string]string)
+	for _, p := range packages {
+		found[p.Name] = p.Version
+		if err := p.Validate(); err != nil {
+			t.Errorf("package %s failed validation: %v", p.Name, err)
+		}
+	}
+
+	if len(found) != 3 {
+		t.Errorf("expected 3 packages, got %d: %v", len(found), found)
+	}
+	if found["range"] != "1.0.0" {
+		t.Errorf("range version = %q, want 1.0.0", found["range"])
+	}
+	if found["anything"] != "latest" {
+		t.Errorf("wildcard version = %q, want latest", found["anything"])
+	}
+}
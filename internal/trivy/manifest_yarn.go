@@ -0,0 +1,108 @@
+// ABOUTME: Yarn lockfile parser for classic (v1) and Berry (v2+) formats
+// ABOUTME: Extracts concrete resolved versions from yarn.lock entries
+
+package trivy
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// yarnLocalProtocols are descriptor protocols that point at local code
+// rather than a registry package and therefore have nothing to scan.
+var yarnLocalProtocols = []string{"workspace:", "link:", "portal:", "file:"}
+
+// ParseYarnLock parses a Yarn yarn.lock file.
+// Both the classic v1 format (version "x.y.z") and the Berry v2+ YAML
+// format (version: x.y.z) are supported. Each entry is reported with its
+// resolved version, deduplicated by name and version.
+func ParseYarnLock(data []byte) ([]Package, error) {
+	var packages []Package
+	seen := make(map[string]bool)
+
+	var name string // Package name of the current entry; empty if skipped
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Unindented lines start a new entry
+		if raw[0] != ' ' && raw[0] != '\t' {
+			name = ""
+			if strings.HasSuffix(line, ":") {
+				name = parseYarnEntryName(strings.TrimSuffix(line, ":"))
+			}
+			continue
+		}
+
+		if name == "" {
+			continue
+		}
+
+		// Only the entry's own version field matters, not nested blocks
+		var version string
+		switch {
+		case strings.HasPrefix(line, "version: "):
+			version = strings.TrimPrefix(line, "version: ")
+		case strings.HasPrefix(line, "version "):
+			version = strings.TrimPrefix(line, "version ")
+		default:
+			continue
+		}
+		version = strings.Trim(strings.TrimSpace(version), `"'`)
+		if version == "" {
+			continue
+		}
+
+		key := name + "@" + version
+		if !seen[key] {
+			seen[key] = true
+			packages = append(packages, Package{
+				Name:      name,
+				Version:   version,
+				Ecosystem: EcosystemNpm,
+			})
+		}
+		name = ""
+	}
+
+	return packages, scanner.Err()
+}
+
+// parseYarnEntryName extracts the package name from a yarn.lock entry header
+// such as `"@babel/core@^7.0.0", "@babel/core@^7.12.3"` or
+// `"@babel/core@npm:^7.0.0, @babel/core@npm:^7.12.3"`.
+// Returns an empty string for metadata blocks and local protocols.
+func parseYarnEntryName(header string) string {
+	// All descriptors in a header share a package; the first is enough
+	descriptor := strings.Split(header, ",")[0]
+	descriptor = strings.Trim(strings.TrimSpace(descriptor), `"'`)
+
+	if descriptor == "" || strings.HasPrefix(descriptor, "__metadata") {
+		return ""
+	}
+
+	// Skip the leading @ of scoped packages when locating the separator
+	idx := strings.Index(descriptor[1:], "@")
+	if idx == -1 {
+		return ""
+	}
+	idx++
+
+	name := descriptor[:idx]
+	spec := descriptor[idx+1:]
+
+	for _, protocol := range yarnLocalProtocols {
+		if strings.HasPrefix(spec, protocol) {
+			return ""
+		}
+	}
+
+	return name
+}
@@ -0,0 +1,164 @@
+// ABOUTME: Unit tests for the yarn.lock parser
+// ABOUTME: Covers classic v1 and Berry v2+ lockfile fixtures
+
+package trivy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const yarnLockV1 = `# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
+# yarn lockfile v1
+
+
+"@babel/code-frame@^7.0.0", "@babel/code-frame@^7.10.4":
+  version "7.12.13"
+  resolved "https://registry.yarnpkg.com/@babel/code-frame/-/code-frame-7.12.13.tgz#dcfc826beef65e75c50e21d3837d7d95798dd658"
+  integrity sha512-HV1Cm0Q3ZrpCR93tkWOYiuYIgLxZXZFVG2VgK+MBWjUqZTundupbfx2aXarXuw5Ko5aMcjtJgbSs4vUGBS5v6g==
+  dependencies:
+    "@babel/highlight" "^7.12.13"
+
+"@babel/core@^7.0.0":
+  version "7.12.3"
+  resolved "https://registry.yarnpkg.com/@babel/core/-/core-7.12.3.tgz"
+
+lodash@^4.17.15, lodash@^4.17.21:
+  version "4.17.21"
+  resolved "https://registry.yarnpkg.com/lodash/-/lodash-4.17.21.tgz"
+
+lodash@^3.0.0:
+  version "3.10.1"
+  resolved "https://registry.yarnpkg.com/lodash/-/lodash-3.10.1.tgz"
+
+"local-lib@file:../local-lib":
+  version "1.0.0"
+`
+
+const yarnLockBerry = `# This file is generated by running "yarn install" inside your project.
+# Manual changes might be lost - proceed with caution!
+
+__metadata:
+  version: 6
+  cacheKey: 8
+
+"@babel/core@npm:^7.0.0, @babel/core@npm:^7.12.3":
+  version: 7.22.9
+  resolution: "@babel/core@npm:7.22.9"
+  dependencies:
+    "@babel/code-frame": ^7.22.5
+  checksum: 4d8d4f6c5a
+  languageName: node
+  linkType: hard
+
+"lodash@npm:^4.17.21":
+  version: 4.17.21
+  resolution: "lodash@npm:4.17.21"
+  languageName: node
+  linkType: hard
+
+"my-app@workspace:.":
+  version: 0.0.0-use.local
+  resolution: "my-app@workspace:."
+  languageName: unknown
+  linkType: soft
+`
+
+func TestParseYarnLock(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    map[string][]string
+	}{
+		{
+			name:    "classic v1",
+			content: yarnLockV1,
+			want: map[string][]string{
+				"@babel/code-frame": {"7.12.13"},
+				"@babel/core":       {"7.12.3"},
+				"lodash":            {"4.17.21", "3.10.1"},
+			},
+		},
+		{
+			name:    "berry v2+",
+			content: yarnLockBerry,
+			want: map[string][]string{
+				"@babel/core": {"7.22.9"},
+				"lodash":      {"4.17.21"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			packages, err := ParseYarnLock([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("ParseYarnLock() error = %v", err)
+			}
+
+			found := make(map[string][]string)
+			for _, p := range packages {
+				if p.Ecosystem != EcosystemNpm {
+					t.Errorf("expected ecosystem npm, got %s", p.Ecosystem)
+				}
+				found[p.Name] = append(found[p.Name], p.Version)
+			}
+
+			if len(found) != len(tt.want) {
+				t.Errorf("expected %d package names, got %d: %v", len(tt.want), len(found), found)
+			}
+			for name, versions := range tt.want {
+				got := found[name]
+				if len(got) != len(versions) {
+					t.Errorf("%s versions = %v, want %v", name, got, versions)
+					continue
+				}
+				for i := range versions {
+					if got[i] != versions[i] {
+						t.Errorf("%s versions = %v, want %v", name, got, versions)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParseYarnLock_Dedupe(t *testing.T) {
+	t.Parallel()
+
+	content := `lodash@^4.17.15:
+  version "4.17.21"
+
+lodash@^4.17.21:
+  version "4.17.21"
+`
+
+	packages, err := ParseYarnLock([]byte(content))
+	if err != nil {
+		t.Fatalf("ParseYarnLock() error = %v", err)
+	}
+	if len(packages) != 1 {
+		t.Errorf("expected 1 package after dedupe, got %d", len(packages))
+	}
+}
+
+func TestScanPath_YarnLock(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "yarn.lock"), []byte(yarnLockV1), 0o644)
+
+	packages, err := ScanPathForPackages(dir)
+	if err != nil {
+		t.Fatalf("ScanPathForPackages() error = %v", err)
+	}
+
+	if len(packages) != 4 {
+		t.Errorf("expected 4 packages, got %d: %v", len(packages), packages)
+	}
+}
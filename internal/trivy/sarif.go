@@ -0,0 +1,238 @@
+// ABOUTME: SARIF 2.1.0 exporter for Trivy scan results
+// ABOUTME: Maps vulnerabilities and secrets to SARIF rules and results
+
+package trivy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// SARIF schema constants.
+const (
+	sarifVersion   = "2.1.0"
+	sarifSchema    = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolName  = "hikmaai-argus"
+	sarifToolURI   = "https://github.com/hikmaai-io/hikmaai-argus"
+	sarifLevelErr  = "error"
+	sarifLevelWarn = "warning"
+	sarifLevelNote = "note"
+)
+
+// SARIFLog is the top-level SARIF document.
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun is a single analysis run.
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+// SARIFTool describes the analysis tool.
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver is the tool component that produced the results.
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+// SARIFRule describes a vulnerability or secret detection rule.
+type SARIFRule struct {
+	ID               string              `json:"id"`
+	Name             string              `json:"name,omitempty"`
+	ShortDescription SARIFMessage        `json:"shortDescription"`
+	FullDescription  *SARIFMessage       `json:"fullDescription,omitempty"`
+	HelpURI          string              `json:"helpUri,omitempty"`
+	Properties       SARIFRuleProperties `json:"properties"`
+}
+
+// SARIFRuleProperties carries rule metadata used by code scanning dashboards.
+type SARIFRuleProperties struct {
+	Tags             []string `json:"tags"`
+	Severity         string   `json:"severity"`
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+}
+
+// SARIFMessage is a plain-text SARIF message.
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+// SARIFResult is a single finding.
+type SARIFResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   SARIFMessage    `json:"message"`
+	Locations []SARIFLocation `json:"locations"`
+}
+
+// SARIFLocation wraps a physical location.
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+// SARIFPhysicalLocation identifies the artifact and optional region.
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+// SARIFArtifactLocation is the URI of the affected artifact.
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFRegion is a line range within an artifact.
+type SARIFRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+// ToSARIF converts a scan result to a SARIF 2.1.0 JSON document.
+// Each vulnerability becomes a result whose rule is its CVE ID and whose
+// artifact is the affected package; each secret becomes a result under a
+// separate rule keyed by its secret rule ID. Vulnerabilities are emitted in
+// SortBySeverity order; result itself is not modified.
+func ToSARIF(result *ScanResult) ([]byte, error) {
+	if result == nil {
+		return nil, fmt.Errorf("scan result is required")
+	}
+
+	sorted := ScanResult{Vulnerabilities: append([]Vulnerability(nil), result.Vulnerabilities...)}
+	sorted.SortBySeverity()
+
+	run := SARIFRun{
+		Tool: SARIFTool{
+			Driver: SARIFDriver{
+				Name:           sarifToolName,
+				InformationURI: sarifToolURI,
+				Rules:          []SARIFRule{},
+			},
+		},
+		Results: []SARIFResult{},
+	}
+
+	ruleIndex := make(map[string]int)
+	addRule := func(rule SARIFRule) int {
+		if idx, ok := ruleIndex[rule.ID]; ok {
+			return idx
+		}
+		idx := len(run.Tool.Driver.Rules)
+		ruleIndex[rule.ID] = idx
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+		return idx
+	}
+
+	for _, v := range sorted.Vulnerabilities {
+		rule := SARIFRule{
+			ID:               v.CVEID,
+			Name:             v.CVEID,
+			ShortDescription: SARIFMessage{Text: sarifText(v.Title, v.CVEID)},
+			Properties: SARIFRuleProperties{
+				Tags:     []string{"vulnerability", "security", v.Severity},
+				Severity: v.Severity,
+			},
+		}
+		if v.Description != "" {
+			rule.FullDescription = &SARIFMessage{Text: v.Description}
+		}
+		if len(v.References) > 0 {
+			rule.HelpURI = v.References[0]
+		}
+		if v.CVSSScore > 0 {
+			rule.Properties.SecuritySeverity = strconv.FormatFloat(v.CVSSScore, 'f', 1, 64)
+		}
+
+		msg := fmt.Sprintf("Package %s@%s is affected by %s", v.Package, v.Version, v.CVEID)
+		if v.FixedVersion != "" {
+			msg += fmt.Sprintf("; fixed in %s", v.FixedVersion)
+		}
+
+		run.Results = append(run.Results, SARIFResult{
+			RuleID:    v.CVEID,
+			RuleIndex: addRule(rule),
+			Level:     sarifLevel(v.Severity),
+			Message:   SARIFMessage{Text: msg},
+			Locations: []SARIFLocation{{
+				PhysicalLocation: SARIFPhysicalLocation{
+					ArtifactLocation: SARIFArtifactLocation{URI: packageURI(v)},
+				},
+			}},
+		})
+	}
+
+	for _, s := range result.Secrets {
+		rule := SARIFRule{
+			ID:               s.RuleID,
+			Name:             s.Category,
+			ShortDescription: SARIFMessage{Text: sarifText(s.Title, s.RuleID)},
+			Properties: SARIFRuleProperties{
+				Tags:     []string{"secret", "security", s.Severity},
+				Severity: s.Severity,
+			},
+		}
+
+		location := SARIFPhysicalLocation{
+			ArtifactLocation: SARIFArtifactLocation{URI: s.Target},
+		}
+		if s.StartLine > 0 {
+			location.Region = &SARIFRegion{StartLine: s.StartLine, EndLine: s.EndLine}
+		}
+
+		run.Results = append(run.Results, SARIFResult{
+			RuleID:    s.RuleID,
+			RuleIndex: addRule(rule),
+			Level:     sarifLevel(s.Severity),
+			Message:   SARIFMessage{Text: fmt.Sprintf("Secret detected: %s", sarifText(s.Title, s.RuleID))},
+			Locations: []SARIFLocation{{PhysicalLocation: location}},
+		})
+	}
+
+	log := SARIFLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []SARIFRun{run},
+	}
+
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding SARIF: %w", err)
+	}
+
+	return data, nil
+}
+
+// sarifLevel maps a Trivy severity to a SARIF result level.
+func sarifLevel(severity string) string {
+	switch severity {
+	case SeverityCritical, SeverityHigh:
+		return sarifLevelErr
+	case SeverityMedium:
+		return sarifLevelWarn
+	default:
+		return sarifLevelNote
+	}
+}
+
+// sarifText returns text, falling back to fallback when empty.
+func sarifText(text, fallback string) string {
+	if text != "" {
+		return text
+	}
+	return fallback
+}
+
+// packageURI identifies a vulnerable package as a SARIF artifact.
+func packageURI(v Vulnerability) string {
+	return fmt.Sprintf("pkg:%s/%s@%s", v.Ecosystem, v.Package, v.Version)
+}
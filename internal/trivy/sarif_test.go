@@ -0,0 +1,215 @@
+// ABOUTME: Unit tests for the SARIF exporter
+// ABOUTME: Compares against a golden file and checks schema-required fields
+
+package trivy
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+func sarifFixture() *ScanResult {
+	vulns := []Vulnerability{
+		{
+			Package:      "requests",
+			Version:      "2.25.0",
+			Ecosystem:    EcosystemPip,
+			CVEID:        "CVE-2023-32681",
+			Severity:     SeverityMedium,
+			Title:        "Unintended leak of Proxy-Authorization header",
+			Description:  "Requests leaks Proxy-Authorization headers to destination servers.",
+			FixedVersion: "2.31.0",
+			References:   []string{"https://avd.aquasec.com/nvd/cve-2023-32681"},
+			CVSSScore:    6.1,
+			CVSSVector:   "CVSS:3.1/AV:N/AC:H/PR:N/UI:R/S:C/C:H/I:N/A:N",
+		},
+		{
+			Package:   "lodash",
+			Version:   "4.17.20",
+			Ecosystem: EcosystemNpm,
+			CVEID:     "CVE-2021-23337",
+			Severity:  SeverityHigh,
+			CVSSScore: 7.2,
+		},
+		{
+			Package:   "lodash-es",
+			Version:   "4.17.20",
+			Ecosystem: EcosystemNpm,
+			CVEID:     "CVE-2021-23337",
+			Severity:  SeverityHigh,
+		},
+	}
+	secrets := []Secret{
+		{
+			RuleID:    "aws-access-key-id",
+			Category:  "AWS",
+			Severity:  SeverityCritical,
+			Title:     "AWS Access Key ID",
+			Target:    "config/settings.py",
+			StartLine: 12,
+			EndLine:   12,
+		},
+	}
+
+	return &ScanResult{
+		Summary:         NewScanSummary(vulns, 3),
+		Vulnerabilities: vulns,
+		Secrets:         secrets,
+		SecretSummary:   NewSecretSummary(secrets),
+	}
+}
+
+func TestToSARIF_Golden(t *testing.T) {
+	t.Parallel()
+
+	got, err := ToSARIF(sarifFixture())
+	if err != nil {
+		t.Fatalf("ToSARIF() error = %v", err)
+	}
+
+	golden := filepath.Join("testdata", "scan_result.sarif")
+	if *updateGolden {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+
+	if !bytes.Equal(bytes.TrimSpace(got), bytes.TrimSpace(want)) {
+		t.Errorf("SARIF output mismatch (run with -update to refresh)\ngot:\n%s", got)
+	}
+}
+
+func TestToSARIF_RequiredFields(t *testing.T) {
+	t.Parallel()
+
+	data, err := ToSARIF(sarifFixture())
+	if err != nil {
+		t.Fatalf("ToSARIF() error = %v", err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	if doc["version"] != "2.1.0" {
+		t.Errorf("version = %v, want 2.1.0", doc["version"])
+	}
+	if doc["$schema"] == nil {
+		t.Error("missing $schema")
+	}
+
+	runs, ok := doc["runs"].([]any)
+	if !ok || len(runs) != 1 {
+		t.Fatalf("expected exactly one run, got %v", doc["runs"])
+	}
+	run := runs[0].(map[string]any)
+
+	driver := run["tool"].(map[string]any)["driver"].(map[string]any)
+	if driver["name"] == "" || driver["name"] == nil {
+		t.Error("missing tool.driver.name")
+	}
+
+	// Duplicate CVE IDs share a rule; the secret gets its own rule.
+	rules := driver["rules"].([]any)
+	if len(rules) != 3 {
+		t.Errorf("expected 3 rules, got %d", len(rules))
+	}
+
+	results := run["results"].([]any)
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
+	}
+
+	for i, r := range results {
+		res := r.(map[string]any)
+		if res["ruleId"] == nil || res["ruleId"] == "" {
+			t.Errorf("result %d missing ruleId", i)
+		}
+		msg, _ := res["message"].(map[string]any)
+		if msg == nil || msg["text"] == "" {
+			t.Errorf("result %d missing message.text", i)
+		}
+		idx := int(res["ruleIndex"].(float64))
+		rule := rules[idx].(map[string]any)
+		if rule["id"] != res["ruleId"] {
+			t.Errorf("result %d ruleIndex points at %v, want %v", i, rule["id"], res["ruleId"])
+		}
+	}
+}
+
+func TestToSARIF_SortedByCVSS(t *testing.T) {
+	t.Parallel()
+
+	result := sarifFixture()
+	data, err := ToSARIF(result)
+	if err != nil {
+		t.Fatalf("ToSARIF() error = %v", err)
+	}
+
+	var log SARIFLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	// Highest CVSS first; the input slice is left untouched.
+	if got := log.Runs[0].Results[0].RuleID; got != "CVE-2021-23337" {
+		t.Errorf("first result = %s, want CVE-2021-23337", got)
+	}
+	if result.Vulnerabilities[0].CVEID != "CVE-2023-32681" {
+		t.Error("ToSARIF must not reorder the input result")
+	}
+}
+
+func TestSarifLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		severity string
+		want     string
+	}{
+		{SeverityCritical, "error"},
+		{SeverityHigh, "error"},
+		{SeverityMedium, "warning"},
+		{SeverityLow, "note"},
+		{SeverityUnknown, "note"},
+	}
+
+	for _, tt := range tests {
+		if got := sarifLevel(tt.severity); got != tt.want {
+			t.Errorf("sarifLevel(%q) = %q, want %q", tt.severity, got, tt.want)
+		}
+	}
+}
+
+func TestToSARIF_Empty(t *testing.T) {
+	t.Parallel()
+
+	data, err := ToSARIF(&ScanResult{})
+	if err != nil {
+		t.Fatalf("ToSARIF() error = %v", err)
+	}
+
+	var log SARIFLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if log.Runs[0].Results == nil {
+		t.Error("results must be an empty array, not null")
+	}
+
+	if _, err := ToSARIF(nil); err == nil {
+		t.Error("expected error for nil result")
+	}
+}
@@ -12,8 +12,17 @@ import (
 	"log/slog"
 	"sort"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/hikmaai-io/hikmaai-argus/internal/observability"
 )
 
+// DefaultMaxBatchSize is the default number of packages sent to the Trivy
+// server in a single blob and scan request.
+const DefaultMaxBatchSize = 500
+
 // ScannerConfig holds configuration for the Scanner.
 type ScannerConfig struct {
 	// ServerURL is the base URL of the Trivy server.
@@ -27,20 +36,32 @@ type ScannerConfig struct {
 
 	// Logger for scan operations.
 	Logger *slog.Logger
+
+	// MaxBatchSize caps the packages sent in one PutBlob/Scan round-trip.
+	// Larger package sets are split into batches. Defaults to
+	// DefaultMaxBatchSize.
+	MaxBatchSize int
+
+	// MaxRetries is how many times a Twirp request is retried after a
+	// transient failure. Zero uses DefaultMaxRetries; a negative value
+	// disables retries.
+	MaxRetries int
 }
 
 // Scanner orchestrates vulnerability scanning via Trivy server.
 type Scanner struct {
-	client *Client
-	cache  *Cache
-	logger *slog.Logger
+	client       *Client
+	cache        *Cache
+	logger       *slog.Logger
+	maxBatchSize int
 }
 
 // NewScanner creates a new Scanner with the given configuration.
 func NewScanner(cfg ScannerConfig) *Scanner {
 	client := NewClient(ClientConfig{
-		ServerURL: cfg.ServerURL,
-		Timeout:   cfg.Timeout,
+		ServerURL:  cfg.ServerURL,
+		Timeout:    cfg.Timeout,
+		MaxRetries: cfg.MaxRetries,
 	})
 
 	logger := cfg.Logger
@@ -48,10 +69,16 @@ func NewScanner(cfg ScannerConfig) *Scanner {
 		logger = slog.Default()
 	}
 
+	maxBatchSize := cfg.MaxBatchSize
+	if maxBatchSize <= 0 {
+		maxBatchSize = DefaultMaxBatchSize
+	}
+
 	return &Scanner{
-		client: client,
-		cache:  cfg.Cache,
-		logger: logger,
+		client:       client,
+		cache:        cfg.Cache,
+		logger:       logger,
+		maxBatchSize: maxBatchSize,
 	}
 }
 
@@ -59,6 +86,41 @@ func NewScanner(cfg ScannerConfig) *Scanner {
 type ScanOptions struct {
 	SeverityFilter []string
 	ScanSecrets    bool
+
+	// ScanLicenses reports the licenses of scanned packages.
+	ScanLicenses bool
+
+	// IgnoreFile is an explicit .trivyignore path. When empty, ScanPath
+	// looks for a .trivyignore at the scan root.
+	IgnoreFile string
+
+	// SecretConfigPath is a trivy secret rule file (trivy-secret.yaml)
+	// adding custom rules, such as internal API key formats. It is passed
+	// as --secret-config in local mode when ScanSecrets is set.
+	SecretConfigPath string
+
+	// ExtendedManifests also collects packages from requirements*.txt
+	// variants and sniffed lockfiles when ScanPath extracts packages in
+	// server mode. It has no effect on the trivy binary in local mode.
+	ExtendedManifests bool
+
+	// SkipGoIndirect leaves out go.mod requirements marked "// indirect"
+	// when ScanPath extracts packages in server mode. Like
+	// ExtendedManifests, it has no effect on the trivy binary.
+	SkipGoIndirect bool
+
+	// OnProgress, if set, is called once cached packages are resolved and
+	// again after each batch completes. It runs on the scanning goroutine.
+	OnProgress func(ScanProgress)
+}
+
+// ManifestOptions returns the manifest discovery options selected by o.
+func (o ScanOptions) ManifestOptions() ScanPackagesOptions {
+	return ScanPackagesOptions{
+		RequirementsVariants: o.ExtendedManifests,
+		SniffContent:         o.ExtendedManifests,
+		SkipGoIndirect:       o.SkipGoIndirect,
+	}
 }
 
 // ScanPackages scans the given packages for vulnerabilities.
@@ -69,6 +131,20 @@ func (s *Scanner) ScanPackages(ctx context.Context, packages []Package, severity
 
 // ScanPackagesWithOptions scans packages with full options including secret scanning.
 func (s *Scanner) ScanPackagesWithOptions(ctx context.Context, packages []Package, opts ScanOptions) (*ScanResult, error) {
+	ctx, span := observability.StartSpan(ctx, "trivy.scan_packages",
+		trace.WithAttributes(attribute.Int("packages.count", len(packages))))
+	defer span.End()
+
+	result, err := s.scanPackages(ctx, packages, opts)
+	if result != nil {
+		span.SetAttributes(attribute.Int("vulnerabilities.count", len(result.Vulnerabilities)))
+	}
+	observability.RecordSpanError(span, err)
+	return result, err
+}
+
+// scanPackages performs the scan for ScanPackagesWithOptions.
+func (s *Scanner) scanPackages(ctx context.Context, packages []Package, opts ScanOptions) (*ScanResult, error) {
 	if len(packages) == 0 {
 		return nil, errors.New("at least one package is required")
 	}
@@ -96,8 +172,14 @@ func (s *Scanner) ScanPackagesWithOptions(ctx context.Context, packages []Packag
 		uncachedPackages = packages
 	}
 
-	// If all packages are cached and no secret scan requested, return aggregated result
-	if len(uncachedPackages) == 0 && !opts.ScanSecrets {
+	// If all packages are cached and no secret or license scan requested,
+	// return aggregated result
+	if len(uncachedPackages) == 0 && !opts.ScanSecrets && !opts.ScanLicenses {
+		if opts.OnProgress != nil {
+			opts.OnProgress(ScanProgress{PackagesScanned: len(packages), PackagesTotal: len(packages)})
+		}
+
+		cachedVulns = DedupeVulnerabilities(cachedVulns)
 		result := &ScanResult{
 			Summary:         NewScanSummary(cachedVulns, len(packages)),
 			Vulnerabilities: cachedVulns,
@@ -113,24 +195,34 @@ func (s *Scanner) ScanPackagesWithOptions(ctx context.Context, packages []Packag
 	}
 
 	// Scan uncached packages via Trivy
-	scanResult, err := s.scanViaTrivy(ctx, uncachedPackages, opts.ScanSecrets)
+	// License findings are not cached, so license scans cover every package.
+	scanPackages := uncachedPackages
+	if opts.ScanLicenses {
+		scanPackages = packages
+	}
+	progress := ScanProgress{
+		PackagesScanned: len(packages) - len(scanPackages),
+		PackagesTotal:   len(packages),
+	}
+	scanResult, err := s.scanInBatches(ctx, scanPackages, opts, progress)
 	if err != nil {
 		return nil, err
 	}
 
-	// Update cache with new results
-	if s.cache != nil {
-		s.cacheResults(ctx, uncachedPackages, scanResult.vulns)
-	}
-
 	// Combine cached and scanned vulnerabilities
-	allVulns := append(cachedVulns, scanResult.vulns...)
+	allVulns := scanResult.vulns
+	if !opts.ScanLicenses {
+		allVulns = append(cachedVulns, scanResult.vulns...)
+	}
+	allVulns = DedupeVulnerabilities(allVulns)
 
 	result := &ScanResult{
 		Summary:         NewScanSummary(allVulns, len(packages)),
 		Vulnerabilities: allVulns,
 		Secrets:         scanResult.secrets,
 		SecretSummary:   NewSecretSummary(scanResult.secrets),
+		Licenses:        scanResult.licenses,
+		LicenseSummary:  NewLicenseSummary(scanResult.licenses),
 		ScannedAt:       time.Now(),
 		ScanTimeMs:      float64(time.Since(startTime).Milliseconds()),
 	}
@@ -146,12 +238,63 @@ func (s *Scanner) ScanPackagesWithOptions(ctx context.Context, packages []Packag
 
 // scanTrivyResult holds the results from a Trivy scan.
 type scanTrivyResult struct {
-	vulns   []Vulnerability
-	secrets []Secret
+	vulns    []Vulnerability
+	secrets  []Secret
+	licenses []License
 }
 
-// scanViaTrivy performs the full Trivy Twirp workflow.
-func (s *Scanner) scanViaTrivy(ctx context.Context, packages []Package, scanSecrets bool) (*scanTrivyResult, error) {
+// scanInBatches scans packages in batches of at most maxBatchSize, caching
+// each batch's results as it completes, and merges the findings. Progress
+// starts from progress and is reported through opts.OnProgress.
+func (s *Scanner) scanInBatches(ctx context.Context, packages []Package, opts ScanOptions, progress ScanProgress) (*scanTrivyResult, error) {
+	merged := &scanTrivyResult{}
+	batches := (len(packages) + s.maxBatchSize - 1) / s.maxBatchSize
+
+	progress.Batches = batches
+	if opts.OnProgress != nil {
+		opts.OnProgress(progress)
+	}
+
+	for i := range batches {
+		batch := packages[i*s.maxBatchSize : min((i+1)*s.maxBatchSize, len(packages))]
+		if batches > 1 {
+			s.logger.Debug("scanning package batch",
+				slog.Int("batch", i+1),
+				slog.Int("batches", batches),
+				slog.Int("packages", len(batch)),
+			)
+		}
+
+		result, err := s.scanViaTrivy(ctx, batch, opts)
+		if err != nil {
+			if batches > 1 {
+				return nil, fmt.Errorf("batch %d/%d: %w", i+1, batches, err)
+			}
+			return nil, err
+		}
+
+		// Update cache with new results
+		if s.cache != nil {
+			s.cacheResults(ctx, batch, result.vulns)
+		}
+
+		merged.vulns = append(merged.vulns, result.vulns...)
+		merged.secrets = append(merged.secrets, result.secrets...)
+		merged.licenses = append(merged.licenses, result.licenses...)
+
+		progress.PackagesScanned += len(batch)
+		progress.Batch = i + 1
+		if opts.OnProgress != nil {
+			opts.OnProgress(progress)
+		}
+	}
+
+	return merged, nil
+}
+
+// scanViaTrivy performs the full Trivy Twirp workflow for one batch of
+// packages.
+func (s *Scanner) scanViaTrivy(ctx context.Context, packages []Package, opts ScanOptions) (*scanTrivyResult, error) {
 	// Generate IDs
 	blobID := generateBlobID(packages)
 	artifactID := generateArtifactID(blobID)
@@ -160,7 +303,8 @@ func (s *Scanner) scanViaTrivy(ctx context.Context, packages []Package, scanSecr
 		slog.String("blob_id", blobID),
 		slog.String("artifact_id", artifactID),
 		slog.Int("packages", len(packages)),
-		slog.Bool("scan_secrets", scanSecrets),
+		slog.Bool("scan_secrets", opts.ScanSecrets),
+		slog.Bool("scan_licenses", opts.ScanLicenses),
 	)
 
 	// Step 1: PutBlob
@@ -194,9 +338,12 @@ func (s *Scanner) scanViaTrivy(ctx context.Context, packages []Package, scanSecr
 
 	// Step 3: Scan
 	scanners := []string{"vuln"}
-	if scanSecrets {
+	if opts.ScanSecrets {
 		scanners = append(scanners, "secret")
 	}
+	if opts.ScanLicenses {
+		scanners = append(scanners, "license")
+	}
 
 	scanReq := TwirpScanRequest{
 		Target:     "dependency-scan",
@@ -216,6 +363,7 @@ func (s *Scanner) scanViaTrivy(ctx context.Context, packages []Package, scanSecr
 	// Convert Twirp results to our types
 	var vulns []Vulnerability
 	var secrets []Secret
+	var licenses []License
 
 	for _, result := range scanResp.Results {
 		ecosystem := result.Type
@@ -228,14 +376,21 @@ func (s *Scanner) scanViaTrivy(ctx context.Context, packages []Package, scanSecr
 		for _, ts := range result.Secrets {
 			secrets = append(secrets, ts.ToSecret(result.Target))
 		}
+		for _, tl := range result.Licenses {
+			licenses = append(licenses, tl.ToLicense())
+		}
 	}
 
+	// The same finding may be reported under several targets
+	vulns = DedupeVulnerabilities(vulns)
+
 	s.logger.Debug("trivy scan complete",
 		slog.Int("vulnerabilities", len(vulns)),
 		slog.Int("secrets", len(secrets)),
+		slog.Int("licenses", len(licenses)),
 	)
 
-	return &scanTrivyResult{vulns: vulns, secrets: secrets}, nil
+	return &scanTrivyResult{vulns: vulns, secrets: secrets, licenses: licenses}, nil
 }
 
 // cacheResults stores scan results in cache, grouped by package.
@@ -6,8 +6,11 @@ package trivy
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 )
@@ -414,6 +417,317 @@ func TestScanner_ScanPackages_AllCached(t *testing.T) {
 	}
 }
 
+func TestScanner_ScanPackagesWithOptions_Licenses(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		scanners []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/twirp/trivy.cache.v1.Cache/PutBlob",
+			"/twirp/trivy.cache.v1.Cache/PutArtifact":
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{}`))
+		case "/twirp/trivy.scanner.v1.Scanner/Scan":
+			var req TwirpScanRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			mu.Lock()
+			scanners = req.Options.Scanners
+			mu.Unlock()
+
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"Results":[{"Target":"dependency-scan","Licenses":[
+				{"Severity":"HIGH","Category":"RESTRICTED","PkgName":"gpl-lib","Name":"GPL-3.0","Confidence":1},
+				{"Severity":"LOW","Category":"NOTICE","PkgName":"requests","Name":"Apache-2.0","Confidence":1,"Link":"https://spdx.org/licenses/Apache-2.0.html"}
+			]}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cache, _ := NewCache(CacheConfig{InMemory: true, TTL: 1 * time.Hour})
+	defer cache.Close()
+
+	ctx := context.Background()
+	pkg := Package{Name: "requests", Version: "2.31.0", Ecosystem: EcosystemPip}
+	// A cached package must still be sent to the server for its license.
+	cache.Set(ctx, pkg, []Vulnerability{})
+
+	scanner := NewScanner(ScannerConfig{
+		ServerURL: server.URL,
+		Timeout:   5 * time.Second,
+		Cache:     cache,
+	})
+
+	packages := []Package{pkg, {Name: "gpl-lib", Version: "1.0.0", Ecosystem: EcosystemPip}}
+	result, err := scanner.ScanPackagesWithOptions(ctx, packages, ScanOptions{
+		SeverityFilter: []string{SeverityCritical},
+		ScanLicenses:   true,
+	})
+	if err != nil {
+		t.Fatalf("ScanPackagesWithOptions() error = %v", err)
+	}
+
+	mu.Lock()
+	gotScanners := scanners
+	mu.Unlock()
+	if !slices.Contains(gotScanners, "license") {
+		t.Errorf("Scan request scanners = %v, want license included", gotScanners)
+	}
+
+	// Licenses are not filtered by the vulnerability severity filter.
+	if len(result.Licenses) != 2 {
+		t.Fatalf("expected 2 licenses, got %d", len(result.Licenses))
+	}
+	gpl := result.Licenses[0]
+	if gpl.Name != "GPL-3.0" || gpl.Package != "gpl-lib" || gpl.Category != "restricted" || gpl.Severity != SeverityHigh {
+		t.Errorf("Licenses[0] = %+v, want GPL-3.0 for gpl-lib, restricted, HIGH", gpl)
+	}
+
+	if result.LicenseSummary == nil {
+		t.Fatal("LicenseSummary is nil")
+	}
+	if result.LicenseSummary.TotalLicenses != 2 || result.LicenseSummary.High != 1 || result.LicenseSummary.Low != 1 {
+		t.Errorf("LicenseSummary = %+v, want 2 total, 1 high, 1 low", result.LicenseSummary)
+	}
+}
+
+func TestScanner_ScanPackages_Batches(t *testing.T) {
+	t.Parallel()
+
+	// The server reports one HIGH vulnerability for every package in the
+	// scanned blob, so each batch's findings are distinguishable.
+	var (
+		mu        sync.Mutex
+		blobs     = make(map[string][]TwirpPackageInfo)
+		scanCalls int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/twirp/trivy.cache.v1.Cache/PutBlob":
+			var req TwirpPutBlobRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			mu.Lock()
+			blobs[req.DiffID] = req.BlobInfo.Packages
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{}`))
+		case "/twirp/trivy.cache.v1.Cache/PutArtifact":
+			_, _ = w.Write([]byte(`{}`))
+		case "/twirp/trivy.scanner.v1.Scanner/Scan":
+			var req TwirpScanRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+
+			mu.Lock()
+			scanCalls++
+			var vulns []TwirpVulnerability
+			for _, id := range req.BlobIDs {
+				for _, pkg := range blobs[id] {
+					vulns = append(vulns, TwirpVulnerability{
+						VulnerabilityID:  "CVE-" + pkg.Name,
+						PkgName:          pkg.Name,
+						InstalledVersion: pkg.Version,
+						Severity:         SeverityHigh,
+					})
+				}
+			}
+			mu.Unlock()
+
+			_ = json.NewEncoder(w).Encode(TwirpScanResponse{
+				Results: []TwirpResult{{Target: "dependency-scan", Type: "pip", Vulnerabilities: vulns}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cache, _ := NewCache(CacheConfig{InMemory: true, TTL: 1 * time.Hour})
+	defer cache.Close()
+
+	scanner := NewScanner(ScannerConfig{
+		ServerURL:    server.URL,
+		Timeout:      5 * time.Second,
+		Cache:        cache,
+		MaxBatchSize: 2,
+	})
+
+	var packages []Package
+	for i := range 5 {
+		packages = append(packages, Package{Name: fmt.Sprintf("pkg%d", i), Version: "1.0.0", Ecosystem: EcosystemPip})
+	}
+
+	ctx := context.Background()
+	result, err := scanner.ScanPackages(ctx, packages, nil)
+	if err != nil {
+		t.Fatalf("ScanPackages() error = %v", err)
+	}
+
+	mu.Lock()
+	gotCalls, gotBlobs := scanCalls, len(blobs)
+	mu.Unlock()
+	if gotCalls != 3 {
+		t.Errorf("Scan called %d times, want 3", gotCalls)
+	}
+	if gotBlobs != 3 {
+		t.Errorf("PutBlob stored %d distinct blobs, want 3", gotBlobs)
+	}
+
+	if result.Summary.TotalVulnerabilities != 5 || result.Summary.High != 5 {
+		t.Errorf("Summary = %+v, want 5 high vulnerabilities", result.Summary)
+	}
+	if result.Summary.PackagesScanned != 5 {
+		t.Errorf("PackagesScanned = %d, want 5", result.Summary.PackagesScanned)
+	}
+
+	// Every package is cached individually regardless of its batch.
+	cached, uncached := cache.GetMultiple(ctx, packages)
+	if len(uncached) != 0 || len(cached) != 5 {
+		t.Errorf("cache holds %d packages with %d missing, want all 5 cached", len(cached), len(uncached))
+	}
+}
+
+func TestScanner_ScanPackages_DuplicateVulnerabilities(t *testing.T) {
+	t.Parallel()
+
+	// The same CVE is reported under two targets, once without details.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/twirp/trivy.cache.v1.Cache/PutBlob", "/twirp/trivy.cache.v1.Cache/PutArtifact":
+			_, _ = w.Write([]byte(`{}`))
+		case "/twirp/trivy.scanner.v1.Scanner/Scan":
+			_ = json.NewEncoder(w).Encode(TwirpScanResponse{
+				Results: []TwirpResult{
+					{
+						Target: "requirements.txt",
+						Type:   "pip",
+						Vulnerabilities: []TwirpVulnerability{{
+							VulnerabilityID:  "CVE-2023-32681",
+							PkgName:          "requests",
+							InstalledVersion: "2.25.0",
+							Severity:         "HIGH",
+						}},
+					},
+					{
+						Target: "requirements-dev.txt",
+						Type:   "pip",
+						Vulnerabilities: []TwirpVulnerability{{
+							VulnerabilityID:  "CVE-2023-32681",
+							PkgName:          "requests",
+							InstalledVersion: "2.25.0",
+							FixedVersion:     "2.31.0",
+							Severity:         "HIGH",
+							Title:            "Proxy-Auth header leak",
+						}},
+					},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cache, _ := NewCache(CacheConfig{InMemory: true, TTL: 1 * time.Hour})
+	defer cache.Close()
+
+	scanner := NewScanner(ScannerConfig{
+		ServerURL: server.URL,
+		Timeout:   5 * time.Second,
+		Cache:     cache,
+	})
+
+	packages := []Package{{Name: "requests", Version: "2.25.0", Ecosystem: EcosystemPip}}
+
+	// The second scan is answered from the cache.
+	for _, pass := range []string{"scanned", "cached"} {
+		result, err := scanner.ScanPackages(context.Background(), packages, nil)
+		if err != nil {
+			t.Fatalf("%s: ScanPackages() error = %v", pass, err)
+		}
+
+		if result.Summary.TotalVulnerabilities != 1 || result.Summary.High != 1 {
+			t.Errorf("%s: Summary = %+v, want 1 high vulnerability", pass, result.Summary)
+		}
+		if len(result.Vulnerabilities) != 1 {
+			t.Fatalf("%s: expected 1 vulnerability, got %d", pass, len(result.Vulnerabilities))
+		}
+		if v := result.Vulnerabilities[0]; v.FixedVersion != "2.31.0" || v.Title != "Proxy-Auth header leak" {
+			t.Errorf("%s: kept the less complete entry: %+v", pass, v)
+		}
+	}
+}
+
+func TestScanner_ScanPackages_Progress(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/twirp/trivy.cache.v1.Cache/PutBlob", "/twirp/trivy.cache.v1.Cache/PutArtifact":
+			_, _ = w.Write([]byte(`{}`))
+		case "/twirp/trivy.scanner.v1.Scanner/Scan":
+			_, _ = w.Write([]byte(`{"Results":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cache, _ := NewCache(CacheConfig{InMemory: true, TTL: 1 * time.Hour})
+	defer cache.Close()
+
+	scanner := NewScanner(ScannerConfig{
+		ServerURL:    server.URL,
+		Timeout:      5 * time.Second,
+		Cache:        cache,
+		MaxBatchSize: 2,
+	})
+
+	var packages []Package
+	for i := range 5 {
+		packages = append(packages, Package{Name: fmt.Sprintf("pkg%d", i), Version: "1.0.0", Ecosystem: EcosystemPip})
+	}
+
+	ctx := context.Background()
+	_ = cache.Set(ctx, packages[0], nil)
+
+	var got []ScanProgress
+	_, err := scanner.ScanPackagesWithOptions(ctx, packages, ScanOptions{
+		OnProgress: func(p ScanProgress) { got = append(got, p) },
+	})
+	if err != nil {
+		t.Fatalf("ScanPackagesWithOptions() error = %v", err)
+	}
+
+	// One cached package, then two batches of two.
+	want := []ScanProgress{
+		{PackagesScanned: 1, PackagesTotal: 5, Batch: 0, Batches: 2},
+		{PackagesScanned: 3, PackagesTotal: 5, Batch: 1, Batches: 2},
+		{PackagesScanned: 5, PackagesTotal: 5, Batch: 2, Batches: 2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("progress = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("progress[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// A fully cached scan reports completion once.
+	got = nil
+	if _, err := scanner.ScanPackagesWithOptions(ctx, packages, ScanOptions{
+		OnProgress: func(p ScanProgress) { got = append(got, p) },
+	}); err != nil {
+		t.Fatalf("ScanPackagesWithOptions() error = %v", err)
+	}
+	if len(got) != 1 || got[0].PackagesScanned != 5 || got[0].PackagesTotal != 5 {
+		t.Errorf("cached progress = %+v, want a single 5/5 report", got)
+	}
+}
+
 func TestGenerateBlobID(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,87 @@
+// ABOUTME: Target type detection for local trivy scans of paths, images, and repositories
+// ABOUTME: Maps a scan target to the trivy subcommand (fs, image, or repo) that handles it
+
+package trivy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Target types for local scans. Each except TargetTypeAuto names the trivy
+// subcommand used to scan the target.
+const (
+	TargetTypeAuto  = "auto"
+	TargetTypeFS    = "fs"
+	TargetTypeImage = "image"
+	TargetTypeRepo  = "repo"
+)
+
+// gitPrefix marks a target as a git repository, e.g. "git::https://host/repo".
+const gitPrefix = "git::"
+
+// IsValidTargetType returns true if targetType is a known target type.
+// The empty string is treated as TargetTypeAuto.
+func IsValidTargetType(targetType string) bool {
+	switch targetType {
+	case "", TargetTypeAuto, TargetTypeFS, TargetTypeImage, TargetTypeRepo:
+		return true
+	default:
+		return false
+	}
+}
+
+// DetectTargetType guesses the target type of target. Targets with a
+// "git::" prefix, remote URLs, and ".git" URLs are repositories; anything
+// that exists on disk or looks like a path is a filesystem target. Other
+// targets are container image references only when they carry a tag or
+// digest, such as "alpine:3.19"; a bare name like "alpine" is a
+// filesystem target, so a mistyped directory is not pulled as an image.
+func DetectTargetType(target string) string {
+	switch {
+	case strings.HasPrefix(target, gitPrefix),
+		strings.HasPrefix(target, "git@"),
+		strings.HasPrefix(target, "https://"),
+		strings.HasPrefix(target, "http://"),
+		strings.HasSuffix(target, ".git"):
+		return TargetTypeRepo
+	}
+
+	if _, err := os.Stat(target); err == nil {
+		return TargetTypeFS
+	}
+	if target == "" || strings.ContainsAny(target, `\ `) ||
+		strings.HasPrefix(target, "/") || strings.HasPrefix(target, ".") || strings.HasPrefix(target, "~") ||
+		IsArchive(target) {
+		return TargetTypeFS
+	}
+	if strings.ContainsAny(target, ":@") {
+		return TargetTypeImage
+	}
+
+	return TargetTypeFS
+}
+
+// ResolveTarget returns the target type and the target as trivy expects
+// it. An empty or auto targetType is detected with DetectTargetType, and
+// the "git::" prefix is stripped from repository targets. A detected
+// filesystem target that does not exist is an error.
+func ResolveTarget(target, targetType string) (string, string, error) {
+	if !IsValidTargetType(targetType) {
+		return "", "", fmt.Errorf("invalid target type %q; expected auto, fs, image, or repo", targetType)
+	}
+	if targetType == "" || targetType == TargetTypeAuto {
+		targetType = DetectTargetType(target)
+		if targetType == TargetTypeFS {
+			if _, err := os.Stat(target); err != nil {
+				return "", "", fmt.Errorf("target %q: %w; use target type image for an untagged image", target, err)
+			}
+		}
+	}
+	if targetType == TargetTypeRepo {
+		target = strings.TrimPrefix(target, gitPrefix)
+	}
+
+	return targetType, target, nil
+}
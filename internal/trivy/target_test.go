@@ -0,0 +1,122 @@
+// ABOUTME: Unit tests for trivy scan target type detection
+// ABOUTME: Covers paths, archives, image references, and git repository targets
+
+package trivy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectTargetType(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "requirements.txt")
+	if err := os.WriteFile(file, []byte("requests==2.25.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{target: dir, want: TargetTypeFS},
+		{target: file, want: TargetTypeFS},
+		{target: "./missing/project", want: TargetTypeFS},
+		{target: "/missing/project", want: TargetTypeFS},
+		{target: "app.zip", want: TargetTypeFS},
+		{target: "alpine", want: TargetTypeFS},
+		{target: "ghcr.io/org/app", want: TargetTypeFS},
+		{target: "alpine:3.19", want: TargetTypeImage},
+		{target: "ghcr.io/org/app:v1.2.3", want: TargetTypeImage},
+		{target: "nginx@sha256:0d17b565c37bcbd895e9d92315a05c1c3c9a29f762b011a10c54a66cd53c9b31", want: TargetTypeImage},
+		{target: "git::https://github.com/org/repo", want: TargetTypeRepo},
+		{target: "https://github.com/org/repo", want: TargetTypeRepo},
+		{target: "git@github.com:org/repo.git", want: TargetTypeRepo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			t.Parallel()
+
+			if got := DetectTargetType(tt.target); got != tt.want {
+				t.Errorf("DetectTargetType(%q) = %q, want %q", tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveTarget(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		target     string
+		targetType string
+		wantType   string
+		wantTarget string
+		wantErr    bool
+	}{
+		{
+			name:       "auto repo strips prefix",
+			target:     "git::https://github.com/org/repo",
+			targetType: TargetTypeAuto,
+			wantType:   TargetTypeRepo,
+			wantTarget: "https://github.com/org/repo",
+		},
+		{
+			name:       "empty type detects",
+			target:     "alpine:3.19",
+			wantType:   TargetTypeImage,
+			wantTarget: "alpine:3.19",
+		},
+		{
+			name:       "explicit type wins",
+			target:     "myapp",
+			targetType: TargetTypeFS,
+			wantType:   TargetTypeFS,
+			wantTarget: "myapp",
+		},
+		{
+			name:       "explicit repo strips prefix",
+			target:     "git::https://github.com/org/repo",
+			targetType: TargetTypeRepo,
+			wantType:   TargetTypeRepo,
+			wantTarget: "https://github.com/org/repo",
+		},
+		{
+			name:    "missing path",
+			target:  "alpine",
+			wantErr: true,
+		},
+		{
+			name:       "explicit image without tag",
+			target:     "alpine",
+			targetType: TargetTypeImage,
+			wantType:   TargetTypeImage,
+			wantTarget: "alpine",
+		},
+		{
+			name:       "invalid type",
+			target:     "alpine",
+			targetType: "vm",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			gotType, gotTarget, err := ResolveTarget(tt.target, tt.targetType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotType != tt.wantType || gotTarget != tt.wantTarget {
+				t.Errorf("ResolveTarget() = (%q, %q), want (%q, %q)", gotType, gotTarget, tt.wantType, tt.wantTarget)
+			}
+		})
+	}
+}
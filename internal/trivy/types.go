@@ -6,6 +6,8 @@ package trivy
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -67,6 +69,21 @@ type Package struct {
 	Version   string `json:"version"`
 	Ecosystem string `json:"ecosystem"`
 	SrcName   string `json:"src_name,omitempty"`
+
+	// RawVersion is the constraint as declared in the manifest (e.g.
+	// "^1.2.3" or "${jackson.version}") when Version had to be cleaned
+	// or resolved from it. It is informational and not part of CacheKey.
+	RawVersion string `json:"raw_version,omitempty"`
+}
+
+// rawVersion returns declared when it differs from the cleaned version,
+// for use as Package.RawVersion.
+func rawVersion(declared, cleaned string) string {
+	declared = strings.TrimSpace(declared)
+	if declared == cleaned {
+		return ""
+	}
+	return declared
 }
 
 // Validate checks that the package has all required fields and a valid ecosystem.
@@ -111,6 +128,33 @@ type Secret struct {
 	Match     string `json:"match,omitempty"`
 }
 
+// Misconfiguration is a failed configuration check, e.g. in a Dockerfile or
+// Kubernetes manifest.
+type Misconfiguration struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Message     string `json:"message,omitempty"`
+	Resolution  string `json:"resolution,omitempty"`
+	Severity    string `json:"severity"`
+	Target      string `json:"target"`
+	StartLine   int    `json:"start_line,omitempty"`
+	EndLine     int    `json:"end_line,omitempty"`
+	PrimaryURL  string `json:"primary_url,omitempty"`
+}
+
+// License is a license detected for a package or file.
+type License struct {
+	Name       string  `json:"name"`
+	Package    string  `json:"package,omitempty"`
+	FilePath   string  `json:"file_path,omitempty"`
+	Category   string  `json:"category,omitempty"`
+	Severity   string  `json:"severity"`
+	Confidence float64 `json:"confidence,omitempty"`
+	Link       string  `json:"link,omitempty"`
+}
+
 // Validate checks that the request has valid packages and severity filters.
 func (r ScanRequest) Validate() error {
 	if len(r.Packages) == 0 {
@@ -143,6 +187,17 @@ type Vulnerability struct {
 	Description  string   `json:"description,omitempty"`
 	FixedVersion string   `json:"fixed_version,omitempty"`
 	References   []string `json:"references,omitempty"`
+	CVSSScore    float64  `json:"cvss_score,omitempty"`
+	CVSSVector   string   `json:"cvss_vector,omitempty"`
+}
+
+// severityRank orders severities from most to least severe.
+var severityRank = map[string]int{
+	SeverityCritical: 4,
+	SeverityHigh:     3,
+	SeverityMedium:   2,
+	SeverityLow:      1,
+	SeverityUnknown:  0,
 }
 
 // MatchesSeverityFilter returns true if the vulnerability matches the severity filter.
@@ -159,6 +214,86 @@ func (v Vulnerability) MatchesSeverityFilter(filter []string) bool {
 	return false
 }
 
+// completeness scores how much detail a vulnerability carries, so the
+// richer of two duplicate reports can be kept.
+func (v Vulnerability) completeness() int {
+	score := 0
+	for _, field := range []string{v.FixedVersion, v.Title, v.Description, v.CVSSVector} {
+		if field != "" {
+			score++
+		}
+	}
+	if v.CVSSScore > 0 {
+		score++
+	}
+	if len(v.References) > 0 {
+		score++
+	}
+	if v.Severity != "" && v.Severity != SeverityUnknown {
+		score++
+	}
+	return score
+}
+
+// mergeFrom fills the fields v is missing from other.
+func (v *Vulnerability) mergeFrom(other Vulnerability) {
+	if v.Ecosystem == "" {
+		v.Ecosystem = other.Ecosystem
+	}
+	if v.Severity == "" || v.Severity == SeverityUnknown {
+		v.Severity = other.Severity
+	}
+	if v.Title == "" {
+		v.Title = other.Title
+	}
+	if v.Description == "" {
+		v.Description = other.Description
+	}
+	if v.FixedVersion == "" {
+		v.FixedVersion = other.FixedVersion
+	}
+	if len(v.References) == 0 {
+		v.References = other.References
+	}
+	if v.CVSSScore == 0 {
+		v.CVSSScore = other.CVSSScore
+		v.CVSSVector = other.CVSSVector
+	}
+}
+
+// DedupeVulnerabilities merges vulnerabilities reported more than once for
+// the same CVE, package, and version, as happens when a package appears in
+// several manifests or the server reports it under several targets. The
+// most complete report is kept, with any fields it lacks filled in from
+// the duplicates. Order of first appearance is preserved.
+func DedupeVulnerabilities(vulns []Vulnerability) []Vulnerability {
+	if len(vulns) < 2 {
+		return vulns
+	}
+
+	merged := make([]Vulnerability, 0, len(vulns))
+	index := make(map[string]int, len(vulns))
+
+	for _, v := range vulns {
+		key := v.CVEID + "|" + v.Package + "|" + v.Version
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			merged = append(merged, v)
+			continue
+		}
+
+		kept := merged[i]
+		if v.completeness() > kept.completeness() {
+			kept, v = v, kept
+		}
+		kept.mergeFrom(v)
+		merged[i] = kept
+	}
+
+	return merged
+}
+
 // ScanSummary provides counts by severity.
 type ScanSummary struct {
 	TotalVulnerabilities int `json:"total_vulnerabilities"`
@@ -167,6 +302,7 @@ type ScanSummary struct {
 	Medium               int `json:"medium"`
 	Low                  int `json:"low"`
 	PackagesScanned      int `json:"packages_scanned"`
+	Suppressed           int `json:"suppressed"`
 }
 
 // NewScanSummary creates a summary from a list of vulnerabilities.
@@ -194,12 +330,15 @@ func NewScanSummary(vulns []Vulnerability, packagesScanned int) ScanSummary {
 
 // ScanResult is the result of a dependency scan.
 type ScanResult struct {
-	Summary         ScanSummary     `json:"summary"`
-	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
-	Secrets         []Secret        `json:"secrets,omitempty"`
-	SecretSummary   *SecretSummary  `json:"secret_summary,omitempty"`
-	ScannedAt       time.Time       `json:"scanned_at"`
-	ScanTimeMs      float64         `json:"scan_time_ms"`
+	Summary           ScanSummary        `json:"summary"`
+	Vulnerabilities   []Vulnerability    `json:"vulnerabilities"`
+	Secrets           []Secret           `json:"secrets,omitempty"`
+	SecretSummary     *SecretSummary     `json:"secret_summary,omitempty"`
+	Misconfigurations []Misconfiguration `json:"misconfigurations,omitempty"`
+	Licenses          []License          `json:"licenses,omitempty"`
+	LicenseSummary    *LicenseSummary    `json:"license_summary,omitempty"`
+	ScannedAt         time.Time          `json:"scanned_at"`
+	ScanTimeMs        float64            `json:"scan_time_ms"`
 }
 
 // SecretSummary provides counts of detected secrets by severity.
@@ -237,7 +376,46 @@ func NewSecretSummary(secrets []Secret) *SecretSummary {
 	return summary
 }
 
-// FilterBySeverity returns a new ScanResult with only vulnerabilities matching the filter.
+// LicenseSummary provides counts of detected licenses by severity.
+type LicenseSummary struct {
+	TotalLicenses int `json:"total_licenses"`
+	Critical      int `json:"critical"`
+	High          int `json:"high"`
+	Medium        int `json:"medium"`
+	Low           int `json:"low"`
+	Unknown       int `json:"unknown"`
+}
+
+// NewLicenseSummary creates a summary from a list of licenses.
+func NewLicenseSummary(licenses []License) *LicenseSummary {
+	if len(licenses) == 0 {
+		return nil
+	}
+
+	summary := &LicenseSummary{
+		TotalLicenses: len(licenses),
+	}
+
+	for _, l := range licenses {
+		switch l.Severity {
+		case SeverityCritical:
+			summary.Critical++
+		case SeverityHigh:
+			summary.High++
+		case SeverityMedium:
+			summary.Medium++
+		case SeverityLow:
+			summary.Low++
+		default:
+			summary.Unknown++
+		}
+	}
+
+	return summary
+}
+
+// FilterBySeverity returns a new ScanResult with only vulnerabilities matching
+// the filter. Secrets and licenses are kept as-is.
 func (r ScanResult) FilterBySeverity(filter []string) ScanResult {
 	if len(filter) == 0 {
 		return r
@@ -253,11 +431,28 @@ func (r ScanResult) FilterBySeverity(filter []string) ScanResult {
 	return ScanResult{
 		Summary:         NewScanSummary(filtered, r.Summary.PackagesScanned),
 		Vulnerabilities: filtered,
+		Secrets:         r.Secrets,
+		SecretSummary:   r.SecretSummary,
+		Licenses:        r.Licenses,
+		LicenseSummary:  r.LicenseSummary,
 		ScannedAt:       r.ScannedAt,
 		ScanTimeMs:      r.ScanTimeMs,
 	}
 }
 
+// SortBySeverity orders vulnerabilities by CVSS score descending, breaking
+// ties by severity bucket. The sort is stable so equal entries keep their
+// original order.
+func (r *ScanResult) SortBySeverity() {
+	sort.SliceStable(r.Vulnerabilities, func(i, j int) bool {
+		a, b := r.Vulnerabilities[i], r.Vulnerabilities[j]
+		if a.CVSSScore != b.CVSSScore {
+			return a.CVSSScore > b.CVSSScore
+		}
+		return severityRank[a.Severity] > severityRank[b.Severity]
+	})
+}
+
 // JobResponse is the response for async scan job submission.
 type JobResponse struct {
 	JobID   string `json:"job_id"`
@@ -265,10 +460,22 @@ type JobResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
+// ScanProgress reports how far a package scan has advanced. Cached
+// packages count as scanned before the first batch is sent.
+type ScanProgress struct {
+	PackagesScanned int `json:"packages_scanned"`
+	PackagesTotal   int `json:"packages_total"`
+
+	// Batch is the number of batches completed so far, out of Batches.
+	Batch   int `json:"batch"`
+	Batches int `json:"batches"`
+}
+
 // JobStatusResponse is the response for job status polling.
 type JobStatusResponse struct {
 	JobID           string          `json:"job_id"`
 	Status          string          `json:"status"`
+	Progress        *ScanProgress   `json:"progress,omitempty"`
 	Summary         *ScanSummary    `json:"summary,omitempty"`
 	Vulnerabilities []Vulnerability `json:"vulnerabilities,omitempty"`
 	ScannedAt       *time.Time      `json:"scanned_at,omitempty"`
@@ -343,6 +550,62 @@ type TwirpVulnerability struct {
 	Description      string   `json:"Description,omitempty"`
 	References       []string `json:"References,omitempty"`
 	PkgType          string   `json:"PkgType,omitempty"`
+
+	// CVSS maps a vendor (nvd, ghsa, redhat, ...) to its CVSS data.
+	CVSS map[string]TwirpCVSS `json:"CVSS,omitempty"`
+}
+
+// TwirpCVSS holds CVSS vectors and scores from a single vendor.
+type TwirpCVSS struct {
+	V2Vector string  `json:"V2Vector,omitempty"`
+	V3Vector string  `json:"V3Vector,omitempty"`
+	V2Score  float64 `json:"V2Score,omitempty"`
+	V3Score  float64 `json:"V3Score,omitempty"`
+}
+
+// cvssSourcePriority lists vendors whose CVSS data is preferred, in order.
+var cvssSourcePriority = []string{"nvd", "ghsa", "redhat"}
+
+// bestCVSS picks a single score and vector from per-vendor CVSS data.
+// Preferred vendors are tried first, then the highest v3 score from any
+// vendor, then the highest v2 score.
+func bestCVSS(cvss map[string]TwirpCVSS) (float64, string) {
+	if len(cvss) == 0 {
+		return 0, ""
+	}
+
+	for _, source := range cvssSourcePriority {
+		if c, ok := cvss[source]; ok && c.V3Score > 0 {
+			return c.V3Score, c.V3Vector
+		}
+	}
+
+	// Iterate in sorted order so ties resolve deterministically
+	sources := make([]string, 0, len(cvss))
+	for source := range cvss {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	var score float64
+	var vector string
+	for _, source := range sources {
+		if c := cvss[source]; c.V3Score > score {
+			score, vector = c.V3Score, c.V3Vector
+		}
+	}
+	if score > 0 {
+		return score, vector
+	}
+
+	// Fall back to the highest CVSS v2 score
+	for _, source := range sources {
+		if c := cvss[source]; c.V2Score > score {
+			score, vector = c.V2Score, c.V2Vector
+		}
+	}
+
+	return score, vector
 }
 
 // TwirpSecret represents a secret finding in the Twirp response.
@@ -356,6 +619,30 @@ type TwirpSecret struct {
 	Match     string `json:"Match"`
 }
 
+// TwirpLicense represents a detected license in the Twirp response.
+type TwirpLicense struct {
+	Severity   string  `json:"Severity"`
+	Category   string  `json:"Category"`
+	PkgName    string  `json:"PkgName"`
+	FilePath   string  `json:"FilePath"`
+	Name       string  `json:"Name"`
+	Confidence float64 `json:"Confidence"`
+	Link       string  `json:"Link"`
+}
+
+// ToLicense converts a Twirp license to our License type.
+func (tl TwirpLicense) ToLicense() License {
+	return License{
+		Name:       tl.Name,
+		Package:    tl.PkgName,
+		FilePath:   tl.FilePath,
+		Category:   strings.ToLower(tl.Category),
+		Severity:   tl.Severity,
+		Confidence: tl.Confidence,
+		Link:       tl.Link,
+	}
+}
+
 // TwirpResult represents a result in the Twirp response.
 type TwirpResult struct {
 	Target          string               `json:"Target"`
@@ -363,6 +650,7 @@ type TwirpResult struct {
 	Type            string               `json:"Type,omitempty"`
 	Vulnerabilities []TwirpVulnerability `json:"Vulnerabilities,omitempty"`
 	Secrets         []TwirpSecret        `json:"Secrets,omitempty"`
+	Licenses        []TwirpLicense       `json:"Licenses,omitempty"`
 }
 
 // ToSecret converts a Twirp secret to our Secret type.
@@ -386,6 +674,8 @@ type TwirpScanResponse struct {
 
 // ToVulnerability converts a Twirp vulnerability to our Vulnerability type.
 func (tv TwirpVulnerability) ToVulnerability(ecosystem string) Vulnerability {
+	score, vector := bestCVSS(tv.CVSS)
+
 	return Vulnerability{
 		Package:      tv.PkgName,
 		Version:      tv.InstalledVersion,
@@ -396,5 +686,7 @@ func (tv TwirpVulnerability) ToVulnerability(ecosystem string) Vulnerability {
 		Description:  tv.Description,
 		FixedVersion: tv.FixedVersion,
 		References:   tv.References,
+		CVSSScore:    score,
+		CVSSVector:   vector,
 	}
 }
@@ -325,6 +325,42 @@ func TestScanResult_FilterBySeverity(t *testing.T) {
 	}
 }
 
+func TestNewLicenseSummary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		licenses []License
+		want     *LicenseSummary
+	}{
+		{name: "no licenses", licenses: nil, want: nil},
+		{
+			name: "mixed severities",
+			licenses: []License{
+				{Name: "AGPL-3.0", Severity: SeverityCritical},
+				{Name: "GPL-3.0", Severity: SeverityHigh},
+				{Name: "MIT", Severity: SeverityLow},
+				{Name: "Custom", Severity: SeverityUnknown},
+			},
+			want: &LicenseSummary{TotalLicenses: 4, Critical: 1, High: 1, Low: 1, Unknown: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := NewLicenseSummary(tt.licenses)
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("NewLicenseSummary() = %+v, want %+v", got, tt.want)
+			}
+			if got != nil && *got != *tt.want {
+				t.Errorf("NewLicenseSummary() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
 func TestPackage_JSONSerialization(t *testing.T) {
 	t.Parallel()
 
@@ -359,6 +395,66 @@ func TestPackage_JSONSerialization(t *testing.T) {
 	}
 }
 
+func TestPackage_RawVersionJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		pkg     Package
+		wantKey bool
+	}{
+		{
+			name:    "cleaned version",
+			pkg:     Package{Name: "lodash", Version: "4.17.21", Ecosystem: EcosystemNpm, RawVersion: "^4.17.21"},
+			wantKey: true,
+		},
+		{
+			name:    "exact version",
+			pkg:     Package{Name: "lodash", Version: "4.17.21", Ecosystem: EcosystemNpm},
+			wantKey: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := json.Marshal(tt.pkg)
+			if err != nil {
+				t.Fatalf("failed to marshal package: %v", err)
+			}
+
+			var fields map[string]any
+			if err := json.Unmarshal(data, &fields); err != nil {
+				t.Fatalf("failed to unmarshal fields: %v", err)
+			}
+			if _, ok := fields["raw_version"]; ok != tt.wantKey {
+				t.Errorf("raw_version present = %v, want %v in %s", ok, tt.wantKey, data)
+			}
+
+			var decoded Package
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("failed to unmarshal package: %v", err)
+			}
+			if decoded != tt.pkg {
+				t.Errorf("round trip = %+v, want %+v", decoded, tt.pkg)
+			}
+		})
+	}
+}
+
+func TestPackage_CacheKeyIgnoresRawVersion(t *testing.T) {
+	t.Parallel()
+
+	resolved := Package{Name: "lodash", Version: "4.17.21", Ecosystem: EcosystemNpm}
+	declared := resolved
+	declared.RawVersion = "^4.17.21"
+
+	if resolved.CacheKey() != declared.CacheKey() {
+		t.Errorf("CacheKey() = %q, want %q", declared.CacheKey(), resolved.CacheKey())
+	}
+}
+
 func TestVulnerability_JSONSerialization(t *testing.T) {
 	t.Parallel()
 
@@ -395,6 +491,124 @@ func TestVulnerability_JSONSerialization(t *testing.T) {
 	}
 }
 
+func TestVulnerability_CVSSJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	vuln := Vulnerability{
+		Package:    "lodash",
+		Version:    "4.17.20",
+		CVEID:      "CVE-2021-23337",
+		Severity:   SeverityHigh,
+		CVSSScore:  7.2,
+		CVSSVector: "CVSS:3.1/AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H",
+	}
+
+	data, err := json.Marshal(vuln)
+	if err != nil {
+		t.Fatalf("failed to marshal vulnerability: %v", err)
+	}
+
+	var raw map[string]any
+	json.Unmarshal(data, &raw)
+	if raw["cvss_score"] != 7.2 {
+		t.Errorf("cvss_score = %v, want 7.2", raw["cvss_score"])
+	}
+
+	var decoded Vulnerability
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal vulnerability: %v", err)
+	}
+	if decoded.CVSSScore != vuln.CVSSScore || decoded.CVSSVector != vuln.CVSSVector {
+		t.Errorf("CVSS mismatch: got %v %q", decoded.CVSSScore, decoded.CVSSVector)
+	}
+
+	// Zero scores are omitted
+	data, _ = json.Marshal(Vulnerability{CVEID: "CVE-1"})
+	raw = nil
+	json.Unmarshal(data, &raw)
+	if _, ok := raw["cvss_score"]; ok {
+		t.Error("expected cvss_score to be omitted when zero")
+	}
+}
+
+func TestTwirpVulnerability_ToVulnerability_CVSS(t *testing.T) {
+	t.Parallel()
+
+	data := `{
+		"VulnerabilityID": "CVE-2021-23337",
+		"PkgName": "lodash",
+		"InstalledVersion": "4.17.20",
+		"Severity": "HIGH",
+		"CVSS": {
+			"ghsa": {"V3Vector": "CVSS:3.1/AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H", "V3Score": 7.2},
+			"nvd": {"V2Vector": "AV:N/AC:L/Au:S/C:P/I:P/A:P", "V2Score": 6.5, "V3Vector": "CVSS:3.1/AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H", "V3Score": 7.2}
+		}
+	}`
+
+	var tv TwirpVulnerability
+	if err := json.Unmarshal([]byte(data), &tv); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	v := tv.ToVulnerability(EcosystemNpm)
+	if v.CVSSScore != 7.2 {
+		t.Errorf("CVSSScore = %v, want 7.2", v.CVSSScore)
+	}
+	if v.CVSSVector == "" {
+		t.Error("expected CVSSVector to be populated")
+	}
+}
+
+func TestBestCVSS(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		cvss  map[string]TwirpCVSS
+		score float64
+	}{
+		{"empty", nil, 0},
+		{"prefers nvd", map[string]TwirpCVSS{"nvd": {V3Score: 5.0}, "redhat": {V3Score: 9.0}}, 5.0},
+		{"highest other vendor", map[string]TwirpCVSS{"vendor-a": {V3Score: 4.0}, "vendor-b": {V3Score: 6.5}}, 6.5},
+		{"v2 fallback", map[string]TwirpCVSS{"nvd": {V2Score: 4.3}}, 4.3},
+	}
+
+	for _, tt := range tests {
+		if score, _ := bestCVSS(tt.cvss); score != tt.score {
+			t.Errorf("%s: bestCVSS() = %v, want %v", tt.name, score, tt.score)
+		}
+	}
+}
+
+func TestScanResult_SortBySeverity(t *testing.T) {
+	t.Parallel()
+
+	result := ScanResult{
+		Vulnerabilities: []Vulnerability{
+			{CVEID: "low-scored", Severity: SeverityLow, CVSSScore: 3.1},
+			{CVEID: "unscored-critical", Severity: SeverityCritical},
+			{CVEID: "high-a", Severity: SeverityHigh, CVSSScore: 7.5},
+			{CVEID: "critical", Severity: SeverityCritical, CVSSScore: 9.8},
+			{CVEID: "high-b", Severity: SeverityHigh, CVSSScore: 7.5},
+			{CVEID: "medium-tie", Severity: SeverityMedium, CVSSScore: 7.5},
+			{CVEID: "unscored-medium", Severity: SeverityMedium},
+		},
+	}
+
+	result.SortBySeverity()
+
+	want := []string{"critical", "high-a", "high-b", "medium-tie", "low-scored", "unscored-critical", "unscored-medium"}
+	for i, id := range want {
+		if result.Vulnerabilities[i].CVEID != id {
+			got := make([]string, len(result.Vulnerabilities))
+			for j, v := range result.Vulnerabilities {
+				got[j] = v.CVEID
+			}
+			t.Fatalf("order = %v, want %v", got, want)
+		}
+	}
+}
+
 func TestScanResult_JSONSerialization(t *testing.T) {
 	t.Parallel()
 
@@ -525,3 +739,49 @@ func TestScanSummary_Recalculate(t *testing.T) {
 		t.Errorf("packages scanned mismatch: got %d, want 10", summary.PackagesScanned)
 	}
 }
+
+func TestDedupeVulnerabilities(t *testing.T) {
+	t.Parallel()
+
+	vulns := []Vulnerability{
+		{CVEID: "CVE-1", Package: "requests", Version: "2.25.0", Severity: SeverityHigh},
+		{CVEID: "CVE-2", Package: "lodash", Version: "4.17.20", Severity: SeverityLow},
+		{
+			CVEID: "CVE-1", Package: "requests", Version: "2.25.0", Severity: SeverityHigh,
+			Title: "Proxy-Auth header leak", FixedVersion: "2.31.0",
+		},
+		{
+			CVEID: "CVE-1", Package: "requests", Version: "2.25.0", Severity: SeverityUnknown,
+			References: []string{"https://nvd.nist.gov/vuln/detail/CVE-1"},
+		},
+		// Same CVE in a different version is a distinct finding.
+		{CVEID: "CVE-1", Package: "requests", Version: "2.26.0", Severity: SeverityHigh},
+	}
+
+	got := DedupeVulnerabilities(vulns)
+
+	if len(got) != 3 {
+		t.Fatalf("expected 3 vulnerabilities, got %d: %+v", len(got), got)
+	}
+
+	merged := got[0]
+	if merged.CVEID != "CVE-1" || merged.Version != "2.25.0" {
+		t.Fatalf("first vulnerability = %s %s, want CVE-1 2.25.0", merged.CVEID, merged.Version)
+	}
+	if merged.FixedVersion != "2.31.0" || merged.Title != "Proxy-Auth header leak" {
+		t.Errorf("merged lost detail: %+v", merged)
+	}
+	if merged.Severity != SeverityHigh {
+		t.Errorf("merged severity = %q, want %q", merged.Severity, SeverityHigh)
+	}
+	if len(merged.References) != 1 {
+		t.Errorf("merged references = %v, want the duplicate's reference", merged.References)
+	}
+	if got[1].CVEID != "CVE-2" || got[2].Version != "2.26.0" {
+		t.Errorf("order not preserved: %+v", got)
+	}
+
+	if summary := NewScanSummary(got, 2); summary.TotalVulnerabilities != 3 || summary.High != 2 {
+		t.Errorf("summary = %+v, want 3 total, 2 high", summary)
+	}
+}
@@ -66,20 +66,65 @@ func (s *UnifiedScanner) Ping(ctx context.Context) error {
 
 // ScanPath scans a path (directory or archive) for vulnerabilities and secrets.
 // This is the main entry point for combined scanning.
+// Vulnerabilities listed in opts.IgnoreFile, or in a .trivyignore at the
+// scan root, are removed from the result.
 func (s *UnifiedScanner) ScanPath(ctx context.Context, path string, opts ScanOptions) (*ScanResult, error) {
+	ignore, err := resolveIgnoreList(path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *ScanResult
 	switch s.Mode() {
 	case "server":
-		return s.scanPathWithServer(ctx, path, opts)
+		result, err = s.scanPathWithServer(ctx, path, opts)
 	default:
-		return s.localScanner.ScanPath(ctx, path, opts)
+		result, err = s.localScanner.ScanPath(ctx, path, opts)
+	}
+	if err != nil {
+		return nil, err
 	}
+
+	ignore.Apply(result)
+
+	return result, nil
+}
+
+// ScanTarget scans a path, container image, or git repository (see
+// LocalScanner.ScanTarget). Images and repositories are only supported in
+// local mode, and only an explicit opts.IgnoreFile applies to them.
+func (s *UnifiedScanner) ScanTarget(ctx context.Context, target, targetType string, opts ScanOptions) (*ScanResult, error) {
+	targetType, target, err := ResolveTarget(target, targetType)
+	if err != nil {
+		return nil, err
+	}
+	if targetType == TargetTypeFS {
+		return s.ScanPath(ctx, target, opts)
+	}
+	if s.Mode() == "server" {
+		return nil, fmt.Errorf("%s targets are only supported in local mode", targetType)
+	}
+
+	ignore, err := resolveIgnoreList("", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.localScanner.ScanTarget(ctx, target, targetType, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ignore.Apply(result)
+
+	return result, nil
 }
 
 // scanPathWithServer scans a path using the Trivy server mode.
 // Extracts packages from manifests and sends to server for vulnerability lookup.
 func (s *UnifiedScanner) scanPathWithServer(ctx context.Context, path string, opts ScanOptions) (*ScanResult, error) {
 	// Extract packages from manifests.
-	packages, err := ScanPathForPackages(path)
+	packages, err := ScanPathForPackagesWithOptions(path, opts.ManifestOptions())
 	if err != nil {
 		return nil, fmt.Errorf("extracting packages from manifests: %w", err)
 	}
@@ -109,7 +154,19 @@ func (s *UnifiedScanner) ScanPackagesWithOptions(ctx context.Context, packages [
 	if s.Mode() != "server" {
 		return nil, fmt.Errorf("ScanPackagesWithOptions is only available in server mode; use ScanPath for local mode")
 	}
-	return s.serverScanner.ScanPackagesWithOptions(ctx, packages, opts)
+	ignore, err := resolveIgnoreList("", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.serverScanner.ScanPackagesWithOptions(ctx, packages, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ignore.Apply(result)
+
+	return result, nil
 }
 
 // DefaultScanOptions returns scan options with HIGH/CRITICAL severity filter and secret scanning enabled.
@@ -58,6 +58,9 @@ type Job struct {
 	FileName string `json:"file_name"`
 	FileSize int64  `json:"file_size"`
 
+	// ContentType is the MIME type detected from the file's leading bytes.
+	ContentType string `json:"content_type,omitempty"`
+
 	// Scan result (set when completed).
 	Result *ScanResult `json:"result,omitempty"`
 
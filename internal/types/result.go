@@ -35,6 +35,17 @@ func (s Status) String() string {
 	}
 }
 
+// ParseStatus parses a status name as returned by String. Unrecognised
+// names are reported as StatusUnknown with ok false.
+func ParseStatus(name string) (status Status, ok bool) {
+	for s := StatusUnknown; s <= StatusError; s++ {
+		if s.String() == name {
+			return s, true
+		}
+	}
+	return StatusUnknown, false
+}
+
 // IsMalicious returns true if the status indicates a malicious file.
 func (s Status) IsMalicious() bool {
 	return s == StatusMalware
@@ -35,6 +35,32 @@ func TestStatus_String(t *testing.T) {
 	}
 }
 
+func TestParseStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input  string
+		want   types.Status
+		wantOK bool
+	}{
+		{input: "unknown", want: types.StatusUnknown, wantOK: true},
+		{input: "clean", want: types.StatusClean, wantOK: true},
+		{input: "malware", want: types.StatusMalware, wantOK: true},
+		{input: "error", want: types.StatusError, wantOK: true},
+		{input: "infected", want: types.StatusUnknown, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			got, ok := types.ParseStatus(tt.input)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseStatus(%q) = %v, %v; want %v, %v", tt.input, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
 func TestStatus_IsMalicious(t *testing.T) {
 	t.Parallel()
 
@@ -61,6 +61,7 @@ type ScanResult struct {
 	// Metadata.
 	ScanTimeMs float64   `json:"scan_time_ms,omitempty"`
 	ScannedAt  time.Time `json:"scanned_at"`
+	Cached     bool      `json:"cached,omitempty"` // Served from a scan cache
 
 	// Error information.
 	Error string `json:"error,omitempty"`
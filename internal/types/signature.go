@@ -5,6 +5,8 @@ package types
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -60,6 +62,18 @@ func (tt ThreatType) String() string {
 	}
 }
 
+// ParseThreatType parses a threat type name as returned by String
+// ("malware", "trojan", ...) case-insensitively.
+func ParseThreatType(s string) (ThreatType, bool) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for tt := ThreatTypeMalware; tt <= ThreatTypeTestFile; tt++ {
+		if tt.String() == name {
+			return tt, true
+		}
+	}
+	return ThreatTypeUnknown, false
+}
+
 // Severity represents the severity level of a threat.
 type Severity int
 
@@ -92,6 +106,23 @@ func (s Severity) String() string {
 	}
 }
 
+// ParseSeverity parses a severity name ("low", "medium", "high",
+// "critical") case-insensitively.
+func ParseSeverity(s string) (Severity, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "low":
+		return SeverityLow, true
+	case "medium":
+		return SeverityMedium, true
+	case "high":
+		return SeverityHigh, true
+	case "critical":
+		return SeverityCritical, true
+	default:
+		return SeverityUnknown, false
+	}
+}
+
 // Signature represents a malware signature with associated metadata.
 type Signature struct {
 	// Primary hash (always SHA256).
@@ -106,15 +137,25 @@ type Signature struct {
 	ThreatType    ThreatType `json:"threat_type"`
 	Severity      Severity   `json:"severity"`
 
-	// Metadata.
+	// Metadata. Source is a comma-separated list once signatures from
+	// several feeds have been merged; use Sources to read it.
 	Source    string    `json:"source"`
 	FirstSeen time.Time `json:"first_seen"`
 	LastSeen  time.Time `json:"last_seen,omitempty"`
 
+	// ExpiresAt is when the signature stops matching, for feed data that
+	// goes stale or may be retracted. Nil means it never expires.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
 	// Optional extended information.
 	Description string   `json:"description,omitempty"`
 	References  []string `json:"references,omitempty"`
 	Tags        []string `json:"tags,omitempty"`
+
+	// Contributions holds the per-source signatures a merged signature was
+	// built from, so dropping a source can recompute it from the others.
+	// Empty for signatures that were never merged.
+	Contributions []*Signature `json:"contributions,omitempty"`
 }
 
 // NewSignature creates a new Signature with the required fields.
@@ -164,18 +205,171 @@ func (s *Signature) WithDescription(desc string) *Signature {
 	return s
 }
 
-// GetHashes returns all available hashes for this signature.
+// WithTTL sets the signature to expire ttl after FirstSeen and returns the
+// signature for chaining.
+func (s *Signature) WithTTL(ttl time.Duration) *Signature {
+	expiresAt := s.FirstSeen.Add(ttl)
+	s.ExpiresAt = &expiresAt
+	return s
+}
+
+// IsExpired reports whether the signature has expired as of now.
+func (s *Signature) IsExpired(now time.Time) bool {
+	return s.ExpiresAt != nil && !now.Before(*s.ExpiresAt)
+}
+
+// Sources returns the feeds the signature came from.
+func (s *Signature) Sources() []string {
+	return splitList(s.Source, ",")
+}
+
+// HasSource reports whether source is one of the signature's sources.
+func (s *Signature) HasSource(source string) bool {
+	return slices.Contains(s.Sources(), source)
+}
+
+// Merge combines s with another signature for the same file and returns
+// the result; neither input is modified. Sources, detection names,
+// references, and tags are unioned, the highest severity wins, missing
+// hashes and details are filled from other, and the seen window covers
+// both. The merged signature only expires when both inputs do. The inputs
+// are kept as its Contributions, with other replacing any earlier
+// contribution from the same source.
+func (s *Signature) Merge(other *Signature) *Signature {
+	merged := *s
+	merged.References = slices.Clone(s.References)
+	merged.Tags = slices.Clone(s.Tags)
+	merged.Contributions = slices.Clone(s.Contributions)
+	if s.ExpiresAt != nil {
+		expiresAt := *s.ExpiresAt
+		merged.ExpiresAt = &expiresAt
+	}
+	if other == nil {
+		return &merged
+	}
+
+	if merged.SHA256 == "" {
+		merged.SHA256 = other.SHA256
+	}
+	if merged.SHA1 == "" {
+		merged.SHA1 = other.SHA1
+	}
+	if merged.MD5 == "" {
+		merged.MD5 = other.MD5
+	}
+
+	merged.Source = strings.Join(unionList(s.Sources(), other.Sources()), ",")
+	merged.DetectionName = strings.Join(
+		unionList(splitList(s.DetectionName, ", "), splitList(other.DetectionName, ", ")), ", ")
+
+	if other.Severity > merged.Severity {
+		merged.Severity = other.Severity
+	}
+	if merged.ThreatType == ThreatTypeUnknown {
+		merged.ThreatType = other.ThreatType
+	}
+	if merged.Description == "" {
+		merged.Description = other.Description
+	}
+
+	if !other.FirstSeen.IsZero() && (merged.FirstSeen.IsZero() || other.FirstSeen.Before(merged.FirstSeen)) {
+		merged.FirstSeen = other.FirstSeen
+	}
+	if other.LastSeen.After(merged.LastSeen) {
+		merged.LastSeen = other.LastSeen
+	}
+	switch {
+	case merged.ExpiresAt == nil || other.ExpiresAt == nil:
+		merged.ExpiresAt = nil
+	case other.ExpiresAt.After(*merged.ExpiresAt):
+		expiresAt := *other.ExpiresAt
+		merged.ExpiresAt = &expiresAt
+	}
+
+	merged.References = unionList(merged.References, other.References)
+	merged.Tags = unionList(merged.Tags, other.Tags)
+
+	contributions := s.contributions()
+	for _, c := range other.contributions() {
+		contributions = slices.DeleteFunc(contributions, func(prev *Signature) bool { return prev.Source == c.Source })
+		contributions = append(contributions, c)
+	}
+	merged.Contributions = contributions
+
+	return &merged
+}
+
+// WithoutSource returns s recomputed from the contributions of its other
+// sources, or nil if source was its only source. A merged signature
+// stored without contributions just has source dropped from its list.
+func (s *Signature) WithoutSource(source string) *Signature {
+	var remaining []*Signature
+	for _, c := range s.contributions() {
+		sources := slices.DeleteFunc(c.Sources(), func(name string) bool { return name == source })
+		if len(sources) == 0 {
+			continue
+		}
+		kept := *c
+		kept.Source = strings.Join(sources, ",")
+		remaining = append(remaining, &kept)
+	}
+	if len(remaining) == 0 {
+		return nil
+	}
+
+	record := remaining[0]
+	for _, c := range remaining[1:] {
+		record = record.Merge(c)
+	}
+	return record
+}
+
+// contributions returns the per-source signatures s was built from: its
+// Contributions if it was merged, otherwise s itself.
+func (s *Signature) contributions() []*Signature {
+	if len(s.Contributions) > 0 {
+		return slices.Clone(s.Contributions)
+	}
+	self := *s
+	return []*Signature{&self}
+}
+
+// splitList splits a sep-separated list, dropping empty entries.
+func splitList(s, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(s, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// unionList returns the distinct items of a followed by those of b, in
+// order of first appearance.
+func unionList(a, b []string) []string {
+	var result []string
+	for _, item := range slices.Concat(a, b) {
+		if !slices.Contains(result, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// GetHashes returns all available hashes for this signature, normalized to
+// lowercase like ParseHash.
 func (s *Signature) GetHashes() []Hash {
 	hashes := make([]Hash, 0, 3)
 
 	if s.SHA256 != "" {
-		hashes = append(hashes, Hash{Type: HashTypeSHA256, Value: s.SHA256})
+		hashes = append(hashes, Hash{Type: HashTypeSHA256, Value: strings.ToLower(s.SHA256)})
 	}
 	if s.SHA1 != "" {
-		hashes = append(hashes, Hash{Type: HashTypeSHA1, Value: s.SHA1})
+		hashes = append(hashes, Hash{Type: HashTypeSHA1, Value: strings.ToLower(s.SHA1)})
 	}
 	if s.MD5 != "" {
-		hashes = append(hashes, Hash{Type: HashTypeMD5, Value: s.MD5})
+		hashes = append(hashes, Hash{Type: HashTypeMD5, Value: strings.ToLower(s.MD5)})
 	}
 
 	return hashes
@@ -123,6 +123,182 @@ func TestSignature_WithThreatInfo(t *testing.T) {
 	}
 }
 
+func TestSignature_IsExpired(t *testing.T) {
+	t.Parallel()
+
+	firstSeen := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		now  time.Time
+		want bool
+	}{
+		{name: "no expiry", now: firstSeen.AddDate(10, 0, 0), want: false},
+		{name: "before expiry", ttl: 90 * 24 * time.Hour, now: firstSeen.AddDate(0, 0, 89), want: false},
+		{name: "at expiry", ttl: 90 * 24 * time.Hour, now: firstSeen.AddDate(0, 0, 90), want: true},
+		{name: "after expiry", ttl: 90 * 24 * time.Hour, now: firstSeen.AddDate(0, 0, 91), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sig := &types.Signature{SHA256: "abc", FirstSeen: firstSeen}
+			if tt.ttl > 0 {
+				sig = sig.WithTTL(tt.ttl)
+			}
+
+			if got := sig.IsExpired(tt.now); got != tt.want {
+				t.Errorf("IsExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignature_Merge(t *testing.T) {
+	t.Parallel()
+
+	early := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := early.AddDate(0, 1, 0)
+
+	a := &types.Signature{
+		SHA256:        "275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f",
+		DetectionName: "Win.Trojan.Agent",
+		Severity:      types.SeverityMedium,
+		Source:        "clamav",
+		FirstSeen:     late,
+		Tags:          []string{"exe"},
+	}
+	b := (&types.Signature{
+		SHA256:        "275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f",
+		MD5:           "44d88612fea8a8f36de82e1278abb02f",
+		DetectionName: "AgentTesla",
+		ThreatType:    types.ThreatTypeSpyware,
+		Severity:      types.SeverityHigh,
+		Source:        "malwarebazaar",
+		FirstSeen:     early,
+		Tags:          []string{"exe", "stealer"},
+	}).WithTTL(time.Hour)
+
+	merged := a.Merge(b)
+
+	if merged.Source != "clamav,malwarebazaar" {
+		t.Errorf("Source = %q, want clamav,malwarebazaar", merged.Source)
+	}
+	if merged.DetectionName != "Win.Trojan.Agent, AgentTesla" {
+		t.Errorf("DetectionName = %q", merged.DetectionName)
+	}
+	if merged.Severity != types.SeverityHigh {
+		t.Errorf("Severity = %v, want high", merged.Severity)
+	}
+	if merged.ThreatType != types.ThreatTypeSpyware {
+		t.Errorf("ThreatType = %v, want spyware", merged.ThreatType)
+	}
+	if merged.MD5 != b.MD5 {
+		t.Errorf("MD5 = %q, want %q", merged.MD5, b.MD5)
+	}
+	if !merged.FirstSeen.Equal(early) {
+		t.Errorf("FirstSeen = %v, want %v", merged.FirstSeen, early)
+	}
+	if merged.ExpiresAt != nil {
+		t.Errorf("ExpiresAt = %v, want nil since a never expires", merged.ExpiresAt)
+	}
+	if len(merged.Tags) != 2 {
+		t.Errorf("Tags = %v, want [exe stealer]", merged.Tags)
+	}
+
+	// Merging again is idempotent and the inputs are untouched.
+	if again := merged.Merge(b); again.Source != merged.Source || again.DetectionName != merged.DetectionName {
+		t.Errorf("re-merge = %q/%q, want %q/%q", again.Source, again.DetectionName, merged.Source, merged.DetectionName)
+	}
+	if a.Source != "clamav" || len(a.Tags) != 1 || a.MD5 != "" {
+		t.Errorf("Merge modified its receiver: %+v", a)
+	}
+	if !merged.HasSource("malwarebazaar") || merged.HasSource("threatfox") {
+		t.Errorf("HasSource() mismatch for %q", merged.Source)
+	}
+}
+
+func TestSignature_WithoutSource(t *testing.T) {
+	t.Parallel()
+
+	const sha256 = "275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f"
+	clamav := &types.Signature{SHA256: sha256, DetectionName: "Win.Trojan.Agent", Severity: types.SeverityMedium, Source: "clamav"}
+	bazaar := &types.Signature{SHA256: sha256, MD5: "44d88612fea8a8f36de82e1278abb02f", DetectionName: "AgentTesla", Severity: types.SeverityHigh, Source: "malwarebazaar"}
+	threatfox := &types.Signature{SHA256: sha256, DetectionName: "Agent.Generic", Severity: types.SeverityLow, Source: "threatfox"}
+	merged := clamav.Merge(bazaar).Merge(threatfox)
+
+	tests := []struct {
+		name          string
+		sig           *types.Signature
+		source        string
+		wantNil       bool
+		wantSource    string
+		wantDetection string
+		wantSeverity  types.Severity
+		wantMD5       string
+	}{
+		{
+			name:          "merged",
+			sig:           merged,
+			source:        "malwarebazaar",
+			wantSource:    "clamav,threatfox",
+			wantDetection: "Win.Trojan.Agent, Agent.Generic",
+			wantSeverity:  types.SeverityMedium,
+		},
+		{
+			name:          "one source left",
+			sig:           clamav.Merge(bazaar),
+			source:        "clamav",
+			wantSource:    "malwarebazaar",
+			wantDetection: "AgentTesla",
+			wantSeverity:  types.SeverityHigh,
+			wantMD5:       bazaar.MD5,
+		},
+		{
+			name:    "only source",
+			sig:     clamav,
+			source:  "clamav",
+			wantNil: true,
+		},
+		{
+			name:          "merged without contributions",
+			sig:           &types.Signature{SHA256: sha256, DetectionName: "A, B", Severity: types.SeverityHigh, Source: "clamav,malwarebazaar"},
+			source:        "malwarebazaar",
+			wantSource:    "clamav",
+			wantDetection: "A, B",
+			wantSeverity:  types.SeverityHigh,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.sig.WithoutSource(tt.source)
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("WithoutSource(%q) = %+v, want nil", tt.source, got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("WithoutSource(%q) = nil", tt.source)
+			}
+			if got.Source != tt.wantSource || got.DetectionName != tt.wantDetection {
+				t.Errorf("WithoutSource(%q) = %q/%q, want %q/%q", tt.source, got.Source, got.DetectionName, tt.wantSource, tt.wantDetection)
+			}
+			if got.Severity != tt.wantSeverity {
+				t.Errorf("Severity = %v, want %v", got.Severity, tt.wantSeverity)
+			}
+			if got.MD5 != tt.wantMD5 {
+				t.Errorf("MD5 = %q, want %q", got.MD5, tt.wantMD5)
+			}
+		})
+	}
+}
+
 func TestSignature_JSON(t *testing.T) {
 	t.Parallel()
 
@@ -172,6 +348,23 @@ func TestSignature_JSON(t *testing.T) {
 	if decoded.Source != sig.Source {
 		t.Errorf("Source = %v, want %v", decoded.Source, sig.Source)
 	}
+	if decoded.ExpiresAt != nil {
+		t.Errorf("ExpiresAt = %v, want nil", decoded.ExpiresAt)
+	}
+
+	// Expiry survives a round trip.
+	expiring := sig.WithTTL(time.Hour)
+	data, err = json.Marshal(expiring)
+	if err != nil {
+		t.Fatalf("json.Marshal() error: %v", err)
+	}
+	decoded = types.Signature{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error: %v", err)
+	}
+	if decoded.ExpiresAt == nil || !decoded.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("ExpiresAt = %v, want %v", decoded.ExpiresAt, now.Add(time.Hour))
+	}
 }
 
 func TestThreatType_String(t *testing.T) {
@@ -227,3 +420,57 @@ func TestSeverity_String(t *testing.T) {
 		})
 	}
 }
+
+func TestParseThreatType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input  string
+		want   types.ThreatType
+		wantOK bool
+	}{
+		{input: "malware", want: types.ThreatTypeMalware, wantOK: true},
+		{input: "Trojan", want: types.ThreatTypeTrojan, wantOK: true},
+		{input: " RANSOMWARE ", want: types.ThreatTypeRansomware, wantOK: true},
+		{input: "testfile", want: types.ThreatTypeTestFile, wantOK: true},
+		{input: "unknown", want: types.ThreatTypeUnknown, wantOK: false},
+		{input: "", want: types.ThreatTypeUnknown, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			got, ok := types.ParseThreatType(tt.input)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseThreatType(%q) = %v, %v; want %v, %v", tt.input, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input  string
+		want   types.Severity
+		wantOK bool
+	}{
+		{input: "low", want: types.SeverityLow, wantOK: true},
+		{input: "Medium", want: types.SeverityMedium, wantOK: true},
+		{input: " HIGH ", want: types.SeverityHigh, wantOK: true},
+		{input: "critical", want: types.SeverityCritical, wantOK: true},
+		{input: "unknown", want: types.SeverityUnknown, wantOK: false},
+		{input: "", want: types.SeverityUnknown, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			got, ok := types.ParseSeverity(tt.input)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseSeverity(%q) = %v, %v; want %v, %v", tt.input, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}